@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"math"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -299,6 +300,35 @@ func (a *Account) VoteByNullifier(nullifier []byte) (*api.Vote, error) {
 	}
 }
 
+// ElectionVoteIDs lists the nullifiers (voteIDs) of every vote cast on the on-chain election
+// with the given id, each voter counted once however many times they overwrote their vote. The
+// node pages the list by a fixed number of votes and answers a page past the end with an empty
+// one, so pages are read until the total it reports is reached or a page comes back empty.
+// There is no apiclient wrapper for it, hence the raw request.
+func (a *Account) ElectionVoteIDs(processID []byte) ([][]byte, error) {
+	var ids [][]byte
+	for page := 0; ; page++ {
+		resp, code, err := a.client.Request(apiclient.HTTPGET, nil,
+			"elections", hex.EncodeToString(processID), "votes", "page", strconv.Itoa(page))
+		if err != nil {
+			return nil, fmt.Errorf("could not list votes of election %x: %w", processID, err)
+		}
+		if code != http.StatusOK {
+			return nil, fmt.Errorf("could not list votes of election %x: status %d (%s)", processID, code, resp)
+		}
+		list := &api.VotesList{}
+		if err := json.Unmarshal(resp, list); err != nil {
+			return nil, fmt.Errorf("could not decode votes of election %x: %w", processID, err)
+		}
+		for _, v := range list.Votes {
+			ids = append(ids, v.VoteID)
+		}
+		if len(list.Votes) == 0 || list.Pagination == nil || uint64(len(ids)) >= list.Pagination.TotalItems {
+			return ids, nil
+		}
+	}
+}
+
 // ElectionEncryptionKeys fetches the encryption public keys of the on-chain election with
 // the given id. Only encrypted (secretUntilTheEnd) elections publish keys, and only after
 // the keykeepers have done so, so the returned slice may be empty for a freshly created
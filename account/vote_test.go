@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strconv"
 	"strings"
 	"testing"
 
@@ -62,3 +63,47 @@ func TestVoteByNullifier(t *testing.T) {
 	c.Assert(err, qt.Not(qt.ErrorIs), ErrVoteNotFound, qt.Commentf("a node failure must not read as not-found"))
 	c.Assert(err, qt.ErrorMatches, fmt.Sprintf("(?s)could not fetch vote %x: status 500.*", broken))
 }
+
+// TestElectionVoteIDs pages through the votes of an election the way the node serves them:
+// a fixed number per page and, past the last one, an empty page rather than an error.
+func TestElectionVoteIDs(t *testing.T) {
+	c := qt.New(t)
+
+	election := []byte(strings.Repeat("e", 32))
+	var votes []*api.Vote
+	for i := range 12 {
+		votes = append(votes, &api.Vote{VoteID: []byte(strings.Repeat(string(rune('a'+i)), 32))})
+	}
+	prefix := "/elections/" + hex.EncodeToString(election) + "/votes/page/"
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, prefix) {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		page, err := strconv.Atoi(strings.TrimPrefix(r.URL.Path, prefix))
+		if err != nil {
+			http.Error(w, "bad page", http.StatusBadRequest)
+			return
+		}
+		list := &api.VotesList{Votes: []*api.Vote{}, Pagination: &api.Pagination{TotalItems: uint64(len(votes))}}
+		list.Votes = append(list.Votes, votes[min(page*10, len(votes)):min(page*10+10, len(votes))]...)
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(list); err != nil {
+			t.Errorf("encoding votes: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	client, err := apiclient.New(srv.URL + "/")
+	c.Assert(err, qt.IsNil)
+	a := &Account{client: client}
+
+	ids, err := a.ElectionVoteIDs(election)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ids, qt.HasLen, len(votes))
+	c.Assert(ids[11], qt.DeepEquals, []byte(votes[11].VoteID))
+
+	_, err = a.ElectionVoteIDs([]byte(strings.Repeat("u", 32)))
+	c.Assert(err, qt.ErrorMatches, "(?s)could not list votes of election .*: status 404.*")
+}
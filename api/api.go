@@ -83,6 +83,7 @@ import (
 	"github.com/vocdoni/saas-backend/csp/handlers"
 	"github.com/vocdoni/saas-backend/db"
 	"github.com/vocdoni/saas-backend/internal"
+	"github.com/vocdoni/saas-backend/liveresults"
 	"github.com/vocdoni/saas-backend/notifications"
 	"github.com/vocdoni/saas-backend/objectstorage"
 	"github.com/vocdoni/saas-backend/subscriptions"
@@ -133,6 +134,12 @@ type Config struct {
 	// StatusSyncer enqueues question status reconciliations with the background
 	// syncer. Nil only when left unwired (e.g. tests) — enqueues become no-ops.
 	StatusSyncer StatusEnqueuer
+	// PublishScheduleInterval is the pause between two passes of the publish scheduler, which fires
+	// the scheduled publishes of voting processes. Zero uses defaultPublishScheduleInterval.
+	PublishScheduleInterval time.Duration
+	// LiveResults runs the shared pollers of the live process streams. Nil builds one with the
+	// default tuning.
+	LiveResults *liveresults.Hub
 }
 
 // StatusEnqueuer hands question status reconciliations to the background syncer. It is satisfied by
@@ -175,6 +182,10 @@ type API struct {
 	otpCooldown     time.Duration
 	notifySync      bool
 	statusSyncer    StatusEnqueuer
+	// publishScheduleInterval is the pause between two passes of the publish scheduler
+	publishScheduleInterval time.Duration
+	// liveResults runs the shared pollers of the live process streams
+	liveResults *liveresults.Hub
 }
 
 // enqueueConfirm asks the status syncer to confirm a status change landed on-chain; a no-op when
@@ -232,6 +243,11 @@ func New(ctx context.Context, conf *Config) *API {
 		otpCooldown = notifications.DefaultOTPCooldown
 	}
 
+	publishScheduleInterval := conf.PublishScheduleInterval
+	if publishScheduleInterval <= 0 {
+		publishScheduleInterval = defaultPublishScheduleInterval
+	}
+
 	var notifyQueue *notifications.Queue
 	if conf.MailService != nil || conf.SMSService != nil {
 		notifyQueue = notifications.NewQueue(ctx, notifications.QueueConfig{
@@ -264,6 +280,12 @@ func New(ctx context.Context, conf *Config) *API {
 		otpCooldown:     otpCooldown,
 		notifySync:      conf.NotificationsSyncDelivery,
 		statusSyncer:    conf.StatusSyncer,
+
+		publishScheduleInterval: publishScheduleInterval,
+		liveResults:             conf.LiveResults,
+	}
+	if a.liveResults == nil {
+		a.liveResults = liveresults.New(ctx, nil)
 	}
 	a.startTxQueue()
 	// clear any publishing markers stranded by a previous crash/restart so those processes are
@@ -295,6 +317,8 @@ func (a *API) Start() {
 			}
 		}()
 	}
+	// fire the scheduled publishes once the notification queue can deliver their failure emails
+	a.startPublishScheduler()
 	go func() {
 		if err := http.ListenAndServe(fmt.Sprintf("%s:%d", a.host, a.port), a.initRouter()); err != nil {
 			log.Fatalf("failed to start the API server: %v", err) //revive:disable:deep-exit
@@ -310,21 +334,23 @@ func (a *API) initRouter() http.Handler {
 	r := chi.NewRouter()
 	r.Use(cors.New(cors.Options{
 		AllowedOrigins:   []string{"*"},
-		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedMethods:   []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
 		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
 		AllowCredentials: true,
 		MaxAge:           300, // Maximum value not ignored by any of major browsers
 	}).Handler)
 	r.Use(middleware.Logger)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.Throttle(100))
-	r.Use(middleware.ThrottleBacklog(5000, 40000, 60*time.Second))
-	r.Use(middleware.Timeout(45 * time.Second))
+	// the live streams stay open for as long as a screen follows a process, so they are left out of
+	// the throttles and the request timeout: the live results hub caps them instead
+	r.Use(unlessStreaming(middleware.Throttle(100)))
+	r.Use(unlessStreaming(middleware.ThrottleBacklog(5000, 40000, 60*time.Second)))
+	r.Use(unlessStreaming(middleware.Timeout(45 * time.Second)))
 	// set lang param in context
 	r.Use(a.setLang)
 
 	a.csp.PasswordSalt = passwordSalt
-	cspHandlers := handlers.New(a.csp, a.db)
+	cspHandlers := handlers.New(a.csp, a.db, a.account)
 
 	// Initialize Stripe service
 	if err := a.InitializeStripeService(); err != nil {
@@ -380,6 +406,29 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodPost, organizationAddMembersEndpoint, a.addOrganizationMembersHandler)
 		handle(r, http.MethodPut, organizationUpsertMemberEndpoint, a.upsertOrganizationMemberHandler)
 		handle(r, http.MethodDelete, organizationDeleteMembersEndpoint, a.deleteOrganizationMembersHandler)
+		handle(r, http.MethodGet, organizationMemberDuplicatesEndpoint, a.organizationMemberDuplicatesHandler)
+		handle(r, http.MethodPost, organizationMergeMembersEndpoint, a.mergeOrganizationMembersHandler)
+		handle(r, http.MethodGet, organizationMemberSelfServiceEndpoint, a.memberSelfServiceConfigHandler)
+		handle(r, http.MethodPut, organizationMemberSelfServiceEndpoint, a.setMemberSelfServiceConfigHandler)
+		handle(r, http.MethodGet, organizationMemberUpdatesEndpoint, a.memberUpdateRequestsHandler)
+		handle(r, http.MethodPut, organizationMemberUpdateEndpoint, a.resolveMemberUpdateRequestHandler)
+		handle(r, http.MethodGet, organizationMemberHistoryEndpoint, a.memberHistoryHandler)
+		handle(r, http.MethodPost, organizationMemberSearchEndpoint, a.searchOrganizationMembersHandler)
+		// SCIM 2.0 provisioning
+		handle(r, http.MethodGet, scimServiceProviderConfigEndpoint, a.scimServiceProviderConfigHandler)
+		handle(r, http.MethodGet, scimUsersEndpoint, a.scimUsersHandler)
+		handle(r, http.MethodPost, scimUsersEndpoint, a.scimCreateUserHandler)
+		handle(r, http.MethodGet, scimUserEndpoint, a.scimUserHandler)
+		handle(r, http.MethodPut, scimUserEndpoint, a.scimReplaceUserHandler)
+		handle(r, http.MethodPatch, scimUserEndpoint, a.scimPatchUserHandler)
+		handle(r, http.MethodDelete, scimUserEndpoint, a.scimDeleteUserHandler)
+		handle(r, http.MethodGet, scimGroupsEndpoint, a.scimGroupsHandler)
+		handle(r, http.MethodPost, scimGroupsEndpoint, a.scimCreateGroupHandler)
+		handle(r, http.MethodGet, scimGroupEndpoint, a.scimGroupHandler)
+		handle(r, http.MethodPut, scimGroupEndpoint, a.scimReplaceGroupHandler)
+		handle(r, http.MethodPatch, scimGroupEndpoint, a.scimPatchGroupHandler)
+		handle(r, http.MethodDelete, scimGroupEndpoint, a.scimDeleteGroupHandler)
+		handle(r, http.MethodPost, scimBulkEndpoint, a.scimBulkHandler)
 		handle(r, http.MethodPost, organizationMetaEndpoint, a.addOrganizationMetaHandler)
 		handle(r, http.MethodPut, organizationMetaEndpoint, a.updateOrganizationMetaHandler)
 		handle(r, http.MethodGet, organizationMetaEndpoint, a.organizationMetaHandler)
@@ -392,6 +441,21 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodPut, organizationGroupEndpoint, a.updateOrganizationMemberGroupHandler)
 		handle(r, http.MethodDelete, organizationGroupEndpoint, a.deleteOrganizationMemberGroupHandler)
 		handle(r, http.MethodPost, organizationGroupValidateEndpoint, a.organizationMemberGroupValidateHandler)
+		handle(r, http.MethodPost, organizationGroupsCombineEndpoint, a.combineOrganizationMemberGroupsHandler)
+		handle(r, http.MethodGet, organizationSuppressionsEndpoint, a.organizationSuppressionsHandler)
+		handle(r, http.MethodPost, organizationSuppressionsEndpoint, a.addOrganizationSuppressionHandler)
+		handle(r, http.MethodDelete, organizationSuppressionsEndpoint, a.deleteOrganizationSuppressionHandler)
+		handle(r, http.MethodGet, organizationProcessTemplatesEndpoint, a.processTemplatesHandler)
+		handle(r, http.MethodPost, organizationProcessTemplatesEndpoint, a.createProcessTemplateHandler)
+		handle(r, http.MethodGet, organizationProcessTemplateEndpoint, a.processTemplateHandler)
+		handle(r, http.MethodDelete, organizationProcessTemplateEndpoint, a.deleteProcessTemplateHandler)
+		handle(r, http.MethodPost, organizationProcessTemplateProcessesEndpoint, a.instantiateProcessTemplateHandler)
+		handle(r, http.MethodGet, organizationScheduledPublishesEndpoint, a.scheduledPublishesHandler)
+		handle(r, http.MethodGet, organizationMemberUnsubscribeLinkEndpoint, a.memberUnsubscribeLinkHandler)
+		handle(r, http.MethodGet, organizationRetentionEndpoint, a.retentionPolicyHandler)
+		handle(r, http.MethodPut, organizationRetentionEndpoint, a.setRetentionPolicyHandler)
+		handle(r, http.MethodGet, organizationPublishReviewEndpoint, a.publishReviewPolicyHandler)
+		handle(r, http.MethodPut, organizationPublishReviewEndpoint, a.setPublishReviewPolicyHandler)
 		handle(r, http.MethodGet, jobsEndpoint, a.jobsHandler)
 		handle(r, http.MethodGet, organizationBundlesEndpoint, a.organizationBundlesHandler)
 		handle(r, http.MethodPost, managedOrganizationsEndpoint, a.createManagedOrganizationHandler)
@@ -412,6 +476,10 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodPost, censusPublishEndpoint, a.publishCensusHandler)
 		handle(r, http.MethodPost, censusGroupPublishEndpoint, a.publishCensusGroupHandler)
 		handle(r, http.MethodGet, censusParticipantsEndpoint, a.censusParticipantsHandler)
+		handle(r, http.MethodGet, censusSnapshotsEndpoint, a.censusSnapshotsHandler)
+		handle(r, http.MethodGet, censusSnapshotsDiffEndpoint, a.censusSnapshotsDiffHandler)
+		handle(r, http.MethodPost, censusExportEndpoint, a.censusExportHandler)
+		handle(r, http.MethodGet, censusExportJobEndpoint, a.censusExportDownloadHandler)
 		handle(r, http.MethodPost, processCreateEndpoint, a.createProcessHandler)
 		handle(r, http.MethodPut, processEndpoint, a.updateProcessHandler)
 		handle(r, http.MethodDelete, processEndpoint, a.deleteProcessHandler)
@@ -422,16 +490,29 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodPost, processBundleParticipantsCheckEndpoint, a.checkProcessBundleVotedParticipantsHandler)
 		// multi-question voting processes: authoring (the GET reads are public — see below)
 		handle(r, http.MethodPost, processesCreateEndpoint, a.createVotingProcessHandler)
+		handle(r, http.MethodPost, processesCloneEndpoint, a.cloneVotingProcessHandler)
 		handle(r, http.MethodPost, processesCensusValidateEndpoint, a.validateProcessCensusHandler)
 		handle(r, http.MethodPut, processesEndpoint, a.updateVotingProcessHandler)
 		handle(r, http.MethodGet, processesValidateEndpoint, a.validateVotingProcessHandler)
 		handle(r, http.MethodPost, processesPublishEndpoint, a.publishVotingProcessHandler)
+		handle(r, http.MethodPut, processesScheduleEndpoint, a.scheduleVotingProcessPublishHandler)
+		handle(r, http.MethodDelete, processesScheduleEndpoint, a.cancelVotingProcessPublishHandler)
+		handle(r, http.MethodPost, processesReviewEndpoint, a.submitVotingProcessReviewHandler)
+		handle(r, http.MethodPost, processesReviewApproveEndpoint, a.approveVotingProcessReviewHandler)
+		handle(r, http.MethodPost, processesReviewRejectEndpoint, a.rejectVotingProcessReviewHandler)
+		handle(r, http.MethodPost, processesQuestionRunoffEndpoint, a.createRunoffHandler)
 		handle(r, http.MethodPut, processesQuestionsStatusEndpoint, a.setVotingProcessQuestionsStatusHandler)
 		handle(r, http.MethodPut, processesQuestionStatusEndpoint, a.setVotingProcessQuestionStatusHandler)
 		handle(r, http.MethodDelete, processesEndpoint, a.deleteVotingProcessHandler)
 		handle(r, http.MethodGet, processesParticipantsEndpoint, a.votingProcessParticipantsHandler)
+		handle(r, http.MethodGet, processesTurnoutEndpoint, a.votingProcessTurnoutHandler)
+		handle(r, http.MethodGet, processesResultsCertificateEndpoint, a.resultsCertificateHandler)
+		handle(r, http.MethodGet, processesDelegationsEndpoint, a.votingProcessDelegationsHandler)
+		handle(r, http.MethodPost, processesDelegationsEndpoint, a.createVotingProcessDelegationHandler)
+		handle(r, http.MethodDelete, processesDelegationEndpoint, a.deleteVotingProcessDelegationHandler)
 		handle(r, http.MethodPut, processesCensusEndpoint, a.updateVotingProcessCensusHandler)
 		handle(r, http.MethodDelete, processesCensusEndpoint, a.removeVotingProcessCensusHandler)
+		handle(r, http.MethodPost, processesCensusImportEndpoint, a.importEphemeralCensusHandler)
 		handle(r, http.MethodPut, processesQuestionCensusEndpoint, a.updateVotingProcessQuestionCensusHandler)
 	})
 
@@ -475,6 +556,13 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodPost, processBundleSignEndpoint, cspHandlers.BundleSignHandler)
 		handle(r, http.MethodPost, processBundleCheckEndpoint, cspHandlers.BundleCheckHandler)
 		handle(r, http.MethodGet, processBundleMemberEndpoint, a.processBundleParticipantInfoHandler)
+		// member self-service portal: the member authenticates with a one-time code instead of a JWT
+		handle(r, http.MethodPost, organizationMemberSelfServiceAuthEndpoint, a.memberSelfServiceAuthHandler)
+		handle(r, http.MethodPost, organizationMemberSelfServiceVerifyEndpoint, a.memberSelfServiceVerifyHandler)
+		handle(r, http.MethodPut, organizationMemberSelfServiceProfileEndpoint, a.memberSelfServiceUpdateHandler)
+		// unsubscribe links: the signed token is the only credential, and reading one changes nothing
+		handle(r, http.MethodGet, unsubscribeEndpoint, a.unsubscribeInfoHandler)
+		handle(r, http.MethodPost, unsubscribeEndpoint, a.unsubscribeHandler)
 		// multi-question voting processes: public voter reads + CSP. The process list and single-read
 		// are public for published processes; drafts + per-question eligibility are gated in-handler to
 		// a manager/admin (or a voting:write API key) via optionalManager.
@@ -483,12 +571,16 @@ func (a *API) initRouter() http.Handler {
 		handle(r, http.MethodGet, processesQuestionEndpoint, a.votingProcessQuestionHandler)
 		handle(r, http.MethodGet, processesParticipantEndpoint, a.votingProcessParticipantHandler)
 		handle(r, http.MethodGet, processesResultsEndpoint, a.votingProcessResultsHandler)
+		handle(r, http.MethodGet, processesStreamEndpoint, a.votingProcessStreamHandler)
+		handle(r, http.MethodPost, processesResultsCertificateVerifyEndpoint, a.verifyResultsCertificateHandler)
 		handle(r, http.MethodPost, processesCheckEndpoint, cspHandlers.ProcessCheckHandler)
 		handle(r, http.MethodPost, processesAuthEndpoint, cspHandlers.ProcessAuthHandler)
 		handle(r, http.MethodPost, processesAuthResendEndpoint, cspHandlers.ProcessAuthResendHandler)
 		handle(r, http.MethodPost, processesSignEndpoint, cspHandlers.ProcessSignHandler)
 		handle(r, http.MethodPost, processesSignBatchEndpoint, cspHandlers.ProcessSignBatchHandler)
 		handle(r, http.MethodPost, processesWeightEndpoint, cspHandlers.ProcessWeightHandler)
+		handle(r, http.MethodPost, processesVoterDelegationEndpoint, cspHandlers.ProcessDelegateHandler)
+		handle(r, http.MethodDelete, processesVoterDelegationEndpoint, cspHandlers.ProcessRevokeDelegationHandler)
 		handle(r, http.MethodPost, processesSignInfoEndpoint, cspHandlers.ProcessSignInfoHandler)
 	})
 	a.router = r
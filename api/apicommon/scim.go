@@ -0,0 +1,180 @@
+package apicommon
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// SCIM 2.0 (RFC 7643, RFC 7644) schema and message URNs.
+const (
+	SCIMSchemaUser            = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup           = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaMember          = "urn:vocdoni:params:scim:schemas:extension:member:2.0:Member"
+	SCIMSchemaServiceProvider = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMMessageListResponse   = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMMessagePatchOp        = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMMessageBulkRequest    = "urn:ietf:params:scim:api:messages:2.0:BulkRequest"
+	SCIMMessageBulkResponse   = "urn:ietf:params:scim:api:messages:2.0:BulkResponse"
+	SCIMMessageError          = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+// SCIMMeta is the read-only metadata of a SCIM resource.
+type SCIMMeta struct {
+	ResourceType string     `json:"resourceType"`
+	Created      *time.Time `json:"created,omitempty"`
+	LastModified *time.Time `json:"lastModified,omitempty"`
+	Location     string     `json:"location,omitempty"`
+}
+
+// SCIMName is the name of a SCIM User.
+type SCIMName struct {
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+// SCIMMultiValue is an entry of a multi-valued SCIM attribute (emails, phoneNumbers, members).
+type SCIMMultiValue struct {
+	Value   string `json:"value"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Display string `json:"display,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMMemberExtension carries the org member fields that have no SCIM core attribute.
+type SCIMMemberExtension struct {
+	NationalID string `json:"nationalId,omitempty"`
+	BirthDate  string `json:"birthDate,omitempty"`
+	Weight     uint64 `json:"weight,omitempty"`
+}
+
+// SCIMUser is a SCIM User, mapped onto an organization member.
+// swagger:model SCIMUser
+type SCIMUser struct {
+	Schemas      []string             `json:"schemas"`
+	ID           string               `json:"id,omitempty"`
+	ExternalID   string               `json:"externalId,omitempty"`
+	UserName     string               `json:"userName"`
+	Name         *SCIMName            `json:"name,omitempty"`
+	Emails       []SCIMMultiValue     `json:"emails,omitempty"`
+	PhoneNumbers []SCIMMultiValue     `json:"phoneNumbers,omitempty"`
+	Active       *bool                `json:"active,omitempty"`
+	Member       *SCIMMemberExtension `json:"urn:vocdoni:params:scim:schemas:extension:member:2.0:Member,omitempty"`
+	Meta         *SCIMMeta            `json:"meta,omitempty"`
+}
+
+// SCIMGroup is a SCIM Group, mapped onto an organization member group.
+// swagger:model SCIMGroup
+type SCIMGroup struct {
+	Schemas     []string         `json:"schemas"`
+	ID          string           `json:"id,omitempty"`
+	DisplayName string           `json:"displayName"`
+	Members     []SCIMMultiValue `json:"members"`
+	Meta        *SCIMMeta        `json:"meta,omitempty"`
+}
+
+// SCIMListResponse is a page of SCIM resources.
+// swagger:model SCIMListResponse
+type SCIMListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int64    `json:"totalResults"`
+	StartIndex   int64    `json:"startIndex"`
+	ItemsPerPage int64    `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+// SCIMPatchOperation is one operation of a SCIM PATCH. Value is kept raw, since its shape depends
+// on the path it applies to.
+type SCIMPatchOperation struct {
+	Op    string          `json:"op"`
+	Path  string          `json:"path,omitempty"`
+	Value json.RawMessage `json:"value,omitempty"`
+}
+
+// SCIMPatchRequest is the body of a SCIM PATCH.
+// swagger:model SCIMPatchRequest
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations"`
+}
+
+// SCIMBulkOperation is one operation of a SCIM bulk request. A bulkId names the resource a POST
+// creates, so later operations can refer to it as "bulkId:<bulkId>".
+type SCIMBulkOperation struct {
+	Method string          `json:"method"`
+	BulkID string          `json:"bulkId,omitempty"`
+	Path   string          `json:"path"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// SCIMBulkRequest is the body of a SCIM bulk request. Processing stops once FailOnErrors
+// operations have failed; zero means it never stops.
+// swagger:model SCIMBulkRequest
+type SCIMBulkRequest struct {
+	Schemas      []string            `json:"schemas"`
+	FailOnErrors int                 `json:"failOnErrors,omitempty"`
+	Operations   []SCIMBulkOperation `json:"Operations"`
+}
+
+// SCIMBulkOperationResult is the outcome of one bulk operation. Response is only set on failure.
+type SCIMBulkOperationResult struct {
+	Method   string     `json:"method"`
+	BulkID   string     `json:"bulkId,omitempty"`
+	Location string     `json:"location,omitempty"`
+	Status   string     `json:"status"`
+	Response *SCIMError `json:"response,omitempty"`
+}
+
+// SCIMBulkResponse is the response of a SCIM bulk request.
+// swagger:model SCIMBulkResponse
+type SCIMBulkResponse struct {
+	Schemas    []string                  `json:"schemas"`
+	Operations []SCIMBulkOperationResult `json:"Operations"`
+}
+
+// SCIMError is the body of every SCIM error response.
+// swagger:model SCIMError
+type SCIMError struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	ScimType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail,omitempty"`
+}
+
+// SCIMSupported flags an optional SCIM feature.
+type SCIMSupported struct {
+	Supported bool `json:"supported"`
+}
+
+// SCIMBulkSupport describes the bulk limits of the service provider.
+type SCIMBulkSupport struct {
+	Supported      bool `json:"supported"`
+	MaxOperations  int  `json:"maxOperations"`
+	MaxPayloadSize int  `json:"maxPayloadSize"`
+}
+
+// SCIMFilterSupport describes the filter limits of the service provider.
+type SCIMFilterSupport struct {
+	Supported  bool `json:"supported"`
+	MaxResults int  `json:"maxResults"`
+}
+
+// SCIMServiceProviderConfig describes the SCIM features the server implements.
+// swagger:model SCIMServiceProviderConfig
+type SCIMServiceProviderConfig struct {
+	Schemas               []string                   `json:"schemas"`
+	Patch                 SCIMSupported              `json:"patch"`
+	Bulk                  SCIMBulkSupport            `json:"bulk"`
+	Filter                SCIMFilterSupport          `json:"filter"`
+	ChangePassword        SCIMSupported              `json:"changePassword"`
+	Sort                  SCIMSupported              `json:"sort"`
+	ETag                  SCIMSupported              `json:"etag"`
+	AuthenticationSchemes []SCIMAuthenticationScheme `json:"authenticationSchemes"`
+}
+
+// SCIMAuthenticationScheme describes an authentication scheme of the service provider.
+type SCIMAuthenticationScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
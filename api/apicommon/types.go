@@ -1137,6 +1137,184 @@ type DeleteMembersResponse struct {
 	Errors []string `json:"errors,omitempty"`
 }
 
+// OrgMemberDuplicatesResponse is returned by GET /organizations/{orgAddress}/members/duplicates.
+// swagger:model OrgMemberDuplicatesResponse
+type OrgMemberDuplicatesResponse struct {
+	// Sets of members sharing a value of one criterion, strongest criterion first. A pair of
+	// members appears once per criterion they share.
+	Duplicates []db.OrgMemberDuplicateSet `json:"duplicates"`
+}
+
+// MergeMembersRequest is the body of POST /organizations/{orgAddress}/members/merge.
+// swagger:model MergeMembersRequest
+type MergeMembersRequest struct {
+	// Internal id of the member that is kept
+	SurvivorID string `json:"survivorId"`
+	// Internal ids of the members folded into the survivor and then deleted
+	DuplicateIDs []string `json:"duplicateIds"`
+}
+
+// MergeMembersResponse is returned by POST /organizations/{orgAddress}/members/merge.
+// swagger:model MergeMembersResponse
+type MergeMembersResponse struct {
+	// Number of duplicate members deleted
+	Merged int `json:"merged"`
+	// Censuses the survivor joined in place of a duplicate
+	Censuses []string `json:"censuses,omitempty"`
+	// Number of groups whose membership now names the survivor instead of a duplicate
+	Groups int64 `json:"groups"`
+}
+
+// MemberSelfServiceAuthRequest is the body of POST /organizations/{orgAddress}/members/selfservice/auth.
+// The member fills in the auth fields of the portal config, plus the email or the phone the
+// one-time code is sent to.
+// swagger:model MemberSelfServiceAuthRequest
+type MemberSelfServiceAuthRequest struct {
+	Name         string `json:"name,omitempty"`
+	Surname      string `json:"surname,omitempty"`
+	MemberNumber string `json:"memberNumber,omitempty"`
+	NationalID   string `json:"nationalId,omitempty"`
+	BirthDate    string `json:"birthDate,omitempty"`
+	Email        string `json:"email,omitempty"`
+	Phone        string `json:"phone,omitempty"`
+}
+
+// MemberSelfServiceAuthResponse is returned by POST /organizations/{orgAddress}/members/selfservice/auth.
+// swagger:model MemberSelfServiceAuthResponse
+type MemberSelfServiceAuthResponse struct {
+	// Token identifying the login, to verify with the one-time code received
+	AuthToken internal.HexBytes `json:"authToken" swaggertype:"string" format:"hex" example:"deadbeef"`
+}
+
+// MemberSelfServiceVerifyRequest is the body of POST /organizations/{orgAddress}/members/selfservice/verify.
+// swagger:model MemberSelfServiceVerifyRequest
+type MemberSelfServiceVerifyRequest struct {
+	AuthToken internal.HexBytes `json:"authToken" swaggertype:"string" format:"hex" example:"deadbeef"`
+	// One-time code received by email or SMS
+	Code string `json:"code"`
+}
+
+// MemberSelfServiceProfile is the member's current value of each editable field, returned once
+// the login is verified. The phone is never returned in clear text, only masked.
+// swagger:model MemberSelfServiceProfile
+type MemberSelfServiceProfile struct {
+	Fields map[string]string `json:"fields"`
+	// Whether the changes submitted wait for a manager's approval
+	RequireApproval bool `json:"requireApproval"`
+}
+
+// MemberSelfServiceUpdateRequest is the body of PUT /organizations/{orgAddress}/members/selfservice/profile.
+// swagger:model MemberSelfServiceUpdateRequest
+type MemberSelfServiceUpdateRequest struct {
+	AuthToken internal.HexBytes `json:"authToken" swaggertype:"string" format:"hex" example:"deadbeef"`
+	// New value of each field to change, keyed by editable field name
+	Changes map[string]string `json:"changes"`
+}
+
+// MemberSelfServiceUpdateResponse is returned by PUT /organizations/{orgAddress}/members/selfservice/profile.
+// swagger:model MemberSelfServiceUpdateResponse
+type MemberSelfServiceUpdateResponse struct {
+	// Id of the recorded update request
+	RequestID string `json:"requestId"`
+	// pending when the change waits for a manager's approval, applied otherwise
+	Status db.MemberUpdateStatus `json:"status"`
+}
+
+// MemberUpdateRequestsResponse is returned by GET /organizations/{orgAddress}/members/updates.
+// swagger:model MemberUpdateRequestsResponse
+type MemberUpdateRequestsResponse struct {
+	Pagination *Pagination               `json:"pagination"`
+	Requests   []*db.MemberUpdateRequest `json:"requests"`
+}
+
+// ResolveMemberUpdateRequest is the body of PUT /organizations/{orgAddress}/members/updates/{requestId}.
+// swagger:model ResolveMemberUpdateRequest
+type ResolveMemberUpdateRequest struct {
+	// true applies the change to the member, false rejects it
+	Approve bool `json:"approve"`
+}
+
+// MemberHistoryResponse is returned by GET /organizations/{orgAddress}/members/{memberId}/history.
+// swagger:model MemberHistoryResponse
+type MemberHistoryResponse struct {
+	Pagination *Pagination         `json:"pagination"`
+	Versions   []*db.MemberVersion `json:"versions"`
+}
+
+// MemberFilter is a predicate on member fields. Every criterion set must hold.
+// swagger:model MemberFilter
+type MemberFilter struct {
+	// Equals matches a field exactly: email, phone, memberNumber, nationalId, name, surname or externalId.
+	Equals map[string]string `json:"equals,omitempty"`
+	// In matches a field against any of a set of values, with the fields of Equals.
+	In map[string][]string `json:"in,omitempty"`
+	// Weight bounds the member weight, inclusively.
+	Weight *WeightRange `json:"weight,omitempty"`
+	// BirthDate bounds the birth date, inclusively.
+	BirthDate *DateRange `json:"birthDate,omitempty"`
+	// HasEmail and HasPhone match members with (true) or without (false) an email or phone.
+	HasEmail *bool `json:"hasEmail,omitempty"`
+	HasPhone *bool `json:"hasPhone,omitempty"`
+}
+
+// MemberSearchRequest is the body of POST /organizations/{orgAddress}/members/search. Every
+// criterion set must hold.
+// swagger:model MemberSearchRequest
+type MemberSearchRequest struct {
+	MemberFilter
+	// GroupID matches the members of a group.
+	GroupID string `json:"groupId,omitempty"`
+	// CensusID matches the participants of a census.
+	CensusID string `json:"censusId,omitempty"`
+	// Sort is name, surname, memberNumber, email, weight, birthDate or createdAt, prefixed with "-"
+	// for descending order. Empty sorts by creation order.
+	Sort string `json:"sort,omitempty"`
+	// Limit is the page size (default 50, max 500).
+	Limit int64 `json:"limit,omitempty"`
+	// Cursor is the nextCursor of the previous page; empty for the first page.
+	Cursor string `json:"cursor,omitempty"`
+	// IncludeTotal also counts every member matching the search.
+	IncludeTotal bool `json:"includeTotal,omitempty"`
+}
+
+// WeightRange bounds a member weight. Either bound can be omitted.
+type WeightRange struct {
+	Min *uint64 `json:"min,omitempty"`
+	Max *uint64 `json:"max,omitempty"`
+}
+
+// DateRange bounds a date, as YYYY-MM-DD. Either bound can be omitted.
+type DateRange struct {
+	From string `json:"from,omitempty"`
+	To   string `json:"to,omitempty"`
+}
+
+// CombineMemberGroupsRequest is the body of POST /organizations/{orgAddress}/groups/combine: a new
+// static group holding the result of a set operation on existing groups.
+// swagger:model CombineMemberGroupsRequest
+type CombineMemberGroupsRequest struct {
+	// Title of the new group
+	Title string `json:"title"`
+	// Description of the new group
+	Description string `json:"description"`
+	// Operation is union, intersection or difference (the first group minus the others).
+	Operation db.GroupSetOperation `json:"operation"`
+	// GroupIDs are the groups to combine, in order.
+	GroupIDs []string `json:"groupIds"`
+	// Filter optionally keeps only the members matching it.
+	Filter *MemberFilter `json:"filter,omitempty"`
+}
+
+// MemberSearchResponse is a page of a member search.
+// swagger:model MemberSearchResponse
+type MemberSearchResponse struct {
+	Members []OrgMember `json:"members"`
+	// NextCursor fetches the next page; absent on the last one.
+	NextCursor string `json:"nextCursor,omitempty"`
+	// Total is only present when the request asked for it.
+	Total *int64 `json:"total,omitempty"`
+}
+
 // OrgMember defines the structure of a member in the API.
 // It is the mirror struct of db.OrgMember.
 // swagger:model OrgMember
@@ -1173,6 +1351,29 @@ type OrgMember struct {
 
 	// Additional custom fields
 	Other map[string]any `json:"other,omitempty"`
+
+	// Member's consent to non-transactional email and SMS messages. A consent sent without `at`
+	// is stamped with the time of the request.
+	EmailConsent *db.MemberConsent `json:"emailConsent,omitempty"`
+	SMSConsent   *db.MemberConsent `json:"smsConsent,omitempty"`
+
+	// When the retention policy of the organization erased the member's personal data. Read-only.
+	AnonymizedAt *time.Time `json:"anonymizedAt,omitempty"`
+
+	// When SCIM provisioning deactivated the member, which then joins no new census. Read-only.
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+}
+
+// stampedConsent returns a copy of the consent with its time set, defaulting to now.
+func stampedConsent(consent *db.MemberConsent) *db.MemberConsent {
+	if consent == nil {
+		return nil
+	}
+	stamped := *consent
+	if stamped.At.IsZero() {
+		stamped.At = time.Now()
+	}
+	return &stamped
 }
 
 // ToDB converts an OrgMember to a db.OrgMember.
@@ -1211,21 +1412,35 @@ func (p *OrgMember) ToDB() *db.OrgMember {
 		Password:       p.Password,
 		Weight:         weight,
 		Other:          p.Other,
+		EmailConsent:   stampedConsent(p.EmailConsent),
+		SMSConsent:     stampedConsent(p.SMSConsent),
 	}
 }
 
 func OrgMemberFromDb(p db.OrgMember) OrgMember {
+	var anonymizedAt *time.Time
+	if !p.AnonymizedAt.IsZero() {
+		anonymizedAt = &p.AnonymizedAt
+	}
+	var deactivatedAt *time.Time
+	if !p.DeactivatedAt.IsZero() {
+		deactivatedAt = &p.DeactivatedAt
+	}
 	return OrgMember{
-		ID:           p.ID.Hex(),
-		MemberNumber: p.MemberNumber,
-		Name:         p.Name,
-		Surname:      p.Surname,
-		NationalID:   p.NationalID,
-		BirthDate:    p.BirthDate,
-		Email:        p.Email,
-		Phone:        p.Phone.String(), // This returns either "" or the masked hash
-		Other:        p.Other,
-		Weight:       fmt.Sprintf("%d", p.Weight),
+		ID:            p.ID.Hex(),
+		MemberNumber:  p.MemberNumber,
+		Name:          p.Name,
+		Surname:       p.Surname,
+		NationalID:    p.NationalID,
+		BirthDate:     p.BirthDate,
+		Email:         p.Email,
+		Phone:         p.Phone.String(), // This returns either "" or the masked hash
+		Other:         p.Other,
+		Weight:        fmt.Sprintf("%d", p.Weight),
+		EmailConsent:  p.EmailConsent,
+		SMSConsent:    p.SMSConsent,
+		AnonymizedAt:  anonymizedAt,
+		DeactivatedAt: deactivatedAt,
 	}
 }
 
@@ -1242,9 +1457,12 @@ type AddMembersResponse struct {
 	// Number of members added
 	Added uint32 `json:"added"`
 
-	// Errors encountered during job. Validation errors are prefixed with
-	// "line N:", the 1-based position of the offending member in the
-	// submitted members list.
+	// Number of existing members updated, with upsert=true
+	Updated uint32 `json:"updated,omitempty"`
+
+	// Errors encountered during job. Validation errors and rejected rows
+	// are prefixed with "line N:", the 1-based position of the offending
+	// member in the submitted members list.
 	Errors []string `json:"errors"`
 
 	// Job ID for tracking the addition process
@@ -1256,6 +1474,79 @@ type AddMembersResponse struct {
 	CensusJobIDs []string `json:"censusJobIds,omitempty"`
 }
 
+// SuppressionRequest names an address of an organization's suppression list, for
+// POST and DELETE /organizations/{orgAddress}/suppressions.
+// swagger:model SuppressionRequest
+type SuppressionRequest struct {
+	// Channel is email or sms
+	Channel db.MessageChannel `json:"channel"`
+	// Address is the email address or the phone number
+	Address string `json:"address"`
+	// Reason is free text kept with a new entry, e.g. "bounced"
+	Reason string `json:"reason,omitempty"`
+}
+
+// SuppressionsResponse is a page of an organization's suppression list.
+// swagger:model SuppressionsResponse
+type SuppressionsResponse struct {
+	Pagination   *Pagination       `json:"pagination"`
+	Suppressions []*db.Suppression `json:"suppressions"`
+}
+
+// UnsubscribeLinkResponse is the signed unsubscribe link of a member on a channel.
+// swagger:model UnsubscribeLinkResponse
+type UnsubscribeLinkResponse struct {
+	// URL is the web app page that unsubscribes the member, carrying the token
+	URL string `json:"url"`
+	// Token is the signed token alone, for POST /unsubscribe
+	Token string `json:"token"`
+}
+
+// UnsubscribeRequest is the body of POST /unsubscribe.
+// swagger:model UnsubscribeRequest
+type UnsubscribeRequest struct {
+	Token string `json:"token"`
+}
+
+// UnsubscribeInfo describes what an unsubscribe token names, and whether it is suppressed.
+// swagger:model UnsubscribeInfo
+type UnsubscribeInfo struct {
+	OrgAddress common.Address    `json:"orgAddress" swaggertype:"string" format:"hex"`
+	Channel    db.MessageChannel `json:"channel"`
+	// Address is the email, or the masked hash of the phone number
+	Address    string `json:"address"`
+	Suppressed bool   `json:"suppressed"`
+}
+
+// AddMembersPreviewResponse is returned by POST /organizations/{orgAddress}/members?dryRun=true:
+// what the same import would do, without any of it being written.
+// swagger:model AddMembersPreviewResponse
+type AddMembersPreviewResponse struct {
+	// Number of rows that would create a new member
+	Insert int `json:"insert"`
+	// Number of rows that match an existing member by memberNumber, or else by email, with upsert=true
+	Update int `json:"update"`
+	// Number of rows writing a member an earlier row already writes, with upsert=true
+	Reject int `json:"reject"`
+	// Rows details every update, with the fields it would change, every rejection, with its
+	// reasons, and every insert imported without some invalid values, with warnings about them.
+	// Other inserts are only counted.
+	Rows []*db.OrgMemberImportRow `json:"rows"`
+	// Quota is the effect of the new members on the plan's member quota
+	Quota MembersQuotaImpact `json:"quota"`
+}
+
+// MembersQuotaImpact reports whether adding members fits in the organization's plan.
+// swagger:model MembersQuotaImpact
+type MembersQuotaImpact struct {
+	// Number of members that would be added
+	NewMembers int `json:"newMembers"`
+	// Allowed reports whether the plan's member quota admits them
+	Allowed bool `json:"allowed"`
+	// Error is why the quota does not admit them
+	Error string `json:"error,omitempty"`
+}
+
 // UpsertOrgMemberResponse is returned by PUT /organizations/{orgAddress}/members. The id is the
 // member's; censusJobIds are present only when creating the member grew a live census and the
 // on-chain maxCensusSize had to be raised.
@@ -1702,3 +1993,10 @@ func JobResponseFromDB(job *db.Job) JobResponse {
 	}
 	return resp
 }
+
+// CensusSnapshotsResponse is returned by GET /census/{id}/snapshots.
+// swagger:model CensusSnapshotsResponse
+type CensusSnapshotsResponse struct {
+	Pagination *Pagination          `json:"pagination"`
+	Snapshots  []*db.CensusSnapshot `json:"snapshots"`
+}
@@ -4,6 +4,7 @@ package apicommon
 
 import (
 	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
 	"github.com/vocdoni/saas-backend/internal"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
@@ -11,7 +12,22 @@ import (
 // CensusSpec is the inline census definition of a voting process. The census type is
 // inferred from the auth/2FA fields; there is no prebuilt-by-id reference over the API.
 type CensusSpec struct {
-	Weighted    bool                    `json:"weighted"`
+	Weighted bool `json:"weighted"`
+	// WeightRule computes the weight of each member of a weighted census as it joins, instead of
+	// taking the member's weight. Round-trips on process reads.
+	WeightRule *db.WeightRule `json:"weightRule,omitempty"`
+	// Ephemeral makes a census whose participants are imported from a CSV file through
+	// POST /processes/{processId}/census/import instead of being organization members. They are
+	// purged once the process ends. Excludes GroupID and MemberIDs; round-trips on process reads.
+	Ephemeral bool `json:"ephemeral,omitempty"`
+	// FreezeOnPublish freezes the census when the process is published: its participants can no
+	// longer change, and Frozen records a commitment to them. Round-trips on process reads.
+	FreezeOnPublish bool `json:"freezeOnPublish,omitempty"`
+	// MaxProxies lets each participant hold the delegated votes of up to this many others; 0, the
+	// default, disables delegation. Round-trips on process reads.
+	MaxProxies int `json:"maxProxies,omitempty"`
+	// Frozen is set on process reads once the census is frozen, and ignored on writes.
+	Frozen      *db.CensusFreeze        `json:"frozen,omitempty"`
 	AuthFields  db.OrgMemberAuthFields  `json:"authFields,omitempty"`
 	TwoFaFields db.OrgMemberTwoFaFields `json:"twoFaFields,omitempty"`
 	// GroupID is the org member group the census was built from. Round-trips: it is echoed back on
@@ -25,7 +41,7 @@ type CensusSpec struct {
 	// Size is the number of members in the census. Response-only (ignored on create/update): for a
 	// published process it equals the on-chain maxCensusSize of its whole-census questions.
 	Size int64 `json:"size,omitempty"`
-	// TotalWeight is the whole-census total voting weight (sum of members' weights). Response-only;
+	// TotalWeight is the whole-census total voting weight (sum of participants' weights). Response-only;
 	// equals Size for a non-weighted census. Needed by clients (e.g. the results report) to turn
 	// per-answer weights into percentages.
 	TotalWeight int64 `json:"totalWeight,omitempty"`
@@ -54,6 +70,10 @@ type VotingProcessQuestionRequest struct {
 	SecretUntilTheEnd bool                 `json:"secretUntilTheEnd"`
 	Eligibility       *EligibilitySpec     `json:"census,omitempty"`
 	Metadata          map[string]any       `json:"metadata,omitempty"`
+	// Conditions name earlier questions of this request by their position, see
+	// db.VotingProcessQuestion.Conditions.
+	Conditions []db.QuestionCondition `json:"conditions,omitempty"`
+	Rules      *db.DecisionRules      `json:"rules,omitempty"`
 }
 
 // CreateVotingProcessRequest is the body of POST /processes (also used by PUT to update a
@@ -145,6 +165,16 @@ type VotingProcessResponse struct {
 	// PUT to make the update conditional on nothing else having written in between (see
 	// CreateVotingProcessRequest.UpdatedAt).
 	UpdatedAt string `json:"updatedAt,omitempty"`
+	// PublishSchedule is the scheduled publish of a draft, pending or fired; absent when none.
+	PublishSchedule *PublishScheduleInfo `json:"publishSchedule,omitempty"`
+	// Review is the review of a draft as stored; absent when it was never submitted, was edited
+	// since, or the process is published.
+	Review *ProcessReviewInfo `json:"review,omitempty"`
+	// RunoffOf is the question this process is a run-off round of; absent for any other process.
+	RunoffOf *RunoffLink `json:"runoffOf,omitempty"`
+	// Runoffs are the run-off rounds created from the questions of this process, oldest first.
+	// Drafts among them are listed to managers only.
+	Runoffs []RunoffLink `json:"runoffs,omitempty"`
 }
 
 // VotingProcessListResponse is the paginated list of voting processes.
@@ -166,6 +196,53 @@ type VotingProcessQuestionResults struct {
 type VotingProcessResultsResponse struct {
 	ID        string                         `json:"id"`
 	Questions []VotingProcessQuestionResults `json:"questions"`
+	// RunoffOf and Runoffs link the rounds of a run-off, as in VotingProcessResponse; only
+	// published rounds are listed.
+	RunoffOf *RunoffLink  `json:"runoffOf,omitempty"`
+	Runoffs  []RunoffLink `json:"runoffs,omitempty"`
+}
+
+// RunoffLink links the two rounds of a run-off: ProcessID is the process at the other end of the
+// link, and QuestionID the question of the earlier round the run-off decides.
+type RunoffLink struct {
+	ProcessID  string `json:"processId"`
+	QuestionID string `json:"questionId"`
+}
+
+// CreateRunoffRequest is the optional body of POST /processes/{processId}/questions/{questionId}/runoff.
+// Candidates is how many of the leading choices go to the run-off, 2 by default; choices tied with
+// the last of them go too. Title and dates are as in CloneVotingProcessRequest.
+type CreateRunoffRequest struct {
+	CloneVotingProcessRequest
+	Candidates int `json:"candidates,omitempty"`
+}
+
+// VotingProcessStatusEvent is the status event of a process stream: the status of every published
+// question of the process.
+type VotingProcessStatusEvent struct {
+	ID        string                `json:"id"`
+	Questions []QuestionStatusEntry `json:"questions"`
+}
+
+// QuestionStatusEntry is the status of one question.
+type QuestionStatusEntry struct {
+	QuestionID string `json:"questionId"`
+	Status     string `json:"status"`
+}
+
+// VerifyResultsCertificateRequest is a results certificate to verify: the sha256 digest of its CSV
+// rendering and the signature it carries.
+type VerifyResultsCertificateRequest struct {
+	Digest    internal.HexBytes `json:"digest"`
+	Signature internal.HexBytes `json:"signature"`
+}
+
+// VerifyResultsCertificateResponse tells whether the backend key signed a results certificate, and
+// whether it is the final certificate of the process, the one issued once every result was final.
+type VerifyResultsCertificateResponse struct {
+	Valid  bool              `json:"valid"`
+	Signer internal.HexBytes `json:"signer,omitempty"`
+	Final  bool              `json:"final"`
 }
 
 // VotingProcessValidateResponse is the publish-readiness dry-run result.
@@ -244,6 +321,11 @@ type PublicQuestionResponse struct {
 	// Manager/Admin of the owning organization; an anonymous or non-manager read never carries it.
 	// An empty/absent list on a manager read means the question is open to the whole census.
 	EligibleMemberIDs []string `json:"eligibleMemberIds,omitempty"`
+	// Conditions are the answers to earlier questions of the process this question depends on; a
+	// voter who did not give them is not asked it.
+	Conditions []db.QuestionCondition `json:"conditions,omitempty"`
+	// Rules are the quorum and majority the question is decided by.
+	Rules *db.DecisionRules `json:"rules,omitempty"`
 }
 
 // PublicQuestionResponseFromDB builds the public question read from a question and its parent
@@ -266,12 +348,19 @@ func PublicQuestionResponseFromDB(q *db.VotingProcessQuestion, census *db.Census
 		Status:            q.Status,
 		EncryptionKeys:    q.EncryptionKeys,
 		Results:           q.Results,
+		Conditions:        q.Conditions,
+		Rules:             q.Rules,
 	}
 	if census != nil {
 		resp.Census = CensusSpec{
-			Weighted:    census.Weighted,
-			AuthFields:  census.AuthFields,
-			TwoFaFields: census.TwoFaFields,
+			Weighted:        census.Weighted,
+			WeightRule:      census.WeightRule,
+			Ephemeral:       census.Ephemeral,
+			FreezeOnPublish: census.FreezeOnPublish,
+			MaxProxies:      census.MaxProxies,
+			Frozen:          census.Frozen,
+			AuthFields:      census.AuthFields,
+			TwoFaFields:     census.TwoFaFields,
 		}
 	}
 	return resp
@@ -304,12 +393,24 @@ func VotingProcessResponseFromDB(
 		// match the stored value when echoed back as a conditional-update token
 		resp.UpdatedAt = vp.UpdatedAt.UTC().Format(UpdatedAtLayout)
 	}
+	resp.PublishSchedule = PublishScheduleInfoFromDB(vp.PublishSchedule)
+	if !vp.Published {
+		resp.Review = ProcessReviewInfoFromDB(vp.CurrentReview())
+	}
+	if vp.RunoffOf != nil {
+		resp.RunoffOf = &RunoffLink{ProcessID: vp.RunoffOf.ProcessID.Hex(), QuestionID: vp.RunoffOf.QuestionID.Hex()}
+	}
 	if census != nil {
 		resp.Census = CensusSpec{
-			Weighted:    census.Weighted,
-			AuthFields:  census.AuthFields,
-			TwoFaFields: census.TwoFaFields,
-			Size:        census.Size,
+			Weighted:        census.Weighted,
+			WeightRule:      census.WeightRule,
+			Ephemeral:       census.Ephemeral,
+			FreezeOnPublish: census.FreezeOnPublish,
+			MaxProxies:      census.MaxProxies,
+			Frozen:          census.Frozen,
+			AuthFields:      census.AuthFields,
+			TwoFaFields:     census.TwoFaFields,
+			Size:            census.Size,
 		}
 		// guard the zero id so an organization-wide census reports no group at all: omitempty keys off
 		// the empty string, but a zero ObjectID hexes to 24 zeros and would serialize as a real group.
@@ -319,3 +420,285 @@ func VotingProcessResponseFromDB(
 	}
 	return resp
 }
+
+// CensusDelegationRequest is the body of a manager's delegation, handing the vote of the census
+// participant DelegatorID to DelegateID, both org member ids.
+type CensusDelegationRequest struct {
+	DelegatorID string `json:"delegatorId"`
+	DelegateID  string `json:"delegateId"`
+}
+
+// CensusDelegationsResponse lists the delegations of a voting process census.
+type CensusDelegationsResponse struct {
+	MaxProxies  int                   `json:"maxProxies"`
+	Delegations []db.CensusDelegation `json:"delegations"`
+}
+
+// DelegationError maps an error setting or revoking a census delegation to the API error to
+// write back: the managers' endpoints and the members' own, through the CSP, share it.
+func DelegationError(err error) errors.Error {
+	switch {
+	case errors.Is(err, db.ErrDelegationNotAllowed):
+		return errors.ErrDelegationNotAllowed
+	case errors.Is(err, db.ErrDelegationsLocked):
+		return errors.ErrDelegationsLocked
+	case errors.Is(err, db.ErrProxyLimitReached):
+		return errors.ErrProxyLimitReached
+	case errors.Is(err, db.ErrNotFound):
+		return errors.ErrCensusParticipantNotFound
+	case errors.Is(err, db.ErrInvalidData):
+		return errors.ErrInvalidData.WithErr(err)
+	default:
+		return errors.ErrGenericInternalServerError.WithErr(err)
+	}
+}
+
+// CloneVotingProcessRequest is the optional body of POST /processes/{processId}/clone, and the
+// body of POST /organizations/{orgAddress}/process-templates/{templateId}/processes: what the new
+// draft changes from its source. An empty title keeps the source's, and the dates (RFC3339) are
+// never copied, so a draft given none has none.
+type CloneVotingProcessRequest struct {
+	Title     db.MultiLangString `json:"title,omitempty"`
+	StartDate string             `json:"startDate,omitempty"`
+	EndDate   string             `json:"endDate,omitempty"`
+}
+
+// ProcessTemplateRequest is the body of POST /organizations/{orgAddress}/process-templates: the
+// template name and exactly one of ProcessID, a process of the organization to save, or Process,
+// a process in the form of a create request whose orgAddress, dates and updatedAt are ignored.
+type ProcessTemplateRequest struct {
+	Name      string                      `json:"name"`
+	ProcessID string                      `json:"processId,omitempty"`
+	Process   *CreateVotingProcessRequest `json:"process,omitempty"`
+}
+
+// ProcessTemplatesResponse is a page of the process templates of an organization.
+type ProcessTemplatesResponse struct {
+	Pagination *Pagination           `json:"pagination"`
+	Templates  []*db.ProcessTemplate `json:"templates"`
+}
+
+// TemplateProcessFromDB captures a process, its questions and its census as a template.
+// participantIDs are the members of a census that was neither built from a group nor ephemeral,
+// and are ignored otherwise.
+func TemplateProcessFromDB(
+	vp *db.VotingProcess, questions []db.VotingProcessQuestion, census *db.Census, participantIDs []string,
+) db.TemplateProcess {
+	tp := db.TemplateProcess{
+		Title:       vp.Title,
+		Description: vp.Description,
+		Header:      vp.Header,
+		StreamURI:   vp.StreamURI,
+		Census: db.TemplateCensus{
+			Weighted:        census.Weighted,
+			WeightRule:      census.WeightRule,
+			Ephemeral:       census.Ephemeral,
+			FreezeOnPublish: census.FreezeOnPublish,
+			MaxProxies:      census.MaxProxies,
+			AuthFields:      census.AuthFields,
+			TwoFaFields:     census.TwoFaFields,
+		},
+		Questions: make([]db.TemplateQuestion, 0, len(questions)),
+	}
+	switch {
+	case !census.GroupID.IsZero():
+		tp.Census.GroupID = census.GroupID.Hex()
+	case !census.Ephemeral:
+		tp.Census.MemberIDs = participantIDs
+	}
+	for i := range questions {
+		q := &questions[i]
+		tp.Questions = append(tp.Questions, db.TemplateQuestion{
+			Title:             q.Title,
+			Description:       q.Description,
+			Choices:           q.Choices,
+			Type:              q.Type,
+			TypeSetup:         q.TypeSetup,
+			BallotProtocol:    q.BallotProtocol,
+			SecretUntilTheEnd: q.SecretUntilTheEnd,
+			EligibleMemberIDs: q.EligibleMemberIDs,
+			Metadata:          q.Metadata,
+			Conditions:        q.Conditions,
+			Rules:             q.Rules,
+		})
+	}
+	return tp
+}
+
+// TemplateProcessFromRequest captures a create request as a template.
+func TemplateProcessFromRequest(req *CreateVotingProcessRequest) db.TemplateProcess {
+	tp := db.TemplateProcess{
+		Title:       req.Title,
+		Description: req.Description,
+		Header:      req.Header,
+		StreamURI:   req.StreamURI,
+		Census: db.TemplateCensus{
+			Weighted:        req.Census.Weighted,
+			WeightRule:      req.Census.WeightRule,
+			Ephemeral:       req.Census.Ephemeral,
+			FreezeOnPublish: req.Census.FreezeOnPublish,
+			MaxProxies:      req.Census.MaxProxies,
+			AuthFields:      req.Census.AuthFields,
+			TwoFaFields:     req.Census.TwoFaFields,
+			GroupID:         req.Census.GroupID,
+			MemberIDs:       req.Census.MemberIDs,
+		},
+		Questions: make([]db.TemplateQuestion, 0, len(req.Questions)),
+	}
+	for _, q := range req.Questions {
+		tq := db.TemplateQuestion{
+			Title:             q.Title,
+			Description:       q.Description,
+			Choices:           q.Choices,
+			Type:              q.Type,
+			TypeSetup:         q.TypeSetup,
+			BallotProtocol:    q.BallotProtocol,
+			SecretUntilTheEnd: q.SecretUntilTheEnd,
+			Metadata:          q.Metadata,
+			Conditions:        q.Conditions,
+			Rules:             q.Rules,
+		}
+		if q.Eligibility != nil {
+			tq.EligibleGroupID = q.Eligibility.GroupID
+			tq.EligibleMemberIDs = q.Eligibility.MemberIDs
+		}
+		tp.Questions = append(tp.Questions, tq)
+	}
+	return tp
+}
+
+// CreateVotingProcessRequestFromTemplate turns a template into the create request of a new draft
+// of orgAddress, with the title and dates of clone.
+func CreateVotingProcessRequestFromTemplate(
+	orgAddress internal.HexBytes, tp *db.TemplateProcess, clone *CloneVotingProcessRequest,
+) *CreateVotingProcessRequest {
+	req := &CreateVotingProcessRequest{
+		OrgAddress:  orgAddress,
+		Title:       tp.Title,
+		Description: tp.Description,
+		Header:      tp.Header,
+		StreamURI:   tp.StreamURI,
+		StartDate:   clone.StartDate,
+		EndDate:     clone.EndDate,
+		Census: CensusSpec{
+			Weighted:        tp.Census.Weighted,
+			WeightRule:      tp.Census.WeightRule,
+			Ephemeral:       tp.Census.Ephemeral,
+			FreezeOnPublish: tp.Census.FreezeOnPublish,
+			MaxProxies:      tp.Census.MaxProxies,
+			AuthFields:      tp.Census.AuthFields,
+			TwoFaFields:     tp.Census.TwoFaFields,
+			GroupID:         tp.Census.GroupID,
+			MemberIDs:       tp.Census.MemberIDs,
+		},
+		Questions: make([]VotingProcessQuestionRequest, 0, len(tp.Questions)),
+	}
+	if len(clone.Title) > 0 {
+		req.Title = clone.Title
+	}
+	for _, q := range tp.Questions {
+		qr := VotingProcessQuestionRequest{
+			Title:             q.Title,
+			Description:       q.Description,
+			Choices:           q.Choices,
+			Type:              q.Type,
+			TypeSetup:         q.TypeSetup,
+			BallotProtocol:    q.BallotProtocol,
+			SecretUntilTheEnd: q.SecretUntilTheEnd,
+			Metadata:          q.Metadata,
+			Conditions:        q.Conditions,
+			Rules:             q.Rules,
+		}
+		if q.EligibleGroupID != "" || len(q.EligibleMemberIDs) > 0 {
+			qr.Eligibility = &EligibilitySpec{GroupID: q.EligibleGroupID, MemberIDs: q.EligibleMemberIDs}
+		}
+		req.Questions = append(req.Questions, qr)
+	}
+	return req
+}
+
+// PublishScheduleRequest is the body of PUT /processes/{processId}/schedule: when to publish the
+// draft, RFC3339.
+type PublishScheduleRequest struct {
+	PublishAt string `json:"publishAt"`
+}
+
+// PublishScheduleInfo is the scheduled publish of a draft. FiredAt is set once the scheduler ran
+// it, along with the JobID of the publish it enqueued or the Problems that prevented it.
+type PublishScheduleInfo struct {
+	PublishAt string   `json:"publishAt"`
+	FiredAt   string   `json:"firedAt,omitempty"`
+	JobID     string   `json:"jobId,omitempty"`
+	Problems  []string `json:"problems,omitempty"`
+}
+
+// PublishScheduleInfoFromDB converts a stored schedule, nil for none.
+func PublishScheduleInfoFromDB(s *db.PublishSchedule) *PublishScheduleInfo {
+	if s == nil {
+		return nil
+	}
+	info := &PublishScheduleInfo{
+		PublishAt: s.At.UTC().Format("2006-01-02T15:04:05Z"),
+		JobID:     s.JobID,
+		Problems:  s.Problems,
+	}
+	if !s.FiredAt.IsZero() {
+		info.FiredAt = s.FiredAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return info
+}
+
+// SubmitReviewRequest is the optional body of POST /processes/{processId}/review. UpdatedAt, as read
+// from GET /processes/{processId}, makes the submission conditional on the draft not having been
+// written since; omitted, the draft as stored is submitted.
+type SubmitReviewRequest struct {
+	UpdatedAt string `json:"updatedAt,omitempty"`
+}
+
+// ReviewDecisionRequest is the body of POST /processes/{processId}/review/approve and .../reject.
+// The comment is required to reject.
+type ReviewDecisionRequest struct {
+	Comment string `json:"comment,omitempty"`
+}
+
+// ProcessReviewInfo is the review of a draft: pending once submitted, then approved or rejected by a
+// reviewer with a comment.
+type ProcessReviewInfo struct {
+	State       string `json:"state"`
+	SubmittedBy uint64 `json:"submittedBy"`
+	SubmittedAt string `json:"submittedAt"`
+	ReviewedBy  uint64 `json:"reviewedBy,omitempty"`
+	ReviewedAt  string `json:"reviewedAt,omitempty"`
+	Comment     string `json:"comment,omitempty"`
+}
+
+// ProcessReviewInfoFromDB converts a stored review, nil for none.
+func ProcessReviewInfoFromDB(r *db.ProcessReview) *ProcessReviewInfo {
+	if r == nil {
+		return nil
+	}
+	info := &ProcessReviewInfo{
+		State:       r.State,
+		SubmittedBy: r.SubmittedBy,
+		SubmittedAt: r.SubmittedAt.UTC().Format("2006-01-02T15:04:05Z"),
+		ReviewedBy:  r.ReviewedBy,
+		Comment:     r.Comment,
+	}
+	if !r.ReviewedAt.IsZero() {
+		info.ReviewedAt = r.ReviewedAt.UTC().Format("2006-01-02T15:04:05Z")
+	}
+	return info
+}
+
+// ScheduledPublish is one draft of an organization with a scheduled publish.
+type ScheduledPublish struct {
+	ProcessID       string               `json:"processId"`
+	Title           db.MultiLangString   `json:"title"`
+	PublishSchedule *PublishScheduleInfo `json:"publishSchedule"`
+}
+
+// ScheduledPublishesResponse is a page of the scheduled publishes of an organization.
+type ScheduledPublishesResponse struct {
+	Pagination *Pagination        `json:"pagination"`
+	Processes  []ScheduledPublish `json:"processes"`
+}
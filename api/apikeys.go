@@ -22,6 +22,7 @@ const (
 	ScopeManagedWrite = "managed:write" // create managed organizations
 	ScopeVotingWrite  = "voting:write"  // create/publish processes, censuses and bundles
 	ScopeMembersWrite = "members:write" // manage members and groups
+	ScopeMembersSCIM  = "members:scim"  // provision members and groups over SCIM 2.0
 )
 
 // AllAPIKeyScopes is the canonical set of assignable scopes, used to validate key creation.
@@ -31,6 +32,7 @@ var AllAPIKeyScopes = []string{
 	ScopeManagedWrite,
 	ScopeVotingWrite,
 	ScopeMembersWrite,
+	ScopeMembersSCIM,
 }
 
 // IsValidAPIKeyScope reports whether s is a known, assignable scope.
@@ -60,6 +62,8 @@ var apiKeyAllowlist = map[string]string{
 	"POST " + organizationAddMembersEndpoint:      ScopeMembersWrite,
 	"PUT " + organizationUpsertMemberEndpoint:     ScopeMembersWrite,
 	"DELETE " + organizationDeleteMembersEndpoint: ScopeMembersWrite,
+	"GET " + organizationMemberDuplicatesEndpoint: ScopeMembersWrite,
+	"POST " + organizationMergeMembersEndpoint:    ScopeMembersWrite,
 	"POST " + organizationGroupsEndpoint:          ScopeMembersWrite,
 	"GET " + organizationGroupsEndpoint:           ScopeMembersWrite,
 	"GET " + organizationGroupEndpoint:            ScopeMembersWrite,
@@ -68,6 +72,55 @@ var apiKeyAllowlist = map[string]string{
 	"GET " + organizationGroupMembersEndpoint:     ScopeMembersWrite,
 	"POST " + organizationGroupValidateEndpoint:   ScopeMembersWrite,
 
+	// member self-service portal config and its approval queue
+	"GET " + organizationMemberSelfServiceEndpoint: ScopeMembersWrite,
+	"PUT " + organizationMemberSelfServiceEndpoint: ScopeMembersWrite,
+	"GET " + organizationMemberUpdatesEndpoint:     ScopeMembersWrite,
+	"PUT " + organizationMemberUpdateEndpoint:      ScopeMembersWrite,
+
+	// member change history
+	"GET " + organizationMemberHistoryEndpoint: ScopeMembersWrite,
+
+	// structured member search
+	"POST " + organizationMemberSearchEndpoint: ScopeMembersWrite,
+
+	// member groups built from set operations on groups
+	"POST " + organizationGroupsCombineEndpoint: ScopeMembersWrite,
+
+	// communication suppression list and member unsubscribe links
+	"GET " + organizationSuppressionsEndpoint:          ScopeMembersWrite,
+	"POST " + organizationSuppressionsEndpoint:         ScopeMembersWrite,
+	"DELETE " + organizationSuppressionsEndpoint:       ScopeMembersWrite,
+	"GET " + organizationMemberUnsubscribeLinkEndpoint: ScopeMembersWrite,
+
+	// SCIM 2.0 provisioning of members and groups
+	"GET " + scimServiceProviderConfigEndpoint: ScopeMembersSCIM,
+	"GET " + scimUsersEndpoint:                 ScopeMembersSCIM,
+	"POST " + scimUsersEndpoint:                ScopeMembersSCIM,
+	"GET " + scimUserEndpoint:                  ScopeMembersSCIM,
+	"PUT " + scimUserEndpoint:                  ScopeMembersSCIM,
+	"PATCH " + scimUserEndpoint:                ScopeMembersSCIM,
+	"DELETE " + scimUserEndpoint:               ScopeMembersSCIM,
+	"GET " + scimGroupsEndpoint:                ScopeMembersSCIM,
+	"POST " + scimGroupsEndpoint:               ScopeMembersSCIM,
+	"GET " + scimGroupEndpoint:                 ScopeMembersSCIM,
+	"PUT " + scimGroupEndpoint:                 ScopeMembersSCIM,
+	"PATCH " + scimGroupEndpoint:               ScopeMembersSCIM,
+	"DELETE " + scimGroupEndpoint:              ScopeMembersSCIM,
+	"POST " + scimBulkEndpoint:                 ScopeMembersSCIM,
+
+	// voting process templates
+	"GET " + organizationProcessTemplatesEndpoint:          ScopeVotingWrite,
+	"POST " + organizationProcessTemplatesEndpoint:         ScopeVotingWrite,
+	"GET " + organizationProcessTemplateEndpoint:           ScopeVotingWrite,
+	"DELETE " + organizationProcessTemplateEndpoint:        ScopeVotingWrite,
+	"POST " + organizationProcessTemplateProcessesEndpoint: ScopeVotingWrite,
+
+	// scheduled publishes of voting processes
+	"GET " + organizationScheduledPublishesEndpoint: ScopeVotingWrite,
+	"PUT " + processesScheduleEndpoint:              ScopeVotingWrite,
+	"DELETE " + processesScheduleEndpoint:           ScopeVotingWrite,
+
 	// voting: processes, censuses, bundles (for managed organizations)
 	"POST " + processCreateEndpoint:                ScopeVotingWrite,
 	"DELETE " + processEndpoint:                    ScopeVotingWrite,
@@ -80,11 +133,16 @@ var apiKeyAllowlist = map[string]string{
 	"POST " + censusPublishEndpoint:                ScopeVotingWrite,
 	"POST " + censusGroupPublishEndpoint:           ScopeVotingWrite,
 	"POST " + censusIDEndpoint:                     ScopeVotingWrite,
+	"GET " + censusSnapshotsEndpoint:               ScopeVotingWrite,
+	"GET " + censusSnapshotsDiffEndpoint:           ScopeVotingWrite,
+	"POST " + censusExportEndpoint:                 ScopeVotingWrite,
+	"GET " + censusExportJobEndpoint:               ScopeVotingWrite,
 	"POST " + processBundleEndpoint:                ScopeVotingWrite,
 	"PUT " + processBundleUpdateEndpoint:           ScopeVotingWrite,
 	// multi-question voting processes (writes; the GET list/single reads are public — a voting:write
 	// key still unlocks drafts + eligibility there, resolved in-handler via optionalManager, not here)
 	"POST " + processesCreateEndpoint:         ScopeVotingWrite,
+	"POST " + processesCloneEndpoint:          ScopeVotingWrite,
 	"PUT " + processesEndpoint:                ScopeVotingWrite,
 	"GET " + processesValidateEndpoint:        ScopeVotingWrite,
 	"POST " + processesPublishEndpoint:        ScopeVotingWrite,
@@ -92,7 +150,11 @@ var apiKeyAllowlist = map[string]string{
 	"PUT " + processesQuestionStatusEndpoint:  ScopeVotingWrite,
 	"PUT " + processesCensusEndpoint:          ScopeVotingWrite,
 	"DELETE " + processesCensusEndpoint:       ScopeVotingWrite,
+	"POST " + processesCensusImportEndpoint:   ScopeVotingWrite,
 	"PUT " + processesQuestionCensusEndpoint:  ScopeVotingWrite,
+	"GET " + processesDelegationsEndpoint:     ScopeVotingWrite,
+	"POST " + processesDelegationsEndpoint:    ScopeVotingWrite,
+	"DELETE " + processesDelegationEndpoint:   ScopeVotingWrite,
 }
 
 // requiredScopeForRoute returns the scope required to call (method, pattern) with an API key and
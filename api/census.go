@@ -128,6 +128,7 @@ func (a *API) censusInfoHandler(w http.ResponseWriter, r *http.Request) {
 //	@Failure		401		{object}	errors.Error							"Unauthorized"
 //	@Failure		403		{object}	errors.Error							"Plan census quota exceeded"
 //	@Failure		404		{object}	errors.Error							"Census not found"
+//	@Failure		409		{object}	errors.Error							"Census is frozen"
 //	@Failure		500		{object}	errors.Error							"Internal server error"
 //	@Deprecated
 //	@Router	/census/{id} [post]
@@ -193,11 +194,17 @@ func (a *API) addCensusParticipantsHandler(w http.ResponseWriter, r *http.Reques
 	// TODO return as error the failed memberIDs
 	switch {
 	case err == nil:
+		// growing a published census changes what was published, so it is recorded as a new snapshot
+		if added > 0 && len(census.Published.Root) > 0 {
+			a.recordCensusSnapshot(census)
+		}
 		apicommon.HTTPWriteJSON(w, &apicommon.AddMembersResponse{Added: uint32(added), Errors: membersErrors})
 	case stderrors.Is(err, db.ErrInvalidData), stderrors.Is(err, db.ErrUpdateWouldCreateDuplicates):
 		errors.ErrInvalidData.WithErr(err).Write(w)
 	case stderrors.Is(err, db.ErrNotFound):
 		errors.ErrCensusNotFound.Write(w)
+	case stderrors.Is(err, db.ErrCensusFrozen):
+		errors.ErrCensusFrozen.Write(w)
 	default:
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 	}
@@ -277,6 +284,7 @@ func (a *API) publishCensusHandler(w http.ResponseWriter, r *http.Request) {
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
+	a.recordCensusSnapshot(census)
 
 	apicommon.HTTPWriteJSON(w, &apicommon.PublishedCensusResponse{
 		URI:  census.Published.URI,
@@ -377,6 +385,11 @@ func (a *API) publishCensusGroupHandler(w http.ResponseWriter, r *http.Request)
 
 	inserted, err := a.db.PopulateGroupCensus(census, groupID.String())
 	if err != nil {
+		// members that cannot join, listed by id
+		if stderrors.Is(err, db.ErrInvalidData) {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
@@ -406,6 +419,7 @@ func (a *API) publishCensusGroupHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
+	a.recordCensusSnapshot(census)
 
 	apicommon.HTTPWriteJSON(w, &apicommon.PublishedCensusResponse{
 		URI:  census.Published.URI,
@@ -0,0 +1,124 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"go.vocdoni.io/dvote/log"
+)
+
+// censusExportHandler godoc
+//
+//	@Summary		Export a published census for auditors
+//	@Description	Start building the signed auditor export of a published census. The export holds
+//	@Description	the census root, URI, size and total weight, and the participants as keys in
+//	@Description	ascending order with their weights: no member data. A key is the sha256 of the
+//	@Description	participant's login hash, and the export names the fields that hash is computed
+//	@Description	from. The CSP key published as the census root signs the export, so it can be
+//	@Description	verified offline (`cli --verifyCensusExport`). The export is built by a job: poll
+//	@Description	GET /jobs/{jobId}, then download it from GET /census/{id}/export/{jobId}.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			census
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id	path		string	true	"Census ID"
+//	@Success		202	{object}	apicommon.EnqueuedResponse	"Export accepted; poll GET /jobs/{jobId}"
+//	@Failure		400	{object}	errors.Error				"Invalid census ID"
+//	@Failure		401	{object}	errors.Error				"Unauthorized"
+//	@Failure		404	{object}	errors.Error				"Census not found"
+//	@Failure		409	{object}	errors.Error				"Census not published"
+//	@Failure		500	{object}	errors.Error				"Internal server error"
+//	@Router			/census/{id}/export [post]
+func (a *API) censusExportHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.managedCensusFromRequest(w, r)
+	if !ok {
+		return
+	}
+	if len(census.Published.Root) == 0 {
+		errors.ErrCensusNotPublished.Write(w)
+		return
+	}
+	jobID, err := apicommon.NewJobID()
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if err := a.db.CreateTxJob(jobID, db.JobTypeCensusExport, census.OrgAddress); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	go func() {
+		status, errMsg := db.JobStatusCompleted, ""
+		if err := a.exportCensus(jobID, census); err != nil {
+			log.Warnw("could not export census", "census", census.ID.Hex(), "jobId", jobID, "error", err)
+			status, errMsg = db.JobStatusFailed, err.Error()
+		}
+		if err := a.db.SetJobStatus(jobID, status, nil, errMsg); err != nil {
+			log.Warnw("failed to persist job status", "error", err, "jobId", jobID)
+		}
+	}()
+	apicommon.HTTPWriteJSONStatus(w, http.StatusAccepted, &apicommon.EnqueuedResponse{JobID: jobID})
+}
+
+// exportCensus builds, signs and stores the export of census under jobID.
+func (a *API) exportCensus(jobID string, census *db.Census) error {
+	// only the key published as the root can vouch for the census
+	rootKey, err := a.csp.PubKey()
+	if err != nil {
+		return fmt.Errorf("could not get the CSP public key: %w", err)
+	}
+	if !bytes.Equal(rootKey, census.Published.Root) {
+		return fmt.Errorf("the census root is not the CSP key, so the CSP cannot sign its export")
+	}
+	export, err := a.db.BuildCensusExport(jobID, census)
+	if err != nil {
+		return err
+	}
+	if export.Signature, err = a.csp.SignMessage(export.SignedMessage()); err != nil {
+		return fmt.Errorf("could not sign the census export: %w", err)
+	}
+	return a.db.SetCensusExport(export)
+}
+
+// censusExportDownloadHandler godoc
+//
+//	@Summary		Download a census export
+//	@Description	Download the signed auditor export built by a POST /census/{id}/export job. It is
+//	@Description	not found until the job completes. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			census
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Census ID"
+//	@Param			jobId	path		string	true	"Export job ID"
+//	@Success		200		{object}	db.CensusExport
+//	@Failure		400		{object}	errors.Error	"Invalid census ID"
+//	@Failure		401		{object}	errors.Error	"Unauthorized"
+//	@Failure		404		{object}	errors.Error	"Census or export not found"
+//	@Failure		500		{object}	errors.Error	"Internal server error"
+//	@Router			/census/{id}/export/{jobId} [get]
+func (a *API) censusExportDownloadHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.managedCensusFromRequest(w, r)
+	if !ok {
+		return
+	}
+	export, err := a.db.CensusExport(census.ID.Hex(), chi.URLParam(r, "jobId"))
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			errors.ErrCensusExportNotFound.Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=census-%s-export.json", census.ID.Hex()))
+	apicommon.HTTPWriteJSON(w, export)
+}
@@ -0,0 +1,40 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestCensusExport(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	members := memberIDs(postOrgMembers(t, token, orgAddress, newOrgMembers(3)...))
+	censusID := postCensus(t, token, orgAddress, db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber}, twoFaEmail)
+	postCensusParticipants(t, token, censusID, members...)
+
+	// only a published census has a root to vouch for it
+	requestAndAssertError(errors.ErrCensusNotPublished, t, http.MethodPost, token, nil, censusEndpoint, censusID, "export")
+	requestAndAssertCode(http.StatusOK, t, http.MethodPost, token, nil, censusEndpoint, censusID, "publish")
+
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, censusEndpoint, censusID, "export")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("export job errors: %v", job.Errors))
+	export := requestAndParse[db.CensusExport](t, http.MethodGet, token, nil, censusEndpoint, censusID, "export", job.JobID)
+	c.Assert(export.Size, qt.Equals, int64(3))
+	c.Assert(export.TotalWeight, qt.Equals, uint64(3))
+	c.Assert(export.Hashing.AuthFields, qt.DeepEquals, db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber})
+	c.Assert(export.Verify(), qt.IsNil)
+
+	// a tampered export no longer verifies
+	export.Participants[0].Weight = 2
+	c.Assert(export.Verify(), qt.IsNotNil)
+
+	requestAndAssertError(errors.ErrCensusExportNotFound, t, http.MethodGet, token, nil,
+		censusEndpoint, censusID, "export", "deadbeef")
+	otherToken := testCreateUser(t, "otherpassword123")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPost, otherToken, nil, censusEndpoint, censusID, "export")
+}
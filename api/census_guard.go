@@ -119,6 +119,22 @@ func (a *API) refuseBlockedVoters(w http.ResponseWriter, censusIDs, memberIDs []
 	return true
 }
 
+// refuseFrozenCensuses answers 409 when any of the censuses is frozen, and reports whether it did.
+// A frozen census keeps the participants its commitment was computed over, so a removal touching
+// it is refused as a whole, like refuseBlockedVoters, and must likewise be called before any write.
+func (a *API) refuseFrozenCensuses(w http.ResponseWriter, censusIDs []string) bool {
+	frozen, err := a.db.FrozenCensusIDs(censusIDs)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return true
+	}
+	if len(frozen) == 0 {
+		return false
+	}
+	errors.ErrCensusFrozen.WithData(map[string]any{"frozenCensusIds": frozen}).Write(w)
+	return true
+}
+
 // refuseVotersLosingEligibility answers 409 when restricting a question to allowed would take the
 // vote away from a member the CSP has already signed for, and reports whether it did.
 //
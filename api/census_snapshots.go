@@ -0,0 +1,154 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.vocdoni.io/dvote/log"
+)
+
+// recordCensusSnapshot records the state of a census that was just published or grown. The
+// publication already happened, so a failure is logged rather than returned; the next
+// publication records the changes missed.
+func (a *API) recordCensusSnapshot(census *db.Census) {
+	if _, err := a.db.CreateCensusSnapshot(census); err != nil {
+		log.Warnw("could not record census snapshot", "census", census.ID.Hex(), "error", err)
+	}
+}
+
+// managedCensusFromRequest loads the census of the {id} URL param and checks the user is a
+// Manager/Admin of its organization. On failure it writes the error and returns false.
+func (a *API) managedCensusFromRequest(w http.ResponseWriter, r *http.Request) (*db.Census, bool) {
+	censusID := internal.HexBytes{}
+	if err := censusID.ParseString(chi.URLParam(r, "id")); err != nil {
+		errors.ErrMalformedURLParam.Withf("wrong census ID").Write(w)
+		return nil, false
+	}
+	census, err := a.db.Census(censusID.String())
+	if err != nil {
+		if err == db.ErrNotFound {
+			errors.ErrCensusNotFound.Write(w)
+			return nil, false
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return nil, false
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return nil, false
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(census.OrgAddress, db.ManagerRole) && !user.HasRoleFor(census.OrgAddress, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user does not have the necessary permissions in the organization").Write(w)
+		return nil, false
+	}
+	return census, true
+}
+
+// censusSnapshotsHandler godoc
+//
+//	@Summary		List the snapshots of a census
+//	@Description	List the immutable snapshots recorded each time the census was published or grown
+//	@Description	(POST /census/{id}/publish, POST /census/{id}/group/{groupId}/publish, POST
+//	@Description	/processes/{processId}/publish and PUT /processes/{processId}/census), newest first.
+//	@Description	Each one holds the published root, the size, the total weight and a digest of its
+//	@Description	hashed participant list. A publication that changed nothing records no new version.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			census
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Census ID"
+//	@Param			page	query		integer	false	"Page number (default: 1)"
+//	@Param			limit	query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200		{object}	apicommon.CensusSnapshotsResponse
+//	@Failure		400		{object}	errors.Error	"Invalid input data"
+//	@Failure		401		{object}	errors.Error	"Unauthorized"
+//	@Failure		404		{object}	errors.Error	"Census not found"
+//	@Failure		500		{object}	errors.Error	"Internal server error"
+//	@Router			/census/{id}/snapshots [get]
+func (a *API) censusSnapshotsHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.managedCensusFromRequest(w, r)
+	if !ok {
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, snapshots, err := a.db.CensusSnapshots(census.ID.Hex(), params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	if snapshots == nil {
+		snapshots = []*db.CensusSnapshot{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.CensusSnapshotsResponse{
+		Pagination: pagination,
+		Snapshots:  snapshots,
+	})
+}
+
+// censusSnapshotsDiffHandler godoc
+//
+//	@Summary		Diff two snapshots of a census
+//	@Description	List the participants added, removed and re-weighted between two snapshots of a
+//	@Description	census. Participants are identified by the sha256 of `{censusId}/{memberId}`, so an
+//	@Description	auditor can trace a known member without the list disclosing who is in it. `from`
+//	@Description	may be 0, the empty census, to list every participant of `to` as added; it may also
+//	@Description	be newer than `to`. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			census
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			id		path		string	true	"Census ID"
+//	@Param			from	query		integer	true	"Version to diff from (0 for the empty census)"
+//	@Param			to		query		integer	true	"Version to diff to"
+//	@Success		200		{object}	db.CensusSnapshotDiff
+//	@Failure		400		{object}	errors.Error	"Invalid input data"
+//	@Failure		401		{object}	errors.Error	"Unauthorized"
+//	@Failure		404		{object}	errors.Error	"Census or snapshot not found"
+//	@Failure		500		{object}	errors.Error	"Internal server error"
+//	@Router			/census/{id}/snapshots/diff [get]
+func (a *API) censusSnapshotsDiffHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.managedCensusFromRequest(w, r)
+	if !ok {
+		return
+	}
+	from, err := strconv.ParseInt(r.URL.Query().Get("from"), 10, 64)
+	if err != nil || from < 0 {
+		errors.ErrMalformedURLParam.Withf("invalid from version").Write(w)
+		return
+	}
+	to, err := strconv.ParseInt(r.URL.Query().Get("to"), 10, 64)
+	if err != nil || to < 1 {
+		errors.ErrMalformedURLParam.Withf("invalid to version").Write(w)
+		return
+	}
+	diff, err := a.db.DiffCensusSnapshots(census.ID.Hex(), from, to)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			errors.ErrCensusSnapshotNotFound.Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, diff)
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestCensusSnapshots(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	members := memberIDs(postOrgMembers(t, token, orgAddress, newOrgMembers(3)...))
+	censusID := postCensus(t, token, orgAddress, db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber}, twoFaEmail)
+	snapshotsURL := []string{censusEndpoint, censusID, "snapshots"}
+
+	// a draft census has no snapshot yet
+	postCensusParticipants(t, token, censusID, members[:2]...)
+	resp := requestAndParse[apicommon.CensusSnapshotsResponse](t, http.MethodGet, token, nil, snapshotsURL...)
+	c.Assert(resp.Snapshots, qt.HasLen, 0)
+
+	requestAndAssertCode(http.StatusOK, t, http.MethodPost, token, nil, censusEndpoint, censusID, "publish")
+	// growing the published census records a second snapshot, an idempotent republish does not
+	postCensusParticipants(t, token, censusID, members[2])
+	requestAndAssertCode(http.StatusOK, t, http.MethodPost, token, nil, censusEndpoint, censusID, "publish")
+	resp = requestAndParse[apicommon.CensusSnapshotsResponse](t, http.MethodGet, token, nil, snapshotsURL...)
+	c.Assert(resp.Snapshots, qt.HasLen, 2)
+	c.Assert(resp.Snapshots[0].Version, qt.Equals, int64(2))
+	c.Assert(resp.Snapshots[0].Size, qt.Equals, int64(3))
+	c.Assert(resp.Snapshots[1].Size, qt.Equals, int64(2))
+	c.Assert(resp.Snapshots[1].Root, qt.Not(qt.HasLen), 0)
+
+	diff := requestAndParse[db.CensusSnapshotDiff](t, http.MethodGet, token, nil,
+		censusEndpoint, censusID, "snapshots", "diff?from=1&to=2")
+	c.Assert(diff.Added, qt.HasLen, 1)
+	c.Assert(diff.Removed, qt.HasLen, 0)
+	c.Assert(diff.Reweighted, qt.HasLen, 0)
+	diff = requestAndParse[db.CensusSnapshotDiff](t, http.MethodGet, token, nil,
+		censusEndpoint, censusID, "snapshots", "diff?from=0&to=1")
+	c.Assert(diff.Added, qt.HasLen, 2)
+
+	requestAndAssertError(errors.ErrCensusSnapshotNotFound, t, http.MethodGet, token, nil,
+		censusEndpoint, censusID, "snapshots", "diff?from=1&to=3")
+	requestAndAssertError(errors.ErrMalformedURLParam, t, http.MethodGet, token, nil,
+		censusEndpoint, censusID, "snapshots", "diff?from=1")
+
+	// another user's census is out of reach
+	otherToken := testCreateUser(t, "otherpassword123")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, otherToken, nil, snapshotsURL...)
+}
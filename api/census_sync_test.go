@@ -1090,3 +1090,55 @@ func TestRemoveProcessCensusIsOrgScoped(t *testing.T) {
 	c.Assert(code, qt.Equals, http.StatusOK,
 		qt.Commentf("another organization deleted this voter's CSP auth session"))
 }
+
+// TestCensusFrozenOnPublish pins that a census asking to be frozen is frozen by the publication,
+// with a public commitment, and that every path changing its participants is refused before writing.
+func TestCensusFrozenOnPublish(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(3)...)
+	ids := memberIDs(members)
+
+	req := newVotingProcessRequest(orgAddress, ids[:2])
+	req.Census.FreezeOnPublish = true
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint,
+	)
+	draft := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(draft.Census.FreezeOnPublish, qt.IsTrue)
+	c.Assert(draft.Census.Frozen, qt.IsNil)
+
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", created.ProcessID, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+
+	// the commitment is public, and matches the snapshot of the publication
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, "", nil, "processes", created.ProcessID)
+	c.Assert(got.Census.Frozen, qt.Not(qt.IsNil))
+	c.Assert(got.Census.Frozen.Size, qt.Equals, int64(2))
+	vp, err := testDB.VotingProcess(objectID(c, created.ProcessID))
+	c.Assert(err, qt.IsNil)
+	censusID := vp.CensusID.Hex()
+	_, snapshots, err := testDB.CensusSnapshots(censusID, 1, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(snapshots, qt.HasLen, 1)
+	c.Assert(got.Census.Frozen.Commitment, qt.DeepEquals, snapshots[0].Digest)
+
+	requestAndAssertError(errors.ErrCensusFrozen, t, http.MethodPut, token,
+		&apicommon.AddCensusParticipantsRequest{MemberIDs: ids[2:]}, "processes", created.ProcessID, "census")
+	requestAndAssertError(errors.ErrCensusFrozen, t, http.MethodDelete, token,
+		&apicommon.AddCensusParticipantsRequest{MemberIDs: ids[:1]}, "processes", created.ProcessID, "census")
+	requestAndAssertError(errors.ErrCensusFrozen, t, http.MethodPost, token,
+		&apicommon.AddCensusParticipantsRequest{MemberIDs: ids[2:]}, "census", censusID)
+	requestAndAssertError(errors.ErrCensusFrozen, t, http.MethodDelete, token,
+		&apicommon.DeleteMembersRequest{IDs: ids[:1]}, "organizations", orgAddress.String(), "members")
+
+	// nothing changed, and a member outside the census is still deleted
+	count, err := testDB.CountCensusParticipants(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(2))
+	del := requestAndParse[apicommon.DeleteMembersResponse](t, http.MethodDelete, token,
+		&apicommon.DeleteMembersRequest{IDs: ids[2:]}, "organizations", orgAddress.String(), "members")
+	c.Assert(del.Count, qt.Equals, 1)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"path"
 	"slices"
 	"strings"
 	"time"
@@ -219,3 +220,18 @@ func (*API) setLang(next http.Handler) http.Handler {
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// unlessStreaming applies mw to every request but those of the live process streams (see
+// votingProcessStreamHandler), which pass straight to the next handler.
+func unlessStreaming(mw func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		wrapped := mw(next)
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if streaming, _ := path.Match(streamPathPattern, r.URL.Path); streaming && r.Method == http.MethodGet {
+				next.ServeHTTP(w, r)
+				return
+			}
+			wrapped.ServeHTTP(w, r)
+		})
+	}
+}
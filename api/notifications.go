@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/vocdoni/saas-backend/db"
 	"github.com/vocdoni/saas-backend/internal"
 	"github.com/vocdoni/saas-backend/notifications"
 	"github.com/vocdoni/saas-backend/notifications/mailtemplates"
@@ -18,7 +19,51 @@ import (
 // (e.g. an OTP code) is stale and the queue must not deliver it.
 // If the notify queue is not configured (notifyQueue is nil), it returns an error.
 // Returns an error only for missing queue configuration, invalid email addresses, or template failures.
+// Campaign templates are refused: they go through sendCampaignMail.
 func (a *API) sendMail(ctx context.Context, to string, mail mailtemplates.MailTemplate, data any, expiresAt time.Time) error {
+	if mail.Campaign {
+		return fmt.Errorf("campaign template %s must be sent through sendCampaignMail", mail.File)
+	}
+	return a.deliverMail(ctx, to, mail, data, expiresAt)
+}
+
+// campaignMailData is what a campaign template is executed with: the data of
+// the message, and the signed link that unsubscribes its recipient.
+type campaignMailData struct {
+	Data           any
+	UnsubscribeURL string
+}
+
+// sendCampaignMail is the send gate of campaign templates: every non-transactional
+// email to an organization member goes through it. It sends nothing, and returns
+// false, when the member refused email or their address is on the suppression list
+// of the organization; otherwise it sends the template with the member's signed
+// unsubscribe link. One-time codes and other transactional mail go through sendMail,
+// so a member who unsubscribed can still log in to vote.
+func (a *API) sendCampaignMail(ctx context.Context, member *db.OrgMember, mail mailtemplates.MailTemplate, data any,
+) (bool, error) {
+	if !mail.Campaign {
+		return false, fmt.Errorf("template %s is transactional", mail.File)
+	}
+	allowed, err := a.db.CampaignRecipientAllowed(member, db.MessageChannelEmail)
+	if err != nil || !allowed {
+		return false, err
+	}
+	target := &db.SuppressionTarget{Channel: db.MessageChannelEmail, Email: member.Email}
+	token, err := a.signUnsubscribeToken(member.OrgAddress, target)
+	if err != nil {
+		return false, err
+	}
+	data = &campaignMailData{Data: data, UnsubscribeURL: a.unsubscribeURL(token)}
+	if err := a.deliverMail(ctx, member.Email, mail, data, time.Time{}); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// deliverMail executes a template and enqueues it, without checking whether it
+// is transactional.
+func (a *API) deliverMail(ctx context.Context, to string, mail mailtemplates.MailTemplate, data any, expiresAt time.Time) error {
 	if a.notifyQueue == nil {
 		return fmt.Errorf("no notification queue configured")
 	}
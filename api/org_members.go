@@ -147,6 +147,13 @@ func (a *API) organizationMembersHandler(w http.ResponseWriter, r *http.Request)
 //	@Description	plan's member quota. With `async=true` the import runs in the background and the response
 //	@Description	carries a `jobId` to poll via GET /jobs/{jobId}; otherwise it
 //	@Description	completes synchronously. An empty members list is a no-op that returns added=0.
+//	@Description	Rows with invalid values are imported without them, and reported in `errors`. With
+//	@Description	`upsert=true` a row matching an existing member by memberNumber, or else by email, updates
+//	@Description	it instead of adding a duplicate (counted in `updated`), and a row writing a member an
+//	@Description	earlier row already writes is rejected. With `dryRun=true` nothing is written: the
+//	@Description	response is an AddMembersPreviewResponse counting the rows the same import would
+//	@Description	insert, update and reject, with the field changes, warnings and rejection reasons,
+//	@Description	and the plan quota impact.
 //	@Description
 //	@Description	Also callable with a scoped API key (scope: `members:write`).
 //	@Tags			organizations
@@ -155,6 +162,8 @@ func (a *API) organizationMembersHandler(w http.ResponseWriter, r *http.Request)
 //	@Security		BearerAuth
 //	@Param			orgAddress	path		string							true	"Organization address"
 //	@Param			async		query		boolean							false	"Process asynchronously and return job ID"
+//	@Param			upsert		query		boolean							false	"Update the matched members instead of adding them"
+//	@Param			dryRun		query		boolean							false	"Only preview the import"
 //	@Param			request		body		apicommon.AddMembersRequest		true	"Members to add"
 //	@Success		200			{object}	apicommon.AddMembersResponse	"Added count (sync) or jobId (async)"
 //	@Failure		400			{object}	errors.Error					"Invalid input data"
@@ -191,13 +200,23 @@ func (a *API) addOrganizationMembersHandler(w http.ResponseWriter, r *http.Reque
 		errors.ErrMalformedBody.Withf("missing members").Write(w)
 		return
 	}
+	// the dry run reports the same plan the import carries out
+	plan, err := a.db.PlanBulkOrgMembers(org, members.ToDB(), passwordSalt, r.URL.Query().Get("upsert") == "true")
+	if err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not plan members import: %v", err).Write(w)
+		return
+	}
+	if r.URL.Query().Get("dryRun") == "true" {
+		a.previewOrganizationMembersImport(w, org, plan)
+		return
+	}
 	// check if there are members to add
 	if len(members.Members) == 0 {
 		apicommon.HTTPWriteJSON(w, &apicommon.AddMembersResponse{Added: 0})
 		return
 	}
-	// check if the new members pass the organization members limit
-	if err := a.subscriptions.OrgCanAddNMembers(org.Address, len(members.Members)); err != nil {
+	// check if the new members pass the organization members limit; updates add nobody
+	if err := a.subscriptions.OrgCanAddNMembers(org.Address, plan.Inserts); err != nil {
 		if apiErr := (errors.Error{}); errors.As(err, &apiErr) {
 			apiErr.Write(w)
 			return
@@ -215,13 +234,21 @@ func (a *API) addOrganizationMembersHandler(w http.ResponseWriter, r *http.Reque
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
-	if err := a.preflightCensusGrowth(org, autoCensuses, len(members.Members)); err != nil {
+	if err := a.preflightCensusGrowth(org, autoCensuses, plan.Inserts); err != nil {
 		writeSubscriptionError(w, err)
 		return
 	}
 
+	// the async job id is drawn before the import starts, so the member history can name it
+	source := memberChangeSource(r, user, db.MemberChangeViaImport)
+	var jobID internal.HexBytes
+	if async {
+		jobID = internal.HexBytes(util.RandomBytes(16))
+		source.JobID = jobID.String()
+	}
+
 	// add the org members to the database
-	progressChan, err := a.db.AddBulkOrgMembers(org, members.ToDB(), passwordSalt)
+	progressChan, err := a.db.ImportBulkOrgMembers(plan, source)
 	if err != nil {
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
@@ -247,15 +274,13 @@ func (a *API) addOrganizationMembersHandler(w http.ResponseWriter, r *http.Reque
 		// Return the number of members added
 		apicommon.HTTPWriteJSON(w, &apicommon.AddMembersResponse{
 			Added:        uint32(lastProgress.Added),
+			Updated:      uint32(lastProgress.Updated),
 			Errors:       append(lastProgress.ErrorsAsStrings(), propagated.Errors...),
 			CensusJobIDs: propagated.JobIDs,
 		})
 		return
 	}
 
-	// if async create a new job identifier
-	jobID := internal.HexBytes(util.RandomBytes(16))
-
 	// Create persistent job record
 	if err := a.db.CreateJob(jobID.String(), db.JobTypeOrgMembers, org.Address, len(members.Members)); err != nil {
 		log.Warnw("failed to create persistent job record", "error", err, "jobId", jobID.String())
@@ -298,6 +323,30 @@ func (a *API) addOrganizationMembersHandler(w http.ResponseWriter, r *http.Reque
 	apicommon.HTTPWriteJSON(w, &apicommon.AddMembersResponse{JobID: jobID})
 }
 
+// previewOrganizationMembersImport writes what carrying out an import plan would do, and whether the
+// plan's member quota admits the members it would add, without writing anything.
+func (a *API) previewOrganizationMembersImport(w http.ResponseWriter, org *db.Organization,
+	preview *db.OrgMemberImportPlan,
+) {
+	quota := apicommon.MembersQuotaImpact{NewMembers: preview.Inserts, Allowed: true}
+	if err := a.subscriptions.OrgCanAddNMembers(org.Address, preview.Inserts); err != nil {
+		// only a quota refusal is part of the preview; failing to evaluate it is not
+		apiErr := errors.Error{}
+		if !errors.As(err, &apiErr) || apiErr.Code != errors.ErrExceedsOrganizationMembersLimit.Code {
+			writeSubscriptionError(w, err)
+			return
+		}
+		quota.Allowed, quota.Error = false, err.Error()
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.AddMembersPreviewResponse{
+		Insert: preview.Inserts,
+		Update: preview.Updates,
+		Reject: preview.Rejects,
+		Rows:   preview.Rows,
+		Quota:  quota,
+	})
+}
+
 // upsertOrganizationMemberHandler godoc
 //
 //	@Summary		Create or update an organization member
@@ -378,7 +427,8 @@ func (a *API) upsertOrganizationMemberHandler(w http.ResponseWriter, r *http.Req
 	}
 
 	// upsert the member in the database
-	memberID, created, err := a.db.UpsertOrgMemberAndCensusParticipants(org, member.ToDB(), passwordSalt)
+	memberID, created, err := a.db.UpsertOrgMemberAndCensusParticipants(org, member.ToDB(), passwordSalt,
+		memberChangeSource(r, user, db.MemberChangeViaAPI))
 	switch {
 	case errors.Is(err, db.ErrUpdateWouldCreateDuplicates):
 		errors.ErrInvalidData.WithErr(err).Write(w)
@@ -427,7 +477,7 @@ func (a *API) upsertOrganizationMemberHandler(w http.ResponseWriter, r *http.Req
 //	@Success		200			{object}	apicommon.DeleteMembersResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
 //	@Failure		401			{object}	errors.Error	"Unauthorized"
-//	@Failure		409			{object}	errors.Error	"A member has already been signed for in an ongoing process"
+//	@Failure		409			{object}	errors.Error	"A member has already been signed for in an ongoing process, or is in a frozen census"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
 //	@Router			/organizations/{orgAddress}/members [delete]
 func (a *API) deleteOrganizationMembersHandler(w http.ResponseWriter, r *http.Request) {
@@ -493,14 +543,14 @@ func (a *API) deleteOrganizationMembersHandler(w http.ResponseWriter, r *http.Re
 		errors.ErrGenericInternalServerError.Withf("could not resolve member censuses: %v", err).Write(w)
 		return
 	}
-	if a.refuseBlockedVoters(w, censusIDs, targetIDs) {
+	if a.refuseFrozenCensuses(w, censusIDs) || a.refuseBlockedVoters(w, censusIDs, targetIDs) {
 		return
 	}
 
 	// check if we should delete all members
 	if members.All {
 		// delete all org members from the database
-		deleted, emptied, err = a.db.DeleteAllOrgMembers(org.Address)
+		deleted, emptied, err = a.db.DeleteAllOrgMembers(org.Address, memberChangeSource(r, user, db.MemberChangeViaAPI))
 		if err != nil {
 			errors.ErrGenericInternalServerError.Withf("could not delete all org members: %v", err).Write(w)
 			return
@@ -511,7 +561,8 @@ func (a *API) deleteOrganizationMembersHandler(w http.ResponseWriter, r *http.Re
 			"user", user.Email)
 	} else {
 		// delete specific org members from the database
-		deleted, emptied, err = a.db.DeleteOrgMembers(org.Address, targetIDs)
+		deleted, emptied, err = a.db.DeleteOrgMembers(org.Address, targetIDs,
+			memberChangeSource(r, user, db.MemberChangeViaAPI))
 		if err != nil {
 			errors.ErrGenericInternalServerError.Withf("could not delete org members: %v", err).Write(w)
 			return
@@ -528,3 +579,146 @@ func (a *API) deleteOrganizationMembersHandler(w http.ResponseWriter, r *http.Re
 	resp.Errors = resizeErrs
 	apicommon.HTTPWriteJSON(w, resp)
 }
+
+// organizationMemberDuplicatesHandler godoc
+//
+//	@Summary		Find duplicate organization members
+//	@Description	Scan the whole memberbase for members that share a national ID, member number, email,
+//	@Description	phone, or name and birthdate. Values are compared exactly and once normalized (case,
+//	@Description	punctuation, an email +tag, leading zeros of a member number), and `exact` tells which.
+//	@Description	Phones are stored hashed, so they only match exactly. Member ids are in creation order.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Success		200			{object}	apicommon.OrgMemberDuplicatesResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/duplicates [get]
+func (a *API) organizationMemberDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+
+	duplicates, err := a.db.OrgMemberDuplicates(org.Address)
+	if err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not find duplicate members: %v", err).Write(w)
+		return
+	}
+	if duplicates == nil {
+		duplicates = []db.OrgMemberDuplicateSet{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.OrgMemberDuplicatesResponse{Duplicates: duplicates})
+}
+
+// mergeOrganizationMembersHandler godoc
+//
+//	@Summary		Merge duplicate organization members
+//	@Description	Fold one or more duplicate members into a surviving member and delete the duplicates.
+//	@Description	The survivor keeps its own data; every group, census and question eligibility list that
+//	@Description	named a duplicate names the survivor instead. In a census the survivor was already part
+//	@Description	of, the duplicate's participation is simply dropped. Requires Manager/Admin role.
+//	@Description
+//	@Description	The merge is refused with 409 while a question of a census any duplicate participates in
+//	@Description	is READY or PAUSED; the processes holding those elections come back in `data.processIds`.
+//	@Description	It is also refused with 409 when the survivor's login data would collide with another
+//	@Description	participant of a census it has to join. Nothing is written when the merge is refused.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string							true	"Organization address"
+//	@Param			request		body		apicommon.MergeMembersRequest	true	"Survivor and duplicate member IDs"
+//	@Success		200			{object}	apicommon.MergeMembersResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Member not found"
+//	@Failure		409			{object}	errors.Error	"The merge would affect an ongoing election or a frozen census, or create duplicates"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/merge [post]
+func (a *API) mergeOrganizationMembersHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	req := &apicommon.MergeMembersRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Withf("error decoding merge request").Write(w)
+		return
+	}
+	if req.SurvivorID == "" || len(req.DuplicateIDs) == 0 {
+		errors.ErrInvalidData.Withf("survivorId and duplicateIds are required").Write(w)
+		return
+	}
+
+	// the merge refuses itself, before writing anything, when a census of a duplicate is frozen
+	// or has an ongoing election
+	var ongoing *db.OngoingElectionError
+	result, err := a.db.MergeOrgMembers(org.Address, req.SurvivorID, req.DuplicateIDs,
+		memberChangeSource(r, user, db.MemberChangeViaMerge))
+	switch {
+	case errors.Is(err, db.ErrInvalidData):
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	case errors.Is(err, db.ErrNotFound):
+		errors.ErrOrgMemberNotFound.WithErr(err).Write(w)
+		return
+	case errors.Is(err, db.ErrUpdateWouldCreateDuplicates):
+		errors.ErrUpdateWouldCreateDuplicates.WithErr(err).Write(w)
+		return
+	case errors.Is(err, db.ErrCensusFrozen):
+		errors.ErrCensusFrozen.WithErr(err).Write(w)
+		return
+	case errors.As(err, &ongoing):
+		errors.ErrMemberMergeAffectsOngoingElection.WithData(map[string]any{"processIds": ongoing.ProcessIDs}).Write(w)
+		return
+	case err != nil:
+		errors.ErrGenericInternalServerError.Withf("could not merge org members: %v", err).Write(w)
+		return
+	default:
+	}
+	log.Infow("merged organization members",
+		"org", org.Address.Hex(),
+		"survivor", req.SurvivorID,
+		"merged", result.Merged,
+		"user", user.Email)
+
+	apicommon.HTTPWriteJSON(w, &apicommon.MergeMembersResponse{
+		Merged:   result.Merged,
+		Censuses: result.Censuses,
+		Groups:   result.Groups,
+	})
+}
@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// memberChangeSource identifies the user (and API key, if any) behind a member change made
+// through the request, for the member history.
+func memberChangeSource(r *http.Request, user *db.User, via string) db.MemberChangeSource {
+	source := db.MemberChangeSource{Actor: user.Email, Via: via}
+	if key, ok := apicommon.APIKeyFromContext(r.Context()); ok {
+		source.APIKey = key.Prefix
+	}
+	return source
+}
+
+// memberHistoryHandler godoc
+//
+//	@Summary		Get the change history of an organization member
+//	@Description	List every create, update and delete of a member, newest first, with who made it
+//	@Description	(user and API key), how (api, import, selfservice, scim, merge), the import job if any,
+//	@Description	and the fields it changed. Sensitive values (email, phone, national id, birth date)
+//	@Description	are masked and passwords are never shown. The history of a deleted member is still
+//	@Description	returned. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			memberId	path		string	true	"Member ID"
+//	@Param			page		query		integer	false	"Page number (default: 1)"
+//	@Param			limit		query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200			{object}	apicommon.MemberHistoryResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/{memberId}/history [get]
+func (a *API) memberHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	memberID := chi.URLParam(r, "memberId")
+	if _, err := primitive.ObjectIDFromHex(memberID); err != nil {
+		errors.ErrMalformedURLParam.Withf("invalid member id").Write(w)
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, versions, err := a.db.MemberHistory(org.Address, memberID, params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	if versions == nil {
+		versions = []*db.MemberVersion{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.MemberHistoryResponse{
+		Pagination: pagination,
+		Versions:   versions,
+	})
+}
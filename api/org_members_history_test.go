@@ -0,0 +1,51 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestMemberHistory(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	member := postOrgMembers(t, token, orgAddress, newOrgMembers(1)...)[0]
+	history := func(memberID string) []string {
+		return []string{"organizations", orgAddress.String(), "members", memberID, "history"}
+	}
+
+	putOrgMember(t, token, orgAddress, apicommon.OrgMember{
+		ID: member.ID, Name: "Renamed", Email: "renamed@example.com", Weight: member.Weight,
+	})
+	requestAndAssertCode(http.StatusOK, t, http.MethodDelete, token,
+		&apicommon.DeleteMembersRequest{IDs: []string{member.ID}}, organizationMembersURL(orgAddress.String()))
+
+	resp := requestAndParse[apicommon.MemberHistoryResponse](t, http.MethodGet, token, nil, history(member.ID)...)
+	c.Assert(resp.Versions, qt.HasLen, 3)
+	c.Assert(resp.Pagination.TotalItems, qt.Equals, int64(3))
+	deleted, updated, created := resp.Versions[0], resp.Versions[1], resp.Versions[2]
+	c.Assert(deleted.Action, qt.Equals, db.MemberDeleted)
+	c.Assert(deleted.Version, qt.Equals, int64(3))
+	c.Assert(deleted.Source.Via, qt.Equals, db.MemberChangeViaAPI)
+	c.Assert(deleted.Source.Actor, qt.Not(qt.Equals), "")
+
+	c.Assert(updated.Action, qt.Equals, db.MemberUpdated)
+	c.Assert(updated.Changes, qt.DeepEquals, []db.MemberFieldChange{
+		{Field: "email", Old: "u***@example.com", New: "r***@example.com"},
+		{Field: "name", Old: "Name 1", New: "Renamed"},
+	})
+
+	c.Assert(created.Action, qt.Equals, db.MemberCreated)
+	c.Assert(created.Source.Via, qt.Equals, db.MemberChangeViaImport)
+	c.Assert(created.Source.Actor, qt.Equals, deleted.Source.Actor)
+
+	// only the manager of the organization can read it, and the id must be a member id
+	otherToken := testCreateUser(t, "otherpassword123")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, otherToken, nil, history(member.ID)...)
+	requestAndAssertError(errors.ErrMalformedURLParam, t, http.MethodGet, token, nil, history("nope")...)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestOrganizationMemberDuplicatesAndMerge(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+
+	members := newOrgMembers(3)
+	// the same person imported again with a differently spelled email and national id
+	members[2].Email = "USER1+import@example.com"
+	members[2].NationalID = "dni-001"
+	stored := postOrgMembers(t, token, orgAddress, members...)
+	byNumber := make(map[string]string, len(stored))
+	for _, m := range stored {
+		byNumber[m.MemberNumber] = m.ID
+	}
+	original, copied, other := byNumber["P001"], byNumber["P003"], byNumber["P002"]
+
+	dups := requestAndParse[apicommon.OrgMemberDuplicatesResponse](t, http.MethodGet, token, nil,
+		"organizations", orgAddress.String(), "members", "duplicates")
+	fields := map[db.OrgMemberDuplicateField]db.OrgMemberDuplicateSet{}
+	for _, set := range dups.Duplicates {
+		fields[set.Field] = set
+	}
+	c.Assert(fields[db.DuplicateFieldNationalID].MemberIDs, qt.DeepEquals, []string{original, copied})
+	c.Assert(fields[db.DuplicateFieldNationalID].Exact, qt.IsFalse)
+	c.Assert(fields[db.DuplicateFieldEmail].MemberIDs, qt.DeepEquals, []string{original, copied})
+
+	group := postGroup(t, token, orgAddress, copied, other)
+
+	merged := requestAndParse[apicommon.MergeMembersResponse](t, http.MethodPost, token,
+		&apicommon.MergeMembersRequest{SurvivorID: original, DuplicateIDs: []string{copied}},
+		"organizations", orgAddress.String(), "members", "merge")
+	c.Assert(merged.Merged, qt.Equals, 1)
+	c.Assert(merged.Groups, qt.Equals, int64(1))
+
+	c.Assert(getOrgMembers(t, token, orgAddress).Members, qt.HasLen, 2)
+	groupMembers := requestAndParse[apicommon.ListOrganizationMemberGroupResponse](t, http.MethodGet, token, nil,
+		"organizations", orgAddress.String(), "groups", group.ID, "members")
+	c.Assert(memberIDs(groupMembers.Members), qt.ContentEquals, []string{original, other})
+
+	// the duplicate is gone, so merging it again names nobody
+	requestAndAssertError(errors.ErrOrgMemberNotFound, t, http.MethodPost, token,
+		&apicommon.MergeMembersRequest{SurvivorID: original, DuplicateIDs: []string{copied}},
+		"organizations", orgAddress.String(), "members", "merge")
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.MergeMembersRequest{SurvivorID: original},
+		"organizations", orgAddress.String(), "members", "merge")
+}
+
+// TestMergeOrganizationMembersRefusesOngoingElection pins that a merge never re-points a
+// participant of a census whose election still accepts votes.
+func TestMergeOrganizationMembersRefusesOngoingElection(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(3)...)
+	ids := memberIDs(members)
+
+	pid, _ := publishedProcess(t, token, orgAddress, ids)
+
+	errResp := requestAndExpectError(t, http.MethodPost, token,
+		&apicommon.MergeMembersRequest{SurvivorID: ids[0], DuplicateIDs: []string{ids[1]}},
+		"organizations", orgAddress.String(), "members", "merge")
+	c.Assert(errResp.Code, qt.Equals, errors.ErrMemberMergeAffectsOngoingElection.Code)
+	data, ok := errResp.Data.(map[string]any)
+	c.Assert(ok, qt.IsTrue, qt.Commentf("data: %#v", errResp.Data))
+	c.Assert(data["processIds"], qt.DeepEquals, []any{pid})
+
+	// nothing was written
+	c.Assert(getOrgMembers(t, token, orgAddress).Members, qt.HasLen, 3)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+)
+
+func TestAddOrganizationMembersDryRun(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	stored := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+
+	rows := newOrgMembers(5)
+	rows[0].Name = "Renamed"            // update by memberNumber
+	rows[1].MemberNumber = "P900"       // update by email
+	rows[3].Email = "not-an-email"      // inserted without it
+	rows[4].MemberNumber = "P003"       // repeats line 3
+	rows[4].Email = "user5@example.com" // but not its email
+	preview := requestAndParse[apicommon.AddMembersPreviewResponse](t, http.MethodPost, token,
+		&apicommon.AddMembersRequest{Members: rows}, organizationMembersURL(orgAddress.String())+"?dryRun=true&upsert=true")
+	c.Assert(preview.Insert, qt.Equals, 2)
+	c.Assert(preview.Update, qt.Equals, 2)
+	c.Assert(preview.Reject, qt.Equals, 1)
+	c.Assert(preview.Quota, qt.DeepEquals, apicommon.MembersQuotaImpact{NewMembers: 2, Allowed: true})
+	c.Assert(preview.Rows, qt.HasLen, 4)
+
+	byID := map[string]string{stored[0].MemberNumber: stored[0].ID, stored[1].MemberNumber: stored[1].ID}
+	c.Assert(preview.Rows[0].Line, qt.Equals, 1)
+	c.Assert(preview.Rows[0].MemberID, qt.Equals, byID["P001"])
+	c.Assert(preview.Rows[0].MatchedBy, qt.Equals, "memberNumber")
+	c.Assert(preview.Rows[0].Changes, qt.DeepEquals, []db.MemberFieldChange{{Field: "name", Old: "Name 1", New: "Renamed"}})
+	c.Assert(preview.Rows[1].MemberID, qt.Equals, byID["P002"])
+	c.Assert(preview.Rows[1].MatchedBy, qt.Equals, "email")
+	c.Assert(preview.Rows[1].Changes, qt.DeepEquals,
+		[]db.MemberFieldChange{{Field: "memberNumber", Old: "P002", New: "P900"}})
+	c.Assert(preview.Rows[2].Line, qt.Equals, 4)
+	c.Assert(preview.Rows[2].Action, qt.Equals, db.OrgMemberImportInsert)
+	c.Assert(preview.Rows[2].Warnings, qt.HasLen, 1)
+	c.Assert(preview.Rows[3].Action, qt.Equals, db.OrgMemberImportReject)
+	c.Assert(preview.Rows[3].Reasons, qt.DeepEquals, []string{`memberNumber "P003" repeats line 3`})
+
+	// nothing was written
+	c.Assert(getOrgMembers(t, token, orgAddress).Members, qt.HasLen, 2)
+
+	// without upsert every row is an insert, as the import does
+	preview = requestAndParse[apicommon.AddMembersPreviewResponse](t, http.MethodPost, token,
+		&apicommon.AddMembersRequest{Members: rows}, organizationMembersURL(orgAddress.String())+"?dryRun=true")
+	c.Assert([]int{preview.Insert, preview.Update, preview.Reject}, qt.DeepEquals, []int{5, 0, 0})
+
+	// the import carries out what the dry run reported: no duplicates of the matched members, and
+	// the rejected row left out
+	added := requestAndParse[apicommon.AddMembersResponse](t, http.MethodPost, token,
+		&apicommon.AddMembersRequest{Members: rows}, organizationMembersURL(orgAddress.String())+"?upsert=true")
+	c.Assert(added.Added, qt.Equals, uint32(2))
+	c.Assert(added.Updated, qt.Equals, uint32(2))
+	c.Assert(added.Errors, qt.HasLen, 2)
+	c.Assert(added.Errors[0], qt.Matches, `line 4: invalid email.*`)
+	c.Assert(added.Errors[1], qt.Matches, `line 5: .*memberNumber "P003" repeats line 3`)
+	members := getOrgMembers(t, token, orgAddress).Members
+	c.Assert(members, qt.HasLen, 4)
+	c.Assert(getOrgMember(t, token, orgAddress, byID["P001"]).Name, qt.Equals, "Renamed")
+	c.Assert(getOrgMember(t, token, orgAddress, byID["P002"]).MemberNumber, qt.Equals, "P900")
+}
@@ -0,0 +1,166 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+)
+
+const (
+	// defaultMemberSearchLimit and maxMemberSearchLimit bound the page of a member search.
+	defaultMemberSearchLimit = 50
+	maxMemberSearchLimit     = 500
+)
+
+// memberSearchFromRequest converts a member search request into its db form.
+func memberSearchFromRequest(req *apicommon.MemberSearchRequest) (*db.OrgMemberSearch, error) {
+	search := &db.OrgMemberSearch{
+		GroupID:      req.GroupID,
+		CensusID:     req.CensusID,
+		Limit:        req.Limit,
+		Cursor:       req.Cursor,
+		IncludeTotal: req.IncludeTotal,
+	}
+	if err := applyMemberFilter(&req.MemberFilter, search); err != nil {
+		return nil, err
+	}
+	sort, descending := strings.CutPrefix(req.Sort, "-")
+	search.Sort, search.Descending = db.OrgMemberSortField(sort), descending
+	if search.Sort != "" && !search.Sort.IsValid() {
+		return nil, errors.ErrMalformedBody.Withf("cannot sort by %q", sort)
+	}
+	switch {
+	case search.Limit == 0:
+		search.Limit = defaultMemberSearchLimit
+	case search.Limit < 0 || search.Limit > maxMemberSearchLimit:
+		return nil, errors.ErrMalformedBody.Withf("limit must be between 1 and %d", maxMemberSearchLimit)
+	}
+	return search, nil
+}
+
+// applyMemberFilter sets the member-field criteria of filter on search.
+func applyMemberFilter(filter *apicommon.MemberFilter, search *db.OrgMemberSearch) error {
+	search.In = map[db.OrgMemberLookupField][]string{}
+	search.HasEmail, search.HasPhone = filter.HasEmail, filter.HasPhone
+	for field, value := range filter.Equals {
+		search.In[db.OrgMemberLookupField(field)] = append(search.In[db.OrgMemberLookupField(field)], value)
+	}
+	for field, values := range filter.In {
+		search.In[db.OrgMemberLookupField(field)] = append(search.In[db.OrgMemberLookupField(field)], values...)
+	}
+	for field, values := range search.In {
+		if !field.IsValid() {
+			return errors.ErrMalformedBody.Withf("cannot filter by field %q", field)
+		}
+		if len(values) == 0 {
+			return errors.ErrMalformedBody.Withf("no values for field %q", field)
+		}
+	}
+	if filter.Weight != nil {
+		search.WeightMin, search.WeightMax = filter.Weight.Min, filter.Weight.Max
+	}
+	if filter.BirthDate != nil {
+		var err error
+		if search.BirthDateFrom, err = parseSearchDate(filter.BirthDate.From); err != nil {
+			return err
+		}
+		if search.BirthDateTo, err = parseSearchDate(filter.BirthDate.To); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// parseSearchDate parses an optional date bound the way member birth dates are parsed.
+func parseSearchDate(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	date, _, err := internal.ParseBirthDate(value)
+	if err != nil {
+		return nil, errors.ErrMalformedBody.Withf("invalid date %q", value)
+	}
+	return &date, nil
+}
+
+// searchOrganizationMembersHandler godoc
+//
+//	@Summary		Search organization members
+//	@Description	Search the members of an organization with structured filters: exact matches
+//	@Description	(`equals`) or sets of values (`in`) on email, phone, memberNumber, nationalId, name,
+//	@Description	surname and externalId; weight and birth date ranges; having an email or a phone; and
+//	@Description	membership of a group or census. Every filter set must hold. Results are sorted by
+//	@Description	`sort` (prefix `-` for descending; creation order by default) and paged with a cursor:
+//	@Description	pass the `nextCursor` of a page, with the same filters and sort, to get the next one.
+//	@Description	The total is only counted when `includeTotal` is set. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string							true	"Organization address"
+//	@Param			request		body		apicommon.MemberSearchRequest	true	"Filters, sort and cursor"
+//	@Success		200			{object}	apicommon.MemberSearchResponse
+//	@Failure		400			{object}	errors.Error	"Invalid filter, sort or cursor, or unknown group or census"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/search [post]
+func (a *API) searchOrganizationMembersHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+
+	req := &apicommon.MemberSearchRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Withf("invalid search request").Write(w)
+		return
+	}
+	search, err := memberSearchFromRequest(req)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+
+	result, err := a.db.SearchOrgMembers(org, search)
+	switch {
+	case errors.Is(err, db.ErrNotFound):
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	case errors.Is(err, db.ErrInvalidData):
+		errors.ErrMalformedBody.WithErr(err).Write(w)
+		return
+	case err != nil:
+		errors.ErrGenericInternalServerError.Withf("could not search org members: %v", err).Write(w)
+		return
+	}
+	resp := &apicommon.MemberSearchResponse{
+		Members:    make([]apicommon.OrgMember, 0, len(result.Members)),
+		NextCursor: result.NextCursor,
+		Total:      result.Total,
+	}
+	for _, m := range result.Members {
+		resp.Members = append(resp.Members, apicommon.OrgMemberFromDb(*m))
+	}
+	apicommon.HTTPWriteJSON(w, resp)
+}
@@ -0,0 +1,53 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestSearchOrganizationMembers(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(5)...)
+	searchURL := []string{"organizations", orgAddress.String(), "members", "search"}
+
+	// two pages of creation order, then the rest
+	resp := requestAndParse[apicommon.MemberSearchResponse](t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{Limit: 3, IncludeTotal: true}, searchURL...)
+	c.Assert(resp.Members, qt.HasLen, 3)
+	c.Assert(*resp.Total, qt.Equals, int64(5))
+	c.Assert(resp.NextCursor, qt.Not(qt.Equals), "")
+	c.Assert(resp.Members[0].ID, qt.Equals, members[0].ID)
+	resp = requestAndParse[apicommon.MemberSearchResponse](t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{Limit: 3, Cursor: resp.NextCursor}, searchURL...)
+	c.Assert(resp.Members, qt.HasLen, 2)
+	c.Assert(resp.NextCursor, qt.Equals, "")
+	c.Assert(resp.Total, qt.IsNil)
+
+	// filters and a descending sort
+	resp = requestAndParse[apicommon.MemberSearchResponse](t, http.MethodPost, token, &apicommon.MemberSearchRequest{
+		MemberFilter: apicommon.MemberFilter{
+			In: map[string][]string{"memberNumber": {members[1].MemberNumber, members[3].MemberNumber}},
+		},
+		Sort: "-memberNumber",
+	}, searchURL...)
+	c.Assert(resp.Members, qt.HasLen, 2)
+	c.Assert(resp.Members[0].ID, qt.Equals, members[3].ID)
+	c.Assert(resp.Members[1].ID, qt.Equals, members[1].ID)
+
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{
+			MemberFilter: apicommon.MemberFilter{Equals: map[string]string{"password": "x"}},
+		}, searchURL...)
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{Sort: "other"}, searchURL...)
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{Cursor: "bogus"}, searchURL...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.MemberSearchRequest{CensusID: "000000000000000000000000"}, searchURL...)
+}
@@ -0,0 +1,579 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/mail"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/csp"
+	"github.com/vocdoni/saas-backend/csp/handlers"
+	"github.com/vocdoni/saas-backend/csp/notifications"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+)
+
+// memberSelfServiceSessionTTL is how long a verified self-service login can submit changes.
+const memberSelfServiceSessionTTL = 15 * time.Minute
+
+// maskedUnknownPhone is shown for the phone of a member whose number the portal does not know in
+// clear text, having been logged in by email.
+const maskedUnknownPhone = "***"
+
+// memberSelfServiceConfigHandler godoc
+//
+//	@Summary		Get the member self-service portal config
+//	@Description	Get the config of the public portal where members update their own contact data.
+//	@Description	Returns an empty, disabled config when the portal was never configured.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Success		200			{object}	db.MemberSelfServiceConfig
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Router			/organizations/{orgAddress}/members/selfservice [get]
+func (a *API) memberSelfServiceConfigHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	config := org.MemberSelfService
+	if config == nil {
+		config = &db.MemberSelfServiceConfig{}
+	}
+	apicommon.HTTPWriteJSON(w, config)
+}
+
+// setMemberSelfServiceConfigHandler godoc
+//
+//	@Summary		Set the member self-service portal config
+//	@Description	Configure the public portal where members update their own contact data. Members log in
+//	@Description	with the `authFields` plus a one-time code sent to one of the `twoFaFields` (email, phone),
+//	@Description	and may then change the `editableFields`: `email`, `phone`, or keys of the member's other
+//	@Description	data such as `address`. Identity fields (name, surname, memberNumber, nationalId,
+//	@Description	birthDate) and the weight cannot be opened up. With `requireApproval` every change waits
+//	@Description	for a manager in GET /organizations/{orgAddress}/members/updates. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string						true	"Organization address"
+//	@Param			request		body		db.MemberSelfServiceConfig	true	"Portal config"
+//	@Success		200			{string}	string						"OK"
+//	@Failure		400			{object}	errors.Error				"Invalid input data"
+//	@Failure		401			{object}	errors.Error				"Unauthorized"
+//	@Failure		500			{object}	errors.Error				"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/selfservice [put]
+func (a *API) setMemberSelfServiceConfigHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	config := &db.MemberSelfServiceConfig{}
+	if err := json.NewDecoder(r.Body).Decode(config); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if err := config.Validate(); err != nil {
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	}
+	if err := a.db.SetMemberSelfServiceConfig(org.Address, config); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// memberUpdateRequestsHandler godoc
+//
+//	@Summary		List member self-service update requests
+//	@Description	List the changes members submitted from the self-service portal, newest first. The list
+//	@Description	is the audit trail of the portal: applied, rejected and failed requests are kept, with a
+//	@Description	phone number masked once the request is resolved. Filter by `status` (pending, applied,
+//	@Description	rejected, failed) to get the approval queue. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			status		query		string	false	"Filter by status (pending, applied, rejected, failed)"
+//	@Param			page		query		integer	false	"Page number (default: 1)"
+//	@Param			limit		query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200			{object}	apicommon.MemberUpdateRequestsResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/updates [get]
+func (a *API) memberUpdateRequestsHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	status := db.MemberUpdateStatus(r.URL.Query().Get("status"))
+	switch status {
+	case "", db.MemberUpdatePending, db.MemberUpdateApplied, db.MemberUpdateRejected, db.MemberUpdateFailed:
+	default:
+		errors.ErrMalformedURLParam.Withf("invalid status %q", status).Write(w)
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, requests, err := a.db.MemberUpdateRequests(org.Address, status, params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	if requests == nil {
+		requests = []*db.MemberUpdateRequest{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.MemberUpdateRequestsResponse{
+		Pagination: pagination,
+		Requests:   requests,
+	})
+}
+
+// resolveMemberUpdateRequestHandler godoc
+//
+//	@Summary		Approve or reject a member update request
+//	@Description	Resolve a pending change submitted from the member self-service portal. Approving it
+//	@Description	writes the change to the member, and to its participation in every census, the same way
+//	@Description	a member update does. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string									true	"Organization address"
+//	@Param			requestId	path		string									true	"Update request ID"
+//	@Param			request		body		apicommon.ResolveMemberUpdateRequest	true	"Decision"
+//	@Success		200			{string}	string									"OK"
+//	@Failure		400			{object}	errors.Error							"Invalid input data"
+//	@Failure		401			{object}	errors.Error							"Unauthorized"
+//	@Failure		404			{object}	errors.Error							"Request or member not found"
+//	@Failure		409			{object}	errors.Error							"Request already resolved, or the change would create duplicates"
+//	@Failure		500			{object}	errors.Error							"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/updates/{requestId} [put]
+func (a *API) resolveMemberUpdateRequestHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	req := &apicommon.ResolveMemberUpdateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	requestID := chi.URLParam(r, "requestId")
+	var err error
+	if req.Approve {
+		err = a.db.ApplyMemberUpdateRequest(org, requestID, user.Email, passwordSalt)
+	} else {
+		err = a.db.RejectMemberUpdateRequest(org, requestID, user.Email)
+	}
+	if err != nil {
+		writeMemberUpdateError(w, err)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// memberSelfServiceAuthHandler godoc
+//
+//	@Summary		Request a member self-service login code
+//	@Description	First step of the member self-service login. The member sends the auth fields of the
+//	@Description	portal config plus the email or phone stored for them; if exactly one member matches,
+//	@Description	a one-time code is sent to that email or phone and an auth token is returned, to verify
+//	@Description	with POST /organizations/{orgAddress}/members/selfservice/verify. A new code can only be
+//	@Description	requested once the notification cooldown has passed.
+//	@Description	Public endpoint.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgAddress	path		string									true	"Organization address"
+//	@Param			request		body		apicommon.MemberSelfServiceAuthRequest	true	"Member login data"
+//	@Success		200			{object}	apicommon.MemberSelfServiceAuthResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Contact mismatch or cooldown time not reached"
+//	@Failure		403			{object}	errors.Error	"Self-service is not enabled"
+//	@Failure		404			{object}	errors.Error	"Organization or member not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/selfservice/auth [post]
+func (a *API) memberSelfServiceAuthHandler(w http.ResponseWriter, r *http.Request) {
+	org, config, ok := a.memberSelfServiceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.MemberSelfServiceAuthRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+
+	// the login data goes through the same normalization the stored members went through; a birth
+	// date that does not parse could never match one
+	if req.BirthDate != "" {
+		if _, _, err := internal.ParseBirthDate(req.BirthDate); err != nil {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
+	}
+	input := (&db.OrgMember{
+		Name:         req.Name,
+		Surname:      req.Surname,
+		MemberNumber: req.MemberNumber,
+		NationalID:   req.NationalID,
+		BirthDate:    req.BirthDate,
+		Email:        req.Email,
+	}).Normalized()
+	var to string
+	var challenge notifications.ChallengeType
+	switch {
+	case req.Email != "" && config.TwoFaFields.Contains(db.OrgMemberTwoFaFieldEmail):
+		to, challenge = input.Email, notifications.EmailChallenge
+	case req.Phone != "" && config.TwoFaFields.Contains(db.OrgMemberTwoFaFieldPhone):
+		normalized, err := internal.SanitizeAndVerifyPhoneNumber(req.Phone, org.Country)
+		if err != nil {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
+		if input.Phone, err = db.NewHashedPhone(normalized, org); err != nil {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
+		input.Email = ""
+		to, challenge = normalized, notifications.SMSChallenge
+	default:
+		errors.ErrInvalidUserData.Withf("no valid contact method provided").Write(w)
+		return
+	}
+
+	members, err := a.db.OrgMembersByLogin(org.Address, config.AuthFields, input)
+	if err != nil {
+		if errors.Is(err, db.ErrInvalidData) {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	// an ambiguous login is refused like an unknown one: it must not tell members apart
+	if len(members) != 1 {
+		errors.ErrOrgMemberNotFound.Write(w)
+		return
+	}
+
+	name, logo := handlers.DefaultOrgName, handlers.DefaultOrgLogo
+	if n := org.DisplayName(); n != "" {
+		name = n
+		if l := org.LogoURL(); l != "" {
+			logo = l
+		}
+	}
+	token, err := a.csp.BundleAuthToken(
+		db.MemberSelfServiceAnchor(org.Address),
+		internal.HexBytesFromString(members[0].ID.Hex()),
+		to,
+		challenge,
+		a.getLanguageFromContext(r.Context()),
+		name,
+		logo,
+		org.Address,
+	)
+	if err != nil {
+		if apiErr, ok := err.(errors.Error); ok {
+			apiErr.Write(w)
+			return
+		}
+		errors.ErrUnauthorized.WithErr(err).Write(w)
+		return
+	}
+	// the number the code went to is the member's own, and the only one the portal can show
+	if challenge == notifications.SMSChallenge {
+		if err := a.db.SetCSPAuthMaskedPhone(token, internal.MaskPhoneNumber(to)); err != nil {
+			errors.ErrInternalStorageError.WithErr(err).Write(w)
+			return
+		}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.MemberSelfServiceAuthResponse{AuthToken: token})
+}
+
+// memberSelfServiceVerifyHandler godoc
+//
+//	@Summary		Verify a member self-service login code
+//	@Description	Second step of the member self-service login. Verifies the one-time code received and
+//	@Description	returns the member's current value of each editable field; the phone is only returned
+//	@Description	masked, and only after a login by phone (otherwise a stored phone shows as "***").
+//	@Description	The verified token can submit changes for a limited time.
+//	@Description	Public endpoint.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgAddress	path		string										true	"Organization address"
+//	@Param			request		body		apicommon.MemberSelfServiceVerifyRequest	true	"Auth token and code"
+//	@Success		200			{object}	apicommon.MemberSelfServiceProfile
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Invalid, expired or exhausted token, or wrong code"
+//	@Failure		403			{object}	errors.Error	"Self-service is not enabled"
+//	@Failure		404			{object}	errors.Error	"Organization or member not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/selfservice/verify [post]
+func (a *API) memberSelfServiceVerifyHandler(w http.ResponseWriter, r *http.Request) {
+	org, config, ok := a.memberSelfServiceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.MemberSelfServiceVerifyRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	// check the anchor before spending an attempt: a voting token has no business here
+	auth, err := a.db.CSPAuth(req.AuthToken)
+	if err != nil || !auth.BundleID.Equals(db.MemberSelfServiceAnchor(org.Address)) {
+		errors.ErrUnauthorized.Withf("invalid auth token").Write(w)
+		return
+	}
+	switch err := a.csp.VerifyBundleAuthToken(req.AuthToken, req.Code); err {
+	case nil:
+	case csp.ErrStorageFailure:
+		errors.ErrInternalStorageError.WithErr(err).Write(w)
+		return
+	default:
+		errors.ErrUnauthorized.WithErr(err).Write(w)
+		return
+	}
+	member, err := a.db.OrgMember(org.Address, auth.UserID.String())
+	if err != nil {
+		errors.ErrOrgMemberNotFound.WithErr(err).Write(w)
+		return
+	}
+	profile := &apicommon.MemberSelfServiceProfile{
+		Fields:          make(map[string]string, len(config.EditableFields)),
+		RequireApproval: config.RequireApproval,
+	}
+	for _, field := range config.EditableFields {
+		switch field {
+		case db.SelfServiceFieldEmail:
+			profile.Fields[field] = member.Email
+		case db.SelfServiceFieldPhone:
+			// only the hash of the phone is stored: after an email login its number is unknown
+			profile.Fields[field] = auth.MaskedPhone
+			if profile.Fields[field] == "" && !member.Phone.IsEmpty() {
+				profile.Fields[field] = maskedUnknownPhone
+			}
+		default:
+			value, _ := member.Other[field].(string)
+			profile.Fields[field] = value
+		}
+	}
+	apicommon.HTTPWriteJSON(w, profile)
+}
+
+// memberSelfServiceUpdateHandler godoc
+//
+//	@Summary		Submit a member self-service profile change
+//	@Description	Submit new values for the editable fields with a verified self-service token. The change
+//	@Description	is recorded as an update request; unless the portal requires approval it is applied right
+//	@Description	away, to the member and to its participation in every census; if that fails the request
+//	@Description	is marked failed, not queued for approval, and the error returned. The token is consumed
+//	@Description	once the changes are validated, whatever happens next: a further change needs a new login.
+//	@Description	Public endpoint (the token authenticates the member).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Param			orgAddress	path		string										true	"Organization address"
+//	@Param			request		body		apicommon.MemberSelfServiceUpdateRequest	true	"Auth token and changes"
+//	@Success		200			{object}	apicommon.MemberSelfServiceUpdateResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Invalid, unverified or expired token"
+//	@Failure		403			{object}	errors.Error	"Self-service is not enabled"
+//	@Failure		404			{object}	errors.Error	"Organization or member not found"
+//	@Failure		409			{object}	errors.Error	"The change would create duplicates"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/selfservice/profile [put]
+func (a *API) memberSelfServiceUpdateHandler(w http.ResponseWriter, r *http.Request) {
+	org, config, ok := a.memberSelfServiceFromRequest(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.MemberSelfServiceUpdateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	auth, err := a.db.CSPAuth(req.AuthToken)
+	if err != nil || !auth.BundleID.Equals(db.MemberSelfServiceAnchor(org.Address)) {
+		errors.ErrUnauthorized.Withf("invalid auth token").Write(w)
+		return
+	}
+	if !auth.Verified || time.Since(auth.VerifiedAt) > memberSelfServiceSessionTTL {
+		errors.ErrUnauthorized.Withf("auth token is not verified or has expired").Write(w)
+		return
+	}
+	if len(req.Changes) == 0 {
+		errors.ErrInvalidData.Withf("no changes provided").Write(w)
+		return
+	}
+	for field, value := range req.Changes {
+		if !config.Editable(field) {
+			errors.ErrInvalidData.Withf("field %q cannot be edited", field).Write(w)
+			return
+		}
+		switch field {
+		case db.SelfServiceFieldEmail:
+			if _, err := mail.ParseAddress(value); err != nil {
+				errors.ErrInvalidData.Withf("invalid email %q", value).Write(w)
+				return
+			}
+		case db.SelfServiceFieldPhone:
+			if _, err := internal.SanitizeAndVerifyPhoneNumber(value, org.Country); err != nil {
+				errors.ErrInvalidData.WithErr(err).Write(w)
+				return
+			}
+		}
+	}
+
+	// one login, one change: the token is spent before the change is recorded, atomically, so of
+	// several requests holding it only one goes through
+	if _, err := a.db.ConsumeCSPAuth(req.AuthToken, db.MemberSelfServiceAnchor(org.Address)); err != nil {
+		if errors.Is(err, db.ErrTokenNotFound) {
+			errors.ErrUnauthorized.Withf("auth token already used").Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	requestID, err := a.db.CreateMemberUpdateRequest(&db.MemberUpdateRequest{
+		OrgAddress: org.Address,
+		MemberID:   auth.UserID.String(),
+		Changes:    req.Changes,
+	})
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	status := db.MemberUpdatePending
+	if !config.RequireApproval {
+		// a change that needs no approval is never queued for it: when it cannot be applied, the
+		// request is marked failed and the member told so
+		if err := a.db.ApplyMemberUpdateRequest(org, requestID, "", passwordSalt); err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				errors.ErrOrgMemberNotFound.WithErr(err).Write(w)
+				return
+			}
+			writeMemberUpdateError(w, err)
+			return
+		}
+		status = db.MemberUpdateApplied
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.MemberSelfServiceUpdateResponse{
+		RequestID: requestID,
+		Status:    status,
+	})
+}
+
+// memberSelfServiceFromRequest resolves the organization of a public self-service request and
+// its portal config, writing the error response when the portal is not available.
+func (a *API) memberSelfServiceFromRequest(
+	w http.ResponseWriter, r *http.Request,
+) (*db.Organization, *db.MemberSelfServiceConfig, bool) {
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrOrganizationNotFound.Write(w)
+		return nil, nil, false
+	}
+	if org.MemberSelfService == nil || !org.MemberSelfService.Enabled {
+		errors.ErrMemberSelfServiceDisabled.Write(w)
+		return nil, nil, false
+	}
+	return org, org.MemberSelfService, true
+}
+
+// writeMemberUpdateError maps an error applying or rejecting a member update request to the
+// proper HTTP error.
+func writeMemberUpdateError(w http.ResponseWriter, err error) {
+	switch {
+	case errors.Is(err, db.ErrInvalidData):
+		errors.ErrInvalidData.WithErr(err).Write(w)
+	case errors.Is(err, db.ErrNotFound):
+		errors.ErrMemberUpdateRequestNotFound.WithErr(err).Write(w)
+	case errors.Is(err, db.ErrConflict):
+		errors.ErrMemberUpdateRequestResolved.Write(w)
+	case errors.Is(err, db.ErrUpdateWouldCreateDuplicates):
+		errors.ErrUpdateWouldCreateDuplicates.WithErr(err).Write(w)
+	default:
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+	}
+}
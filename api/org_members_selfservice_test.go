@@ -0,0 +1,152 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+)
+
+func TestMemberSelfService(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	byNumber := make(map[string]apicommon.OrgMember)
+	for _, m := range postOrgMembers(t, token, orgAddress, newOrgMembers(3)...) {
+		byNumber[m.MemberNumber] = m
+	}
+	first, second := byNumber["P001"], byNumber["P002"]
+	selfService := func(step ...string) []string {
+		return append([]string{"organizations", orgAddress.String(), "members", "selfservice"}, step...)
+	}
+	updates := func(rest ...string) []string {
+		return append([]string{"organizations", orgAddress.String(), "members", "updates"}, rest...)
+	}
+
+	// the portal is closed until a manager configures it
+	requestAndAssertError(errors.ErrMemberSelfServiceDisabled, t, http.MethodPost, "",
+		&apicommon.MemberSelfServiceAuthRequest{MemberNumber: first.MemberNumber, Email: first.Email},
+		selfService("auth")...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPut, token,
+		&db.MemberSelfServiceConfig{
+			Enabled: true, TwoFaFields: db.OrgMemberTwoFaFields{db.OrgMemberTwoFaFieldEmail},
+			EditableFields: []string{"memberNumber"},
+		}, selfService()...)
+	config := &db.MemberSelfServiceConfig{
+		Enabled:        true,
+		AuthFields:     db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber},
+		TwoFaFields:    db.OrgMemberTwoFaFields{db.OrgMemberTwoFaFieldEmail},
+		EditableFields: []string{"email", "phone", "address"},
+	}
+	requestAndAssertCode(http.StatusOK, t, http.MethodPut, token, config, selfService()...)
+	stored := requestAndParse[db.MemberSelfServiceConfig](t, http.MethodGet, token, nil, selfService()...)
+	c.Assert(stored.EditableFields, qt.DeepEquals, config.EditableFields)
+
+	// the contact must be the member's own
+	requestAndAssertError(errors.ErrOrgMemberNotFound, t, http.MethodPost, "",
+		&apicommon.MemberSelfServiceAuthRequest{MemberNumber: first.MemberNumber, Email: second.Email},
+		selfService("auth")...)
+
+	login := func(member apicommon.OrgMember) (internal.HexBytes, apicommon.MemberSelfServiceProfile) {
+		auth := requestAndParse[apicommon.MemberSelfServiceAuthResponse](t, http.MethodPost, "",
+			&apicommon.MemberSelfServiceAuthRequest{MemberNumber: member.MemberNumber, Email: member.Email},
+			selfService("auth")...)
+		code := extractOTPFromBody(waitForEmail(t, member.Email))
+		c.Assert(code, qt.Not(qt.Equals), "")
+		profile := requestAndParse[apicommon.MemberSelfServiceProfile](t, http.MethodPost, "",
+			&apicommon.MemberSelfServiceVerifyRequest{AuthToken: auth.AuthToken, Code: code},
+			selfService("verify")...)
+		c.Assert(profile.Fields["email"], qt.Equals, member.Email)
+		// only the hash of the phone is stored, so a login by email cannot show its number
+		c.Assert(profile.Fields["phone"], qt.Equals, "***")
+		return auth.AuthToken, profile
+	}
+
+	// without approval, the change lands right away
+	authToken, profile := login(first)
+	c.Assert(profile.RequireApproval, qt.IsFalse)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{"name": "Mallory"}},
+		selfService("profile")...)
+	applied := requestAndParse[apicommon.MemberSelfServiceUpdateResponse](t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{
+			"email": "first.new@example.com", "address": "Main St 1",
+		}},
+		selfService("profile")...)
+	c.Assert(applied.Status, qt.Equals, db.MemberUpdateApplied)
+	member := getOrgMember(t, token, orgAddress, first.ID)
+	c.Assert(member.Email, qt.Equals, "first.new@example.com")
+	c.Assert(member.Other["address"], qt.Equals, "Main St 1")
+	c.Assert(member.Other["some"], qt.Equals, "data")
+	// one login, one change
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{"address": "Main St 2"}},
+		selfService("profile")...)
+	// ...even when the change cannot be applied: the token is spent before it is recorded
+	first.Email = member.Email
+	authToken, _ = login(first)
+	_, _, err := testDB.DeleteOrgMembers(orgAddress, []string{first.ID}, db.MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	requestAndAssertError(errors.ErrOrgMemberNotFound, t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{"address": "Main St 2"}},
+		selfService("profile")...)
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{"address": "Main St 2"}},
+		selfService("profile")...)
+
+	// with approval, the change waits for a manager
+	config.RequireApproval = true
+	requestAndAssertCode(http.StatusOK, t, http.MethodPut, token, config, selfService()...)
+	authToken, profile = login(second)
+	c.Assert(profile.RequireApproval, qt.IsTrue)
+	pending := requestAndParse[apicommon.MemberSelfServiceUpdateResponse](t, http.MethodPut, "",
+		&apicommon.MemberSelfServiceUpdateRequest{AuthToken: authToken, Changes: map[string]string{"address": "Side St 9"}},
+		selfService("profile")...)
+	c.Assert(pending.Status, qt.Equals, db.MemberUpdatePending)
+	_, hasAddress := getOrgMember(t, token, orgAddress, second.ID).Other["address"]
+	c.Assert(hasAddress, qt.IsFalse)
+
+	queue := requestAndParse[apicommon.MemberUpdateRequestsResponse](t, http.MethodGet, token, nil,
+		updates("?status=pending")...)
+	c.Assert(queue.Requests, qt.HasLen, 1)
+	c.Assert(queue.Requests[0].ID.Hex(), qt.Equals, pending.RequestID)
+	c.Assert(queue.Requests[0].MemberID, qt.Equals, second.ID)
+
+	requestAndAssertCode(http.StatusOK, t, http.MethodPut, token,
+		&apicommon.ResolveMemberUpdateRequest{Approve: true}, updates(pending.RequestID)...)
+	c.Assert(getOrgMember(t, token, orgAddress, second.ID).Other["address"], qt.Equals, "Side St 9")
+	requestAndAssertError(errors.ErrMemberUpdateRequestResolved, t, http.MethodPut, token,
+		&apicommon.ResolveMemberUpdateRequest{Approve: false}, updates(pending.RequestID)...)
+
+	// every request stays as the audit trail
+	all := requestAndParse[apicommon.MemberUpdateRequestsResponse](t, http.MethodGet, token, nil, updates()...)
+	statuses := map[db.MemberUpdateStatus]int{}
+	for _, req := range all.Requests {
+		statuses[req.Status]++
+	}
+	c.Assert(statuses, qt.DeepEquals, map[db.MemberUpdateStatus]int{db.MemberUpdateApplied: 2, db.MemberUpdateFailed: 1})
+
+	// a login by phone shows the number the code went to, masked, and reads the birth date in any
+	// format the import accepts
+	third := byNumber["P003"]
+	config.AuthFields = db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber, db.OrgMemberAuthFieldsBirthDate}
+	config.TwoFaFields = db.OrgMemberTwoFaFields{db.OrgMemberTwoFaFieldEmail, db.OrgMemberTwoFaFieldPhone}
+	requestAndAssertCode(http.StatusOK, t, http.MethodPut, token, config, selfService()...)
+	thirdPhone := "+34600000003"
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, "",
+		&apicommon.MemberSelfServiceAuthRequest{MemberNumber: third.MemberNumber, BirthDate: "1980-13-45", Phone: thirdPhone},
+		selfService("auth")...)
+	auth := requestAndParse[apicommon.MemberSelfServiceAuthResponse](t, http.MethodPost, "",
+		&apicommon.MemberSelfServiceAuthRequest{MemberNumber: third.MemberNumber, BirthDate: "03/01/1980", Phone: thirdPhone},
+		selfService("auth")...)
+	code := extractOTPFromBody(waitForSMS(t, thirdPhone))
+	c.Assert(code, qt.Not(qt.Equals), "")
+	profile = requestAndParse[apicommon.MemberSelfServiceProfile](t, http.MethodPost, "",
+		&apicommon.MemberSelfServiceVerifyRequest{AuthToken: auth.AuthToken, Code: code},
+		selfService("verify")...)
+	c.Assert(profile.Fields["phone"], qt.Equals, "+34******003")
+}
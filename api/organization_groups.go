@@ -249,6 +249,101 @@ func (a *API) createOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 	})
 }
 
+// combineOrganizationMemberGroupsHandler godoc
+//
+//	@Summary		Create a member group from existing groups
+//	@Description	Create a static member group holding the union, intersection or difference (the first
+//	@Description	group minus the others) of existing groups of the organization, optionally keeping only
+//	@Description	the members matching a filter on member fields. The selection runs server-side, and the
+//	@Description	new group does not follow later changes to the groups it was built from. Needs admin or
+//	@Description	manager role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string									true	"Organization address"
+//	@Param			request		body		apicommon.CombineMemberGroupsRequest	true	"Operation, groups and filter"
+//	@Success		200			{object}	apicommon.OrganizationMemberGroupInfo
+//	@Failure		400			{object}	errors.Error	"Invalid operation or filter, or no member selected"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Group not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/groups/combine [post]
+func (a *API) combineOrganizationMemberGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	if !user.HasRoleFor(org.Address, db.AdminRole) && !user.HasRoleFor(org.Address, db.ManagerRole) {
+		// if the user is not admin or manager of the organization, return an error
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+
+	var req apicommon.CombineMemberGroupsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if !req.Operation.IsValid() {
+		errors.ErrMalformedBody.Withf("operation must be union, intersection or difference").Write(w)
+		return
+	}
+	if len(req.GroupIDs) == 0 {
+		errors.ErrMalformedBody.Withf("no groups to combine").Write(w)
+		return
+	}
+	filter := &db.OrgMemberSearch{}
+	if req.Filter != nil {
+		if err := applyMemberFilter(req.Filter, filter); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+	}
+
+	memberIDs, err := a.db.GroupSetMemberIDs(org, req.Operation, req.GroupIDs, filter)
+	switch {
+	case stderrors.Is(err, db.ErrNotFound):
+		errors.ErrGroupNotFound.WithErr(err).Write(w)
+		return
+	case stderrors.Is(err, db.ErrInvalidData):
+		errors.ErrMalformedBody.WithErr(err).Write(w)
+		return
+	case err != nil:
+		errors.ErrGenericInternalServerError.Withf("could not combine member groups: %v", err).Write(w)
+		return
+	}
+	if len(memberIDs) == 0 {
+		errors.ErrInvalidData.Withf("the combination selects no members").Write(w)
+		return
+	}
+
+	groupID, err := a.db.CreateOrganizationMemberGroup(&db.OrganizationMemberGroup{
+		Title:       req.Title,
+		Description: req.Description,
+		MemberIDs:   memberIDs,
+		OrgAddress:  org.Address,
+	})
+	if err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not create organization member group: %v", err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.OrganizationMemberGroupInfo{
+		ID:           groupID,
+		MembersCount: len(memberIDs),
+	})
+}
+
 // updateOrganizationMemberGroupHandler godoc
 //
 //	@Summary		Update an organization member group
@@ -268,6 +363,7 @@ func (a *API) createOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 //	@Failure		400			{object}	errors.Error									"Invalid input data, or organization/group not found"
 //	@Failure		401			{object}	errors.Error									"Unauthorized"
 //	@Failure		403			{object}	errors.Error									"Auto-generated group membership cannot be modified"
+//	@Failure		409			{object}	errors.Error									"A census of the group is frozen, or a member already signed for"
 //	@Failure		500			{object}	errors.Error									"Internal server error"
 //	@Router			/organizations/{orgAddress}/groups/{groupId} [put]
 func (a *API) updateOrganizationMemberGroupHandler(w http.ResponseWriter, r *http.Request) {
@@ -301,27 +397,50 @@ func (a *API) updateOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 		return
 	}
 
+	resp, err := a.updateMemberGroup(org, groupID, toUpdate.Title, toUpdate.Description,
+		toUpdate.AddMembers, toUpdate.RemoveMembers)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	// the endpoint answered a bare OK before; it still does when there is nothing to report
+	if len(resp.CensusJobIDs) == 0 && len(resp.Errors) == 0 {
+		apicommon.HTTPWriteOK(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, resp)
+}
+
+// updateMemberGroup updates a group's info and membership behind the guards every membership change
+// needs, and returns the census jobs and errors to report. It is shared by the group PUT and the
+// SCIM Groups resource, so both refuse exactly the same removals. Failures are errors.Error values,
+// or the subscription error of the quota preflight.
+func (a *API) updateMemberGroup(org *db.Organization, groupID, title, description string,
+	addMembers, removeMembers []string,
+) (*apicommon.UpdateOrganizationMemberGroupResponse, error) {
 	group, err := a.db.OrganizationMemberGroup(groupID, org.Address)
 	if err != nil {
 		if stderrors.Is(err, db.ErrNotFound) {
-			errors.ErrInvalidData.Withf("group not found").Write(w)
-			return
+			return nil, errors.ErrInvalidData.Withf("group not found")
 		}
-		errors.ErrGenericInternalServerError.Withf("could not load organization member group: %v", err).Write(w)
-		return
+		return nil, errors.ErrGenericInternalServerError.Withf("could not load organization member group: %v", err)
 	}
 	// members leaving the group leave its censuses too, so the refusal has to happen before any
 	// write: otherwise a blocked member is dropped from the group but stays in the census. Only
 	// the ids the group actually holds are considered — the rest change nothing, and matching the
 	// set the DB layer revokes keeps the guard from answering 409 for a member it will not touch.
-	removedInGroup := make([]string, 0, len(toUpdate.RemoveMembers))
-	for _, id := range toUpdate.RemoveMembers {
+	removedInGroup := make([]string, 0, len(removeMembers))
+	for _, id := range removeMembers {
 		if slices.Contains(group.MemberIDs, id) {
 			removedInGroup = append(removedInGroup, id)
 		}
 	}
-	if a.refuseBlockedVoters(w, group.CensusIDs, removedInGroup) {
-		return
+	blocked, err := a.blockedVoters(group.CensusIDs, removedInGroup)
+	if err != nil {
+		return nil, errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	if len(blocked) > 0 {
+		return nil, errors.ErrCensusMemberAlreadySignedFor.WithData(map[string]any{"signedMemberIds": blocked})
 	}
 	// read-only checks that must refuse before the group is touched, so an over-quota request
 	// leaves the member in neither the group nor the census.
@@ -335,37 +454,38 @@ func (a *API) updateOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 	// the same thing — a census can hold participants added by other paths — so this narrows the
 	// over-count rather than eliminating it, in the direction that stops refusing requests which
 	// would have fit.
-	addedMembers := make([]string, 0, len(toUpdate.AddMembers))
-	for _, id := range toUpdate.AddMembers {
+	addedMembers := make([]string, 0, len(addMembers))
+	for _, id := range addMembers {
 		if !slices.Contains(group.MemberIDs, id) {
 			addedMembers = append(addedMembers, id)
 		}
 	}
 	if err := a.preflightCensusGrowth(org, group.CensusIDs, len(addedMembers)); err != nil {
-		writeSubscriptionError(w, err)
-		return
+		return nil, err
 	}
 
 	emptied, err := a.db.UpdateOrganizationMemberGroup(
 		groupID,
 		org.Address,
-		toUpdate.Title,
-		toUpdate.Description,
-		toUpdate.AddMembers,
-		toUpdate.RemoveMembers,
+		title,
+		description,
+		addMembers,
+		removeMembers,
 	)
 	if err != nil {
+		if stderrors.Is(err, db.ErrCensusFrozen) {
+			return nil, errors.ErrCensusFrozen.WithErr(err)
+		}
 		switch err {
 		case db.ErrNotFound, db.ErrInvalidData:
-			errors.ErrInvalidData.Withf("group not found").Write(w)
+			return nil, errors.ErrInvalidData.Withf("group not found")
 		case db.ErrAutoGroupMembersCannotBeModified:
-			errors.ErrAutoGroupMembersCannotBeModified.Write(w)
+			return nil, errors.ErrAutoGroupMembersCannotBeModified
 		default:
-			errors.ErrGenericInternalServerError.Withf("could not update organization member group: %v", err).Write(w)
+			return nil, errors.ErrGenericInternalServerError.Withf("could not update organization member group: %v", err)
 		}
-		return
 	}
-	resp := apicommon.UpdateOrganizationMemberGroupResponse{}
+	resp := &apicommon.UpdateOrganizationMemberGroupResponse{}
 	jobID, resizeErrs := a.resizeEmptiedQuestions(org.Address, emptied)
 	if jobID != "" {
 		resp.CensusJobIDs = append(resp.CensusJobIDs, jobID)
@@ -379,12 +499,7 @@ func (a *API) updateOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 		resp.CensusJobIDs = append(resp.CensusJobIDs, propagated.JobIDs...)
 		resp.Errors = append(resp.Errors, propagated.Errors...)
 	}
-	// the endpoint answered a bare OK before; it still does when there is nothing to report
-	if len(resp.CensusJobIDs) == 0 && len(resp.Errors) == 0 {
-		apicommon.HTTPWriteOK(w)
-		return
-	}
-	apicommon.HTTPWriteJSON(w, &resp)
+	return resp, nil
 }
 
 // deleteOrganizationMemberGroupHandler godoc
@@ -404,6 +519,7 @@ func (a *API) updateOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 //	@Failure		400			{object}	errors.Error									"Invalid input data, or organization/group not found"
 //	@Failure		401			{object}	errors.Error									"Unauthorized"
 //	@Failure		403			{object}	errors.Error									"Auto-generated group cannot be deleted"
+//	@Failure		409			{object}	errors.Error									"A census of the group is frozen, or a member already signed for"
 //	@Failure		500			{object}	errors.Error									"Internal server error"
 //	@Router			/organizations/{orgAddress}/groups/{groupId} [delete]
 func (a *API) deleteOrganizationMemberGroupHandler(w http.ResponseWriter, r *http.Request) {
@@ -435,7 +551,7 @@ func (a *API) deleteOrganizationMemberGroupHandler(w http.ResponseWriter, r *htt
 	group, err := a.db.OrganizationMemberGroup(groupID, org.Address)
 	switch {
 	case err == nil:
-		if a.refuseBlockedVoters(w, group.CensusIDs, group.MemberIDs) {
+		if a.refuseFrozenCensuses(w, group.CensusIDs) || a.refuseBlockedVoters(w, group.CensusIDs, group.MemberIDs) {
 			return
 		}
 	case stderrors.Is(err, db.ErrNotFound):
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestCombineOrganizationMemberGroups(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(4)...)
+	board := postGroup(t, token, orgAddress, members[0].ID, members[1].ID, members[2].ID)
+	staff := postGroup(t, token, orgAddress, members[1].ID, members[3].ID)
+	combineURL := []string{"organizations", orgAddress.String(), "groups", "combine"}
+
+	weight := uint64(3)
+	created := requestAndParse[apicommon.OrganizationMemberGroupInfo](t, http.MethodPost, token,
+		&apicommon.CombineMemberGroupsRequest{
+			Title:     "board, not staff, weighing 3+",
+			Operation: db.GroupSetDifference,
+			GroupIDs:  []string{board.ID, staff.ID},
+			Filter:    &apicommon.MemberFilter{Weight: &apicommon.WeightRange{Min: &weight}},
+		}, combineURL...)
+	c.Assert(created.MembersCount, qt.Equals, 1)
+	group := requestAndParse[apicommon.OrganizationMemberGroupInfo](t, http.MethodGet, token, nil,
+		"organizations", orgAddress.String(), "groups", created.ID)
+	c.Assert(group.MemberIDs, qt.DeepEquals, []string{members[2].ID})
+
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, token,
+		&apicommon.CombineMemberGroupsRequest{Operation: "xor", GroupIDs: []string{board.ID}}, combineURL...)
+	requestAndAssertError(errors.ErrGroupNotFound, t, http.MethodPost, token,
+		&apicommon.CombineMemberGroupsRequest{
+			Operation: db.GroupSetUnion, GroupIDs: []string{board.ID, "000000000000000000000000"},
+		}, combineURL...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.CombineMemberGroupsRequest{Operation: db.GroupSetDifference, GroupIDs: []string{staff.ID, staff.ID}},
+		combineURL...)
+}
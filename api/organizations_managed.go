@@ -532,12 +532,27 @@ func (a *API) deleteManagedOrganizationHandler(w http.ResponseWriter, r *http.Re
 	}
 	// the emptied questions are ignored on purpose: this is an org teardown, so there is no
 	// election left to resize.
-	if _, _, err := a.db.DeleteAllOrgMembers(managedAddr); err != nil {
+	if _, _, err := a.db.DeleteAllOrgMembers(managedAddr, db.MemberChangeSource{}); err != nil {
 		log.Warnw("could not delete org members", "org", managedAddr.Hex(), "error", err)
 	}
 	if _, err := a.db.DeleteJobsByOrg(managedAddr); err != nil {
 		log.Warnw("could not delete jobs", "org", managedAddr.Hex(), "error", err)
 	}
+	if _, err := a.db.DeleteMemberUpdateRequestsByOrg(managedAddr); err != nil {
+		log.Warnw("could not delete member update requests", "org", managedAddr.Hex(), "error", err)
+	}
+	if _, err := a.db.DeleteCSPAuthByBundle(db.MemberSelfServiceAnchor(managedAddr)); err != nil {
+		log.Warnw("could not delete member self-service tokens", "org", managedAddr.Hex(), "error", err)
+	}
+	if _, err := a.db.DeleteMemberHistoryByOrg(managedAddr); err != nil {
+		log.Warnw("could not delete member history", "org", managedAddr.Hex(), "error", err)
+	}
+	if _, err := a.db.DeleteSuppressionsByOrg(managedAddr); err != nil {
+		log.Warnw("could not delete suppressions", "org", managedAddr.Hex(), "error", err)
+	}
+	if _, err := a.db.DeleteProcessTemplatesByOrg(managedAddr); err != nil {
+		log.Warnw("could not delete process templates", "org", managedAddr.Hex(), "error", err)
+	}
 	if _, err := a.db.DeleteInvitationsByOrg(managedAddr); err != nil {
 		log.Warnw("could not delete invitations", "org", managedAddr.Hex(), "error", err)
 	}
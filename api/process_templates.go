@@ -0,0 +1,337 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// maxProcessTemplateNameLength bounds the name of a process template.
+const maxProcessTemplateNameLength = 128
+
+// processTemplatesOrg resolves the organization in the path, gated on Manager/Admin of it,
+// writing the proper error and returning false on failure.
+func (a *API) processTemplatesOrg(w http.ResponseWriter, r *http.Request) (*db.Organization, bool) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return nil, false
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return nil, false
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin or manager of the organization").Write(w)
+		return nil, false
+	}
+	return org, true
+}
+
+// processTemplateFromPath loads the template in the path, of org, writing the proper error and
+// returning false on failure.
+func (a *API) processTemplateFromPath(w http.ResponseWriter, r *http.Request, org *db.Organization,
+) (*db.ProcessTemplate, bool) {
+	template, err := a.db.ProcessTemplate(org.Address, chi.URLParam(r, "templateId"))
+	if err != nil {
+		switch {
+		case errors.Is(err, db.ErrInvalidData):
+			errors.ErrMalformedURLParam.Withf("invalid template ID").Write(w)
+		case errors.Is(err, db.ErrNotFound):
+			errors.ErrProcessTemplateNotFound.Write(w)
+		default:
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		}
+		return nil, false
+	}
+	return template, true
+}
+
+// validateTemplateRequest checks a process supplied inline to a template the way a create request
+// is checked, except for what only the instantiation can resolve: the dates, which are not kept,
+// and the members the census and the question subsets name, which may change in between.
+func (a *API) validateTemplateRequest(org *db.Organization, req *apicommon.CreateVotingProcessRequest) error {
+	if len(req.Questions) == 0 || len(req.Questions) > db.MaxQuestionsPerProcess {
+		return errors.ErrMalformedBody.Withf("questions must be between 1 and %d", db.MaxQuestionsPerProcess)
+	}
+	if err := validateCensusWeightRule(req.Census); err != nil {
+		return err
+	}
+	if err := validateEphemeralCensus(req.Census); err != nil {
+		return err
+	}
+	if req.Census.MaxProxies < 0 || req.Census.MaxProxies > maxCensusProxies {
+		return errors.ErrInvalidData.Withf("maxProxies must be between 0 and %d", maxCensusProxies)
+	}
+	shapes := make([]apicommon.VotingProcessQuestionRequest, len(req.Questions))
+	for i, q := range req.Questions {
+		q.Eligibility = nil
+		shapes[i] = q
+	}
+	_, err := a.buildQuestions(org.Address, shapes, nil)
+	return err
+}
+
+// processTemplatesHandler godoc
+//
+//	@Summary		List the voting process templates of an organization
+//	@Description	List the saved process templates of the organization, by name. Requires Manager/Admin
+//	@Description	role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			page		query		integer	false	"Page number (default: 1)"
+//	@Param			limit		query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200			{object}	apicommon.ProcessTemplatesResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/process-templates [get]
+func (a *API) processTemplatesHandler(w http.ResponseWriter, r *http.Request) {
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, templates, err := a.db.ProcessTemplates(org.Address, params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	if templates == nil {
+		templates = []*db.ProcessTemplate{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.ProcessTemplatesResponse{
+		Pagination: pagination,
+		Templates:  templates,
+	})
+}
+
+// createProcessTemplateHandler godoc
+//
+//	@Summary		Save a voting process template
+//	@Description	Save a process to run again, under a name unique in the organization: either an
+//	@Description	existing process of the organization, draft or published (processId), or a process
+//	@Description	in the form of a create request (process). Texts, questions with their ballots and
+//	@Description	eligibility, and the census configuration are kept; dates are not. The census group
+//	@Description	or members, and the question subsets, are resolved when the template is used, so the
+//	@Description	members they name must still be there then: save a group for a recurring census. An
+//	@Description	organization keeps up to 100 templates. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string								true	"Organization address"
+//	@Param			request		body		apicommon.ProcessTemplateRequest	true	"Name and process"
+//	@Success		200			{object}	db.ProcessTemplate
+//	@Failure		400			{object}	errors.Error	"Invalid input data, or the template limit reached"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		409			{object}	errors.Error	"A template with the same name exists"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/process-templates [post]
+func (a *API) createProcessTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.ProcessTemplateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if req.Name == "" || len(req.Name) > maxProcessTemplateNameLength {
+		errors.ErrInvalidData.Withf("name must be between 1 and %d characters", maxProcessTemplateNameLength).Write(w)
+		return
+	}
+	if (req.ProcessID == "") == (req.Process == nil) {
+		errors.ErrInvalidData.Withf("exactly one of processId and process is required").Write(w)
+		return
+	}
+	template := &db.ProcessTemplate{OrgAddress: org.Address, Name: req.Name}
+	if req.Process != nil {
+		if err := a.validateTemplateRequest(org, req.Process); err != nil {
+			writeSubscriptionError(w, err)
+			return
+		}
+		template.Process = apicommon.TemplateProcessFromRequest(req.Process)
+	} else {
+		oid, err := primitive.ObjectIDFromHex(req.ProcessID)
+		if err != nil {
+			errors.ErrInvalidData.Withf("invalid processId").Write(w)
+			return
+		}
+		vp, questions, err := a.db.ProcessWithQuestions(oid)
+		if err != nil {
+			if errors.Is(err, db.ErrNotFound) {
+				errors.ErrProcessNotFound.Write(w)
+				return
+			}
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+			return
+		}
+		// a process of another organization is as good as missing
+		if vp.OrgAddress != org.Address {
+			errors.ErrProcessNotFound.Write(w)
+			return
+		}
+		tp, err := a.templateProcessOf(vp, questions)
+		if err != nil {
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+			return
+		}
+		template.Process = *tp
+	}
+	if _, err := a.db.CreateProcessTemplate(template); err != nil {
+		switch {
+		case errors.Is(err, db.ErrAlreadyExists):
+			errors.ErrDuplicateConflict.Withf("a template named %q exists", req.Name).Write(w)
+		case errors.Is(err, db.ErrInvalidData):
+			errors.ErrInvalidData.WithErr(err).Write(w)
+		default:
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		}
+		return
+	}
+	apicommon.HTTPWriteJSON(w, template)
+}
+
+// processTemplateHandler godoc
+//
+//	@Summary		Get a voting process template
+//	@Description	Get a saved process template of the organization. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			templateId	path		string	true	"Template ID"
+//	@Success		200			{object}	db.ProcessTemplate
+//	@Failure		400			{object}	errors.Error	"Invalid template ID"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Template not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/process-templates/{templateId} [get]
+func (a *API) processTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	template, ok := a.processTemplateFromPath(w, r, org)
+	if !ok {
+		return
+	}
+	apicommon.HTTPWriteJSON(w, template)
+}
+
+// deleteProcessTemplateHandler godoc
+//
+//	@Summary		Delete a voting process template
+//	@Description	Delete a saved process template of the organization. The drafts created from it are
+//	@Description	kept. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			templateId	path		string	true	"Template ID"
+//	@Success		200			{string}	string	"OK"
+//	@Failure		400			{object}	errors.Error	"Invalid template ID"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Template not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/process-templates/{templateId} [delete]
+func (a *API) deleteProcessTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	if err := a.db.DeleteProcessTemplate(org.Address, chi.URLParam(r, "templateId")); err != nil {
+		switch {
+		case errors.Is(err, db.ErrInvalidData):
+			errors.ErrMalformedURLParam.Withf("invalid template ID").Write(w)
+		case errors.Is(err, db.ErrNotFound):
+			errors.ErrProcessTemplateNotFound.Write(w)
+		default:
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		}
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// instantiateProcessTemplateHandler godoc
+//
+//	@Summary		Create a voting process draft from a template
+//	@Description	Create a new draft from a saved process template, with the title and dates of the
+//	@Description	body; an empty title keeps the template's. The census is built as a create request
+//	@Description	builds it, from the current members of the template group or from its members, and
+//	@Description	the question subsets are checked against it. Counts against the draft quota.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string								true	"Organization address"
+//	@Param			templateId	path		string								true	"Template ID"
+//	@Param			request		body		apicommon.CloneVotingProcessRequest	false	"Title and dates of the draft"
+//	@Success		200			{object}	apicommon.CreateVotingProcessResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data, a member not in the census, or a quota reached"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Template not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/process-templates/{templateId}/processes [post]
+func (a *API) instantiateProcessTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	template, ok := a.processTemplateFromPath(w, r, org)
+	if !ok {
+		return
+	}
+	clone, ok := decodeCloneRequest(r)
+	if !ok {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	req := apicommon.CreateVotingProcessRequestFromTemplate(org.Address.Bytes(), &template.Process, clone)
+	vpID, err := a.createVotingProcessDraft(org.Address, req, user.ID, nil, nil)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: vpID.Hex()})
+}
@@ -0,0 +1,126 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestCloneVotingProcess(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+
+	req := newVotingProcessRequest(orgAddress, ids)
+	req.Census.MaxProxies = 1
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint,
+	)
+	source := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil,
+		"processes", created.ProcessID)
+
+	// without a body the draft keeps the title and has no dates
+	cloned := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token, nil,
+		"processes", created.ProcessID, "clone")
+	c.Assert(cloned.ProcessID, qt.Not(qt.Equals), created.ProcessID)
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", cloned.ProcessID)
+	c.Assert(got.Published, qt.IsFalse)
+	c.Assert(got.Title, qt.DeepEquals, source.Title)
+	c.Assert(got.StartDate, qt.Equals, "")
+	c.Assert(got.EndDate, qt.Equals, "")
+	c.Assert(got.Census.MaxProxies, qt.Equals, 1)
+	c.Assert(got.Census.Size, qt.Equals, int64(2))
+	c.Assert(got.Questions, qt.HasLen, 2)
+	for i := range got.Questions {
+		c.Assert(got.Questions[i].Title, qt.DeepEquals, source.Questions[i].Title)
+		c.Assert(got.Questions[i].Choices, qt.DeepEquals, source.Questions[i].Choices)
+		c.Assert(got.Questions[i].Type, qt.Equals, source.Questions[i].Type)
+		c.Assert(got.Questions[i].TypeSetup, qt.Equals, source.Questions[i].TypeSetup)
+		c.Assert(got.Questions[i].BallotProtocol, qt.DeepEquals, source.Questions[i].BallotProtocol)
+	}
+	c.Assert(got.Questions[1].EligibleMemberIDs, qt.DeepEquals, ids[:1])
+
+	// a published process is cloned with new dates
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", created.ProcessID, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+	start := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	cloned = requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		&apicommon.CloneVotingProcessRequest{Title: db.MultiLangString{"default": "Again"}, StartDate: start},
+		"processes", created.ProcessID, "clone")
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", cloned.ProcessID)
+	c.Assert(got.Published, qt.IsFalse)
+	c.Assert(got.Title, qt.DeepEquals, db.MultiLangString{"default": "Again"})
+	c.Assert(got.StartDate, qt.Equals, start)
+	c.Assert(got.Questions[0].UpstreamID, qt.HasLen, 0)
+
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPost, testCreateUser(t, "otherpassword123"), nil,
+		"processes", created.ProcessID, "clone")
+}
+
+func TestProcessTemplates(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+	templatesURL := []string{"organizations", orgAddress.String(), "process-templates"}
+
+	// saved from a process
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, newVotingProcessRequest(orgAddress, ids), processesCreateEndpoint,
+	)
+	fromProcess := requestAndParse[db.ProcessTemplate](t, http.MethodPost, token,
+		&apicommon.ProcessTemplateRequest{Name: "assembly", ProcessID: created.ProcessID}, templatesURL...)
+	c.Assert(fromProcess.Process.Questions, qt.HasLen, 2)
+	c.Assert(fromProcess.Process.Census.MemberIDs, qt.HasLen, 2)
+	c.Assert(fromProcess.Process.Questions[1].EligibleMemberIDs, qt.DeepEquals, ids[:1])
+	requestAndAssertError(errors.ErrDuplicateConflict, t, http.MethodPost, token,
+		&apicommon.ProcessTemplateRequest{Name: "assembly", ProcessID: created.ProcessID}, templatesURL...)
+
+	// saved from a create request, whose ballots are checked
+	inline := newVotingProcessRequest(orgAddress, ids)
+	fromRequest := requestAndParse[db.ProcessTemplate](t, http.MethodPost, token,
+		&apicommon.ProcessTemplateRequest{Name: "board", Process: inline}, templatesURL...)
+	c.Assert(fromRequest.Process.Questions[1].Type, qt.Equals, db.VotingTypeMultiChoice)
+	bad := newVotingProcessRequest(orgAddress, ids)
+	bad.Questions[1].TypeSetup.MaxChoices = 5
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.ProcessTemplateRequest{Name: "bad", Process: bad}, templatesURL...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.ProcessTemplateRequest{Name: "both", ProcessID: created.ProcessID, Process: inline}, templatesURL...)
+
+	listed := requestAndParse[apicommon.ProcessTemplatesResponse](t, http.MethodGet, token, nil, templatesURL...)
+	c.Assert(listed.Templates, qt.HasLen, 2)
+	c.Assert(listed.Templates[0].Name, qt.Equals, "assembly")
+
+	// instantiated with new dates
+	start := time.Now().Add(24 * time.Hour).UTC().Format(time.RFC3339)
+	end := time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339)
+	draft := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		&apicommon.CloneVotingProcessRequest{StartDate: start, EndDate: end},
+		append(templatesURL, fromRequest.ID.Hex(), "processes")...)
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", draft.ProcessID)
+	c.Assert(got.Title, qt.DeepEquals, inline.Title)
+	c.Assert(got.StartDate, qt.Equals, start)
+	c.Assert(got.EndDate, qt.Equals, end)
+	c.Assert(got.Census.Size, qt.Equals, int64(2))
+	c.Assert(got.Questions, qt.HasLen, 2)
+	c.Assert(got.Questions[1].EligibleMemberIDs, qt.DeepEquals, ids[:1])
+
+	requestAndParse[db.ProcessTemplate](t, http.MethodGet, token, nil, append(templatesURL, fromProcess.ID.Hex())...)
+	_, code := testRequest(t, http.MethodDelete, token, nil, append(templatesURL, fromProcess.ID.Hex())...)
+	c.Assert(code, qt.Equals, http.StatusOK)
+	requestAndAssertError(errors.ErrProcessTemplateNotFound, t, http.MethodGet, token, nil,
+		append(templatesURL, fromProcess.ID.Hex())...)
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, testCreateUser(t, "otherpassword123"), nil,
+		templatesURL...)
+}
@@ -5,6 +5,7 @@ import (
 	stderrors "errors"
 	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
@@ -102,27 +103,44 @@ func (a *API) createVotingProcessHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrMalformedBody.Withf("questions must be between 1 and %d", db.MaxQuestionsPerProcess).Write(w)
 		return
 	}
-	if err := a.subscriptions.OrgCanCreateVotingProcessDraft(orgAddr); err != nil {
+	vpID, err := a.createVotingProcessDraft(orgAddr, req, user.ID, nil, nil)
+	if err != nil {
 		writeSubscriptionError(w, err)
 		return
 	}
+	apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: vpID.Hex()})
+}
+
+// createVotingProcessDraft creates the draft of a create request, with its census, once the
+// caller checked the request shape and the user's role in orgAddr. The draft quota is enforced
+// here, and createdBy is recorded as the creator of the draft, as is runoffOf for a run-off round.
+// A non-nil census is an existing one the draft shares instead, leaving req.Census unused; it is
+// never deleted on failure. It returns the id of the draft, or an errors.Error for a rejected
+// request; nothing is left behind on failure.
+func (a *API) createVotingProcessDraft(
+	orgAddr common.Address, req *apicommon.CreateVotingProcessRequest, createdBy uint64, runoffOf *db.RunoffOrigin,
+	census *db.Census,
+) (primitive.ObjectID, error) {
+	if err := a.subscriptions.OrgCanCreateVotingProcessDraft(orgAddr); err != nil {
+		return primitive.NilObjectID, err
+	}
 	start, end, err := parseProcessDates(req)
 	if err != nil {
-		errors.ErrMalformedBody.WithErr(err).Write(w)
-		return
+		return primitive.NilObjectID, errors.ErrMalformedBody.WithErr(err)
 	}
-	census, err := a.resolveOrCreateDefaultCensus(req.Census, orgAddr)
-	if err != nil {
-		writeSubscriptionError(w, err)
-		return
+	rollbackCensus := func() {}
+	if census == nil {
+		if census, err = a.resolveOrCreateDefaultCensus(req.Census, orgAddr); err != nil {
+			return primitive.NilObjectID, err
+		}
+		rollbackCensus = func() { _ = a.db.DelCensus(census.ID.Hex()) }
 	}
 	// validate + build the questions (incl. eligibility against the census) before any process
 	// write, so a bad request rolls the census back and never creates a half-written draft.
 	built, err := a.buildQuestions(orgAddr, req.Questions, census)
 	if err != nil {
-		_ = a.db.DelCensus(census.ID.Hex())
-		writeSubscriptionError(w, err)
-		return
+		rollbackCensus()
+		return primitive.NilObjectID, err
 	}
 
 	vp := &db.VotingProcess{
@@ -135,22 +153,22 @@ func (a *API) createVotingProcessHandler(w http.ResponseWriter, r *http.Request)
 		StartDate:   start,
 		EndDate:     end,
 		CensusID:    census.ID,
+		CreatedBy:   createdBy,
+		RunoffOf:    runoffOf,
 	}
 	vpID, err := a.db.SetVotingProcess(vp)
 	if err != nil {
-		_ = a.db.DelCensus(census.ID.Hex())
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		rollbackCensus()
+		return primitive.NilObjectID, err
 	}
 	if err := a.writeQuestions(vp, built); err != nil {
 		// roll back the just-created draft and its census so a failed create leaves nothing
 		// behind (an orphaned draft would still count against the org's MaxDrafts quota).
 		_ = a.db.DeleteVotingProcess(vpID)
-		_ = a.db.DelCensus(census.ID.Hex())
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		rollbackCensus()
+		return primitive.NilObjectID, err
 	}
-	apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: vpID.Hex()})
+	return vpID, nil
 }
 
 // buildQuestions resolves and validates the questions of a voting process in memory — including
@@ -224,10 +242,16 @@ func (a *API) buildQuestions(
 		if err != nil {
 			return nil, errors.ErrInvalidData.Withf("question %d: %v", i, err)
 		}
+		if err := validateQuestionConditions(i, q.Conditions, built); err != nil {
+			return nil, err
+		}
 		eligible, err := a.resolveEligibleMemberIDs(q.Eligibility, census, orgAddress)
 		if err != nil {
 			return nil, err
 		}
+		if err := validateDecisionRules(i, q.Rules, shape.Type, q.Choices, census, len(eligible) > 0); err != nil {
+			return nil, err
+		}
 		built = append(built, &db.VotingProcessQuestion{
 			OrgAddress:        orgAddress,
 			Order:             i,
@@ -240,11 +264,118 @@ func (a *API) buildQuestions(
 			SecretUntilTheEnd: q.SecretUntilTheEnd,
 			EligibleMemberIDs: eligible,
 			Metadata:          q.Metadata,
+			Conditions:        q.Conditions,
+			Rules:             q.Rules,
 		})
 	}
 	return built, nil
 }
 
+// validateQuestionConditions checks the conditions of question i against the questions built
+// before it. A condition can only name an earlier question, which keeps the dependency graph
+// acyclic and lets a client walk the questions in order, showing each one once the answers it
+// depends on are known. The question it names must be singlechoice, so an answer is one value, and
+// not secretUntilTheEnd: the CSP reads the voter's ballot on it from the chain, which serves an
+// encrypted ballot in clear only once the election ends.
+func validateQuestionConditions(i int, conditions []db.QuestionCondition, earlier []*db.VotingProcessQuestion) error {
+	seen := make(map[int]bool, len(conditions))
+	for _, cond := range conditions {
+		if cond.Question < 0 || cond.Question >= i {
+			return errors.ErrInvalidData.Withf(
+				"question %d: a condition can only depend on an earlier question, not on question %d", i, cond.Question,
+			)
+		}
+		if seen[cond.Question] {
+			return errors.ErrInvalidData.Withf("question %d: more than one condition on question %d", i, cond.Question)
+		}
+		seen[cond.Question] = true
+		parent := earlier[cond.Question]
+		if parent.Type != db.VotingTypeSingleChoice {
+			return errors.ErrInvalidData.Withf(
+				"question %d: a condition can only depend on a %s question, question %d is %s",
+				i, db.VotingTypeSingleChoice, cond.Question, parent.Type,
+			)
+		}
+		if parent.SecretUntilTheEnd {
+			return errors.ErrInvalidData.Withf(
+				"question %d: a condition cannot depend on question %d, which is secretUntilTheEnd", i, cond.Question,
+			)
+		}
+		if len(cond.Choices) == 0 {
+			return errors.ErrInvalidData.Withf("question %d: a condition on question %d lists no choices", i, cond.Question)
+		}
+		values := make(map[uint32]bool, len(parent.Choices))
+		for _, choice := range parent.Choices {
+			values[choice.Value] = true
+		}
+		listed := make(map[uint32]bool, len(cond.Choices))
+		for _, v := range cond.Choices {
+			switch {
+			case !values[v]:
+				return errors.ErrInvalidData.Withf("question %d: question %d has no choice with value %d", i, cond.Question, v)
+			case listed[v]:
+				return errors.ErrInvalidData.Withf("question %d: a condition lists choice %d twice", i, v)
+			}
+			listed[v] = true
+		}
+	}
+	return nil
+}
+
+// validateDecisionRules checks the decision rules of question i against its ballot. A majority is
+// a share of one choice's votes, so it needs the votes per choice of a singlechoice or multichoice
+// question, and a Choice to measure it on must be one of the question's. A quorum counts the weight
+// that voted: a non-weighted census counts the votes, while a weighted one reads it from the tally,
+// where every ballot adds its weight to one cell of each field except in a cumulative ballot. A
+// weighted quorum is measured against the whole census weight, so it cannot apply to a question
+// only part of the census may vote.
+func validateDecisionRules(
+	i int, rules *db.DecisionRules, qType string, choices []db.Choice, census *db.Census, restricted bool,
+) error {
+	if rules == nil {
+		return nil
+	}
+	if rules.Quorum == 0 && rules.Majority == "" {
+		return errors.ErrInvalidData.Withf("question %d: rules declare neither a quorum nor a majority", i)
+	}
+	if rules.Quorum < 0 || rules.Quorum > 1 {
+		return errors.ErrInvalidData.Withf("question %d: quorum must be a share between 0 and 1", i)
+	}
+	if rules.Quorum > 0 && census != nil && census.Weighted {
+		switch {
+		case qType != db.VotingTypeSingleChoice && qType != db.VotingTypeMultiChoice && qType != db.VotingTypeRanked:
+			return errors.ErrInvalidData.Withf(
+				"question %d: a quorum on a weighted census needs a %s, %s or %s question",
+				i, db.VotingTypeSingleChoice, db.VotingTypeMultiChoice, db.VotingTypeRanked,
+			)
+		case restricted:
+			return errors.ErrInvalidData.Withf(
+				"question %d: a quorum on a weighted census cannot apply to a question with an eligibility list", i,
+			)
+		}
+	}
+	switch rules.Majority {
+	case "":
+		if rules.Choice != nil {
+			return errors.ErrInvalidData.Withf("question %d: a rules choice needs a majority", i)
+		}
+		return nil
+	case db.MajoritySimple, db.MajorityAbsolute, db.MajorityTwoThirds:
+	default:
+		return errors.ErrInvalidData.Withf("question %d: unknown majority %q", i, rules.Majority)
+	}
+	if qType != db.VotingTypeSingleChoice && qType != db.VotingTypeMultiChoice {
+		return errors.ErrInvalidData.Withf(
+			"question %d: a majority applies to %s and %s questions only",
+			i, db.VotingTypeSingleChoice, db.VotingTypeMultiChoice,
+		)
+	}
+	if rules.Choice != nil && !slices.ContainsFunc(choices, func(c db.Choice) bool { return c.Value == *rules.Choice }) {
+		return errors.ErrInvalidData.Withf("question %d: no choice with value %d", i, *rules.Choice)
+	}
+	return nil
+}
+
 // refusePublishInProgress answers 409 while a publish worker holds the process, and reports
 // whether it did.
 //
@@ -427,9 +558,12 @@ func (a *API) updateVotingProcessHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
-	// success: reap the previous census (and its participants) so edits don't accumulate orphans.
+	// success: reap the previous census (and its participants) so edits don't accumulate orphans,
+	// unless it is the census of another process, as a run-off's is.
 	if oldCensusID != census.ID {
-		_ = a.db.DelCensus(oldCensusID.Hex())
+		if shared, err := a.censusSharedWithOtherProcess(oldCensusID, vp.ID); err == nil && !shared {
+			_ = a.db.DelCensus(oldCensusID.Hex())
+		}
 	}
 	apicommon.HTTPWriteOK(w)
 }
@@ -479,6 +613,10 @@ func (a *API) votingProcessInfoHandler(w http.ResponseWriter, r *http.Request) {
 	// each needs a Vochain round-trip, so bounded pools keep this read fast for a many-question process.
 	a.resolveQuestionEncryptionKeysBatch(questions)
 	a.resolveQuestionResultsBatch(questions)
+	if err := a.withoutLiveTallies(vp.OrgAddress, questions); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
 	// a draft written before the two ballot halves were reconciled is served reconciled, so the
 	// body a client echoes back is one authoring still accepts.
 	reconcileDraftQuestionShapes(questions)
@@ -487,7 +625,15 @@ func (a *API) votingProcessInfoHandler(w http.ResponseWriter, r *http.Request) {
 		redactQuestionsForPublic(questions)
 	}
 	resp := apicommon.VotingProcessResponseFromDB(vp, questions, census, a.account.ChainID())
-	resp.Census.TotalWeight = a.censusTotalWeight(census)
+	// a total that could not be aggregated is left out (0, dropped by omitempty), so the client renders
+	// "not available" instead of computing every percentage against a wrong total
+	if resp.Census.TotalWeight, err = a.censusTotalWeight(census); err != nil {
+		log.Warnw("census total weight: aggregation failed", "census", vp.CensusID.Hex(), "error", err)
+	}
+	if resp.Runoffs, err = a.runoffLinks(vp.ID, isManager); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
 	apicommon.HTTPWriteJSON(w, resp)
 }
 
@@ -736,6 +882,10 @@ func (a *API) votingProcessQuestionHandler(w http.ResponseWriter, r *http.Reques
 	question.EncryptionKeys = a.resolveQuestionEncryptionKeys(question)
 	// surface the on-chain tally once the question is in RESULTS status (nil/no chain call otherwise).
 	question.Results = a.resolveQuestionResults(question)
+	if err := a.withoutLiveTallies(vp.OrgAddress, []db.VotingProcessQuestion{*question}); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
 	resp := apicommon.PublicQuestionResponseFromDB(question, census)
 	// the eligibility subset names who may vote: only a manager/admin of the owning org sees it
 	if a.optionalManager(r, vp.OrgAddress) {
@@ -807,23 +957,22 @@ func (a *API) resolveQuestionEncryptionKeys(q *db.VotingProcessQuestion) []db.En
 
 // censusTotalWeight returns the whole-census total voting weight (sum of members' weights) exposed
 // on CensusSpec. A non-weighted census contributes weight 1 per member, so the total is just the
-// participant count (Size) with no query; a weighted census sums OrgMember.Weight over its members.
-// On aggregation failure it returns 0 (NOT Size): totalWeight backs a report/certification denominator,
-// where a plausible-but-wrong total is worse than an absent one — 0 makes omitempty drop the field so
-// the client renders "not available" instead of computing every percentage against a wrong total.
-func (a *API) censusTotalWeight(census *db.Census) int64 {
+// participant count (Size) with no query; a weighted census sums the weights its WeightRule stored on
+// the participants, or else OrgMember.Weight.
+// An aggregation failure is returned, never a fallback total: totalWeight backs a report/certification
+// denominator, where a plausible-but-wrong total is worse than an absent one.
+func (a *API) censusTotalWeight(census *db.Census) (int64, error) {
 	if census == nil {
-		return 0
+		return 0, nil
 	}
 	if !census.Weighted {
-		return census.Size
+		return census.Size, nil
 	}
 	total, err := a.db.CensusTotalWeight(census.ID.Hex())
 	if err != nil {
-		log.Warnw("census total weight: aggregation failed", "census", census.ID.Hex(), "error", err)
-		return 0
+		return 0, fmt.Errorf("could not aggregate the census total weight: %w", err)
 	}
-	return total
+	return total, nil
 }
 
 // questionResultsFromElection maps a question's on-chain election onto the QuestionResults shape.
@@ -886,13 +1035,24 @@ func (a *API) resolveQuestionResultsBatch(questions []db.VotingProcessQuestion)
 }
 
 // electionResultsBatch fetches the on-chain tally of every published question concurrently (bounded)
-// and maps each to a results entry, preserving question order. Questions not yet on chain are skipped.
+// and maps each to a results entry with its counted outcome and decision, preserving question order.
+// Questions not yet on chain are skipped.
 // Unlike the read resolvers it is all-or-error: the first election fetch failure (by question order)
 // is returned so GET /processes/{id}/results never emits a silent partial tally set. Returns nil when
 // no question is on chain (preserving the endpoint's "questions": null shape for that case).
 func (a *API) electionResultsBatch(
-	questions []db.VotingProcessQuestion,
+	questions []db.VotingProcessQuestion, census *db.Census,
 ) ([]apicommon.VotingProcessQuestionResults, error) {
+	weighted := census != nil && census.Weighted
+	delegated := census != nil && census.MaxProxies > 0
+	// the census weight is aggregated once, and only when a question declares rules to need it
+	var censusWeight int64
+	if weighted && slices.ContainsFunc(questions, func(q db.VotingProcessQuestion) bool { return q.Rules != nil }) {
+		var err error
+		if censusWeight, err = a.censusTotalWeight(census); err != nil {
+			return nil, err
+		}
+	}
 	entries := make([]*apicommon.VotingProcessQuestionResults, len(questions))
 	errs := make([]error, len(questions))
 	parallelForEach(len(questions), func(i int) {
@@ -905,10 +1065,13 @@ func (a *API) electionResultsBatch(
 			errs[i] = fmt.Errorf("question %s: %w", q.ID.Hex(), err)
 			return
 		}
+		qr := questionResultsFromElection(election)
+		qr.Outcome = a.questionOutcome(q, &qr)
+		qr.Decision = questionDecision(q, &qr, weighted, delegated, censusWeight)
 		entries[i] = &apicommon.VotingProcessQuestionResults{
 			QuestionID:      q.ID.Hex(),
 			UpstreamID:      q.UpstreamID,
-			QuestionResults: questionResultsFromElection(election),
+			QuestionResults: qr,
 		}
 	})
 	var out []apicommon.VotingProcessQuestionResults
@@ -969,6 +1132,16 @@ func (a *API) votingProcessParticipantHandler(w http.ResponseWriter, r *http.Req
 //	@Description	Public per-question on-chain results of a published voting process: one entry per
 //	@Description	published question, each with its tally (vote count, max voters, whether final, and
 //	@Description	the per-choice results). No authentication is required.
+//	@Description	Each question with a named type also gets an outcome counted by the rules of its
+//	@Description	type: plurality for singlechoice, approval for multichoice, Borda for ranked and
+//	@Description	total points for cumulative, as scores per choice and the winners. Once the
+//	@Description	results of a ranked question are final its outcome adds an instant-runoff count,
+//	@Description	read from the individual ballots and stored, so it may be missing from the first
+//	@Description	reads while the count is made. A question with decision rules also gets a
+//	@Description	decision: whether its quorum was reached and its majority met, and whether it
+//	@Description	passed, pending until its results are final. Unless the organization's plan has
+//	@Description	live results, the tally, outcome and decision of a question are left out until its
+//	@Description	results are final; its vote count is always there.
 //	@Tags			processes
 //	@Produce		json
 //	@Param			processId	path		string	true	"Process ID"
@@ -998,12 +1171,31 @@ func (a *API) votingProcessResultsHandler(w http.ResponseWriter, r *http.Request
 	}
 	// fetch every published question's tally concurrently (bounded); all-or-error so this endpoint
 	// never emits a partial tally set (a transient chain error on one question fails the response).
-	entries, err := a.electionResultsBatch(questions)
+	census, _ := a.db.Census(vp.CensusID.Hex())
+	entries, err := a.electionResultsBatch(questions, census)
 	if err != nil {
 		errors.ErrVochainRequestFailed.WithErr(err).Write(w)
 		return
 	}
-	apicommon.HTTPWriteJSON(w, &apicommon.VotingProcessResultsResponse{ID: oid.Hex(), Questions: entries})
+	live, err := a.subscriptions.OrgHasLiveResults(vp.OrgAddress)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	if !live {
+		for i := range entries {
+			withoutLiveTally(&entries[i].QuestionResults)
+		}
+	}
+	resp := &apicommon.VotingProcessResultsResponse{ID: oid.Hex(), Questions: entries}
+	if vp.RunoffOf != nil {
+		resp.RunoffOf = &apicommon.RunoffLink{ProcessID: vp.RunoffOf.ProcessID.Hex(), QuestionID: vp.RunoffOf.QuestionID.Hex()}
+	}
+	if resp.Runoffs, err = a.runoffLinks(vp.ID, false); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, resp)
 }
 
 // votingProcessID parses and validates the {processId} URL param.
@@ -55,9 +55,12 @@ func (a *API) deleteVotingProcessHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
-	// best-effort: drop the draft's inline census so it is not orphaned.
+	// best-effort: drop the draft's inline census so it is not orphaned, unless it is the census of
+	// another process, as a run-off's is.
 	if !vp.CensusID.IsZero() {
-		_ = a.db.DelCensus(vp.CensusID.Hex())
+		if shared, err := a.censusSharedWithOtherProcess(vp.CensusID, vp.ID); err == nil && !shared {
+			_ = a.db.DelCensus(vp.CensusID.Hex())
+		}
 	}
 	apicommon.HTTPWriteOK(w)
 }
@@ -208,7 +211,7 @@ func (a *API) votingProcessParticipantsHandler(w http.ResponseWriter, r *http.Re
 //	@Failure		400			{object}	errors.Error							"Invalid input data"
 //	@Failure		401			{object}	errors.Error							"Unauthorized"
 //	@Failure		404			{object}	errors.Error							"Process not found"
-//	@Failure		409			{object}	errors.Error							"Process is not published"
+//	@Failure		409			{object}	errors.Error							"Process is not published, or census is frozen"
 //	@Failure		500			{object}	errors.Error							"Internal server error"
 //	@Router			/processes/{processId}/census [put]
 func (a *API) updateVotingProcessCensusHandler(w http.ResponseWriter, r *http.Request) {
@@ -231,6 +234,10 @@ func (a *API) updateVotingProcessCensusHandler(w http.ResponseWriter, r *http.Re
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
+	if census.Frozen != nil {
+		errors.ErrCensusFrozen.Write(w)
+		return
+	}
 
 	var req apicommon.AddCensusParticipantsRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
@@ -272,6 +279,7 @@ func (a *API) updateVotingProcessCensusHandler(w http.ResponseWriter, r *http.Re
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 		return
 	}
+	a.recordCensusSnapshot(census)
 
 	// only whole-census questions grow when participants are added; one that names an eligibility
 	// subset is unaffected by who else joined the census.
@@ -389,6 +397,11 @@ func (a *API) updateVotingProcessQuestionCensusHandler(w http.ResponseWriter, r
 		return
 	}
 
+	// the eligibility of a draft question is part of the draft, yet changing it does not write the
+	// process
+	if !a.voidReview(w, vp) {
+		return
+	}
 	won, err := a.db.SetQuestionEligibleMemberIDs(question.ID, previous, eligible)
 	if err != nil {
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
@@ -506,7 +519,7 @@ func diffMemberIDs(previous, next []string) (added, removed []string) {
 //	@Failure		400			{object}	errors.Error							"Invalid input data"
 //	@Failure		401			{object}	errors.Error							"Unauthorized"
 //	@Failure		404			{object}	errors.Error							"Process not found"
-//	@Failure		409			{object}	errors.Error							"Process is not published, or a member has already been signed for"
+//	@Failure		409			{object}	errors.Error							"Process is not published, census is frozen, or a member already signed for"
 //	@Failure		500			{object}	errors.Error							"Internal server error"
 //	@Router			/processes/{processId}/census [delete]
 func (a *API) removeVotingProcessCensusHandler(w http.ResponseWriter, r *http.Request) {
@@ -560,7 +573,7 @@ func (a *API) removeVotingProcessCensusHandler(w http.ResponseWriter, r *http.Re
 
 	censusID := vp.CensusID.Hex()
 	// before any write, so a refusal leaves the census exactly as it was
-	if a.refuseBlockedVoters(w, []string{censusID}, targetIDs) {
+	if a.refuseFrozenCensuses(w, []string{censusID}) || a.refuseBlockedVoters(w, []string{censusID}, targetIDs) {
 		return
 	}
 
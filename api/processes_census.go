@@ -9,20 +9,78 @@ import (
 	"github.com/vocdoni/saas-backend/api/apicommon"
 	"github.com/vocdoni/saas-backend/db"
 	"github.com/vocdoni/saas-backend/errors"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
+// maxCensusProxies bounds MaxProxies: a delegate holding more votes than this is no longer a
+// proxy but a bloc.
+const maxCensusProxies = 100
+
+// validateCensusWeightRule checks the weight rule of a census spec, if any: it must be complete,
+// and only a weighted census has weights to compute.
+func validateCensusWeightRule(spec apicommon.CensusSpec) error {
+	if spec.WeightRule == nil {
+		return nil
+	}
+	if !spec.Weighted {
+		return errors.ErrInvalidData.Withf("a weight rule needs a weighted census")
+	}
+	if err := spec.WeightRule.Validate(); err != nil {
+		return errors.ErrInvalidData.WithErr(err)
+	}
+	return nil
+}
+
+// validateEphemeralCensus checks an ephemeral census spec, if it is one: its participants are
+// imported from a file later, so it selects no organization members.
+func validateEphemeralCensus(spec apicommon.CensusSpec) error {
+	if spec.Ephemeral && (spec.GroupID != "" || len(spec.MemberIDs) > 0) {
+		return errors.ErrInvalidData.Withf("an ephemeral census takes no groupId nor memberIds")
+	}
+	return nil
+}
+
+// censusSharedWithOtherProcess reports whether a voting process other than processID is built on
+// the census censusID, as a run-off round is on the census of the process it follows. Such a census
+// is not the draft's own to delete or re-import.
+func (a *API) censusSharedWithOtherProcess(censusID, processID primitive.ObjectID) (bool, error) {
+	processes, err := a.db.VotingProcessesByCensus([]string{censusID.Hex()})
+	if err != nil {
+		return false, err
+	}
+	for i := range processes {
+		if processes[i].ID != processID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 // resolveOrCreateDefaultCensus materializes the inline census spec of a voting process into
 // a db.Census (auth/2FA policy + participants) and returns it. The census type is inferred
 // from the 2FA fields (SetCensus does this). Census/vote quotas are enforced, mirroring
 // addCensusParticipantsHandler. The census is created unpublished; publishing happens at
 // process publish time.
 func (a *API) resolveOrCreateDefaultCensus(spec apicommon.CensusSpec, orgAddress common.Address) (*db.Census, error) {
+	if err := validateCensusWeightRule(spec); err != nil {
+		return nil, err
+	}
+	if err := validateEphemeralCensus(spec); err != nil {
+		return nil, err
+	}
+	if spec.MaxProxies < 0 || spec.MaxProxies > maxCensusProxies {
+		return nil, errors.ErrInvalidData.Withf("maxProxies must be between 0 and %d", maxCensusProxies)
+	}
 	census := &db.Census{
-		OrgAddress:  orgAddress,
-		Weighted:    spec.Weighted,
-		AuthFields:  spec.AuthFields,
-		TwoFaFields: spec.TwoFaFields,
-		CreatedAt:   time.Now(),
+		OrgAddress:      orgAddress,
+		Weighted:        spec.Weighted,
+		WeightRule:      spec.WeightRule,
+		Ephemeral:       spec.Ephemeral,
+		FreezeOnPublish: spec.FreezeOnPublish,
+		MaxProxies:      spec.MaxProxies,
+		AuthFields:      spec.AuthFields,
+		TwoFaFields:     spec.TwoFaFields,
+		CreatedAt:       time.Now(),
 	}
 	censusID, err := a.db.SetCensus(census)
 	if err != nil {
@@ -40,6 +98,9 @@ func (a *API) resolveOrCreateDefaultCensus(spec apicommon.CensusSpec, orgAddress
 	switch {
 	case spec.GroupID != "":
 		if _, err := a.db.PopulateGroupCensus(census, spec.GroupID); err != nil {
+			if errors.Is(err, db.ErrInvalidData) {
+				return nil, errors.ErrInvalidData.WithErr(err)
+			}
 			return nil, fmt.Errorf("failed to populate group census: %w", err)
 		}
 		if err := a.subscriptions.OrgCanAddCensusParticipants(orgAddress, censusID, 0); err != nil {
@@ -53,7 +114,8 @@ func (a *API) resolveOrCreateDefaultCensus(spec apicommon.CensusSpec, orgAddress
 			return nil, fmt.Errorf("failed to add census participants: %w", err)
 		}
 	default:
-		// no members and no group: an empty (auth-only shell) census
+		// no members and no group: an empty (auth-only shell) census, or an ephemeral one whose
+		// participants are imported later
 	}
 
 	// persist the resulting census size for downstream maxCensusSize computation
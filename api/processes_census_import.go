@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/csv"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// maxEphemeralCensusCSVBytes caps the CSV body of an ephemeral census import.
+const maxEphemeralCensusCSVBytes = 32 << 20
+
+// ephemeralCensusColumns maps the lower-cased CSV headers an ephemeral census import understands
+// to the member field they fill. Any other column is kept in the member's other data.
+var ephemeralCensusColumns = map[string]func(*db.OrgMember, string) error{
+	"membernumber": func(m *db.OrgMember, v string) error { m.MemberNumber = v; return nil },
+	"name":         func(m *db.OrgMember, v string) error { m.Name = v; return nil },
+	"surname":      func(m *db.OrgMember, v string) error { m.Surname = v; return nil },
+	"nationalid":   func(m *db.OrgMember, v string) error { m.NationalID = v; return nil },
+	"birthdate":    func(m *db.OrgMember, v string) error { m.BirthDate = v; return nil },
+	"email":        func(m *db.OrgMember, v string) error { m.Email = v; return nil },
+	"phone":        func(m *db.OrgMember, v string) error { m.PlaintextPhone = v; return nil },
+	"weight": func(m *db.OrgMember, v string) error {
+		if v == "" {
+			return nil
+		}
+		weight, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid weight %q", v)
+		}
+		m.Weight = weight
+		return nil
+	},
+}
+
+// readEphemeralCensusCSV parses the participants of an ephemeral census from a CSV with a header
+// row. Each participant weighs 1 unless the file has a weight column. Row errors are prefixed with
+// "line N:", the 1-based position of the participant row.
+func readEphemeralCensusCSV(r io.Reader) ([]*db.OrgMember, []string, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		if stderrors.Is(err, io.EOF) {
+			return nil, nil, fmt.Errorf("empty file")
+		}
+		return nil, nil, fmt.Errorf("invalid CSV header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, name := range header {
+		columns[i] = strings.TrimSpace(name)
+	}
+
+	var members []*db.OrgMember
+	var rowErrs []string
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if stderrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		member := &db.OrgMember{Weight: 1}
+		for i, value := range record {
+			if i >= len(columns) || columns[i] == "" {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			set, known := ephemeralCensusColumns[strings.ToLower(columns[i])]
+			if !known {
+				if value != "" {
+					if member.Other == nil {
+						member.Other = make(map[string]any)
+					}
+					member.Other[columns[i]] = value
+				}
+				continue
+			}
+			if err := set(member, value); err != nil {
+				rowErrs = append(rowErrs, fmt.Sprintf("line %d: %v", line, err))
+			}
+		}
+		members = append(members, member)
+	}
+	return members, rowErrs, nil
+}
+
+// importEphemeralCensusHandler godoc
+//
+//	@Summary		Import the participants of an ephemeral census
+//	@Description	Replace the participants of the ephemeral census (census.ephemeral) of a draft voting
+//	@Description	process with the rows of a CSV file. The participants are not added as organization
+//	@Description	members: they live only in the census, can authenticate through the CSP like members,
+//	@Description	and are purged once every process using the census has ended. The first row names
+//	@Description	the columns: memberNumber, name, surname, nationalId, birthDate, email, phone and
+//	@Description	weight are understood (case-insensitive), any other column is kept as extra data a
+//	@Description	weight rule can read. Each row must carry the census auth and 2FA fields and log in
+//	@Description	uniquely. The import is all or nothing: on invalid rows nothing is stored and 400 lists
+//	@Description	them as "line N: ...", N being the position of the row after the header. Editing the
+//	@Description	draft recreates its census, so import after the last edit. Requires Manager/Admin role
+//	@Description	and is subject to the plan's census quota.
+//	@Tags			processes
+//	@Accept			text/csv
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string							true	"Process ID"
+//	@Param			request		body		string							true	"CSV file"
+//	@Success		200			{object}	apicommon.AddMembersResponse	"Participants imported"
+//	@Failure		400			{object}	errors.Error					"Invalid file or rows, or census not ephemeral"
+//	@Failure		401			{object}	errors.Error					"Unauthorized"
+//	@Failure		404			{object}	errors.Error					"Process not found"
+//	@Failure		409			{object}	errors.Error					"Process is published, or census frozen"
+//	@Failure		413			{object}	errors.Error					"File too large"
+//	@Failure		500			{object}	errors.Error					"Internal server error"
+//	@Router			/processes/{processId}/census/import [post]
+func (a *API) importEphemeralCensusHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	// loads the process + questions and gates on Manager/Admin of the owning org.
+	vp, _, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return
+	}
+	// the census is published with the process, so its participants are final by then
+	if vp.Published {
+		errors.ErrDuplicateConflict.Withf("process already published and not in draft mode").Write(w)
+		return
+	}
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if !census.Ephemeral {
+		errors.ErrInvalidData.Withf("the process census is not ephemeral").Write(w)
+		return
+	}
+	// a run-off round votes with the participants of the process it follows
+	if shared, err := a.censusSharedWithOtherProcess(census.ID, vp.ID); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	} else if shared {
+		errors.ErrDuplicateConflict.Withf("the census is shared with another process").Write(w)
+		return
+	}
+
+	members, rowErrs, err := readEphemeralCensusCSV(http.MaxBytesReader(w, r.Body, maxEphemeralCensusCSVBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if stderrors.As(err, &tooLarge) {
+			errors.ErrRequestBodyTooLarge.Withf("the limit is %d bytes", maxEphemeralCensusCSVBytes).Write(w)
+			return
+		}
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	}
+	if len(rowErrs) > 0 {
+		errors.ErrInvalidData.WithData(rowErrs).Write(w)
+		return
+	}
+
+	// the import replaces the current participants, so only the difference counts against the quota
+	current, err := a.db.CountCensusParticipants(census.ID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if err := a.subscriptions.OrgCanAddCensusParticipants(census.OrgAddress, census.ID.Hex(),
+		len(members)-int(current)); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+
+	// the participants are part of the draft, yet replacing them does not write the process
+	if !a.voidReview(w, vp) {
+		return
+	}
+	added, memberErrs, err := a.db.SetEphemeralCensusParticipants(census, members)
+	if err != nil {
+		if stderrors.Is(err, db.ErrInvalidData) {
+			errors.ErrInvalidData.WithErr(err).Write(w)
+			return
+		}
+		if stderrors.Is(err, db.ErrCensusFrozen) {
+			errors.ErrCensusFrozen.Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if len(memberErrs) > 0 {
+		msgs := make([]string, 0, len(memberErrs))
+		for _, err := range memberErrs {
+			msgs = append(msgs, err.Error())
+		}
+		errors.ErrInvalidData.WithData(msgs).Write(w)
+		return
+	}
+
+	census.Size = int64(added)
+	if _, err := a.db.SetCensus(census); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.AddMembersResponse{Added: uint32(added)})
+}
@@ -0,0 +1,84 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+)
+
+// importCensusCSV posts csv to POST /processes/{processId}/census/import.
+func importCensusCSV(t *testing.T, token, processID, csv string) ([]byte, int) {
+	t.Helper()
+	u := fmt.Sprintf("http://%s:%d/processes/%s/census/import", testHost, testPort, processID)
+	req, err := http.NewRequest(http.MethodPost, u, strings.NewReader(csv))
+	qt.Assert(t, err, qt.IsNil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "text/csv")
+	resp, err := http.DefaultClient.Do(req)
+	qt.Assert(t, err, qt.IsNil)
+	defer func() { _ = resp.Body.Close() }()
+	data, err := io.ReadAll(resp.Body)
+	qt.Assert(t, err, qt.IsNil)
+	return data, resp.StatusCode
+}
+
+func TestImportEphemeralCensus(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "ephemeralpass123")
+	orgAddress := testCreateOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+
+	req := minimalVotingProcessRequest(orgAddress)
+	req.Census = apicommon.CensusSpec{
+		Ephemeral:   true,
+		Weighted:    true,
+		AuthFields:  db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber},
+		TwoFaFields: db.OrgMemberTwoFaFields{db.OrgMemberTwoFaFieldEmail},
+	}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint)
+
+	// an ephemeral census selects no organization members
+	bad := minimalVotingProcessRequest(orgAddress)
+	bad.Census.Ephemeral, bad.Census.MemberIDs = true, []string{"000000000000000000000000"}
+	requestAndAssertCode(http.StatusBadRequest, t, http.MethodPost, token, bad, processesCreateEndpoint)
+
+	// a duplicate login (after normalization) or a missing 2FA field rejects the whole file
+	_, code := importCensusCSV(t, token, created.ProcessID,
+		"memberNumber,email,weight\n1,a@example.com,2\n1,A@example.com ,3\n3,,1\n")
+	c.Assert(code, qt.Equals, http.StatusBadRequest)
+	got := requestAndParse[apicommon.VotingProcessResponse](
+		t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Census.Ephemeral, qt.IsTrue)
+	c.Assert(got.Census.Size, qt.Equals, int64(0))
+
+	data, code := importCensusCSV(t, token, created.ProcessID,
+		"memberNumber,Email,weight,tier\n1,a@example.com,2,gold\n2,b@example.com,3,silver\n")
+	c.Assert(code, qt.Equals, http.StatusOK, qt.Commentf("%s", data))
+	got = requestAndParse[apicommon.VotingProcessResponse](
+		t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Census.Size, qt.Equals, int64(2))
+	c.Assert(got.Census.TotalWeight, qt.Equals, int64(5))
+
+	// a new file replaces the participants, and none of them joined the organization
+	_, code = importCensusCSV(t, token, created.ProcessID, "memberNumber,email\n9,c@example.com\n")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	got = requestAndParse[apicommon.VotingProcessResponse](
+		t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Census.Size, qt.Equals, int64(1))
+	total, _, err := testDB.OrgMembers(orgAddress, 1, 10, "")
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(0))
+
+	// a census of organization members takes no file
+	plain := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, minimalVotingProcessRequest(orgAddress), processesCreateEndpoint)
+	_, code = importCensusCSV(t, token, plain.ProcessID, "email\nd@example.com\n")
+	c.Assert(code, qt.Equals, http.StatusBadRequest)
+}
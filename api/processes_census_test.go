@@ -190,3 +190,40 @@ func TestProcessesCensusGroupID(t *testing.T) {
 	c.Assert(groups, qt.Contains, "")
 	c.Assert(groups, qt.Not(qt.Contains), primitive.NilObjectID.Hex())
 }
+
+// TestProcessCensusWeightRule creates a weighted process whose census weighs members with a rule
+// instead of their own weight, and checks the rule round-trips and drives the total weight.
+func TestProcessCensusWeightRule(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "weightrulepass123")
+	orgAddress := testCreateOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(3)...)
+	rule := &db.WeightRule{Type: db.WeightRuleExpression, Expression: "weight * 10"}
+
+	validate := func(spec apicommon.CensusSpec) int {
+		_, code := testRequest(t, http.MethodPost, token,
+			&apicommon.ValidateProcessCensusRequest{OrgAddress: orgAddress.Bytes(), Census: spec},
+			"processes", "census", "validation")
+		return code
+	}
+	authFields := db.OrgMemberAuthFields{db.OrgMemberAuthFieldsMemberNumber}
+	c.Assert(validate(apicommon.CensusSpec{Weighted: true, AuthFields: authFields, WeightRule: rule}),
+		qt.Equals, http.StatusOK)
+	// a rule needs a weighted census, and must be complete
+	c.Assert(validate(apicommon.CensusSpec{AuthFields: authFields, WeightRule: rule}), qt.Equals, http.StatusBadRequest)
+	c.Assert(validate(apicommon.CensusSpec{
+		Weighted: true, AuthFields: authFields, WeightRule: &db.WeightRule{Type: db.WeightRuleLookup, Field: "other.tier"},
+	}), qt.Equals, http.StatusBadRequest)
+
+	req := newVotingProcessRequest(orgAddress, memberIDs(members))
+	req.Census.Weighted = true
+	req.Census.WeightRule = rule
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint)
+	got := requestAndParse[apicommon.VotingProcessResponse](
+		t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Census.WeightRule, qt.DeepEquals, rule)
+	// the members weigh 1, 2 and 3
+	c.Assert(got.Census.TotalWeight, qt.Equals, int64(60))
+}
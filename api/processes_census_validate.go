@@ -17,7 +17,9 @@ import (
 //	@Description	produce unique, complete credentials over the target members — a group (groupId), an
 //	@Description	explicit memberIds subset, or the whole organization when neither is set. Returns 400
 //	@Description	with the offending member ids (duplicates / missingData) when the census is not usable,
-//	@Description	otherwise 200. Requires Manager/Admin role of the organization.
+//	@Description	otherwise 200. A weightRule is checked too (400 when incomplete or on a non-weighted
+//	@Description	census). An ephemeral census has no members yet, so only its spec is checked; its
+//	@Description	participants are validated when imported. Requires Manager/Admin role of the organization.
 //	@Tags			processes
 //	@Accept			json
 //	@Produce		json
@@ -55,6 +57,18 @@ func (a *API) validateProcessCensusHandler(w http.ResponseWriter, r *http.Reques
 		errors.ErrInvalidData.Withf("missing both authFields and twoFaFields").Write(w)
 		return
 	}
+	if err := validateCensusWeightRule(census); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	if err := validateEphemeralCensus(census); err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	if census.Ephemeral {
+		apicommon.HTTPWriteOK(w)
+		return
+	}
 
 	// select the member set to validate: a group, an explicit subset, or (default) the whole org.
 	var results *db.OrgMemberAggregationResults
@@ -0,0 +1,100 @@
+package api
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// decodeCloneRequest decodes the optional body of a clone or a template instantiation: an empty
+// body keeps every field of the source.
+func decodeCloneRequest(r *http.Request) (*apicommon.CloneVotingProcessRequest, bool) {
+	req := &apicommon.CloneVotingProcessRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !stderrors.Is(err, io.EOF) {
+		return nil, false
+	}
+	return req, true
+}
+
+// templateProcessOf captures a stored process as a template, reading its census and, for a census
+// of explicit members, the members in it.
+func (a *API) templateProcessOf(vp *db.VotingProcess, questions []db.VotingProcessQuestion) (*db.TemplateProcess, error) {
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process census: %w", err)
+	}
+	var participantIDs []string
+	if census.GroupID.IsZero() && !census.Ephemeral {
+		participants, err := a.db.CensusParticipants(census.ID.Hex())
+		if err != nil {
+			return nil, fmt.Errorf("failed to get census participants: %w", err)
+		}
+		participantIDs = make([]string, 0, len(participants))
+		for i := range participants {
+			participantIDs = append(participantIDs, participants[i].ParticipantID)
+		}
+	}
+	tp := apicommon.TemplateProcessFromDB(vp, questions, census, participantIDs)
+	return &tp, nil
+}
+
+// cloneVotingProcessHandler godoc
+//
+//	@Summary		Clone a voting process
+//	@Description	Create a new draft copying a draft or published process: its texts, its questions
+//	@Description	with their choices, ballot protocols and eligibility, and its census configuration.
+//	@Description	The census is created anew: from the current members of its group, from the same
+//	@Description	members when it listed them, or empty when it is ephemeral, to be imported again.
+//	@Description	Neither dates, on-chain state, freeze nor delegations are copied; the optional body
+//	@Description	sets the title and dates of the draft. Counts against the draft quota. Requires
+//	@Description	Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string								true	"Process ID"
+//	@Param			request		body		apicommon.CloneVotingProcessRequest	false	"Title and dates of the draft"
+//	@Success		200			{object}	apicommon.CreateVotingProcessResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data, or the draft or census quota reached"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/clone [post]
+func (a *API) cloneVotingProcessHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	vp, questions, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return
+	}
+	clone, ok := decodeCloneRequest(r)
+	if !ok {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	tp, err := a.templateProcessOf(vp, questions)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	req := apicommon.CreateVotingProcessRequestFromTemplate(vp.OrgAddress.Bytes(), tp, clone)
+	vpID, err := a.createVotingProcessDraft(vp.OrgAddress, req, user.ID, nil, nil)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: vpID.Hex()})
+}
@@ -0,0 +1,149 @@
+package api
+
+import (
+	"fmt"
+	"math/big"
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/csp/handlers"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+// TestConditionalQuestions covers the dependency graph of a voting process: its validation on a
+// draft, and the CSP refusing a conditional question to a voter whose ballots on chain do not
+// satisfy it.
+func TestConditionalQuestions(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+
+	// question 2 (the allocation) applies only to whoever approves question 1 (the budget)
+	conditional := func(conditions ...db.QuestionCondition) *apicommon.CreateVotingProcessRequest {
+		req := newVotingProcessRequest(orgAddress, ids)
+		req.StartDate = ""
+		req.Census.AuthFields = db.OrgMemberAuthFields{db.OrgMemberAuthFieldsName, db.OrgMemberAuthFieldsSurname}
+		req.Questions[1].Eligibility = nil
+		req.Questions[1].Conditions = conditions
+		return req
+	}
+
+	// a condition on the question itself or a later one, on a choice the question lacks, on the
+	// same question twice, or with no choices at all is refused
+	for _, conds := range [][]db.QuestionCondition{
+		{{Question: 1, Choices: []uint32{0}}},
+		{{Question: 2, Choices: []uint32{0}}},
+		{{Question: 0, Choices: []uint32{7}}},
+		{{Question: 0, Choices: []uint32{0, 0}}},
+		{{Question: 0, Choices: []uint32{0}}, {Question: 0, Choices: []uint32{1}}},
+		{{Question: 0}},
+	} {
+		requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token, conditional(conds...),
+			processesCreateEndpoint)
+	}
+	// ...and so is one on a secret or a non-singlechoice question
+	secret := conditional(db.QuestionCondition{Question: 0, Choices: []uint32{0}})
+	secret.Questions[0].SecretUntilTheEnd = true
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token, secret, processesCreateEndpoint)
+	multi := conditional()
+	multi.Questions = append(multi.Questions, apicommon.VotingProcessQuestionRequest{
+		Title:      db.MultiLangString{"default": "Q3"},
+		Choices:    multi.Questions[0].Choices,
+		Type:       db.VotingTypeSingleChoice,
+		Conditions: []db.QuestionCondition{{Question: 1, Choices: []uint32{0}}},
+	})
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token, multi, processesCreateEndpoint)
+
+	approve := db.QuestionCondition{Question: 0, Choices: []uint32{0}}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, conditional(approve), processesCreateEndpoint,
+	)
+	pid := created.ProcessID
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", pid, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("job error: %s", job.Errors))
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(got.Questions[0].Conditions, qt.HasLen, 0)
+	c.Assert(got.Questions[1].Conditions, qt.DeepEquals, []db.QuestionCondition{approve})
+	budget, allocation := got.Questions[0].UpstreamID, got.Questions[1].UpstreamID
+	public := requestAndParse[apicommon.PublicQuestionResponse](t, http.MethodGet, "", nil,
+		"processes", pid, "questions", got.Questions[1].ID.Hex())
+	c.Assert(public.Conditions, qt.DeepEquals, []db.QuestionCondition{approve})
+
+	// each voter signs the budget alone, votes it on chain and then asks for the allocation
+	vocdoniClient := testNewVocdoniClient(t)
+	type voterFixture struct {
+		sign     func(ballots ...internal.HexBytes) *handlers.SignBatchRequest
+		single   *handlers.SignRequest
+		consumed func() []handlers.QuestionConsumedAddress
+		vote     func(signed handlers.SignBatchResult, choice int)
+	}
+	newVoter := func(member apicommon.OrgMember) *voterFixture {
+		tok := authProcessCSP(t, pid, &handlers.AuthRequest{
+			Name: member.Name, Surname: member.Surname, Email: member.Email,
+		})
+		voter := ethereum.SignKeys{}
+		c.Assert(voter.Generate(), qt.IsNil)
+		address := internal.HexBytes(voter.Address().Bytes())
+		return &voterFixture{
+			sign: func(elections ...internal.HexBytes) *handlers.SignBatchRequest {
+				req := &handlers.SignBatchRequest{AuthToken: tok}
+				for _, election := range elections {
+					req.Ballots = append(req.Ballots, handlers.SignBatchBallot{UpstreamID: election, Address: address})
+				}
+				return req
+			},
+			single: &handlers.SignRequest{AuthToken: tok, ProcessID: allocation, Payload: address.String()},
+			consumed: func() []handlers.QuestionConsumedAddress {
+				return requestAndParse[handlers.ProcessSignInfoResponse](t, http.MethodPost, "",
+					&handlers.ConsumedAddressRequest{AuthToken: tok}, "processes", pid, "sign-info").Consumed
+			},
+			vote: func(signed handlers.SignBatchResult, choice int) {
+				weight := new(big.Int).SetBytes(signed.Weight).Uint64()
+				proof := testGenerateVoteProof(signed.UpstreamID, address, signed.Signature, weight)
+				testCastVote(t, vocdoniClient, &voter, signed.UpstreamID, proof, fmt.Appendf(nil, `{"votes":[%d]}`, choice))
+			},
+		}
+	}
+	signBatch := func(req *handlers.SignBatchRequest) []handlers.SignBatchResult {
+		signed := requestAndParse[handlers.SignBatchResponse](t, http.MethodPost, "", req, "processes", pid, "sign-batch")
+		for i, s := range signed.Signatures {
+			c.Assert(s.Code, qt.Equals, "", qt.Commentf("ballot %d", i))
+			c.Assert(s.Signature, qt.Not(qt.HasLen), 0)
+		}
+		return signed.Signatures
+	}
+
+	// the allocation is not signed before the budget is voted on chain, not even along with it
+	approver, rejecter := newVoter(members[0]), newVoter(members[1])
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		approver.sign(allocation), "processes", pid, "sign-batch")
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		approver.sign(budget, allocation), "processes", pid, "sign-batch")
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		approver.single, "processes", pid, "sign")
+	c.Assert(approver.consumed(), qt.HasLen, 0)
+
+	// a budget signed but not cast is not an answer either
+	approver.vote(signBatch(approver.sign(budget))[0], 0)
+	rejected := signBatch(rejecter.sign(budget))[0]
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		rejecter.sign(allocation), "processes", pid, "sign-batch")
+
+	// rejecting the budget on chain keeps the allocation refused, approving it signs the allocation
+	rejecter.vote(rejected, 1)
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		rejecter.sign(allocation), "processes", pid, "sign-batch")
+	requestAndAssertError(errors.ErrQuestionConditionNotMet, t, http.MethodPost, "",
+		rejecter.single, "processes", pid, "sign")
+	c.Assert(rejecter.consumed(), qt.HasLen, 1)
+	signBatch(approver.sign(allocation))
+	c.Assert(approver.consumed(), qt.HasLen, 2)
+}
@@ -0,0 +1,141 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// votingProcessDelegationsHandler godoc
+//
+//	@Summary		List the vote delegations of a voting process
+//	@Description	The delegations registered in the process census, by managers or by the members
+//	@Description	themselves through the CSP, oldest first, with the census maxProxies. A delegation
+//	@Description	whose delegator or delegate left the census is listed but no longer counts.
+//	@Description	Requires Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string	true	"Process ID"
+//	@Success		200			{object}	apicommon.CensusDelegationsResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/delegations [get]
+func (a *API) votingProcessDelegationsHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.delegationsCensus(w, r)
+	if !ok {
+		return
+	}
+	delegations, err := a.db.CensusDelegations(census.ID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.CensusDelegationsResponse{
+		MaxProxies:  census.MaxProxies,
+		Delegations: delegations,
+	})
+}
+
+// createVotingProcessDelegationHandler godoc
+//
+//	@Summary		Register a vote delegation in a voting process
+//	@Description	Hand the vote of one participant of the process census, the delegator, to another,
+//	@Description	the delegate, who then signs with both weights; a delegator who has delegated before
+//	@Description	is moved to the new delegate. The census must allow proxies (maxProxies, set on the
+//	@Description	process census), the delegate must hold fewer than maxProxies delegations, and
+//	@Description	delegations cannot chain: a participant holding proxies cannot delegate, nor be
+//	@Description	delegated to once they delegated. Delegations are locked from one minute before the
+//	@Description	process starts. Requires Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string								true	"Process ID"
+//	@Param			request		body		apicommon.CensusDelegationRequest	true	"Delegator and delegate member ids"
+//	@Success		200			{object}	db.CensusDelegation
+//	@Failure		400			{object}	errors.Error	"Invalid input data, or a chained or self delegation"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found, or a member not in the census"
+//	@Failure		409			{object}	errors.Error	"Delegation not allowed, delegations locked, or proxy limit reached"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/delegations [post]
+func (a *API) createVotingProcessDelegationHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.delegationsCensus(w, r)
+	if !ok {
+		return
+	}
+	var req apicommon.CensusDelegationRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if req.DelegatorID == "" || req.DelegateID == "" {
+		errors.ErrInvalidData.Withf("delegatorId and delegateId are required").Write(w)
+		return
+	}
+	delegation, err := a.db.SetCensusDelegation(census, req.DelegatorID, req.DelegateID, db.DelegationSourceManager)
+	if err != nil {
+		apicommon.DelegationError(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, delegation)
+}
+
+// deleteVotingProcessDelegationHandler godoc
+//
+//	@Summary		Revoke a vote delegation in a voting process
+//	@Description	Give the delegator back their vote. Delegations are locked from one minute before the
+//	@Description	process starts. Requires Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string	true	"Process ID"
+//	@Param			delegatorId	path		string	true	"Delegator member ID"
+//	@Success		200			{string}	string	"OK"
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process or delegation not found"
+//	@Failure		409			{object}	errors.Error	"Delegations locked"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/delegations/{delegatorId} [delete]
+func (a *API) deleteVotingProcessDelegationHandler(w http.ResponseWriter, r *http.Request) {
+	census, ok := a.delegationsCensus(w, r)
+	if !ok {
+		return
+	}
+	if err := a.db.DeleteCensusDelegation(census, chi.URLParam(r, "delegatorId")); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			errors.ErrDelegationNotFound.Write(w)
+			return
+		}
+		apicommon.DelegationError(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// delegationsCensus loads the census of the process in the path, gated on Manager/Admin of the
+// owning org, writing the proper error and returning false on failure.
+func (a *API) delegationsCensus(w http.ResponseWriter, r *http.Request) (*db.Census, bool) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return nil, false
+	}
+	vp, _, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return nil, false
+	}
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return nil, false
+	}
+	return census, true
+}
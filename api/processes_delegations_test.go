@@ -0,0 +1,124 @@
+package api
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/csp/handlers"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+func TestVotingProcessDelegations(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(4)...)
+	ids := memberIDs(members)
+	authFields := db.OrgMemberAuthFields{db.OrgMemberAuthFieldsName, db.OrgMemberAuthFieldsSurname}
+
+	// a manager delegates on a draft that starts as soon as it is published
+	req := newVotingProcessRequest(orgAddress, ids)
+	req.StartDate = ""
+	req.Census.AuthFields = authFields
+	req.Census.MaxProxies = 1
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint,
+	)
+	pid := created.ProcessID
+	delegation := requestAndParse[db.CensusDelegation](t, http.MethodPost, token,
+		&apicommon.CensusDelegationRequest{DelegatorID: ids[1], DelegateID: ids[0]}, "processes", pid, "delegations")
+	c.Assert(delegation.Source, qt.Equals, db.DelegationSourceManager)
+	requestAndAssertError(errors.ErrProxyLimitReached, t, http.MethodPost, token,
+		&apicommon.CensusDelegationRequest{DelegatorID: ids[2], DelegateID: ids[0]}, "processes", pid, "delegations")
+	// no chains
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.CensusDelegationRequest{DelegatorID: ids[2], DelegateID: ids[1]}, "processes", pid, "delegations")
+	listed := requestAndParse[apicommon.CensusDelegationsResponse](t, http.MethodGet, token, nil,
+		"processes", pid, "delegations")
+	c.Assert(listed.MaxProxies, qt.Equals, 1)
+	c.Assert(listed.Delegations, qt.HasLen, 1)
+
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", pid, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(got.Census.MaxProxies, qt.Equals, 1)
+
+	// the delegate signs the whole-census question for both, the delegator no longer signs
+	voter := ethereum.SignKeys{}
+	c.Assert(voter.Generate(), qt.IsNil)
+	tok := authProcessCSP(t, pid, &handlers.AuthRequest{
+		Name: members[0].Name, Surname: members[0].Surname, Email: members[0].Email,
+	})
+	body, code := testRequest(t, http.MethodPost, "", &handlers.SignRequest{
+		AuthToken: tok, ProcessID: got.Questions[0].UpstreamID, Payload: hex.EncodeToString(voter.Address().Bytes()),
+	}, "processes", pid, "sign")
+	c.Assert(code, qt.Equals, http.StatusOK, qt.Commentf("%s", body))
+	var signed handlers.AuthResponse
+	c.Assert(json.Unmarshal(body, &signed), qt.IsNil)
+	c.Assert(signed.Weight, qt.DeepEquals, internal.HexBytes{0x02})
+	c.Assert(signAs(t, pid, members[1], got.Questions[0].UpstreamID), qt.Equals, http.StatusUnauthorized)
+
+	// the process started: delegations are locked
+	requestAndAssertError(errors.ErrDelegationsLocked, t, http.MethodDelete, token, nil,
+		"processes", pid, "delegations", ids[1])
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, testCreateUser(t, "otherpassword123"), nil,
+		"processes", pid, "delegations")
+}
+
+func TestVotingProcessMemberDelegation(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+
+	// published an hour ahead of its start, so the members can authenticate and still delegate
+	req := newVotingProcessRequest(orgAddress, ids)
+	req.Census.AuthFields = db.OrgMemberAuthFields{db.OrgMemberAuthFieldsName, db.OrgMemberAuthFieldsSurname}
+	req.Census.MaxProxies = 2
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint,
+	)
+	pid := created.ProcessID
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", pid, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+
+	tok := authProcessCSP(t, pid, &handlers.AuthRequest{
+		Name: members[1].Name, Surname: members[1].Surname, Email: members[1].Email,
+	})
+	delegate := &handlers.AuthRequest{Name: members[0].Name, Surname: members[0].Surname, Email: members[0].Email}
+	_, code := testRequest(t, http.MethodPost, "", &handlers.DelegationRequest{AuthToken: tok, Delegate: delegate},
+		"processes", pid, "delegation")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	// delegating to themselves is refused
+	self := &handlers.AuthRequest{Name: members[1].Name, Surname: members[1].Surname, Email: members[1].Email}
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, "",
+		&handlers.DelegationRequest{AuthToken: tok, Delegate: self}, "processes", pid, "delegation")
+
+	listed := requestAndParse[apicommon.CensusDelegationsResponse](t, http.MethodGet, token, nil,
+		"processes", pid, "delegations")
+	c.Assert(listed.Delegations, qt.HasLen, 1)
+	c.Assert(listed.Delegations[0].DelegatorID, qt.Equals, ids[1])
+	c.Assert(listed.Delegations[0].DelegateID, qt.Equals, ids[0])
+	c.Assert(listed.Delegations[0].Source, qt.Equals, db.DelegationSourceMember)
+
+	check := requestAndParse[handlers.ProcessCheckResponse](t, http.MethodPost, "",
+		&handlers.CheckMembershipRequest{AuthToken: tok}, "processes", pid, "check")
+	c.Assert(check.Delegated, qt.IsTrue)
+	c.Assert(check.Questions[0].CanVote, qt.IsFalse)
+
+	_, code = testRequest(t, http.MethodDelete, "", &handlers.DelegationRequest{AuthToken: tok},
+		"processes", pid, "delegation")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	requestAndAssertError(errors.ErrDelegationNotFound, t, http.MethodDelete, "",
+		&handlers.DelegationRequest{AuthToken: tok}, "processes", pid, "delegation")
+}
@@ -105,6 +105,9 @@ func (a *API) publishPreflightProblems(
 	if !user.HasRoleFor(vp.OrgAddress, db.AdminRole) {
 		problems = append(problems, "publishing requires the admin role")
 	}
+	if orgDoc.ReviewRequired() && !vp.ReviewApproved() {
+		problems = append(problems, reviewRequiredProblem)
+	}
 	// Per-question plan voting-type gate, on the ballot each question actually encodes rather
 	// than the type it is labelled with: a stored type is only a label, and a question written
 	// before the two halves were reconciled may carry one its protocol contradicts.
@@ -183,7 +186,8 @@ func (a *API) publishPreflightProblems(
 //	@Description	Requires Admin role (or a `voting:write` key). Returns 202 with a job id; poll
 //	@Description	GET /jobs/{jobId}. Idempotent once published.
 //	@Description	409 (40172) means the stored questions do not match the process and the draft has to be
-//	@Description	saved again before it can be published.
+//	@Description	saved again before it can be published. When the organization requires publish review,
+//	@Description	only an approved draft, unedited since, is published; 409 (40192) otherwise.
 //	@Tags			processes
 //	@Accept			json
 //	@Produce		json
@@ -224,6 +228,12 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 		apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: oid.Hex()})
 		return
 	}
+	// an unapproved draft answers with its own code rather than as one of the preflight problems,
+	// which it also is: it is not fixed by editing the draft but by having it reviewed
+	if org, err := a.db.Organization(vp.OrgAddress); err == nil && org.ReviewRequired() && !vp.ReviewApproved() {
+		errors.ErrPublishReviewRequired.Write(w)
+		return
+	}
 	census, err := a.db.Census(vp.CensusID.Hex())
 	if err != nil {
 		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
@@ -245,19 +255,36 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
+	jobID, err := a.enqueueVotingProcessPublish(vp, questions, census, user)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	if jobID == "" {
+		apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: oid.Hex()})
+		return
+	}
+	apicommon.HTTPWriteJSONStatus(w, http.StatusAccepted, &apicommon.EnqueuedResponse{JobID: jobID})
+}
+
+// enqueueVotingProcessPublish claims a process that passed the publish preflight, publishes its
+// census and enqueues the publish worker, returning the id of its job. It returns an empty id and no
+// error when the process turned out to be published already. Errors are errors.Error values, ready
+// to be written; it is shared by the publish endpoint and the publish scheduler.
+func (a *API) enqueueVotingProcessPublish(
+	vp *db.VotingProcess, questions []db.VotingProcessQuestion, census *db.Census, user *db.User,
+) (string, error) {
+	oid := vp.ID
 	// atomically claim the process for publishing (duplicate-publish guard)
 	claimed, err := a.db.ClaimVotingProcessForPublish(oid)
 	if err != nil {
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.WithErr(err)
 	}
 	if !claimed {
 		if cur, e := a.db.VotingProcess(oid); e == nil && cur.Published {
-			apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: oid.Hex()})
-			return
+			return "", nil
 		}
-		errors.ErrPublishInProgress.Write(w)
-		return
+		return "", errors.ErrPublishInProgress
 	}
 	committed := false
 	defer func() {
@@ -271,8 +298,18 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 
 	org, err := a.db.Organization(vp.OrgAddress)
 	if err != nil {
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	// the approval was checked on the process as read before the claim; an edit landing in between
+	// would void it, and the claim now holds further edits off, so read it again
+	if org.ReviewRequired() {
+		cur, err := a.db.VotingProcess(oid)
+		if err != nil {
+			return "", errors.ErrGenericInternalServerError.WithErr(err)
+		}
+		if !cur.ReviewApproved() || !cur.UpdatedAt.Equal(vp.UpdatedAt) {
+			return "", errors.ErrPublishReviewRequired
+		}
 	}
 	// a process is one billed unit; reserve a single managed slot when applicable. A resume (a
 	// re-publish of a process that already mined some elections) must NOT reserve again — the
@@ -283,8 +320,7 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 	if !anyMined(questions) {
 		integratorAddr, managedReserved, err = a.reserveManagedProcessSlot(org, uint64(census.Size))
 		if err != nil {
-			writeSubscriptionError(w, err)
-			return
+			return "", err
 		}
 	}
 	if managedReserved {
@@ -300,21 +336,32 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 
 	orgSigner, err := account.OrganizationSigner(a.secret, org.Creator, org.Nonce)
 	if err != nil {
-		errors.ErrGenericInternalServerError.Withf("could not restore organization signer: %v", err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.Withf("could not restore organization signer: %v", err)
 	}
 	cspPubKey, err := a.csp.PubKey()
 	if err != nil {
-		errors.ErrGenericInternalServerError.Withf("could not get csp public key: %v", err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.Withf("could not get csp public key: %v", err)
 	}
 	// publish the census (root = CSP public key); the on-chain census authorization is
 	// delegated to the CSP for every question.
 	census.Published = db.PublishedCensus{Root: cspPubKey, URI: a.serverURL, CreatedAt: time.Now()}
 	if _, err := a.db.SetCensus(census); err != nil {
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	// a census this publish freezes is unfrozen again if the publish fails before any election is
+	// mined: until then nobody can vote by it, and it would keep refusing edits for nothing
+	var froze *db.CensusFreeze
+	if census.FreezeOnPublish && census.Frozen == nil {
+		if froze, err = a.db.FreezeCensus(census); err != nil {
+			return "", errors.ErrGenericInternalServerError.WithErr(err)
+		}
+		defer func() {
+			if !committed {
+				a.unfreezeCensus(census, froze, oid)
+			}
+		}()
 	}
+	a.recordCensusSnapshot(census)
 
 	orgLock := a.orgTxLocks.lock(org.Address)
 	lockHeld := true
@@ -326,19 +373,17 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 
 	jobID, err := apicommon.NewJobID()
 	if err != nil {
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.WithErr(err)
 	}
 	if err := a.db.CreateTxJob(jobID, db.JobTypePublishVotingProcess, org.Address); err != nil {
-		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
-		return
+		return "", errors.ErrGenericInternalServerError.WithErr(err)
 	}
 
 	reserved := managedReserved
 	worker := &publishWorker{
 		a: a, vp: vp, questions: questions, census: census, org: org, user: user,
 		orgSigner: orgSigner, cspPubKey: cspPubKey, integratorAddr: integratorAddr,
-		reserved: reserved, nonTestSized: nonTestSized,
+		reserved: reserved, nonTestSized: nonTestSized, froze: froze,
 	}
 	if !a.enqueueTx(txTask{jobID: jobID, run: func() (*db.JobResult, error) {
 		defer orgLock.Unlock()
@@ -347,14 +392,13 @@ func (a *API) publishVotingProcessHandler(w http.ResponseWriter, r *http.Request
 		if e := a.db.SetJobStatus(jobID, db.JobStatusFailed, nil, "tx queue full"); e != nil {
 			log.Warnw("could not mark job failed after full queue", "error", e)
 		}
-		errors.ErrTxQueueFull.Write(w)
-		return
+		return "", errors.ErrTxQueueFull
 	}
-	// the worker now owns the lock, the publishing claim and the managed reservation.
+	// the worker now owns the lock, the publishing claim, the managed reservation and the freeze.
 	committed = true
 	managedReserved = false
 	lockHeld = false
-	apicommon.HTTPWriteJSONStatus(w, http.StatusAccepted, &apicommon.EnqueuedResponse{JobID: jobID})
+	return jobID, nil
 }
 
 // publishWorker carries the state of an async voting-process publish across the batch +
@@ -371,6 +415,8 @@ type publishWorker struct {
 	integratorAddr common.Address
 	reserved       bool
 	nonTestSized   bool
+	// froze is the freeze this publish put on the census, nil when it was frozen already.
+	froze *db.CensusFreeze
 }
 
 // run builds and submits one election per question in a single batch, confirms them on
@@ -455,8 +501,9 @@ func (pw *publishWorker) resolveStartDate() time.Time {
 
 // abandon rolls back a failed/aborted publish: it resets the not-yet-mined questions (so a later
 // publish resumes them), clears the publishing marker, and releases the managed-process
-// reservation only when nothing was mined this run. A partial-mine failure keeps the slot so the
-// resume (which skips a new reservation) consumes it, avoiding a leak or a double-reserve.
+// reservation and unfreezes the census it froze only when nothing was mined this run. A
+// partial-mine failure keeps the slot so the resume (which skips a new reservation) consumes it,
+// avoiding a leak or a double-reserve, and keeps the census its mined elections are voted by frozen.
 func (pw *publishWorker) abandon() {
 	a := pw.a
 	if e := a.db.ResetQuestionsPublish(pw.vp.ID); e != nil {
@@ -470,6 +517,17 @@ func (pw *publishWorker) abandon() {
 			log.Warnw("could not roll back managed processes counter", "error", e)
 		}
 	}
+	if pw.froze != nil && !anyMined(pw.questions) {
+		a.unfreezeCensus(pw.census, pw.froze, pw.vp.ID)
+	}
+}
+
+// unfreezeCensus undoes freeze, the freeze of census by the failed publish of processID, logging
+// when it cannot (see db.UnfreezeCensus).
+func (a *API) unfreezeCensus(census *db.Census, freeze *db.CensusFreeze, processID primitive.ObjectID) {
+	if _, err := a.db.UnfreezeCensus(census, freeze, processID); err != nil {
+		log.Warnw("could not unfreeze census after failed publish", "census", census.ID.Hex(), "error", err)
+	}
 }
 
 // anyMined reports whether any question already has an on-chain election (upstreamId) — i.e. a
@@ -770,6 +828,7 @@ func (a *API) enqueueStatusChange(
 			// write above if the tx never reaches the requested status.
 			a.enqueueConfirm(published[i].UpstreamID, statusStr)
 		}
+		a.liveResults.Notify(vp.ID.Hex())
 		return &db.JobResult{Status: statusStr}, nil
 	}}) {
 		orgLock.Unlock()
@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// TestDecisionRulesValidation covers the decision rules a draft may declare for the ballot of
+// each question, and their round-trip on process reads.
+func TestDecisionRulesValidation(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+
+	yes, missing := uint32(0), uint32(7)
+	withRules := func(qType string, weighted bool, rules db.DecisionRules) *apicommon.CreateVotingProcessRequest {
+		req := newVotingProcessRequest(orgAddress, ids)
+		req.Census.Weighted = weighted
+		req.Questions = req.Questions[:1]
+		req.Questions[0].Eligibility = nil
+		req.Questions[0].Type = qType
+		req.Questions[0].TypeSetup = db.QuestionTypeSetup{}
+		if qType == db.VotingTypeMultiChoice {
+			req.Questions[0].TypeSetup = db.QuestionTypeSetup{MaxChoices: 1}
+		}
+		req.Questions[0].Rules = &rules
+		return req
+	}
+
+	for _, req := range []*apicommon.CreateVotingProcessRequest{
+		withRules(db.VotingTypeSingleChoice, false, db.DecisionRules{}),
+		withRules(db.VotingTypeSingleChoice, false, db.DecisionRules{Quorum: 1.5}),
+		withRules(db.VotingTypeSingleChoice, false, db.DecisionRules{Majority: "most"}),
+		withRules(db.VotingTypeSingleChoice, false, db.DecisionRules{Quorum: 0.5, Choice: &yes}),
+		withRules(db.VotingTypeSingleChoice, false, db.DecisionRules{Majority: db.MajoritySimple, Choice: &missing}),
+		// a majority needs votes per choice, and a weighted quorum a tally that adds up the weight
+		withRules(db.VotingTypeRanked, false, db.DecisionRules{Majority: db.MajorityAbsolute}),
+		withRules(db.VotingTypeRanked, true, db.DecisionRules{Quorum: 0.5}),
+	} {
+		requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token, req, processesCreateEndpoint)
+	}
+	// a weighted quorum cannot apply to part of the census
+	restricted := withRules(db.VotingTypeSingleChoice, true, db.DecisionRules{Quorum: 0.5})
+	restricted.Questions[0].Eligibility = &apicommon.EligibilitySpec{MemberIDs: ids[:1]}
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token, restricted, processesCreateEndpoint)
+
+	rules := db.DecisionRules{Quorum: 0.5, Majority: db.MajorityTwoThirds, Choice: &yes}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		withRules(db.VotingTypeSingleChoice, true, rules), processesCreateEndpoint)
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Questions[0].Rules, qt.DeepEquals, &rules)
+	// a quorum alone suits any ballot in a non-weighted census
+	requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		withRules(db.VotingTypeRanked, false, db.DecisionRules{Quorum: 0.5}), processesCreateEndpoint)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/tally"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// defaultRunoffCandidates is how many of the leading choices go to a run-off when the request does
+// not say.
+const defaultRunoffCandidates = 2
+
+// createRunoffHandler godoc
+//
+//	@Summary		Create a run-off round of a question
+//	@Description	Create a new draft deciding a question of a published process between its leading
+//	@Description	choices, once the results of the question are final. The draft has a single
+//	@Description	singlechoice question with the same title, description and eligibility, whose
+//	@Description	choices are the candidates leading the final results by the scores of their
+//	@Description	outcome, best first; choices tied with the last candidate are taken too, and
+//	@Description	choices without any score never are. The draft shares the census of the process,
+//	@Description	so the same participants vote with the same weights and delegations; an ephemeral
+//	@Description	census already purged cannot be shared. The draft stays linked to the question it
+//	@Description	decides, and both processes list the link when read. Counts against the draft
+//	@Description	quota. Requires Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string							true	"Process ID"
+//	@Param			questionId	path		string							true	"Question ID"
+//	@Param			request		body		apicommon.CreateRunoffRequest	false	"Candidates and draft dates"
+//	@Success		200			{object}	apicommon.CreateVotingProcessResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data, or the draft or census quota reached"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process or question not found"
+//	@Failure		409			{object}	errors.Error	"Results of the question not final yet"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/questions/{questionId}/runoff [post]
+func (a *API) createRunoffHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	vp, questions, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return
+	}
+	question, ok := questionOfProcess(w, questions, chi.URLParam(r, "questionId"))
+	if !ok {
+		return
+	}
+	req := &apicommon.CreateRunoffRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && !stderrors.Is(err, io.EOF) {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if req.Candidates == 0 {
+		req.Candidates = defaultRunoffCandidates
+	}
+	if req.Candidates < 2 || req.Candidates > len(question.Choices) {
+		errors.ErrInvalidData.Withf("candidates must be between 2 and the number of choices (%d)", len(question.Choices)).Write(w)
+		return
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	if !vp.Published {
+		errors.ErrResultsNotFinal.Withf("process not published").Write(w)
+		return
+	}
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not get the census of the process: %v", err).Write(w)
+		return
+	}
+	if !census.PurgedAt.IsZero() {
+		errors.ErrInvalidData.Withf("the participants of the ephemeral census of the process were purged").Write(w)
+		return
+	}
+	entries, err := a.electionResultsBatch([]db.VotingProcessQuestion{*question}, census)
+	if err != nil {
+		errors.ErrVochainRequestFailed.WithErr(err).Write(w)
+		return
+	}
+	if len(entries) == 0 || !entries[0].FinalResults {
+		errors.ErrResultsNotFinal.Write(w)
+		return
+	}
+	if entries[0].Outcome == nil {
+		errors.ErrInvalidData.Withf("question has no outcome to take the leading choices from").Write(w)
+		return
+	}
+	candidates, err := tally.Top(entries[0].Outcome, req.Candidates)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if len(candidates) < 2 {
+		errors.ErrInvalidData.Withf("fewer than 2 choices got votes").Write(w)
+		return
+	}
+
+	tp, err := a.templateProcessOf(vp, []db.VotingProcessQuestion{*question})
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	// the run-off is a plain choice between the candidates, renumbered in their order of the
+	// results; conditions named questions of the first round, and a rule on a choice named one of
+	// its values, so neither carries over.
+	tq := &tp.Questions[0]
+	tq.Choices = make([]db.Choice, 0, len(candidates))
+	for i, value := range candidates {
+		tq.Choices = append(tq.Choices, db.Choice{Title: choiceTitle(question, value), Value: uint32(i)})
+	}
+	tq.Type, tq.TypeSetup, tq.BallotProtocol = db.VotingTypeSingleChoice, db.QuestionTypeSetup{MinChoices: 1, MaxChoices: 1}, nil
+	tq.Conditions = nil
+	if tq.Rules != nil {
+		rules := *tq.Rules
+		rules.Choice = nil
+		tq.Rules = &rules
+	}
+	createReq := apicommon.CreateVotingProcessRequestFromTemplate(vp.OrgAddress.Bytes(), tp, &req.CloneVotingProcessRequest)
+	origin := &db.RunoffOrigin{ProcessID: vp.ID, QuestionID: question.ID}
+	// the run-off is voted by the census of the first round itself: a new one would be rebuilt from
+	// the members of today, without its frozen participants, weights and delegations
+	vpID, err := a.createVotingProcessDraft(vp.OrgAddress, createReq, user.ID, origin, census)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, apicommon.CreateVotingProcessResponse{ProcessID: vpID.Hex()})
+}
+
+// runoffLinks lists the run-off rounds of a process, leaving out drafts unless withDrafts.
+func (a *API) runoffLinks(processID primitive.ObjectID, withDrafts bool) ([]apicommon.RunoffLink, error) {
+	runoffs, err := a.db.VotingProcessRunoffs(processID)
+	if err != nil {
+		return nil, err
+	}
+	var links []apicommon.RunoffLink
+	for i := range runoffs {
+		if !runoffs[i].Published && !withDrafts {
+			continue
+		}
+		links = append(links, apicommon.RunoffLink{
+			ProcessID:  runoffs[i].ID.Hex(),
+			QuestionID: runoffs[i].RunoffOf.QuestionID.Hex(),
+		})
+	}
+	return links, nil
+}
@@ -0,0 +1,172 @@
+package api
+
+import (
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/csp/handlers"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+func TestRunoff(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+	pid, got := publishedProcess(t, token, orgAddress, ids)
+	qid := got.Questions[0].ID.Hex()
+
+	// a run-off needs final results, a known question and at least two candidates among its choices
+	requestAndAssertError(errors.ErrResultsNotFinal, t, http.MethodPost, token, nil, "processes", pid, "questions", qid, "runoff")
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.CreateRunoffRequest{Candidates: 3}, "processes", pid, "questions", qid, "runoff")
+	requestAndAssertError(errors.ErrProcessNotFound, t, http.MethodPost, token, nil,
+		"processes", pid, "questions", primitive.NewObjectID().Hex(), "runoff")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPost, testCreateUser(t, "otherpassword123"), nil,
+		"processes", pid, "questions", qid, "runoff")
+	draft := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, newVotingProcessRequest(orgAddress, ids), processesCreateEndpoint,
+	)
+	draftQuestion := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", draft.ProcessID)
+	requestAndAssertError(errors.ErrResultsNotFinal, t, http.MethodPost, token, nil,
+		"processes", draft.ProcessID, "questions", draftQuestion.Questions[0].ID.Hex(), "runoff")
+
+	// a run-off round links both ways; the public results list it once it is published
+	parentID, err := primitive.ObjectIDFromHex(pid)
+	c.Assert(err, qt.IsNil)
+	round := &db.VotingProcess{
+		OrgAddress: orgAddress,
+		Title:      db.MultiLangString{"default": "Run-off"},
+		RunoffOf:   &db.RunoffOrigin{ProcessID: parentID, QuestionID: got.Questions[0].ID},
+	}
+	roundID, err := testDB.SetVotingProcess(round)
+	c.Assert(err, qt.IsNil)
+	link := apicommon.RunoffLink{ProcessID: roundID.Hex(), QuestionID: qid}
+
+	parent := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(parent.Runoffs, qt.DeepEquals, []apicommon.RunoffLink{link})
+	parent = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, "", nil, "processes", pid)
+	c.Assert(parent.Runoffs, qt.HasLen, 0)
+	results := requestAndParse[apicommon.VotingProcessResultsResponse](t, http.MethodGet, "", nil, "processes", pid, "results")
+	c.Assert(results.Runoffs, qt.HasLen, 0)
+	child := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", roundID.Hex())
+	c.Assert(child.RunoffOf, qt.DeepEquals, &apicommon.RunoffLink{ProcessID: pid, QuestionID: qid})
+
+	round.Published = true
+	_, err = testDB.SetVotingProcess(round)
+	c.Assert(err, qt.IsNil)
+	results = requestAndParse[apicommon.VotingProcessResultsResponse](t, http.MethodGet, "", nil, "processes", pid, "results")
+	c.Assert(results.Runoffs, qt.DeepEquals, []apicommon.RunoffLink{link})
+	results = requestAndParse[apicommon.VotingProcessResultsResponse](
+		t, http.MethodGet, "", nil, "processes", roundID.Hex(), "results",
+	)
+	c.Assert(results.RunoffOf, qt.DeepEquals, &apicommon.RunoffLink{ProcessID: pid, QuestionID: qid})
+}
+
+// TestRunoffFromFinalResults votes a question on chain, ends it and creates its run-off from the
+// final results: the leading choices are renumbered in their order of the results, and the run-off
+// is voted by the census of the first round itself.
+func TestRunoffFromFinalResults(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(3)...)
+	ids := memberIDs(members)
+
+	choices := []db.Choice{
+		{Title: db.MultiLangString{"default": "A"}, Value: 0},
+		{Title: db.MultiLangString{"default": "B"}, Value: 1},
+		{Title: db.MultiLangString{"default": "C"}, Value: 2},
+	}
+	req := newVotingProcessRequest(orgAddress, ids)
+	req.StartDate = ""
+	req.Census.AuthFields = db.OrgMemberAuthFields{db.OrgMemberAuthFieldsName, db.OrgMemberAuthFieldsSurname}
+	req.Census.FreezeOnPublish = true
+	req.Questions = []apicommon.VotingProcessQuestionRequest{{
+		Title:   db.MultiLangString{"default": "Board"},
+		Choices: choices,
+		Type:    db.VotingTypeSingleChoice,
+	}}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint)
+	pid := created.ProcessID
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", pid, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("job error: %s", job.Errors))
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	question := got.Questions[0]
+	election := question.UpstreamID
+
+	// C gets two votes and A one; B gets none, so it does not reach the run-off
+	for i, vote := range []uint32{0, 2, 2} {
+		authToken := authProcessCSP(t, pid, &handlers.AuthRequest{
+			Name: members[i].Name, Surname: members[i].Surname, Email: members[i].Email,
+		})
+		voter := ethereum.SignKeys{}
+		c.Assert(voter.Generate(), qt.IsNil)
+		voterAddr := internal.HexBytes(voter.Address().Bytes())
+		sign := requestAndParse[handlers.AuthResponse](t, http.MethodPost, "",
+			&handlers.SignRequest{AuthToken: authToken, ProcessID: election, Payload: hex.EncodeToString(voterAddr)},
+			"processes", pid, "sign")
+		nullifier := testRelayVoteRequest(t, &voter, election,
+			testGenerateVoteProof(election, voterAddr, sign.Signature, 1), fmt.Appendf(nil, `{"votes":[%d]}`, vote))
+		c.Assert(nullifier, qt.Not(qt.HasLen), 0)
+	}
+	endJob := enqueueAndPollJob(t, http.MethodPut, token, &apicommon.SetProcessStatusRequest{Status: "ended"},
+		"processes", pid, "questions", question.ID.Hex(), "status")
+	c.Assert(endJob.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("end error: %s", endJob.Errors))
+	waitForElectionStatus(t, election, "RESULTS")
+	for i := 0; i < 20; i++ {
+		res := requestAndParse[apicommon.VotingProcessResultsResponse](t, http.MethodGet, "", nil, "processes", pid, "results")
+		if res.Questions[0].FinalResults && res.Questions[0].VoteCount == 3 {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+
+	runoff := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		&apicommon.CreateRunoffRequest{}, "processes", pid, "questions", question.ID.Hex(), "runoff")
+	round := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", runoff.ProcessID)
+	c.Assert(round.RunoffOf, qt.DeepEquals, &apicommon.RunoffLink{ProcessID: pid, QuestionID: question.ID.Hex()})
+	c.Assert(round.Questions, qt.HasLen, 1)
+	c.Assert(round.Questions[0].Type, qt.Equals, db.VotingTypeSingleChoice)
+	c.Assert(round.Questions[0].Choices, qt.DeepEquals, []db.Choice{
+		{Title: db.MultiLangString{"default": "C"}, Value: 0},
+		{Title: db.MultiLangString{"default": "A"}, Value: 1},
+	})
+
+	// the run-off shares the frozen census of the first round, participants and all
+	parentID, err := primitive.ObjectIDFromHex(pid)
+	c.Assert(err, qt.IsNil)
+	roundID, err := primitive.ObjectIDFromHex(runoff.ProcessID)
+	c.Assert(err, qt.IsNil)
+	parent, err := testDB.VotingProcess(parentID)
+	c.Assert(err, qt.IsNil)
+	child, err := testDB.VotingProcess(roundID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(child.CensusID, qt.Equals, parent.CensusID)
+	c.Assert(round.Census.Frozen, qt.IsNotNil)
+	size, err := testDB.CountCensusParticipants(parent.CensusID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(size, qt.Equals, int64(3))
+
+	// deleting the draft run-off leaves the census of the first round in place
+	requestAndAssertCode(http.StatusOK, t, http.MethodDelete, token, nil, "processes", runoff.ProcessID)
+	census, err := testDB.Census(parent.CensusID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(census.Frozen, qt.IsNotNil)
+	size, err = testDB.CountCensusParticipants(parent.CensusID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(size, qt.Equals, int64(3))
+}
@@ -0,0 +1,334 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/notifications/mailtemplates"
+	"go.vocdoni.io/dvote/log"
+)
+
+// defaultPublishScheduleInterval is the pause between two passes of the publish scheduler, when
+// Config.PublishScheduleInterval is 0. It bounds how late a scheduled publish fires.
+const defaultPublishScheduleInterval = 30 * time.Second
+
+// PublishScheduleFailedData is the data of the email telling the creator of a process that its
+// scheduled publish did not happen.
+type PublishScheduleFailedData struct {
+	OrganizationName string
+	UserName         string
+	ProcessTitle     string
+	PublishAt        time.Time
+	Problems         []string
+	Link             string
+}
+
+// startPublishScheduler launches the loop firing the scheduled publishes that are due. The
+// schedules live in the database, so the ones that fell due while the service was down fire on the
+// first pass.
+func (a *API) startPublishScheduler() {
+	log.Infow("starting publish scheduler", "interval", a.publishScheduleInterval.String())
+	go func() {
+		for {
+			a.runScheduledPublishes(time.Now())
+			select {
+			case <-a.ctx.Done():
+				return
+			case <-time.After(a.publishScheduleInterval):
+			}
+		}
+	}()
+}
+
+// runScheduledPublishes fires every scheduled publish due at now and returns how many it fired. It
+// is the deterministic test hook of the scheduler loop.
+func (a *API) runScheduledPublishes(now time.Time) int {
+	ids, err := a.db.DueScheduledPublishes(now)
+	if err != nil {
+		log.Warnw("could not scan for scheduled publishes", "error", err)
+		return 0
+	}
+	fired := 0
+	for _, id := range ids {
+		// the claim makes each schedule fire once, even with several instances of the service
+		vp, err := a.db.ClaimScheduledPublish(id, now)
+		if err != nil {
+			log.Warnw("could not claim scheduled publish", "processId", id.Hex(), "error", err)
+			continue
+		}
+		if vp == nil {
+			continue
+		}
+		a.fireScheduledPublish(vp)
+		fired++
+	}
+	return fired
+}
+
+// fireScheduledPublish publishes a process whose schedule was just claimed, exactly as the publish
+// endpoint would on behalf of the user who scheduled it: the same preflight, then the same worker.
+// The outcome is recorded on the schedule, and a publish that could not start is emailed to the
+// creator of the process.
+func (a *API) fireScheduledPublish(vp *db.VotingProcess) {
+	var problems []string
+	var jobID string
+	questions, census, user, err := a.scheduledPublishInputs(vp)
+	if err != nil {
+		problems = []string{err.Error()}
+	} else {
+		// the user who scheduled the publish may have lost the admin role since; the preflight
+		// reports that like any other problem
+		problems, _ = a.publishPreflightProblems(vp, questions, census, user)
+	}
+	if len(problems) == 0 {
+		if jobID, err = a.enqueueVotingProcessPublish(vp, questions, census, user); err != nil {
+			problems = []string{err.Error()}
+		}
+	}
+	if err := a.db.SetScheduledPublishOutcome(vp.ID, jobID, problems); err != nil {
+		log.Warnw("could not record scheduled publish outcome", "processId", vp.ID.Hex(), "error", err)
+	}
+	if len(problems) > 0 {
+		log.Infow("scheduled publish failed", "processId", vp.ID.Hex(), "problems", strings.Join(problems, "; "))
+		a.sendPublishScheduleFailedEmail(vp, problems)
+		return
+	}
+	log.Infow("scheduled publish enqueued", "processId", vp.ID.Hex(), "jobId", jobID)
+}
+
+// scheduledPublishInputs loads what a publish of vp needs besides the process itself. A census that
+// cannot be read is returned as nil, which the preflight reports.
+func (a *API) scheduledPublishInputs(
+	vp *db.VotingProcess,
+) ([]db.VotingProcessQuestion, *db.Census, *db.User, error) {
+	user, err := a.db.User(vp.PublishSchedule.UserID)
+	if err != nil {
+		return nil, nil, nil, errors.ErrUserNotFound.Withf("the user who scheduled the publish was not found")
+	}
+	questions, err := a.db.QuestionsByProcess(vp.ID)
+	if err != nil {
+		return nil, nil, nil, errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		log.Warnw("could not get census of scheduled publish", "processId", vp.ID.Hex(), "error", err)
+		census = nil
+	}
+	return questions, census, user, nil
+}
+
+// sendPublishScheduleFailedEmail tells the creator of vp why its scheduled publish did not happen.
+// Processes created before their creator was recorded notify the user who scheduled the publish.
+func (a *API) sendPublishScheduleFailedEmail(vp *db.VotingProcess, problems []string) {
+	if a.mail == nil {
+		return // Email service not configured
+	}
+	recipientID := vp.CreatedBy
+	if recipientID == 0 {
+		recipientID = vp.PublishSchedule.UserID
+	}
+	recipient, err := a.db.User(recipientID)
+	if err != nil {
+		log.Warnw("could not get recipient of scheduled publish failure", "processId", vp.ID.Hex(), "error", err)
+		return
+	}
+	var orgName string
+	if org, err := a.db.Organization(vp.OrgAddress); err == nil {
+		orgName = org.DisplayName()
+	}
+	link, err := a.buildWebAppURL("/admin/", nil)
+	if err != nil {
+		log.Errorf("failed to build web app URL for scheduled publish failure email: %v", err)
+	}
+	data := PublishScheduleFailedData{
+		OrganizationName: orgName,
+		UserName:         recipient.FirstName,
+		ProcessTitle:     vp.Title["default"],
+		PublishAt:        vp.PublishSchedule.At,
+		Problems:         problems,
+		Link:             link,
+	}
+	if err := a.sendMail(context.Background(), recipient.Email, mailtemplates.PublishScheduleFailedNotification,
+		data, time.Time{}); err != nil {
+		log.Errorf("failed to send scheduled publish failure email to %s for process %s: %v",
+			recipient.Email, vp.ID.Hex(), err)
+	}
+}
+
+// authorizePublishSchedule loads the process in the path for a change of its schedule, which, like
+// publishing it, requires the Admin role of its organization.
+func (a *API) authorizePublishSchedule(w http.ResponseWriter, r *http.Request) (*db.VotingProcess, *db.User, bool) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return nil, nil, false
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return nil, nil, false
+	}
+	vp, ok := a.loadVotingProcess(w, oid)
+	if !ok {
+		return nil, nil, false
+	}
+	if !user.HasRoleFor(vp.OrgAddress, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of the organization").Write(w)
+		return nil, nil, false
+	}
+	return vp, user, true
+}
+
+// scheduleVotingProcessPublishHandler godoc
+//
+//	@Summary		Schedule the publish of a voting process
+//	@Description	Schedule a draft to be published at `publishAt` (RFC3339), which must be in the
+//	@Description	future and before the end date. At that time the same checks as POST
+//	@Description	/processes/{processId}/publish run on behalf of the scheduling user, and the publish
+//	@Description	job is enqueued; when they fail, the creator of the process is emailed the problems,
+//	@Description	which are also recorded on the schedule. Replaces any previous schedule, so a failed
+//	@Description	one is retried by scheduling again. Requires Admin role.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string								true	"Process ID"
+//	@Param			request		body		apicommon.PublishScheduleRequest	true	"Publish time"
+//	@Success		200			{object}	apicommon.PublishScheduleInfo
+//	@Failure		400			{object}	errors.Error	"Invalid or past publish time"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		409			{object}	errors.Error	"Process already published, or publish in progress"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/schedule [put]
+func (a *API) scheduleVotingProcessPublishHandler(w http.ResponseWriter, r *http.Request) {
+	vp, user, ok := a.authorizePublishSchedule(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.PublishScheduleRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	at, err := time.Parse(time.RFC3339, req.PublishAt)
+	if err != nil {
+		errors.ErrMalformedBody.Withf("invalid publishAt: %v", err).Write(w)
+		return
+	}
+	if !at.After(time.Now()) {
+		errors.ErrMalformedBody.Withf("publishAt must be in the future").Write(w)
+		return
+	}
+	if !vp.EndDate.IsZero() && !at.Before(vp.EndDate) {
+		errors.ErrMalformedBody.Withf("publishAt must be before the end date of the process").Write(w)
+		return
+	}
+	if vp.Published {
+		errors.ErrDuplicateConflict.Withf("process already published and not in draft mode").Write(w)
+		return
+	}
+	if err := a.db.ScheduleVotingProcessPublish(vp.ID, at, user.ID); err != nil {
+		writePublishScheduleError(w, err)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, apicommon.PublishScheduleInfoFromDB(&db.PublishSchedule{At: at}))
+}
+
+// cancelVotingProcessPublishHandler godoc
+//
+//	@Summary		Cancel the scheduled publish of a voting process
+//	@Description	Remove the schedule of a draft: a pending publish is cancelled and a failed one
+//	@Description	dismissed. Requires Admin role.
+//	@Tags			processes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string	true	"Process ID"
+//	@Success		200			{string}	string	"OK"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found, or no scheduled publish"
+//	@Failure		409			{object}	errors.Error	"Publish in progress"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/schedule [delete]
+func (a *API) cancelVotingProcessPublishHandler(w http.ResponseWriter, r *http.Request) {
+	vp, _, ok := a.authorizePublishSchedule(w, r)
+	if !ok {
+		return
+	}
+	if err := a.db.CancelVotingProcessPublish(vp.ID); err != nil {
+		writePublishScheduleError(w, err)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// writePublishScheduleError maps the errors of the schedule writes.
+func writePublishScheduleError(w http.ResponseWriter, err error) {
+	switch err {
+	case db.ErrNotFound:
+		errors.ErrPublishScheduleNotFound.Write(w)
+	case db.ErrConflict:
+		errors.ErrPublishInProgress.Withf("process is published or being published").Write(w)
+	default:
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+	}
+}
+
+// scheduledPublishesHandler godoc
+//
+//	@Summary		List the scheduled publishes of an organization
+//	@Description	List the drafts of the organization with a scheduled publish, soonest first: the
+//	@Description	pending ones, and the fired ones that did not get published, with their problems.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `voting:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			page		query		integer	false	"Page number (default: 1)"
+//	@Param			limit		query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200			{object}	apicommon.ScheduledPublishesResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/scheduled-publishes [get]
+func (a *API) scheduledPublishesHandler(w http.ResponseWriter, r *http.Request) {
+	// the same organization and role checks as the process templates
+	org, ok := a.processTemplatesOrg(w, r)
+	if !ok {
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, processes, err := a.db.ScheduledPublishes(org.Address, params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	scheduled := make([]apicommon.ScheduledPublish, 0, len(processes))
+	for i := range processes {
+		scheduled = append(scheduled, apicommon.ScheduledPublish{
+			ProcessID:       processes[i].ID.Hex(),
+			Title:           processes[i].Title,
+			PublishSchedule: apicommon.PublishScheduleInfoFromDB(processes[i].PublishSchedule),
+		})
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.ScheduledPublishesResponse{
+		Pagination: pagination,
+		Processes:  scheduled,
+	})
+}
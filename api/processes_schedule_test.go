@@ -0,0 +1,87 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestScheduledPublish(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	me := requestAndParse[apicommon.UserInfo](t, http.MethodGet, token, nil, usersMeEndpoint)
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+	scheduledURL := []string{"organizations", orgAddress.String(), "scheduled-publishes"}
+
+	ready := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, newVotingProcessRequest(orgAddress, ids), processesCreateEndpoint,
+	)
+	// a process without an end date fails the publish checks
+	notReady := newVotingProcessRequest(orgAddress, ids)
+	notReady.EndDate = ""
+	broken := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, notReady, processesCreateEndpoint,
+	)
+
+	past := time.Now().Add(-time.Minute).UTC().Format(time.RFC3339)
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPut, token,
+		&apicommon.PublishScheduleRequest{PublishAt: past}, "processes", ready.ProcessID, "schedule")
+	afterEnd := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPut, token,
+		&apicommon.PublishScheduleRequest{PublishAt: afterEnd}, "processes", ready.ProcessID, "schedule")
+	requestAndAssertError(errors.ErrPublishScheduleNotFound, t, http.MethodDelete, token, nil,
+		"processes", ready.ProcessID, "schedule")
+
+	publishAt := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+	for _, id := range []string{ready.ProcessID, broken.ProcessID} {
+		info := requestAndParse[apicommon.PublishScheduleInfo](t, http.MethodPut, token,
+			&apicommon.PublishScheduleRequest{PublishAt: publishAt}, "processes", id, "schedule")
+		c.Assert(info.PublishAt, qt.Equals, publishAt)
+	}
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", ready.ProcessID)
+	c.Assert(got.PublishSchedule.PublishAt, qt.Equals, publishAt)
+	c.Assert(got.PublishSchedule.FiredAt, qt.Equals, "")
+	listed := requestAndParse[apicommon.ScheduledPublishesResponse](t, http.MethodGet, token, nil, scheduledURL...)
+	c.Assert(listed.Processes, qt.HasLen, 2)
+
+	// nothing fires before its time
+	c.Assert(testAPI.runScheduledPublishes(time.Now()), qt.Equals, 0)
+	c.Assert(testAPI.runScheduledPublishes(time.Now().Add(2*time.Hour)), qt.Equals, 2)
+	c.Assert(testAPI.runScheduledPublishes(time.Now().Add(2*time.Hour)), qt.Equals, 0)
+
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", ready.ProcessID)
+	c.Assert(got.PublishSchedule.JobID, qt.Not(qt.Equals), "")
+	job := pollJob(t, got.PublishSchedule.JobID)
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", ready.ProcessID)
+	c.Assert(got.Published, qt.IsTrue)
+
+	// the failed one stays a draft, with its problems, and its creator is told
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", broken.ProcessID)
+	c.Assert(got.Published, qt.IsFalse)
+	c.Assert(got.PublishSchedule.FiredAt, qt.Not(qt.Equals), "")
+	c.Assert(got.PublishSchedule.JobID, qt.Equals, "")
+	c.Assert(got.PublishSchedule.Problems, qt.Not(qt.HasLen), 0)
+	c.Assert(waitForEmail(t, me.Email), qt.Not(qt.Equals), "")
+	listed = requestAndParse[apicommon.ScheduledPublishesResponse](t, http.MethodGet, token, nil, scheduledURL...)
+	c.Assert(listed.Processes, qt.HasLen, 1)
+	c.Assert(listed.Processes[0].ProcessID, qt.Equals, broken.ProcessID)
+
+	// a published process cannot be scheduled, a failed schedule can be dismissed
+	requestAndAssertError(errors.ErrDuplicateConflict, t, http.MethodPut, token,
+		&apicommon.PublishScheduleRequest{PublishAt: publishAt}, "processes", ready.ProcessID, "schedule")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodDelete, testCreateUser(t, "otherpassword123"), nil,
+		"processes", broken.ProcessID, "schedule")
+	_, code := testRequest(t, http.MethodDelete, token, nil, "processes", broken.ProcessID, "schedule")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	listed = requestAndParse[apicommon.ScheduledPublishesResponse](t, http.MethodGet, token, nil, scheduledURL...)
+	c.Assert(listed.Processes, qt.HasLen, 0)
+}
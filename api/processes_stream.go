@@ -0,0 +1,177 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/liveresults"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// streamPathPattern matches the request paths of processesStreamEndpoint.
+	streamPathPattern = "/processes/*/stream"
+	// streamKeepAlive is the pause after which an idle stream sends a comment, so the proxies on
+	// the way do not close it.
+	streamKeepAlive = 20 * time.Second
+	// streamRetry is how long a client waits before reconnecting a dropped stream.
+	streamRetry = 3 * time.Second
+	// The events of a process stream.
+	streamEventStatus  = "status"
+	streamEventResults = "results"
+)
+
+// votingProcessStreamHandler godoc
+//
+//	@Summary		Stream the live statuses and results of a voting process
+//	@Description	Server-Sent Events stream of a published voting process, for dashboards and
+//	@Description	projector screens. A `status` event carries the status of every published question
+//	@Description	and a `results` event the same body as GET /processes/{processId}/results. Both are
+//	@Description	sent on connect, then whenever they change. Every stream of a process shares a single
+//	@Description	reader of the chain, and a client that falls behind gets the latest event of each
+//	@Description	kind rather than every intermediate one. Unless the organization's plan has live
+//	@Description	results, the tallies of a question are left out until they are final. No
+//	@Description	authentication is required; the open streams are capped, in all and per process.
+//	@Tags			processes
+//	@Produce		text/event-stream
+//	@Param			processId	path		string	true	"Process ID"
+//	@Success		200			{string}	string	"Event stream"
+//	@Failure		400			{object}	errors.Error
+//	@Failure		404			{object}	errors.Error
+//	@Failure		503			{object}	errors.Error	"Too many live streams"
+//	@Router			/processes/{processId}/stream [get]
+func (a *API) votingProcessStreamHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	vp, ok := a.loadVotingProcess(w, oid)
+	if !ok {
+		return
+	}
+	if !vp.Published {
+		errors.ErrProcessNotFound.Withf("process not published").Write(w)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		errors.ErrGenericInternalServerError.Withf("streaming not supported").Write(w)
+		return
+	}
+	sub, err := a.liveResults.Subscribe(oid.Hex(), func() ([]liveresults.Update, error) {
+		return a.liveUpdates(oid)
+	})
+	if err != nil {
+		errors.ErrTooManyLiveStreams.WithErr(err).Write(w)
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	// tells nginx not to buffer the stream
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	if _, err := fmt.Fprintf(w, "retry: %d\n\n", streamRetry.Milliseconds()); err != nil {
+		return
+	}
+	flusher.Flush()
+	keepAlive := time.NewTicker(streamKeepAlive)
+	defer keepAlive.Stop()
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case <-sub.Done():
+			return
+		case <-keepAlive.C:
+			_, err = fmt.Fprint(w, ": keepalive\n\n")
+		case <-sub.C():
+			for _, u := range sub.Updates() {
+				if _, err = fmt.Fprintf(w, "event: %s\ndata: %s\n\n", u.Event, u.Data); err != nil {
+					break
+				}
+			}
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// liveUpdates reads the state a process stream sends: the stored status of its published questions,
+// each refreshed from the chain in the background when stale, and their results. A non-final tally
+// is left out unless the organization's plan has live results. A results read that fails is logged
+// and skipped, so the statuses still go out.
+func (a *API) liveUpdates(oid primitive.ObjectID) ([]liveresults.Update, error) {
+	vp, questions, err := a.db.ProcessWithQuestions(oid)
+	if err != nil {
+		return nil, err
+	}
+	status := &apicommon.VotingProcessStatusEvent{ID: oid.Hex(), Questions: []apicommon.QuestionStatusEntry{}}
+	for i := range questions {
+		q := &questions[i]
+		if len(q.UpstreamID) == 0 {
+			continue
+		}
+		a.enqueueReconcileIfStale(q)
+		status.Questions = append(status.Questions, apicommon.QuestionStatusEntry{QuestionID: q.ID.Hex(), Status: q.Status})
+	}
+	statusData, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	updates := []liveresults.Update{{Event: streamEventStatus, Data: statusData}}
+
+	live, err := a.subscriptions.OrgHasLiveResults(vp.OrgAddress)
+	if err != nil {
+		return nil, err
+	}
+	census, _ := a.db.Census(vp.CensusID.Hex())
+	entries, err := a.electionResultsBatch(questions, census)
+	if err != nil {
+		log.Warnw("live results: could not read results", "process", oid.Hex(), "error", err)
+		return updates, nil
+	}
+	if !live {
+		for i := range entries {
+			withoutLiveTally(&entries[i].QuestionResults)
+		}
+	}
+	resultsData, err := json.Marshal(&apicommon.VotingProcessResultsResponse{ID: oid.Hex(), Questions: entries})
+	if err != nil {
+		return nil, err
+	}
+	return append(updates, liveresults.Update{Event: streamEventResults, Data: resultsData}), nil
+}
+
+// withoutLiveTally leaves the tally of qr out while its results are not final, for an organization
+// whose plan does not have live results: the matrix, its outcome and its decision. The turnout stays.
+func withoutLiveTally(qr *db.QuestionResults) {
+	if qr != nil && !qr.FinalResults {
+		qr.Results, qr.Outcome, qr.Decision = nil, nil, nil
+	}
+}
+
+// withoutLiveTallies leaves out the tallies of the questions whose results are not final yet, unless
+// the plan of the organization has live results.
+func (a *API) withoutLiveTallies(orgAddress common.Address, questions []db.VotingProcessQuestion) error {
+	live, err := a.subscriptions.OrgHasLiveResults(orgAddress)
+	if err != nil || live {
+		return err
+	}
+	for i := range questions {
+		withoutLiveTally(questions[i].Results)
+	}
+	return nil
+}
@@ -0,0 +1,111 @@
+package api
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// readStreamEvents reads events off a process stream until it has one of each of the given names,
+// and returns the data of the last of each.
+func readStreamEvents(t *testing.T, scanner *bufio.Scanner, names ...string) map[string]string {
+	t.Helper()
+	events := map[string]string{}
+	var name string
+	for len(events) < len(names) && scanner.Scan() {
+		line := scanner.Text()
+		if event, ok := strings.CutPrefix(line, "event: "); ok {
+			name = event
+		}
+		if data, ok := strings.CutPrefix(line, "data: "); ok {
+			events[name] = data
+		}
+	}
+	qt.Assert(t, scanner.Err(), qt.IsNil)
+	return events
+}
+
+func TestVotingProcessStream(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	pid, got := publishedProcess(t, token, orgAddress, memberIDs(members))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		fmt.Sprintf("http://%s:%d/processes/%s/stream", testHost, testPort, pid), nil)
+	c.Assert(err, qt.IsNil)
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	c.Assert(resp.Header.Get("Content-Type"), qt.Equals, "text/event-stream")
+
+	// both events are sent on connect
+	events := readStreamEvents(t, bufio.NewScanner(resp.Body), streamEventStatus, streamEventResults)
+	var status apicommon.VotingProcessStatusEvent
+	c.Assert(json.Unmarshal([]byte(events[streamEventStatus]), &status), qt.IsNil)
+	c.Assert(status.ID, qt.Equals, pid)
+	c.Assert(status.Questions, qt.HasLen, len(got.Questions))
+	c.Assert(status.Questions[0].QuestionID, qt.Equals, got.Questions[0].ID.Hex())
+	c.Assert(status.Questions[0].Status, qt.Equals, got.Questions[0].Status)
+	var results apicommon.VotingProcessResultsResponse
+	c.Assert(json.Unmarshal([]byte(events[streamEventResults]), &results), qt.IsNil)
+	c.Assert(results.ID, qt.Equals, pid)
+	c.Assert(results.Questions, qt.HasLen, len(got.Questions))
+
+	// a draft has no stream
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token,
+		newVotingProcessRequest(orgAddress, memberIDs(members)), processesCreateEndpoint)
+	requestAndAssertError(errors.ErrProcessNotFound, t, http.MethodGet, "", nil,
+		"processes", created.ProcessID, "stream")
+}
+
+func TestVotingProcessResultsWithoutLiveResults(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	plan := *mockEssentialPlan
+	plan.ID = "prod_test_no_live_results"
+	plan.Name = "No Live Results Plan"
+	plan.StripeMonthlyPriceID = "price_month_test_no_live_results"
+	plan.StripeYearlyPriceID = "price_year_test_no_live_results"
+	plan.Public = false
+	plan.Features.LiveResults = false
+	c.Assert(testDB.SetPlan(&plan), qt.IsNil)
+	setOrganizationSubscription(t, orgAddress, plan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	pid, got := publishedProcess(t, token, orgAddress, memberIDs(members))
+
+	// the turnout of a question is there, but not its tally until it is final
+	res := requestAndParse[apicommon.VotingProcessResultsResponse](t, http.MethodGet, "", nil, "processes", pid, "results")
+	c.Assert(res.Questions, qt.HasLen, len(got.Questions))
+	for _, q := range res.Questions {
+		c.Assert(q.FinalResults, qt.IsFalse)
+		c.Assert(q.MaxVoters, qt.Not(qt.Equals), uint64(0))
+		c.Assert(q.Results, qt.IsNil)
+		c.Assert(q.Outcome, qt.IsNil)
+		c.Assert(q.Decision, qt.IsNil)
+	}
+	info := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	for _, q := range info.Questions {
+		c.Assert(q.Results, qt.Not(qt.IsNil))
+		c.Assert(q.Results.Results, qt.IsNil)
+	}
+	pub := requestAndParse[apicommon.PublicQuestionResponse](
+		t, http.MethodGet, "", nil, "processes", pid, "questions", got.Questions[0].ID.Hex())
+	c.Assert(pub.Results, qt.Not(qt.IsNil))
+	c.Assert(pub.Results.Results, qt.IsNil)
+}
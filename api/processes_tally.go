@@ -0,0 +1,194 @@
+package api
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/tally"
+	"go.vocdoni.io/dvote/log"
+	"go.vocdoni.io/dvote/vochain/state"
+)
+
+// maxRunoffBallots bounds the ballots read to count a question by instant-runoff: each is one node
+// round-trip, so past this a ranked question is served with its Borda outcome only.
+const maxRunoffBallots = 10000
+
+// runoffRetryDelay is how long a question whose ballots could not be read, because the node failed
+// or they are still sealed, waits before a results read starts another instant-runoff count.
+const runoffRetryDelay = time.Minute
+
+var (
+	// errTooManyRunoffBallots and errUncountableBallot fail an instant-runoff count for good, and
+	// get the question stored as not counted: reading its ballots again would fail the same way.
+	errTooManyRunoffBallots = stderrors.New("too many ballots")
+	errUncountableBallot    = stderrors.New("uncountable ballot")
+)
+
+// runoffsInFlight holds the ids of the questions whose ballots are being read for an instant-runoff
+// count, so concurrent results reads of a freshly final question do not all walk its ballots: the
+// first one starts the count, and they all serve the outcome without it meanwhile.
+var runoffsInFlight sync.Map
+
+// runoffsFailed holds, by question id, when the last instant-runoff count of a question could not
+// read its ballots, to hold off the next one for runoffRetryDelay.
+var runoffsFailed sync.Map
+
+// questionOutcome counts a question's on-chain tally by the rules of its ballot type (see
+// tally.Outcome), adding the instant-runoff count of a ranked question once its results are final.
+// A tally that cannot be counted is logged and served without an outcome: the raw matrix still is.
+func (a *API) questionOutcome(q *db.VotingProcessQuestion, qr *db.QuestionResults) *db.TallyOutcome {
+	outcome, err := tally.Outcome(q, qr.Results)
+	if err != nil {
+		log.Warnw("results: could not count outcome", "question", q.ID.Hex(), "error", err)
+		return nil
+	}
+	if outcome != nil && qr.FinalResults && tally.Ranked(q) {
+		outcome.InstantRunoff = a.questionInstantRunoff(q, qr.VoteCount)
+	}
+	return outcome
+}
+
+// questionInstantRunoff returns the instant-runoff count of a ranked question whose results are
+// final, with the number of votes its election reports. The matrix does not carry the order of each
+// ballot, so the count reads every ballot from the chain, once and in the background, and stores it
+// on the question; reads after that serve the stored count. A question with more votes than a count
+// reads is stored as not counted without listing them, and so is one holding a ballot that cannot
+// be counted. It returns nil while the count is being made, and for runoffRetryDelay after a count
+// that could not read the ballots.
+func (a *API) questionInstantRunoff(q *db.VotingProcessQuestion, votes uint64) *db.InstantRunoff {
+	if q.InstantRunoff != nil {
+		return q.InstantRunoff
+	}
+	if votes > maxRunoffBallots {
+		runoff := &db.InstantRunoff{Ballots: votes, NotCounted: db.RunoffNotCountedTooManyBallots}
+		a.storeInstantRunoff(q, runoff)
+		return runoff
+	}
+	if failed, ok := runoffsFailed.Load(q.ID); ok && time.Since(failed.(time.Time)) < runoffRetryDelay {
+		return nil
+	}
+	if _, busy := runoffsInFlight.LoadOrStore(q.ID, struct{}{}); busy {
+		return nil
+	}
+	// the caller goes on filling the results of its own copy of the question
+	question := *q
+	go func() {
+		defer runoffsInFlight.Delete(question.ID)
+		runoff, err := a.countInstantRunoff(&question)
+		if err != nil {
+			log.Warnw("results: could not read ballots for instant-runoff", "question", question.ID.Hex(), "error", err)
+			runoffsFailed.Store(question.ID, time.Now())
+			return
+		}
+		runoffsFailed.Delete(question.ID)
+		a.storeInstantRunoff(&question, runoff)
+	}()
+	return nil
+}
+
+// countInstantRunoff reads the ballots of a ranked question and counts them by instant-runoff. A
+// count that can never be made is returned as not counted, with the reason; the error is left to
+// the failures worth retrying, a node that failed or a ballot still sealed.
+func (a *API) countInstantRunoff(q *db.VotingProcessQuestion) (*db.InstantRunoff, error) {
+	ballots, err := a.questionBallots(q)
+	switch {
+	case stderrors.Is(err, errTooManyRunoffBallots):
+		log.Warnw("results: instant-runoff not counted", "question", q.ID.Hex(), "error", err)
+		return &db.InstantRunoff{NotCounted: db.RunoffNotCountedTooManyBallots}, nil
+	case stderrors.Is(err, errUncountableBallot):
+		log.Warnw("results: instant-runoff not counted", "question", q.ID.Hex(), "error", err)
+		return &db.InstantRunoff{NotCounted: db.RunoffNotCountedFailed}, nil
+	case err != nil:
+		return nil, err
+	}
+	runoff, err := tally.InstantRunoff(q, ballots)
+	if err != nil {
+		log.Warnw("results: could not count instant-runoff", "question", q.ID.Hex(), "error", err)
+		return &db.InstantRunoff{Ballots: uint64(len(ballots)), NotCounted: db.RunoffNotCountedFailed}, nil
+	}
+	return runoff, nil
+}
+
+// storeInstantRunoff stores the instant-runoff count of a question. A count that could not be
+// stored is logged, and made again by a later read.
+func (a *API) storeInstantRunoff(q *db.VotingProcessQuestion, runoff *db.InstantRunoff) {
+	if err := a.db.SetQuestionInstantRunoff(q.ID, runoff); err != nil {
+		log.Warnw("results: could not persist instant-runoff", "question", q.ID.Hex(), "error", err)
+	}
+}
+
+// questionBallots reads every ballot cast on a question's election, concurrently (bounded). It fails
+// when one cannot be read or decoded, since a count missing ballots would name the wrong winner, and
+// when a ballot is still sealed: an encrypted election serves its ballots in clear only once the
+// keykeepers have revealed the keys. A failure no later read can overcome, more ballots than
+// maxRunoffBallots or one that cannot be decoded, wraps errTooManyRunoffBallots or
+// errUncountableBallot.
+func (a *API) questionBallots(q *db.VotingProcessQuestion) ([]tally.Ballot, error) {
+	ids, err := a.account.ElectionVoteIDs(q.UpstreamID)
+	if err != nil {
+		return nil, err
+	}
+	if len(ids) > maxRunoffBallots {
+		return nil, fmt.Errorf("%w: %d, more than the %d an instant-runoff count reads",
+			errTooManyRunoffBallots, len(ids), maxRunoffBallots)
+	}
+	ballots := make([]tally.Ballot, len(ids))
+	errs := make([]error, len(ids))
+	parallelForEach(len(ids), func(i int) {
+		vote, err := a.account.VoteByNullifier(ids[i])
+		if err != nil {
+			errs[i] = err
+			return
+		}
+		pkg := &state.VotePackage{}
+		if err := json.Unmarshal(vote.VotePackage, pkg); err != nil {
+			errs[i] = fmt.Errorf("%w: could not decode vote %x: %v", errUncountableBallot, ids[i], err)
+			return
+		}
+		if pkg.Votes == nil {
+			errs[i] = fmt.Errorf("vote %x is still encrypted", ids[i])
+			return
+		}
+		ballots[i].Values = pkg.Votes
+		if vote.VoteWeight != "" {
+			weight, ok := new(big.Int).SetString(vote.VoteWeight, 10)
+			if !ok {
+				errs[i] = fmt.Errorf("%w: invalid weight %q of vote %x", errUncountableBallot, vote.VoteWeight, ids[i])
+				return
+			}
+			ballots[i].Weight = weight
+		}
+	})
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return ballots, nil
+}
+
+// questionDecision applies a question's decision rules to its tally (see tally.Decide). The eligible
+// weight is the census weight in a weighted census, where validateDecisionRules keeps a quorum to
+// questions the whole census votes, and otherwise the election's census size, restricted to the
+// question's eligibility list at publish. delegated tells a census whose participants may delegate
+// their vote, where the votes are measured by the weight cast too. A tally that cannot be decided
+// is logged and served without a decision.
+func questionDecision(
+	q *db.VotingProcessQuestion, qr *db.QuestionResults, weighted, delegated bool, censusWeight int64,
+) *db.QuestionDecision {
+	eligible := new(big.Int).SetUint64(qr.MaxVoters)
+	if weighted {
+		eligible = big.NewInt(censusWeight)
+	}
+	decision, err := tally.Decide(q, qr, eligible, weighted, delegated)
+	if err != nil {
+		log.Warnw("results: could not decide question", "question", q.ID.Hex(), "error", err)
+		return nil
+	}
+	return decision
+}
@@ -6,6 +6,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/ethereum/go-ethereum/common"
 	qt "github.com/frankban/quicktest"
 	"github.com/vocdoni/saas-backend/api/apicommon"
 	"github.com/vocdoni/saas-backend/csp/handlers"
@@ -111,3 +112,175 @@ func TestVotingProcessMultichoiceTally(t *testing.T) {
 		{"0", "1"}, {"1", "0"}, {"0", "1"}, {"1", "0"},
 	})
 }
+
+// publishQuestion publishes a process with the single question q, its census made of members, and
+// returns the process id and the question as published.
+func publishQuestion(t *testing.T, token string, orgAddress common.Address, members []apicommon.OrgMember,
+	weighted bool, q apicommon.VotingProcessQuestionRequest,
+) (string, db.VotingProcessQuestion) {
+	t.Helper()
+	c := qt.New(t)
+	req := &apicommon.CreateVotingProcessRequest{
+		OrgAddress: orgAddress.Bytes(),
+		Census: apicommon.CensusSpec{
+			AuthFields:  db.OrgMemberAuthFields{db.OrgMemberAuthFieldsName, db.OrgMemberAuthFieldsSurname},
+			TwoFaFields: db.OrgMemberTwoFaFields{db.OrgMemberTwoFaFieldEmail},
+			MemberIDs:   memberIDs(members),
+			Weighted:    weighted,
+		},
+		Title:     db.MultiLangString{"default": "Tally"},
+		EndDate:   time.Now().Add(48 * time.Hour).UTC().Format(time.RFC3339),
+		Questions: []apicommon.VotingProcessQuestionRequest{q},
+	}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, token, req, processesCreateEndpoint)
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", created.ProcessID, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("job error: %s", job.Errors))
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	c.Assert(got.Questions, qt.HasLen, 1)
+	return created.ProcessID, got.Questions[0]
+}
+
+// castBallot casts the vote package ballot in the election of process pid as member, through the
+// CSP.
+func castBallot(t *testing.T, pid string, election internal.HexBytes, member apicommon.OrgMember, ballot string) {
+	t.Helper()
+	c := qt.New(t)
+	authToken := authProcessCSP(t, pid, &handlers.AuthRequest{
+		Name: member.Name, Surname: member.Surname, Email: member.Email,
+	})
+	voter := ethereum.SignKeys{}
+	c.Assert(voter.Generate(), qt.IsNil)
+	voterAddr := internal.HexBytes(voter.Address().Bytes())
+	sign := requestAndParse[handlers.AuthResponse](t, http.MethodPost, "",
+		&handlers.SignRequest{AuthToken: authToken, ProcessID: election, Payload: hex.EncodeToString(voterAddr)},
+		"processes", pid, "sign")
+	nullifier := testRelayVoteRequest(t, &voter, election,
+		testGenerateVoteProof(election, voterAddr, sign.Signature, 1), []byte(ballot))
+	c.Assert(nullifier, qt.Not(qt.HasLen), 0)
+}
+
+// questionResults reads the results of the single question of process pid until ready reports
+// them complete, and returns the last read.
+func questionResults(t *testing.T, pid string, ready func(*db.QuestionResults) bool) *db.QuestionResults {
+	t.Helper()
+	var res apicommon.VotingProcessResultsResponse
+	for i := 0; i < 20; i++ {
+		res = requestAndParse[apicommon.VotingProcessResultsResponse](
+			t, http.MethodGet, "", nil, "processes", pid, "results")
+		if len(res.Questions) > 0 && ready(&res.Questions[0].QuestionResults) {
+			break
+		}
+		time.Sleep(time.Second)
+	}
+	qt.New(t).Assert(res.Questions, qt.HasLen, 1)
+	return &res.Questions[0].QuestionResults
+}
+
+// TestVotingProcessTallyOutcome reads the outcome the server counts from a multichoice tally: the
+// choices are counted by approval, and the selected ones tie for the win.
+func TestVotingProcessTallyOutcome(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+
+	choices := make([]db.Choice, 4)
+	for i := range choices {
+		choices[i] = db.Choice{Title: db.MultiLangString{"default": string(rune('A' + i))}, Value: uint32(i)}
+	}
+	pid, question := publishQuestion(t, token, orgAddress, members, false, apicommon.VotingProcessQuestionRequest{
+		Title:     db.MultiLangString{"default": "Pick up to two"},
+		Choices:   choices,
+		Type:      db.VotingTypeMultiChoice,
+		TypeSetup: db.QuestionTypeSetup{MinChoices: 1, MaxChoices: 2},
+	})
+	castBallot(t, pid, question.UpstreamID, members[0], `{"votes":[1,0,1,0]}`)
+
+	res := questionResults(t, pid, func(r *db.QuestionResults) bool { return r.VoteCount > 0 })
+	c.Assert(res.VoteCount, qt.Equals, uint64(1))
+	outcome := res.Outcome
+	c.Assert(outcome, qt.IsNotNil)
+	c.Assert(outcome.Method, qt.Equals, db.TallyMethodApproval)
+	c.Assert(outcome.Scores, qt.DeepEquals, []db.ChoiceScore{
+		{Value: 0, Score: "1"}, {Value: 1, Score: "0"}, {Value: 2, Score: "1"}, {Value: 3, Score: "0"},
+	})
+	c.Assert(outcome.Winners, qt.DeepEquals, []uint32{0, 2})
+	c.Assert(outcome.InstantRunoff, qt.IsNil)
+	// a multichoice question has no decision without rules
+	c.Assert(res.Decision, qt.IsNil)
+}
+
+// TestVotingProcessDecision applies the decision rules of a question to its tally: measured while
+// the election runs, and decided once its results are final.
+func TestVotingProcessDecision(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(3)...)
+
+	yes := uint32(0)
+	pid, question := publishQuestion(t, token, orgAddress, members, false, apicommon.VotingProcessQuestionRequest{
+		Title: db.MultiLangString{"default": "Approve the budget"},
+		Choices: []db.Choice{
+			{Title: db.MultiLangString{"default": "Yes"}, Value: 0},
+			{Title: db.MultiLangString{"default": "No"}, Value: 1},
+		},
+		Type:  db.VotingTypeSingleChoice,
+		Rules: &db.DecisionRules{Quorum: 0.5, Majority: db.MajorityAbsolute, Choice: &yes},
+	})
+	election := question.UpstreamID
+	// two of the three participants vote yes: the quorum is reached and the majority met
+	castBallot(t, pid, election, members[0], `{"votes":[0]}`)
+	castBallot(t, pid, election, members[1], `{"votes":[0]}`)
+
+	res := questionResults(t, pid, func(r *db.QuestionResults) bool { return r.VoteCount == 2 })
+	decision := res.Decision
+	c.Assert(decision, qt.IsNotNil)
+	c.Assert(decision.EligibleWeight, qt.Equals, "3")
+	c.Assert(decision.VotedWeight, qt.Equals, "2")
+	c.Assert(*decision.QuorumReached, qt.IsTrue)
+	c.Assert(*decision.ThresholdMet, qt.IsTrue)
+	c.Assert(*decision.Choice, qt.Equals, yes)
+	// nothing is decided before the results are final
+	c.Assert(decision.Decision, qt.Equals, db.DecisionPending)
+
+	endJob := enqueueAndPollJob(t, http.MethodPut, token, &apicommon.SetProcessStatusRequest{Status: "ended"},
+		"processes", pid, "questions", question.ID.Hex(), "status")
+	c.Assert(endJob.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("end error: %s", endJob.Errors))
+	waitForElectionStatus(t, election, "RESULTS")
+	res = questionResults(t, pid, func(r *db.QuestionResults) bool { return r.FinalResults })
+	c.Assert(res.FinalResults, qt.IsTrue)
+	c.Assert(res.Decision.Decision, qt.Equals, db.DecisionPassed)
+}
+
+// TestInstantRunoffTooManyBallots stores a ranked question whose election holds more votes than an
+// instant-runoff count reads as not counted, without reading its ballots, so later reads serve the
+// stored marker instead of trying again.
+func TestInstantRunoffTooManyBallots(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(1)...)
+
+	req := newVotingProcessRequest(orgAddress, memberIDs(members))
+	req.Questions[0].Type = db.VotingTypeRanked
+	req.Questions[0].TypeSetup = db.QuestionTypeSetup{}
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](t, http.MethodPost, token, req,
+		processesCreateEndpoint)
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", created.ProcessID)
+	question, err := testDB.Question(got.Questions[0].ID)
+	c.Assert(err, qt.IsNil)
+
+	runoff := testAPI.questionInstantRunoff(question, maxRunoffBallots+1)
+	c.Assert(runoff, qt.DeepEquals, &db.InstantRunoff{
+		Ballots: maxRunoffBallots + 1, NotCounted: db.RunoffNotCountedTooManyBallots,
+	})
+	stored, err := testDB.Question(question.ID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.InstantRunoff, qt.DeepEquals, runoff)
+	c.Assert(testAPI.questionInstantRunoff(stored, maxRunoffBallots+1), qt.Equals, stored.InstantRunoff)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// maxTurnoutBreakdowns caps the breakdowns a single turnout request asks for.
+const maxTurnoutBreakdowns = 5
+
+// votingProcessTurnoutHandler godoc
+//
+//	@Summary		Get the turnout of a voting process
+//	@Description	Per question, the eligible participants of the process census (the question's
+//	@Description	eligibility list, if it has one) and how many of them the CSP has signed for, with
+//	@Description	their weight and the turnout ratios. `by` breaks each question down, comma-separated:
+//	@Description	`group` by member group, `other.<key>` by a value of the member's other data. Only
+//	@Description	aggregates of at least minCellSize participants are returned: a smaller question
+//	@Description	reports `suppressed`, a smaller group is left out, and the smaller values of a field
+//	@Description	are merged, with the smallest others until the merge is large enough too. A signature
+//	@Description	is counted when issued, whether or not the ballot reaches the chain. Requires
+//	@Description	Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string	true	"Process ID"
+//	@Param			by			query		string	false	"Breakdowns: group and/or other.<key>, comma-separated (up to 5)"
+//	@Success		200			{object}	db.ProcessTurnout
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/turnout [get]
+func (a *API) votingProcessTurnoutHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	// loads the process + questions and gates on Manager/Admin of the owning org.
+	vp, questions, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return
+	}
+	var breakdowns []string
+	if by := r.URL.Query().Get("by"); by != "" {
+		for _, field := range strings.Split(by, ",") {
+			field = strings.TrimSpace(field)
+			if !db.ValidTurnoutBreakdown(field) {
+				errors.ErrInvalidData.Withf("invalid breakdown %q: must be group or other.<key>", field).Write(w)
+				return
+			}
+			if !slices.Contains(breakdowns, field) {
+				breakdowns = append(breakdowns, field)
+			}
+		}
+		if len(breakdowns) > maxTurnoutBreakdowns {
+			errors.ErrInvalidData.Withf("too many breakdowns: the limit is %d", maxTurnoutBreakdowns).Write(w)
+			return
+		}
+	}
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	turnout, err := a.db.ProcessTurnout(vp, questions, census, breakdowns)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, turnout)
+}
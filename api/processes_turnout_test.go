@@ -0,0 +1,42 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestVotingProcessTurnout(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(6)...)
+	ids := memberIDs(members)
+
+	pid, got := publishedProcess(t, token, orgAddress, ids)
+	c.Assert(signAs(t, pid, members[0], got.Questions[0].UpstreamID), qt.Equals, http.StatusOK)
+
+	turnout := requestAndParse[db.ProcessTurnout](t, http.MethodGet, token, nil,
+		"processes", pid, "turnout?by=other.some,group")
+	c.Assert(turnout.MinCellSize, qt.Equals, db.TurnoutMinCellSize)
+	c.Assert(turnout.Questions, qt.HasLen, 2)
+	whole := turnout.Questions[0]
+	c.Assert(whole.Eligible, qt.Equals, int64(6))
+	c.Assert(whole.Signed, qt.Equals, int64(1))
+	c.Assert(whole.Breakdowns, qt.HasLen, 2)
+	c.Assert(whole.Breakdowns[0].By, qt.Equals, "other.some")
+	c.Assert(whole.Breakdowns[0].Cells, qt.HasLen, 1)
+	c.Assert(whole.Breakdowns[0].Cells[0].Value, qt.Equals, "data")
+	// the second question is open to a single member, too few to report
+	c.Assert(turnout.Questions[1].Suppressed, qt.IsTrue)
+	c.Assert(turnout.Questions[1].TurnoutCount, qt.IsNil)
+
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodGet, token, nil,
+		"processes", pid, "turnout?by=email")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, testCreateUser(t, "otherpassword123"), nil,
+		"processes", pid, "turnout")
+}
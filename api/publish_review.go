@@ -0,0 +1,320 @@
+package api
+
+import (
+	"encoding/json"
+	stderrors "errors"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// reviewRequiredProblem is the publish preflight problem of a draft its organization requires to be
+// approved, and that is not.
+const reviewRequiredProblem = "the draft must be approved by a reviewer before it is published"
+
+// publishReviewPolicyHandler godoc
+//
+//	@Summary		Get the publish review policy of an organization
+//	@Description	Get whether the drafts of the organization must be approved by a reviewer before they
+//	@Description	are published, and the role reviewers need. Returns a policy not required when it was
+//	@Description	never configured. Requires Manager/Admin role.
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Success		200			{object}	db.PublishReviewPolicy
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Router			/organizations/{orgAddress}/publish-review [get]
+func (a *API) publishReviewPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	policy := org.PublishReview
+	if policy == nil {
+		policy = &db.PublishReviewPolicy{}
+	}
+	apicommon.HTTPWriteJSON(w, policy)
+}
+
+// setPublishReviewPolicyHandler godoc
+//
+//	@Summary		Set the publish review policy of an organization
+//	@Description	With `required`, a draft of the organization can only be published once submitted for
+//	@Description	review and approved by a user with `reviewerRole` (admin, the default, or manager;
+//	@Description	admins may always review) other than the one who submitted it. Any edit of an approved
+//	@Description	draft voids the approval. Requires Admin role.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string					true	"Organization address"
+//	@Param			request		body		db.PublishReviewPolicy	true	"Publish review policy"
+//	@Success		200			{string}	string					"OK"
+//	@Failure		400			{object}	errors.Error			"Invalid input data"
+//	@Failure		401			{object}	errors.Error			"Unauthorized"
+//	@Failure		500			{object}	errors.Error			"Internal server error"
+//	@Router			/organizations/{orgAddress}/publish-review [put]
+func (a *API) setPublishReviewPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// the review exists to hold managers back, so only admins turn it on or off
+	if !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	policy := &db.PublishReviewPolicy{}
+	if err := json.NewDecoder(r.Body).Decode(policy); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	}
+	if err := a.db.SetPublishReviewPolicy(org.Address, policy); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// authorizeReview loads the draft in the path for a step of its review, which requires the
+// organization to have the review enabled and the user to be Manager/Admin of it.
+func (a *API) authorizeReview(w http.ResponseWriter, r *http.Request) (*db.VotingProcess, *db.Organization, *db.User, bool) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return nil, nil, nil, false
+	}
+	vp, ok := a.loadVotingProcess(w, oid)
+	if !ok {
+		return nil, nil, nil, false
+	}
+	if !user.HasRoleFor(vp.OrgAddress, db.ManagerRole) && !user.HasRoleFor(vp.OrgAddress, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin or manager of the organization").Write(w)
+		return nil, nil, nil, false
+	}
+	org, err := a.db.Organization(vp.OrgAddress)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return nil, nil, nil, false
+	}
+	if !org.ReviewRequired() {
+		errors.ErrPublishReviewDisabled.Write(w)
+		return nil, nil, nil, false
+	}
+	if vp.Published {
+		errors.ErrDuplicateConflict.Withf("process already published and not in draft mode").Write(w)
+		return nil, nil, nil, false
+	}
+	if refusePublishInProgress(w, vp) {
+		return nil, nil, nil, false
+	}
+	return vp, org, user, true
+}
+
+// writeReview answers a review step with the review as stored after it.
+func (a *API) writeReview(w http.ResponseWriter, vp *db.VotingProcess) {
+	current, err := a.db.VotingProcess(vp.ID)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, apicommon.ProcessReviewInfoFromDB(current.CurrentReview()))
+}
+
+// submitVotingProcessReviewHandler godoc
+//
+//	@Summary		Submit a draft for review
+//	@Description	Submit a draft of an organization that requires publish review, making its review
+//	@Description	pending. The review covers the draft as stored: any later edit voids it, and the draft
+//	@Description	has to be submitted again. Send the updatedAt read from GET /processes/{processId} to
+//	@Description	submit only if the draft was not written since (409, 40171, otherwise). A rejected
+//	@Description	draft may be submitted again. Requires Manager/Admin role.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string							true	"Process ID"
+//	@Param			request		body		apicommon.SubmitReviewRequest	false	"Draft version"
+//	@Success		200			{object}	apicommon.ProcessReviewInfo
+//	@Failure		400			{object}	errors.Error	"Invalid process ID or body"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		403			{object}	errors.Error	"Publish review not enabled"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		409			{object}	errors.Error	"Already submitted, published, being published, or changed"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/review [post]
+func (a *API) submitVotingProcessReviewHandler(w http.ResponseWriter, r *http.Request) {
+	vp, _, user, ok := a.authorizeReview(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.SubmitReviewRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	seen, err := parseUpdatedAt(req.UpdatedAt)
+	if err != nil {
+		errors.ErrMalformedBody.WithErr(err).Write(w)
+		return
+	}
+	if !seen.IsZero() && !seen.Equal(vp.UpdatedAt) {
+		errors.ErrStaleUpdate.Withf("the process was modified after updatedAt %s; refetch and retry",
+			req.UpdatedAt).Write(w)
+		return
+	}
+	if current := vp.CurrentReview(); current != nil && current.State != db.ReviewStateRejected {
+		errors.ErrDuplicateConflict.Withf("process already %s", current.State).Write(w)
+		return
+	}
+	if err := a.db.SubmitVotingProcessReview(vp.ID, vp.UpdatedAt, user.ID); err != nil {
+		if stderrors.Is(err, db.ErrConflict) {
+			a.writeDraftWriteConflict(w, vp.ID, vp.UpdatedAt.UTC().Format(apicommon.UpdatedAtLayout))
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	a.writeReview(w, vp)
+}
+
+// approveVotingProcessReviewHandler godoc
+//
+//	@Summary		Approve a draft
+//	@Description	Approve the pending review of a draft, with an optional comment, so it can be
+//	@Description	published as it is. Requires the reviewer role of the publish review policy, and to be
+//	@Description	another user than the one who submitted the draft.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string							true	"Process ID"
+//	@Param			request		body		apicommon.ReviewDecisionRequest	false	"Comment"
+//	@Success		200			{object}	apicommon.ProcessReviewInfo
+//	@Failure		400			{object}	errors.Error	"Invalid process ID or body"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		403			{object}	errors.Error	"Publish review not enabled, or own submission"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		409			{object}	errors.Error	"Not pending review, published or being published"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/review/approve [post]
+func (a *API) approveVotingProcessReviewHandler(w http.ResponseWriter, r *http.Request) {
+	a.decideVotingProcessReview(w, r, db.ReviewStateApproved)
+}
+
+// rejectVotingProcessReviewHandler godoc
+//
+//	@Summary		Reject a draft
+//	@Description	Reject the pending review of a draft with a comment saying what to change. The draft
+//	@Description	can be edited and submitted again. Requires the reviewer role of the publish review
+//	@Description	policy, and to be another user than the one who submitted the draft.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			processId	path		string							true	"Process ID"
+//	@Param			request		body		apicommon.ReviewDecisionRequest	true	"Comment"
+//	@Success		200			{object}	apicommon.ProcessReviewInfo
+//	@Failure		400			{object}	errors.Error	"Invalid process ID or body"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		403			{object}	errors.Error	"Publish review not enabled, or own submission"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		409			{object}	errors.Error	"Not pending review, published or being published"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/review/reject [post]
+func (a *API) rejectVotingProcessReviewHandler(w http.ResponseWriter, r *http.Request) {
+	a.decideVotingProcessReview(w, r, db.ReviewStateRejected)
+}
+
+// decideVotingProcessReview approves or rejects, by state, the pending review of the draft in the
+// path.
+func (a *API) decideVotingProcessReview(w http.ResponseWriter, r *http.Request, state string) {
+	vp, org, user, ok := a.authorizeReview(w, r)
+	if !ok {
+		return
+	}
+	if !org.PublishReview.CanReview(user, org.Address) {
+		errors.ErrUnauthorized.Withf("reviewing requires the %s role", org.PublishReview.ReviewerRole).Write(w)
+		return
+	}
+	req := &apicommon.ReviewDecisionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil && err != io.EOF {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	req.Comment = strings.TrimSpace(req.Comment)
+	if len(req.Comment) > db.MaxReviewCommentLength {
+		errors.ErrMalformedBody.Withf("comment is longer than %d bytes", db.MaxReviewCommentLength).Write(w)
+		return
+	}
+	if state == db.ReviewStateRejected && req.Comment == "" {
+		errors.ErrMalformedBody.Withf("a comment is required to reject").Write(w)
+		return
+	}
+	current := vp.CurrentReview()
+	if current == nil || current.State != db.ReviewStatePending {
+		errors.ErrReviewNotPending.Write(w)
+		return
+	}
+	if current.SubmittedBy == user.ID {
+		errors.ErrSelfReview.Write(w)
+		return
+	}
+	if err := a.db.ReviewVotingProcess(vp.ID, state, user.ID, req.Comment); err != nil {
+		if stderrors.Is(err, db.ErrConflict) {
+			errors.ErrReviewNotPending.Withf("the draft changed or was submitted again; refetch it").Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	a.writeReview(w, vp)
+}
+
+// voidReview drops the review of a draft ahead of a write of it that leaves updatedAt as it was,
+// so an approval does not outlive the change. It writes the error and returns false on failure.
+func (a *API) voidReview(w http.ResponseWriter, vp *db.VotingProcess) bool {
+	if vp.Published {
+		return true
+	}
+	if err := a.db.VoidVotingProcessReview(vp.ID); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return false
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestPublishReview(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	ids := memberIDs(members)
+	policyURL := []string{"organizations", orgAddress.String(), "publish-review"}
+
+	// a second user, manager of the organization
+	managerToken := testCreateUser(t, "managerpassword123")
+	me := requestAndParse[apicommon.UserInfo](t, http.MethodGet, managerToken, nil, usersMeEndpoint)
+	manager, err := testDB.UserByEmail(me.Email)
+	c.Assert(err, qt.IsNil)
+	manager.Organizations = append(manager.Organizations, db.OrganizationUser{Address: orgAddress, Role: db.ManagerRole})
+	_, err = testDB.SetUser(manager)
+	c.Assert(err, qt.IsNil)
+
+	created := requestAndParse[apicommon.CreateVotingProcessResponse](
+		t, http.MethodPost, managerToken, newVotingProcessRequest(orgAddress, ids), processesCreateEndpoint,
+	)
+	pid := created.ProcessID
+	requestAndAssertError(errors.ErrPublishReviewDisabled, t, http.MethodPost, managerToken, nil, "processes", pid, "review")
+
+	// only admins set the policy, to a role that can edit drafts
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPut, managerToken,
+		&db.PublishReviewPolicy{Required: true}, policyURL...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPut, token,
+		&db.PublishReviewPolicy{Required: true, ReviewerRole: db.ViewerRole}, policyURL...)
+	_, code := testRequest(t, http.MethodPut, token,
+		&db.PublishReviewPolicy{Required: true, ReviewerRole: db.ManagerRole}, policyURL...)
+	c.Assert(code, qt.Equals, http.StatusOK)
+	policy := requestAndParse[db.PublishReviewPolicy](t, http.MethodGet, managerToken, nil, policyURL...)
+	c.Assert(policy.Required, qt.IsTrue)
+	c.Assert(policy.ReviewerRole, qt.Equals, db.ManagerRole)
+
+	requestAndAssertError(errors.ErrPublishReviewRequired, t, http.MethodPost, token, nil, "processes", pid, "publish")
+	requestAndAssertError(errors.ErrReviewNotPending, t, http.MethodPost, token, nil, "processes", pid, "review", "approve")
+
+	// the manager submits, cannot approve their own draft, and the admin rejects it with a comment
+	review := requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, managerToken, nil, "processes", pid, "review")
+	c.Assert(review.State, qt.Equals, db.ReviewStatePending)
+	c.Assert(review.SubmittedBy, qt.Equals, me.ID)
+	requestAndAssertError(errors.ErrDuplicateConflict, t, http.MethodPost, managerToken, nil, "processes", pid, "review")
+	requestAndAssertError(errors.ErrSelfReview, t, http.MethodPost, managerToken, nil, "processes", pid, "review", "approve")
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, token,
+		&apicommon.ReviewDecisionRequest{}, "processes", pid, "review", "reject")
+	review = requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, token,
+		&apicommon.ReviewDecisionRequest{Comment: "add an end date reminder"}, "processes", pid, "review", "reject")
+	c.Assert(review.State, qt.Equals, db.ReviewStateRejected)
+	c.Assert(review.Comment, qt.Equals, "add an end date reminder")
+
+	// submitted again and approved, the draft reports it
+	requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, managerToken, nil, "processes", pid, "review")
+	requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, token, nil, "processes", pid, "review", "approve")
+	got := requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(got.Review.State, qt.Equals, db.ReviewStateApproved)
+
+	// an edit after the approval voids it
+	edit := newVotingProcessRequest(orgAddress, ids)
+	edit.UpdatedAt = got.UpdatedAt
+	_, code = testRequest(t, http.MethodPut, managerToken, edit, "processes", pid)
+	c.Assert(code, qt.Equals, http.StatusOK)
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(got.Review, qt.IsNil)
+	requestAndAssertError(errors.ErrPublishReviewRequired, t, http.MethodPost, token, nil, "processes", pid, "publish")
+
+	// a stale version cannot be submitted; the current one, submitted by the admin, the manager approves
+	requestAndAssertError(errors.ErrStaleUpdate, t, http.MethodPost, token,
+		&apicommon.SubmitReviewRequest{UpdatedAt: edit.UpdatedAt}, "processes", pid, "review")
+	requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, token,
+		&apicommon.SubmitReviewRequest{UpdatedAt: got.UpdatedAt}, "processes", pid, "review")
+	requestAndParse[apicommon.ProcessReviewInfo](t, http.MethodPost, managerToken,
+		&apicommon.ReviewDecisionRequest{Comment: "ok"}, "processes", pid, "review", "approve")
+
+	job := enqueueAndPollJob(t, http.MethodPost, token, nil, "processes", pid, "publish")
+	c.Assert(job.Status, qt.Equals, db.JobStatusCompleted, qt.Commentf("publish job error: %s", job.Errors))
+	got = requestAndParse[apicommon.VotingProcessResponse](t, http.MethodGet, token, nil, "processes", pid)
+	c.Assert(got.Published, qt.IsTrue)
+	c.Assert(got.Review, qt.IsNil)
+}
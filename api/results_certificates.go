@@ -0,0 +1,317 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/certificate"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+	"github.com/vocdoni/saas-backend/objectstorage"
+	"github.com/vocdoni/saas-backend/tally"
+	"go.vocdoni.io/dvote/log"
+)
+
+// Results certificate formats, by the format query parameter.
+const (
+	certificateFormatPDF = "pdf"
+	certificateFormatCSV = "csv"
+)
+
+// issuedCertificate is a signed results certificate in both renderings.
+type issuedCertificate struct {
+	csv, pdf []byte
+	digest   internal.HexBytes
+	sig      internal.HexBytes
+	signer   internal.HexBytes
+	issuedAt time.Time
+	final    bool
+}
+
+// resultsCertificateHandler godoc
+//
+//	@Summary		Download the results certificate of a voting process
+//	@Description	The signed results certificate of a published voting process, as a PDF (default)
+//	@Description	or CSV: the title and dates, the census root, URI and size, and per published
+//	@Description	question its on-chain election, status, turnout and the tally per choice, with the
+//	@Description	winners and the decision of its rules. The CSV is the data of the certificate: the
+//	@Description	backend key signs its sha256 digest, and the PDF carries the digest, the signature
+//	@Description	and the key. Both are returned in the X-Results-Digest, X-Results-Signature and
+//	@Description	X-Results-Signer headers, and X-Results-Final tells whether every result was final.
+//	@Description	Until then the certificate is provisional and issued anew on each request; the
+//	@Description	first one issued with final results is stored and served from then on. Check a
+//	@Description	certificate with POST /processes/{processId}/results-certificate/verify. Requires
+//	@Description	Manager/Admin of the owning organization.
+//	@Tags			processes
+//	@Produce		application/pdf
+//	@Produce		text/csv
+//	@Security		BearerAuth
+//	@Param			processId	path		string	true	"Process ID"
+//	@Param			format		query		string	false	"pdf (default) or csv"
+//	@Success		200			{file}		file
+//	@Failure		400			{object}	errors.Error	"Invalid process ID or format"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Process not found or not published"
+//	@Failure		500			{object}	errors.Error	"Internal server error or Vochain request failed"
+//	@Router			/processes/{processId}/results-certificate [get]
+func (a *API) resultsCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = certificateFormatPDF
+	}
+	if format != certificateFormatPDF && format != certificateFormatCSV {
+		errors.ErrMalformedURLParam.Withf("invalid format %q: must be pdf or csv", format).Write(w)
+		return
+	}
+	vp, questions, ok := a.authorizeStatusChange(w, r, oid)
+	if !ok {
+		return
+	}
+	if !vp.Published {
+		errors.ErrProcessNotFound.Withf("process not published").Write(w)
+		return
+	}
+	cert := vp.ResultsCertificate
+	var issued *issuedCertificate
+	if cert == nil {
+		var err error
+		if issued, err = a.issueResultsCertificate(vp, questions); err != nil {
+			if apiErr, ok := err.(errors.Error); ok {
+				apiErr.Write(w)
+				return
+			}
+			errors.ErrVochainRequestFailed.WithErr(err).Write(w)
+			return
+		}
+		if issued.final {
+			a.storeResultsCertificate(vp, issued)
+		}
+	}
+
+	var data []byte
+	var sum *db.ResultsCertificate
+	switch {
+	case issued != nil:
+		data = issued.pdf
+		if format == certificateFormatCSV {
+			data = issued.csv
+		}
+		sum = &db.ResultsCertificate{Digest: issued.digest, Signature: issued.sig, Signer: issued.signer}
+	default:
+		name := cert.PDF
+		if format == certificateFormatCSV {
+			name = cert.CSV
+		}
+		object, err := a.objectStorage.GetByName(name)
+		if err != nil {
+			errors.ErrGenericInternalServerError.Withf("could not read the stored certificate: %v", err).Write(w)
+			return
+		}
+		data, sum = object.Data, cert
+	}
+	contentType := objectstorage.FileTypePDF
+	if format == certificateFormatCSV {
+		contentType = objectstorage.FileTypeCSV
+	}
+	w.Header().Set("Content-Type", string(contentType))
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=process-%s-results.%s", oid.Hex(), format))
+	w.Header().Set("X-Results-Digest", sum.Digest.String())
+	w.Header().Set("X-Results-Signature", sum.Signature.String())
+	w.Header().Set("X-Results-Signer", sum.Signer.String())
+	w.Header().Set("X-Results-Final", fmt.Sprint(issued == nil || issued.final))
+	if _, err := w.Write(data); err != nil {
+		log.Warnw("could not write results certificate", "process", oid.Hex(), "error", err)
+	}
+}
+
+// issueResultsCertificate reads the results of a published process, renders its certificate and
+// signs it with the backend key. The certificate is final when every question is on chain with final
+// results, counted in full and, for a ranked question, with its instant-runoff count: one that could
+// not be counted yet would leave the stored certificate without it. The census and its total weight
+// are read or the certificate is not issued at all, so none is ever stored without them.
+func (a *API) issueResultsCertificate(
+	vp *db.VotingProcess, questions []db.VotingProcessQuestion,
+) (*issuedCertificate, error) {
+	census, err := a.db.Census(vp.CensusID.Hex())
+	if err != nil {
+		return nil, errors.ErrGenericInternalServerError.Withf("could not get the census of the process: %v", err)
+	}
+	totalWeight, err := a.censusTotalWeight(census)
+	if err != nil {
+		return nil, errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	entries, err := a.electionResultsBatch(questions, census)
+	if err != nil {
+		return nil, err
+	}
+	report := &certificate.Report{
+		ProcessID:  vp.ID.Hex(),
+		OrgAddress: vp.OrgAddress,
+		ChainID:    a.account.ChainID(),
+		Title:      vp.Title,
+		StartDate:  vp.StartDate,
+		EndDate:    vp.EndDate,
+		Final:      len(entries) > 0 && len(entries) == len(questions),
+		IssuedAt:   time.Now(),
+		Census: certificate.Census{
+			Root:        census.Published.Root,
+			URI:         census.Published.URI,
+			Size:        census.Size,
+			Weighted:    census.Weighted,
+			TotalWeight: totalWeight,
+		},
+	}
+	byID := make(map[string]*db.VotingProcessQuestion, len(questions))
+	for i := range questions {
+		byID[questions[i].ID.Hex()] = &questions[i]
+	}
+	for i := range entries {
+		e := &entries[i]
+		q := byID[e.QuestionID]
+		cq := certificate.Question{
+			QuestionID:   e.QuestionID,
+			UpstreamID:   e.UpstreamID,
+			Title:        q.Title,
+			Type:         q.Type,
+			Status:       q.Status,
+			VoteCount:    e.VoteCount,
+			MaxVoters:    e.MaxVoters,
+			FinalResults: e.FinalResults,
+		}
+		if o := e.Outcome; o != nil {
+			cq.Method, cq.Winners = o.Method, o.Winners
+			for _, s := range o.Scores {
+				cq.Choices = append(cq.Choices, certificate.Choice{Value: s.Value, Title: choiceTitle(q, s.Value), Score: s.Score})
+			}
+			if r := o.InstantRunoff; r != nil && r.NotCounted != "" {
+				cq.RunoffNotCounted = r.NotCounted
+			} else if r != nil {
+				cq.RunoffWinners = r.Winners
+			}
+		} else {
+			cq.Results = e.Results
+		}
+		if e.Decision != nil {
+			cq.Decision = e.Decision.Decision
+		}
+		if !e.FinalResults || (tally.Ranked(q) && e.Outcome != nil && e.Outcome.InstantRunoff == nil) {
+			report.Final = false
+		}
+		// an outcome that failed to count is left out of the entry, and so would be of the certificate
+		if e.Outcome == nil {
+			if _, err := tally.Outcome(q, e.Results); err != nil {
+				report.Final = false
+			}
+		}
+		report.Questions = append(report.Questions, cq)
+	}
+
+	out := &issuedCertificate{issuedAt: report.IssuedAt, final: report.Final}
+	if out.csv, err = report.CSV(); err != nil {
+		return nil, err
+	}
+	out.digest = certificate.Digest(out.csv)
+	if out.sig, err = a.csp.SignMessage(certificate.SignedMessage(report.ProcessID, out.digest)); err != nil {
+		return nil, fmt.Errorf("could not sign the results certificate: %w", err)
+	}
+	if out.signer, err = a.csp.PubKey(); err != nil {
+		return nil, fmt.Errorf("could not get the backend public key: %w", err)
+	}
+	out.pdf = report.PDF(out.digest, out.sig, out.signer)
+	return out, nil
+}
+
+// storeResultsCertificate stores a final certificate in object storage and records it on the
+// process. A failure is logged and the certificate is served all the same: the next request issues
+// it again.
+func (a *API) storeResultsCertificate(vp *db.VotingProcess, issued *issuedCertificate) {
+	cert := &db.ResultsCertificate{
+		Digest:    issued.digest,
+		Signature: issued.sig,
+		Signer:    issued.signer,
+		IssuedAt:  issued.issuedAt,
+	}
+	var err error
+	if cert.CSV, err = a.objectStorage.PutDocument(issued.csv, objectstorage.FileTypeCSV, metadataObjectUserID); err == nil {
+		cert.PDF, err = a.objectStorage.PutDocument(issued.pdf, objectstorage.FileTypePDF, metadataObjectUserID)
+	}
+	if err == nil {
+		err = a.db.SetVotingProcessResultsCertificate(vp.ID, cert)
+	}
+	if err != nil {
+		log.Warnw("could not store results certificate", "process", vp.ID.Hex(), "error", err)
+	}
+}
+
+// choiceTitle returns the title of the choice of q with the given value.
+func choiceTitle(q *db.VotingProcessQuestion, value uint32) db.MultiLangString {
+	for _, c := range q.Choices {
+		if c.Value == value {
+			return c.Title
+		}
+	}
+	return nil
+}
+
+// verifyResultsCertificateHandler godoc
+//
+//	@Summary		Verify a results certificate
+//	@Description	Check that the backend key signed a results certificate of a published voting
+//	@Description	process, given the sha256 digest of its CSV and its signature (both printed on the
+//	@Description	PDF). final tells whether it is the certificate stored once every result was final.
+//	@Description	A signature that does not verify is answered with valid false, not an error. No
+//	@Description	authentication is required.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Param			processId	path		string										true	"Process ID"
+//	@Param			request		body		apicommon.VerifyResultsCertificateRequest	true	"Certificate digest and signature"
+//	@Success		200			{object}	apicommon.VerifyResultsCertificateResponse
+//	@Failure		400			{object}	errors.Error	"Invalid process ID or body"
+//	@Failure		404			{object}	errors.Error	"Process not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/processes/{processId}/results-certificate/verify [post]
+func (a *API) verifyResultsCertificateHandler(w http.ResponseWriter, r *http.Request) {
+	oid, ok := a.votingProcessID(w, r)
+	if !ok {
+		return
+	}
+	req := &apicommon.VerifyResultsCertificateRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if len(req.Digest) == 0 || len(req.Signature) == 0 {
+		errors.ErrMalformedBody.Withf("digest and signature are required").Write(w)
+		return
+	}
+	vp, ok := a.loadVotingProcess(w, oid)
+	if !ok {
+		return
+	}
+	if !vp.Published {
+		errors.ErrProcessNotFound.Withf("process not published").Write(w)
+		return
+	}
+	key, err := a.csp.PubKey()
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	resp := &apicommon.VerifyResultsCertificateResponse{}
+	signer, err := certificate.Verify(oid.Hex(), req.Digest, req.Signature, key)
+	if err == nil {
+		resp.Valid, resp.Signer = true, signer
+		resp.Final = vp.ResultsCertificate != nil && bytes.Equal(vp.ResultsCertificate.Digest, req.Digest)
+	}
+	apicommon.HTTPWriteJSON(w, resp)
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/certificate"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/internal"
+)
+
+func TestResultsCertificate(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateProvisionedOrganization(t, token)
+	setOrganizationSubscription(t, orgAddress, mockEssentialPlan.ID)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	pid, got := publishedProcess(t, token, orgAddress, memberIDs(members))
+
+	// the CSV is served with its digest and signature in the headers
+	req, err := http.NewRequest(http.MethodGet,
+		fmt.Sprintf("http://%s:%d/processes/%s/results-certificate?format=csv", testHost, testPort, pid), nil)
+	c.Assert(err, qt.IsNil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	resp, err := http.DefaultClient.Do(req)
+	c.Assert(err, qt.IsNil)
+	defer resp.Body.Close()
+	c.Assert(resp.StatusCode, qt.Equals, http.StatusOK)
+	data, err := io.ReadAll(resp.Body)
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.Contains(data, []byte("process,id,"+pid)), qt.IsTrue)
+	c.Assert(bytes.Contains(data, []byte("electionId,"+got.Questions[0].UpstreamID.String())), qt.IsTrue)
+	c.Assert(resp.Header.Get("X-Results-Final"), qt.Equals, "false", qt.Commentf("no question has final results"))
+	digest := certificate.Digest(data)
+	c.Assert(resp.Header.Get("X-Results-Digest"), qt.Equals, digest.String())
+	signature := internal.HexBytes{}
+	c.Assert(signature.ParseString(resp.Header.Get("X-Results-Signature")), qt.IsNil)
+
+	// which the backend key made, but the certificate is not the final one
+	verify := requestAndParse[apicommon.VerifyResultsCertificateResponse](t, http.MethodPost, "",
+		&apicommon.VerifyResultsCertificateRequest{Digest: digest, Signature: signature},
+		"processes", pid, "results-certificate", "verify")
+	c.Assert(verify.Valid, qt.IsTrue)
+	c.Assert(verify.Signer.String(), qt.Equals, resp.Header.Get("X-Results-Signer"))
+	c.Assert(verify.Final, qt.IsFalse)
+	// an altered CSV does not verify
+	verify = requestAndParse[apicommon.VerifyResultsCertificateResponse](t, http.MethodPost, "",
+		&apicommon.VerifyResultsCertificateRequest{Digest: certificate.Digest(append(data, ' ')), Signature: signature},
+		"processes", pid, "results-certificate", "verify")
+	c.Assert(verify.Valid, qt.IsFalse)
+
+	pdf, code := testRequest(t, http.MethodGet, token, nil, "processes", pid, "results-certificate")
+	c.Assert(code, qt.Equals, http.StatusOK)
+	c.Assert(bytes.HasPrefix(pdf, []byte("%PDF-")), qt.IsTrue)
+
+	requestAndAssertError(errors.ErrMalformedURLParam, t, http.MethodGet, token, nil,
+		"processes", pid, "results-certificate?format=xlsx")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodGet, testCreateUser(t, "otherpassword123"), nil,
+		"processes", pid, "results-certificate")
+	requestAndAssertError(errors.ErrMalformedBody, t, http.MethodPost, "",
+		&apicommon.VerifyResultsCertificateRequest{Digest: digest},
+		"processes", pid, "results-certificate", "verify")
+}
@@ -0,0 +1,103 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// retentionPolicyHandler godoc
+//
+//	@Summary		Get the data retention policy of an organization
+//	@Description	Get the rules the retention sweeper enforces on the organization's members, and the
+//	@Description	outcome of its last sweep. Returns an empty policy when retention was never configured.
+//	@Description	Requires Manager/Admin role.
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Success		200			{object}	db.RetentionPolicy
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Router			/organizations/{orgAddress}/retention [get]
+func (a *API) retentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	policy := org.RetentionPolicy
+	if policy == nil {
+		policy = &db.RetentionPolicy{}
+	}
+	apicommon.HTTPWriteJSON(w, policy)
+}
+
+// setRetentionPolicyHandler godoc
+//
+//	@Summary		Set the data retention policy of an organization
+//	@Description	Set the rules a background sweeper enforces on the organization's members. With
+//	@Description	`anonymizeInactiveMembersMonths`, members neither created, changed nor added to a census
+//	@Description	for that many months have their personal data irreversibly replaced by placeholders,
+//	@Description	keeping member and census counts and results intact. With `purgeCspTokensDays`, the CSP
+//	@Description	auth tokens of a voting process are deleted that many days after it ended. 0 disables a
+//	@Description	rule. `lastSweep` is ignored. Requires Admin role.
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string				true	"Organization address"
+//	@Param			request		body		db.RetentionPolicy	true	"Retention rules"
+//	@Success		200			{string}	string				"OK"
+//	@Failure		400			{object}	errors.Error		"Invalid input data"
+//	@Failure		401			{object}	errors.Error		"Unauthorized"
+//	@Failure		500			{object}	errors.Error		"Internal server error"
+//	@Router			/organizations/{orgAddress}/retention [put]
+func (a *API) setRetentionPolicyHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// erasing member data cannot be undone, so only admins decide when it happens
+	if !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	policy := &db.RetentionPolicy{}
+	if err := json.NewDecoder(r.Body).Decode(policy); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	if err := policy.Validate(); err != nil {
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	}
+	if err := a.db.SetRetentionPolicy(org.Address, policy); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
@@ -0,0 +1,33 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	retentionURL := []string{"organizations", orgAddress.String(), "retention"}
+
+	policy := requestAndParse[db.RetentionPolicy](t, http.MethodGet, token, nil, retentionURL...)
+	c.Assert(policy, qt.DeepEquals, db.RetentionPolicy{})
+
+	requestAndAssertCode(http.StatusOK, t, http.MethodPut, token,
+		&db.RetentionPolicy{AnonymizeInactiveMembersMonths: 12, PurgeCSPTokensDays: 90}, retentionURL...)
+	policy = requestAndParse[db.RetentionPolicy](t, http.MethodGet, token, nil, retentionURL...)
+	c.Assert(policy.AnonymizeInactiveMembersMonths, qt.Equals, 12)
+	c.Assert(policy.PurgeCSPTokensDays, qt.Equals, 90)
+
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPut, token,
+		&db.RetentionPolicy{AnonymizeInactiveMembersMonths: db.MaxRetentionMonths + 1}, retentionURL...)
+
+	// another user's organization is out of reach
+	otherToken := testCreateUser(t, "otherpassword123")
+	requestAndAssertError(errors.ErrUnauthorized, t, http.MethodPut, otherToken, &db.RetentionPolicy{}, retentionURL...)
+}
@@ -92,6 +92,56 @@ const (
 	organizationUpsertMemberEndpoint = "/organizations/{orgAddress}/members"
 	// DELETE /organizations/{orgAddress}/members to delete members
 	organizationDeleteMembersEndpoint = "/organizations/{orgAddress}/members"
+	// GET /organizations/{orgAddress}/members/duplicates to find likely duplicate members
+	organizationMemberDuplicatesEndpoint = "/organizations/{orgAddress}/members/duplicates"
+	// POST /organizations/{orgAddress}/members/merge to fold duplicate members into a surviving one
+	organizationMergeMembersEndpoint = "/organizations/{orgAddress}/members/merge"
+	// GET/PUT /organizations/{orgAddress}/members/selfservice to get or set the member self-service portal config
+	organizationMemberSelfServiceEndpoint = "/organizations/{orgAddress}/members/selfservice"
+	// POST /organizations/{orgAddress}/members/selfservice/auth to request a self-service login code (public)
+	organizationMemberSelfServiceAuthEndpoint = "/organizations/{orgAddress}/members/selfservice/auth"
+	// POST /organizations/{orgAddress}/members/selfservice/verify to verify a self-service login code (public)
+	organizationMemberSelfServiceVerifyEndpoint = "/organizations/{orgAddress}/members/selfservice/verify"
+	// PUT /organizations/{orgAddress}/members/selfservice/profile to submit a self-service profile change (public)
+	organizationMemberSelfServiceProfileEndpoint = "/organizations/{orgAddress}/members/selfservice/profile"
+	// GET /organizations/{orgAddress}/members/updates to list the member self-service update requests
+	organizationMemberUpdatesEndpoint = "/organizations/{orgAddress}/members/updates"
+	// PUT /organizations/{orgAddress}/members/updates/{requestId} to approve or reject a member update request
+	organizationMemberUpdateEndpoint = "/organizations/{orgAddress}/members/updates/{requestId}"
+	// GET /organizations/{orgAddress}/members/{memberId}/history to get the change history of a member
+	organizationMemberHistoryEndpoint = "/organizations/{orgAddress}/members/{memberId}/history"
+	// POST /organizations/{orgAddress}/members/search to search members with filters, sort and cursor
+	organizationMemberSearchEndpoint = "/organizations/{orgAddress}/members/search"
+	// GET /organizations/{orgAddress}/members/{memberId}/unsubscribe to get the unsubscribe link of a member
+	organizationMemberUnsubscribeLinkEndpoint = "/organizations/{orgAddress}/members/{memberId}/unsubscribe"
+	// GET/POST/DELETE /organizations/{orgAddress}/suppressions to list, add or remove suppressed addresses
+	organizationSuppressionsEndpoint = "/organizations/{orgAddress}/suppressions"
+	// GET/POST /organizations/{orgAddress}/process-templates to list or save voting process templates
+	organizationProcessTemplatesEndpoint = "/organizations/{orgAddress}/process-templates"
+	// GET/DELETE /organizations/{orgAddress}/process-templates/{templateId} to get or delete a template
+	organizationProcessTemplateEndpoint = "/organizations/{orgAddress}/process-templates/{templateId}"
+	// POST /organizations/{orgAddress}/process-templates/{templateId}/processes to create a draft from a template
+	organizationProcessTemplateProcessesEndpoint = "/organizations/{orgAddress}/process-templates/{templateId}/processes"
+	// GET /organizations/{orgAddress}/scheduled-publishes to list the drafts with a scheduled publish
+	organizationScheduledPublishesEndpoint = "/organizations/{orgAddress}/scheduled-publishes"
+	// GET/POST /unsubscribe to describe or follow a signed unsubscribe link (public)
+	unsubscribeEndpoint = "/unsubscribe"
+	// GET/PUT /organizations/{orgAddress}/retention to get or set the data retention policy of the organization
+	organizationRetentionEndpoint = "/organizations/{orgAddress}/retention"
+	// GET/PUT /organizations/{orgAddress}/publish-review to get or set whether drafts need approval before publishing
+	organizationPublishReviewEndpoint = "/organizations/{orgAddress}/publish-review"
+	// GET /organizations/{orgAddress}/scim/v2/ServiceProviderConfig to describe the SCIM 2.0 server
+	scimServiceProviderConfigEndpoint = "/organizations/{orgAddress}/scim/v2/ServiceProviderConfig"
+	// GET/POST /organizations/{orgAddress}/scim/v2/Users to list/create SCIM users (org members)
+	scimUsersEndpoint = "/organizations/{orgAddress}/scim/v2/Users"
+	// GET/PUT/PATCH/DELETE /organizations/{orgAddress}/scim/v2/Users/{scimId} to manage a SCIM user
+	scimUserEndpoint = "/organizations/{orgAddress}/scim/v2/Users/{scimId}"
+	// GET/POST /organizations/{orgAddress}/scim/v2/Groups to list/create SCIM groups (member groups)
+	scimGroupsEndpoint = "/organizations/{orgAddress}/scim/v2/Groups"
+	// GET/PUT/PATCH/DELETE /organizations/{orgAddress}/scim/v2/Groups/{scimId} to manage a SCIM group
+	scimGroupEndpoint = "/organizations/{orgAddress}/scim/v2/Groups/{scimId}"
+	// POST /organizations/{orgAddress}/scim/v2/Bulk to run several SCIM operations at once
+	scimBulkEndpoint = "/organizations/{orgAddress}/scim/v2/Bulk"
 	// POST/PUT/GET/DELETE /organizations/{orgAddress}/meta to add/set/get/delete the organization metadata
 	organizationMetaEndpoint = "/organizations/{orgAddress}/meta"
 	// POST /organizations/{orgAddress}/ticket to create a new ticket to our support system
@@ -99,6 +149,8 @@ const (
 	// POST/GET /organizations/{orgAddress}/groups to create a new organization member group or get the
 	// list of groups of an organization
 	organizationGroupsEndpoint = "/organizations/{orgAddress}/groups"
+	// POST /organizations/{orgAddress}/groups/combine to create a member group from a set operation on groups
+	organizationGroupsCombineEndpoint = "/organizations/{orgAddress}/groups/combine"
 	// PUT/DELETE /organizations/{orgAddress}/groups/{groupId} to update or delete an organization member group
 	organizationGroupEndpoint = "/organizations/{orgAddress}/groups/{groupId}"
 	// GET /organizations/{orgAddress}/groups/{groupId}/members to get the members of an organization member group
@@ -150,6 +202,14 @@ const (
 	censusGroupPublishEndpoint = "/census/{id}/group/{groupId}/publish"
 	// GET /census/{id}/participants to get the census participants
 	censusParticipantsEndpoint = "/census/{id}/participants"
+	// GET /census/{id}/snapshots to list the snapshots recorded at each publication of a census
+	censusSnapshotsEndpoint = "/census/{id}/snapshots"
+	// GET /census/{id}/snapshots/diff?from=&to= to diff the participants of two snapshots
+	censusSnapshotsDiffEndpoint = "/census/{id}/snapshots/diff"
+	// POST /census/{id}/export to start building the signed auditor export of a published census
+	censusExportEndpoint = "/census/{id}/export"
+	// GET /census/{id}/export/{jobId} to download the export built by a job
+	censusExportJobEndpoint = "/census/{id}/export/{jobId}"
 
 	// process routes
 	// POST /process/{processId} to create a new process
@@ -224,6 +284,8 @@ const (
 	// PUT /processes/{processId}/census adds members to a published process's census (+ maxCensusSize bump);
 	// DELETE removes them from it and from every question eligibility list built on it
 	processesCensusEndpoint = "/processes/{processId}/census"
+	// POST /processes/{processId}/census/import replaces the participants of a draft's ephemeral census from a CSV
+	processesCensusImportEndpoint = "/processes/{processId}/census/import"
 	// GET /processes/{processId}/validation publish-readiness dry-run (protected)
 	processesValidateEndpoint = "/processes/{processId}/validation"
 	// POST /processes/{processId}/check voter eligibility/status (public CSP)
@@ -242,9 +304,34 @@ const (
 	processesParticipantEndpoint = "/processes/{processId}/participants/{participantId}"
 	// GET /processes/{processId}/results for the per-question on-chain results (public)
 	processesResultsEndpoint = "/processes/{processId}/results"
+	// GET /processes/{processId}/stream for the Server-Sent Events stream of the question statuses
+	// and tallies (public)
+	processesStreamEndpoint = "/processes/{processId}/stream"
+	// GET /processes/{processId}/results-certificate?format=pdf|csv for the signed results certificate
+	// (protected), POST .../verify to check one (public)
+	processesResultsCertificateEndpoint       = "/processes/{processId}/results-certificate"
+	processesResultsCertificateVerifyEndpoint = "/processes/{processId}/results-certificate/verify"
 	// GET /processes/{processId}/participants?field=&value= — Manager/Admin lookup of org members by
 	// field intersected with the census, with per-question voted status (protected)
 	processesParticipantsEndpoint = "/processes/{processId}/participants"
+	// GET /processes/{processId}/turnout for the per-question turnout, broken down by group or member field
+	processesTurnoutEndpoint = "/processes/{processId}/turnout"
+	// GET, POST /processes/{processId}/delegations to list and register vote delegations in the
+	// process census, DELETE /processes/{processId}/delegations/{delegatorId} to revoke one (protected)
+	processesDelegationsEndpoint = "/processes/{processId}/delegations"
+	processesDelegationEndpoint  = "/processes/{processId}/delegations/{delegatorId}"
+	// POST /processes/{processId}/clone to copy a process into a new draft (protected)
+	processesCloneEndpoint = "/processes/{processId}/clone"
+	// PUT/DELETE /processes/{processId}/schedule to schedule or cancel the publish of a draft (protected)
+	processesScheduleEndpoint = "/processes/{processId}/schedule"
+	// POST /processes/{processId}/review to submit a draft for review, POST .../review/approve and
+	// .../review/reject to decide on it (protected)
+	processesReviewEndpoint        = "/processes/{processId}/review"
+	processesReviewApproveEndpoint = "/processes/{processId}/review/approve"
+	processesReviewRejectEndpoint  = "/processes/{processId}/review/reject"
+	// POST /processes/{processId}/questions/{questionId}/runoff to create a run-off round of a
+	// question between its leading choices (protected)
+	processesQuestionRunoffEndpoint = "/processes/{processId}/questions/{questionId}/runoff"
 	// POST /processes/{processId}/sign-info — voter's per-question consumed address/nullifier (public)
 	processesSignInfoEndpoint = "/processes/{processId}/sign-info"
 	// CSP voter routes for a voting process (public)
@@ -257,6 +344,9 @@ const (
 	// follows reports per ballot: one entry can fail while its siblings succeed.
 	processesSignBatchEndpoint = "/processes/{processId}/sign-batch"
 	processesWeightEndpoint    = "/processes/{processId}/weight"
+	// POST /processes/{processId}/delegation to hand the voter's vote to another participant, and
+	// DELETE to take it back, until the process starts (public, the auth token authenticates)
+	processesVoterDelegationEndpoint = "/processes/{processId}/delegation"
 
 	// // census auth routes (currently not implemented)
 	// // POST /process/{processId}/auth/0 to initiate auth
@@ -0,0 +1,1519 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	scimContentType = "application/scim+json"
+	// scimMaxResults caps a page of a SCIM list, filtered or not.
+	scimMaxResults = 1000
+	// scimMaxOperations and scimMaxPayloadSize are the bulk limits advertised to clients.
+	scimMaxOperations  = 100
+	scimMaxPayloadSize = 1 << 20
+
+	scimResourceUsers  = "Users"
+	scimResourceGroups = "Groups"
+)
+
+// scimError is a failed SCIM operation. SCIM clients parse their own error format (RFC 7644
+// §3.12), so it is written as a SCIM error body rather than as an errors.Error.
+type scimError struct {
+	status   int
+	scimType string
+	detail   string
+}
+
+func newSCIMError(status int, scimType, format string, args ...any) *scimError {
+	return &scimError{status: status, scimType: scimType, detail: fmt.Sprintf(format, args...)}
+}
+
+// scimErrorFrom converts an API error into a SCIM error with the same status, its data (the
+// signed member ids of a refused removal, for instance) appended to the detail. Any other error
+// is a 500.
+func scimErrorFrom(err error) *scimError {
+	var apiErr errors.Error
+	if !errors.As(err, &apiErr) {
+		return newSCIMError(http.StatusInternalServerError, "", "%v", err)
+	}
+	detail := apiErr.Error()
+	if apiErr.Data != nil {
+		if data, err := json.Marshal(apiErr.Data); err == nil {
+			detail += ": " + string(data)
+		}
+	}
+	return &scimError{status: apiErr.HTTPstatus, detail: detail}
+}
+
+// scimErrorFromDB converts the error of a db write on a SCIM resource.
+func scimErrorFromDB(err error, resource string) *scimError {
+	switch {
+	case errors.Is(err, db.ErrUpdateWouldCreateDuplicates):
+		return newSCIMError(http.StatusConflict, "uniqueness", "%v", err)
+	case errors.Is(err, db.ErrInvalidData):
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "%v", err)
+	default:
+		return newSCIMError(http.StatusInternalServerError, "", "could not write %s: %v", resource, err)
+	}
+}
+
+func (e *scimError) response() *apicommon.SCIMError {
+	return &apicommon.SCIMError{
+		Schemas:  []string{apicommon.SCIMMessageError},
+		Status:   strconv.Itoa(e.status),
+		ScimType: e.scimType,
+		Detail:   e.detail,
+	}
+}
+
+// writeSCIM writes a SCIM response. A nil body writes the status alone, as a deletion answers.
+func writeSCIM(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", scimContentType)
+	w.WriteHeader(status)
+	if body == nil {
+		return
+	}
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Warnw("failed to write SCIM response", "error", err)
+	}
+}
+
+func writeSCIMError(w http.ResponseWriter, e *scimError) {
+	writeSCIM(w, e.status, e.response())
+}
+
+// decodeSCIM decodes a SCIM request body.
+func decodeSCIM(data []byte, v any) *scimError {
+	if err := json.Unmarshal(data, v); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", "invalid body: %v", err)
+	}
+	return nil
+}
+
+// scimScope is what a SCIM operation runs within: the organization, and who is provisioning it,
+// for the member history.
+type scimScope struct {
+	org    *db.Organization
+	source db.MemberChangeSource
+}
+
+// location is the URL path of a SCIM resource.
+func (s *scimScope) location(resource, id string) string {
+	return "/organizations/" + s.org.Address.Hex() + "/scim/v2/" + resource + "/" + id
+}
+
+func (s *scimScope) meta(resource, id string, created, modified time.Time) *apicommon.SCIMMeta {
+	meta := &apicommon.SCIMMeta{
+		ResourceType: strings.TrimSuffix(resource, "s"),
+		Location:     s.location(resource, id),
+	}
+	if !created.IsZero() {
+		meta.Created = &created
+	}
+	if !modified.IsZero() {
+		meta.LastModified = &modified
+	}
+	return meta
+}
+
+// scimScopeFromRequest resolves the organization of a SCIM request and checks the caller manages
+// it, writing the SCIM error otherwise.
+func (a *API) scimScopeFromRequest(w http.ResponseWriter, r *http.Request) (*scimScope, bool) {
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		writeSCIMError(w, newSCIMError(http.StatusNotFound, "", "organization not found"))
+		return nil, false
+	}
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		writeSCIMError(w, newSCIMError(http.StatusUnauthorized, "", "unauthorized"))
+		return nil, false
+	}
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		writeSCIMError(w, newSCIMError(http.StatusForbidden, "", "user is not admin of organization"))
+		return nil, false
+	}
+	return &scimScope{org: org, source: memberChangeSource(r, user, db.MemberChangeViaSCIM)}, true
+}
+
+// scimPage reads the startIndex (1-based) and count of a SCIM list request.
+func scimPage(r *http.Request) (startIndex, count int64, serr *scimError) {
+	startIndex, count = 1, scimMaxResults
+	if v := r.URL.Query().Get("startIndex"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid startIndex %q", v)
+		}
+		startIndex = max(n, 1)
+	}
+	if v := r.URL.Query().Get("count"); v != "" {
+		n, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, 0, newSCIMError(http.StatusBadRequest, "invalidValue", "invalid count %q", v)
+		}
+		count = min(max(n, 0), scimMaxResults)
+	}
+	return startIndex, count, nil
+}
+
+// scimPageOf returns the page of items starting at the 1-based startIndex.
+func scimPageOf[T any](items []T, startIndex, count int64) []T {
+	from := min(startIndex-1, int64(len(items)))
+	to := min(from+count, int64(len(items)))
+	return items[from:to]
+}
+
+func scimListResponse(total, startIndex int64, resources []any) *apicommon.SCIMListResponse {
+	return &apicommon.SCIMListResponse{
+		Schemas:      []string{apicommon.SCIMMessageListResponse},
+		TotalResults: total,
+		StartIndex:   startIndex,
+		ItemsPerPage: int64(len(resources)),
+		Resources:    resources,
+	}
+}
+
+// scimCondition is one `<attribute> eq <value>` term of a SCIM filter. attr is lowercased, since
+// SCIM attribute names are case-insensitive.
+type scimCondition struct {
+	attr  string
+	value string
+}
+
+// parseSCIMFilter parses the subset of the SCIM filter grammar that provisioning clients send to
+// look a resource up: equality terms joined by "and". Anything else is refused as invalidFilter.
+func parseSCIMFilter(filter string) ([]scimCondition, *scimError) {
+	invalid := func(format string, args ...any) *scimError {
+		return newSCIMError(http.StatusBadRequest, "invalidFilter", format, args...)
+	}
+	var conditions []scimCondition
+	rest := strings.TrimSpace(filter)
+	for {
+		attr, after, ok := strings.Cut(rest, " ")
+		if !ok {
+			return nil, invalid("invalid filter %q", filter)
+		}
+		op, after, ok := strings.Cut(strings.TrimLeft(after, " "), " ")
+		if !ok || !strings.EqualFold(op, "eq") {
+			return nil, invalid("only the eq operator is supported")
+		}
+		value, after, serr := scimFilterValue(strings.TrimLeft(after, " "))
+		if serr != nil {
+			return nil, serr
+		}
+		conditions = append(conditions, scimCondition{attr: strings.ToLower(attr), value: value})
+
+		after = strings.TrimSpace(after)
+		if after == "" {
+			return conditions, nil
+		}
+		keyword, after, ok := strings.Cut(after, " ")
+		if !ok || !strings.EqualFold(keyword, "and") {
+			return nil, invalid("only terms joined by and are supported")
+		}
+		rest = strings.TrimLeft(after, " ")
+	}
+}
+
+// scimFilterValue reads the value of a filter term, a JSON string or a bare word (true, a
+// number), and returns the rest of the filter.
+func scimFilterValue(s string) (value, rest string, serr *scimError) {
+	if !strings.HasPrefix(s, `"`) {
+		value, rest, _ = strings.Cut(s, " ")
+		if value == "" {
+			return "", "", newSCIMError(http.StatusBadRequest, "invalidFilter", "missing filter value")
+		}
+		return value, rest, nil
+	}
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			i++
+		case '"':
+			if err := json.Unmarshal([]byte(s[:i+1]), &value); err != nil {
+				return "", "", newSCIMError(http.StatusBadRequest, "invalidFilter", "invalid filter value %s", s[:i+1])
+			}
+			return value, s[i+1:], nil
+		}
+	}
+	return "", "", newSCIMError(http.StatusBadRequest, "invalidFilter", "unterminated filter value")
+}
+
+// scimUserFilterFields maps the User attributes a filter can match onto member lookup fields.
+var scimUserFilterFields = map[string]db.OrgMemberLookupField{
+	"username":        db.OrgMemberLookupFieldMemberNumber,
+	"externalid":      db.OrgMemberLookupFieldExternalID,
+	"emails":          db.OrgMemberLookupFieldEmail,
+	"emails.value":    db.OrgMemberLookupFieldEmail,
+	"name.givenname":  db.OrgMemberLookupFieldName,
+	"name.familyname": db.OrgMemberLookupFieldSurname,
+}
+
+// scimMemberField returns the value of a lookup field of member.
+func scimMemberField(member *db.OrgMember, field db.OrgMemberLookupField) string {
+	switch field {
+	case db.OrgMemberLookupFieldMemberNumber:
+		return member.MemberNumber
+	case db.OrgMemberLookupFieldExternalID:
+		return member.ExternalID
+	case db.OrgMemberLookupFieldEmail:
+		return member.Email
+	case db.OrgMemberLookupFieldName:
+		return member.Name
+	case db.OrgMemberLookupFieldSurname:
+		return member.Surname
+	default:
+		return ""
+	}
+}
+
+// scimFindUsers returns the members matching every filter condition, in creation order. The
+// first condition is looked up in the db, the others are checked on its matches.
+func (a *API) scimFindUsers(s *scimScope, conditions []scimCondition) ([]*db.OrgMember, *scimError) {
+	fields := make([]db.OrgMemberLookupField, 0, len(conditions))
+	for i, c := range conditions {
+		field, ok := scimUserFilterFields[c.attr]
+		if !ok {
+			return nil, newSCIMError(http.StatusBadRequest, "invalidFilter", "cannot filter users by %q", c.attr)
+		}
+		// emails are stored lowercased
+		if field == db.OrgMemberLookupFieldEmail {
+			conditions[i].value = strings.ToLower(c.value)
+		}
+		fields = append(fields, field)
+	}
+	if conditions[0].value == "" {
+		return []*db.OrgMember{}, nil
+	}
+	members, err := a.db.OrgMembersByField(s.org.Address, fields[0], conditions[0].value)
+	if err != nil {
+		return nil, newSCIMError(http.StatusInternalServerError, "", "could not look users up: %v", err)
+	}
+	return slices.DeleteFunc(members, func(m *db.OrgMember) bool {
+		for i, c := range conditions[1:] {
+			if scimMemberField(m, fields[i+1]) != c.value {
+				return true
+			}
+		}
+		return false
+	}), nil
+}
+
+// scimUser maps an org member onto a SCIM User. The phone is stored hashed, so it is never
+// returned.
+func (s *scimScope) scimUser(member *db.OrgMember) *apicommon.SCIMUser {
+	active := member.DeactivatedAt.IsZero()
+	id := member.ID.Hex()
+	user := &apicommon.SCIMUser{
+		Schemas:    []string{apicommon.SCIMSchemaUser, apicommon.SCIMSchemaMember},
+		ID:         id,
+		ExternalID: member.ExternalID,
+		UserName:   member.MemberNumber,
+		Active:     &active,
+		Member: &apicommon.SCIMMemberExtension{
+			NationalID: member.NationalID,
+			BirthDate:  member.BirthDate,
+			Weight:     member.Weight,
+		},
+		Meta: s.meta(scimResourceUsers, id, member.CreatedAt, member.UpdatedAt),
+	}
+	if member.Name != "" || member.Surname != "" {
+		user.Name = &apicommon.SCIMName{GivenName: member.Name, FamilyName: member.Surname}
+	}
+	if member.Email != "" {
+		user.Emails = []apicommon.SCIMMultiValue{{Value: member.Email, Primary: true}}
+	}
+	return user
+}
+
+// scimPrimary returns the primary value of a multi-valued attribute, or else its first one.
+func scimPrimary(values []apicommon.SCIMMultiValue) string {
+	for _, v := range values {
+		if v.Primary {
+			return v.Value
+		}
+	}
+	if len(values) > 0 {
+		return values[0].Value
+	}
+	return ""
+}
+
+// applySCIMUser copies the attributes of a SCIM User onto member. An absent or empty attribute
+// keeps the member's value: the memberbase cannot tell a cleared field from an omitted one, and
+// clearing a login field would lock the member out of every census built on it.
+func applySCIMUser(member *db.OrgMember, user *apicommon.SCIMUser) {
+	set := func(field *string, value string) {
+		if value != "" {
+			*field = value
+		}
+	}
+	set(&member.MemberNumber, user.UserName)
+	set(&member.ExternalID, user.ExternalID)
+	if user.Name != nil {
+		set(&member.Name, user.Name.GivenName)
+		set(&member.Surname, user.Name.FamilyName)
+	}
+	set(&member.Email, scimPrimary(user.Emails))
+	set(&member.PlaintextPhone, scimPrimary(user.PhoneNumbers))
+	if user.Member != nil {
+		set(&member.NationalID, user.Member.NationalID)
+		set(&member.BirthDate, user.Member.BirthDate)
+		if user.Member.Weight > 0 {
+			member.Weight = user.Member.Weight
+		}
+	}
+}
+
+// scimLoadUser returns the member a SCIM User id names in the organization.
+func (a *API) scimLoadUser(s *scimScope, id string) (*db.OrgMember, *scimError) {
+	ids, err := a.db.FilterOrgMemberIDs(s.org.Address, []string{id})
+	if err != nil {
+		return nil, newSCIMError(http.StatusInternalServerError, "", "could not resolve user: %v", err)
+	}
+	if len(ids) == 0 {
+		return nil, newSCIMError(http.StatusNotFound, "", "user %s not found", id)
+	}
+	member, err := a.db.OrgMember(s.org.Address, id)
+	if err != nil {
+		return nil, newSCIMError(http.StatusInternalServerError, "", "could not load user: %v", err)
+	}
+	return member, nil
+}
+
+// scimCheckUserName refuses a userName another member already has: SCIM clients match their
+// users on it, so it has to stay unique.
+func (a *API) scimCheckUserName(s *scimScope, userName, id string) *scimError {
+	if userName == "" {
+		return nil
+	}
+	members, err := a.db.OrgMembersByField(s.org.Address, db.OrgMemberLookupFieldMemberNumber, userName)
+	if err != nil {
+		return newSCIMError(http.StatusInternalServerError, "", "could not look users up: %v", err)
+	}
+	for _, m := range members {
+		if m.ID.Hex() != id {
+			return newSCIMError(http.StatusConflict, "uniqueness", "userName %q is already taken", userName)
+		}
+	}
+	return nil
+}
+
+// scimCreateUser creates a member behind the same quota checks as the member upsert, and adds it
+// to the censuses of the organization's auto group.
+func (a *API) scimCreateUser(s *scimScope, user *apicommon.SCIMUser) (*apicommon.SCIMUser, *scimError) {
+	if user.UserName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "userName is required")
+	}
+	if serr := a.scimCheckUserName(s, user.UserName, ""); serr != nil {
+		return nil, serr
+	}
+	if err := a.subscriptions.OrgCanAddNMembers(s.org.Address, 1); err != nil {
+		return nil, scimErrorFrom(err)
+	}
+	autoCensuses, err := a.autoGroupCensuses(s.org.Address)
+	if err != nil {
+		return nil, scimErrorFrom(err)
+	}
+	if err := a.preflightCensusGrowth(s.org, autoCensuses, 1); err != nil {
+		return nil, scimErrorFrom(err)
+	}
+
+	member := &db.OrgMember{Weight: 1}
+	applySCIMUser(member, user)
+	memberID, _, err := a.db.UpsertOrgMemberAndCensusParticipants(s.org, member, passwordSalt, s.source)
+	if err != nil {
+		return nil, scimErrorFromDB(err, "user")
+	}
+	// a user created inactive is kept out of the censuses, as a deactivated one is
+	if user.Active != nil && !*user.Active {
+		if err := a.db.SetOrgMemberActive(s.org.Address, memberID.Hex(), false, s.source); err != nil {
+			return nil, scimErrorFromDB(err, "user")
+		}
+		return a.scimReadUser(s, memberID.Hex())
+	}
+	// the member exists either way: a census it could not join is logged, as SCIM has no room
+	// in its response to report it
+	propagated := a.propagateMembersToCensuses(s.org.Address, autoCensuses, []string{memberID.Hex()})
+	if len(propagated.Errors) > 0 {
+		log.Warnw("SCIM user not fully propagated to censuses", "member", memberID.Hex(), "errors", propagated.Errors)
+	}
+	return a.scimReadUser(s, memberID.Hex())
+}
+
+func (a *API) scimReadUser(s *scimScope, id string) (*apicommon.SCIMUser, *scimError) {
+	member, serr := a.scimLoadUser(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	return s.scimUser(member), nil
+}
+
+// scimSaveUser writes user onto the member it replaces or patches. A user made inactive is
+// deactivated rather than deleted: the member keeps its data and its censuses, and joins no new
+// one until it is made active again.
+func (a *API) scimSaveUser(s *scimScope, member *db.OrgMember, user *apicommon.SCIMUser,
+) (*apicommon.SCIMUser, *scimError) {
+	if serr := a.scimCheckUserName(s, user.UserName, member.ID.Hex()); serr != nil {
+		return nil, serr
+	}
+	applySCIMUser(member, user)
+	if _, _, err := a.db.UpsertOrgMemberAndCensusParticipants(s.org, member, passwordSalt, s.source); err != nil {
+		return nil, scimErrorFromDB(err, "user")
+	}
+	if user.Active != nil {
+		if err := a.db.SetOrgMemberActive(s.org.Address, member.ID.Hex(), *user.Active, s.source); err != nil {
+			return nil, scimErrorFromDB(err, "user")
+		}
+	}
+	return a.scimReadUser(s, member.ID.Hex())
+}
+
+func (a *API) scimReplaceUser(s *scimScope, id string, user *apicommon.SCIMUser) (*apicommon.SCIMUser, *scimError) {
+	member, serr := a.scimLoadUser(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	return a.scimSaveUser(s, member, user)
+}
+
+func (a *API) scimPatchUser(s *scimScope, id string, patch *apicommon.SCIMPatchRequest,
+) (*apicommon.SCIMUser, *scimError) {
+	member, serr := a.scimLoadUser(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	user := s.scimUser(member)
+	for _, op := range patch.Operations {
+		if serr := patchSCIMUser(user, op); serr != nil {
+			return nil, serr
+		}
+	}
+	return a.scimSaveUser(s, member, user)
+}
+
+// patchSCIMUser applies one PATCH operation to user. Removing an attribute is refused, for the
+// reason applySCIMUser keeps absent ones.
+func patchSCIMUser(user *apicommon.SCIMUser, op apicommon.SCIMPatchOperation) *scimError {
+	switch strings.ToLower(op.Op) {
+	case "add", "replace":
+	case "remove":
+		return newSCIMError(http.StatusBadRequest, "mutability", "user attributes can be replaced, not removed")
+	default:
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", "unknown PATCH op %q", op.Op)
+	}
+	if op.Path != "" {
+		return setSCIMUserAttribute(user, op.Path, op.Value)
+	}
+	// without a path, the value holds the attributes to set
+	attrs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(op.Value, &attrs); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "a PATCH without path needs an object value")
+	}
+	for path, value := range attrs {
+		if serr := setSCIMUserAttribute(user, path, value); serr != nil {
+			return serr
+		}
+	}
+	return nil
+}
+
+// setSCIMUserAttribute sets the attribute at path. Extension attributes are addressed with the
+// schema URN as prefix, as clients do.
+func setSCIMUserAttribute(user *apicommon.SCIMUser, path string, value json.RawMessage) *scimError {
+	attr := strings.ToLower(path)
+	if rest, ok := strings.CutPrefix(attr, strings.ToLower(apicommon.SCIMSchemaMember)); ok {
+		attr = "member." + strings.TrimPrefix(rest, ":")
+	}
+	if user.Name == nil && strings.HasPrefix(attr, "name") {
+		user.Name = &apicommon.SCIMName{}
+	}
+	if user.Member == nil && strings.HasPrefix(attr, "member.") {
+		user.Member = &apicommon.SCIMMemberExtension{}
+	}
+	var err error
+	switch {
+	case attr == "username":
+		err = json.Unmarshal(value, &user.UserName)
+	case attr == "externalid":
+		err = json.Unmarshal(value, &user.ExternalID)
+	case attr == "active":
+		var active bool
+		active, err = scimBool(value)
+		user.Active = &active
+	case attr == "name":
+		err = json.Unmarshal(value, user.Name)
+	case attr == "name.givenname":
+		err = json.Unmarshal(value, &user.Name.GivenName)
+	case attr == "name.familyname":
+		err = json.Unmarshal(value, &user.Name.FamilyName)
+	case strings.HasPrefix(attr, "emails"):
+		var email string
+		email, err = scimMultiValue(value)
+		user.Emails = []apicommon.SCIMMultiValue{{Value: email, Primary: true}}
+	case strings.HasPrefix(attr, "phonenumbers"):
+		var phone string
+		phone, err = scimMultiValue(value)
+		user.PhoneNumbers = []apicommon.SCIMMultiValue{{Value: phone, Primary: true}}
+	case attr == "member.":
+		err = json.Unmarshal(value, user.Member)
+	case attr == "member.nationalid":
+		err = json.Unmarshal(value, &user.Member.NationalID)
+	case attr == "member.birthdate":
+		err = json.Unmarshal(value, &user.Member.BirthDate)
+	case attr == "member.weight":
+		user.Member.Weight, err = scimUint(value)
+	default:
+		return newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported attribute %q", path)
+	}
+	if err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid value for %q: %v", path, err)
+	}
+	return nil
+}
+
+// scimBool decodes a boolean, which some clients send as a string ("False").
+func scimBool(value json.RawMessage) (bool, error) {
+	var b bool
+	if err := json.Unmarshal(value, &b); err == nil {
+		return b, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return false, err
+	}
+	return strconv.ParseBool(s)
+}
+
+// scimUint decodes an unsigned integer, which some clients send as a string.
+func scimUint(value json.RawMessage) (uint64, error) {
+	var n uint64
+	if err := json.Unmarshal(value, &n); err == nil {
+		return n, nil
+	}
+	var s string
+	if err := json.Unmarshal(value, &s); err != nil {
+		return 0, err
+	}
+	return strconv.ParseUint(s, 10, 64)
+}
+
+// scimMultiValue decodes the value set on a multi-valued attribute, or on one of its entries: a
+// list (its primary value is kept), a single entry, or a bare string.
+func scimMultiValue(value json.RawMessage) (string, error) {
+	var values []apicommon.SCIMMultiValue
+	if err := json.Unmarshal(value, &values); err == nil {
+		return scimPrimary(values), nil
+	}
+	var entry apicommon.SCIMMultiValue
+	if err := json.Unmarshal(value, &entry); err == nil {
+		return entry.Value, nil
+	}
+	var s string
+	err := json.Unmarshal(value, &s)
+	return s, err
+}
+
+// scimDeleteUser deletes a member behind the same census guards as the member delete: a member
+// the CSP has already signed for in an ongoing question cannot be removed.
+func (a *API) scimDeleteUser(s *scimScope, id string) *scimError {
+	ids, err := a.db.FilterOrgMemberIDs(s.org.Address, []string{id})
+	if err != nil {
+		return newSCIMError(http.StatusInternalServerError, "", "could not resolve user: %v", err)
+	}
+	if len(ids) == 0 {
+		return newSCIMError(http.StatusNotFound, "", "user %s not found", id)
+	}
+	censusIDs, err := a.db.CensusesForMembers(ids)
+	if err != nil {
+		return newSCIMError(http.StatusInternalServerError, "", "could not resolve user censuses: %v", err)
+	}
+	blocked, err := a.blockedVoters(censusIDs, ids)
+	if err != nil {
+		return scimErrorFrom(err)
+	}
+	if len(blocked) > 0 {
+		return scimErrorFrom(errors.ErrCensusMemberAlreadySignedFor.WithData(map[string]any{"signedMemberIds": blocked}))
+	}
+	_, emptied, err := a.db.DeleteOrgMembers(s.org.Address, ids, s.source)
+	if errors.Is(err, db.ErrCensusFrozen) {
+		return scimErrorFrom(errors.ErrCensusFrozen.WithErr(err))
+	}
+	if err != nil {
+		return newSCIMError(http.StatusInternalServerError, "", "could not delete user: %v", err)
+	}
+	if _, errs := a.resizeEmptiedQuestions(s.org.Address, emptied); len(errs) > 0 {
+		log.Warnw("SCIM user deletion could not resize censuses", "member", id, "errors", errs)
+	}
+	return nil
+}
+
+// scimGroup maps a member group onto a SCIM Group.
+func (s *scimScope) scimGroup(group *db.OrganizationMemberGroup) *apicommon.SCIMGroup {
+	id := group.ID.Hex()
+	members := make([]apicommon.SCIMMultiValue, 0, len(group.MemberIDs))
+	for _, memberID := range group.MemberIDs {
+		members = append(members, apicommon.SCIMMultiValue{
+			Value: memberID,
+			Ref:   s.location(scimResourceUsers, memberID),
+		})
+	}
+	return &apicommon.SCIMGroup{
+		Schemas:     []string{apicommon.SCIMSchemaGroup},
+		ID:          id,
+		DisplayName: group.Title,
+		Members:     members,
+		Meta:        s.meta(scimResourceGroups, id, group.CreatedAt, group.UpdatedAt),
+	}
+}
+
+// scimLoadGroup returns the member group a SCIM Group id names. The auto "All members" group
+// follows the memberbase by itself, so it is not exposed over SCIM.
+func (a *API) scimLoadGroup(s *scimScope, id string) (*db.OrganizationMemberGroup, *scimError) {
+	group, err := a.db.OrganizationMemberGroup(id, s.org.Address)
+	switch {
+	case errors.Is(err, db.ErrNotFound), errors.Is(err, db.ErrInvalidData):
+		return nil, newSCIMError(http.StatusNotFound, "", "group %s not found", id)
+	case err != nil:
+		return nil, newSCIMError(http.StatusInternalServerError, "", "could not load group: %v", err)
+	case group.IsAutoGroup:
+		return nil, newSCIMError(http.StatusNotFound, "", "group %s not found", id)
+	}
+	return group, nil
+}
+
+func (a *API) scimReadGroup(s *scimScope, id string) (*apicommon.SCIMGroup, *scimError) {
+	group, serr := a.scimLoadGroup(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	return s.scimGroup(group), nil
+}
+
+// scimGroupMembers returns the distinct member ids of a SCIM Group's members, checking each names
+// a member of the organization. A group cannot be empty.
+func (a *API) scimGroupMembers(s *scimScope, members []string) ([]string, *scimError) {
+	ids := make([]string, 0, len(members))
+	for _, id := range members {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "a group needs at least one member")
+	}
+	known, err := a.db.FilterOrgMemberIDs(s.org.Address, ids)
+	if err != nil {
+		return nil, newSCIMError(http.StatusInternalServerError, "", "could not resolve members: %v", err)
+	}
+	if len(known) != len(ids) {
+		unknown := slices.DeleteFunc(slices.Clone(ids), func(id string) bool { return slices.Contains(known, id) })
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "unknown members %v", unknown)
+	}
+	return ids, nil
+}
+
+func scimMemberValues(members []apicommon.SCIMMultiValue) []string {
+	values := make([]string, 0, len(members))
+	for _, m := range members {
+		values = append(values, m.Value)
+	}
+	return values
+}
+
+func (a *API) scimCreateGroup(s *scimScope, group *apicommon.SCIMGroup) (*apicommon.SCIMGroup, *scimError) {
+	if group.DisplayName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	memberIDs, serr := a.scimGroupMembers(s, scimMemberValues(group.Members))
+	if serr != nil {
+		return nil, serr
+	}
+	groupID, err := a.db.CreateOrganizationMemberGroup(&db.OrganizationMemberGroup{
+		Title:      group.DisplayName,
+		MemberIDs:  memberIDs,
+		OrgAddress: s.org.Address,
+	})
+	if err != nil {
+		return nil, scimErrorFromDB(err, "group")
+	}
+	return a.scimReadGroup(s, groupID)
+}
+
+// scimSetGroup sets the title and members of a group through updateMemberGroup, so the members
+// removed pass the same census guards as with the group endpoint.
+func (a *API) scimSetGroup(s *scimScope, group *db.OrganizationMemberGroup, title string, members []string,
+) (*apicommon.SCIMGroup, *scimError) {
+	memberIDs, serr := a.scimGroupMembers(s, members)
+	if serr != nil {
+		return nil, serr
+	}
+	var added, removed []string
+	for _, id := range memberIDs {
+		if !slices.Contains(group.MemberIDs, id) {
+			added = append(added, id)
+		}
+	}
+	for _, id := range group.MemberIDs {
+		if !slices.Contains(memberIDs, id) {
+			removed = append(removed, id)
+		}
+	}
+	groupID := group.ID.Hex()
+	resp, err := a.updateMemberGroup(s.org, groupID, title, group.Description, added, removed)
+	if err != nil {
+		return nil, scimErrorFrom(err)
+	}
+	if len(resp.Errors) > 0 {
+		log.Warnw("SCIM group update could not fully update censuses", "group", groupID, "errors", resp.Errors)
+	}
+	return a.scimReadGroup(s, groupID)
+}
+
+func (a *API) scimReplaceGroup(s *scimScope, id string, replacement *apicommon.SCIMGroup,
+) (*apicommon.SCIMGroup, *scimError) {
+	group, serr := a.scimLoadGroup(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	if replacement.DisplayName == "" {
+		return nil, newSCIMError(http.StatusBadRequest, "invalidValue", "displayName is required")
+	}
+	return a.scimSetGroup(s, group, replacement.DisplayName, scimMemberValues(replacement.Members))
+}
+
+func (a *API) scimPatchGroup(s *scimScope, id string, patch *apicommon.SCIMPatchRequest,
+) (*apicommon.SCIMGroup, *scimError) {
+	group, serr := a.scimLoadGroup(s, id)
+	if serr != nil {
+		return nil, serr
+	}
+	state := &scimGroupPatch{title: group.Title, members: slices.Clone(group.MemberIDs)}
+	for _, op := range patch.Operations {
+		if serr := state.apply(op); serr != nil {
+			return nil, serr
+		}
+	}
+	return a.scimSetGroup(s, group, state.title, state.members)
+}
+
+// scimGroupPatch is the title and members of a group as the operations of a PATCH leave them.
+type scimGroupPatch struct {
+	title   string
+	members []string
+}
+
+func (p *scimGroupPatch) apply(op apicommon.SCIMPatchOperation) *scimError {
+	opName := strings.ToLower(op.Op)
+	if opName != "add" && opName != "replace" && opName != "remove" {
+		return newSCIMError(http.StatusBadRequest, "invalidSyntax", "unknown PATCH op %q", op.Op)
+	}
+	if op.Path != "" {
+		return p.set(opName, op.Path, op.Value)
+	}
+	attrs := map[string]json.RawMessage{}
+	if err := json.Unmarshal(op.Value, &attrs); err != nil {
+		return newSCIMError(http.StatusBadRequest, "invalidValue", "a PATCH without path needs an object value")
+	}
+	for path, value := range attrs {
+		if serr := p.set(opName, path, value); serr != nil {
+			return serr
+		}
+	}
+	return nil
+}
+
+func (p *scimGroupPatch) set(op, path string, value json.RawMessage) *scimError {
+	attr := strings.ToLower(path)
+	switch {
+	case attr == "displayname":
+		if op == "remove" {
+			return newSCIMError(http.StatusBadRequest, "mutability", "displayName cannot be removed")
+		}
+		if err := json.Unmarshal(value, &p.title); err != nil {
+			return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid displayName: %v", err)
+		}
+	case attr == "members":
+		var members []apicommon.SCIMMultiValue
+		if len(value) > 0 {
+			if err := json.Unmarshal(value, &members); err != nil {
+				return newSCIMError(http.StatusBadRequest, "invalidValue", "invalid members: %v", err)
+			}
+		}
+		values := scimMemberValues(members)
+		switch op {
+		case "add":
+			p.members = append(p.members, values...)
+		case "replace":
+			p.members = values
+		case "remove":
+			// without a value, every member is removed
+			if len(value) == 0 {
+				p.members = nil
+			} else {
+				p.members = slices.DeleteFunc(p.members, func(id string) bool { return slices.Contains(values, id) })
+			}
+		}
+	case strings.HasPrefix(attr, "members[") && strings.HasSuffix(attr, "]") && op == "remove":
+		// members[value eq "<id>"]; ids are lowercase hex, so the lowercased path is safe to read
+		conditions, serr := parseSCIMFilter(strings.TrimSuffix(strings.TrimPrefix(attr, "members["), "]"))
+		if serr != nil {
+			return serr
+		}
+		if len(conditions) != 1 || conditions[0].attr != "value" {
+			return newSCIMError(http.StatusBadRequest, "invalidFilter", "members can only be selected by value")
+		}
+		p.members = slices.DeleteFunc(p.members, func(id string) bool { return id == conditions[0].value })
+	default:
+		return newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported attribute %q", path)
+	}
+	return nil
+}
+
+// scimDeleteGroup deletes a group behind the same census guards as the group delete.
+func (a *API) scimDeleteGroup(s *scimScope, id string) *scimError {
+	group, serr := a.scimLoadGroup(s, id)
+	if serr != nil {
+		return serr
+	}
+	blocked, err := a.blockedVoters(group.CensusIDs, group.MemberIDs)
+	if err != nil {
+		return scimErrorFrom(err)
+	}
+	if len(blocked) > 0 {
+		return scimErrorFrom(errors.ErrCensusMemberAlreadySignedFor.WithData(map[string]any{"signedMemberIds": blocked}))
+	}
+	emptied, err := a.db.DeleteOrganizationMemberGroup(id, s.org.Address)
+	if errors.Is(err, db.ErrCensusFrozen) {
+		return scimErrorFrom(errors.ErrCensusFrozen.WithErr(err))
+	}
+	if err != nil {
+		return newSCIMError(http.StatusInternalServerError, "", "could not delete group: %v", err)
+	}
+	if _, errs := a.resizeEmptiedQuestions(s.org.Address, emptied); len(errs) > 0 {
+		log.Warnw("SCIM group deletion could not resize censuses", "group", id, "errors", errs)
+	}
+	return nil
+}
+
+// scimWrite runs one SCIM write, for the endpoints and for bulk alike. It returns the status to
+// answer, the id of the resource written and the resource itself, nil after a deletion.
+func (a *API) scimWrite(s *scimScope, method, resource, id string, data []byte) (int, string, any, *scimError) {
+	unsupported := newSCIMError(http.StatusBadRequest, "invalidPath", "unsupported operation %s %s", method, resource)
+	switch resource {
+	case scimResourceUsers:
+		var user *apicommon.SCIMUser
+		var serr *scimError
+		status := http.StatusOK
+		switch {
+		case method == http.MethodPost && id == "":
+			user = &apicommon.SCIMUser{}
+			if serr = decodeSCIM(data, user); serr == nil {
+				user, serr = a.scimCreateUser(s, user)
+				status = http.StatusCreated
+			}
+		case id == "":
+			return 0, "", nil, unsupported
+		case method == http.MethodPut:
+			user = &apicommon.SCIMUser{}
+			if serr = decodeSCIM(data, user); serr == nil {
+				user, serr = a.scimReplaceUser(s, id, user)
+			}
+		case method == http.MethodPatch:
+			patch := &apicommon.SCIMPatchRequest{}
+			if serr = decodeSCIM(data, patch); serr == nil {
+				user, serr = a.scimPatchUser(s, id, patch)
+			}
+		case method == http.MethodDelete:
+			serr = a.scimDeleteUser(s, id)
+		default:
+			return 0, "", nil, unsupported
+		}
+		switch {
+		case serr != nil:
+			return 0, "", nil, serr
+		case user == nil:
+			return http.StatusNoContent, id, nil, nil
+		default:
+			return status, user.ID, user, nil
+		}
+	case scimResourceGroups:
+		var group *apicommon.SCIMGroup
+		var serr *scimError
+		status := http.StatusOK
+		switch {
+		case method == http.MethodPost && id == "":
+			group = &apicommon.SCIMGroup{}
+			if serr = decodeSCIM(data, group); serr == nil {
+				group, serr = a.scimCreateGroup(s, group)
+				status = http.StatusCreated
+			}
+		case id == "":
+			return 0, "", nil, unsupported
+		case method == http.MethodPut:
+			group = &apicommon.SCIMGroup{}
+			if serr = decodeSCIM(data, group); serr == nil {
+				group, serr = a.scimReplaceGroup(s, id, group)
+			}
+		case method == http.MethodPatch:
+			patch := &apicommon.SCIMPatchRequest{}
+			if serr = decodeSCIM(data, patch); serr == nil {
+				group, serr = a.scimPatchGroup(s, id, patch)
+			}
+		case method == http.MethodDelete:
+			serr = a.scimDeleteGroup(s, id)
+		default:
+			return 0, "", nil, unsupported
+		}
+		switch {
+		case serr != nil:
+			return 0, "", nil, serr
+		case group == nil:
+			return http.StatusNoContent, id, nil, nil
+		default:
+			return status, group.ID, group, nil
+		}
+	default:
+		return 0, "", nil, unsupported
+	}
+}
+
+// serveSCIMWrite answers a SCIM write request to a resource with scimWrite.
+func (a *API) serveSCIMWrite(w http.ResponseWriter, r *http.Request, resource string) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, scimMaxPayloadSize))
+	if err != nil {
+		writeSCIMError(w, newSCIMError(http.StatusRequestEntityTooLarge, "", "could not read body: %v", err))
+		return
+	}
+	status, id, body, serr := a.scimWrite(s, r.Method, resource, chi.URLParam(r, "scimId"), data)
+	if serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	if status == http.StatusCreated {
+		w.Header().Set("Location", s.location(resource, id))
+	}
+	writeSCIM(w, status, body)
+}
+
+// scimServiceProviderConfigHandler godoc
+//
+//	@Summary		Describe the SCIM 2.0 server of an organization
+//	@Description	Return the SCIM ServiceProviderConfig: PATCH, bulk and filter are supported; sort,
+//	@Description	ETags and password changes are not. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Success		200			{object}	apicommon.SCIMServiceProviderConfig
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Router			/organizations/{orgAddress}/scim/v2/ServiceProviderConfig [get]
+func (a *API) scimServiceProviderConfigHandler(w http.ResponseWriter, r *http.Request) {
+	if _, ok := a.scimScopeFromRequest(w, r); !ok {
+		return
+	}
+	writeSCIM(w, http.StatusOK, &apicommon.SCIMServiceProviderConfig{
+		Schemas: []string{apicommon.SCIMSchemaServiceProvider},
+		Patch:   apicommon.SCIMSupported{Supported: true},
+		Bulk: apicommon.SCIMBulkSupport{
+			Supported:      true,
+			MaxOperations:  scimMaxOperations,
+			MaxPayloadSize: scimMaxPayloadSize,
+		},
+		Filter: apicommon.SCIMFilterSupport{Supported: true, MaxResults: scimMaxResults},
+		AuthenticationSchemes: []apicommon.SCIMAuthenticationScheme{{
+			Type:        "oauthbearertoken",
+			Name:        "API key",
+			Description: "An API key with the " + ScopeMembersSCIM + " scope, sent as a Bearer token",
+		}},
+	})
+}
+
+// scimUsersHandler godoc
+//
+//	@Summary		List SCIM users
+//	@Description	List the members of an organization as SCIM Users. `filter` supports `eq` terms on
+//	@Description	userName (the member number), externalId, emails.value, name.givenName and
+//	@Description	name.familyName, joined with `and`. Pages are 1-based (`startIndex`), up to 1000 users
+//	@Description	(`count`). Phones are stored hashed and are never returned. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			filter		query		string	false	"SCIM filter, e.g. userName eq \"P001\""
+//	@Param			startIndex	query		integer	false	"1-based index of the first user (default: 1)"
+//	@Param			count		query		integer	false	"Number of users (default and max: 1000)"
+//	@Success		200			{object}	apicommon.SCIMListResponse
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid filter"
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users [get]
+func (a *API) scimUsersHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	startIndex, count, serr := scimPage(r)
+	if serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	var total int64
+	var members []*db.OrgMember
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		conditions, serr := parseSCIMFilter(filter)
+		if serr != nil {
+			writeSCIMError(w, serr)
+			return
+		}
+		matched, serr := a.scimFindUsers(s, conditions)
+		if serr != nil {
+			writeSCIMError(w, serr)
+			return
+		}
+		total, members = int64(len(matched)), scimPageOf(matched, startIndex, count)
+	} else {
+		var err error
+		total, members, err = a.db.OrgMembersFrom(s.org.Address, startIndex-1, count)
+		if err != nil {
+			writeSCIMError(w, newSCIMError(http.StatusInternalServerError, "", "could not list users: %v", err))
+			return
+		}
+	}
+	resources := make([]any, 0, len(members))
+	for _, m := range members {
+		resources = append(resources, s.scimUser(m))
+	}
+	writeSCIM(w, http.StatusOK, scimListResponse(total, startIndex, resources))
+}
+
+// scimUserHandler godoc
+//
+//	@Summary		Get a SCIM user
+//	@Description	Get a member of an organization as a SCIM User. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			scimId		path		string	true	"Member ID"
+//	@Success		200			{object}	apicommon.SCIMUser
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Failure		404			{object}	apicommon.SCIMError	"User not found"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users/{scimId} [get]
+func (a *API) scimUserHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	user, serr := a.scimReadUser(s, chi.URLParam(r, "scimId"))
+	if serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	writeSCIM(w, http.StatusOK, user)
+}
+
+// scimCreateUserHandler godoc
+//
+//	@Summary		Create a SCIM user
+//	@Description	Create an organization member from a SCIM User. userName is required and unique: it
+//	@Description	is the member number. externalId, name, the primary email and phone, and the member
+//	@Description	extension (nationalId, birthDate, weight; weight defaults to 1) are stored too. The
+//	@Description	member joins the censuses of the auto "All members" group, behind the same plan limits
+//	@Description	as any new member. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string				true	"Organization address"
+//	@Param			user		body		apicommon.SCIMUser	true	"User to create"
+//	@Success		201			{object}	apicommon.SCIMUser
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid user"
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization, or plan limit reached"
+//	@Failure		409			{object}	apicommon.SCIMError	"userName already taken"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users [post]
+func (a *API) scimCreateUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceUsers)
+}
+
+// scimReplaceUserHandler godoc
+//
+//	@Summary		Replace a SCIM user
+//	@Description	Replace the attributes of a member with those of a SCIM User. Attributes left out or
+//	@Description	empty keep their value: a member's login fields cannot be cleared. Setting `active` to
+//	@Description	false deactivates the member: it keeps its data and censuses, and joins no new census.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string				true	"Organization address"
+//	@Param			scimId		path		string				true	"Member ID"
+//	@Param			user		body		apicommon.SCIMUser	true	"User attributes"
+//	@Success		200			{object}	apicommon.SCIMUser
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid user"
+//	@Failure		404			{object}	apicommon.SCIMError	"User not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"userName taken"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users/{scimId} [put]
+func (a *API) scimReplaceUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceUsers)
+}
+
+// scimPatchUserHandler godoc
+//
+//	@Summary		Patch a SCIM user
+//	@Description	Apply add and replace operations to a member. Paths are userName, externalId, active,
+//	@Description	name(.givenName|.familyName), emails, phoneNumbers and the member extension attributes,
+//	@Description	prefixed with its URN; without a path, the value holds the attributes to set. remove
+//	@Description	is refused. Setting `active` to false deactivates the member, as the PUT does.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string						true	"Organization address"
+//	@Param			scimId		path		string						true	"Member ID"
+//	@Param			patch		body		apicommon.SCIMPatchRequest	true	"PATCH operations"
+//	@Success		200			{object}	apicommon.SCIMUser
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid operation"
+//	@Failure		404			{object}	apicommon.SCIMError	"User not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"userName taken"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users/{scimId} [patch]
+func (a *API) scimPatchUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceUsers)
+}
+
+// scimDeleteUserHandler godoc
+//
+//	@Summary		Delete a SCIM user
+//	@Description	Delete a member, with the guards of the member delete: a member the CSP has already
+//	@Description	signed for in a READY or PAUSED question is refused with 409, and questions left with
+//	@Description	no eligible voter are resized. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Security		BearerAuth
+//	@Param			orgAddress	path	string	true	"Organization address"
+//	@Param			scimId		path	string	true	"Member ID"
+//	@Success		204			"Deleted"
+//	@Failure		404			{object}	apicommon.SCIMError	"User not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"Member signed for in an ongoing process"
+//	@Router			/organizations/{orgAddress}/scim/v2/Users/{scimId} [delete]
+func (a *API) scimDeleteUserHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceUsers)
+}
+
+// scimGroupsHandler godoc
+//
+//	@Summary		List SCIM groups
+//	@Description	List the member groups of an organization as SCIM Groups. The auto "All members"
+//	@Description	group is not listed. `filter` supports `displayName eq "<title>"`. Requires
+//	@Description	Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			filter		query		string	false	"SCIM filter, e.g. displayName eq \"Board\""
+//	@Param			startIndex	query		integer	false	"1-based index of the first group (default: 1)"
+//	@Param			count		query		integer	false	"Number of groups (default and max: 1000)"
+//	@Success		200			{object}	apicommon.SCIMListResponse
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid filter"
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups [get]
+func (a *API) scimGroupsHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	startIndex, count, serr := scimPage(r)
+	if serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	var conditions []scimCondition
+	if filter := r.URL.Query().Get("filter"); filter != "" {
+		if conditions, serr = parseSCIMFilter(filter); serr != nil {
+			writeSCIMError(w, serr)
+			return
+		}
+		for _, c := range conditions {
+			if c.attr != "displayname" {
+				writeSCIMError(w, newSCIMError(http.StatusBadRequest, "invalidFilter", "cannot filter groups by %q", c.attr))
+				return
+			}
+		}
+	}
+	// groups are few per organization, so they are all read and filtered here
+	_, groups, err := a.db.OrganizationMemberGroups(s.org.Address, 1, 0)
+	if err != nil {
+		writeSCIMError(w, newSCIMError(http.StatusInternalServerError, "", "could not list groups: %v", err))
+		return
+	}
+	groups = slices.DeleteFunc(groups, func(g *db.OrganizationMemberGroup) bool {
+		if g.IsAutoGroup {
+			return true
+		}
+		for _, c := range conditions {
+			if !strings.EqualFold(g.Title, c.value) {
+				return true
+			}
+		}
+		return false
+	})
+	resources := make([]any, 0, count)
+	for _, g := range scimPageOf(groups, startIndex, count) {
+		resources = append(resources, s.scimGroup(g))
+	}
+	writeSCIM(w, http.StatusOK, scimListResponse(int64(len(groups)), startIndex, resources))
+}
+
+// scimGroupHandler godoc
+//
+//	@Summary		Get a SCIM group
+//	@Description	Get a member group of an organization as a SCIM Group. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			scimId		path		string	true	"Group ID"
+//	@Success		200			{object}	apicommon.SCIMGroup
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Failure		404			{object}	apicommon.SCIMError	"Group not found"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups/{scimId} [get]
+func (a *API) scimGroupHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	group, serr := a.scimReadGroup(s, chi.URLParam(r, "scimId"))
+	if serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	writeSCIM(w, http.StatusOK, group)
+}
+
+// scimCreateGroupHandler godoc
+//
+//	@Summary		Create a SCIM group
+//	@Description	Create a member group from a SCIM Group: displayName is its title, members the ids of
+//	@Description	its members, at least one. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string				true	"Organization address"
+//	@Param			group		body		apicommon.SCIMGroup	true	"Group to create"
+//	@Success		201			{object}	apicommon.SCIMGroup
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid group, or unknown members"
+//	@Failure		401			{object}	apicommon.SCIMError	"Unauthorized"
+//	@Failure		403			{object}	apicommon.SCIMError	"Not a manager of the organization"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups [post]
+func (a *API) scimCreateGroupHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceGroups)
+}
+
+// scimReplaceGroupHandler godoc
+//
+//	@Summary		Replace a SCIM group
+//	@Description	Set the title and members of a member group. Members joining it join its censuses,
+//	@Description	behind the plan limits; members leaving it are refused with 409 if the CSP has already
+//	@Description	signed for them in a READY or PAUSED question of its censuses. Requires Manager/Admin
+//	@Description	role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string				true	"Organization address"
+//	@Param			scimId		path		string				true	"Group ID"
+//	@Param			group		body		apicommon.SCIMGroup	true	"Group attributes"
+//	@Success		200			{object}	apicommon.SCIMGroup
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid group, or unknown members"
+//	@Failure		404			{object}	apicommon.SCIMError	"Group not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"A removed member was signed for in an ongoing process"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups/{scimId} [put]
+func (a *API) scimReplaceGroupHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceGroups)
+}
+
+// scimPatchGroupHandler godoc
+//
+//	@Summary		Patch a SCIM group
+//	@Description	Apply add, replace and remove operations to a member group's displayName and members,
+//	@Description	including `members[value eq "<id>"]` removals. The resulting membership change has
+//	@Description	the guards of the group PUT. A group cannot be left empty. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string						true	"Organization address"
+//	@Param			scimId		path		string						true	"Group ID"
+//	@Param			patch		body		apicommon.SCIMPatchRequest	true	"PATCH operations"
+//	@Success		200			{object}	apicommon.SCIMGroup
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid operation, or unknown members"
+//	@Failure		404			{object}	apicommon.SCIMError	"Group not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"A removed member was signed for in an ongoing process"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups/{scimId} [patch]
+func (a *API) scimPatchGroupHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceGroups)
+}
+
+// scimDeleteGroupHandler godoc
+//
+//	@Summary		Delete a SCIM group
+//	@Description	Delete a member group, with the guards of the group delete: refused with 409 if the
+//	@Description	CSP has already signed for any of its members in a READY or PAUSED question of its
+//	@Description	censuses. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Security		BearerAuth
+//	@Param			orgAddress	path	string	true	"Organization address"
+//	@Param			scimId		path	string	true	"Group ID"
+//	@Success		204			"Deleted"
+//	@Failure		404			{object}	apicommon.SCIMError	"Group not found"
+//	@Failure		409			{object}	apicommon.SCIMError	"A member was signed for in an ongoing process"
+//	@Router			/organizations/{orgAddress}/scim/v2/Groups/{scimId} [delete]
+func (a *API) scimDeleteGroupHandler(w http.ResponseWriter, r *http.Request) {
+	a.serveSCIMWrite(w, r, scimResourceGroups)
+}
+
+// scimBulkHandler godoc
+//
+//	@Summary		Run SCIM bulk operations
+//	@Description	Run up to 100 SCIM writes (POST, PUT, PATCH, DELETE on Users and Groups) in order, each
+//	@Description	with the behaviour of its own endpoint. A POST needs a bulkId, and later operations can
+//	@Description	name the resource it created as `bulkId:<bulkId>`, in their path or data. Processing
+//	@Description	stops after `failOnErrors` failures; each operation reports its own status. Requires
+//	@Description	Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:scim`).
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string						true	"Organization address"
+//	@Param			bulk		body		apicommon.SCIMBulkRequest	true	"Bulk operations"
+//	@Success		200			{object}	apicommon.SCIMBulkResponse
+//	@Failure		400			{object}	apicommon.SCIMError	"Invalid body"
+//	@Failure		413			{object}	apicommon.SCIMError	"Too many operations, or body too large"
+//	@Router			/organizations/{orgAddress}/scim/v2/Bulk [post]
+func (a *API) scimBulkHandler(w http.ResponseWriter, r *http.Request) {
+	s, ok := a.scimScopeFromRequest(w, r)
+	if !ok {
+		return
+	}
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, scimMaxPayloadSize))
+	if err != nil {
+		writeSCIMError(w, newSCIMError(http.StatusRequestEntityTooLarge, "", "could not read body: %v", err))
+		return
+	}
+	bulk := &apicommon.SCIMBulkRequest{}
+	if serr := decodeSCIM(data, bulk); serr != nil {
+		writeSCIMError(w, serr)
+		return
+	}
+	if len(bulk.Operations) > scimMaxOperations {
+		writeSCIMError(w, newSCIMError(http.StatusRequestEntityTooLarge, "",
+			"%d operations exceed the maximum of %d", len(bulk.Operations), scimMaxOperations))
+		return
+	}
+
+	resp := &apicommon.SCIMBulkResponse{
+		Schemas:    []string{apicommon.SCIMMessageBulkResponse},
+		Operations: make([]apicommon.SCIMBulkOperationResult, 0, len(bulk.Operations)),
+	}
+	bulkIDs := map[string]string{}
+	failed := 0
+	for _, op := range bulk.Operations {
+		if bulk.FailOnErrors > 0 && failed >= bulk.FailOnErrors {
+			break
+		}
+		result := a.scimBulkOperation(s, op, bulkIDs)
+		if result.Response != nil {
+			failed++
+		}
+		resp.Operations = append(resp.Operations, result)
+	}
+	writeSCIM(w, http.StatusOK, resp)
+}
+
+// scimBulkOperation runs one bulk operation, resolving the bulkId references it makes to the
+// resources earlier operations created, and records the id of the resource a POST creates.
+func (a *API) scimBulkOperation(s *scimScope, op apicommon.SCIMBulkOperation, bulkIDs map[string]string,
+) apicommon.SCIMBulkOperationResult {
+	method := strings.ToUpper(op.Method)
+	result := apicommon.SCIMBulkOperationResult{Method: method, BulkID: op.BulkID}
+	fail := func(serr *scimError) apicommon.SCIMBulkOperationResult {
+		result.Status = strconv.Itoa(serr.status)
+		result.Response = serr.response()
+		return result
+	}
+	if method == http.MethodPost && op.BulkID == "" {
+		return fail(newSCIMError(http.StatusBadRequest, "invalidValue", "a bulk POST needs a bulkId"))
+	}
+	path, data := op.Path, string(op.Data)
+	for bulkID, id := range bulkIDs {
+		path = strings.ReplaceAll(path, "bulkId:"+bulkID, id)
+		data = strings.ReplaceAll(data, `"bulkId:`+bulkID+`"`, strconv.Quote(id))
+	}
+	if strings.Contains(path, "bulkId:") || strings.Contains(data, `"bulkId:`) {
+		return fail(newSCIMError(http.StatusConflict, "invalidValue", "reference to an unknown bulkId"))
+	}
+
+	resource, id, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	status, written, _, serr := a.scimWrite(s, method, resource, id, []byte(data))
+	if serr != nil {
+		return fail(serr)
+	}
+	result.Status = strconv.Itoa(status)
+	result.Location = s.location(resource, written)
+	if method == http.MethodPost {
+		bulkIDs[op.BulkID] = written
+	}
+	return result
+}
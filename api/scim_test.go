@@ -0,0 +1,156 @@
+package api
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+)
+
+func scimURL(orgAddress string, resource ...string) string {
+	return strings.Join(append([]string{"/organizations", orgAddress, "scim", "v2"}, resource...), "/")
+}
+
+func TestParseSCIMFilter(t *testing.T) {
+	c := qt.New(t)
+	conditions, serr := parseSCIMFilter(`userName eq "P 001" and Emails.Value EQ "a\"b@example.com"`)
+	c.Assert(serr, qt.IsNil)
+	c.Assert(conditions, qt.DeepEquals, []scimCondition{
+		{attr: "username", value: "P 001"},
+		{attr: "emails.value", value: `a"b@example.com`},
+	})
+	conditions, serr = parseSCIMFilter("active eq true")
+	c.Assert(serr, qt.IsNil)
+	c.Assert(conditions, qt.DeepEquals, []scimCondition{{attr: "active", value: "true"}})
+
+	for _, filter := range []string{
+		`userName co "P"`,
+		`userName eq "P" or externalId eq "x"`,
+		`userName eq "P`,
+		`userName eq`,
+	} {
+		_, serr := parseSCIMFilter(filter)
+		c.Assert(serr, qt.Not(qt.IsNil), qt.Commentf("filter %s", filter))
+		c.Assert(serr.scimType, qt.Equals, "invalidFilter")
+	}
+}
+
+func TestSCIM(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	org := orgAddress.String()
+
+	config := requestAndParse[apicommon.SCIMServiceProviderConfig](t, http.MethodGet, token, nil,
+		scimURL(org, "ServiceProviderConfig"))
+	c.Assert(config.Patch.Supported, qt.IsTrue)
+	c.Assert(config.Bulk.MaxOperations, qt.Equals, scimMaxOperations)
+
+	// users: create, refuse a second user with the same userName, read and filter
+	user := requestAndParseWithAssertCode[apicommon.SCIMUser](http.StatusCreated, t, http.MethodPost, token,
+		&apicommon.SCIMUser{
+			Schemas:    []string{apicommon.SCIMSchemaUser},
+			UserName:   "P001",
+			ExternalID: "hr-1",
+			Name:       &apicommon.SCIMName{GivenName: "Ana", FamilyName: "Gil"},
+			Emails:     []apicommon.SCIMMultiValue{{Value: "other@example.com"}, {Value: "Ana@Example.com", Primary: true}},
+		}, scimURL(org, "Users"))
+	c.Assert(user.ID, qt.Not(qt.Equals), "")
+	c.Assert(user.Emails, qt.DeepEquals, []apicommon.SCIMMultiValue{{Value: "ana@example.com", Primary: true}})
+	c.Assert(user.Member.Weight, qt.Equals, uint64(1))
+	c.Assert(*user.Active, qt.IsTrue)
+	conflict := requestAndParseWithAssertCode[apicommon.SCIMError](http.StatusConflict, t, http.MethodPost, token,
+		&apicommon.SCIMUser{UserName: "P001"}, scimURL(org, "Users"))
+	c.Assert(conflict.ScimType, qt.Equals, "uniqueness")
+
+	member := getOrgMember(t, token, orgAddress, user.ID)
+	c.Assert(member.MemberNumber, qt.Equals, "P001")
+	c.Assert(member.Name, qt.Equals, "Ana")
+
+	filter := url.QueryEscape(`externalId eq "hr-1" and userName eq "P001"`)
+	list := requestAndParse[apicommon.SCIMListResponse](t, http.MethodGet, token, nil,
+		scimURL(org, "Users?filter="+filter))
+	c.Assert(list.TotalResults, qt.Equals, int64(1))
+	filter = url.QueryEscape(`externalId eq "hr-2"`)
+	list = requestAndParse[apicommon.SCIMListResponse](t, http.MethodGet, token, nil,
+		scimURL(org, "Users?filter="+filter))
+	c.Assert(list.TotalResults, qt.Equals, int64(0))
+	requestAndAssertCode(http.StatusBadRequest, t, http.MethodGet, token, nil,
+		scimURL(org, "Users?filter="+url.QueryEscape(`nickName eq "x"`)))
+
+	// PATCH replaces attributes, keeps the others, and takes the extension by its URN
+	patched := requestAndParse[apicommon.SCIMUser](t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Schemas: []string{apicommon.SCIMMessagePatchOp},
+		Operations: []apicommon.SCIMPatchOperation{
+			{Op: "Replace", Path: "name.givenName", Value: []byte(`"Anna"`)},
+			{Op: "replace", Value: []byte(`{"` + apicommon.SCIMSchemaMember + `:weight": "3"}`)},
+		},
+	}, scimURL(org, "Users", user.ID))
+	c.Assert(patched.Name, qt.DeepEquals, &apicommon.SCIMName{GivenName: "Anna", FamilyName: "Gil"})
+	c.Assert(patched.Member.Weight, qt.Equals, uint64(3))
+	c.Assert(patched.UserName, qt.Equals, "P001")
+	requestAndAssertCode(http.StatusBadRequest, t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Operations: []apicommon.SCIMPatchOperation{{Op: "remove", Path: "emails"}},
+	}, scimURL(org, "Users", user.ID))
+
+	// bulk: a user, and a group naming it by its bulkId
+	bulk := requestAndParse[apicommon.SCIMBulkResponse](t, http.MethodPost, token, &apicommon.SCIMBulkRequest{
+		Schemas: []string{apicommon.SCIMMessageBulkRequest},
+		Operations: []apicommon.SCIMBulkOperation{
+			{Method: "POST", BulkID: "u2", Path: "/Users", Data: []byte(`{"userName": "P002"}`)},
+			{Method: "POST", BulkID: "g1", Path: "/Groups", Data: []byte(
+				`{"displayName": "Board", "members": [{"value": "` + user.ID + `"}, {"value": "bulkId:u2"}]}`)},
+			{Method: "DELETE", Path: "/Users/000000000000000000000000"},
+		},
+	}, scimURL(org, "Bulk"))
+	c.Assert(bulk.Operations, qt.HasLen, 3)
+	c.Assert(bulk.Operations[0].Status, qt.Equals, "201")
+	c.Assert(bulk.Operations[1].Status, qt.Equals, "201")
+	c.Assert(bulk.Operations[2].Status, qt.Equals, "404")
+	c.Assert(bulk.Operations[2].Response, qt.Not(qt.IsNil))
+	groupLocation := bulk.Operations[1].Location
+	groupID := groupLocation[strings.LastIndex(groupLocation, "/")+1:]
+	secondID := bulk.Operations[0].Location[strings.LastIndex(bulk.Operations[0].Location, "/")+1:]
+
+	group := requestAndParse[apicommon.SCIMGroup](t, http.MethodGet, token, nil, scimURL(org, "Groups", groupID))
+	c.Assert(group.DisplayName, qt.Equals, "Board")
+	c.Assert(group.Members, qt.HasLen, 2)
+
+	// the auto "All members" group is not exposed
+	groups := requestAndParse[apicommon.SCIMListResponse](t, http.MethodGet, token, nil, scimURL(org, "Groups"))
+	c.Assert(groups.TotalResults, qt.Equals, int64(1))
+
+	// group PATCH: a filtered removal, and a group cannot be emptied
+	group = requestAndParse[apicommon.SCIMGroup](t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Operations: []apicommon.SCIMPatchOperation{{Op: "remove", Path: `members[value eq "` + secondID + `"]`}},
+	}, scimURL(org, "Groups", groupID))
+	c.Assert(group.Members, qt.HasLen, 1)
+	c.Assert(group.Members[0].Value, qt.Equals, user.ID)
+	requestAndAssertCode(http.StatusBadRequest, t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Operations: []apicommon.SCIMPatchOperation{{Op: "remove", Path: "members"}},
+	}, scimURL(org, "Groups", groupID))
+
+	// deactivating a user keeps the member, inactive, until it is activated again
+	deactivated := requestAndParse[apicommon.SCIMUser](t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Operations: []apicommon.SCIMPatchOperation{{Op: "replace", Path: "active", Value: []byte(`"False"`)}},
+	}, scimURL(org, "Users", secondID))
+	c.Assert(*deactivated.Active, qt.IsFalse)
+	deactivated = requestAndParse[apicommon.SCIMUser](t, http.MethodGet, token, nil, scimURL(org, "Users", secondID))
+	c.Assert(*deactivated.Active, qt.IsFalse)
+	c.Assert(deactivated.UserName, qt.Equals, "P002")
+	activated := requestAndParse[apicommon.SCIMUser](t, http.MethodPatch, token, &apicommon.SCIMPatchRequest{
+		Operations: []apicommon.SCIMPatchOperation{{Op: "replace", Path: "active", Value: []byte(`true`)}},
+	}, scimURL(org, "Users", secondID))
+	c.Assert(*activated.Active, qt.IsTrue)
+
+	requestAndAssertCode(http.StatusNoContent, t, http.MethodDelete, token, nil, scimURL(org, "Groups", groupID))
+	requestAndAssertCode(http.StatusNoContent, t, http.MethodDelete, token, nil, scimURL(org, "Users", user.ID))
+	requestAndAssertCode(http.StatusNotFound, t, http.MethodDelete, token, nil, scimURL(org, "Users", user.ID))
+
+	// only the managers of the organization can provision it
+	otherToken := testCreateUser(t, "otherpassword123")
+	requestAndAssertCode(http.StatusForbidden, t, http.MethodGet, otherToken, nil, scimURL(org, "Users"))
+}
@@ -3,6 +3,7 @@ package api
 import (
 	"context"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
@@ -129,8 +130,9 @@ func TestStatusSync(t *testing.T) {
 
 	// maxAttempts == 1 (interval == confirmTimeout) makes ProcessPending deterministic: a confirm
 	// resolves in a single pass instead of rescheduling.
+	notified := &notifyRecorder{}
 	syncer := statussync.New(context.Background(), &statussync.Config{
-		DB: testDB, Account: testAPI.account, Interval: time.Second, ConfirmTimeout: time.Second,
+		DB: testDB, Account: testAPI.account, Interval: time.Second, ConfirmTimeout: time.Second, Notifier: notified,
 	})
 
 	// --- read-triggered reconcile: stored READY converges to the chain status ---
@@ -147,6 +149,8 @@ func TestStatusSync(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(qPaused.Status, qt.Equals, db.QuestionStatusPaused)
 	c.Assert(qPaused.SyncedAt.IsZero(), qt.IsFalse)
+	// both changes are pushed to the live streams of the process
+	c.Assert(notified.take(), qt.DeepEquals, []string{vpID.Hex(), vpID.Hex()})
 
 	// --- confirm success: chain already at the target only refreshes syncedAt, keeps the status ---
 	before := qPaused.SyncedAt
@@ -157,6 +161,7 @@ func TestStatusSync(t *testing.T) {
 	c.Assert(err, qt.IsNil)
 	c.Assert(qPaused.Status, qt.Equals, db.QuestionStatusPaused)
 	c.Assert(qPaused.SyncedAt.After(before), qt.IsTrue)
+	c.Assert(notified.take(), qt.HasLen, 0)
 
 	// --- confirm give-up: an optimistic target that never lands is reconciled back to the chain ---
 	c.Assert(testDB.SetQuestionStatus(qPausedID, db.QuestionStatusCanceled), qt.IsNil) // optimistic (wrong) write
@@ -165,4 +170,26 @@ func TestStatusSync(t *testing.T) {
 	qPaused, err = testDB.Question(qPausedID)
 	c.Assert(err, qt.IsNil)
 	c.Assert(qPaused.Status, qt.Equals, db.QuestionStatusPaused)
+	c.Assert(notified.take(), qt.DeepEquals, []string{vpID.Hex()})
+}
+
+// notifyRecorder is a statussync.Notifier recording the processes it is told about.
+type notifyRecorder struct {
+	mu        sync.Mutex
+	processes []string
+}
+
+func (n *notifyRecorder) Notify(processID string) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.processes = append(n.processes, processID)
+}
+
+// take returns the processes recorded since the last call.
+func (n *notifyRecorder) take() []string {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	processes := n.processes
+	n.processes = nil
+	return processes
 }
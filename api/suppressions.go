@@ -0,0 +1,401 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// unsubscribeToken is the payload of a signed unsubscribe link: an address of an organization on
+// a channel, in the stored form of db.SuppressionTarget.Key, so a phone number never shows in a
+// link in clear.
+type unsubscribeToken struct {
+	OrgAddress common.Address    `json:"o"`
+	Channel    db.MessageChannel `json:"c"`
+	Key        string            `json:"k"`
+}
+
+// unsubscribeMAC signs an encoded token payload with a key derived from the server secret, so
+// the link cannot be reused as any other kind of token.
+func (a *API) unsubscribeMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, []byte("unsubscribe:"+a.secret))
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// signUnsubscribeToken returns the token of the target of org. Unsubscribe links do not expire: a
+// recipient must be able to act on an old message.
+func (a *API) signUnsubscribeToken(orgAddress common.Address, target *db.SuppressionTarget) (string, error) {
+	payload, err := json.Marshal(&unsubscribeToken{OrgAddress: orgAddress, Channel: target.Channel, Key: target.Key()})
+	if err != nil {
+		return "", fmt.Errorf("could not encode unsubscribe token: %w", err)
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + base64.RawURLEncoding.EncodeToString(a.unsubscribeMAC(encoded)), nil
+}
+
+// unsubscribeURL is the web app page that acts on an unsubscribe token.
+func (a *API) unsubscribeURL(token string) string {
+	return a.webAppURL + "/unsubscribe?token=" + url.QueryEscape(token)
+}
+
+// parseUnsubscribeToken verifies a token and returns the organization and target it names.
+func (a *API) parseUnsubscribeToken(token string) (common.Address, *db.SuppressionTarget, error) {
+	encoded, signature, ok := strings.Cut(token, ".")
+	if !ok {
+		return common.Address{}, nil, errors.ErrInvalidUnsubscribeToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil || !hmac.Equal(mac, a.unsubscribeMAC(encoded)) {
+		return common.Address{}, nil, errors.ErrInvalidUnsubscribeToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return common.Address{}, nil, errors.ErrInvalidUnsubscribeToken
+	}
+	t := &unsubscribeToken{}
+	if err := json.Unmarshal(payload, t); err != nil {
+		return common.Address{}, nil, errors.ErrInvalidUnsubscribeToken
+	}
+	target, err := db.ParseSuppressionTarget(t.Channel, t.Key)
+	if err != nil {
+		return common.Address{}, nil, errors.ErrInvalidUnsubscribeToken.WithErr(err)
+	}
+	return t.OrgAddress, target, nil
+}
+
+// unsubscribeInfo describes a target for the recipient of its unsubscribe link.
+func unsubscribeInfo(orgAddress common.Address, target *db.SuppressionTarget, suppressed bool) *apicommon.UnsubscribeInfo {
+	address := target.Email
+	if target.Channel == db.MessageChannelSMS {
+		address = target.Phone.String()
+	}
+	return &apicommon.UnsubscribeInfo{
+		OrgAddress: orgAddress,
+		Channel:    target.Channel,
+		Address:    address,
+		Suppressed: suppressed,
+	}
+}
+
+// suppressionTargetFromRequest decodes a suppression request body into its target.
+func suppressionTargetFromRequest(r *http.Request, org *db.Organization,
+) (*apicommon.SuppressionRequest, *db.SuppressionTarget, error) {
+	req := &apicommon.SuppressionRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, nil, errors.ErrMalformedBody.Withf("invalid suppression request")
+	}
+	target, err := db.NewSuppressionTarget(org, req.Channel, req.Address)
+	if err != nil {
+		return nil, nil, errors.ErrInvalidData.WithErr(err)
+	}
+	return req, target, nil
+}
+
+// organizationSuppressionsHandler godoc
+//
+//	@Summary		List the suppression list of an organization
+//	@Description	List the addresses no campaign-style message is sent to, newest first. SMS entries show
+//	@Description	the masked hash of the number, as member phones do. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			page		query		integer	false	"Page number (default: 1)"
+//	@Param			limit		query		integer	false	"Number of items per page (default: 10)"
+//	@Success		200			{object}	apicommon.SuppressionsResponse
+//	@Failure		400			{object}	errors.Error	"Invalid input data"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/suppressions [get]
+func (a *API) organizationSuppressionsHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	params, err := parsePaginationParams(r.URL.Query().Get(ParamPage), r.URL.Query().Get(ParamLimit))
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	totalItems, suppressions, err := a.db.Suppressions(org.Address, params.Page, params.Limit)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	pagination, err := calculatePagination(params.Page, params.Limit, totalItems)
+	if err != nil {
+		errors.ErrMalformedURLParam.WithErr(err).Write(w)
+		return
+	}
+	if suppressions == nil {
+		suppressions = []*db.Suppression{}
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.SuppressionsResponse{
+		Pagination:   pagination,
+		Suppressions: suppressions,
+	})
+}
+
+// addOrganizationSuppressionHandler godoc
+//
+//	@Summary		Add an address to the suppression list of an organization
+//	@Description	Stop every campaign-style message to an email address or phone number, and record
+//	@Description	that the members holding it refused messages on the channel. Adding an address already
+//	@Description	listed keeps its entry. One-time codes are transactional and still delivered.
+//	@Description	Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string							true	"Organization address"
+//	@Param			request		body		apicommon.SuppressionRequest	true	"Channel, address and reason"
+//	@Success		200			{string}	string							"OK"
+//	@Failure		400			{object}	errors.Error					"Invalid channel or address"
+//	@Failure		401			{object}	errors.Error					"Unauthorized"
+//	@Failure		500			{object}	errors.Error					"Internal server error"
+//	@Router			/organizations/{orgAddress}/suppressions [post]
+func (a *API) addOrganizationSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	req, target, err := suppressionTargetFromRequest(r, org)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	source := memberChangeSource(r, user, db.MemberChangeViaAPI)
+	if _, err := a.db.SuppressAddress(org.Address, target, req.Reason, source); err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not add suppression: %v", err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// deleteOrganizationSuppressionHandler godoc
+//
+//	@Summary		Remove an address from the suppression list of an organization
+//	@Description	Allow campaign-style messages to an address again. The consent the members holding it
+//	@Description	refused is not granted back: only they can grant it. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Accept			json
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string							true	"Organization address"
+//	@Param			request		body		apicommon.SuppressionRequest	true	"Channel and address"
+//	@Success		200			{string}	string							"OK"
+//	@Failure		400			{object}	errors.Error					"Invalid channel or address"
+//	@Failure		401			{object}	errors.Error					"Unauthorized"
+//	@Failure		404			{object}	errors.Error					"Address not on the suppression list"
+//	@Failure		500			{object}	errors.Error					"Internal server error"
+//	@Router			/organizations/{orgAddress}/suppressions [delete]
+func (a *API) deleteOrganizationSuppressionHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	_, target, err := suppressionTargetFromRequest(r, org)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	if err := a.db.UnsuppressAddress(org.Address, target); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			errors.ErrSuppressionNotFound.Write(w)
+			return
+		}
+		errors.ErrGenericInternalServerError.Withf("could not remove suppression: %v", err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// memberUnsubscribeLinkHandler godoc
+//
+//	@Summary		Get the unsubscribe link of an organization member
+//	@Description	Get the signed link that unsubscribes a member from campaign-style messages on a
+//	@Description	channel, to include in every non-transactional message sent to them. The link names
+//	@Description	the member's current address and does not expire. Requires Manager/Admin role.
+//	@Description
+//	@Description	Also callable with a scoped API key (scope: `members:write`).
+//	@Tags			organizations
+//	@Produce		json
+//	@Security		BearerAuth
+//	@Param			orgAddress	path		string	true	"Organization address"
+//	@Param			memberId	path		string	true	"Member ID"
+//	@Param			channel		query		string	true	"email or sms"
+//	@Success		200			{object}	apicommon.UnsubscribeLinkResponse
+//	@Failure		400			{object}	errors.Error	"Invalid channel, or the member has no address on it"
+//	@Failure		401			{object}	errors.Error	"Unauthorized"
+//	@Failure		404			{object}	errors.Error	"Member not found"
+//	@Failure		500			{object}	errors.Error	"Internal server error"
+//	@Router			/organizations/{orgAddress}/members/{memberId}/unsubscribe [get]
+func (a *API) memberUnsubscribeLinkHandler(w http.ResponseWriter, r *http.Request) {
+	// get the organization info from the request context
+	org, _, ok := a.organizationFromRequest(r)
+	if !ok {
+		errors.ErrNoOrganizationProvided.Write(w)
+		return
+	}
+	// get the user from the request context
+	user, ok := apicommon.UserFromContext(r.Context())
+	if !ok {
+		errors.ErrUnauthorized.Write(w)
+		return
+	}
+	// check the user has the necessary permissions
+	if !user.HasRoleFor(org.Address, db.ManagerRole) && !user.HasRoleFor(org.Address, db.AdminRole) {
+		errors.ErrUnauthorized.Withf("user is not admin of organization").Write(w)
+		return
+	}
+	member, err := a.db.OrgMember(org.Address, chi.URLParam(r, "memberId"))
+	if err != nil {
+		errors.ErrOrgMemberNotFound.WithErr(err).Write(w)
+		return
+	}
+	target := &db.SuppressionTarget{Channel: db.MessageChannel(r.URL.Query().Get("channel"))}
+	switch target.Channel {
+	case db.MessageChannelEmail:
+		target.Email = member.Email
+	case db.MessageChannelSMS:
+		target.Phone = member.Phone
+	default:
+		errors.ErrMalformedURLParam.Withf("channel must be email or sms").Write(w)
+		return
+	}
+	if target.Key() == "" {
+		errors.ErrInvalidData.Withf("member has no address on channel %s", target.Channel).Write(w)
+		return
+	}
+	token, err := a.signUnsubscribeToken(org.Address, target)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, &apicommon.UnsubscribeLinkResponse{
+		URL:   a.unsubscribeURL(token),
+		Token: token,
+	})
+}
+
+// unsubscribeInfoHandler godoc
+//
+//	@Summary		Describe an unsubscribe link
+//	@Description	Describe the organization, channel and address an unsubscribe token names, and whether
+//	@Description	the address is already suppressed. Reading it changes nothing, so link scanners that
+//	@Description	follow every URL of a message do not unsubscribe anybody.
+//	@Tags			members
+//	@Produce		json
+//	@Param			token	query		string	true	"Signed unsubscribe token"
+//	@Success		200		{object}	apicommon.UnsubscribeInfo
+//	@Failure		400		{object}	errors.Error	"Invalid token"
+//	@Failure		500		{object}	errors.Error	"Internal server error"
+//	@Router			/unsubscribe [get]
+func (a *API) unsubscribeInfoHandler(w http.ResponseWriter, r *http.Request) {
+	orgAddress, target, err := a.parseUnsubscribeToken(r.URL.Query().Get("token"))
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	suppressed, err := a.db.IsSuppressed(orgAddress, target)
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, unsubscribeInfo(orgAddress, target, suppressed))
+}
+
+// unsubscribeHandler godoc
+//
+//	@Summary		Unsubscribe through a signed link
+//	@Description	Add the address an unsubscribe token names to the suppression list of its organization,
+//	@Description	and record that the members holding it refused messages on the channel. Unsubscribing
+//	@Description	twice is harmless. One-time login codes are still delivered.
+//	@Tags			members
+//	@Accept			json
+//	@Produce		json
+//	@Param			request	body		apicommon.UnsubscribeRequest	true	"Signed unsubscribe token"
+//	@Success		200		{object}	apicommon.UnsubscribeInfo
+//	@Failure		400		{object}	errors.Error	"Invalid token"
+//	@Failure		500		{object}	errors.Error	"Internal server error"
+//	@Router			/unsubscribe [post]
+func (a *API) unsubscribeHandler(w http.ResponseWriter, r *http.Request) {
+	req := &apicommon.UnsubscribeRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return
+	}
+	orgAddress, target, err := a.parseUnsubscribeToken(req.Token)
+	if err != nil {
+		writeSubscriptionError(w, err)
+		return
+	}
+	// the organization may be gone since the message was sent
+	if _, err := a.db.Organization(orgAddress); err != nil {
+		errors.ErrInvalidUnsubscribeToken.Withf("organization not found").Write(w)
+		return
+	}
+	source := db.MemberChangeSource{Via: db.MemberChangeViaUnsubscribe}
+	if _, err := a.db.SuppressAddress(orgAddress, target, "unsubscribed", source); err != nil {
+		errors.ErrGenericInternalServerError.Withf("could not unsubscribe: %v", err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteJSON(w, unsubscribeInfo(orgAddress, target, true))
+}
@@ -0,0 +1,145 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+	"github.com/vocdoni/saas-backend/notifications/mailtemplates"
+)
+
+func TestUnsubscribeLink(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	members := postOrgMembers(t, token, orgAddress, newOrgMembers(2)...)
+	memberURL := []string{"organizations", orgAddress.String(), "members", members[0].ID}
+
+	link := requestAndParse[apicommon.UnsubscribeLinkResponse](t, http.MethodGet, token, nil,
+		append(memberURL, "unsubscribe?channel=email")...)
+	c.Assert(strings.HasSuffix(link.URL, "/unsubscribe?token="+url.QueryEscape(link.Token)), qt.IsTrue)
+	requestAndAssertError(errors.ErrMalformedURLParam, t, http.MethodGet, token, nil,
+		append(memberURL, "unsubscribe?channel=fax")...)
+
+	// reading the link changes nothing
+	info := requestAndParse[apicommon.UnsubscribeInfo](t, http.MethodGet, "", nil,
+		"unsubscribe?token="+url.QueryEscape(link.Token))
+	c.Assert(info.OrgAddress, qt.Equals, orgAddress)
+	c.Assert(info.Channel, qt.Equals, db.MessageChannelEmail)
+	c.Assert(info.Address, qt.Equals, members[0].Email)
+	c.Assert(info.Suppressed, qt.IsFalse)
+
+	// following it suppresses the address and withdraws the member's consent
+	info = requestAndParse[apicommon.UnsubscribeInfo](t, http.MethodPost, "",
+		&apicommon.UnsubscribeRequest{Token: link.Token}, "unsubscribe")
+	c.Assert(info.Suppressed, qt.IsTrue)
+	member := getOrgMember(t, token, orgAddress, members[0].ID)
+	c.Assert(member.EmailConsent, qt.Not(qt.IsNil))
+	c.Assert(member.EmailConsent.Granted, qt.IsFalse)
+	c.Assert(member.EmailConsent.Source, qt.Equals, db.MemberChangeViaUnsubscribe)
+
+	suppressionsURL := []string{"organizations", orgAddress.String(), "suppressions"}
+	list := requestAndParse[apicommon.SuppressionsResponse](t, http.MethodGet, token, nil, suppressionsURL...)
+	c.Assert(list.Suppressions, qt.HasLen, 1)
+	c.Assert(list.Suppressions[0].Email, qt.Equals, members[0].Email)
+	c.Assert(list.Suppressions[0].Source.Via, qt.Equals, db.MemberChangeViaUnsubscribe)
+
+	// a tampered or malformed token is refused
+	tampered := link.Token[:len(link.Token)-2] + "xx"
+	requestAndAssertError(errors.ErrInvalidUnsubscribeToken, t, http.MethodPost, "",
+		&apicommon.UnsubscribeRequest{Token: tampered}, "unsubscribe")
+	requestAndAssertError(errors.ErrInvalidUnsubscribeToken, t, http.MethodGet, "", nil, "unsubscribe?token=garbage")
+}
+
+func TestOrganizationSuppressions(t *testing.T) {
+	c := qt.New(t)
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	newMembers := newOrgMembers(1)
+	members := postOrgMembers(t, token, orgAddress, newMembers...)
+	suppressionsURL := []string{"organizations", orgAddress.String(), "suppressions"}
+
+	phone := &apicommon.SuppressionRequest{Channel: db.MessageChannelSMS, Address: newMembers[0].Phone, Reason: "bounced"}
+	requestAndAssertCode(http.StatusOK, t, http.MethodPost, token, phone, suppressionsURL...)
+	member := getOrgMember(t, token, orgAddress, members[0].ID)
+	c.Assert(member.SMSConsent.Granted, qt.IsFalse)
+	c.Assert(member.EmailConsent, qt.IsNil)
+
+	list := requestAndParse[apicommon.SuppressionsResponse](t, http.MethodGet, token, nil, suppressionsURL...)
+	c.Assert(list.Suppressions, qt.HasLen, 1)
+	c.Assert(list.Suppressions[0].Reason, qt.Equals, "bounced")
+	c.Assert(list.Suppressions[0].Email, qt.Equals, "")
+
+	requestAndAssertCode(http.StatusOK, t, http.MethodDelete, token, phone, suppressionsURL...)
+	requestAndAssertError(errors.ErrSuppressionNotFound, t, http.MethodDelete, token, phone, suppressionsURL...)
+	requestAndAssertError(errors.ErrInvalidData, t, http.MethodPost, token,
+		&apicommon.SuppressionRequest{Channel: db.MessageChannelEmail, Address: "not an email"}, suppressionsURL...)
+}
+
+func TestCampaignSendGate(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	token := testCreateUser(t, "adminpassword123")
+	orgAddress := testCreateOrganization(t, token)
+	newMembers := newOrgMembers(3)
+	for i := range newMembers {
+		newMembers[i].Email = fmt.Sprintf("campaign%d-%s@example.com", i, strings.ToLower(orgAddress.Hex()[2:10]))
+	}
+	newMembers[1].EmailConsent = &db.MemberConsent{Granted: false, Source: "signup"}
+	members := postOrgMembers(t, token, orgAddress, newMembers...)
+	stored := make(map[string]*db.OrgMember, len(members))
+	for _, m := range members {
+		member, err := testDB.OrgMember(orgAddress, m.ID)
+		c.Assert(err, qt.IsNil)
+		stored[member.Email] = member
+	}
+	allowed, optedOut, suppressed := stored[newMembers[0].Email], stored[newMembers[1].Email], stored[newMembers[2].Email]
+	message := struct {
+		OrganizationName string
+		MemberName       string
+		Subject          string
+		Message          string
+	}{"Test Club", "Ana", "General assembly", "The assembly is on Friday."}
+
+	// a campaign template goes only through the gate, which adds the unsubscribe link
+	err := testAPI.sendMail(ctx, allowed.Email, mailtemplates.MemberMessageNotification, message, time.Time{})
+	c.Assert(err, qt.ErrorMatches, ".*must be sent through sendCampaignMail")
+	_, err = testAPI.sendCampaignMail(ctx, allowed, mailtemplates.VerifyOTPCodeNotification, message)
+	c.Assert(err, qt.ErrorMatches, ".*is transactional")
+	sent, err := testAPI.sendCampaignMail(ctx, allowed, mailtemplates.MemberMessageNotification, message)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sent, qt.IsTrue)
+	c.Assert(waitForEmail(t, allowed.Email), qt.Contains, "/unsubscribe?token")
+
+	// a member who refused email, or whose address is suppressed, gets nothing
+	sent, err = testAPI.sendCampaignMail(ctx, optedOut, mailtemplates.MemberMessageNotification, message)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sent, qt.IsFalse)
+	requestAndAssertCode(http.StatusOK, t, http.MethodPost, token,
+		&apicommon.SuppressionRequest{Channel: db.MessageChannelEmail, Address: suppressed.Email},
+		"organizations", orgAddress.String(), "suppressions")
+	sent, err = testAPI.sendCampaignMail(ctx, suppressed, mailtemplates.MemberMessageNotification, message)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sent, qt.IsFalse)
+
+	// one-time codes are transactional and still reach them
+	otp := struct {
+		Code             string
+		Organization     string
+		OrganizationLogo string
+		ExpiryTime       string
+	}{"123456", "Test Club", "", "5 minutes"}
+	for _, member := range []*db.OrgMember{optedOut, suppressed} {
+		err := testAPI.sendMail(ctx, member.Email, mailtemplates.VerifyOTPCodeNotification, otp, time.Time{})
+		c.Assert(err, qt.IsNil)
+		c.Assert(waitForEmail(t, member.Email), qt.Contains, "123456")
+	}
+}
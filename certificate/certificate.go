@@ -0,0 +1,216 @@
+// Package certificate renders the results certificate of a voting process, the document an
+// organization files with the minutes of an election: the process, its census and, per question,
+// the on-chain election, the tally per choice and the turnout. The CSV rendering is the data of
+// the certificate and the PDF its human-readable form, which carries the sha256 digest of the CSV
+// and the signature the backend key made over it (see SignedMessage), so anyone holding the CSV
+// can check it against the PDF and the key.
+package certificate
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/csv"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+// Version is the format version of the certificates rendered by this package.
+const Version = 1
+
+// Report is the content of a results certificate. Final is set when every question has final
+// results; a certificate issued before that is provisional.
+type Report struct {
+	ProcessID  string
+	OrgAddress common.Address
+	ChainID    string
+	Title      db.MultiLangString
+	StartDate  time.Time
+	EndDate    time.Time
+	Census     Census
+	Questions  []Question
+	Final      bool
+	IssuedAt   time.Time
+}
+
+// Census is the census of a certified process: its published root and URI, its size and the total
+// weight of its participants (their number in a non-weighted census).
+type Census struct {
+	Root        internal.HexBytes
+	URI         string
+	Size        int64
+	Weighted    bool
+	TotalWeight int64
+}
+
+// Question is a certified question: its on-chain election, its turnout (votes out of the
+// election's census size) and its tally. Choices carry the count of each choice by the rules of
+// the question's type; a question whose ballot protocol has no named type is certified with its
+// raw tally matrix instead. A ranked question also carries the winners of its instant-runoff count
+// once there is one, or in RunoffNotCounted why it will never get one. Decision is the outcome of its decision rules, if it has any.
+type Question struct {
+	QuestionID       string
+	UpstreamID       internal.HexBytes
+	Title            db.MultiLangString
+	Type             string
+	Status           string
+	VoteCount        uint64
+	MaxVoters        uint64
+	FinalResults     bool
+	Method           string
+	Choices          []Choice
+	Winners          []uint32
+	RunoffWinners    []uint32
+	RunoffNotCounted string
+	Results          [][]string
+	Decision         string
+}
+
+// Choice is the tally of one choice of a certified question.
+type Choice struct {
+	Value uint32
+	Title db.MultiLangString
+	Score string
+}
+
+// Turnout returns the share of the election's census that voted the question, 0 when the census
+// size is unknown.
+func (q *Question) Turnout() float64 {
+	if q.MaxVoters == 0 {
+		return 0
+	}
+	return float64(q.VoteCount) / float64(q.MaxVoters)
+}
+
+// label returns the default text of s, or the text of its first language when it has none.
+func label(s db.MultiLangString) string {
+	if text, ok := s["default"]; ok {
+		return text
+	}
+	keys := slices.Sorted(maps.Keys(s))
+	if len(keys) == 0 {
+		return ""
+	}
+	return s[keys[0]]
+}
+
+// date formats t as RFC3339 in UTC, empty when it is not set.
+func date(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// CSV renders the report as CSV records of a section name, a field and its values: first the
+// process and its census, then a row per choice of each question, a question without a named
+// type listing its raw tally fields. The rows are in a fixed order, so the same report always
+// renders the same bytes, which Digest commits to.
+func (r *Report) CSV() ([]byte, error) {
+	var b bytes.Buffer
+	w := csv.NewWriter(&b)
+	records := [][]string{
+		{"section", "field", "value", "detail"},
+		{"certificate", "version", strconv.Itoa(Version), ""},
+		{"certificate", "final", strconv.FormatBool(r.Final), ""},
+		{"certificate", "issuedAt", date(r.IssuedAt), ""},
+		{"process", "id", r.ProcessID, ""},
+		{"process", "organization", r.OrgAddress.Hex(), ""},
+		{"process", "title", label(r.Title), ""},
+		{"process", "startDate", date(r.StartDate), ""},
+		{"process", "endDate", date(r.EndDate), ""},
+		{"process", "chainId", r.ChainID, ""},
+		{"census", "root", r.Census.Root.String(), ""},
+		{"census", "uri", r.Census.URI, ""},
+		{"census", "size", strconv.FormatInt(r.Census.Size, 10), ""},
+		{"census", "weighted", strconv.FormatBool(r.Census.Weighted), ""},
+		{"census", "totalWeight", strconv.FormatInt(r.Census.TotalWeight, 10), ""},
+	}
+	for i := range r.Questions {
+		q := &r.Questions[i]
+		section := fmt.Sprintf("question %d", i+1)
+		records = append(records,
+			[]string{section, "id", q.QuestionID, ""},
+			[]string{section, "title", label(q.Title), ""},
+			[]string{section, "type", q.Type, ""},
+			[]string{section, "electionId", q.UpstreamID.String(), ""},
+			[]string{section, "status", q.Status, ""},
+			[]string{section, "finalResults", strconv.FormatBool(q.FinalResults), ""},
+			[]string{section, "votes", strconv.FormatUint(q.VoteCount, 10), ""},
+			[]string{section, "maxVoters", strconv.FormatUint(q.MaxVoters, 10), ""},
+			[]string{section, "turnout", strconv.FormatFloat(q.Turnout(), 'f', 4, 64), ""},
+		)
+		if q.Method != "" {
+			records = append(records, []string{section, "method", q.Method, ""})
+		}
+		for _, c := range q.Choices {
+			records = append(records, []string{section, "choice " + strconv.FormatUint(uint64(c.Value), 10), c.Score, label(c.Title)})
+		}
+		for j, field := range q.Results {
+			for k, v := range field {
+				records = append(records, []string{section, fmt.Sprintf("field %d value %d", j, k), v, ""})
+			}
+		}
+		if q.Method != "" {
+			records = append(records, []string{section, "winners", values(q.Winners), ""})
+		}
+		if q.RunoffWinners != nil {
+			records = append(records, []string{section, "runoffWinners", values(q.RunoffWinners), ""})
+		}
+		if q.RunoffNotCounted != "" {
+			records = append(records, []string{section, "runoffNotCounted", q.RunoffNotCounted, ""})
+		}
+		if q.Decision != "" {
+			records = append(records, []string{section, "decision", q.Decision, ""})
+		}
+	}
+	if err := w.WriteAll(records); err != nil {
+		return nil, fmt.Errorf("could not write the certificate CSV: %w", err)
+	}
+	return b.Bytes(), nil
+}
+
+// values formats choice values as a space-separated list.
+func values(vs []uint32) string {
+	var b bytes.Buffer
+	for i, v := range vs {
+		if i > 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteString(strconv.FormatUint(uint64(v), 10))
+	}
+	return b.String()
+}
+
+// Digest returns the sha256 of the CSV rendering of a certificate.
+func Digest(csvData []byte) internal.HexBytes {
+	h := sha256.Sum256(csvData)
+	return internal.HexBytes(h[:])
+}
+
+// SignedMessage returns the text the signature of the certificate of processID with the given
+// digest is made over.
+func SignedMessage(processID string, digest []byte) []byte {
+	return fmt.Appendf(nil, "vocdoni results certificate v%d\nprocess: %s\ndigest: %x\n", Version, processID, digest)
+}
+
+// Verify checks that signature was made over the certificate of processID with the given digest
+// by key, a compressed public key, and returns the key that made it.
+func Verify(processID string, digest, signature, key []byte) (internal.HexBytes, error) {
+	// PubKeyFromSignature normalizes the recovery byte in place
+	signer, err := ethereum.PubKeyFromSignature(SignedMessage(processID, digest), bytes.Clone(signature))
+	if err != nil {
+		return nil, fmt.Errorf("invalid signature: %w", err)
+	}
+	if !bytes.Equal(signer, key) {
+		return signer, fmt.Errorf("certificate was signed by %x, not by the backend key", signer)
+	}
+	return signer, nil
+}
@@ -0,0 +1,165 @@
+package certificate
+
+import (
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+func testReport() *Report {
+	return &Report{
+		ProcessID:  "65f1",
+		OrgAddress: common.Address{0x01},
+		ChainID:    "test",
+		Title:      db.MultiLangString{"es": "Asamblea", "default": "Assembly (2026)"},
+		EndDate:    time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Census:     Census{Root: internal.HexBytes{0xaa}, Size: 12, TotalWeight: 12},
+		Questions: []Question{{
+			QuestionID: "65f2", UpstreamID: internal.HexBytes{0xbb}, Title: db.MultiLangString{"default": "Budget"},
+			Type: db.VotingTypeSingleChoice, Status: db.QuestionStatusResults, VoteCount: 9, MaxVoters: 12,
+			FinalResults: true, Method: db.TallyMethodPlurality, Winners: []uint32{0},
+			Choices: []Choice{
+				{Value: 0, Title: db.MultiLangString{"default": "Yes"}, Score: "6"},
+				{Value: 1, Title: db.MultiLangString{"default": "No"}, Score: "3"},
+			},
+			Decision: db.DecisionPassed,
+		}, {
+			QuestionID: "65f3", UpstreamID: internal.HexBytes{0xcc}, Title: db.MultiLangString{"default": "Raw"},
+			VoteCount: 2, Results: [][]string{{"1", "1"}},
+		}},
+		Final:    true,
+		IssuedAt: time.Date(2026, 10, 2, 9, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestCSV(t *testing.T) {
+	c := qt.New(t)
+	data, err := testReport().CSV()
+	c.Assert(err, qt.IsNil)
+	again, err := testReport().CSV()
+	c.Assert(err, qt.IsNil)
+	c.Assert(again, qt.DeepEquals, data, qt.Commentf("the same report renders the same bytes"))
+
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	c.Assert(err, qt.IsNil)
+	find := func(section, field string) []string {
+		for _, r := range records {
+			if r[0] == section && r[1] == field {
+				return r
+			}
+		}
+		c.Fatalf("no %s %s record", section, field)
+		return nil
+	}
+	c.Assert(find("process", "title")[2], qt.Equals, "Assembly (2026)")
+	c.Assert(find("process", "endDate")[2], qt.Equals, "2026-10-01T12:00:00Z")
+	c.Assert(find("census", "root")[2], qt.Equals, "aa")
+	c.Assert(find("question 1", "electionId")[2], qt.Equals, "bb")
+	c.Assert(find("question 1", "turnout")[2], qt.Equals, "0.7500")
+	c.Assert(find("question 1", "choice 0"), qt.DeepEquals, []string{"question 1", "choice 0", "6", "Yes"})
+	c.Assert(find("question 1", "decision")[2], qt.Equals, db.DecisionPassed)
+	// a question without a named type is certified with its raw tally
+	c.Assert(find("question 2", "field 0 value 1")[2], qt.Equals, "1")
+	c.Assert(find("question 2", "turnout")[2], qt.Equals, "0.0000")
+
+	// the instant-runoff winners are certified once counted
+	r := testReport()
+	r.Questions[0].RunoffWinners = []uint32{1, 0}
+	data, err = r.CSV()
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.Contains(data, []byte("question 1,runoffWinners,1 0,\n")), qt.IsTrue)
+	c.Assert(Digest(data), qt.Not(qt.DeepEquals), Digest(again))
+
+	// ...and so is the reason a question is never counted
+	r = testReport()
+	r.Questions[0].RunoffNotCounted = db.RunoffNotCountedTooManyBallots
+	data, err = r.CSV()
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.Contains(data, []byte("question 1,runoffNotCounted,tooManyBallots,\n")), qt.IsTrue)
+}
+
+func TestPDF(t *testing.T) {
+	c := qt.New(t)
+	r := testReport()
+	// enough choices to need more than one page
+	for i := range 80 {
+		r.Questions[0].Choices = append(r.Questions[0].Choices, Choice{
+			Value: uint32(i + 2), Title: db.MultiLangString{"default": "Choix " + strconv.Itoa(i) + " éñ 日本"}, Score: "0",
+		})
+	}
+	pdf := r.PDF(internal.HexBytes{0x01}, internal.HexBytes{0x02}, internal.HexBytes{0x03})
+	c.Assert(bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")), qt.IsTrue)
+	c.Assert(bytes.HasSuffix(pdf, []byte("%%EOF\n")), qt.IsTrue)
+	count := regexp.MustCompile(`/Count (\d+)`).FindSubmatch(pdf)
+	c.Assert(count, qt.HasLen, 2)
+	pages, err := strconv.Atoi(string(count[1]))
+	c.Assert(err, qt.IsNil)
+	c.Assert(pages > 1, qt.IsTrue)
+	c.Assert(bytes.Contains(pdf, []byte("(Assembly \\(2026\\))")), qt.IsTrue)
+	// Latin-1 is kept as WinAnsi bytes, anything else becomes '?'
+	c.Assert(bytes.Contains(pdf, []byte("Choix 0 \xe9\xf1 ??")), qt.IsTrue)
+
+	// every object the cross-reference table points at starts where it says
+	m := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+	c.Assert(m, qt.HasLen, 2)
+	xref, err := strconv.Atoi(string(m[1]))
+	c.Assert(err, qt.IsNil)
+	c.Assert(bytes.HasPrefix(pdf[xref:], []byte("xref\n")), qt.IsTrue)
+	entries := regexp.MustCompile(`(\d{10}) 00000 n `).FindAllSubmatch(pdf[xref:], -1)
+	// the catalog, the page tree, two fonts, and a page and its content per page
+	c.Assert(entries, qt.HasLen, 4+2*pages)
+	for i, e := range entries {
+		offset, err := strconv.Atoi(string(e[1]))
+		c.Assert(err, qt.IsNil)
+		c.Assert(bytes.HasPrefix(pdf[offset:], fmt.Appendf(nil, "%d 0 obj\n", i+1)), qt.IsTrue, qt.Commentf("object %d", i+1))
+	}
+	// and every stream is as long as it declares
+	for _, s := range regexp.MustCompile(`(?s)<< /Length (\d+) >>\nstream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		c.Assert(strconv.Itoa(len(s[2])), qt.Equals, string(s[1]))
+	}
+}
+
+func TestWrap(t *testing.T) {
+	c := qt.New(t)
+	long := strings.Repeat("ab ", 40) + strings.Repeat("f", 200)
+	for _, l := range wrap([]pdfLine{{text: long}}) {
+		c.Assert(len([]rune(l.text)) <= lineChars, qt.IsTrue)
+		c.Assert(strings.HasPrefix(l.text, " "), qt.IsFalse)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	c := qt.New(t)
+	key := ethereum.NewSignKeys()
+	c.Assert(key.Generate(), qt.IsNil)
+	data, err := testReport().CSV()
+	c.Assert(err, qt.IsNil)
+	digest := Digest(data)
+	signature, err := key.SignEthereum(SignedMessage("65f1", digest))
+	c.Assert(err, qt.IsNil)
+
+	signer, err := Verify("65f1", digest, signature, key.PublicKey())
+	c.Assert(err, qt.IsNil)
+	c.Assert([]byte(signer), qt.DeepEquals, []byte(key.PublicKey()))
+
+	// another process, another digest or another key does not verify
+	_, err = Verify("65f9", digest, signature, key.PublicKey())
+	c.Assert(err, qt.IsNotNil)
+	_, err = Verify("65f1", Digest([]byte("forged")), signature, key.PublicKey())
+	c.Assert(err, qt.IsNotNil)
+	other := ethereum.NewSignKeys()
+	c.Assert(other.Generate(), qt.IsNil)
+	_, err = Verify("65f1", digest, signature, other.PublicKey())
+	c.Assert(err, qt.ErrorMatches, "certificate was signed by .*, not by the backend key")
+}
@@ -0,0 +1,200 @@
+package certificate
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/vocdoni/saas-backend/internal"
+)
+
+const (
+	// pageWidth and pageHeight are an A4 page in points.
+	pageWidth  = 595
+	pageHeight = 842
+	// margin is the blank border of a page, in points.
+	margin = 56
+	// leading is the distance between two lines, in points.
+	leading = 14
+	// lineChars is how many characters of 10pt Helvetica fit on a line between the margins.
+	lineChars = 88
+)
+
+// pdfLine is a line of text of the PDF, bold for headings.
+type pdfLine struct {
+	text string
+	bold bool
+}
+
+// PDF renders the report as a PDF certificate signed with signature, made by signer, over the
+// digest of its CSV rendering. The document is plain text laid out on A4 pages in the Helvetica
+// fonts every PDF reader has, so it embeds nothing.
+func (r *Report) PDF(digest, signature, signer internal.HexBytes) []byte {
+	heading := func(text string) pdfLine { return pdfLine{text: text, bold: true} }
+	field := func(name, value string) pdfLine { return pdfLine{text: name + ": " + value} }
+
+	title := "Results certificate"
+	if !r.Final {
+		title += " (provisional: the results are not final)"
+	}
+	lines := []pdfLine{
+		heading(title),
+		{},
+		heading(label(r.Title)),
+		field("Process", r.ProcessID),
+		field("Organization", r.OrgAddress.Hex()),
+		field("Start date", date(r.StartDate)),
+		field("End date", date(r.EndDate)),
+		field("Chain", r.ChainID),
+		field("Issued at", date(r.IssuedAt)),
+		{},
+		heading("Census"),
+		field("Root", r.Census.Root.String()),
+		field("URI", r.Census.URI),
+		field("Size", strconv.FormatInt(r.Census.Size, 10)),
+		field("Weighted", strconv.FormatBool(r.Census.Weighted)),
+		field("Total weight", strconv.FormatInt(r.Census.TotalWeight, 10)),
+	}
+	for i := range r.Questions {
+		q := &r.Questions[i]
+		lines = append(lines,
+			pdfLine{},
+			heading(fmt.Sprintf("Question %d: %s", i+1, label(q.Title))),
+			field("Election", q.UpstreamID.String()),
+			field("Type", q.Type),
+			field("Status", q.Status),
+			field("Final results", strconv.FormatBool(q.FinalResults)),
+			field("Turnout", fmt.Sprintf("%d of %d (%.2f%%)", q.VoteCount, q.MaxVoters, 100*q.Turnout())),
+		)
+		if q.Method != "" {
+			lines = append(lines, field("Counted by", q.Method))
+		}
+		for _, c := range q.Choices {
+			lines = append(lines, pdfLine{text: fmt.Sprintf("    %s: %s", label(c.Title), c.Score)})
+		}
+		for j, row := range q.Results {
+			lines = append(lines, pdfLine{text: fmt.Sprintf("    field %d: %s", j, strings.Join(row, " "))})
+		}
+		if q.Method != "" {
+			lines = append(lines, field("Winning choices", values(q.Winners)))
+		}
+		if q.RunoffWinners != nil {
+			lines = append(lines, field("Instant-runoff winners", values(q.RunoffWinners)))
+		}
+		if q.RunoffNotCounted != "" {
+			lines = append(lines, field("Instant-runoff not counted", q.RunoffNotCounted))
+		}
+		if q.Decision != "" {
+			lines = append(lines, field("Decision", q.Decision))
+		}
+	}
+	lines = append(lines,
+		pdfLine{},
+		heading("Signature"),
+		pdfLine{text: "The digest is the sha256 of the CSV rendering of this certificate. The signature is made over"},
+		pdfLine{text: fmt.Sprintf("the text \"vocdoni results certificate v%d\\nprocess: <process>\\ndigest: <digest>\\n\"", Version)},
+		pdfLine{text: "in the Ethereum signed-message format."},
+		field("Digest", digest.String()),
+		field("Signature", signature.String()),
+		field("Signer", signer.String()),
+	)
+	return writePDF(wrap(lines))
+}
+
+// wrap splits the lines longer than lineChars, at the last space that fits or else anywhere, as
+// hex strings have no spaces.
+func wrap(lines []pdfLine) []pdfLine {
+	var out []pdfLine
+	for _, l := range lines {
+		text := []rune(l.text)
+		for len(text) > lineChars {
+			cut := lineChars
+			for i := lineChars; i > 0; i-- {
+				if text[i] == ' ' {
+					cut = i
+					break
+				}
+			}
+			out = append(out, pdfLine{text: string(text[:cut]), bold: l.bold})
+			for cut < len(text) && text[cut] == ' ' {
+				cut++
+			}
+			text = text[cut:]
+		}
+		out = append(out, pdfLine{text: string(text), bold: l.bold})
+	}
+	return out
+}
+
+// writePDF lays lines out on as many pages as they need and returns the PDF document. Objects 1
+// to 4 are the catalog, the page tree and the two fonts, and every page is followed by its content
+// stream.
+func writePDF(lines []pdfLine) []byte {
+	perPage := (pageHeight - 2*margin) / leading
+	var pages [][]pdfLine
+	for len(lines) > perPage {
+		pages = append(pages, lines[:perPage])
+		lines = lines[perPage:]
+	}
+	pages = append(pages, lines)
+
+	var b bytes.Buffer
+	var offsets []int
+	object := func(body string) {
+		offsets = append(offsets, b.Len())
+		fmt.Fprintf(&b, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+	// the binary comment marks the file as binary to transfer tools
+	b.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica-Bold /Encoding /WinAnsiEncoding >>")
+	for i, page := range pages {
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] "+
+			"/Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>", pageWidth, pageHeight, 6+2*i))
+		var content bytes.Buffer
+		fmt.Fprintf(&content, "BT\n%d TL\n%d %d Td\n", leading, margin, pageHeight-margin)
+		for _, l := range page {
+			font := "F1"
+			if l.bold {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s 10 Tf\n(%s) Tj\nT*\n", font, escape(l.text))
+		}
+		content.WriteString("ET")
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", content.Len(), content.Bytes()))
+	}
+	xref := b.Len()
+	fmt.Fprintf(&b, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&b, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&b, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return b.Bytes()
+}
+
+// escape encodes text as the bytes of a PDF string in WinAnsiEncoding, which matches Latin-1 from
+// 0xA0 on: the delimiters are escaped, and characters it cannot show become '?'.
+func escape(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		switch {
+		case r == '(' || r == ')' || r == '\\':
+			b.WriteByte('\\')
+			b.WriteRune(r)
+		case r >= 0x20 && r < 0x7f:
+			b.WriteRune(r)
+		case r >= 0xa0 && r <= 0xff:
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
@@ -2,11 +2,14 @@
 // from the database. It supports two modes:
 // 1. Process-only mode: displays bundle, census, and CSP statistics for a process
 // 2. Process+User mode: displays user-specific participation details
+//
+// With --verifyCensusExport it instead verifies a census export file offline.
 package main
 
 import (
 	"encoding/json"
 	"fmt"
+	"os"
 
 	"github.com/ethereum/go-ethereum/common"
 	flag "github.com/spf13/pflag"
@@ -29,6 +32,7 @@ func main() {
 	flag.Bool("setIntegrator", false, "enable the given organization as an integrator and set its managed-org limit")
 	flag.String("orgAddress", "", "organization address (hex) for --setIntegrator")
 	flag.Int("maxManagedOrgs", 0, "integrator limit: max managed organizations")
+	flag.String("verifyCensusExport", "", "verify the census export in the given file offline and exit")
 
 	// Parse flags
 	flag.Parse()
@@ -49,6 +53,13 @@ func main() {
 	// Initialize logger
 	log.Init("info", "stdout", nil)
 
+	if exportFile := viper.GetString("verifyCensusExport"); exportFile != "" {
+		if err := verifyCensusExport(exportFile); err != nil {
+			log.Fatalf("census export is not valid: %v", err)
+		}
+		return
+	}
+
 	if viper.GetBool("setIntegrator") {
 		if mongoURL == "" || mongoDB == "" {
 			log.Fatal("mongoURL and mongoDB are required")
@@ -113,6 +124,30 @@ func main() {
 	}
 }
 
+// verifyCensusExport checks the census export (GET /census/{id}/export/{jobId}) in the given file
+// with no access to the backend: its participants match its digest, size and total weight, and
+// its root key signed it.
+func verifyCensusExport(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("could not read census export: %w", err)
+	}
+	export := &db.CensusExport{}
+	if err := json.Unmarshal(data, export); err != nil {
+		return fmt.Errorf("could not decode census export: %w", err)
+	}
+	if err := export.Verify(); err != nil {
+		return err
+	}
+	log.Infow("census export is valid",
+		"census", export.CensusID,
+		"root", export.Root.String(),
+		"size", export.Size,
+		"totalWeight", export.TotalWeight,
+		"digest", export.Digest.String())
+	return nil
+}
+
 // setIntegrator enables the organization at the given address as an integrator and
 // sets its managed-org limit override. The aggregate process/census caps come from the
 // integrator's subscription plan (Plan.Organization.MaxProcesses / MaxCensus).
@@ -17,11 +17,13 @@ import (
 	"github.com/vocdoni/saas-backend/csp"
 	"github.com/vocdoni/saas-backend/db"
 	"github.com/vocdoni/saas-backend/internal"
+	"github.com/vocdoni/saas-backend/liveresults"
 	"github.com/vocdoni/saas-backend/notifications"
 	"github.com/vocdoni/saas-backend/notifications/mailtemplates"
 	"github.com/vocdoni/saas-backend/notifications/smtp"
 	"github.com/vocdoni/saas-backend/notifications/twilio"
 	"github.com/vocdoni/saas-backend/objectstorage"
+	"github.com/vocdoni/saas-backend/retention"
 	"github.com/vocdoni/saas-backend/statussync"
 	"github.com/vocdoni/saas-backend/subscriptions"
 	"go.vocdoni.io/dvote/apiclient"
@@ -70,6 +72,14 @@ func main() {
 	// when a status changes through the API or a process/question is read)
 	flag.Duration("statusSyncInterval", 60*time.Second, "poll cadence for confirm retries + read freshness window (0=60s)")
 	flag.Duration("statusSyncConfirmTimeout", 5*time.Minute, "max wait for on-chain confirmation before reconciling (0=5m)")
+	// data retention sweeper (enforces the retention policies organizations set on their members)
+	flag.Duration("retentionSweepInterval", time.Hour, "pause between two sweeps of the organization retention policies (0=1h)")
+	// publish scheduler (publishes the voting processes scheduled for a later time)
+	flag.Duration("publishScheduleInterval", 30*time.Second, "pause between two passes of the publish scheduler (0=30s)")
+	// live process streams (one shared reader per followed process)
+	flag.Duration("liveResultsInterval", 5*time.Second, "pause between two reads of a process followed by live streams (0=5s)")
+	flag.Int("liveResultsMaxStreams", 2000, "max live process streams open at once (0=2000)")
+	flag.Int("liveResultsMaxProcessStreams", 500, "max live streams open at once to a single process (0=500)")
 	// parse flags
 	flag.Parse()
 	// initialize Viper
@@ -246,6 +256,12 @@ func main() {
 		log.Fatalf("could not create the CSP service: %v", err)
 		return
 	}
+	// the shared readers of the live process streams, told by the status syncer when a status changes
+	apiConf.LiveResults = liveresults.New(ctx, &liveresults.Config{
+		Interval:              viper.GetDuration("liveResultsInterval"),
+		MaxSubscribers:        viper.GetInt("liveResultsMaxStreams"),
+		MaxProcessSubscribers: viper.GetInt("liveResultsMaxProcessStreams"),
+	})
 	// background worker: reconcile a published question's status with the chain on demand (a status
 	// change through the API, or a read of the process/question). Enqueues from the API handlers flow
 	// through apiConf.StatusSyncer.
@@ -254,9 +270,17 @@ func main() {
 		Account:        acc,
 		Interval:       viper.GetDuration("statusSyncInterval"),
 		ConfirmTimeout: viper.GetDuration("statusSyncConfirmTimeout"),
+		Notifier:       apiConf.LiveResults,
 	})
 	apiConf.StatusSyncer = syncer
 	syncer.Start()
+	// background worker in the API: publish the voting processes scheduled for a later time
+	apiConf.PublishScheduleInterval = viper.GetDuration("publishScheduleInterval")
+	// background worker: enforce the data retention policies of the organizations
+	retention.New(ctx, &retention.Config{
+		DB:       database,
+		Interval: viper.GetDuration("retentionSweepInterval"),
+	}).Start()
 	apiConf.Subscriptions = subscriptions.New(&subscriptions.Config{
 		DB: database,
 	})
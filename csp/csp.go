@@ -169,3 +169,9 @@ func (c *CSP) PubKey() (internal.HexBytes, error) {
 	}
 	return ethcrypto.CompressPubkey(pub), nil
 }
+
+// SignMessage signs msg, in the Ethereum signed-message format, with the root key of the CSP,
+// unsalted, so the signer recovered from the signature is the root published for its censuses.
+func (c *CSP) SignMessage(msg []byte) (internal.HexBytes, error) {
+	return c.Signer.SignECDSA([saltedkey.SaltSize]byte{}, msg)
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+
+	"github.com/vocdoni/saas-backend/api/apicommon"
+	"github.com/vocdoni/saas-backend/csp"
+	"github.com/vocdoni/saas-backend/db"
+	"github.com/vocdoni/saas-backend/errors"
+)
+
+// delegationAuth resolves the voter behind the auth token of a delegation request: the token
+// must be verified and anchored to the process. It returns the process census and the voter's
+// member id, writing the proper error and returning false on failure.
+func (c *CSPHandlers) delegationAuth(w http.ResponseWriter, r *http.Request, req *DelegationRequest) (*db.Census, string, bool) {
+	oid, anchor, ok := parseProcessID(w, r)
+	if !ok {
+		return nil, "", false
+	}
+	vp, ok := c.getVotingProcess(w, oid)
+	if !ok {
+		return nil, "", false
+	}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		errors.ErrMalformedBody.Write(w)
+		return nil, "", false
+	}
+	auth, ok := c.getAuthInfo(w, req.AuthToken)
+	if !ok {
+		return nil, "", false
+	}
+	if !bytes.Equal(anchor, auth.BundleID) {
+		errors.ErrUnauthorized.Withf("token does not belong to the process").Write(w)
+		return nil, "", false
+	}
+	if !auth.Verified {
+		errors.ErrUnauthorized.WithErr(csp.ErrAuthTokenNotVerified).Write(w)
+		return nil, "", false
+	}
+	census, err := c.mainDB.Census(vp.CensusID.Hex())
+	if err != nil {
+		errors.ErrCensusNotFound.WithErr(err).Write(w)
+		return nil, "", false
+	}
+	return census, auth.UserID.String(), true
+}
+
+// ProcessDelegateHandler godoc
+//
+//	@Summary		Delegate a voter's vote in a voting process
+//	@Description	Hand the vote of the voter behind a verified auth token, bound to the process, to
+//	@Description	another participant of the process census, who then signs with both weights. The
+//	@Description	delegate is identified by the same fields as the first auth step (name, surname,
+//	@Description	memberNumber, nationalId, birthDate, email, phone, as the census requires). A voter
+//	@Description	delegates to one member at most: a new delegation replaces the previous one. The census
+//	@Description	must allow proxies (maxProxies), the delegate must hold fewer than maxProxies
+//	@Description	delegations, and delegations cannot chain: a voter holding proxies cannot delegate, nor
+//	@Description	be delegated to once they delegated. Delegations are locked once the process starts.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Param			processId	path		string						true	"Process ID"
+//	@Param			request		body		handlers.DelegationRequest	true	"Auth token and the delegate's identification"
+//	@Success		200			{string}	string						"OK"
+//	@Failure		400			{object}	errors.Error				"Invalid input data, or a chained or self delegation"
+//	@Failure		401			{object}	errors.Error				"Invalid token, token not verified, or token not belonging to the process"
+//	@Failure		404			{object}	errors.Error				"Process or census not found, or the delegate is not in the census"
+//	@Failure		409			{object}	errors.Error				"Delegation not allowed, delegations locked, or proxy limit reached"
+//	@Failure		500			{object}	errors.Error				"Internal server error"
+//	@Router			/processes/{processId}/delegation [post]
+func (c *CSPHandlers) ProcessDelegateHandler(w http.ResponseWriter, r *http.Request) {
+	var req DelegationRequest
+	census, memberID, ok := c.delegationAuth(w, r, &req)
+	if !ok {
+		return
+	}
+	if req.Delegate == nil {
+		errors.ErrInvalidData.Withf("missing delegate").Write(w)
+		return
+	}
+	org, err := c.mainDB.Organization(census.OrgAddress)
+	if err != nil {
+		errors.ErrOrganizationNotFound.WithErr(err).Write(w)
+		return
+	}
+	delegate, err := c.censusParticipantByAuthRequest(census, org, req.Delegate)
+	if err != nil {
+		if apiErr, ok := err.(errors.Error); ok {
+			apiErr.Write(w)
+			return
+		}
+		errors.ErrInvalidData.WithErr(err).Write(w)
+		return
+	}
+	if _, err := c.mainDB.SetCensusDelegation(census, memberID, delegate.ParticipantID,
+		db.DelegationSourceMember); err != nil {
+		apicommon.DelegationError(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
+
+// ProcessRevokeDelegationHandler godoc
+//
+//	@Summary		Revoke a voter's delegation in a voting process
+//	@Description	Take back the vote the voter behind a verified auth token, bound to the process,
+//	@Description	delegated. Only possible until the process starts.
+//	@Tags			processes
+//	@Accept			json
+//	@Produce		json
+//	@Param			processId	path		string						true	"Process ID"
+//	@Param			request		body		handlers.DelegationRequest	true	"Auth token (delegate is ignored)"
+//	@Success		200			{string}	string						"OK"
+//	@Failure		400			{object}	errors.Error				"Invalid input data"
+//	@Failure		401			{object}	errors.Error				"Invalid token, token not verified, or token not belonging to the process"
+//	@Failure		404			{object}	errors.Error				"Process or census not found, or no delegation"
+//	@Failure		409			{object}	errors.Error				"Delegations locked"
+//	@Failure		500			{object}	errors.Error				"Internal server error"
+//	@Router			/processes/{processId}/delegation [delete]
+func (c *CSPHandlers) ProcessRevokeDelegationHandler(w http.ResponseWriter, r *http.Request) {
+	var req DelegationRequest
+	census, memberID, ok := c.delegationAuth(w, r, &req)
+	if !ok {
+		return
+	}
+	if err := c.mainDB.DeleteCensusDelegation(census, memberID); err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			errors.ErrDelegationNotFound.Write(w)
+			return
+		}
+		apicommon.DelegationError(err).Write(w)
+		return
+	}
+	apicommon.HTTPWriteOK(w)
+}
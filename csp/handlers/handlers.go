@@ -14,6 +14,7 @@ import (
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/account"
 	"github.com/vocdoni/saas-backend/api/apicommon"
 	"github.com/vocdoni/saas-backend/csp"
 	"github.com/vocdoni/saas-backend/csp/notifications"
@@ -35,21 +36,24 @@ const (
 // electionIDLength is the byte length of a Vochain election (process) id: 32 bytes / 64 hex chars.
 const electionIDLength = 32
 
-// CSPHandlers is a struct that contains an instance of the CSP and the main
-// database (where the bundle and census data is stored). It is used to handle
-// the CSP API requests such as the authentication and signing of the bundle
-// processes.
+// CSPHandlers is a struct that contains an instance of the CSP, the main
+// database (where the bundle and census data is stored) and the Vochain
+// account, which reads the ballots conditional questions depend on. It is used
+// to handle the CSP API requests such as the authentication and signing of the
+// bundle processes.
 type CSPHandlers struct {
-	csp    *csp.CSP
-	mainDB *db.MongoStorage
+	csp     *csp.CSP
+	mainDB  *db.MongoStorage
+	account *account.Account
 }
 
 // New creates a new instance of the CSP handlers instance. It receives the CSP
-// instance and the main database instance as parameters.
-func New(c *csp.CSP, mainDB *db.MongoStorage) *CSPHandlers {
+// instance, the main database instance and the Vochain account as parameters.
+func New(c *csp.CSP, mainDB *db.MongoStorage, acc *account.Account) *CSPHandlers {
 	return &CSPHandlers{
-		csp:    c,
-		mainDB: mainDB,
+		csp:     c,
+		mainDB:  mainDB,
+		account: acc,
 	}
 }
 
@@ -243,7 +247,7 @@ func (c *CSPHandlers) BundleAuthResendHandler(w http.ResponseWriter, r *http.Req
 		errors.ErrUnauthorized.WithErr(fmt.Errorf("invalid user ID in token: %w", err)).Write(w)
 		return
 	}
-	member, err := c.mainDB.OrgMember(bundle.OrgAddress, oid.Hex())
+	member, err := c.mainDB.CensusMember(bundle.OrgAddress, oid.Hex())
 	if err != nil {
 		errors.ErrUserNotFound.WithErr(err).Write(w)
 		return
@@ -331,6 +335,16 @@ func weightBytes(w uint64) internal.HexBytes {
 	return new(big.Int).SetUint64(w).Bytes()
 }
 
+// writeWeightError maps a db.DelegatedVoterWeight error to the proper HTTP error: a member who
+// delegated their vote is refused, anything else is internal.
+func writeWeightError(w http.ResponseWriter, err error) {
+	if errors.Is(err, db.ErrVoteDelegated) {
+		errors.ErrVoteDelegated.Write(w)
+		return
+	}
+	errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+}
+
 // validateVoterAddress checks a voter address is a full 20-byte Ethereum address. The address is
 // signed into the CA bundle and pinned as the election's consumer, after which a different
 // address is rejected forever — and HexBytes accepts any length and pads odd input — so one
@@ -398,7 +412,7 @@ func (c *CSPHandlers) signAndRespond(w http.ResponseWriter, authToken, address,
 //	@Param			request		body		handlers.SignRequest	true	"Sign request (see description for fields)"
 //	@Success		200			{object}	handlers.AuthResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
-//	@Failure		401			{object}	errors.Error	"Unauthorized, invalid/unverified token, or process not in the bundle"
+//	@Failure		401			{object}	errors.Error	"Unauthorized, invalid/unverified token, process not in the bundle, or vote delegated"
 //	@Failure		404			{object}	errors.Error	"Bundle not found or user not found"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
 //	@Deprecated
@@ -444,7 +458,7 @@ func (c *CSPHandlers) BundleSignHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrUnauthorized.WithErr(fmt.Errorf("invalid user ID in token: %w", err)).Write(w)
 		return
 	}
-	member, err := c.mainDB.OrgMember(bundle.OrgAddress, oid.Hex())
+	member, err := c.mainDB.CensusMember(bundle.OrgAddress, oid.Hex())
 	if err != nil {
 		errors.ErrUserNotFound.WithErr(err).Write(w)
 		return
@@ -469,10 +483,11 @@ func (c *CSPHandlers) BundleSignHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// default weight to 1 if not set
-	weight := uint64(1)
-	if census.Weighted {
-		weight = member.Weight
+	// 1 unless the census is weighted, plus the votes delegated to the member
+	weight, err := c.mainDB.DelegatedVoterWeight(census, member)
+	if err != nil {
+		writeWeightError(w, err)
+		return
 	}
 
 	// Parse the address from the payload
@@ -496,7 +511,7 @@ func (c *CSPHandlers) BundleSignHandler(w http.ResponseWriter, r *http.Request)
 //	@Success		200			{object}	handlers.UserWeightResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
 //	@Failure		401			{object}	errors.Error	"Unauthorized, invalid token, token not verified (ErrAuthTokenNotVerified)"
-//	@Failure		401			{object}	errors.Error	"token not belonging to bundle"
+//	@Failure		401			{object}	errors.Error	"token not belonging to bundle, or vote delegated (ErrVoteDelegated)"
 //	@Failure		404			{object}	errors.Error	"Bundle not found, user not found, or census not found"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
 //	@Deprecated
@@ -537,7 +552,7 @@ func (c *CSPHandlers) UserWeightHandler(w http.ResponseWriter, r *http.Request)
 		errors.ErrUnauthorized.WithErr(fmt.Errorf("invalid user ID in token: %w", err)).Write(w)
 		return
 	}
-	member, err := c.mainDB.OrgMember(bundle.OrgAddress, oid.Hex())
+	member, err := c.mainDB.CensusMember(bundle.OrgAddress, oid.Hex())
 	if err != nil {
 		errors.ErrUserNotFound.WithErr(err).Write(w)
 		return
@@ -548,10 +563,11 @@ func (c *CSPHandlers) UserWeightHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// default weight to 1 if not set
-	weight := uint64(1)
-	if census.Weighted {
-		weight = member.Weight
+	// 1 unless the census is weighted, plus the votes delegated to the member
+	weight, err := c.mainDB.DelegatedVoterWeight(census, member)
+	if err != nil {
+		writeWeightError(w, err)
+		return
 	}
 
 	// return the user weight for the bundle
@@ -627,7 +643,7 @@ func (c *CSPHandlers) BundleCheckHandler(w http.ResponseWriter, r *http.Request)
 		notEligible()
 		return
 	}
-	member, err := c.mainDB.OrgMember(bundle.OrgAddress, oid.Hex())
+	member, err := c.mainDB.CensusMember(bundle.OrgAddress, oid.Hex())
 	if err != nil {
 		notEligible()
 		return
@@ -649,15 +665,21 @@ func (c *CSPHandlers) BundleCheckHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	// Compute the voter weight (1 unless the census is weighted). A zero weight
-	// on a weighted census means the user cannot vote.
-	weight := uint64(1)
-	if census.Weighted {
-		weight = member.Weight
-		if weight == 0 {
-			notEligible()
-			return
-		}
+	// Compute the voter weight (1 unless the census is weighted, plus the votes
+	// delegated to the user). A zero weight on a weighted census, or a vote
+	// delegated to someone else, means the user cannot vote.
+	weight, err := c.mainDB.DelegatedVoterWeight(census, member)
+	if errors.Is(err, db.ErrVoteDelegated) {
+		notEligible()
+		return
+	}
+	if err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return
+	}
+	if weight == 0 {
+		notEligible()
+		return
 	}
 
 	// Optionally report whether the user already voted in the requested process.
@@ -919,47 +941,23 @@ func (c *CSPHandlers) authFirstStep(
 		return nil, errors.ErrGenericInternalServerError.WithErr(err)
 	}
 
-	// Validate request with census information
-	if err := validateAuthRequest(&req, census); err != nil {
-		return nil, err
-	}
-
-	phone, err := db.NewHashedPhone(req.Phone, org)
-	if err != nil {
-		return nil, errors.ErrInvalidData.WithErr(err)
-	}
-
-	// create an empty member and assign the input data where applicable, then
-	// normalize it through the same method that normalized the member at
-	// creation time. Both sides of the login-hash comparison therefore derive
-	// from one definition of the canonical form, and cannot drift apart.
-	inputMember := (&db.OrgMember{
-		OrgAddress:   census.OrgAddress,
-		Name:         req.Name,
-		Surname:      req.Surname,
-		MemberNumber: req.MemberNumber,
-		NationalID:   req.NationalID,
-		BirthDate:    req.BirthDate,
-		Email:        req.Email,
-		Phone:        phone,
-	}).Normalized()
-
 	// Check the participant is in the census
-	censusParticipant, err := c.mainDB.CensusParticipantByLoginHash(*census, *inputMember)
+	censusParticipant, err := c.censusParticipantByAuthRequest(census, org, &req)
 	if err != nil {
-		if errors.Is(err, db.ErrNotFound) {
-			return nil, errors.ErrCensusParticipantNotFound
-		}
-		return nil, errors.ErrGenericInternalServerError.WithErr(err)
+		return nil, err
 	}
 
 	// Fetch the corresponding org member using the participant ID (which is the ObjectID hex string)
-	orgMember, err := c.mainDB.OrgMember(census.OrgAddress, censusParticipant.ParticipantID)
+	orgMember, err := c.mainDB.CensusMember(census.OrgAddress, censusParticipant.ParticipantID)
 	if err != nil {
 		return nil, errors.ErrCensusParticipantNotFound.With("failed to get org member")
 	}
 
-	if census.Weighted && orgMember.Weight == 0 {
+	weight, err := c.mainDB.VoterWeight(census, orgMember)
+	if err != nil {
+		return nil, errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	if weight == 0 {
 		return nil, errors.ErrZeroWeightVoter
 	}
 
@@ -984,6 +982,48 @@ func (c *CSPHandlers) authFirstStep(
 	)
 }
 
+// censusParticipantByAuthRequest validates the member identification fields of req against
+// the census and returns the census participant they identify, or the API error to return:
+// ErrCensusParticipantNotFound when they identify nobody.
+func (c *CSPHandlers) censusParticipantByAuthRequest(
+	census *db.Census,
+	org *db.Organization,
+	req *AuthRequest,
+) (*db.CensusParticipant, error) {
+	if err := validateAuthRequest(req, census); err != nil {
+		return nil, err
+	}
+
+	phone, err := db.NewHashedPhone(req.Phone, org)
+	if err != nil {
+		return nil, errors.ErrInvalidData.WithErr(err)
+	}
+
+	// create an empty member and assign the input data where applicable, then
+	// normalize it through the same method that normalized the member at
+	// creation time. Both sides of the login-hash comparison therefore derive
+	// from one definition of the canonical form, and cannot drift apart.
+	inputMember := (&db.OrgMember{
+		OrgAddress:   census.OrgAddress,
+		Name:         req.Name,
+		Surname:      req.Surname,
+		MemberNumber: req.MemberNumber,
+		NationalID:   req.NationalID,
+		BirthDate:    req.BirthDate,
+		Email:        req.Email,
+		Phone:        phone,
+	}).Normalized()
+
+	participant, err := c.mainDB.CensusParticipantByLoginHash(*census, *inputMember)
+	if err != nil {
+		if errors.Is(err, db.ErrNotFound) {
+			return nil, errors.ErrCensusParticipantNotFound
+		}
+		return nil, errors.ErrGenericInternalServerError.WithErr(err)
+	}
+	return participant, nil
+}
+
 // authSecondStep is the second step of the authentication process. It
 // receives the request and checks the token and the challenge solution
 // against the server data. If the data is valid, it returns the token and
@@ -4,11 +4,14 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"math"
 	"net/http"
+	"slices"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
 	"github.com/go-chi/chi/v5"
+	"github.com/vocdoni/saas-backend/account"
 	"github.com/vocdoni/saas-backend/api/apicommon"
 	"github.com/vocdoni/saas-backend/csp"
 	"github.com/vocdoni/saas-backend/csp/signers"
@@ -213,7 +216,10 @@ func (c *CSPHandlers) ProcessAuthResendHandler(w http.ResponseWriter, r *http.Re
 //	@Description	bound to the process; authorizes the member against the question's eligibility subset
 //	@Description	and consumes the per-election signing slot (a question cannot be signed twice).
 //	@Description	Body: authToken, electionId (the question's on-chain election id) and payload (the voter
-//	@Description	address). tokenR is unused.
+//	@Description	address). tokenR is unused. The ballot weighs the member's weight plus that of the
+//	@Description	delegators eligible for the question; a member who delegated their vote gets 401.
+//	@Description	A conditional question gets 403 unless the ballots the voter cast on chain to the
+//	@Description	questions it depends on satisfy its conditions.
 //	@Tags			processes
 //	@Accept			json
 //	@Produce		json
@@ -222,6 +228,7 @@ func (c *CSPHandlers) ProcessAuthResendHandler(w http.ResponseWriter, r *http.Re
 //	@Success		200			{object}	handlers.AuthResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
 //	@Failure		401			{object}	errors.Error	"Unauthorized, unverified token, election not in process, or member not eligible"
+//	@Failure		403			{object}	errors.Error	"Conditions of the conditional question not met"
 //	@Failure		404			{object}	errors.Error	"Process, census, or user not found"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
 //	@Router			/processes/{processId}/sign [post]
@@ -238,7 +245,7 @@ func (c *CSPHandlers) ProcessSignHandler(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
-	upstreamID, sErr := c.authorizeQuestion(sc, req.ProcessID)
+	upstreamID, weight, sErr := c.authorizeQuestion(sc, req.ProcessID)
 	if sErr != nil {
 		sErr.Write(w)
 		return
@@ -247,22 +254,39 @@ func (c *CSPHandlers) ProcessSignHandler(w http.ResponseWriter, r *http.Request)
 	if !ok {
 		return
 	}
-	c.signAndRespond(w, req.AuthToken, *address, upstreamID, sc.weight)
+	c.signAndRespond(w, req.AuthToken, *address, upstreamID, weight)
 }
 
 // signContext is the part of a ballot signing request that is resolved once per call, from
 // the process named in the path and the auth token in the body: the voting process, the
-// member behind the token and their census weight. A batch signs every ballot under one of
-// these, which is the whole point of the batch endpoint.
+// member behind the token, their census weight and the members who delegated their vote to
+// them. A batch signs every ballot under one of these, which is the whole point of the batch
+// endpoint.
 type signContext struct {
-	process  *db.VotingProcess
-	memberID string
-	weight   internal.HexBytes
+	process    *db.VotingProcess
+	userID     internal.HexBytes
+	memberID   string
+	weight     uint64
+	delegators []db.DelegatedWeight
+}
+
+// questionWeight is the weight the member signs question with: their own, plus that of each
+// delegator eligible for the question. A delegator's vote is lost on a question their delegate
+// is not eligible for, since nobody can sign it.
+func (sc *signContext) questionWeight(question *db.VotingProcessQuestion) internal.HexBytes {
+	weight := sc.weight
+	for _, d := range sc.delegators {
+		if memberEligibleForQuestion(question, d.MemberID) {
+			weight += d.Weight
+		}
+	}
+	return weightBytes(weight)
 }
 
 // resolveSignContext runs the per-request half of a ballot signing request: load the voting
 // process, check the auth token is verified and anchored to it, resolve the org member behind
-// it and compute their census weight. It writes the proper error and returns false on failure.
+// it, refuse them if they delegated their vote and compute their census weight, with the
+// delegations they hold. It writes the proper error and returns false on failure.
 func (c *CSPHandlers) resolveSignContext(
 	w http.ResponseWriter, oid primitive.ObjectID, authToken internal.HexBytes,
 ) (*signContext, bool) {
@@ -303,43 +327,148 @@ func (c *CSPHandlers) resolveSignContext(
 		errors.ErrCensusNotFound.WithErr(err).Write(w)
 		return nil, false
 	}
-	weight := uint64(1)
-	if census.Weighted {
-		if member.Weight == 0 {
-			errors.ErrZeroWeightVoter.Write(w)
+	sc := &signContext{process: vp, userID: auth.UserID, memberID: auth.UserID.String()}
+	if sc.weight, err = c.mainDB.VoterWeight(census, member); err != nil {
+		errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+		return nil, false
+	}
+	total := sc.weight
+	if census.MaxProxies > 0 {
+		// unlike the weight endpoints, which use db.DelegatedVoterWeight, signing needs the
+		// delegators themselves, to weigh each question by who among them is eligible for it.
+		delegate, err := c.mainDB.VoteDelegate(census, sc.memberID)
+		if err != nil {
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
+			return nil, false
+		}
+		if delegate != "" {
+			errors.ErrVoteDelegated.Write(w)
+			return nil, false
+		}
+		if sc.delegators, err = c.mainDB.VoterDelegators(census, sc.memberID); err != nil {
+			errors.ErrGenericInternalServerError.WithErr(err).Write(w)
 			return nil, false
 		}
-		weight = member.Weight
+		for _, d := range sc.delegators {
+			if total > math.MaxUint64-d.Weight {
+				errors.ErrGenericInternalServerError.Withf("delegated weight overflows").Write(w)
+				return nil, false
+			}
+			total += d.Weight
+		}
 	}
-	return &signContext{
-		process:  vp,
-		memberID: auth.UserID.String(),
-		weight:   weightBytes(weight),
-	}, true
+	if total == 0 {
+		errors.ErrZeroWeightVoter.Write(w)
+		return nil, false
+	}
+	return sc, true
 }
 
 // authorizeQuestion runs the per-ballot half of a signing request: resolve the target question
 // by its on-chain election id, verify it belongs to this process and authorize the member
-// against the question's eligibility subset. It returns the question's on-chain election id, or
-// the API error to write back, never both. The batch handler resolves against a preloaded map
-// instead (authorizeLoadedQuestion) to avoid one query per ballot.
+// against the question's eligibility subset. It returns the question's on-chain election id and
+// the weight to sign it with, or the API error to write back, never both. The batch handler
+// resolves against a preloaded map instead (authorizeLoadedQuestion) to avoid one query per
+// ballot.
 func (c *CSPHandlers) authorizeQuestion(
 	sc *signContext, electionID internal.HexBytes,
-) (internal.HexBytes, *errors.Error) {
+) (upstreamID, weight internal.HexBytes, sErr *errors.Error) {
 	// an empty id cannot name an election, and db.QuestionByUpstreamID reports it as
 	// ErrInvalidData, which the branch below would surface to the client as a 500. The message is
 	// field-neutral because the single-sign endpoint sends this same id as "electionId".
 	if len(electionID) == 0 {
-		return nil, errors.Ptr(errors.ErrMalformedBody.With("missing election id"))
+		return nil, nil, errors.Ptr(errors.ErrMalformedBody.With("missing election id"))
 	}
 	question, err := c.mainDB.QuestionByUpstreamID(electionID)
 	if err != nil && !errors.Is(err, db.ErrNotFound) {
-		return nil, errors.Ptr(errors.ErrGenericInternalServerError.WithErr(err))
+		return nil, nil, errors.Ptr(errors.ErrGenericInternalServerError.WithErr(err))
 	}
 	if err != nil {
 		question = nil // not found: authorizeLoadedQuestion folds it into the same 401
 	}
-	return authorizeLoadedQuestion(sc, question)
+	upstreamID, weight, sErr = authorizeLoadedQuestion(sc, question)
+	if sErr != nil || len(question.Conditions) == 0 {
+		return upstreamID, weight, sErr
+	}
+	questions, err := c.mainDB.QuestionsByProcess(sc.process.ID)
+	if err != nil {
+		return nil, nil, errors.Ptr(errors.ErrGenericInternalServerError.WithErr(err))
+	}
+	if sErr := c.checkConditions(sc, question, questions); sErr != nil {
+		return nil, nil, sErr
+	}
+	return upstreamID, weight, nil
+}
+
+// checkConditions checks the conditions of question, one of questions, against the ballots the
+// voter behind sc cast on chain to the questions it depends on. It returns nil when all of them
+// hold, ErrQuestionConditionNotMet when one does not, or the error that kept it from reading a
+// ballot.
+func (c *CSPHandlers) checkConditions(
+	sc *signContext, question *db.VotingProcessQuestion, questions []db.VotingProcessQuestion,
+) *errors.Error {
+	for _, cond := range question.Conditions {
+		var parent *db.VotingProcessQuestion
+		for i := range questions {
+			if questions[i].Order == cond.Question {
+				parent = &questions[i]
+				break
+			}
+		}
+		if parent == nil {
+			return errors.Ptr(errors.ErrQuestionConditionNotMet.Withf("question %d not found", cond.Question))
+		}
+		answer, ok, sErr := c.castAnswer(sc, parent)
+		if sErr != nil {
+			return sErr
+		}
+		if !ok {
+			return errors.Ptr(errors.ErrQuestionConditionNotMet.Withf("question %d not voted yet", cond.Question))
+		}
+		if !slices.Contains(cond.Choices, answer) {
+			return errors.Ptr(errors.ErrQuestionConditionNotMet.Withf("answer to question %d", cond.Question))
+		}
+	}
+	return nil
+}
+
+// castAnswer reads the answer the voter behind sc cast on chain to question. The address the CSP
+// signed the question for gives the nullifier of the ballot, which the chain serves in clear: a
+// question a condition names is singlechoice and never secret until the end
+// (validateQuestionConditions), so its ballot is a single value. ok is false when the voter has
+// no such ballot, because they were never signed one or have not cast it yet.
+func (c *CSPHandlers) castAnswer(
+	sc *signContext, question *db.VotingProcessQuestion,
+) (answer uint32, ok bool, sErr *errors.Error) {
+	if len(question.UpstreamID) == 0 {
+		return 0, false, nil
+	}
+	cspProc, err := c.mainDB.CSPProcessByUserAndProcess(sc.userID, question.UpstreamID)
+	if errors.Is(err, db.ErrTokenNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Ptr(errors.ErrGenericInternalServerError.WithErr(err))
+	}
+	if !cspProc.Used {
+		return 0, false, nil
+	}
+	nullifier := state.GenerateNullifier(common.BytesToAddress(cspProc.UsedAddress), question.UpstreamID)
+	vote, err := c.account.VoteByNullifier(nullifier)
+	if errors.Is(err, account.ErrVoteNotFound) {
+		return 0, false, nil
+	}
+	if err != nil {
+		return 0, false, errors.Ptr(errors.ErrVochainRequestFailed.WithErr(err))
+	}
+	pkg := &state.VotePackage{}
+	if err := json.Unmarshal(vote.VotePackage, pkg); err != nil {
+		return 0, false, errors.Ptr(errors.ErrVochainRequestFailed.Withf("could not decode vote %x: %v", nullifier, err))
+	}
+	if len(pkg.Votes) != 1 || pkg.Votes[0] < 0 || pkg.Votes[0] > math.MaxUint32 {
+		return 0, false, nil
+	}
+	return uint32(pkg.Votes[0]), true, nil
 }
 
 // authorizeLoadedQuestion authorizes one already-loaded question (nil means the election id
@@ -348,17 +477,17 @@ func (c *CSPHandlers) authorizeQuestion(
 // 401 on purpose — a caller learns nothing about elections outside the process it is signing for.
 func authorizeLoadedQuestion(
 	sc *signContext, question *db.VotingProcessQuestion,
-) (internal.HexBytes, *errors.Error) {
+) (upstreamID, weight internal.HexBytes, sErr *errors.Error) {
 	if question == nil || question.ProcessID != sc.process.ID {
-		return nil, errors.Ptr(errors.ErrUnauthorized.Withf("election not found in process"))
+		return nil, nil, errors.Ptr(errors.ErrUnauthorized.Withf("election not found in process"))
 	}
 	if sErr := unvotableElection(sc.process, question); sErr != nil {
-		return nil, sErr
+		return nil, nil, sErr
 	}
 	if !memberEligibleForQuestion(question, sc.memberID) {
-		return nil, errors.Ptr(errors.ErrUnauthorized.Withf("member not eligible for this question"))
+		return nil, nil, errors.Ptr(errors.ErrUnauthorized.Withf("member not eligible for this question"))
 	}
-	return question.UpstreamID, nil
+	return question.UpstreamID, sc.questionWeight(question), nil
 }
 
 // maxSignBatchBodyBytes bounds a POST /processes/{processId}/sign-batch body. One ballot is an
@@ -369,9 +498,11 @@ func authorizeLoadedQuestion(
 const maxSignBatchBodyBytes = db.MaxQuestionsPerProcess*512 + 4<<10
 
 // authorizedBallot is one item of a sign batch that survived the authorization pass: the
-// question's on-chain election id, resolved from the request, and the voter address.
+// question's on-chain election id, resolved from the request, the weight to sign it with and
+// the voter address.
 type authorizedBallot struct {
 	upstreamID internal.HexBytes
+	weight     internal.HexBytes
 	address    internal.HexBytes
 }
 
@@ -383,12 +514,17 @@ type authorizedBallot struct {
 //	@Description	signing them one by one costs a round trip each. This signs them all under a single
 //	@Description	verified auth token. Public endpoint: the token authenticates the voter.
 //	@Description	Each ballot names a question by its on-chain election id (upstreamId, as returned by
-//	@Description	the check and sign-info endpoints) and the voter address to sign for it.
+//	@Description	the check and sign-info endpoints) and the voter address to sign for it. Each ballot
+//	@Description	weighs the member's weight plus that of the delegators eligible for its question; a
+//	@Description	member who delegated their vote gets 401 (ErrVoteDelegated).
 //	@Description	The batch is authorized as a unit and signs nothing on failure — every ballot must
 //	@Description	name an election of this process (else 401) the member is eligible for (else 401),
 //	@Description	carry a 20-byte voter address (else 400), and no election may be repeated (else
-//	@Description	400). Authorization strictly precedes the first signature, so a rejected batch
-//	@Description	consumes nothing.
+//	@Description	400). A conditional question is signed only when the ballots the voter already cast
+//	@Description	on chain to the questions it depends on satisfy its conditions, else the batch gets
+//	@Description	403 (ErrQuestionConditionNotMet): those questions are voted first, in an earlier
+//	@Description	batch, and not along with it. Authorization strictly precedes the first
+//	@Description	signature, so a rejected batch consumes nothing.
 //	@Description	Once authorized, every ballot is signed and the response is always 200 with one
 //	@Description	entry per request item, in order — even if every ballot fails (the request was
 //	@Description	honored; the outcome is per item). Each entry carries a signature and weight, or a
@@ -410,6 +546,7 @@ type authorizedBallot struct {
 //	@Success		200			{object}	handlers.SignBatchResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
 //	@Failure		401			{object}	errors.Error	"Unauthorized, unverified token, election not in process, or member not eligible"
+//	@Failure		403			{object}	errors.Error	"Conditions of a conditional question not met"
 //	@Failure		404			{object}	errors.Error	"Census or user not found"
 //	@Failure		413			{object}	errors.Error	"Request body too large"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
@@ -475,7 +612,7 @@ func (c *CSPHandlers) ProcessSignBatchHandler(w http.ResponseWriter, r *http.Req
 			errors.ErrMalformedBody.With("missing election id").Withf("at index %d", i).Write(w)
 			return
 		}
-		upstreamID, sErr := authorizeLoadedQuestion(sc, byUpstream[string(item.UpstreamID)])
+		upstreamID, weight, sErr := authorizeLoadedQuestion(sc, byUpstream[string(item.UpstreamID)])
 		if sErr != nil {
 			sErr.Withf("at index %d", i).Write(w)
 			return
@@ -484,7 +621,14 @@ func (c *CSPHandlers) ProcessSignBatchHandler(w http.ResponseWriter, r *http.Req
 			sErr.Withf("at index %d", i).Write(w)
 			return
 		}
-		ballots[i] = authorizedBallot{upstreamID: upstreamID, address: item.Address}
+		// a conditional question is checked last, as it reads the chain
+		if question := byUpstream[string(item.UpstreamID)]; len(question.Conditions) > 0 {
+			if sErr := c.checkConditions(sc, question, questions); sErr != nil {
+				sErr.Withf("at index %d", i).Write(w)
+				return
+			}
+		}
+		ballots[i] = authorizedBallot{upstreamID: upstreamID, weight: weight, address: item.Address}
 	}
 
 	// ponytail: signed sequentially. db.ConsumeCSPProcess takes the storage write lock, so a
@@ -494,7 +638,7 @@ func (c *CSPHandlers) ProcessSignBatchHandler(w http.ResponseWriter, r *http.Req
 	for i, b := range ballots {
 		res := &resp.Signatures[i]
 		res.UpstreamID = b.upstreamID
-		signature, err := c.csp.Sign(req.AuthToken, b.address, b.upstreamID, sc.weight,
+		signature, err := c.csp.Sign(req.AuthToken, b.address, b.upstreamID, b.weight,
 			signers.SignerTypeECDSASalted)
 		if err != nil {
 			// usually per-ballot: this election's signing slot is spent, or a concurrent
@@ -515,7 +659,7 @@ func (c *CSPHandlers) ProcessSignBatchHandler(w http.ResponseWriter, r *http.Req
 			continue
 		}
 		res.Signature = signature
-		res.Weight = sc.weight
+		res.Weight = b.weight
 	}
 	apicommon.HTTPWriteJSON(w, resp)
 }
@@ -589,8 +733,10 @@ func parseSignBatchRequest(w http.ResponseWriter, r *http.Request) (*SignBatchRe
 // ProcessWeightHandler godoc
 //
 //	@Summary		Get a voter's weight for a voting process
-//	@Description	Return the voter's weight for a voting process. Requires a verified token bound to the
-//	@Description	process.
+//	@Description	Return the voter's weight for a voting process, including the votes delegated to them:
+//	@Description	a question signs with the part of it that is eligible for that question. A voter who
+//	@Description	delegated their vote gets 401 (ErrVoteDelegated). Requires a verified token bound to
+//	@Description	the process.
 //	@Tags			processes
 //	@Accept			json
 //	@Produce		json
@@ -598,7 +744,7 @@ func parseSignBatchRequest(w http.ResponseWriter, r *http.Request) (*SignBatchRe
 //	@Param			request		body		handlers.UserWeightRequest	true	"Request with auth token"
 //	@Success		200			{object}	handlers.UserWeightResponse
 //	@Failure		400			{object}	errors.Error	"Invalid input data"
-//	@Failure		401			{object}	errors.Error	"Invalid token, token not verified, or token not belonging to the process"
+//	@Failure		401			{object}	errors.Error	"Invalid or unverified token, token of another process, or vote delegated"
 //	@Failure		404			{object}	errors.Error	"Process, user, or census not found"
 //	@Failure		500			{object}	errors.Error	"Internal server error"
 //	@Router			/processes/{processId}/weight [post]
@@ -637,9 +783,10 @@ func (c *CSPHandlers) ProcessWeightHandler(w http.ResponseWriter, r *http.Reques
 		errors.ErrCensusNotFound.WithErr(err).Write(w)
 		return
 	}
-	weight := uint64(1)
-	if census.Weighted {
-		weight = member.Weight
+	weight, err := c.mainDB.DelegatedVoterWeight(census, member)
+	if err != nil {
+		writeWeightError(w, err)
+		return
 	}
 	apicommon.HTTPWriteJSON(w, &UserWeightResponse{Weight: weightBytes(weight)})
 }
@@ -650,7 +797,8 @@ func (c *CSPHandlers) ProcessWeightHandler(w http.ResponseWriter, r *http.Reques
 //	@Description	Report the voter's status for a voting process: census membership, weight, and per
 //	@Description	question eligibility and vote status. The voter is identified solely by the auth token
 //	@Description	(the only voter data the client stores); the token must be verified and issued for this
-//	@Description	process. Ineligibility is reported as belongsToProcess=false with HTTP 200, not an error.
+//	@Description	process. Ineligibility is reported as belongsToProcess=false with HTTP 200, not an error;
+//	@Description	a voter who delegated their vote gets delegated=true and cannot vote any question.
 //	@Tags			processes
 //	@Accept			json
 //	@Produce		json
@@ -692,10 +840,16 @@ func (c *CSPHandlers) ProcessCheckHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 	if member, err := c.orgMember(vp.OrgAddress, auth); err == nil {
-		census, cErr := c.mainDB.Census(vp.CensusID.Hex())
 		weight := uint64(1)
-		if cErr == nil && census.Weighted {
-			weight = member.Weight
+		if census, cErr := c.mainDB.Census(vp.CensusID.Hex()); cErr == nil {
+			vw, wErr := c.mainDB.DelegatedVoterWeight(census, member)
+			switch {
+			case errors.Is(wErr, db.ErrVoteDelegated):
+				resp.Delegated = true
+				weight = 0
+			case wErr == nil:
+				weight = vw
+			}
 		}
 		resp.Weight = weightBytes(weight)
 	}
@@ -710,8 +864,9 @@ func (c *CSPHandlers) ProcessCheckHandler(w http.ResponseWriter, r *http.Request
 			QuestionID: q.ID.Hex(),
 			UpstreamID: q.UpstreamID,
 			// a voter can only vote a question if they are a participant of the process
-			// census AND fall within the question's eligibility subset
-			CanVote: resp.BelongsToProcess && memberEligibleForQuestion(q, memberID),
+			// census, have not delegated their vote AND fall within the question's
+			// eligibility subset
+			CanVote: resp.BelongsToProcess && !resp.Delegated && memberEligibleForQuestion(q, memberID),
 		}
 		if len(q.UpstreamID) > 0 {
 			if cspProc, err := c.mainDB.CSPProcessByUserAndProcess(auth.UserID, q.UpstreamID); err == nil {
@@ -736,13 +891,14 @@ func (c *CSPHandlers) orgMemberFromAuth(
 	return member, true
 }
 
-// orgMember resolves the org member referenced by an auth token (member ObjectID hex).
+// orgMember resolves the org member, or ephemeral census member, referenced by an auth token
+// (member ObjectID hex).
 func (c *CSPHandlers) orgMember(orgAddress common.Address, auth *db.CSPAuth) (*db.OrgMember, error) {
 	oid, err := primitive.ObjectIDFromHex(auth.UserID.String())
 	if err != nil {
 		return nil, fmt.Errorf("invalid user ID in token: %w", err)
 	}
-	return c.mainDB.OrgMember(orgAddress, oid.Hex())
+	return c.mainDB.CensusMember(orgAddress, oid.Hex())
 }
 
 // orgNameAndLogo returns the organization display name and logo, falling back to defaults.
@@ -97,6 +97,13 @@ type UserWeightRequest struct {
 	AuthToken internal.HexBytes `json:"authToken" swaggertype:"string" format:"hex" example:"deadbeef"`
 }
 
+// DelegationRequest is the body of a voter's delegation request: their auth token and, to
+// delegate, the identification of the census participant taking their vote.
+type DelegationRequest struct {
+	AuthToken internal.HexBytes `json:"authToken" swaggertype:"string" format:"hex" example:"deadbeef"`
+	Delegate  *AuthRequest      `json:"delegate,omitempty"`
+}
+
 // USerWeightResponse defines the payload for the response to the
 // request to get the weight of a user for a given bundle. It includes
 // the weight of the user.
@@ -126,10 +133,12 @@ type CheckMembershipResponse struct {
 
 // ProcessCheckResponse is the voter status/eligibility response of the new /processes
 // flow. BelongsToProcess reports whether the token's member is in the process census;
-// Weight is the member weight; Questions carries per-question eligibility and vote status.
+// Weight is the member weight, with the votes delegated to them; Delegated reports the member
+// delegated their own vote; Questions carries per-question eligibility and vote status.
 type ProcessCheckResponse struct {
 	BelongsToProcess bool                    `json:"belongsToProcess"`
 	Weight           internal.HexBytes       `json:"weight,omitempty" swaggertype:"string" format:"hex" example:"2a"`
+	Delegated        bool                    `json:"delegated,omitempty"`
 	Questions        []ProcessQuestionStatus `json:"questions"`
 }
 
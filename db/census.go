@@ -71,9 +71,12 @@ func (ms *MongoStorage) PopulateGroupCensus(
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
 
-	if census.OrgAddress.Cmp(common.Address{}) == 0 {
+	if census.OrgAddress.Cmp(common.Address{}) == 0 || census.Ephemeral {
 		return 0, ErrInvalidData
 	}
+	if census.Frozen != nil {
+		return 0, ErrCensusFrozen
+	}
 
 	ms.keysLock.Lock()
 	defer ms.keysLock.Unlock()
@@ -134,7 +137,7 @@ func (ms *MongoStorage) PopulateGroupCensus(
 	return census.Size, nil
 }
 
-// DeleteCensus removes a census and all its members
+// DeleteCensus removes a census, all its members, its snapshots, its exports and its delegations
 func (ms *MongoStorage) DelCensus(censusID string) error {
 	objID, err := primitive.ObjectIDFromHex(censusID)
 	if err != nil {
@@ -152,6 +155,22 @@ func (ms *MongoStorage) DelCensus(censusID string) error {
 	if _, err := ms.censusParticipants.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil { //nolint:goconst
 		return fmt.Errorf("failed to delete census participants: %w", err)
 	}
+	// the snapshots describe the census, so they go with it
+	if _, err := ms.censusSnapshotParticipants.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete census snapshot participants: %w", err)
+	}
+	if _, err := ms.censusSnapshots.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete census snapshots: %w", err)
+	}
+	if _, err := ms.censusExports.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete census exports: %w", err)
+	}
+	if _, err := ms.ephemeralMembers.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete ephemeral members: %w", err)
+	}
+	if _, err := ms.censusDelegations.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete census delegations: %w", err)
+	}
 	// delete the census from the database using the ID
 	if _, err := ms.censuses.DeleteOne(ctx, bson.M{"_id": objID}); err != nil {
 		return fmt.Errorf("failed to delete census: %w", err)
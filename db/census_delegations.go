@@ -0,0 +1,279 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// Sources of a census delegation.
+const (
+	DelegationSourceManager = "manager"
+	DelegationSourceMember  = "member"
+)
+
+// DelegationStartGrace locks the delegations of a census this long before a process using it
+// starts, matching the grace the CSP gives voters arriving on the start boundary: once a voter
+// may be signed for, the weights they sign with must no longer move.
+const DelegationStartGrace = time.Minute
+
+// CensusDelegation hands the vote of one census participant, the delegator, to another, the
+// delegate, who then votes with both weights. A participant delegates to one delegate at most,
+// and neither a delegator can hold proxies nor a delegate delegate in turn.
+type CensusDelegation struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	CensusID    string             `json:"censusId" bson:"censusId"`
+	DelegatorID string             `json:"delegatorId" bson:"delegatorId"`
+	DelegateID  string             `json:"delegateId" bson:"delegateId"`
+	Source      string             `json:"source" bson:"source"`
+	CreatedAt   time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// DelegatedWeight is the weight one delegator hands to its delegate.
+type DelegatedWeight struct {
+	MemberID string `json:"memberId"`
+	Weight   uint64 `json:"weight"`
+}
+
+// SetCensusDelegation delegates the vote of delegatorID to delegateID in census, replacing any
+// delegation of delegatorID, and returns it. Both must be participants of the census, which
+// must allow proxies (MaxProxies) and not be used by a process that started.
+func (ms *MongoStorage) SetCensusDelegation(census *Census, delegatorID, delegateID, source string) (*CensusDelegation, error) {
+	if census == nil || census.ID.IsZero() || delegatorID == "" || delegateID == "" {
+		return nil, ErrInvalidData
+	}
+	if delegatorID == delegateID {
+		return nil, fmt.Errorf("%w: a member cannot delegate to themselves", ErrInvalidData)
+	}
+	if census.MaxProxies <= 0 {
+		return nil, ErrDelegationNotAllowed
+	}
+	censusID := census.ID.Hex()
+	for _, id := range []string{delegatorID, delegateID} {
+		if _, err := ms.CensusParticipant(censusID, id); err != nil {
+			return nil, err
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// the checks and the write run under the lock, so two requests cannot both take the last
+	// proxy of a delegate nor build a chain between them.
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	if err := ms.checkDelegationsUnlocked(ctx, census); err != nil {
+		return nil, err
+	}
+	delegating, err := ms.censusDelegations.CountDocuments(ctx, bson.M{"censusId": censusID, "delegatorId": delegateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count delegations: %w", err)
+	}
+	if delegating > 0 {
+		return nil, fmt.Errorf("%w: the delegate has delegated their own vote", ErrInvalidData)
+	}
+	proxies, err := ms.censusDelegations.CountDocuments(ctx, bson.M{"censusId": censusID, "delegateId": delegatorID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count delegations: %w", err)
+	}
+	if proxies > 0 {
+		return nil, fmt.Errorf("%w: the delegator holds proxies", ErrInvalidData)
+	}
+	held, err := ms.censusDelegations.CountDocuments(ctx, bson.M{
+		"censusId": censusID, "delegateId": delegateID, "delegatorId": bson.M{"$ne": delegatorID},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count delegations: %w", err)
+	}
+	if held >= int64(census.MaxProxies) {
+		return nil, ErrProxyLimitReached
+	}
+
+	delegation := &CensusDelegation{
+		ID:          primitive.NewObjectID(),
+		CensusID:    censusID,
+		DelegatorID: delegatorID,
+		DelegateID:  delegateID,
+		Source:      source,
+		CreatedAt:   time.Now(),
+	}
+	filter := bson.M{"censusId": censusID, "delegatorId": delegatorID}
+	if _, err := ms.censusDelegations.DeleteOne(ctx, filter); err != nil {
+		return nil, fmt.Errorf("failed to replace delegation: %w", err)
+	}
+	if _, err := ms.censusDelegations.InsertOne(ctx, delegation); err != nil {
+		return nil, fmt.Errorf("failed to create delegation: %w", err)
+	}
+	return delegation, nil
+}
+
+// DeleteCensusDelegation revokes the delegation of delegatorID in census, unless a process
+// using the census started. Returns ErrNotFound when the member had not delegated.
+func (ms *MongoStorage) DeleteCensusDelegation(census *Census, delegatorID string) error {
+	if census == nil || census.ID.IsZero() || delegatorID == "" {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	if err := ms.checkDelegationsUnlocked(ctx, census); err != nil {
+		return err
+	}
+	result, err := ms.censusDelegations.DeleteOne(ctx, bson.M{"censusId": census.ID.Hex(), "delegatorId": delegatorID})
+	if err != nil {
+		return fmt.Errorf("failed to delete delegation: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// CensusDelegations returns the delegations of a census, oldest first.
+func (ms *MongoStorage) CensusDelegations(censusID string) ([]CensusDelegation, error) {
+	if censusID == "" {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cursor, err := ms.censusDelegations.Find(ctx, bson.M{"censusId": censusID},
+		options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get census delegations: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	delegations := []CensusDelegation{}
+	if err := cursor.All(ctx, &delegations); err != nil {
+		return nil, fmt.Errorf("failed to parse census delegations: %w", err)
+	}
+	return delegations, nil
+}
+
+// VoteDelegate returns the member memberID delegated their vote to in census, or "" when they
+// did not. A delegation to a member who has since left the census no longer counts, so its
+// delegator votes again.
+func (ms *MongoStorage) VoteDelegate(census *Census, memberID string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	delegation := &CensusDelegation{}
+	err := ms.censusDelegations.FindOne(ctx, bson.M{"censusId": census.ID.Hex(), "delegatorId": memberID}).Decode(delegation)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get delegation: %w", err)
+	}
+	if _, err := ms.CensusParticipant(census.ID.Hex(), delegation.DelegateID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", nil
+		}
+		return "", err
+	}
+	return delegation.DelegateID, nil
+}
+
+// VoterDelegators returns the members who delegated their vote to delegateID in census, with
+// the weight each of them hands over. Delegators who have since left the census are skipped.
+func (ms *MongoStorage) VoterDelegators(census *Census, delegateID string) ([]DelegatedWeight, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	cursor, err := ms.censusDelegations.Find(ctx, bson.M{"censusId": census.ID.Hex(), "delegateId": delegateID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delegations: %w", err)
+	}
+	var delegations []CensusDelegation
+	if err := cursor.All(ctx, &delegations); err != nil {
+		return nil, fmt.Errorf("failed to parse delegations: %w", err)
+	}
+	var delegators []DelegatedWeight
+	for _, d := range delegations {
+		if _, err := ms.CensusParticipant(census.ID.Hex(), d.DelegatorID); err != nil {
+			if errors.Is(err, ErrNotFound) {
+				continue
+			}
+			return nil, err
+		}
+		weight := uint64(1)
+		if census.Weighted {
+			member, err := ms.CensusMember(census.OrgAddress, d.DelegatorID)
+			if err != nil {
+				if errors.Is(err, ErrNotFound) {
+					continue
+				}
+				return nil, err
+			}
+			if weight, err = ms.VoterWeight(census, member); err != nil {
+				return nil, err
+			}
+		}
+		delegators = append(delegators, DelegatedWeight{MemberID: d.DelegatorID, Weight: weight})
+	}
+	return delegators, nil
+}
+
+// DelegatedVoterWeight returns the weight member votes with in census once delegations are
+// accounted for: their own VoterWeight plus the weight of every member who delegated to them.
+// Returns ErrVoteDelegated when member delegated their vote.
+func (ms *MongoStorage) DelegatedVoterWeight(census *Census, member *OrgMember) (uint64, error) {
+	weight, err := ms.VoterWeight(census, member)
+	if err != nil {
+		return 0, err
+	}
+	if census.MaxProxies <= 0 {
+		return weight, nil
+	}
+	delegate, err := ms.VoteDelegate(census, member.ID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	if delegate != "" {
+		return 0, ErrVoteDelegated
+	}
+	delegators, err := ms.VoterDelegators(census, member.ID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	for _, d := range delegators {
+		if weight > math.MaxUint64-d.Weight {
+			return 0, fmt.Errorf("%w: delegated weight overflows", ErrInvalidData)
+		}
+		weight += d.Weight
+	}
+	return weight, nil
+}
+
+// checkDelegationsUnlocked returns ErrDelegationsLocked when a published process using census
+// starts within DelegationStartGrace, or already started.
+func (ms *MongoStorage) checkDelegationsUnlocked(ctx context.Context, census *Census) error {
+	started, err := ms.votingProcesses.CountDocuments(ctx, bson.M{
+		"censusId":  census.ID,
+		"published": true,
+		"$or": bson.A{
+			bson.M{"startDate": bson.M{"$exists": false}},
+			bson.M{"startDate": bson.M{"$lte": time.Now().Add(DelegationStartGrace)}},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to check the census processes: %w", err)
+	}
+	if started > 0 {
+		return ErrDelegationsLocked
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCensusDelegations(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true, MaxProxies: 2,
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	members := make([]*OrgMember, 0, 5)
+	for i := range 5 {
+		member := &OrgMember{ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, Weight: uint64(i + 1)}
+		_, err := testDB.orgMembers.InsertOne(ctx, member)
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{ParticipantID: member.ID.Hex(), CensusID: censusID})
+		c.Assert(err, qt.IsNil)
+		members = append(members, member)
+	}
+	id := func(i int) string { return members[i].ID.Hex() }
+
+	// the first member holds the votes of the second and third
+	_, err = testDB.SetCensusDelegation(census, id(1), id(0), DelegationSourceManager)
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.SetCensusDelegation(census, id(2), id(0), DelegationSourceMember)
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.SetCensusDelegation(census, id(3), id(0), DelegationSourceMember)
+	c.Assert(err, qt.ErrorIs, ErrProxyLimitReached)
+	// no chains, nor delegating to oneself or outside the census
+	_, err = testDB.SetCensusDelegation(census, id(3), id(1), DelegationSourceMember)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	_, err = testDB.SetCensusDelegation(census, id(0), id(3), DelegationSourceMember)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	_, err = testDB.SetCensusDelegation(census, id(3), id(3), DelegationSourceMember)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	_, err = testDB.SetCensusDelegation(census, id(3), primitive.NewObjectID().Hex(), DelegationSourceMember)
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+
+	weight, err := testDB.DelegatedVoterWeight(census, members[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(1+2+3))
+	_, err = testDB.DelegatedVoterWeight(census, members[1])
+	c.Assert(err, qt.ErrorIs, ErrVoteDelegated)
+
+	// moving a delegation frees the proxy it held
+	_, err = testDB.SetCensusDelegation(census, id(2), id(4), DelegationSourceMember)
+	c.Assert(err, qt.IsNil)
+	delegations, err := testDB.CensusDelegations(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(delegations, qt.HasLen, 2)
+	weight, err = testDB.DelegatedVoterWeight(census, members[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(1+2))
+
+	// a delegator who left the census hands nothing over
+	c.Assert(testDB.DelCensusParticipant(censusID, id(1)), qt.IsNil)
+	weight, err = testDB.DelegatedVoterWeight(census, members[0])
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(1))
+
+	c.Assert(testDB.DeleteCensusDelegation(census, id(2)), qt.IsNil)
+	c.Assert(testDB.DeleteCensusDelegation(census, id(2)), qt.ErrorIs, ErrNotFound)
+
+	// once a process using the census started, delegations are locked
+	_, err = testDB.votingProcesses.InsertOne(ctx, &VotingProcess{
+		ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, CensusID: census.ID,
+		Published: true, StartDate: time.Now().Add(DelegationStartGrace / 2),
+	})
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.SetCensusDelegation(census, id(3), id(0), DelegationSourceManager)
+	c.Assert(err, qt.ErrorIs, ErrDelegationsLocked)
+	c.Assert(testDB.DeleteCensusDelegation(census, id(1)), qt.ErrorIs, ErrDelegationsLocked)
+
+	_, err = testDB.SetCensusDelegation(&Census{ID: census.ID}, id(3), id(0), DelegationSourceManager)
+	c.Assert(err, qt.ErrorIs, ErrDelegationNotAllowed)
+}
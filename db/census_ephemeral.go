@@ -0,0 +1,287 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// ephemeralInsertBatchSize caps the documents written per InsertMany of an ephemeral import.
+const ephemeralInsertBatchSize = 1000
+
+// ephemeralMember is a participant of an ephemeral census, stored with the member data it logs in
+// with apart from the organization's members.
+type ephemeralMember struct {
+	OrgMember `bson:",inline"`
+	CensusID  string `bson:"censusId"`
+}
+
+// missingCensusField returns the first field census authenticates with that member lacks, or "".
+// A census asking for both the email and the phone needs either, as the member picks one.
+func missingCensusField(census *Census, member *OrgMember) string {
+	for _, field := range census.AuthFields {
+		var value string
+		switch field {
+		case OrgMemberAuthFieldsName:
+			value = member.Name
+		case OrgMemberAuthFieldsSurname:
+			value = member.Surname
+		case OrgMemberAuthFieldsMemberNumber:
+			value = member.MemberNumber
+		case OrgMemberAuthFieldsNationalID:
+			value = member.NationalID
+		case OrgMemberAuthFieldsBirthDate:
+			value = member.BirthDate
+		default:
+			continue
+		}
+		if value == "" {
+			return string(field)
+		}
+	}
+	hasEmail, hasPhone := member.Email != "", !member.Phone.IsEmpty()
+	switch {
+	case len(census.TwoFaFields) == 2 && !hasEmail && !hasPhone:
+		return "email or phone"
+	case len(census.TwoFaFields) == 1 && census.TwoFaFields[0] == OrgMemberTwoFaFieldEmail && !hasEmail:
+		return string(OrgMemberTwoFaFieldEmail)
+	case len(census.TwoFaFields) == 1 && census.TwoFaFields[0] == OrgMemberTwoFaFieldPhone && !hasPhone:
+		return string(OrgMemberTwoFaFieldPhone)
+	}
+	return ""
+}
+
+// SetEphemeralCensusParticipants replaces the participants of an ephemeral census with members,
+// which do not join the organization, and returns how many it stored. In a weighted census a
+// member weighs what the census WeightRule gives it, or else its Weight.
+//
+// It is all or nothing: when a member is invalid, lacks a field the census authenticates with, or
+// would log in like another one, nothing is written and the errors are returned, each prefixed
+// with "line N:", the 1-based position of the member.
+func (ms *MongoStorage) SetEphemeralCensusParticipants(census *Census, members []*OrgMember) (int, []error, error) {
+	if census == nil || census.ID.IsZero() || !census.Ephemeral || !census.PurgedAt.IsZero() {
+		return 0, nil, ErrInvalidData
+	}
+	if census.Frozen != nil {
+		return 0, nil, ErrCensusFrozen
+	}
+	if len(census.AuthFields)+len(census.TwoFaFields) == 0 {
+		return 0, nil, fmt.Errorf("%w: census has no auth fields nor twoFa fields", ErrInvalidData)
+	}
+	org, err := ms.Organization(census.OrgAddress)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get organization: %w", err)
+	}
+	var weigh func(*OrgMember) (uint64, error)
+	if census.Weighted && census.WeightRule != nil {
+		if weigh, err = census.WeightRule.weigher(); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	censusID := census.ID.Hex()
+	now := time.Now()
+	var errs []error
+	seen := make(map[string]int, len(members))
+	var totalWeight uint64
+	memberDocs := make([]any, 0, len(members))
+	participantDocs := make([]any, 0, len(members))
+	for i, m := range members {
+		line := i + 1
+		member, memberErrs := prepareOrgMember(org, m, "", now)
+		if len(memberErrs) > 0 {
+			for _, err := range memberErrs {
+				errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+			}
+			continue
+		}
+		if field := missingCensusField(census, member); field != "" {
+			errs = append(errs, fmt.Errorf("line %d: missing %s", line, field))
+			continue
+		}
+		hashes := calculateParticipantHashesBson(*census, *member)
+		duplicate := false
+		for key, hash := range hashes {
+			seenKey := key + "/" + string(hash.([]byte))
+			if first, ok := seen[seenKey]; ok {
+				errs = append(errs, fmt.Errorf("line %d: %w of line %d", line, ErrUpdateWouldCreateDuplicates, first))
+				duplicate = true
+				break
+			}
+			seen[seenKey] = line
+		}
+		if duplicate {
+			continue
+		}
+
+		participant := bson.M{
+			"participantID": member.ID.Hex(),
+			"censusId":      censusID,
+			"createdAt":     now,
+			"updatedAt":     now,
+		}
+		for key, hash := range hashes {
+			participant[key] = hash
+		}
+		if census.Weighted {
+			// stored on the participant too, as the census total weight cannot join it to a member
+			weight := member.Weight
+			if weigh != nil {
+				if weight, err = weigh(member); err != nil {
+					errs = append(errs, fmt.Errorf("line %d: %w", line, err))
+					continue
+				}
+			}
+			if weight > math.MaxInt64-totalWeight {
+				errs = append(errs, fmt.Errorf("line %d: %w: census total weight overflow", line, ErrInvalidData))
+				continue
+			}
+			totalWeight += weight
+			participant["weight"] = weight
+		}
+		memberDocs = append(memberDocs, &ephemeralMember{OrgMember: *member, CensusID: censusID})
+		participantDocs = append(participantDocs, participant)
+	}
+	if len(errs) > 0 {
+		return 0, errs, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	if err := ms.deleteEphemeralParticipants(ctx, censusID); err != nil {
+		return 0, nil, err
+	}
+	for _, batch := range []struct {
+		collection *mongo.Collection
+		docs       []any
+	}{{ms.ephemeralMembers, memberDocs}, {ms.censusParticipants, participantDocs}} {
+		for start := 0; start < len(batch.docs); start += ephemeralInsertBatchSize {
+			end := min(start+ephemeralInsertBatchSize, len(batch.docs))
+			if _, err := batch.collection.InsertMany(ctx, batch.docs[start:end]); err != nil {
+				// leave the census empty rather than half imported
+				if cleanupErr := ms.deleteEphemeralParticipants(ctx, censusID); cleanupErr != nil {
+					log.Warnw("could not clean up ephemeral census import", "census", censusID, "error", cleanupErr)
+				}
+				return 0, nil, fmt.Errorf("failed to import ephemeral participants: %w", err)
+			}
+		}
+	}
+	return len(memberDocs), nil, nil
+}
+
+// deleteEphemeralParticipants deletes the participants of an ephemeral census and their member
+// data. The caller holds keysLock.
+func (ms *MongoStorage) deleteEphemeralParticipants(ctx context.Context, censusID string) error {
+	if _, err := ms.censusParticipants.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete ephemeral census participants: %w", err)
+	}
+	if _, err := ms.ephemeralMembers.DeleteMany(ctx, bson.M{"censusId": censusID}); err != nil {
+		return fmt.Errorf("failed to delete ephemeral members: %w", err)
+	}
+	return nil
+}
+
+// CensusMember returns the member a census participant stands for: the organization member, or
+// the participant of an ephemeral census of the organization. It fails like OrgMember when
+// neither exists.
+func (ms *MongoStorage) CensusMember(orgAddress common.Address, id string) (*OrgMember, error) {
+	member, err := ms.OrgMember(orgAddress, id)
+	if !errors.Is(err, ErrNotFound) {
+		return member, err
+	}
+	objID, idErr := primitive.ObjectIDFromHex(id)
+	if idErr != nil {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ephemeral := &ephemeralMember{}
+	filter := bson.M{"_id": objID, "orgAddress": orgAddress}
+	if findErr := ms.ephemeralMembers.FindOne(ctx, filter).Decode(ephemeral); findErr != nil {
+		if errors.Is(findErr, mongo.ErrNoDocuments) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("failed to get ephemeral member: %w", findErr)
+	}
+	return &ephemeral.OrgMember, nil
+}
+
+// PurgeEndedEphemeralCensuses deletes the participants of the ephemeral censuses whose voting
+// processes all ended before now, with their member data, stamps the censuses PurgedAt and
+// returns how many it purged. A process has ended once it was published, its end date has passed
+// and none of its questions accepts votes any more; a census no process uses is kept.
+func (ms *MongoStorage) PurgeEndedEphemeralCensuses(now time.Time) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	candidates, err := ms.censuses.Distinct(ctx, "_id", bson.M{
+		"ephemeral": true,
+		"purgedAt":  bson.M{"$exists": false},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find ephemeral censuses: %w", err)
+	}
+	if len(candidates) == 0 {
+		return 0, nil
+	}
+	ongoing, err := ms.processesQuestions.Distinct(ctx, "processId", bson.M{
+		"status": bson.M{"$in": []string{QuestionStatusReady, QuestionStatusPaused}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find ongoing processes: %w", err)
+	}
+	ongoingIDs := make(map[primitive.ObjectID]bool, len(ongoing))
+	for _, id := range ongoing {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			ongoingIDs[oid] = true
+		}
+	}
+	cursor, err := ms.votingProcesses.Find(ctx, bson.M{"censusId": bson.M{"$in": candidates}},
+		options.Find().SetProjection(bson.M{"censusId": 1, "published": 1, "endDate": 1}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find the processes of ephemeral censuses: %w", err)
+	}
+	var processes []VotingProcess
+	if err := cursor.All(ctx, &processes); err != nil {
+		return 0, fmt.Errorf("failed to decode the processes of ephemeral censuses: %w", err)
+	}
+	// a census is ended when it has processes and all of them ended
+	ended := make(map[primitive.ObjectID]bool)
+	for _, p := range processes {
+		done := p.Published && !p.EndDate.IsZero() && p.EndDate.Before(now) && !ongoingIDs[p.ID]
+		if previous, ok := ended[p.CensusID]; ok {
+			done = done && previous
+		}
+		ended[p.CensusID] = done
+	}
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	var purged int64
+	for censusID, done := range ended {
+		if !done {
+			continue
+		}
+		if err := ms.deleteEphemeralParticipants(ctx, censusID.Hex()); err != nil {
+			return purged, err
+		}
+		if _, err := ms.censuses.UpdateOne(ctx, bson.M{"_id": censusID},
+			bson.M{"$set": bson.M{"purgedAt": now}}); err != nil {
+			return purged, fmt.Errorf("failed to mark ephemeral census purged: %w", err)
+		}
+		purged++
+	}
+	return purged, nil
+}
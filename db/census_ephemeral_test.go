@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEphemeralCensus(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Ephemeral: true, Weighted: true,
+		AuthFields:  OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+
+	// invalid, incomplete and duplicate rows reject the whole import
+	added, rowErrs, err := testDB.SetEphemeralCensusParticipants(census, []*OrgMember{
+		{MemberNumber: "1", Email: "one@example.com", Weight: 2},
+		{MemberNumber: "2"},
+		{MemberNumber: "1", Email: "ONE@example.com"},
+		{MemberNumber: "3", Email: "not an email"},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(added, qt.Equals, 0)
+	c.Assert(rowErrs, qt.HasLen, 3)
+	c.Assert(rowErrs[0], qt.ErrorMatches, "line 2: missing email")
+	c.Assert(rowErrs[1], qt.ErrorIs, ErrUpdateWouldCreateDuplicates)
+	count, err := testDB.CountCensusParticipants(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(0))
+
+	added, rowErrs, err = testDB.SetEphemeralCensusParticipants(census, []*OrgMember{
+		{MemberNumber: "1", Email: "one@example.com", Weight: 2},
+		{MemberNumber: "2", Email: "two@example.com", Weight: 3},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(rowErrs, qt.HasLen, 0)
+	c.Assert(added, qt.Equals, 2)
+	total, err := testDB.CensusTotalWeight(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(5))
+
+	// the CSP finds the participant by its login and resolves it without an org member
+	participant, err := testDB.CensusParticipantByLoginHash(*census,
+		OrgMember{MemberNumber: "2", Email: "two@example.com"})
+	c.Assert(err, qt.IsNil)
+	member, err := testDB.CensusMember(testOrgAddress, participant.ParticipantID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.Email, qt.Equals, "two@example.com")
+	_, err = testDB.OrgMember(testOrgAddress, participant.ParticipantID)
+	c.Assert(err, qt.IsNotNil)
+	_, _, err = testDB.AddCensusParticipantsByMemberIDs(censusID, []string{primitive.NewObjectID().Hex()})
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+
+	// purged only once every process of the census is published, past its end and not voting
+	newProcess := func(published bool, endDate time.Time, status string) {
+		id := primitive.NewObjectID()
+		_, err := testDB.votingProcesses.InsertOne(ctx, &VotingProcess{
+			ID: id, OrgAddress: testOrgAddress, Published: published, EndDate: endDate, CensusID: census.ID,
+		})
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.processesQuestions.InsertOne(ctx, &VotingProcessQuestion{
+			ProcessID: id, OrgAddress: testOrgAddress, Status: status,
+		})
+		c.Assert(err, qt.IsNil)
+	}
+	now := time.Now()
+	purged, err := testDB.PurgeEndedEphemeralCensuses(now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(purged, qt.Equals, int64(0))
+
+	newProcess(true, now.Add(-time.Hour), QuestionStatusEnded)
+	newProcess(true, now.Add(-time.Hour), QuestionStatusPaused)
+	purged, err = testDB.PurgeEndedEphemeralCensuses(now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(purged, qt.Equals, int64(0))
+
+	_, err = testDB.processesQuestions.UpdateMany(ctx, bson.M{},
+		bson.M{"$set": bson.M{"status": QuestionStatusEnded}})
+	c.Assert(err, qt.IsNil)
+	purged, err = testDB.PurgeEndedEphemeralCensuses(now)
+	c.Assert(err, qt.IsNil)
+	c.Assert(purged, qt.Equals, int64(1))
+	count, err = testDB.CountCensusParticipants(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(0))
+	_, err = testDB.CensusMember(testOrgAddress, participant.ParticipantID)
+	c.Assert(err, qt.IsNotNil)
+	stored, err := testDB.Census(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.PurgedAt.IsZero(), qt.IsFalse)
+
+	// a purged census takes no new import
+	_, _, err = testDB.SetEphemeralCensusParticipants(stored, []*OrgMember{{MemberNumber: "4", Email: "f@example.com"}})
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+}
@@ -0,0 +1,229 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+const (
+	// CensusExportVersion is the format version of the census exports built by this backend.
+	CensusExportVersion = 1
+	// CensusExportLoginHashScheme names how HashAuthTwoFaFields computes the login hash of a
+	// participant: the lower-cased values of the auth fields and then of the 2FA fields (the phone
+	// as its stored hash), sorted and formatted as a Go string slice, followed by sha256("").
+	CensusExportLoginHashScheme = "sorted-fields-v1"
+	// CensusExportKeyScheme names how the key of a participant is derived from its login hash.
+	// The login hash itself carries the member data in clear, so only its digest is exported.
+	CensusExportKeyScheme = "sha256(loginHash)"
+	// MaxCensusExportParticipants caps the participants of an export, which is stored as a single
+	// document and must stay below the 16MB document limit.
+	MaxCensusExportParticipants = 200000
+)
+
+// CensusExportHashing records the parameters the participant keys of a CensusExport were derived
+// with, so an auditor holding a member's data can compute its key and find it in the export.
+type CensusExportHashing struct {
+	LoginHash   string               `json:"loginHash" bson:"loginHash"`
+	Key         string               `json:"key" bson:"key"`
+	AuthFields  OrgMemberAuthFields  `json:"authFields,omitempty" bson:"authFields,omitempty"`
+	TwoFaFields OrgMemberTwoFaFields `json:"twoFaFields,omitempty" bson:"twoFaFields,omitempty"`
+}
+
+// CensusExportParticipant is a participant of a census export: its key and the weight it votes
+// with (1 in a non-weighted census).
+type CensusExportParticipant struct {
+	Key    internal.HexBytes `json:"key" bson:"key"`
+	Weight uint64            `json:"weight" bson:"weight"`
+}
+
+// CensusExport is the auditor bundle of a published census: its root, the participant keys in
+// ascending order with their weights, and no member data. Digest commits to the participants and
+// Signature, made with the key published as the census root, commits to the rest (see
+// SignedMessage), so Verify can check a bundle offline.
+type CensusExport struct {
+	ID           primitive.ObjectID        `json:"-" bson:"_id"`
+	JobID        string                    `json:"jobId" bson:"jobId"`
+	Version      int                       `json:"version" bson:"version"`
+	CensusID     string                    `json:"censusId" bson:"censusId"`
+	OrgAddress   common.Address            `json:"orgAddress" bson:"orgAddress"`
+	Root         internal.HexBytes         `json:"root" bson:"root"`
+	URI          string                    `json:"uri" bson:"uri"`
+	Weighted     bool                      `json:"weighted" bson:"weighted"`
+	Size         int64                     `json:"size" bson:"size"`
+	TotalWeight  uint64                    `json:"totalWeight" bson:"totalWeight"`
+	Hashing      CensusExportHashing       `json:"hashing" bson:"hashing"`
+	Participants []CensusExportParticipant `json:"participants" bson:"participants"`
+	// Digest is the sha256 of the participant keys in ascending order, each followed by its
+	// weight as a big-endian uint64.
+	Digest    internal.HexBytes `json:"digest" bson:"digest"`
+	CreatedAt time.Time         `json:"createdAt" bson:"createdAt"`
+	Signature internal.HexBytes `json:"signature" bson:"signature"`
+}
+
+// censusExportKey derives the key of a participant from its login hash.
+func censusExportKey(loginHash []byte) internal.HexBytes {
+	h := sha256.Sum256(loginHash)
+	return internal.HexBytes(h[:])
+}
+
+// digest computes the Digest of the export participants.
+func (e *CensusExport) digest() internal.HexBytes {
+	h := sha256.New()
+	weight := make([]byte, 8)
+	for _, p := range e.Participants {
+		h.Write(p.Key)
+		binary.BigEndian.PutUint64(weight, p.Weight)
+		h.Write(weight)
+	}
+	return internal.HexBytes(h.Sum(nil))
+}
+
+// SignedMessage returns the text the export Signature is made over, one field per line. It holds
+// every field of the export but the participants, which the digest stands for.
+func (e *CensusExport) SignedMessage() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "vocdoni census export v%d\n", e.Version)
+	fmt.Fprintf(&b, "census: %s\n", e.CensusID)
+	fmt.Fprintf(&b, "organization: %s\n", e.OrgAddress.Hex())
+	fmt.Fprintf(&b, "root: %s\n", e.Root.String())
+	fmt.Fprintf(&b, "uri: %s\n", e.URI)
+	fmt.Fprintf(&b, "weighted: %t\n", e.Weighted)
+	fmt.Fprintf(&b, "size: %d\n", e.Size)
+	fmt.Fprintf(&b, "total weight: %d\n", e.TotalWeight)
+	fmt.Fprintf(&b, "login hash: %s %v %v\n", e.Hashing.LoginHash, e.Hashing.AuthFields, e.Hashing.TwoFaFields)
+	fmt.Fprintf(&b, "key: %s\n", e.Hashing.Key)
+	fmt.Fprintf(&b, "digest: %s\n", e.Digest.String())
+	fmt.Fprintf(&b, "created: %s\n", e.CreatedAt.UTC().Format(time.RFC3339))
+	return []byte(b.String())
+}
+
+// Verify checks an export offline: the participants are in ascending key order and add up to its
+// size and total weight, the digest matches them, and the signature was made by the root key.
+func (e *CensusExport) Verify() error {
+	if e.Version != CensusExportVersion {
+		return fmt.Errorf("unsupported census export version %d", e.Version)
+	}
+	if int64(len(e.Participants)) != e.Size {
+		return fmt.Errorf("export lists %d participants, but its size is %d", len(e.Participants), e.Size)
+	}
+	var total uint64
+	for i, p := range e.Participants {
+		if i > 0 && bytes.Compare(e.Participants[i-1].Key, p.Key) > 0 {
+			return fmt.Errorf("participant %d is out of order", i)
+		}
+		total += p.Weight
+	}
+	if total != e.TotalWeight {
+		return fmt.Errorf("participants weigh %d, but the total weight is %d", total, e.TotalWeight)
+	}
+	if !bytes.Equal(e.digest(), e.Digest) {
+		return fmt.Errorf("digest does not match the participants")
+	}
+	// PubKeyFromSignature normalizes the recovery byte in place
+	signature := bytes.Clone(e.Signature)
+	signer, err := ethereum.PubKeyFromSignature(e.SignedMessage(), signature)
+	if err != nil {
+		return fmt.Errorf("invalid signature: %w", err)
+	}
+	if !bytes.Equal(signer, e.Root) {
+		return fmt.Errorf("export was signed by %x, not by the census root", signer)
+	}
+	return nil
+}
+
+// BuildCensusExport builds the unsigned export of a published census from its current
+// participants, under the id of the job building it.
+func (ms *MongoStorage) BuildCensusExport(jobID string, census *Census) (*CensusExport, error) {
+	if census == nil || census.ID.IsZero() || len(census.Published.Root) == 0 {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	rows, err := ms.censusParticipantWeights(ctx, census)
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > MaxCensusExportParticipants {
+		return nil, fmt.Errorf("%w: census has more than %d participants to export", ErrInvalidData,
+			MaxCensusExportParticipants)
+	}
+	export := &CensusExport{
+		ID:         primitive.NewObjectID(),
+		JobID:      jobID,
+		Version:    CensusExportVersion,
+		CensusID:   census.ID.Hex(),
+		OrgAddress: census.OrgAddress,
+		Root:       census.Published.Root,
+		URI:        census.Published.URI,
+		Weighted:   census.Weighted,
+		Size:       int64(len(rows)),
+		Hashing: CensusExportHashing{
+			LoginHash:   CensusExportLoginHashScheme,
+			Key:         CensusExportKeyScheme,
+			AuthFields:  census.AuthFields,
+			TwoFaFields: census.TwoFaFields,
+		},
+		Participants: make([]CensusExportParticipant, 0, len(rows)),
+		// the signed message carries it to the second
+		CreatedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	for _, row := range rows {
+		export.Participants = append(export.Participants, CensusExportParticipant{
+			Key:    censusExportKey(row.LoginHash),
+			Weight: row.Weight,
+		})
+		export.TotalWeight += row.Weight
+	}
+	sort.Slice(export.Participants, func(i, j int) bool {
+		return bytes.Compare(export.Participants[i].Key, export.Participants[j].Key) < 0
+	})
+	export.Digest = export.digest()
+	return export, nil
+}
+
+// SetCensusExport stores a signed census export.
+func (ms *MongoStorage) SetCensusExport(export *CensusExport) error {
+	if export == nil || export.JobID == "" || len(export.Signature) == 0 {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	if _, err := ms.censusExports.InsertOne(ctx, export); err != nil {
+		return fmt.Errorf("failed to store census export: %w", err)
+	}
+	return nil
+}
+
+// CensusExport returns the export of a census built by the given job. It returns ErrNotFound
+// while the job has not stored it.
+func (ms *MongoStorage) CensusExport(censusID, jobID string) (*CensusExport, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	export := &CensusExport{}
+	err := ms.censusExports.FindOne(ctx, bson.M{"censusId": censusID, "jobId": jobID}).Decode(export)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get census export: %w", err)
+	}
+	return export, nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.vocdoni.io/dvote/crypto/ethereum"
+)
+
+func TestCensusExport(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	rootKey := ethereum.NewSignKeys()
+	c.Assert(rootKey.Generate(), qt.IsNil)
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true,
+		AuthFields: OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		Published:  PublishedCensus{Root: internal.HexBytes(rootKey.PublicKey()), URI: "uri", CreatedAt: time.Now()},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	for i, weight := range []uint64{2, 3} {
+		member := OrgMember{
+			ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, MemberNumber: string(rune('a' + i)), Weight: weight,
+		}
+		_, err := testDB.orgMembers.InsertOne(ctx, &member)
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{
+			ParticipantID: member.ID.Hex(), CensusID: censusID,
+			LoginHash: HashAuthTwoFaFields(member, census.AuthFields, nil),
+		})
+		c.Assert(err, qt.IsNil)
+	}
+
+	export, err := testDB.BuildCensusExport("job", census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(export.Size, qt.Equals, int64(2))
+	c.Assert(export.TotalWeight, qt.Equals, uint64(5))
+	// an auditor holding the member data finds the member by its key
+	key := censusExportKey(HashAuthTwoFaFields(OrgMember{MemberNumber: "a"}, census.AuthFields, nil))
+	c.Assert([]internal.HexBytes{export.Participants[0].Key, export.Participants[1].Key}, qt.Contains, key)
+
+	export.Signature, err = rootKey.SignEthereum(export.SignedMessage())
+	c.Assert(err, qt.IsNil)
+	c.Assert(testDB.SetCensusExport(export), qt.IsNil)
+	stored, err := testDB.CensusExport(censusID, "job")
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Verify(), qt.IsNil)
+	_, err = testDB.CensusExport(censusID, "other")
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+
+	// any change to the bundle breaks it
+	stored.TotalWeight++
+	c.Assert(stored.Verify(), qt.IsNotNil)
+	stored.TotalWeight--
+	stored.URI = "other"
+	c.Assert(stored.Verify(), qt.IsNotNil)
+	// and only the root key can sign it
+	otherKey := ethereum.NewSignKeys()
+	c.Assert(otherKey.Generate(), qt.IsNil)
+	export.Signature, err = otherKey.SignEthereum(export.SignedMessage())
+	c.Assert(err, qt.IsNil)
+	c.Assert(export.Verify(), qt.IsNotNil)
+}
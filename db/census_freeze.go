@@ -0,0 +1,189 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// CensusFreeze records the participant set a census was frozen with. Commitment is computed like
+// a snapshot Digest: the sha256 of the participant hashes in ascending order, each followed by its
+// weight as a big-endian uint64, so it matches the digest of the snapshot recorded at the same
+// publication.
+type CensusFreeze struct {
+	At          time.Time         `json:"at" bson:"at"`
+	Size        int64             `json:"size" bson:"size"`
+	TotalWeight uint64            `json:"totalWeight" bson:"totalWeight"`
+	Commitment  internal.HexBytes `json:"commitment" bson:"commitment"`
+}
+
+// FreezeCensus freezes census with a commitment to its current participants, and returns the
+// freeze. A census already frozen keeps, and returns, its first freeze. The participants keep the
+// weight and the login hashes they were frozen with: the weight a member's own weight gives them is
+// pinned on them, and member updates no longer rehash them.
+func (ms *MongoStorage) FreezeCensus(census *Census) (*CensusFreeze, error) {
+	if census == nil || census.ID.IsZero() {
+		return nil, ErrInvalidData
+	}
+	if census.Frozen != nil {
+		return census.Frozen, nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	if freezePinsWeights(census) {
+		if err := ms.pinCensusParticipantWeights(ctx, census); err != nil {
+			return nil, err
+		}
+	}
+	participants, err := ms.currentSnapshotParticipants(ctx, census)
+	if err != nil {
+		return nil, err
+	}
+	freeze := &CensusFreeze{
+		At:         time.Now(),
+		Size:       int64(len(participants)),
+		Commitment: censusSnapshotDigest(participants),
+	}
+	for _, p := range participants {
+		freeze.TotalWeight += p.Weight
+	}
+	result, err := ms.censuses.UpdateOne(ctx,
+		bson.M{"_id": census.ID, "frozen": bson.M{"$exists": false}},
+		bson.M{"$set": bson.M{"frozen": freeze}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to freeze census: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		// frozen meanwhile, or gone
+		stored := &Census{}
+		if err := ms.censuses.FindOne(ctx, bson.M{"_id": census.ID}).Decode(stored); err != nil {
+			return nil, fmt.Errorf("failed to get census: %w", err)
+		}
+		freeze = stored.Frozen
+	}
+	census.Frozen = freeze
+	return freeze, nil
+}
+
+// UnfreezeCensus undoes freeze, the freeze of census by the publish of process processID, when that
+// publish failed, and reports whether it did. The census stays frozen when it was frozen otherwise
+// since, or when another process voted by it is published or being published. The weights the
+// freeze pinned are unpinned, so the participants weigh what their member does again.
+func (ms *MongoStorage) UnfreezeCensus(census *Census, freeze *CensusFreeze, processID primitive.ObjectID) (bool, error) {
+	if census == nil || census.ID.IsZero() || freeze == nil {
+		return false, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	others, err := ms.votingProcesses.CountDocuments(ctx, bson.M{
+		"censusId": census.ID,
+		"_id":      bson.M{"$ne": processID},
+		"$or":      bson.A{bson.M{"published": true}, bson.M{"publishing": bson.M{"$exists": true}}},
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to count the processes of the census: %w", err)
+	}
+	if others > 0 {
+		return false, nil
+	}
+	result, err := ms.censuses.UpdateOne(ctx,
+		bson.M{"_id": census.ID, "frozen.at": freeze.At.Truncate(time.Millisecond)},
+		bson.M{"$unset": bson.M{"frozen": ""}})
+	if err != nil {
+		return false, fmt.Errorf("failed to unfreeze census: %w", err)
+	}
+	if result.MatchedCount == 0 {
+		return false, nil
+	}
+	if freezePinsWeights(census) {
+		if _, err := ms.censusParticipants.UpdateMany(ctx,
+			bson.M{"censusId": census.ID.Hex()}, bson.M{"$unset": bson.M{"weight": ""}}); err != nil {
+			return false, fmt.Errorf("failed to unpin census participant weights: %w", err)
+		}
+	}
+	census.Frozen = nil
+	return true, nil
+}
+
+// freezePinsWeights reports whether freezing census pins the weight of its participants: those of
+// a weighted census that weighs them by their member's weight, which can still change, rather than
+// by its WeightRule or as imported into an ephemeral census, which the participant already stores.
+func freezePinsWeights(census *Census) bool {
+	return census.Weighted && census.WeightRule == nil && !census.Ephemeral
+}
+
+// pinCensusParticipantWeights stores on every participant of census the weight it votes with now,
+// so it keeps voting with it whatever its member's weight becomes. The caller holds keysLock.
+func (ms *MongoStorage) pinCensusParticipantWeights(ctx context.Context, census *Census) error {
+	rows, err := ms.censusParticipantWeights(ctx, census)
+	if err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return nil
+	}
+	censusID := census.ID.Hex()
+	updates := make([]mongo.WriteModel, 0, len(rows))
+	for _, row := range rows {
+		updates = append(updates, mongo.NewUpdateOneModel().
+			SetFilter(bson.M{"censusId": censusID, "participantID": row.ParticipantID, "weight": bson.M{"$exists": false}}).
+			SetUpdate(bson.M{"$set": bson.M{"weight": row.Weight}}))
+	}
+	if _, err := ms.censusParticipants.BulkWrite(ctx, updates, options.BulkWrite().SetOrdered(false)); err != nil {
+		return fmt.Errorf("failed to pin census participant weights: %w", err)
+	}
+	return nil
+}
+
+// FrozenCensusIDs returns the ids, among censusIDs, of the censuses that are frozen.
+func (ms *MongoStorage) FrozenCensusIDs(censusIDs []string) ([]string, error) {
+	if len(censusIDs) == 0 {
+		return nil, nil
+	}
+	oids, err := censusObjectIDs(censusIDs)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	values, err := ms.censuses.Distinct(ctx, "_id", bson.M{
+		"_id":    bson.M{"$in": oids},
+		"frozen": bson.M{"$exists": true},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query frozen censuses: %w", err)
+	}
+	frozen := make([]string, 0, len(values))
+	for _, v := range values {
+		if oid, ok := v.(primitive.ObjectID); ok {
+			frozen = append(frozen, oid.Hex())
+		}
+	}
+	return frozen, nil
+}
+
+// refuseFrozenCensuses returns ErrCensusFrozen, naming them, when any of censusIDs is frozen.
+func (ms *MongoStorage) refuseFrozenCensuses(censusIDs []string) error {
+	frozen, err := ms.FrozenCensusIDs(censusIDs)
+	if err != nil {
+		return err
+	}
+	if len(frozen) > 0 {
+		return fmt.Errorf("%w: %s", ErrCensusFrozen, strings.Join(frozen, ", "))
+	}
+	return nil
+}
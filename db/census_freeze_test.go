@@ -0,0 +1,182 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCensusFreeze(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, FreezeOnPublish: true,
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+		Published:   PublishedCensus{Root: internal.HexBytes{0x01}, URI: "uri", CreatedAt: time.Now()},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	var memberIDs []string
+	for range 2 {
+		id := primitive.NewObjectID()
+		_, err := testDB.orgMembers.InsertOne(ctx, &OrgMember{ID: id, OrgAddress: testOrgAddress})
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{ParticipantID: id.Hex(), CensusID: censusID})
+		c.Assert(err, qt.IsNil)
+		memberIDs = append(memberIDs, id.Hex())
+	}
+
+	frozen, err := testDB.FrozenCensusIDs([]string{censusID})
+	c.Assert(err, qt.IsNil)
+	c.Assert(frozen, qt.HasLen, 0)
+
+	freeze, err := testDB.FreezeCensus(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(freeze.Size, qt.Equals, int64(2))
+	c.Assert(freeze.TotalWeight, qt.Equals, uint64(2))
+	// the commitment is the digest of the snapshot of the same participants
+	snapshot, err := testDB.CreateCensusSnapshot(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(freeze.Commitment, qt.DeepEquals, snapshot.Digest)
+
+	// freezing again keeps the first freeze
+	stored, err := testDB.Census(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Frozen, qt.Not(qt.IsNil))
+	c.Assert(stored.Frozen.Commitment, qt.DeepEquals, freeze.Commitment)
+	stored.Frozen = nil
+	again, err := testDB.FreezeCensus(stored)
+	c.Assert(err, qt.IsNil)
+	c.Assert(again.At.Equal(freeze.At.Truncate(time.Millisecond)), qt.IsTrue)
+
+	frozen, err = testDB.FrozenCensusIDs([]string{censusID, primitive.NewObjectID().Hex()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(frozen, qt.DeepEquals, []string{censusID})
+
+	// the participants can no longer change
+	_, _, err = testDB.RevokeMembersFromCensuses([]string{censusID}, memberIDs[:1])
+	c.Assert(err, qt.ErrorIs, ErrCensusFrozen)
+	_, _, err = testDB.DeleteOrgMembers(testOrgAddress, memberIDs[:1], MemberChangeSource{})
+	c.Assert(err, qt.ErrorIs, ErrCensusFrozen)
+	newcomer := primitive.NewObjectID()
+	_, err = testDB.orgMembers.InsertOne(ctx, &OrgMember{ID: newcomer, OrgAddress: testOrgAddress})
+	c.Assert(err, qt.IsNil)
+	_, _, err = testDB.AddCensusParticipantsByMemberIDs(censusID, []string{newcomer.Hex()})
+	c.Assert(err, qt.ErrorIs, ErrCensusFrozen)
+	count, err := testDB.CountCensusParticipants(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(2))
+}
+
+func TestCensusFreezePinsParticipants(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := &Organization{Address: testOrgAddress, CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true, FreezeOnPublish: true,
+		AuthFields:  OrgMemberAuthFields{OrgMemberAuthFieldsName},
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+		Published:   PublishedCensus{Root: internal.HexBytes{0x01}, URI: "uri", CreatedAt: time.Now()},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	member := &OrgMember{OrgAddress: testOrgAddress, Name: "Alice", Email: "alice@example.com", Weight: 3}
+	memberID, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, member, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	_, _, err = testDB.AddCensusParticipantsByMemberIDs(censusID, []string{memberID.Hex()})
+	c.Assert(err, qt.IsNil)
+	before, err := testDB.CensusParticipant(censusID, memberID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(before.Weight, qt.IsNil)
+
+	freeze, err := testDB.FreezeCensus(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(freeze.TotalWeight, qt.Equals, uint64(3))
+
+	// the member changes its weight and login data after the freeze
+	member.Weight, member.Name = 7, "Alicia"
+	_, _, err = testDB.UpsertOrgMemberAndCensusParticipants(org, member, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	stored, err := testDB.OrgMember(testOrgAddress, memberID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Weight, qt.Equals, uint64(7))
+
+	// the participant still votes with, and logs in with, what it was frozen with
+	frozenCensus, err := testDB.Census(censusID)
+	c.Assert(err, qt.IsNil)
+	weight, err := testDB.VoterWeight(frozenCensus, stored)
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(3))
+	after, err := testDB.CensusParticipant(censusID, memberID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(after.LoginHash, qt.DeepEquals, before.LoginHash)
+	snapshot, err := testDB.CreateCensusSnapshot(frozenCensus)
+	c.Assert(err, qt.IsNil)
+	c.Assert(snapshot.Digest, qt.DeepEquals, freeze.Commitment)
+}
+
+func TestUnfreezeCensus(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true, FreezeOnPublish: true,
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+		Published:   PublishedCensus{Root: internal.HexBytes{0x01}, URI: "uri", CreatedAt: time.Now()},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	memberID := primitive.NewObjectID()
+	_, err = testDB.orgMembers.InsertOne(ctx, &OrgMember{ID: memberID, OrgAddress: testOrgAddress, Weight: 2})
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{ParticipantID: memberID.Hex(), CensusID: censusID})
+	c.Assert(err, qt.IsNil)
+	processID, other := primitive.NewObjectID(), primitive.NewObjectID()
+
+	// another published process voted by the census keeps it frozen
+	freeze, err := testDB.FreezeCensus(census)
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.votingProcesses.InsertOne(ctx, &VotingProcess{
+		ID: other, OrgAddress: testOrgAddress, CensusID: census.ID, Published: true,
+	})
+	c.Assert(err, qt.IsNil)
+	unfrozen, err := testDB.UnfreezeCensus(census, freeze, processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(unfrozen, qt.IsFalse)
+
+	// otherwise the freeze is undone, and so is the weight it pinned
+	_, err = testDB.votingProcesses.DeleteOne(ctx, bson.M{"_id": other})
+	c.Assert(err, qt.IsNil)
+	unfrozen, err = testDB.UnfreezeCensus(census, freeze, processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(unfrozen, qt.IsTrue)
+	stored, err := testDB.Census(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(stored.Frozen, qt.IsNil)
+	participant, err := testDB.CensusParticipant(censusID, memberID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(participant.Weight, qt.IsNil)
+
+	// a later freeze is not undone by the earlier one
+	time.Sleep(2 * time.Millisecond)
+	_, err = testDB.FreezeCensus(stored)
+	c.Assert(err, qt.IsNil)
+	unfrozen, err = testDB.UnfreezeCensus(stored, freeze, processID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(unfrozen, qt.IsFalse)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/ethereum/go-ethereum/common"
@@ -493,6 +494,14 @@ func (ms *MongoStorage) SetBulkCensusOrgMemberParticipant(
 		close(progressChan)
 		return progressChan, fmt.Errorf("failed to get published census: %w", err)
 	}
+	if census.Ephemeral {
+		close(progressChan)
+		return progressChan, fmt.Errorf("%w: an ephemeral census takes no organization members", ErrInvalidData)
+	}
+	if census.Frozen != nil {
+		close(progressChan)
+		return progressChan, ErrCensusFrozen
+	}
 
 	if _, err := ms.Organization(org.Address); err != nil {
 		close(progressChan)
@@ -512,7 +521,8 @@ func (ms *MongoStorage) SetBulkCensusOrgMemberParticipant(
 }
 
 // AddCensusParticipantsByMemberIDs adds existing organization members to a census.
-// It skips members already added to the census, and inserts new participants one by one.
+// It skips members already added to the census, and inserts new participants one by one. An
+// inactive member is reported among the errors, as it joins no new census.
 func (ms *MongoStorage) AddCensusParticipantsByMemberIDs(censusID string, memberIDs []string) (int, []string, error) {
 	if len(censusID) == 0 {
 		return 0, nil, ErrInvalidData
@@ -525,6 +535,16 @@ func (ms *MongoStorage) AddCensusParticipantsByMemberIDs(censusID string, member
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to get census: %w", err)
 	}
+	if census.Ephemeral {
+		return 0, nil, fmt.Errorf("%w: an ephemeral census takes no organization members", ErrInvalidData)
+	}
+	if census.Frozen != nil {
+		return 0, nil, ErrCensusFrozen
+	}
+	weigh, totalWeight, err := ms.censusWeigher(census)
+	if err != nil {
+		return 0, nil, err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
 	defer cancel()
@@ -539,7 +559,7 @@ func (ms *MongoStorage) AddCensusParticipantsByMemberIDs(censusID string, member
 
 		member, err := ms.OrgMember(census.OrgAddress, memberID)
 		switch {
-		case errors.Is(err, ErrInvalidData), errors.Is(err, mongo.ErrNoDocuments):
+		case errors.Is(err, ErrInvalidData), errors.Is(err, ErrNotFound):
 			memberErrors = append(memberErrors, fmt.Errorf("%s: %w", memberID, ErrInvalidData))
 			continue
 		case err != nil:
@@ -548,6 +568,11 @@ func (ms *MongoStorage) AddCensusParticipantsByMemberIDs(censusID string, member
 		default:
 		}
 
+		if !member.DeactivatedAt.IsZero() {
+			memberErrors = append(memberErrors, fmt.Errorf("%s: %w: member is inactive", memberID, ErrInvalidData))
+			continue
+		}
+
 		participantFilter := bson.M{
 			"participantID": member.ID.Hex(),
 			"censusId":      census.ID.Hex(),
@@ -590,6 +615,20 @@ func (ms *MongoStorage) AddCensusParticipantsByMemberIDs(censusID string, member
 			"createdAt":     now,
 			"updatedAt":     now,
 		}
+		if weigh != nil {
+			weight, err := weigh(member)
+			if err != nil {
+				memberErrors = append(memberErrors, fmt.Errorf("%s: %w", memberID, err))
+				continue
+			}
+			// the census total is an int64 once aggregated, so no participant may take it past that
+			if weight > math.MaxInt64-totalWeight {
+				memberErrors = append(memberErrors, fmt.Errorf("%s: %w: census total weight overflow", memberID, ErrInvalidData))
+				continue
+			}
+			totalWeight += weight
+			newParticipant["weight"] = weight
+		}
 		if hash, ok := hashes["loginHashEmail"]; ok {
 			newParticipant["loginHashEmail"] = hash
 		}
@@ -656,6 +695,10 @@ func (ms *MongoStorage) updateCensusSize(censusID string) error {
 	return nil
 }
 
+// setBulkCensusParticipant upserts a participant of census for every active member of the group, and
+// returns how many it inserted. When a member cannot join, because its weight cannot be computed
+// or would take the census total weight past what it can hold, nothing is written and the error
+// lists every such member by id, as AddCensusParticipantsByMemberIDs reports them.
 func (ms *MongoStorage) setBulkCensusParticipant(ctx context.Context, census *Census, groupID string) (int64, error) {
 	_, members, err := ms.ListOrganizationMemberGroup(groupID, census.OrgAddress, 0, 0)
 	if err != nil {
@@ -665,11 +708,24 @@ func (ms *MongoStorage) setBulkCensusParticipant(ctx context.Context, census *Ce
 		return 0, nil // nothing to do
 	}
 
+	var weigh func(*OrgMember) (uint64, error)
+	if census.Weighted && census.WeightRule != nil {
+		if weigh, err = census.WeightRule.weigher(); err != nil {
+			return 0, err
+		}
+	}
+
 	// prepare filter for upsert
 	currentTime := time.Now()
 
+	var totalWeight uint64
+	var memberErrors []error
 	docs := make([]mongo.WriteModel, 0, len(members))
 	for _, member := range members {
+		// an inactive member joins no new census
+		if !member.DeactivatedAt.IsZero() {
+			continue
+		}
 		// Create participant filter and document
 		id := member.ID.Hex()
 		censusParticipantsFilter := bson.M{
@@ -682,6 +738,22 @@ func (ms *MongoStorage) setBulkCensusParticipant(ctx context.Context, census *Ce
 			CensusID:      census.ID.Hex(),
 			UpdatedAt:     currentTime,
 		}
+		if census.Weighted {
+			weight := member.Weight
+			if weigh != nil {
+				if weight, err = weigh(member); err != nil {
+					memberErrors = append(memberErrors, fmt.Errorf("%s: %w", id, err))
+					continue
+				}
+				participantDoc.Weight = &weight
+			}
+			// the census total is an int64 once aggregated, so no participant may take it past that
+			if weight > math.MaxInt64-totalWeight {
+				memberErrors = append(memberErrors, fmt.Errorf("%s: %w: census total weight overflow", id, ErrInvalidData))
+				continue
+			}
+			totalWeight += weight
+		}
 
 		if len(census.TwoFaFields) == 2 && member.Email != "" {
 			participantDoc.LoginHashEmail = HashAuthTwoFaFields(*member, census.AuthFields, OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail})
@@ -720,6 +792,9 @@ func (ms *MongoStorage) setBulkCensusParticipant(ctx context.Context, census *Ce
 			SetUpsert(true)
 		docs = append(docs, upsertCensusParticipantsModel)
 	}
+	if len(memberErrors) > 0 {
+		return 0, fmt.Errorf("%w: %w", ErrInvalidData, errors.Join(memberErrors...))
+	}
 	// Unordered makes it continue on errors (e.g., one dup)
 	bulkOpts := options.BulkWrite().SetOrdered(false)
 
@@ -747,7 +822,8 @@ func (ms *MongoStorage) CountCensusParticipants(censusID string) (int64, error)
 }
 
 // CensusTotalWeight returns the sum of the weights of a census's members, joining each participant
-// (whose participantID is its org member's hex ObjectID) to its OrgMember and summing OrgMember.Weight.
+// (whose participantID is its org member's hex ObjectID) to its OrgMember and summing OrgMember.Weight,
+// or the participant's own weight when the census WeightRule gave it one.
 // It backs the census total voting weight exposed on the /processes read; it is only meaningful for
 // weighted censuses (for a non-weighted census every member counts as 1, so the total equals the
 // participant count). Returns 0 for a census with no participants.
@@ -770,7 +846,10 @@ func (ms *MongoStorage) CensusTotalWeight(censusID string) (int64, error) {
 			"as":           "member",
 		}}},
 		{{Key: "$unwind", Value: "$member"}},
-		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": "$member.weight"}}}},
+		// a participant weighed by the census WeightRule carries its own weight
+		{{Key: "$group", Value: bson.M{"_id": nil, "total": bson.M{"$sum": bson.M{
+			"$ifNull": bson.A{"$weight", "$member.weight"},
+		}}}}},
 	}
 	cur, err := ms.censusParticipants.Aggregate(ctx, pipeline)
 	if err != nil {
@@ -789,6 +868,43 @@ func (ms *MongoStorage) CensusTotalWeight(censusID string) (int64, error) {
 	return res[0].Total, nil
 }
 
+// censusWeigher returns the function weighing the members that join census under its WeightRule,
+// and the current total weight of the census; no function when the census has no rule.
+func (ms *MongoStorage) censusWeigher(census *Census) (func(*OrgMember) (uint64, error), uint64, error) {
+	if !census.Weighted || census.WeightRule == nil {
+		return nil, 0, nil
+	}
+	weigh, err := census.WeightRule.weigher()
+	if err != nil {
+		return nil, 0, err
+	}
+	total, err := ms.CensusTotalWeight(census.ID.Hex())
+	if err != nil {
+		return nil, 0, err
+	}
+	return weigh, uint64(total), nil
+}
+
+// VoterWeight returns the weight member votes with in census: 1 in a non-weighted census, the
+// weight the census WeightRule gave its participation or the census was frozen with, or else the
+// member's own weight.
+func (ms *MongoStorage) VoterWeight(census *Census, member *OrgMember) (uint64, error) {
+	if !census.Weighted {
+		return 1, nil
+	}
+	if census.WeightRule == nil && census.Frozen == nil {
+		return member.Weight, nil
+	}
+	participant, err := ms.CensusParticipant(census.ID.Hex(), member.ID.Hex())
+	if err != nil {
+		return 0, err
+	}
+	if participant.Weight == nil {
+		return member.Weight, nil
+	}
+	return *participant.Weight, nil
+}
+
 // CensusParticipants retrieves all the census participants for a given census.
 func (ms *MongoStorage) CensusParticipants(censusID string) ([]CensusParticipant, error) {
 	// create a context with a timeout
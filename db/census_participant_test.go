@@ -667,7 +667,7 @@ func TestCensusParticipant(t *testing.T) {
 		member0.PlaintextPhone = members[1].PlaintextPhone
 
 		{
-			_, _, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member0, "test_salt")
+			_, _, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member0, "test_salt", MemberChangeSource{})
 			c.Assert(err, qt.ErrorMatches, ".*update would create duplicates.*",
 				qt.Commentf("trying to UpdateOrgMember(%+v) should create a conflict with %+v", member0, members[1]))
 
@@ -682,7 +682,7 @@ func TestCensusParticipant(t *testing.T) {
 		oldHashedPhone := member1.Phone
 		member1.PlaintextPhone = "+34698123321"
 		{
-			_, created, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member1, "test_salt")
+			_, created, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member1, "test_salt", MemberChangeSource{})
 			c.Assert(err, qt.IsNil)
 			c.Assert(created, qt.IsFalse)
 
@@ -695,7 +695,8 @@ func TestCensusParticipant(t *testing.T) {
 		// since duplicates in memberbase are allowed, and a new member is not part of any census
 		{
 			member0.ID = primitive.NilObjectID
-			newMemberID, created, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member0, "test_salt")
+			newMemberID, created, err := testDB.UpsertOrgMemberAndCensusParticipants(
+				testOrg, member0, "test_salt", MemberChangeSource{})
 			c.Assert(err, qt.IsNil)
 			c.Assert(created, qt.IsTrue)
 			member, err := testDB.OrgMember(testOrgAddress, newMemberID.Hex())
@@ -706,7 +707,8 @@ func TestCensusParticipant(t *testing.T) {
 		// Passing an arbitrary (new) memberID should also work OK and create a new member
 		{
 			member0.ID = primitive.NewObjectID()
-			newMemberID, created, err := testDB.UpsertOrgMemberAndCensusParticipants(testOrg, member0, "test_salt")
+			newMemberID, created, err := testDB.UpsertOrgMemberAndCensusParticipants(
+				testOrg, member0, "test_salt", MemberChangeSource{})
 			c.Assert(err, qt.IsNil)
 			c.Assert(created, qt.IsTrue)
 			member, err := testDB.OrgMember(testOrgAddress, newMemberID.Hex())
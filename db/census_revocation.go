@@ -247,13 +247,18 @@ func (ms *MongoStorage) SignedVotersForElections(processIDs []internal.HexBytes)
 // maxCensusSize resize for it.
 //
 // Callers must refuse the removal first for any member the CSP has already signed for
-// (MembersWithUsedCSPProcesses) — this function is the write, not the guard.
+// (MembersWithUsedCSPProcesses) — this function is the write, not the guard. A frozen census is
+// the exception it guards itself: nothing is revoked, and ErrCensusFrozen returned, when any of
+// the censuses is frozen.
 func (ms *MongoStorage) RevokeMembersFromCensuses(
 	censusIDs, memberIDs []string,
 ) (int64, []VotingProcessQuestion, error) {
 	if len(censusIDs) == 0 || len(memberIDs) == 0 {
 		return 0, nil, nil
 	}
+	if err := ms.refuseFrozenCensuses(censusIDs); err != nil {
+		return 0, nil, err
+	}
 
 	processes, err := ms.VotingProcessesByCensus(censusIDs)
 	if err != nil {
@@ -391,7 +396,15 @@ func (ms *MongoStorage) revokeWrites(
 ) (int64, error) {
 	ms.keysLock.Lock()
 	defer ms.keysLock.Unlock()
+	return ms.revokeWritesLocked(ctx, censusIDs, memberIDs, processIDs)
+}
 
+// revokeWritesLocked is revokeWrites for a caller already holding keysLock.
+func (ms *MongoStorage) revokeWritesLocked(
+	ctx context.Context,
+	censusIDs, memberIDs []string,
+	processIDs []primitive.ObjectID,
+) (int64, error) {
 	// 1. the census participant rows. This is what actually revokes: the CSP re-checks
 	//    participation at sign time, and the census size is a count of these. Its DeletedCount is
 	//    the only honest answer to "how many members were removed" — the ids come from a request
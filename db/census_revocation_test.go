@@ -342,7 +342,7 @@ func TestDeleteOrgMembersScopesToOrg(t *testing.T) {
 		Address: otherOrg, CreatedAt: time.Now(),
 	}), qt.IsNil)
 
-	deleted, emptied, err := testDB.DeleteOrgMembers(otherOrg, []string{f.alice.ID.Hex()})
+	deleted, emptied, err := testDB.DeleteOrgMembers(otherOrg, []string{f.alice.ID.Hex()}, MemberChangeSource{})
 	c.Assert(err, qt.IsNil)
 	c.Assert(deleted, qt.Equals, 0)
 	c.Assert(emptied, qt.HasLen, 0)
@@ -365,7 +365,7 @@ func TestDeleteOrgMembersScopesToOrg(t *testing.T) {
 	c.Assert(auth, qt.Not(qt.IsNil))
 
 	// the owning organization still deletes normally: the scoping must not over-filter
-	deleted, _, err = testDB.DeleteOrgMembers(testOrgAddress, []string{f.alice.ID.Hex()})
+	deleted, _, err = testDB.DeleteOrgMembers(testOrgAddress, []string{f.alice.ID.Hex()}, MemberChangeSource{})
 	c.Assert(err, qt.IsNil)
 	c.Assert(deleted, qt.Equals, 1)
 	_, err = testDB.CensusParticipant(f.census.ID.Hex(), f.alice.ID.Hex())
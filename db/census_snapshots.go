@@ -0,0 +1,339 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// snapshotInsertBatchSize caps the participants written per InsertMany of a snapshot.
+const snapshotInsertBatchSize = 1000
+
+// CensusSnapshot is the immutable record of a census as one of its publications left it. Versions
+// are numbered from 1 per census. The participant list itself is stored apart and only read by a
+// diff; Digest commits to it, so an auditor holding the list can check it was not altered.
+type CensusSnapshot struct {
+	ID          primitive.ObjectID `json:"id" bson:"_id"`
+	CensusID    string             `json:"censusId" bson:"censusId"`
+	OrgAddress  common.Address     `json:"orgAddress" bson:"orgAddress"`
+	Version     int64              `json:"version" bson:"version"`
+	Root        internal.HexBytes  `json:"root" bson:"root"`
+	Size        int64              `json:"size" bson:"size"`
+	TotalWeight uint64             `json:"totalWeight" bson:"totalWeight"`
+	// Digest is the sha256 of the participant hashes in ascending order, each followed by its
+	// weight as a big-endian uint64.
+	Digest    internal.HexBytes `json:"digest" bson:"digest"`
+	CreatedAt time.Time         `json:"createdAt" bson:"createdAt"`
+}
+
+// CensusSnapshotParticipant is a participant of a census snapshot. Hash stands for the member:
+// the same member hashes the same in every snapshot of a census, and differently in any other.
+type CensusSnapshotParticipant struct {
+	SnapshotID primitive.ObjectID `json:"-" bson:"snapshotId"`
+	CensusID   string             `json:"-" bson:"censusId"`
+	Hash       internal.HexBytes  `json:"hash" bson:"hash"`
+	// Weight is the member weight in a weighted census, 1 otherwise.
+	Weight uint64 `json:"weight" bson:"weight"`
+}
+
+// CensusReweight is a participant of both snapshots of a diff whose weight changed.
+type CensusReweight struct {
+	Hash       internal.HexBytes `json:"hash"`
+	FromWeight uint64            `json:"fromWeight"`
+	ToWeight   uint64            `json:"toWeight"`
+}
+
+// CensusSnapshotDiff lists how the participants of a census changed from one snapshot to another.
+// From is nil when the diff starts from version 0, the empty census before its first publication.
+type CensusSnapshotDiff struct {
+	From       *CensusSnapshot             `json:"from,omitempty"`
+	To         *CensusSnapshot             `json:"to"`
+	Added      []CensusSnapshotParticipant `json:"added"`
+	Removed    []CensusSnapshotParticipant `json:"removed"`
+	Reweighted []CensusReweight            `json:"reweighted"`
+}
+
+// censusParticipantHash is the hash a participant is recorded under in the snapshots of a census.
+func censusParticipantHash(censusID, participantID string) internal.HexBytes {
+	h := sha256.Sum256([]byte(censusID + "/" + participantID))
+	return internal.HexBytes(h[:])
+}
+
+// censusSnapshotDigest commits to a participant list sorted by hash.
+func censusSnapshotDigest(participants []CensusSnapshotParticipant) internal.HexBytes {
+	h := sha256.New()
+	weight := make([]byte, 8)
+	for _, p := range participants {
+		h.Write(p.Hash)
+		binary.BigEndian.PutUint64(weight, p.Weight)
+		h.Write(weight)
+	}
+	return internal.HexBytes(h.Sum(nil))
+}
+
+// censusParticipantWeight is a participant of a census with the weight it votes with.
+type censusParticipantWeight struct {
+	ParticipantID string `bson:"participantID"`
+	LoginHash     []byte `bson:"loginHash"`
+	Weight        uint64 `bson:"weight"`
+}
+
+// censusParticipantWeights reads the current participants of census with their weight: 1 in a
+// non-weighted census, else the weight its WeightRule stored or the member's. A participant whose
+// member is gone weighs 0 in a weighted census.
+func (ms *MongoStorage) censusParticipantWeights(ctx context.Context, census *Census,
+) ([]censusParticipantWeight, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"censusId": census.ID.Hex()}}},
+		// participantID is the member's ObjectID hex; convert it to join the member's weight.
+		{{Key: "$addFields", Value: bson.M{"memberOID": bson.M{
+			"$convert": bson.M{"input": "$participantID", "to": "objectId", "onError": nil, "onNull": nil},
+		}}}},
+		{{Key: "$lookup", Value: bson.M{
+			"from":         "orgMembers",
+			"localField":   "memberOID",
+			"foreignField": "_id",
+			"as":           "member",
+		}}},
+		// a participant weighed by the census WeightRule carries its own weight
+		{{Key: "$project", Value: bson.M{
+			"participantID": 1,
+			"loginHash":     1,
+			"weight": bson.M{"$ifNull": bson.A{"$weight", bson.M{
+				"$ifNull": bson.A{bson.M{"$arrayElemAt": bson.A{"$member.weight", 0}}, 0},
+			}}},
+		}}},
+	}
+	cursor, err := ms.censusParticipants.Aggregate(ctx, pipeline)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read census participants: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	var rows []censusParticipantWeight
+	if err := cursor.All(ctx, &rows); err != nil {
+		return nil, fmt.Errorf("failed to decode census participants: %w", err)
+	}
+	if !census.Weighted {
+		for i := range rows {
+			rows[i].Weight = 1
+		}
+	}
+	return rows, nil
+}
+
+// currentSnapshotParticipants reads the current participants of census with their weight, sorted
+// by hash.
+func (ms *MongoStorage) currentSnapshotParticipants(ctx context.Context, census *Census,
+) ([]CensusSnapshotParticipant, error) {
+	rows, err := ms.censusParticipantWeights(ctx, census)
+	if err != nil {
+		return nil, err
+	}
+	censusID := census.ID.Hex()
+	participants := make([]CensusSnapshotParticipant, 0, len(rows))
+	for _, row := range rows {
+		participants = append(participants, CensusSnapshotParticipant{
+			CensusID: censusID,
+			Hash:     censusParticipantHash(censusID, row.ParticipantID),
+			Weight:   row.Weight,
+		})
+	}
+	sort.Slice(participants, func(i, j int) bool {
+		return bytes.Compare(participants[i].Hash, participants[j].Hash) < 0
+	})
+	return participants, nil
+}
+
+// CreateCensusSnapshot records the current state of a published census as its next snapshot, and
+// returns it. When nothing changed since the latest snapshot, no version is added and the latest
+// is returned.
+func (ms *MongoStorage) CreateCensusSnapshot(census *Census) (*CensusSnapshot, error) {
+	if census == nil || census.ID.IsZero() {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	participants, err := ms.currentSnapshotParticipants(ctx, census)
+	if err != nil {
+		return nil, err
+	}
+	snapshot := &CensusSnapshot{
+		ID:         primitive.NewObjectID(),
+		CensusID:   census.ID.Hex(),
+		OrgAddress: census.OrgAddress,
+		Version:    1,
+		Root:       census.Published.Root,
+		Size:       int64(len(participants)),
+		Digest:     censusSnapshotDigest(participants),
+		CreatedAt:  time.Now(),
+	}
+	for _, p := range participants {
+		snapshot.TotalWeight += p.Weight
+	}
+
+	latest := &CensusSnapshot{}
+	err = ms.censusSnapshots.FindOne(ctx, bson.M{"censusId": snapshot.CensusID},
+		options.FindOne().SetSort(bson.D{{Key: "version", Value: -1}})).Decode(latest)
+	switch {
+	case err == nil:
+		if bytes.Equal(latest.Digest, snapshot.Digest) && bytes.Equal(latest.Root, snapshot.Root) {
+			return latest, nil
+		}
+		snapshot.Version = latest.Version + 1
+	case err != mongo.ErrNoDocuments:
+		return nil, fmt.Errorf("failed to get latest census snapshot: %w", err)
+	}
+
+	// the participants go first, so a snapshot is never visible without its list
+	for start := 0; start < len(participants); start += snapshotInsertBatchSize {
+		end := min(start+snapshotInsertBatchSize, len(participants))
+		docs := make([]any, 0, end-start)
+		for i := range participants[start:end] {
+			participants[start+i].SnapshotID = snapshot.ID
+			docs = append(docs, participants[start+i])
+		}
+		if _, err := ms.censusSnapshotParticipants.InsertMany(ctx, docs); err != nil {
+			ms.deleteSnapshotParticipants(ctx, snapshot.ID)
+			return nil, fmt.Errorf("failed to store census snapshot participants: %w", err)
+		}
+	}
+	if _, err := ms.censusSnapshots.InsertOne(ctx, snapshot); err != nil {
+		ms.deleteSnapshotParticipants(ctx, snapshot.ID)
+		return nil, fmt.Errorf("failed to store census snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// deleteSnapshotParticipants removes the participants written for a snapshot that could not be
+// stored. Best-effort: the leftovers are unreachable anyway.
+func (ms *MongoStorage) deleteSnapshotParticipants(ctx context.Context, snapshotID primitive.ObjectID) {
+	if _, err := ms.censusSnapshotParticipants.DeleteMany(ctx, bson.M{"snapshotId": snapshotID}); err != nil {
+		log.Warnw("could not delete participants of a failed census snapshot", "snapshot", snapshotID.Hex(), "error", err)
+	}
+}
+
+// CensusSnapshots returns a page of the snapshots of a census, newest first.
+func (ms *MongoStorage) CensusSnapshots(censusID string, page, limit int64) (int64, []*CensusSnapshot, error) {
+	if censusID == "" {
+		return 0, nil, ErrInvalidData
+	}
+	filter := bson.M{"censusId": censusID}
+	findOptions := options.Find().SetSort(bson.D{{Key: "version", Value: -1}})
+	return paginatedDocuments[*CensusSnapshot](ms.censusSnapshots, page, limit, filter, findOptions)
+}
+
+// CensusSnapshot returns one snapshot of a census, or ErrNotFound.
+func (ms *MongoStorage) CensusSnapshot(censusID string, version int64) (*CensusSnapshot, error) {
+	if censusID == "" || version < 1 {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	snapshot := &CensusSnapshot{}
+	err := ms.censusSnapshots.FindOne(ctx, bson.M{"censusId": censusID, "version": version}).Decode(snapshot)
+	if err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get census snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// censusSnapshotWeights returns the weight of every participant of a snapshot, by hash.
+func (ms *MongoStorage) censusSnapshotWeights(ctx context.Context, snapshotID primitive.ObjectID,
+) (map[string]uint64, error) {
+	cursor, err := ms.censusSnapshotParticipants.Find(ctx, bson.M{"snapshotId": snapshotID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get census snapshot participants: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	weights := map[string]uint64{}
+	for cursor.Next(ctx) {
+		p := CensusSnapshotParticipant{}
+		if err := cursor.Decode(&p); err != nil {
+			return nil, fmt.Errorf("failed to decode census snapshot participant: %w", err)
+		}
+		weights[string(p.Hash)] = p.Weight
+	}
+	return weights, cursor.Err()
+}
+
+// DiffCensusSnapshots compares two snapshots of a census. Version 0 stands for the empty census,
+// so diffing from 0 lists every participant of the other snapshot as added. Returns ErrNotFound
+// if a version does not exist. The lists are sorted by hash.
+func (ms *MongoStorage) DiffCensusSnapshots(censusID string, from, to int64) (*CensusSnapshotDiff, error) {
+	if censusID == "" || from < 0 || to < 1 {
+		return nil, ErrInvalidData
+	}
+	diff := &CensusSnapshotDiff{
+		Added:      []CensusSnapshotParticipant{},
+		Removed:    []CensusSnapshotParticipant{},
+		Reweighted: []CensusReweight{},
+	}
+	var err error
+	if diff.To, err = ms.CensusSnapshot(censusID, to); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	fromWeights := map[string]uint64{}
+	if from > 0 {
+		if diff.From, err = ms.CensusSnapshot(censusID, from); err != nil {
+			return nil, err
+		}
+		if fromWeights, err = ms.censusSnapshotWeights(ctx, diff.From.ID); err != nil {
+			return nil, err
+		}
+	}
+	toWeights, err := ms.censusSnapshotWeights(ctx, diff.To.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	for hash, weight := range toWeights {
+		fromWeight, ok := fromWeights[hash]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, CensusSnapshotParticipant{Hash: internal.HexBytes(hash), Weight: weight})
+		case fromWeight != weight:
+			diff.Reweighted = append(diff.Reweighted, CensusReweight{
+				Hash: internal.HexBytes(hash), FromWeight: fromWeight, ToWeight: weight,
+			})
+		}
+	}
+	for hash, weight := range fromWeights {
+		if _, ok := toWeights[hash]; !ok {
+			diff.Removed = append(diff.Removed, CensusSnapshotParticipant{Hash: internal.HexBytes(hash), Weight: weight})
+		}
+	}
+	sort.Slice(diff.Added, func(i, j int) bool { return bytes.Compare(diff.Added[i].Hash, diff.Added[j].Hash) < 0 })
+	sort.Slice(diff.Removed, func(i, j int) bool { return bytes.Compare(diff.Removed[i].Hash, diff.Removed[j].Hash) < 0 })
+	sort.Slice(diff.Reweighted, func(i, j int) bool {
+		return bytes.Compare(diff.Reweighted[i].Hash, diff.Reweighted[j].Hash) < 0
+	})
+	return diff, nil
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestCensusSnapshots(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true,
+		TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+		Published:   PublishedCensus{Root: internal.HexBytes{0x01}, URI: "uri", CreatedAt: time.Now()},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	newParticipant := func(weight uint64) string {
+		id := primitive.NewObjectID()
+		_, err := testDB.orgMembers.InsertOne(ctx, &OrgMember{ID: id, OrgAddress: testOrgAddress, Weight: weight})
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{ParticipantID: id.Hex(), CensusID: censusID})
+		c.Assert(err, qt.IsNil)
+		return id.Hex()
+	}
+	newParticipant(1) // unchanged across snapshots
+	reweighted, removed := newParticipant(2), newParticipant(3)
+
+	first, err := testDB.CreateCensusSnapshot(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(first.Version, qt.Equals, int64(1))
+	c.Assert(first.Size, qt.Equals, int64(3))
+	c.Assert(first.TotalWeight, qt.Equals, uint64(6))
+	// publishing an unchanged census adds no version
+	again, err := testDB.CreateCensusSnapshot(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(again.ID, qt.Equals, first.ID)
+
+	added := newParticipant(5)
+	_, err = testDB.censusParticipants.DeleteOne(ctx, bson.M{"censusId": censusID, "participantID": removed})
+	c.Assert(err, qt.IsNil)
+	oid, err := primitive.ObjectIDFromHex(reweighted)
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.orgMembers.UpdateOne(ctx, bson.M{"_id": oid}, bson.M{"$set": bson.M{"weight": 4}})
+	c.Assert(err, qt.IsNil)
+	second, err := testDB.CreateCensusSnapshot(census)
+	c.Assert(err, qt.IsNil)
+	c.Assert(second.Version, qt.Equals, int64(2))
+	c.Assert(second.TotalWeight, qt.Equals, uint64(10))
+	c.Assert(second.Digest, qt.Not(qt.DeepEquals), first.Digest)
+
+	total, snapshots, err := testDB.CensusSnapshots(censusID, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(2))
+	c.Assert(snapshots[0].Version, qt.Equals, int64(2))
+
+	diff, err := testDB.DiffCensusSnapshots(censusID, 1, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(diff.Added, qt.DeepEquals, []CensusSnapshotParticipant{
+		{Hash: censusParticipantHash(censusID, added), Weight: 5},
+	})
+	c.Assert(diff.Removed, qt.DeepEquals, []CensusSnapshotParticipant{
+		{Hash: censusParticipantHash(censusID, removed), Weight: 3},
+	})
+	c.Assert(diff.Reweighted, qt.DeepEquals, []CensusReweight{
+		{Hash: censusParticipantHash(censusID, reweighted), FromWeight: 2, ToWeight: 4},
+	})
+
+	// from the empty census, every participant is new
+	diff, err = testDB.DiffCensusSnapshots(censusID, 0, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(diff.From, qt.IsNil)
+	c.Assert(diff.Added, qt.HasLen, 3)
+	c.Assert(diff.Removed, qt.HasLen, 0)
+
+	_, err = testDB.DiffCensusSnapshots(censusID, 1, 3)
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+
+	// deleting the census deletes its snapshots
+	c.Assert(testDB.DelCensus(censusID), qt.IsNil)
+	total, _, err = testDB.CensusSnapshots(censusID, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(0))
+	n, err := testDB.censusSnapshotParticipants.CountDocuments(ctx, bson.M{"censusId": censusID})
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, int64(0))
+}
@@ -0,0 +1,413 @@
+package db
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// WeightRuleType names how a WeightRule computes the weight of a participant.
+type WeightRuleType string
+
+const (
+	// WeightRuleConstant gives every participant the same weight.
+	WeightRuleConstant WeightRuleType = "constant"
+	// WeightRuleField takes the weight from a numeric member field.
+	WeightRuleField WeightRuleType = "field"
+	// WeightRuleLookup maps the value of a member field, e.g. a membership tier, to a weight.
+	WeightRuleLookup WeightRuleType = "lookup"
+	// WeightRuleExpression computes the weight with an arithmetic expression over numeric fields.
+	WeightRuleExpression WeightRuleType = "expression"
+)
+
+const (
+	// MaxRuleWeight caps the weight a rule gives a participant, so no census total can overflow.
+	MaxRuleWeight = math.MaxUint32
+	// maxWeightLookupEntries caps the values of a lookup rule.
+	maxWeightLookupEntries = 100
+	// maxWeightExpressionLength caps the length of an expression rule.
+	maxWeightExpressionLength = 256
+	// memberOtherFieldPrefix prefixes the member fields a rule reads from the member's extra data.
+	memberOtherFieldPrefix = "other."
+)
+
+// WeightRule computes the weight of the participants of a weighted census from their member data,
+// instead of taking OrgMember.Weight as is. A field names `weight` or a key of the member's extra
+// data as `other.<key>`; a missing field reads as 0, and as Default in a lookup. The weight is
+// computed when a member joins the census and stored on its participation, so later changes to
+// the member do not reweigh it.
+type WeightRule struct {
+	Type WeightRuleType `json:"type" bson:"type"`
+	// Value is the weight of a constant rule.
+	Value uint64 `json:"value,omitempty" bson:"value,omitempty"`
+	// Field is the member field a field or lookup rule reads.
+	Field string `json:"field,omitempty" bson:"field,omitempty"`
+	// Table maps the values of Field to weights in a lookup rule.
+	Table map[string]uint64 `json:"table,omitempty" bson:"table,omitempty"`
+	// Default is the weight of the values a lookup rule has no entry for.
+	Default uint64 `json:"default,omitempty" bson:"default,omitempty"`
+	// Expression is the formula of an expression rule: numbers, fields, + - * /, parentheses and
+	// min(...)/max(...). The result is rounded down.
+	Expression string `json:"expression,omitempty" bson:"expression,omitempty"`
+}
+
+// Validate checks the rule is complete and within bounds. A nil rule is valid: it means weights
+// are taken from OrgMember.Weight.
+func (r *WeightRule) Validate() error {
+	if r == nil {
+		return nil
+	}
+	_, err := r.weigher()
+	return err
+}
+
+// weigher compiles the rule into the function that weighs a member.
+func (r *WeightRule) weigher() (func(*OrgMember) (uint64, error), error) {
+	switch r.Type {
+	case WeightRuleConstant:
+		if r.Value > MaxRuleWeight {
+			return nil, fmt.Errorf("%w: weight above %d", ErrInvalidData, uint64(MaxRuleWeight))
+		}
+		return func(*OrgMember) (uint64, error) { return r.Value, nil }, nil
+	case WeightRuleField:
+		if !validWeightField(r.Field) {
+			return nil, fmt.Errorf("%w: invalid weight field %q", ErrInvalidData, r.Field)
+		}
+		return func(m *OrgMember) (uint64, error) {
+			value, err := memberNumericField(m, r.Field)
+			if err != nil {
+				return 0, err
+			}
+			return ruleWeight(value)
+		}, nil
+	case WeightRuleLookup:
+		if !validWeightField(r.Field) {
+			return nil, fmt.Errorf("%w: invalid weight field %q", ErrInvalidData, r.Field)
+		}
+		if len(r.Table) == 0 || len(r.Table) > maxWeightLookupEntries {
+			return nil, fmt.Errorf("%w: a weight lookup needs 1 to %d entries", ErrInvalidData, maxWeightLookupEntries)
+		}
+		for _, weight := range r.Table {
+			if weight > MaxRuleWeight {
+				return nil, fmt.Errorf("%w: weight above %d", ErrInvalidData, uint64(MaxRuleWeight))
+			}
+		}
+		if r.Default > MaxRuleWeight {
+			return nil, fmt.Errorf("%w: weight above %d", ErrInvalidData, uint64(MaxRuleWeight))
+		}
+		return func(m *OrgMember) (uint64, error) {
+			value, ok := memberField(m, r.Field)
+			if !ok {
+				return r.Default, nil
+			}
+			if weight, ok := r.Table[fmt.Sprint(value)]; ok {
+				return weight, nil
+			}
+			return r.Default, nil
+		}, nil
+	case WeightRuleExpression:
+		if len(r.Expression) > maxWeightExpressionLength {
+			return nil, fmt.Errorf("%w: weight expression longer than %d", ErrInvalidData, maxWeightExpressionLength)
+		}
+		expr, err := parseWeightExpression(r.Expression)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %w", ErrInvalidData, err)
+		}
+		return func(m *OrgMember) (uint64, error) {
+			value, err := expr.eval(m)
+			if err != nil {
+				return 0, err
+			}
+			return ruleWeight(value)
+		}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown weight rule type %q", ErrInvalidData, r.Type)
+	}
+}
+
+// validWeightField tells whether a rule may read field.
+func validWeightField(field string) bool {
+	if field == "weight" {
+		return true
+	}
+	key, ok := strings.CutPrefix(field, memberOtherFieldPrefix)
+	return ok && key != ""
+}
+
+// memberField returns the value of a rule field of m, and whether m has it.
+func memberField(m *OrgMember, field string) (any, bool) {
+	if field == "weight" {
+		return m.Weight, true
+	}
+	value, ok := m.Other[strings.TrimPrefix(field, memberOtherFieldPrefix)]
+	if value == nil {
+		return nil, false
+	}
+	return value, ok
+}
+
+// memberNumericField returns the value of a rule field of m as a number. Numeric strings, as a
+// spreadsheet import stores them, count as numbers; a missing field is 0.
+func memberNumericField(m *OrgMember, field string) (float64, error) {
+	value, ok := memberField(m, field)
+	if !ok {
+		return 0, nil
+	}
+	switch v := value.(type) {
+	case uint64:
+		return float64(v), nil
+	case int:
+		return float64(v), nil
+	case int32:
+		return float64(v), nil
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case string:
+		f, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w: field %s is not a number", ErrInvalidData, field)
+		}
+		return f, nil
+	default:
+		return 0, fmt.Errorf("%w: field %s is not a number", ErrInvalidData, field)
+	}
+}
+
+// ruleWeight rounds a computed value down to a weight, refusing what no weight can be.
+func ruleWeight(value float64) (uint64, error) {
+	if math.IsNaN(value) || value < 0 || value > MaxRuleWeight {
+		return 0, fmt.Errorf("%w: computed weight %v out of range", ErrInvalidData, value)
+	}
+	return uint64(math.Floor(value)), nil
+}
+
+// weightExpr is a node of a parsed expression rule.
+type weightExpr interface {
+	eval(m *OrgMember) (float64, error)
+}
+
+type (
+	weightNumber float64
+	weightField  string
+	weightNegate struct{ x weightExpr }
+	weightBinary struct {
+		op   string
+		l, r weightExpr
+	}
+	weightCall struct {
+		fn   string
+		args []weightExpr
+	}
+)
+
+func (n weightNumber) eval(*OrgMember) (float64, error) { return float64(n), nil }
+
+func (f weightField) eval(m *OrgMember) (float64, error) { return memberNumericField(m, string(f)) }
+
+func (n weightNegate) eval(m *OrgMember) (float64, error) {
+	x, err := n.x.eval(m)
+	return -x, err
+}
+
+func (b weightBinary) eval(m *OrgMember) (float64, error) {
+	l, err := b.l.eval(m)
+	if err != nil {
+		return 0, err
+	}
+	r, err := b.r.eval(m)
+	if err != nil {
+		return 0, err
+	}
+	switch b.op {
+	case "+":
+		return l + r, nil
+	case "-":
+		return l - r, nil
+	case "*":
+		return l * r, nil
+	default:
+		if r == 0 {
+			return 0, fmt.Errorf("%w: division by zero", ErrInvalidData)
+		}
+		return l / r, nil
+	}
+}
+
+func (c weightCall) eval(m *OrgMember) (float64, error) {
+	result := 0.0
+	for i, arg := range c.args {
+		x, err := arg.eval(m)
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 || (c.fn == "min" && x < result) || (c.fn == "max" && x > result) {
+			result = x
+		}
+	}
+	return result, nil
+}
+
+// weightExprParser is a recursive descent parser of expression rules:
+//
+//	expr   = term { ("+" | "-") term }
+//	term   = factor { ("*" | "/") factor }
+//	factor = number | field | ("min" | "max") "(" expr { "," expr } ")" | "(" expr ")" | "-" factor
+type weightExprParser struct {
+	tokens []string
+	pos    int
+}
+
+// parseWeightExpression parses an expression rule, checking every field it reads.
+func parseWeightExpression(s string) (weightExpr, error) {
+	tokens, err := tokenizeWeightExpression(s)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty weight expression")
+	}
+	p := &weightExprParser{tokens: tokens}
+	expr, err := p.expr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in weight expression", p.tokens[p.pos])
+	}
+	return expr, nil
+}
+
+// tokenizeWeightExpression splits an expression into numbers, names and operators.
+func tokenizeWeightExpression(s string) ([]string, error) {
+	var tokens []string
+	runes := []rune(s)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case strings.ContainsRune("+-*/(),", r):
+			tokens = append(tokens, string(r))
+			i++
+		case unicode.IsDigit(r) || r == '.':
+			start := i
+			for i < len(runes) && (unicode.IsDigit(runes[i]) || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		case unicode.IsLetter(r) || r == '_':
+			start := i
+			for i < len(runes) && (unicode.IsLetter(runes[i]) || unicode.IsDigit(runes[i]) ||
+				runes[i] == '_' || runes[i] == '.') {
+				i++
+			}
+			tokens = append(tokens, string(runes[start:i]))
+		default:
+			return nil, fmt.Errorf("unexpected %q in weight expression", r)
+		}
+	}
+	return tokens, nil
+}
+
+func (p *weightExprParser) peek() string {
+	if p.pos < len(p.tokens) {
+		return p.tokens[p.pos]
+	}
+	return ""
+}
+
+func (p *weightExprParser) expect(token string) error {
+	if p.peek() != token {
+		return fmt.Errorf("expected %q in weight expression", token)
+	}
+	p.pos++
+	return nil
+}
+
+func (p *weightExprParser) expr() (weightExpr, error) {
+	l, err := p.term()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "+" || op == "-"; op = p.peek() {
+		p.pos++
+		r, err := p.term()
+		if err != nil {
+			return nil, err
+		}
+		l = weightBinary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *weightExprParser) term() (weightExpr, error) {
+	l, err := p.factor()
+	if err != nil {
+		return nil, err
+	}
+	for op := p.peek(); op == "*" || op == "/"; op = p.peek() {
+		p.pos++
+		r, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		l = weightBinary{op: op, l: l, r: r}
+	}
+	return l, nil
+}
+
+func (p *weightExprParser) factor() (weightExpr, error) {
+	token := p.peek()
+	switch {
+	case token == "":
+		return nil, fmt.Errorf("incomplete weight expression")
+	case token == "-":
+		p.pos++
+		x, err := p.factor()
+		if err != nil {
+			return nil, err
+		}
+		return weightNegate{x: x}, nil
+	case token == "(":
+		p.pos++
+		x, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return x, p.expect(")")
+	case token == "min" || token == "max":
+		p.pos++
+		if err := p.expect("("); err != nil {
+			return nil, err
+		}
+		call := weightCall{fn: token}
+		for {
+			arg, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			call.args = append(call.args, arg)
+			if p.peek() != "," {
+				break
+			}
+			p.pos++
+		}
+		return call, p.expect(")")
+	case unicode.IsDigit(rune(token[0])) || token[0] == '.':
+		p.pos++
+		n, err := strconv.ParseFloat(token, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid number %q in weight expression", token)
+		}
+		return weightNumber(n), nil
+	case validWeightField(token):
+		p.pos++
+		return weightField(token), nil
+	default:
+		return nil, fmt.Errorf("unknown field %q in weight expression", token)
+	}
+}
@@ -0,0 +1,177 @@
+package db
+
+import (
+	"context"
+	"math"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestWeightRules(t *testing.T) {
+	c := qt.New(t)
+	member := &OrgMember{Weight: 7, Other: map[string]any{"tier": "gold", "shares": "12", "years": 3.5}}
+	weigh := func(rule WeightRule) (uint64, error) {
+		w, err := rule.weigher()
+		if err != nil {
+			return 0, err
+		}
+		return w(member)
+	}
+
+	for _, tc := range []struct {
+		rule   WeightRule
+		weight uint64
+	}{
+		{WeightRule{Type: WeightRuleConstant, Value: 4}, 4},
+		{WeightRule{Type: WeightRuleField, Field: "weight"}, 7},
+		{WeightRule{Type: WeightRuleField, Field: "other.shares"}, 12},
+		{WeightRule{Type: WeightRuleField, Field: "other.missing"}, 0},
+		{WeightRule{Type: WeightRuleLookup, Field: "other.tier", Table: map[string]uint64{"gold": 3}, Default: 1}, 3},
+		{WeightRule{Type: WeightRuleLookup, Field: "other.none", Table: map[string]uint64{"gold": 3}, Default: 1}, 1},
+		{WeightRule{Type: WeightRuleExpression, Expression: "other.shares * 2 + other.years"}, 27},
+		{WeightRule{Type: WeightRuleExpression, Expression: "max(1, min(weight, 5)) - -1"}, 6},
+		{WeightRule{Type: WeightRuleExpression, Expression: "(weight + 1) / 3"}, 2},
+	} {
+		weight, err := weigh(tc.rule)
+		c.Assert(err, qt.IsNil, qt.Commentf("%+v", tc.rule))
+		c.Assert(weight, qt.Equals, tc.weight, qt.Commentf("%+v", tc.rule))
+	}
+
+	// incomplete rules are rejected up front
+	for _, rule := range []WeightRule{
+		{Type: "percent"},
+		{Type: WeightRuleConstant, Value: MaxRuleWeight + 1},
+		{Type: WeightRuleField, Field: "email"},
+		{Type: WeightRuleField, Field: "other."},
+		{Type: WeightRuleLookup, Field: "other.tier"},
+		{Type: WeightRuleExpression, Expression: "weight +"},
+		{Type: WeightRuleExpression, Expression: "pow(weight, 2)"},
+		{Type: WeightRuleExpression, Expression: "(weight"},
+	} {
+		c.Assert(rule.Validate(), qt.ErrorIs, ErrInvalidData, qt.Commentf("%+v", rule))
+	}
+	c.Assert((*WeightRule)(nil).Validate(), qt.IsNil)
+
+	// members the rule cannot weigh fail on evaluation
+	for _, rule := range []WeightRule{
+		{Type: WeightRuleField, Field: "other.tier"},
+		{Type: WeightRuleExpression, Expression: "weight / 0"},
+		{Type: WeightRuleExpression, Expression: "1 - weight"},
+	} {
+		_, err := weigh(rule)
+		c.Assert(err, qt.IsNotNil, qt.Commentf("%+v", rule))
+	}
+}
+
+func TestCensusWeightRule(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{
+		OrgAddress: testOrgAddress, Weighted: true,
+		AuthFields: OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		WeightRule: &WeightRule{
+			Type: WeightRuleLookup, Field: "other.tier", Table: map[string]uint64{"gold": 3, "silver": 2}, Default: 1,
+		},
+	}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	newMember := func(number string, tier any) *OrgMember {
+		member := &OrgMember{
+			ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, MemberNumber: number,
+			Weight: 100, Other: map[string]any{"tier": tier},
+		}
+		_, err := testDB.orgMembers.InsertOne(ctx, member)
+		c.Assert(err, qt.IsNil)
+		return member
+	}
+	gold, silver, other := newMember("1", "gold"), newMember("2", "silver"), newMember("3", "bronze")
+
+	added, memberErrs, err := testDB.AddCensusParticipantsByMemberIDs(censusID,
+		[]string{gold.ID.Hex(), silver.ID.Hex(), other.ID.Hex()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(memberErrs, qt.HasLen, 0)
+	c.Assert(added, qt.Equals, 3)
+
+	total, err := testDB.CensusTotalWeight(censusID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(6))
+	weight, err := testDB.VoterWeight(census, gold)
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(3))
+
+	// the weight is frozen when the member joins: a later tier change does not reweigh it
+	_, err = testDB.orgMembers.UpdateOne(ctx, bson.M{"_id": gold.ID}, bson.M{"$set": bson.M{"other.tier": "silver"}})
+	c.Assert(err, qt.IsNil)
+	weight, err = testDB.VoterWeight(census, gold)
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(3))
+
+	// without a rule the member weight applies, and a non-weighted census weighs everyone 1
+	weight, err = testDB.VoterWeight(&Census{ID: census.ID, Weighted: true}, silver)
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(100))
+	weight, err = testDB.VoterWeight(&Census{ID: census.ID}, silver)
+	c.Assert(err, qt.IsNil)
+	c.Assert(weight, qt.Equals, uint64(1))
+}
+
+func TestGroupCensusWeightErrors(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	newGroup := func(members ...*OrgMember) string {
+		ids := make([]string, 0, len(members))
+		for _, member := range members {
+			member.ID, member.OrgAddress = primitive.NewObjectID(), testOrgAddress
+			_, err := testDB.orgMembers.InsertOne(ctx, member)
+			c.Assert(err, qt.IsNil)
+			ids = append(ids, member.ID.Hex())
+		}
+		groupID, err := testDB.CreateOrganizationMemberGroup(&OrganizationMemberGroup{
+			OrgAddress: testOrgAddress, Title: "group", MemberIDs: ids,
+		})
+		c.Assert(err, qt.IsNil)
+		return groupID
+	}
+	newCensus := func(rule *WeightRule) *Census {
+		census := &Census{
+			OrgAddress: testOrgAddress, Weighted: true, WeightRule: rule,
+			AuthFields: OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		}
+		_, err := testDB.SetCensus(census)
+		c.Assert(err, qt.IsNil)
+		return census
+	}
+
+	// a member the rule cannot weigh keeps the whole group out, and is named
+	unweighable := &OrgMember{MemberNumber: "2", Other: map[string]any{"shares": "many"}}
+	groupID := newGroup(&OrgMember{MemberNumber: "1", Other: map[string]any{"shares": "3"}}, unweighable)
+	census := newCensus(&WeightRule{Type: WeightRuleField, Field: "other.shares"})
+	_, err := testDB.PopulateGroupCensus(census, groupID)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	c.Assert(err.Error(), qt.Contains, unweighable.ID.Hex())
+	count, err := testDB.CountCensusParticipants(census.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(0))
+
+	// so does a member that would take the total weight past what it can hold
+	groupID = newGroup(&OrgMember{MemberNumber: "3", Weight: 1}, &OrgMember{MemberNumber: "4", Weight: math.MaxInt64})
+	census = newCensus(nil)
+	_, err = testDB.PopulateGroupCensus(census, groupID)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	c.Assert(err.Error(), qt.Contains, "census total weight overflow")
+	count, err = testDB.CountCensusParticipants(census.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(0))
+}
@@ -42,6 +42,22 @@ const (
 	VotingTypeMultiChoice  = "multichoice"
 	VotingTypeRanked       = "ranked"
 	VotingTypeCumulative   = "cumulative"
+
+	// Counting methods of a question's tally outcome, one per named ballot type.
+	TallyMethodPlurality = "plurality"
+	TallyMethodApproval  = "approval"
+	TallyMethodBorda     = "borda"
+	TallyMethodPoints    = "points"
+
+	// Majorities a question's decision rules can require of the choice they decide on.
+	MajoritySimple    = "simple"
+	MajorityAbsolute  = "absolute"
+	MajorityTwoThirds = "twoThirds"
+
+	// Decisions of a question with decision rules.
+	DecisionPending = "pending"
+	DecisionPassed  = "passed"
+	DecisionFailed  = "failed"
 )
 
 // IsNamedVotingType reports whether t is one of the named question ballot types the question API
@@ -19,9 +19,10 @@ type CSPAuth struct {
 	Token internal.HexBytes `json:"token" bson:"_id"`
 	// UserID is the member ObjectID (hex) the token authenticates.
 	UserID internal.HexBytes `json:"userID" bson:"userid"`
-	// BundleID is the token's anchor: a process-bundle id in the legacy bundle flow, or a
-	// voting-process id in the new /processes flow. It only binds the token and gates the
-	// resend cooldown; per-election signing/consumption keys on the election id separately.
+	// BundleID is the token's anchor: a process-bundle id in the legacy bundle flow, a
+	// voting-process id in the new /processes flow, or MemberSelfServiceAnchor for the member
+	// self-service portal. It only binds the token and gates the resend cooldown;
+	// per-election signing/consumption keys on the election id separately.
 	BundleID  internal.HexBytes `json:"bundleID" bson:"bundleid"`
 	CreatedAt time.Time         `json:"createdAt" bson:"createdat"`
 	// Secret is the per-token OTP challenge secret. It must never leave the
@@ -30,6 +31,9 @@ type CSPAuth struct {
 	Attempts   int       `json:"attempts" bson:"attempts"`
 	Verified   bool      `json:"verified" bson:"verified"`
 	VerifiedAt time.Time `json:"verifiedAt" bson:"verifiedat"`
+	// MaskedPhone is the masked phone number a member self-service token was sent to, the only
+	// form of it the portal can show: members only store the hash of their phone.
+	MaskedPhone string `json:"-" bson:"maskedphone,omitempty"`
 }
 
 // CSPProcess is the status of a process in a bundle of processes for a user
@@ -131,6 +135,26 @@ func (ms *MongoStorage) VerifyCSPAuth(token internal.HexBytes) error {
 	return nil
 }
 
+// SetCSPAuthMaskedPhone records on a CSP authentication token the masked phone number its code
+// was sent to. It returns ErrTokenNotFound if the token does not exist.
+func (ms *MongoStorage) SetCSPAuthMaskedPhone(token internal.HexBytes, maskedPhone string) error {
+	if token == nil {
+		return ErrBadInputs
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.cspTokens.UpdateOne(ctx, bson.M{"_id": token}, bson.M{"$set": bson.M{"maskedphone": maskedPhone}})
+	if err != nil {
+		return errors.Join(ErrStoreToken, err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrTokenNotFound
+	}
+	return nil
+}
+
 // IncrementCSPAuthAttempts atomically records a failed verification attempt for
 // the given token, but only while the stored attempt count is still below
 // maxAttempts. It returns recorded=true when the attempt was counted, and
@@ -459,6 +483,43 @@ func (ms *MongoStorage) MembersWithUsedCSPProcess(
 	return result, nil
 }
 
+// DeleteCSPAuth removes a single CSP authentication token, so it cannot be used again.
+func (ms *MongoStorage) DeleteCSPAuth(token internal.HexBytes) error {
+	if token == nil {
+		return ErrBadInputs
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := ms.cspTokens.DeleteOne(ctx, bson.M{"_id": token}); err != nil {
+		return fmt.Errorf("failed to delete CSP auth token: %w", err)
+	}
+	return nil
+}
+
+// ConsumeCSPAuth atomically removes a verified CSP authentication token of the given bundle, so
+// that, of several requests holding it, exactly one gets to use it. It returns ErrTokenNotFound
+// when no such token is left, as when another request consumed it first.
+func (ms *MongoStorage) ConsumeCSPAuth(token, bundleID internal.HexBytes) (*CSPAuth, error) {
+	if token == nil || bundleID == nil {
+		return nil, ErrBadInputs
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	auth := new(CSPAuth)
+	filter := bson.M{"_id": token, "bundleid": bundleID, "verified": true}
+	if err := ms.cspTokens.FindOneAndDelete(ctx, filter).Decode(auth); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrTokenNotFound
+		}
+		return nil, fmt.Errorf("failed to consume CSP auth token: %w", err)
+	}
+	return auth, nil
+}
+
 // DeleteCSPAuthByBundle removes every CSP authentication token tied to the given bundle.
 // It is a best-effort cleanup used when tearing down an organization (the bundle's
 // processes share a common census/auth flow). Returns the number of deleted tokens.
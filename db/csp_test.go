@@ -98,6 +98,30 @@ func TestVerifyCSPAuth(t *testing.T) {
 	})
 }
 
+func TestConsumeCSPAuth(t *testing.T) {
+	c := qt.New(t)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	_, err := testDB.ConsumeCSPAuth(nil, testCSPBundleID)
+	c.Assert(err, qt.ErrorIs, ErrBadInputs)
+	c.Assert(testDB.SetCSPAuth(testAuthToken, testUserID, testCSPBundleID, ""), qt.IsNil)
+	// an unverified token, or one of another bundle, is not consumed
+	_, err = testDB.ConsumeCSPAuth(testAuthToken, testCSPBundleID)
+	c.Assert(err, qt.ErrorIs, ErrTokenNotFound)
+	c.Assert(testDB.VerifyCSPAuth(testAuthToken), qt.IsNil)
+	_, err = testDB.ConsumeCSPAuth(testAuthToken, invalidAuthToken)
+	c.Assert(err, qt.ErrorIs, ErrTokenNotFound)
+
+	// a verified token is consumed once
+	auth, err := testDB.ConsumeCSPAuth(testAuthToken, testCSPBundleID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(auth.UserID, qt.DeepEquals, testUserID)
+	_, err = testDB.ConsumeCSPAuth(testAuthToken, testCSPBundleID)
+	c.Assert(err, qt.ErrorIs, ErrTokenNotFound)
+	_, err = testDB.CSPAuth(testAuthToken)
+	c.Assert(err, qt.ErrorIs, ErrTokenNotFound)
+}
+
 func TestIncrementCSPAuthAttempts(t *testing.T) {
 	c := qt.New(t)
 	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
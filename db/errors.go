@@ -1,6 +1,9 @@
 package db
 
-import "fmt"
+import (
+	"fmt"
+	"strings"
+)
 
 var (
 	ErrNotFound      = fmt.Errorf("not found")
@@ -30,8 +33,32 @@ var (
 	// ErrManagedQuotaReached is returned when an atomic integrator-quota reservation would
 	// exceed the integrator's managed-orgs, managed-processes or managed-census-size limit.
 	ErrManagedQuotaReached = fmt.Errorf("integrator managed quota reached")
+	// ErrCensusFrozen is returned when a write would change the participants of a frozen census.
+	ErrCensusFrozen = fmt.Errorf("census is frozen")
+	// ErrOngoingElection is returned when a write would re-point the participants of a census while
+	// one of its elections can still be voted.
+	ErrOngoingElection = fmt.Errorf("census has an ongoing election")
+	// ErrDelegationNotAllowed is returned when delegating a vote in a census without proxies.
+	ErrDelegationNotAllowed = fmt.Errorf("census does not allow vote delegation")
+	// ErrDelegationsLocked is returned when a delegation changes once voting started with the census.
+	ErrDelegationsLocked = fmt.Errorf("delegations are locked once voting starts")
+	// ErrProxyLimitReached is returned when a delegate already holds the census MaxProxies.
+	ErrProxyLimitReached = fmt.Errorf("delegate holds the maximum number of proxies")
+	// ErrVoteDelegated is returned when weighing the vote of a member who delegated it.
+	ErrVoteDelegated = fmt.Errorf("vote delegated to another member")
 )
 
+// OngoingElectionError is ErrOngoingElection naming the processes holding the elections.
+type OngoingElectionError struct {
+	ProcessIDs []string
+}
+
+func (e *OngoingElectionError) Error() string {
+	return fmt.Sprintf("%v: %s", ErrOngoingElection, strings.Join(e.ProcessIDs, ", "))
+}
+
+func (e *OngoingElectionError) Unwrap() error { return ErrOngoingElection }
+
 // errorsAsStrings converts a slice of errors to a slice of strings
 func errorsAsStrings(errs []error) []string {
 	s := []string{}
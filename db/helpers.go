@@ -16,26 +16,35 @@ import (
 // in the MongoStorage struct. This is used by both init and Reset methods.
 func (ms *MongoStorage) collectionsMap() map[string]**mongo.Collection {
 	return map[string]**mongo.Collection{
-		"users":               &ms.users,
-		"verifications":       &ms.verifications,
-		"organizations":       &ms.organizations,
-		"organizationInvites": &ms.organizationInvites,
-		"plans":               &ms.plans,
-		"objects":             &ms.objects,
-		"census":              &ms.censuses,
-		"orgMembers":          &ms.orgMembers,
-		"orgMemberGroups":     &ms.orgMemberGroups,
-		"censusParticipants":  &ms.censusParticipants,
-		"publishedCensuses":   &ms.publishedCensuses,
-		"processes":           &ms.processes,
-		"processBundles":      &ms.processBundles,
-		"votingProcesses":     &ms.votingProcesses,
-		"processesQuestions":  &ms.processesQuestions,
-		"cspTokens":           &ms.cspTokens,
-		"cspTokensStatus":     &ms.cspTokensStatus,
-		"jobs":                &ms.jobs,
-		"apiKeys":             &ms.apiKeys,
-		"migrations":          &ms.migrations,
+		"users":                      &ms.users,
+		"verifications":              &ms.verifications,
+		"organizations":              &ms.organizations,
+		"organizationInvites":        &ms.organizationInvites,
+		"plans":                      &ms.plans,
+		"objects":                    &ms.objects,
+		"census":                     &ms.censuses,
+		"orgMembers":                 &ms.orgMembers,
+		"orgMemberGroups":            &ms.orgMemberGroups,
+		"censusParticipants":         &ms.censusParticipants,
+		"publishedCensuses":          &ms.publishedCensuses,
+		"processes":                  &ms.processes,
+		"processBundles":             &ms.processBundles,
+		"votingProcesses":            &ms.votingProcesses,
+		"processesQuestions":         &ms.processesQuestions,
+		"cspTokens":                  &ms.cspTokens,
+		"cspTokensStatus":            &ms.cspTokensStatus,
+		"jobs":                       &ms.jobs,
+		"apiKeys":                    &ms.apiKeys,
+		"memberUpdates":              &ms.memberUpdates,
+		"memberHistory":              &ms.memberHistory,
+		"suppressions":               &ms.suppressions,
+		"censusSnapshots":            &ms.censusSnapshots,
+		"censusSnapshotParticipants": &ms.censusSnapshotParticipants,
+		"censusExports":              &ms.censusExports,
+		"ephemeralMembers":           &ms.ephemeralMembers,
+		"censusDelegations":          &ms.censusDelegations,
+		"processTemplates":           &ms.processTemplates,
+		"migrations":                 &ms.migrations,
 	}
 }
 
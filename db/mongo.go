@@ -34,26 +34,35 @@ type MongoStorage struct {
 	DBClient *mongo.Client
 	keysLock sync.RWMutex
 
-	users               *mongo.Collection
-	verifications       *mongo.Collection
-	organizations       *mongo.Collection
-	organizationInvites *mongo.Collection
-	plans               *mongo.Collection
-	objects             *mongo.Collection
-	orgMembers          *mongo.Collection
-	orgMemberGroups     *mongo.Collection
-	censusParticipants  *mongo.Collection
-	censuses            *mongo.Collection
-	publishedCensuses   *mongo.Collection
-	processes           *mongo.Collection
-	processBundles      *mongo.Collection
-	votingProcesses     *mongo.Collection
-	processesQuestions  *mongo.Collection
-	cspTokens           *mongo.Collection
-	cspTokensStatus     *mongo.Collection
-	jobs                *mongo.Collection
-	apiKeys             *mongo.Collection
-	migrations          *mongo.Collection
+	users                      *mongo.Collection
+	verifications              *mongo.Collection
+	organizations              *mongo.Collection
+	organizationInvites        *mongo.Collection
+	plans                      *mongo.Collection
+	objects                    *mongo.Collection
+	orgMembers                 *mongo.Collection
+	orgMemberGroups            *mongo.Collection
+	censusParticipants         *mongo.Collection
+	censuses                   *mongo.Collection
+	publishedCensuses          *mongo.Collection
+	processes                  *mongo.Collection
+	processBundles             *mongo.Collection
+	votingProcesses            *mongo.Collection
+	processesQuestions         *mongo.Collection
+	cspTokens                  *mongo.Collection
+	cspTokensStatus            *mongo.Collection
+	jobs                       *mongo.Collection
+	apiKeys                    *mongo.Collection
+	memberUpdates              *mongo.Collection
+	memberHistory              *mongo.Collection
+	suppressions               *mongo.Collection
+	censusSnapshots            *mongo.Collection
+	censusSnapshotParticipants *mongo.Collection
+	censusExports              *mongo.Collection
+	ephemeralMembers           *mongo.Collection
+	censusDelegations          *mongo.Collection
+	processTemplates           *mongo.Collection
+	migrations                 *mongo.Collection
 }
 
 type Options struct {
@@ -47,12 +47,17 @@ func (ms *MongoStorage) SetOrgMember(salt string, orgMember *OrgMember) (string,
 	}
 	ms.keysLock.Lock()
 	defer ms.keysLock.Unlock()
+	before, err := ms.storedOrgMember(ctx, member.ID)
+	if err != nil {
+		return "", err
+	}
 	filter := bson.M{"_id": member.ID}
 	opts := options.Update().SetUpsert(true)
 	_, err = ms.orgMembers.UpdateOne(ctx, filter, updateDoc, opts)
 	if err != nil {
 		return "", err
 	}
+	ms.recordMemberWrite(ctx, before, member.ID, MemberChangeSource{})
 
 	// Ensure the auto group exists now that at least one member is present.
 	if err := ms.EnsureAutoMemberGroup(orgMember.OrgAddress); err != nil {
@@ -101,6 +106,7 @@ func (ms *MongoStorage) DelOrgMember(id string) ([]VotingProcessQuestion, error)
 	if err != nil {
 		return nil, err
 	}
+	ms.recordMemberVersions(deletedMemberVersions(existing.OrgAddress, []string{id}, MemberChangeSource{}, nil))
 
 	// Clean up the auto group if the org now has no members.
 	if err := ms.DeleteAutoMemberGroupIfEmpty(existing.OrgAddress); err != nil {
@@ -109,6 +115,19 @@ func (ms *MongoStorage) DelOrgMember(id string) ([]VotingProcessQuestion, error)
 	return emptied, nil
 }
 
+// storedOrgMember reads the member with the given id as stored, or nil if there is none.
+func (ms *MongoStorage) storedOrgMember(ctx context.Context, id primitive.ObjectID) (*OrgMember, error) {
+	stored := &OrgMember{}
+	switch err := ms.orgMembers.FindOne(ctx, bson.M{"_id": id}).Decode(stored); {
+	case err == nil:
+		return stored, nil
+	case errors.Is(err, mongo.ErrNoDocuments):
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("failed to read stored org member: %w", err)
+	}
+}
+
 // OrgMember retrieves a orgMember from the DB based on it ID
 func (ms *MongoStorage) OrgMember(orgAddress common.Address, id string) (*OrgMember, error) {
 	objID, err := primitive.ObjectIDFromHex(id)
@@ -122,6 +141,9 @@ func (ms *MongoStorage) OrgMember(orgAddress common.Address, id string) (*OrgMem
 
 	orgMember := &OrgMember{}
 	if err = ms.orgMembers.FindOne(ctx, bson.M{"_id": objID, "orgAddress": orgAddress}).Decode(orgMember); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, ErrNotFound
+		}
 		return nil, fmt.Errorf("failed to get orgMember: %w", err)
 	}
 
@@ -157,6 +179,7 @@ type BulkOrgMembersJob struct {
 	Progress int
 	Total    int
 	Added    int
+	Updated  int
 	Errors   []error
 	// MemberIDs are the hex ids of the members actually inserted, so the caller can propagate
 	// them to the censuses the organization's auto group backs.
@@ -173,18 +196,21 @@ func (j *BulkOrgMembersJob) ErrorsAsStrings() []string {
 // the value it received while the batch loop is still writing, so every send is a snapshot taken
 // under the mutex.
 type bulkOrgMembersProgress struct {
-	mu  sync.Mutex
-	job BulkOrgMembersJob
+	mu        sync.Mutex
+	job       BulkOrgMembersJob
+	processed int
 }
 
-// record folds one finished batch into the job state.
-func (p *bulkOrgMembersProgress) record(memberIDs []string, errs []error) {
+// record folds one finished batch of rows into the job state.
+func (p *bulkOrgMembersProgress) record(rows int, memberIDs []string, updated int, errs []error) {
 	p.mu.Lock()
 	defer p.mu.Unlock()
+	p.processed += rows
 	p.job.Added += len(memberIDs)
+	p.job.Updated += updated
 	p.job.MemberIDs = append(p.job.MemberIDs, memberIDs...)
 	p.job.Errors = append(p.job.Errors, errs...)
-	p.job.Progress = int(float64(p.job.Added) / float64(p.job.Total) * 100)
+	p.job.Progress = int(float64(p.processed) / float64(p.job.Total) * 100)
 }
 
 // snapshot returns a copy that stays valid once the next batch starts writing.
@@ -264,33 +290,67 @@ func prepareOrgMember(org *Organization, m *OrgMember, salt string, currentTime
 	return &member, errs
 }
 
-// createOrgMemberBulkOperations creates a batch of members using bulk write operations,
-// and returns the hex ids of the members inserted plus any errors encountered.
-// firstLine is the 1-based position of members[0] in the originally submitted list;
-// validation errors are prefixed with the line each offending member sits at, so
-// users can locate it in the imported file.
+// createOrgMemberBulkOperations carries out a batch of the steps of an import plan: it inserts the
+// new members using bulk write operations and upserts the matched ones, and returns the hex ids of
+// the members inserted, how many were updated, and any errors encountered. Errors are prefixed with
+// the line of the offending row, so users can locate it in the imported file: the invalid values a
+// row was imported without, why a row was rejected, and the writes that failed.
 func (ms *MongoStorage) createOrgMemberBulkOperations(
-	org *Organization,
-	members []*OrgMember,
-	salt string,
-	currentTime time.Time,
-	firstLine int,
-) ([]string, []error) {
+	plan *OrgMemberImportPlan,
+	steps []*orgMemberImportStep,
+	source MemberChangeSource,
+) ([]string, int, []error) {
 	var preparedMembers []any
+	var updates []*orgMemberImportStep
 	var errs []error
+	byID := make(map[primitive.ObjectID]*OrgMember, len(steps))
+
+	for _, step := range steps {
+		for _, err := range step.errs {
+			errs = append(errs, fmt.Errorf("line %d: %w", step.line, err))
+		}
+		switch step.action {
+		case OrgMemberImportReject:
+			for _, reason := range step.reasons {
+				errs = append(errs, fmt.Errorf("line %d: %w: %s", step.line, ErrInvalidData, reason))
+			}
+		case OrgMemberImportUpdate:
+			updates = append(updates, step)
+		default:
+			preparedMembers = append(preparedMembers, step.member)
+			byID[step.member.ID] = step.member
+		}
+	}
 
-	for i, m := range members {
-		// Prepare the member
-		member, validationErrors := prepareOrgMember(org, m, salt, currentTime)
-		for _, err := range validationErrors {
-			errs = append(errs, fmt.Errorf("line %d: %w", firstLine+i, err))
+	insertedIDs, insertErrs := ms.insertOrgMembers(plan.org, preparedMembers, byID, steps, source)
+	errs = append(errs, insertErrs...)
+
+	// an update goes through the same upsert as a single member write, which rehashes the member
+	// in every census it belongs to; it takes the lock itself
+	updated := 0
+	for _, step := range updates {
+		if _, _, err := ms.UpsertOrgMemberAndCensusParticipants(plan.org, step.input, plan.salt, source); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", step.line, err))
+			continue
 		}
-		preparedMembers = append(preparedMembers, member)
+		updated++
 	}
+	return insertedIDs, updated, errs
+}
 
+// insertOrgMembers inserts the prepared members of a batch of steps and records their creation in
+// the member history, returning the hex ids inserted.
+func (ms *MongoStorage) insertOrgMembers(
+	org *Organization,
+	preparedMembers []any,
+	byID map[primitive.ObjectID]*OrgMember,
+	steps []*orgMemberImportStep,
+	source MemberChangeSource,
+) ([]string, []error) {
 	if len(preparedMembers) == 0 {
-		return nil, errs
+		return nil, nil
 	}
+	var errs []error
 
 	// Only lock the mutex during the actual database operations
 	ms.keysLock.Lock()
@@ -301,16 +361,18 @@ func (ms *MongoStorage) createOrgMemberBulkOperations(
 	defer batchCancel()
 
 	// Execute the bulk write operations
+	currentTime := time.Now()
 	result, err := ms.orgMembers.InsertMany(batchCtx, preparedMembers)
 	if err != nil {
 		log.Warnw("error during bulk addition of members batch", "error", err)
-		errs = append(errs, fmt.Errorf("lines %d-%d: %w", firstLine, firstLine+len(members)-1, err))
+		errs = append(errs, fmt.Errorf("lines %d-%d: %w", steps[0].line, steps[len(steps)-1].line, err))
 	}
 	if result == nil {
 		return nil, errs
 	}
 
 	insertedIDs := make([]string, 0, len(result.InsertedIDs))
+	versions := make([]*MemberVersion, 0, len(result.InsertedIDs))
 	for _, id := range result.InsertedIDs {
 		oid, ok := id.(primitive.ObjectID)
 		if !ok {
@@ -320,7 +382,10 @@ func (ms *MongoStorage) createOrgMemberBulkOperations(
 			continue
 		}
 		insertedIDs = append(insertedIDs, oid.Hex())
+		versions = append(versions, newMemberVersion(org.Address, oid.Hex(), MemberCreated, source,
+			diffOrgMembers(nil, byID[oid]), currentTime))
 	}
+	ms.recordMemberVersions(versions)
 
 	return insertedIDs, errs
 }
@@ -355,22 +420,20 @@ func startOrgMemberProgressReporter(
 	}
 }
 
-// addOrgMemberBatches processes new members in batches and sends progress updates
+// addOrgMemberBatches carries out the steps of an import plan in batches and sends progress updates
 func (ms *MongoStorage) addOrgMemberBatches(
-	org *Organization,
-	orgMembers []*OrgMember,
-	salt string,
+	plan *OrgMemberImportPlan,
+	source MemberChangeSource,
 	progressChan chan<- *BulkOrgMembersJob,
 ) {
-	if len(orgMembers) == 0 {
+	if len(plan.steps) == 0 {
 		close(progressChan)
 		return
 	}
 
 	// Process members in batches of 200
 	batchSize := 200
-	total := len(orgMembers)
-	currentTime := time.Now()
+	total := len(plan.steps)
 
 	progress := &bulkOrgMembersProgress{job: BulkOrgMembersJob{Total: total, Errors: []error{}}}
 
@@ -392,22 +455,16 @@ func (ms *MongoStorage) addOrgMemberBatches(
 		end := min(start+batchSize, total)
 
 		// Process the batch and get the ids of the members added
-		memberIDs, errs := ms.createOrgMemberBulkOperations(
-			org,
-			orgMembers[start:end],
-			salt,
-			currentTime,
-			start+1,
-		)
+		memberIDs, updated, errs := ms.createOrgMemberBulkOperations(plan, plan.steps[start:end], source)
 
-		progress.record(memberIDs, errs)
+		progress.record(end-start, memberIDs, updated, errs)
 	}
 
 	// Ensure the auto group exists now that members have been added.
 	if progress.snapshot().Added > 0 {
 		ms.keysLock.Lock()
 		defer ms.keysLock.Unlock()
-		if err := ms.EnsureAutoMemberGroup(org.Address); err != nil {
+		if err := ms.EnsureAutoMemberGroup(plan.org.Address); err != nil {
 			log.Warnw("could not ensure auto member group after bulk add", "error", err)
 		}
 	}
@@ -417,19 +474,40 @@ func (ms *MongoStorage) addOrgMemberBatches(
 // Requires an existing organization.
 // Returns a channel that sends the percentage of members processed every 10 seconds.
 // This function must be called in a goroutine.
+// Every member inserted is recorded in the member history as created by source.
 func (ms *MongoStorage) AddBulkOrgMembers(org *Organization, members []*OrgMember, salt string,
+	source MemberChangeSource,
 ) (chan *BulkOrgMembersJob, error) {
 	// Early returns for invalid input
 	if len(members) == 0 {
 		return nil, nil // Not an error, just no work to do
 	}
-	if org.Address.Cmp(common.Address{}) == 0 {
+	plan, err := ms.PlanBulkOrgMembers(org, members, salt, false)
+	if err != nil {
+		return nil, err
+	}
+	return ms.ImportBulkOrgMembers(plan, source)
+}
+
+// ImportBulkOrgMembers carries out an import plan made by PlanBulkOrgMembers, in batches of 200
+// rows: it inserts the new members, upserts the matched ones and rejects the rows the plan rejects,
+// so the import does exactly what a dry run of the same plan reports.
+// Returns a channel that sends the percentage of rows processed every 10 seconds.
+// This function must be called in a goroutine.
+// Every member inserted or updated is recorded in the member history as changed by source.
+func (ms *MongoStorage) ImportBulkOrgMembers(plan *OrgMemberImportPlan, source MemberChangeSource,
+) (chan *BulkOrgMembersJob, error) {
+	// Early returns for invalid input
+	if plan == nil || len(plan.steps) == 0 {
+		return nil, nil // Not an error, just no work to do
+	}
+	if plan.org == nil || plan.org.Address.Cmp(common.Address{}) == 0 {
 		return nil, ErrInvalidData
 	}
 
 	// Start processing in a goroutine
 	progressChan := make(chan *BulkOrgMembersJob, 10)
-	go ms.addOrgMemberBatches(org, members, salt, progressChan)
+	go ms.addOrgMemberBatches(plan, source, progressChan)
 	return progressChan, nil
 }
 
@@ -472,7 +550,9 @@ func mergeLoginHashFields(member, stored *OrgMember) {
 // of processes where this member is a participant.
 // The returned bool reports whether the member was created rather than updated, so callers can
 // propagate a brand new member to the censuses of the organization's auto group.
+// The change is recorded in the member history as made by source.
 func (ms *MongoStorage) UpsertOrgMemberAndCensusParticipants(org *Organization, member *OrgMember, salt string,
+	source MemberChangeSource,
 ) (primitive.ObjectID, bool, error) {
 	if org.Address.Cmp(common.Address{}) == 0 {
 		return primitive.NilObjectID, false, ErrInvalidData
@@ -503,7 +583,7 @@ func (ms *MongoStorage) UpsertOrgMemberAndCensusParticipants(org *Organization,
 
 	preparedMember, validationErrors := prepareOrgMember(org, member, salt, time.Now())
 	if len(validationErrors) > 0 {
-		return primitive.NilObjectID, false, fmt.Errorf("errors: %s", errorsAsStrings(validationErrors))
+		return primitive.NilObjectID, false, fmt.Errorf("%w: %s", ErrInvalidData, errorsAsStrings(validationErrors))
 	}
 
 	// Update the census participants first, to bail out early in case this would create any duplicates conflict
@@ -522,6 +602,11 @@ func (ms *MongoStorage) UpsertOrgMemberAndCensusParticipants(org *Organization,
 	if err != nil {
 		return primitive.NilObjectID, false, fmt.Errorf("failed to upsert org member: %w", err)
 	}
+	if created {
+		ms.recordMemberWrite(ctx, nil, preparedMember.ID, source)
+	} else {
+		ms.recordMemberWrite(ctx, orgMemberInDB, preparedMember.ID, source)
+	}
 
 	// Ensure the auto group exists now that at least one member is present.
 	if err := ms.EnsureAutoMemberGroup(org.Address); err != nil {
@@ -531,6 +616,45 @@ func (ms *MongoStorage) UpsertOrgMemberAndCensusParticipants(org *Organization,
 	return preparedMember.ID, created, nil
 }
 
+// SetOrgMemberActive activates or deactivates a member of orgAddress, recording the change in the
+// member history as made by source. A deactivated member keeps its data and the censuses it is in,
+// but joins no new census until it is activated again. Setting the state the member already has
+// changes nothing.
+func (ms *MongoStorage) SetOrgMemberActive(orgAddress common.Address, memberID string, active bool,
+	source MemberChangeSource,
+) error {
+	oid, err := primitive.ObjectIDFromHex(memberID)
+	if err != nil || orgAddress.Cmp(common.Address{}) == 0 {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+
+	stored := &OrgMember{}
+	filter := bson.M{"_id": oid, "orgAddress": orgAddress}
+	if err := ms.orgMembers.FindOne(ctx, filter).Decode(stored); err != nil {
+		if errors.Is(err, mongo.ErrNoDocuments) {
+			return ErrNotFound
+		}
+		return fmt.Errorf("failed to get org member: %w", err)
+	}
+	if stored.DeactivatedAt.IsZero() == active {
+		return nil
+	}
+	update := bson.M{"$set": bson.M{"deactivatedAt": time.Now(), "updatedAt": time.Now()}}
+	if active {
+		update = bson.M{"$set": bson.M{"updatedAt": time.Now()}, "$unset": bson.M{"deactivatedAt": ""}}
+	}
+	if _, err := ms.orgMembers.UpdateOne(ctx, filter, update); err != nil {
+		return fmt.Errorf("failed to set org member active: %w", err)
+	}
+	ms.recordMemberWrite(ctx, stored, oid, source)
+	return nil
+}
+
 // updateCensusParticipantsForMember updates all census participants where participantID == orgMemberID
 func (ms *MongoStorage) updateCensusParticipantsForMember(ctx context.Context, member *OrgMember) error {
 	// Find all census participants for this member
@@ -557,6 +681,12 @@ func (ms *MongoStorage) updateCensusParticipantsForMember(ctx context.Context, m
 			return fmt.Errorf("failed to get census %s: %w", participant.CensusID, err)
 		}
 
+		// a frozen census keeps the login hashes its participants were frozen with, as the
+		// commitment does: the member logs in to it with the data it had then
+		if census.Frozen != nil {
+			continue
+		}
+
 		// Calculate new hashes based on census configuration
 		hashes := calculateParticipantHashesBson(*census, *member)
 
@@ -637,11 +767,42 @@ func (ms *MongoStorage) OrgMembers(orgAddress common.Address, page, limit int64,
 	return paginatedDocuments[*OrgMember](ms.orgMembers, page, limit, filter, findOptions)
 }
 
+// OrgMembersFrom returns the members of an organization in creation order, skipping the first
+// offset and returning at most limit, along with the total count. It serves offset-based clients
+// such as SCIM, whose start index need not fall on a page boundary.
+func (ms *MongoStorage) OrgMembersFrom(orgAddress common.Address, offset, limit int64) (int64, []*OrgMember, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || offset < 0 || limit < 0 {
+		return 0, nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	filter := bson.M{"orgAddress": orgAddress}
+	total, err := ms.orgMembers.CountDocuments(ctx, filter)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to count orgMembers: %w", err)
+	}
+	if limit == 0 {
+		return total, []*OrgMember{}, nil
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}).SetSkip(offset).SetLimit(limit)
+	cursor, err := ms.orgMembers.Find(ctx, filter, opts)
+	if err != nil {
+		return 0, nil, fmt.Errorf("failed to get orgMembers: %w", err)
+	}
+	members := []*OrgMember{}
+	if err := cursor.All(ctx, &members); err != nil {
+		return 0, nil, fmt.Errorf("failed to decode orgMembers: %w", err)
+	}
+	return total, members, nil
+}
+
 // DeleteOrgMembers removes the given members and revokes them from every census they were part of.
 // The returned questions are those whose eligibility list became empty, so their elections are now
 // whole-census and undersized on chain; the caller resizes them.
+// Each deletion is recorded in the member history as made by source.
 func (ms *MongoStorage) DeleteOrgMembers(
-	orgAddress common.Address, ids []string,
+	orgAddress common.Address, ids []string, source MemberChangeSource,
 ) (int, []VotingProcessQuestion, error) {
 	if orgAddress.Cmp(common.Address{}) == 0 {
 		return 0, nil, ErrInvalidData
@@ -693,6 +854,7 @@ func (ms *MongoStorage) DeleteOrgMembers(
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to delete orgMembers: %w", err)
 	}
+	ms.recordMemberVersions(deletedMemberVersions(orgAddress, scoped, source, nil))
 
 	// Convert ObjectIDs to string IDs for group updates (groups store member IDs as strings)
 	var stringIDs []string
@@ -735,7 +897,9 @@ func (ms *MongoStorage) DeleteOrgMembers(
 
 // DeleteAllOrgMembers removes all members from an organization, revoking them from every census
 // they were part of. The returned questions are those whose eligibility list became empty.
-func (ms *MongoStorage) DeleteAllOrgMembers(orgAddress common.Address) (int, []VotingProcessQuestion, error) {
+// Each deletion is recorded in the member history as made by source.
+func (ms *MongoStorage) DeleteAllOrgMembers(orgAddress common.Address, source MemberChangeSource,
+) (int, []VotingProcessQuestion, error) {
 	if orgAddress.Cmp(common.Address{}) == 0 {
 		return 0, nil, ErrInvalidData
 	}
@@ -766,6 +930,7 @@ func (ms *MongoStorage) DeleteAllOrgMembers(orgAddress common.Address) (int, []V
 	if err != nil {
 		return 0, nil, fmt.Errorf("failed to delete all orgMembers: %w", err)
 	}
+	ms.recordMemberVersions(deletedMemberVersions(orgAddress, memberIDs, source, nil))
 
 	// Update all groups to remove the deleted member IDs
 	groupFilter := bson.M{
@@ -1008,6 +1173,7 @@ const (
 	OrgMemberLookupFieldNationalID   OrgMemberLookupField = "nationalId"
 	OrgMemberLookupFieldName         OrgMemberLookupField = "name"
 	OrgMemberLookupFieldSurname      OrgMemberLookupField = "surname"
+	OrgMemberLookupFieldExternalID   OrgMemberLookupField = "externalId"
 )
 
 // IsValid reports whether the field is one of the supported lookup fields.
@@ -1018,7 +1184,8 @@ func (f OrgMemberLookupField) IsValid() bool {
 		OrgMemberLookupFieldMemberNumber,
 		OrgMemberLookupFieldNationalID,
 		OrgMemberLookupFieldName,
-		OrgMemberLookupFieldSurname:
+		OrgMemberLookupFieldSurname,
+		OrgMemberLookupFieldExternalID:
 		return true
 	}
 	return false
@@ -1039,6 +1206,8 @@ func (f OrgMemberLookupField) bsonField() string {
 		return "name"
 	case OrgMemberLookupFieldSurname:
 		return "surname"
+	case OrgMemberLookupFieldExternalID:
+		return "externalId"
 	}
 	return ""
 }
@@ -0,0 +1,485 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// OrgMemberDuplicateField names the criterion a set of duplicate members was matched on.
+type OrgMemberDuplicateField string
+
+const (
+	DuplicateFieldEmail         OrgMemberDuplicateField = "email"
+	DuplicateFieldPhone         OrgMemberDuplicateField = "phone"
+	DuplicateFieldMemberNumber  OrgMemberDuplicateField = "memberNumber"
+	DuplicateFieldNationalID    OrgMemberDuplicateField = "nationalId"
+	DuplicateFieldNameBirthDate OrgMemberDuplicateField = "nameBirthDate"
+)
+
+// duplicateFieldsOrder is the order duplicate sets are reported in, strongest identifier first.
+var duplicateFieldsOrder = []OrgMemberDuplicateField{
+	DuplicateFieldNationalID,
+	DuplicateFieldMemberNumber,
+	DuplicateFieldEmail,
+	DuplicateFieldPhone,
+	DuplicateFieldNameBirthDate,
+}
+
+// OrgMemberDuplicateSet is a group of members of the same organization that share a value of Field.
+// Exact reports whether the stored values are identical; when false they only match once normalized
+// (case, surrounding punctuation, an email +tag, leading zeros...), which is the typical footprint of
+// the same person imported twice from different sources.
+//
+// MemberIDs are in creation order, so the first one is the oldest record — the natural candidate to
+// survive a merge.
+type OrgMemberDuplicateSet struct {
+	Field     OrgMemberDuplicateField `json:"field"`
+	Exact     bool                    `json:"exact"`
+	MemberIDs []string                `json:"memberIds"`
+}
+
+// OrgMemberMergeResult reports what MergeOrgMembers did.
+type OrgMemberMergeResult struct {
+	// Merged is the number of duplicate members deleted in favour of the survivor.
+	Merged int
+	// Censuses are the censuses the survivor joined in place of a duplicate.
+	Censuses []string
+	// Groups is the number of groups whose membership was re-pointed to the survivor.
+	Groups int64
+}
+
+// normalizeDuplicateEmail reduces an email to the form used to detect duplicates: lowercased,
+// trimmed and without a +tag in the local part. It is deliberately not the stored canonical form
+// (see OrgMember.Normalized): a +tag is a distinct address for login purposes, just a very likely
+// duplicate.
+func normalizeDuplicateEmail(email string) string {
+	email = strings.ToLower(strings.TrimSpace(email))
+	local, domain, ok := strings.Cut(email, "@")
+	if !ok {
+		return email
+	}
+	if tagged, _, found := strings.Cut(local, "+"); found && tagged != "" {
+		local = tagged
+	}
+	return local + "@" + domain
+}
+
+// normalizeDuplicateIdentifier uppercases an identifier and drops everything that is not a letter or
+// a digit, so "12.345.678-z" and "12345678Z" compare equal.
+func normalizeDuplicateIdentifier(id string) string {
+	var b strings.Builder
+	for _, r := range id {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			b.WriteRune(unicode.ToUpper(r))
+		}
+	}
+	return b.String()
+}
+
+// normalizeDuplicateMemberNumber is normalizeDuplicateIdentifier without leading zeros, which
+// spreadsheets add or drop at will.
+func normalizeDuplicateMemberNumber(number string) string {
+	identifier := normalizeDuplicateIdentifier(number)
+	if trimmed := strings.TrimLeft(identifier, "0"); trimmed != "" {
+		return trimmed
+	}
+	return identifier
+}
+
+// normalizeDuplicateName lowercases a name and collapses its inner whitespace.
+func normalizeDuplicateName(name string) string {
+	return strings.Join(strings.Fields(strings.ToLower(name)), " ")
+}
+
+// duplicateKey is a member's value for one criterion: as stored, and normalized for grouping.
+type duplicateKey struct {
+	raw, key string
+}
+
+// duplicateKeys returns the criteria the member has a value for.
+func duplicateKeys(m *OrgMember) map[OrgMemberDuplicateField]duplicateKey {
+	keys := make(map[OrgMemberDuplicateField]duplicateKey, len(duplicateFieldsOrder))
+	if key := normalizeDuplicateEmail(m.Email); key != "" {
+		keys[DuplicateFieldEmail] = duplicateKey{m.Email, key}
+	}
+	// the phone is stored hashed, so only an exact match can be detected
+	if !m.Phone.IsEmpty() {
+		keys[DuplicateFieldPhone] = duplicateKey{string(m.Phone), string(m.Phone)}
+	}
+	if key := normalizeDuplicateMemberNumber(m.MemberNumber); key != "" {
+		keys[DuplicateFieldMemberNumber] = duplicateKey{m.MemberNumber, key}
+	}
+	if key := normalizeDuplicateIdentifier(m.NationalID); key != "" {
+		keys[DuplicateFieldNationalID] = duplicateKey{m.NationalID, key}
+	}
+	name := normalizeDuplicateName(m.Name + " " + m.Surname)
+	if name != "" && m.BirthDate != "" {
+		raw := m.Name + "\x00" + m.Surname + "\x00" + m.BirthDate
+		keys[DuplicateFieldNameBirthDate] = duplicateKey{raw, name + "\x00" + m.BirthDate}
+	}
+	return keys
+}
+
+// OrgMemberDuplicates scans the whole memberbase of an organization and returns every set of two or
+// more members that share a national ID, member number, email, phone, or name and birthdate, either
+// exactly or once normalized. A pair of members can appear in several sets, one per criterion they
+// share. Members with an empty value for a criterion are never matched on it.
+//
+// Unlike CheckMembersFields, which only looks at the auth fields a census is about to use, this is
+// org-wide and independent of any census configuration.
+func (ms *MongoStorage) OrgMemberDuplicates(orgAddress common.Address) ([]OrgMemberDuplicateSet, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return nil, ErrInvalidData
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	projection := bson.M{
+		"_id": 1, "email": 1, "phone": 1, "memberNumber": 1,
+		"nationalId": 1, "name": 1, "surname": 1, "birthDate": 1,
+	}
+	cursor, err := ms.orgMembers.Find(ctx, bson.M{"orgAddress": orgAddress},
+		options.Find().SetProjection(projection).SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find org members: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+
+	type bucket struct {
+		raw       string
+		exact     bool
+		memberIDs []string
+	}
+	buckets := make(map[OrgMemberDuplicateField]map[string]*bucket, len(duplicateFieldsOrder))
+	// the keys of each criterion in first-seen order, so the output is stable across calls
+	order := make(map[OrgMemberDuplicateField][]string, len(duplicateFieldsOrder))
+	for _, field := range duplicateFieldsOrder {
+		buckets[field] = make(map[string]*bucket)
+	}
+
+	for cursor.Next(ctx) {
+		var member OrgMember
+		if err := cursor.Decode(&member); err != nil {
+			return nil, fmt.Errorf("failed to decode org member: %w", err)
+		}
+		for field, k := range duplicateKeys(&member) {
+			b, ok := buckets[field][k.key]
+			if !ok {
+				b = &bucket{raw: k.raw, exact: true}
+				buckets[field][k.key] = b
+				order[field] = append(order[field], k.key)
+			}
+			if b.raw != k.raw {
+				b.exact = false
+			}
+			b.memberIDs = append(b.memberIDs, member.ID.Hex())
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("cursor error: %w", err)
+	}
+
+	var sets []OrgMemberDuplicateSet
+	for _, field := range duplicateFieldsOrder {
+		for _, key := range order[field] {
+			b := buckets[field][key]
+			if len(b.memberIDs) < 2 {
+				continue
+			}
+			sets = append(sets, OrgMemberDuplicateSet{
+				Field:     field,
+				Exact:     b.exact,
+				MemberIDs: b.memberIDs,
+			})
+		}
+	}
+	return sets, nil
+}
+
+// MergeOrgMembers folds the duplicate members into the survivor and deletes them. The survivor's own
+// data is kept as is; what is transferred is everything that points at a duplicate:
+//   - group memberships: every group that lists a duplicate lists the survivor instead
+//   - census participation: the survivor joins, with its own login hashes, every census a duplicate
+//     was a participant of; a census both were in keeps the survivor's row
+//   - question eligibility lists: a list that names a duplicate names the survivor instead
+//
+// Every id must identify a member of orgAddress, or ErrNotFound is returned. Nothing is written when
+// the merge is refused: ErrInvalidData when the survivor is inactive, since it joins no new census
+// (see OrgMember.DeactivatedAt), ErrUpdateWouldCreateDuplicates when the survivor cannot join a census without
+// colliding with the login hash of another participant, ErrCensusFrozen when a census of a duplicate
+// is frozen, and an OngoingElectionError, naming the processes, when a census of a duplicate has an
+// election that can still be voted (see OngoingQuestionsByCensuses): re-pointing a participant
+// mid-vote would let one person authenticate under a different record than the one they may have
+// already been signed for.
+//
+// The refusals are decided under keysLock and the writes made without releasing it, so no freeze,
+// participant change or other merge lands in between. The writes are not transactional: the repo
+// opens no mongo session, as multi-document transactions need a replica set. They are ordered so a
+// failure part-way leaves the survivor in every census beside the duplicates it did not remove yet,
+// and re-running the merge finishes it.
+//
+// Each duplicate is recorded in the member history as deleted by source, naming the survivor it
+// was merged into.
+func (ms *MongoStorage) MergeOrgMembers(
+	orgAddress common.Address, survivorID string, duplicateIDs []string, source MemberChangeSource,
+) (*OrgMemberMergeResult, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || len(duplicateIDs) == 0 {
+		return nil, ErrInvalidData
+	}
+	if slices.Contains(duplicateIDs, survivorID) {
+		return nil, fmt.Errorf("survivor cannot be one of the duplicates: %w", ErrInvalidData)
+	}
+	duplicateIDs = slices.Compact(slices.Sorted(slices.Values(duplicateIDs)))
+
+	scoped, err := ms.FilterOrgMemberIDs(orgAddress, duplicateIDs)
+	if err != nil {
+		return nil, fmt.Errorf("could not scope member ids to the organization: %w", err)
+	}
+	if len(scoped) != len(duplicateIDs) {
+		return nil, fmt.Errorf("some duplicates are not members of the organization: %w", ErrNotFound)
+	}
+	duplicateOIDs := make([]primitive.ObjectID, 0, len(duplicateIDs))
+	for _, id := range duplicateIDs {
+		oid, err := primitive.ObjectIDFromHex(id)
+		if err != nil {
+			return nil, ErrInvalidData
+		}
+		duplicateOIDs = append(duplicateOIDs, oid)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	ms.keysLock.Lock()
+	locked := true
+	defer func() {
+		if locked {
+			ms.keysLock.Unlock()
+		}
+	}()
+
+	// the survivor is read under the lock, so it cannot be deactivated between this check and its
+	// census joins
+	survivor, err := ms.OrgMember(orgAddress, survivorID)
+	if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidData) {
+		return nil, fmt.Errorf("survivor %s: %w", survivorID, ErrNotFound)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !survivor.DeactivatedAt.IsZero() {
+		return nil, fmt.Errorf("survivor %s is inactive: %w", survivorID, ErrInvalidData)
+	}
+	survivorHex := survivor.ID.Hex()
+	duplicateCensuses, err := ms.CensusesForMembers(duplicateIDs)
+	if err != nil {
+		return nil, err
+	}
+	survivorCensuses, err := ms.CensusesForMembers([]string{survivorHex})
+	if err != nil {
+		return nil, err
+	}
+	joins, err := ms.mergeCensusJoins(ctx, survivor, duplicateIDs, duplicateCensuses, survivorCensuses)
+	if err != nil {
+		return nil, err
+	}
+	if err := ms.refuseFrozenCensuses(duplicateCensuses); err != nil {
+		return nil, err
+	}
+	ongoing, err := ms.OngoingQuestionsByCensuses(duplicateCensuses)
+	if err != nil {
+		return nil, err
+	}
+	if len(ongoing) > 0 {
+		ongoingErr := &OngoingElectionError{}
+		for i := range ongoing {
+			if id := ongoing[i].ProcessID.Hex(); !slices.Contains(ongoingErr.ProcessIDs, id) {
+				ongoingErr.ProcessIDs = append(ongoingErr.ProcessIDs, id)
+			}
+		}
+		return nil, ongoingErr
+	}
+	processes, err := ms.VotingProcessesByCensus(duplicateCensuses)
+	if err != nil {
+		return nil, err
+	}
+	processIDs := make([]primitive.ObjectID, 0, len(processes))
+	for _, p := range processes {
+		processIDs = append(processIDs, p.ID)
+	}
+
+	// the survivor joins first and is named wherever a duplicate is named before the duplicates go,
+	// so no census loses the person and no eligibility list is ever emptied (and silently opened to
+	// the whole census) by a merge
+	result := &OrgMemberMergeResult{}
+	for _, join := range joins {
+		if _, err := ms.censusParticipants.InsertOne(ctx, join.participant); err != nil {
+			if mongo.IsDuplicateKeyError(err) {
+				return nil, fmt.Errorf("survivor in census %s: %w", join.censusID, ErrUpdateWouldCreateDuplicates)
+			}
+			return nil, fmt.Errorf("failed to add survivor to census %s: %w", join.censusID, err)
+		}
+		result.Censuses = append(result.Censuses, join.censusID)
+	}
+	if len(processIDs) > 0 {
+		if _, err := ms.processesQuestions.UpdateMany(ctx,
+			bson.M{
+				"processId":         bson.M{"$in": processIDs},
+				"eligibleMemberIds": bson.M{"$in": duplicateIDs},
+			},
+			bson.M{"$addToSet": bson.M{"eligibleMemberIds": survivorHex}},
+		); err != nil {
+			return nil, fmt.Errorf("failed to re-point question eligibility lists: %w", err)
+		}
+	}
+	if len(duplicateCensuses) > 0 {
+		if _, err := ms.revokeWritesLocked(ctx, duplicateCensuses, duplicateIDs, processIDs); err != nil {
+			return nil, fmt.Errorf("could not revoke duplicates from censuses: %w", err)
+		}
+	}
+
+	// groups: add the survivor first and pull the duplicates second, two writes because Mongo
+	// refuses $addToSet and $pull on the same array in one update
+	groupFilter := bson.M{
+		"orgAddress": orgAddress,
+		"memberIds":  bson.M{"$in": duplicateIDs},
+	}
+	groupRes, err := ms.orgMemberGroups.UpdateMany(ctx, groupFilter, bson.M{
+		"$addToSet": bson.M{"memberIds": survivorHex},
+		"$set":      bson.M{"updatedAt": time.Now()},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to add survivor to groups: %w", err)
+	}
+	result.Groups = groupRes.MatchedCount
+	if _, err := ms.orgMemberGroups.UpdateMany(ctx, groupFilter, bson.M{
+		"$pull": bson.M{"memberIds": bson.M{"$in": duplicateIDs}},
+	}); err != nil {
+		return nil, fmt.Errorf("failed to remove duplicates from groups: %w", err)
+	}
+
+	res, err := ms.orgMembers.DeleteMany(ctx, bson.M{
+		"orgAddress": orgAddress,
+		"_id":        bson.M{"$in": duplicateOIDs},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete duplicate members: %w", err)
+	}
+	ms.keysLock.Unlock()
+	locked = false
+
+	ms.recordMemberVersions(deletedMemberVersions(orgAddress, duplicateIDs, source,
+		[]MemberFieldChange{{Field: "mergedInto", New: survivorHex}}))
+	result.Merged = int(res.DeletedCount)
+
+	// updateCensusSize takes keysLock through SetCensus, which is not reentrant. A recount failure
+	// is logged rather than returned, as after a revocation: the merge has committed.
+	for _, censusID := range duplicateCensuses {
+		if err := ms.updateCensusSize(censusID); err != nil {
+			log.Warnw("failed to recount census after merge", "census", censusID, "error", err)
+		}
+	}
+	return result, nil
+}
+
+// mergeCensusJoin is the participant row the survivor of a merge takes in a census of a duplicate.
+type mergeCensusJoin struct {
+	censusID    string
+	participant bson.M
+}
+
+// loginHashesTaken reports whether a participant of the census other than the excluded ones holds
+// any of the login hashes. With no hash there is nothing to collide with, and nothing is looked
+// up: mongo refuses an empty $or.
+func (ms *MongoStorage) loginHashesTaken(ctx context.Context, censusID string, hashes bson.M,
+	excludedIDs []string,
+) (bool, error) {
+	if len(hashes) == 0 {
+		return false, nil
+	}
+	findHashes := make([]bson.M, 0, len(hashes))
+	for k, v := range hashes {
+		findHashes = append(findHashes, bson.M{k: v})
+	}
+	count, err := ms.censusParticipants.CountDocuments(ctx, bson.M{
+		"censusId":      censusID,
+		"participantID": bson.M{"$nin": excludedIDs},
+		"$or":           findHashes,
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+// mergeCensusJoins prepares the rows the survivor takes in the censuses of the duplicates it is not
+// in yet, and checks it can take each without colliding with the login hash of another participant.
+// The duplicates' own rows are about to go, so they do not count as a collision.
+func (ms *MongoStorage) mergeCensusJoins(ctx context.Context, survivor *OrgMember, duplicateIDs,
+	duplicateCensuses, survivorCensuses []string,
+) ([]mergeCensusJoin, error) {
+	survivorHex := survivor.ID.Hex()
+	var joins []mergeCensusJoin
+	for _, censusID := range duplicateCensuses {
+		if slices.Contains(survivorCensuses, censusID) {
+			continue
+		}
+		census, err := ms.Census(censusID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get census %s: %w", censusID, err)
+		}
+		hashes := calculateParticipantHashesBson(*census, *survivor)
+		collides, err := ms.loginHashesTaken(ctx, censusID, hashes, append([]string{survivorHex}, duplicateIDs...))
+		if err != nil {
+			return nil, fmt.Errorf("failed to check census %s for duplicates: %w", censusID, err)
+		}
+		if collides {
+			return nil, fmt.Errorf("survivor in census %s: %w", censusID, ErrUpdateWouldCreateDuplicates)
+		}
+
+		now := time.Now()
+		participant := bson.M{
+			"participantID": survivorHex,
+			"censusId":      censusID,
+			"createdAt":     now,
+			"updatedAt":     now,
+		}
+		for k, v := range hashes {
+			participant[k] = v
+		}
+		weigh, totalWeight, err := ms.censusWeigher(census)
+		if err != nil {
+			return nil, err
+		}
+		if weigh != nil {
+			weight, err := weigh(survivor)
+			if err != nil {
+				return nil, fmt.Errorf("survivor in census %s: %w", censusID, err)
+			}
+			if weight > math.MaxInt64-totalWeight {
+				return nil, fmt.Errorf("survivor in census %s: %w: census total weight overflow", censusID, ErrInvalidData)
+			}
+			participant["weight"] = weight
+		}
+		joins = append(joins, mergeCensusJoin{censusID: censusID, participant: participant})
+	}
+	return joins, nil
+}
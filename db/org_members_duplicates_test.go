@@ -0,0 +1,206 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestOrgMemberDuplicates(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	c.Assert(testDB.SetOrganization(&Organization{
+		Address: testOrgAddress, CreatedAt: time.Now(),
+	}), qt.IsNil)
+
+	newMember := func(m *OrgMember) string {
+		m.OrgAddress = testOrgAddress
+		id, err := testDB.SetOrgMember(testSalt, m)
+		c.Assert(err, qt.IsNil)
+		return id
+	}
+	ana := newMember(&OrgMember{
+		Email: "ana@example.com", MemberNumber: "007", NationalID: "12.345.678-z",
+		Name: "Ana", Surname: "Pérez", BirthDate: "1990-01-02",
+	})
+	anaAgain := newMember(&OrgMember{
+		Email: "ana+club@example.com", MemberNumber: "7", NationalID: "12345678Z",
+		Name: "ana ", Surname: "pérez", BirthDate: "1990-01-02",
+	})
+	// exact copy of ana's email only
+	anaMail := newMember(&OrgMember{Email: "ana@example.com", MemberNumber: "8"})
+	// shares nothing with anyone
+	newMember(&OrgMember{Email: "bob@example.com", MemberNumber: "9", Name: "Bob", BirthDate: "1990-01-02"})
+
+	sets, err := testDB.OrgMemberDuplicates(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sets, qt.DeepEquals, []OrgMemberDuplicateSet{
+		{Field: DuplicateFieldNationalID, Exact: false, MemberIDs: []string{ana, anaAgain}},
+		{Field: DuplicateFieldMemberNumber, Exact: false, MemberIDs: []string{ana, anaAgain}},
+		{Field: DuplicateFieldEmail, Exact: false, MemberIDs: []string{ana, anaAgain, anaMail}},
+		{Field: DuplicateFieldNameBirthDate, Exact: false, MemberIDs: []string{ana, anaAgain}},
+	})
+
+	// an organization without duplicates reports none
+	_, _, err = testDB.DeleteOrgMembers(testOrgAddress, []string{anaAgain, anaMail}, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	sets, err = testDB.OrgMemberDuplicates(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(sets, qt.HasLen, 0)
+}
+
+func TestMergeOrgMembers(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	f := setupRevocationFixture(t)
+	// the elections of the census are over: a merge never re-points a participant mid-vote
+	c.Assert(testDB.SetQuestionStatus(f.restricted, QuestionStatusEnded), qt.IsNil)
+	c.Assert(testDB.SetQuestionStatus(f.openToAll, QuestionStatusEnded), qt.IsNil)
+
+	// alice was imported a second time, and only the copy made it into a census and a group
+	survivorID, err := testDB.SetOrgMember(testSalt, &OrgMember{
+		OrgAddress: testOrgAddress, MemberNumber: "revoke-alice-2", Email: "alice.second@example.com",
+	})
+	c.Assert(err, qt.IsNil)
+	groupID, err := testDB.CreateOrganizationMemberGroup(&OrganizationMemberGroup{
+		OrgAddress: testOrgAddress,
+		Title:      "merge group",
+		MemberIDs:  []string{f.alice.ID.Hex(), f.bob.ID.Hex()},
+	})
+	c.Assert(err, qt.IsNil)
+
+	result, err := testDB.MergeOrgMembers(testOrgAddress, survivorID, []string{f.alice.ID.Hex()}, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	c.Assert(result.Merged, qt.Equals, 1)
+	c.Assert(result.Censuses, qt.DeepEquals, []string{f.census.ID.Hex()})
+	c.Assert(result.Groups, qt.Equals, int64(1))
+
+	// the duplicate is gone, the survivor took its place everywhere
+	_, err = testDB.OrgMember(testOrgAddress, f.alice.ID.Hex())
+	c.Assert(err, qt.Not(qt.IsNil))
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), f.alice.ID.Hex())
+	c.Assert(err, qt.Equals, ErrNotFound)
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), survivorID)
+	c.Assert(err, qt.IsNil)
+	census, err := testDB.Census(f.census.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(census.Size, qt.Equals, int64(3))
+
+	group, err := testDB.OrganizationMemberGroup(groupID, testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(group.MemberIDs, qt.DeepEquals, []string{f.bob.ID.Hex(), survivorID})
+
+	restricted, err := testDB.Question(f.restricted)
+	c.Assert(err, qt.IsNil)
+	c.Assert(restricted.EligibleMemberIDs, qt.DeepEquals, []string{f.bob.ID.Hex(), survivorID})
+}
+
+func TestMergeOrgMembersRefusesCollisions(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	f := setupRevocationFixture(t)
+
+	// a survivor whose login data is bob's cannot take alice's place in the census
+	survivorID, err := testDB.SetOrgMember(testSalt, &OrgMember{
+		OrgAddress: testOrgAddress, MemberNumber: f.bob.MemberNumber, Email: f.bob.Email,
+	})
+	c.Assert(err, qt.IsNil)
+
+	_, err = testDB.MergeOrgMembers(testOrgAddress, survivorID, []string{f.alice.ID.Hex()}, MemberChangeSource{})
+	c.Assert(errors.Is(err, ErrUpdateWouldCreateDuplicates), qt.IsTrue, qt.Commentf("%v", err))
+
+	// nothing was written
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.OrgMember(testOrgAddress, f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+
+	// the survivor cannot be one of its own duplicates, and every duplicate must exist
+	_, err = testDB.MergeOrgMembers(testOrgAddress, f.carol.ID.Hex(),
+		[]string{f.bob.ID.Hex(), f.carol.ID.Hex()}, MemberChangeSource{})
+	c.Assert(errors.Is(err, ErrInvalidData), qt.IsTrue)
+	_, err = testDB.MergeOrgMembers(testOrgAddress, f.carol.ID.Hex(), []string{"000000000000000000000000"}, MemberChangeSource{})
+	c.Assert(errors.Is(err, ErrNotFound), qt.IsTrue)
+
+	// an inactive survivor joins no census, so it cannot take a duplicate's place
+	c.Assert(testDB.SetOrgMemberActive(testOrgAddress, f.carol.ID.Hex(), false, MemberChangeSource{}), qt.IsNil)
+	_, err = testDB.MergeOrgMembers(testOrgAddress, f.carol.ID.Hex(), []string{f.alice.ID.Hex()}, MemberChangeSource{})
+	c.Assert(errors.Is(err, ErrInvalidData), qt.IsTrue, qt.Commentf("%v", err))
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.OrgMember(testOrgAddress, f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+}
+
+func TestMergeOrgMembersRefusesOngoingElection(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	f := setupRevocationFixture(t)
+
+	survivorID, err := testDB.SetOrgMember(testSalt, &OrgMember{
+		OrgAddress: testOrgAddress, MemberNumber: "revoke-alice-2", Email: "alice.second@example.com",
+	})
+	c.Assert(err, qt.IsNil)
+
+	// a paused election still holds its voters
+	c.Assert(testDB.SetQuestionStatus(f.restricted, QuestionStatusEnded), qt.IsNil)
+	c.Assert(testDB.SetQuestionStatus(f.openToAll, QuestionStatusPaused), qt.IsNil)
+	_, err = testDB.MergeOrgMembers(testOrgAddress, survivorID, []string{f.alice.ID.Hex()}, MemberChangeSource{})
+	var ongoing *OngoingElectionError
+	c.Assert(errors.As(err, &ongoing), qt.IsTrue, qt.Commentf("%v", err))
+	c.Assert(errors.Is(err, ErrOngoingElection), qt.IsTrue)
+	c.Assert(ongoing.ProcessIDs, qt.DeepEquals, []string{f.processID.Hex()})
+
+	// a frozen census refuses the merge too, once the election is over
+	c.Assert(testDB.SetQuestionStatus(f.openToAll, QuestionStatusEnded), qt.IsNil)
+	census, err := testDB.Census(f.census.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.FreezeCensus(census)
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.MergeOrgMembers(testOrgAddress, survivorID, []string{f.alice.ID.Hex()}, MemberChangeSource{})
+	c.Assert(errors.Is(err, ErrCensusFrozen), qt.IsTrue, qt.Commentf("%v", err))
+
+	// nothing was written
+	_, err = testDB.OrgMember(testOrgAddress, f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), f.alice.ID.Hex())
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.CensusParticipant(f.census.ID.Hex(), survivorID)
+	c.Assert(err, qt.Equals, ErrNotFound)
+	restricted, err := testDB.Question(f.restricted)
+	c.Assert(err, qt.IsNil)
+	c.Assert(restricted.EligibleMemberIDs, qt.Not(qt.Contains), survivorID)
+}
+
+func TestMergeLoginHashesTaken(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	f := setupRevocationFixture(t)
+	ctx := context.Background()
+	censusID := f.census.ID.Hex()
+	bob, err := testDB.CensusParticipant(censusID, f.bob.ID.Hex())
+	c.Assert(err, qt.IsNil)
+
+	// another participant's hash collides, unless that participant is excluded
+	taken, err := testDB.loginHashesTaken(ctx, censusID, bson.M{"loginHash": bob.LoginHash}, []string{f.alice.ID.Hex()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(taken, qt.IsTrue)
+	taken, err = testDB.loginHashesTaken(ctx, censusID, bson.M{"loginHash": bob.LoginHash}, []string{f.bob.ID.Hex()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(taken, qt.IsFalse)
+
+	// a survivor with no login hash for the census collides with nobody, and is not sent as an
+	// empty $or, which mongo refuses
+	taken, err = testDB.loginHashesTaken(ctx, censusID, bson.M{}, []string{f.alice.ID.Hex()})
+	c.Assert(err, qt.IsNil)
+	c.Assert(taken, qt.IsFalse)
+}
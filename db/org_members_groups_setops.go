@@ -0,0 +1,220 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// GroupSetOperation is how GroupSetMemberIDs combines the members of several groups.
+type GroupSetOperation string
+
+const (
+	// GroupSetUnion selects the members of any of the groups.
+	GroupSetUnion GroupSetOperation = "union"
+	// GroupSetIntersection selects the members of every one of the groups.
+	GroupSetIntersection GroupSetOperation = "intersection"
+	// GroupSetDifference selects the members of the first group that are in none of the others.
+	GroupSetDifference GroupSetOperation = "difference"
+)
+
+// IsValid reports whether op is a known set operation.
+func (op GroupSetOperation) IsValid() bool {
+	switch op {
+	case GroupSetUnion, GroupSetIntersection, GroupSetDifference:
+		return true
+	}
+	return false
+}
+
+// GroupSetMemberIDs returns the hex ids, in creation order, of the members of org selected by
+// combining the groups groupIDs with op, and matching filter if any. Only the member-field
+// criteria of filter apply (In, the weight and birth date ranges, HasEmail and HasPhone); its
+// group, census, sort and paging are ignored.
+//
+// The selection runs as an aggregation: the memberIds of the groups are unwound and grouped per
+// member, so only the members of the groups involved are read. The auto group holds every member
+// of the organization, so an operation whose result starts from it (a union including it, an
+// intersection of nothing else, or a difference from it) scans the organization's members instead.
+//
+// Returns ErrInvalidData for an unknown op or no groups, and ErrNotFound for a group that is not
+// the organization's.
+func (ms *MongoStorage) GroupSetMemberIDs(org *Organization, op GroupSetOperation, groupIDs []string,
+	filter *OrgMemberSearch,
+) ([]string, error) {
+	if org == nil || !op.IsValid() || len(groupIDs) == 0 {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// a filter on member fields only: the group and census criteria would be a second selection
+	memberFilter := &OrgMemberSearch{}
+	if filter != nil {
+		memberFilter = &OrgMemberSearch{
+			In:            filter.In,
+			WeightMin:     filter.WeightMin,
+			WeightMax:     filter.WeightMax,
+			BirthDateFrom: filter.BirthDateFrom,
+			BirthDateTo:   filter.BirthDateTo,
+			HasEmail:      filter.HasEmail,
+			HasPhone:      filter.HasPhone,
+		}
+	}
+	conditions, err := ms.searchConditions(ctx, org, memberFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	groups := make([]*OrganizationMemberGroup, 0, len(groupIDs))
+	for _, id := range groupIDs {
+		group, err := ms.OrganizationMemberGroup(id, org.Address)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidData) {
+				return nil, fmt.Errorf("group %s: %w", id, ErrNotFound)
+			}
+			return nil, fmt.Errorf("could not load group: %w", err)
+		}
+		groups = append(groups, group)
+	}
+	staticIDs := func(groups []*OrganizationMemberGroup) []primitive.ObjectID {
+		ids := []primitive.ObjectID{}
+		for _, g := range groups {
+			if !g.IsAutoGroup {
+				ids = append(ids, g.ID)
+			}
+		}
+		return ids
+	}
+	hasAutoGroup := func(groups []*OrganizationMemberGroup) bool {
+		for _, g := range groups {
+			if g.IsAutoGroup {
+				return true
+			}
+		}
+		return false
+	}
+
+	switch op {
+	case GroupSetUnion:
+		if hasAutoGroup(groups) {
+			return ms.allMembersExcept(ctx, conditions, nil)
+		}
+		ids := staticIDs(groups)
+		return ms.groupMembersMatching(ctx, ids, bson.M{}, conditions)
+	case GroupSetIntersection:
+		ids := staticIDs(groups)
+		if len(ids) == 0 {
+			return ms.allMembersExcept(ctx, conditions, nil)
+		}
+		return ms.groupMembersMatching(ctx, ids, bson.M{"groups": bson.M{"$all": ids}}, conditions)
+	default: // GroupSetDifference
+		first, rest := groups[0], groups[1:]
+		if hasAutoGroup(rest) {
+			// nobody is left once every member is taken away
+			return []string{}, nil
+		}
+		excluded := staticIDs(rest)
+		if first.IsAutoGroup {
+			return ms.allMembersExcept(ctx, conditions, excluded)
+		}
+		return ms.groupMembersMatching(ctx, append([]primitive.ObjectID{first.ID}, excluded...), bson.M{
+			"$and": bson.A{bson.M{"groups": first.ID}, bson.M{"groups": bson.M{"$nin": excluded}}},
+		}, conditions)
+	}
+}
+
+// groupMembersMatching returns the ids of the members of the groups groupIDs whose set of groups,
+// among those, matches selection, and whose member document matches conditions. Stale ids of
+// members deleted since they joined a group drop out of the join.
+func (ms *MongoStorage) groupMembersMatching(ctx context.Context, groupIDs []primitive.ObjectID,
+	selection bson.M, conditions []bson.M,
+) ([]string, error) {
+	pipeline := mongo.Pipeline{
+		{{Key: "$match", Value: bson.M{"_id": bson.M{"$in": groupIDs}}}},
+		{{Key: "$unwind", Value: "$memberIds"}},
+		{{Key: "$group", Value: bson.M{"_id": "$memberIds", "groups": bson.M{"$addToSet": "$_id"}}}},
+		{{Key: "$match", Value: selection}},
+		{{Key: "$lookup", Value: bson.M{
+			"from": ms.orgMembers.Name(),
+			"let": bson.M{"memberOID": bson.M{
+				"$convert": bson.M{"input": "$_id", "to": "objectId", "onError": nil, "onNull": nil},
+			}},
+			"pipeline": bson.A{
+				bson.M{"$match": bson.M{"$expr": bson.M{"$eq": bson.A{"$_id", "$$memberOID"}}}},
+				bson.M{"$match": bson.M{"$and": conditions}},
+				bson.M{"$project": bson.M{"_id": 1}},
+			},
+			"as": "member",
+		}}},
+		{{Key: "$match", Value: bson.M{"member.0": bson.M{"$exists": true}}}},
+		{{Key: "$project", Value: bson.M{"_id": 1}}},
+		{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	}
+	cursor, err := ms.orgMemberGroups.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("could not aggregate group members: %w", err)
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+	var results []struct {
+		ID string `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("could not decode group members: %w", err)
+	}
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	return ids, nil
+}
+
+// allMembersExcept returns the ids of the members matching conditions that belong to none of the
+// groups excluded.
+func (ms *MongoStorage) allMembersExcept(ctx context.Context, conditions []bson.M, excluded []primitive.ObjectID,
+) ([]string, error) {
+	pipeline := mongo.Pipeline{{{Key: "$match", Value: bson.M{"$and": conditions}}}}
+	if len(excluded) > 0 {
+		pipeline = append(pipeline,
+			bson.D{{Key: "$lookup", Value: bson.M{
+				"from": ms.orgMemberGroups.Name(),
+				"let":  bson.M{"memberId": bson.M{"$toString": "$_id"}},
+				"pipeline": bson.A{
+					bson.M{"$match": bson.M{
+						"_id":   bson.M{"$in": excluded},
+						"$expr": bson.M{"$in": bson.A{"$$memberId", "$memberIds"}},
+					}},
+					bson.M{"$limit": 1},
+					bson.M{"$project": bson.M{"_id": 1}},
+				},
+				"as": "excludedBy",
+			}}},
+			bson.D{{Key: "$match", Value: bson.M{"excludedBy": bson.M{"$size": 0}}}},
+		)
+	}
+	pipeline = append(pipeline,
+		bson.D{{Key: "$project", Value: bson.M{"_id": 1}}},
+		bson.D{{Key: "$sort", Value: bson.M{"_id": 1}}},
+	)
+	cursor, err := ms.orgMembers.Aggregate(ctx, pipeline, options.Aggregate().SetAllowDiskUse(true))
+	if err != nil {
+		return nil, fmt.Errorf("could not aggregate org members: %w", err)
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+	var results []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}
+	if err := cursor.All(ctx, &results); err != nil {
+		return nil, fmt.Errorf("could not decode org members: %w", err)
+	}
+	ids := make([]string, 0, len(results))
+	for _, r := range results {
+		ids = append(ids, r.ID.Hex())
+	}
+	return ids, nil
+}
@@ -0,0 +1,72 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGroupSetMemberIDs(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	ids := make([]string, 0, 4)
+	for _, m := range []*OrgMember{
+		{MemberNumber: "1", Name: "Ana", Email: "ana@example.com", Weight: 1},
+		{MemberNumber: "2", Name: "Bea", Weight: 2},
+		{MemberNumber: "3", Name: "Carla", Email: "carla@example.com", Weight: 3},
+		{MemberNumber: "4", Name: "Dani", Weight: 4},
+	} {
+		id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, m, testSalt, MemberChangeSource{})
+		c.Assert(err, qt.IsNil)
+		ids = append(ids, id.Hex())
+	}
+	newGroup := func(memberIDs ...string) string {
+		id, err := testDB.CreateOrganizationMemberGroup(&OrganizationMemberGroup{
+			OrgAddress: testOrgAddress, Title: "g", MemberIDs: memberIDs,
+		})
+		c.Assert(err, qt.IsNil)
+		return id
+	}
+	board := newGroup(ids[0], ids[1], ids[2])
+	staff := newGroup(ids[1], ids[3])
+	auto, err := testDB.AutoMemberGroup(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	all := auto.ID.Hex()
+
+	combine := func(op GroupSetOperation, filter *OrgMemberSearch, groups ...string) []string {
+		memberIDs, err := testDB.GroupSetMemberIDs(org, op, groups, filter)
+		c.Assert(err, qt.IsNil)
+		return memberIDs
+	}
+	c.Assert(combine(GroupSetUnion, nil, board, staff), qt.DeepEquals, ids)
+	c.Assert(combine(GroupSetIntersection, nil, board, staff), qt.DeepEquals, []string{ids[1]})
+	c.Assert(combine(GroupSetDifference, nil, board, staff), qt.DeepEquals, []string{ids[0], ids[2]})
+	// everyone except staff, and the auto group on the other side
+	c.Assert(combine(GroupSetDifference, nil, all, staff), qt.DeepEquals, []string{ids[0], ids[2]})
+	c.Assert(combine(GroupSetDifference, nil, board, all), qt.DeepEquals, []string{})
+	c.Assert(combine(GroupSetIntersection, nil, all, staff), qt.DeepEquals, []string{ids[1], ids[3]})
+	c.Assert(combine(GroupSetUnion, nil, staff, all), qt.DeepEquals, ids)
+
+	// a member-field predicate, on both kinds of selection
+	hasEmail := true
+	c.Assert(combine(GroupSetUnion, &OrgMemberSearch{HasEmail: &hasEmail}, board, staff),
+		qt.DeepEquals, []string{ids[0], ids[2]})
+	weight := uint64(3)
+	c.Assert(combine(GroupSetDifference, &OrgMemberSearch{WeightMin: &weight}, all, board),
+		qt.DeepEquals, []string{ids[3]})
+
+	// a deleted member drops out of the groups it was left in
+	_, err = testDB.DelOrgMember(ids[2])
+	c.Assert(err, qt.IsNil)
+	c.Assert(combine(GroupSetDifference, nil, board, staff), qt.DeepEquals, []string{ids[0]})
+
+	_, err = testDB.GroupSetMemberIDs(org, GroupSetUnion, []string{board, "000000000000000000000000"}, nil)
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+	_, err = testDB.GroupSetMemberIDs(org, "xor", []string{board}, nil)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+}
@@ -1178,7 +1178,7 @@ func TestOrganizationMemberGroup(t *testing.T) {
 
 		// Delete the first 2 members (indices 0 and 1)
 		membersToDelete := existingMemberIDs[:2]
-		deletedCount, _, err := testDB.DeleteOrgMembers(testOrgAddress, membersToDelete)
+		deletedCount, _, err := testDB.DeleteOrgMembers(testOrgAddress, membersToDelete, MemberChangeSource{})
 		c.Assert(err, qt.IsNil)
 		c.Assert(deletedCount, qt.Equals, 2)
 
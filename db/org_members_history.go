@@ -0,0 +1,243 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// MemberChangeAction is what a member version records happened to the member.
+type MemberChangeAction string
+
+const (
+	MemberCreated MemberChangeAction = "created"
+	MemberUpdated MemberChangeAction = "updated"
+	MemberDeleted MemberChangeAction = "deleted"
+	// MemberAnonymized lists the fields the retention sweeper erased, without their values.
+	MemberAnonymized MemberChangeAction = "anonymized"
+)
+
+// The channels a member change can come through (MemberChangeSource.Via).
+const (
+	MemberChangeViaAPI         = "api"
+	MemberChangeViaImport      = "import"
+	MemberChangeViaSelfService = "selfservice"
+	MemberChangeViaMerge       = "merge"
+	MemberChangeViaSCIM        = "scim"
+)
+
+// maskedValue stands in for a secret that is never shown, not even masked (the password).
+const maskedValue = "***"
+
+// MemberChangeSource identifies who made a member change and how. Every field is optional: writes
+// that do not come from a request (tests, internal maintenance) leave it empty.
+type MemberChangeSource struct {
+	// Actor is the email of the user who made the change. For an API key, the key's creator.
+	Actor string `json:"actor,omitempty" bson:"actor,omitempty"`
+	// APIKey is the prefix of the API key the change was made with, if any.
+	APIKey string `json:"apiKey,omitempty" bson:"apiKey,omitempty"`
+	// Via is one of the MemberChangeVia* channels.
+	Via string `json:"via,omitempty" bson:"via,omitempty"`
+	// JobID is the asynchronous import job the change belongs to, if any.
+	JobID string `json:"jobId,omitempty" bson:"jobId,omitempty"`
+}
+
+// MemberFieldChange is one field changed by a member version. Sensitive values (email, phone,
+// national id, birth date, password) are stored masked: the history says that they changed, not
+// what they are. Keys of the member's other data are named "other.<key>".
+type MemberFieldChange struct {
+	Field string `json:"field" bson:"field"`
+	Old   string `json:"old,omitempty" bson:"old,omitempty"`
+	New   string `json:"new,omitempty" bson:"new,omitempty"`
+}
+
+// MemberVersion is one entry of the change history of an org member. Entries are append-only and
+// outlive the member they describe, so a deleted member's history can still be read.
+type MemberVersion struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	OrgAddress common.Address     `json:"orgAddress" bson:"orgAddress"`
+	MemberID   string             `json:"memberId" bson:"memberId"`
+	// Version counts the member's entries from 1, oldest first. It is computed when reading.
+	Version   int64               `json:"version" bson:"-"`
+	Action    MemberChangeAction  `json:"action" bson:"action"`
+	Source    MemberChangeSource  `json:"source" bson:"source"`
+	Changes   []MemberFieldChange `json:"changes" bson:"changes"`
+	CreatedAt time.Time           `json:"createdAt" bson:"createdAt"`
+}
+
+// maskSensitive keeps the first character of a value, enough to tell two values apart at a
+// glance without disclosing either. Emails keep their domain too.
+func maskSensitive(value string) string {
+	if value == "" {
+		return ""
+	}
+	local, domain, isEmail := strings.Cut(value, "@")
+	masked := maskedValue
+	if r := []rune(local); len(r) > 0 {
+		masked = string(r[0]) + maskedValue
+	}
+	if isEmail {
+		masked += "@" + domain
+	}
+	return masked
+}
+
+// diffOrgMembers lists the fields that differ between two stored members, sorted by field, with
+// the sensitive values masked. A nil before diffs against an empty member, as for a creation.
+func diffOrgMembers(before, after *OrgMember) []MemberFieldChange {
+	if before == nil {
+		before = &OrgMember{}
+	}
+	changes := []MemberFieldChange{}
+	add := func(field, oldValue, newValue string) {
+		if oldValue != newValue {
+			changes = append(changes, MemberFieldChange{Field: field, Old: oldValue, New: newValue})
+		}
+	}
+	add("active", strconv.FormatBool(before.DeactivatedAt.IsZero()), strconv.FormatBool(after.DeactivatedAt.IsZero()))
+	add("birthDate", maskSensitive(before.BirthDate), maskSensitive(after.BirthDate))
+	add("email", maskSensitive(before.Email), maskSensitive(after.Email))
+	add("emailConsent", before.EmailConsent.String(), after.EmailConsent.String())
+	add("externalId", before.ExternalID, after.ExternalID)
+	add("memberNumber", before.MemberNumber, after.MemberNumber)
+	add("name", before.Name, after.Name)
+	add("nationalId", maskSensitive(before.NationalID), maskSensitive(after.NationalID))
+	if !bytes.Equal(before.HashedPass, after.HashedPass) {
+		changes = append(changes, MemberFieldChange{Field: "password", New: maskedValue})
+	}
+	add("phone", before.Phone.String(), after.Phone.String())
+	add("smsConsent", before.SMSConsent.String(), after.SMSConsent.String())
+	add("surname", before.Surname, after.Surname)
+	add("weight", strconv.FormatUint(before.Weight, 10), strconv.FormatUint(after.Weight, 10))
+
+	keys := maps.Clone(before.Other)
+	if keys == nil {
+		keys = map[string]any{}
+	}
+	maps.Copy(keys, after.Other)
+	for _, k := range slices.Sorted(maps.Keys(keys)) {
+		oldValue, newValue := "", ""
+		if v, ok := before.Other[k]; ok {
+			oldValue = fmt.Sprint(v)
+		}
+		if v, ok := after.Other[k]; ok {
+			newValue = fmt.Sprint(v)
+		}
+		add("other."+k, oldValue, newValue)
+	}
+	return changes
+}
+
+// newMemberVersion builds the history entry of a change to member.
+func newMemberVersion(orgAddress common.Address, memberID string, action MemberChangeAction,
+	source MemberChangeSource, changes []MemberFieldChange, now time.Time,
+) *MemberVersion {
+	return &MemberVersion{
+		ID:         primitive.NewObjectID(),
+		OrgAddress: orgAddress,
+		MemberID:   memberID,
+		Action:     action,
+		Source:     source,
+		Changes:    changes,
+		CreatedAt:  now,
+	}
+}
+
+// deletedMemberVersions builds one deletion entry per member id.
+func deletedMemberVersions(orgAddress common.Address, memberIDs []string, source MemberChangeSource,
+	changes []MemberFieldChange,
+) []*MemberVersion {
+	now := time.Now()
+	versions := make([]*MemberVersion, 0, len(memberIDs))
+	for _, id := range memberIDs {
+		versions = append(versions, newMemberVersion(orgAddress, id, MemberDeleted, source, changes, now))
+	}
+	return versions
+}
+
+// recordMemberVersions appends entries to the member history. The history must never make a
+// member write fail after it happened, so an error is logged, not returned. It does not take
+// keysLock, so writers call it while holding it: the collection is append-only.
+func (ms *MongoStorage) recordMemberVersions(versions []*MemberVersion) {
+	if len(versions) == 0 {
+		return
+	}
+	docs := make([]any, 0, len(versions))
+	for _, v := range versions {
+		docs = append(docs, v)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := ms.memberHistory.InsertMany(ctx, docs); err != nil {
+		log.Warnw("could not record member history", "entries", len(docs), "error", err)
+	}
+}
+
+// recordMemberWrite records the history entry of an upsert of a member: it reads the stored
+// document back and diffs it against before, the document as it was (nil for a creation). An
+// update that changed no field is not recorded.
+func (ms *MongoStorage) recordMemberWrite(ctx context.Context, before *OrgMember, id primitive.ObjectID,
+	source MemberChangeSource,
+) {
+	after := &OrgMember{}
+	if err := ms.orgMembers.FindOne(ctx, bson.M{"_id": id}).Decode(after); err != nil {
+		log.Warnw("could not read member back to record its history", "member", id.Hex(), "error", err)
+		return
+	}
+	action := MemberUpdated
+	if before == nil {
+		action = MemberCreated
+	}
+	changes := diffOrgMembers(before, after)
+	if action == MemberUpdated && len(changes) == 0 {
+		return
+	}
+	ms.recordMemberVersions([]*MemberVersion{
+		newMemberVersion(after.OrgAddress, id.Hex(), action, source, changes, time.Now()),
+	})
+}
+
+// MemberHistory returns a page of the change history of an org member, newest first. The history
+// of a deleted member is still returned; a member without any entry returns an empty list.
+func (ms *MongoStorage) MemberHistory(orgAddress common.Address, memberID string, page, limit int64,
+) (int64, []*MemberVersion, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || memberID == "" {
+		return 0, nil, ErrInvalidData
+	}
+	filter := bson.M{"orgAddress": orgAddress, "memberId": memberID}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	total, versions, err := paginatedDocuments[*MemberVersion](ms.memberHistory, page, limit, filter, findOptions)
+	if err != nil {
+		return 0, nil, err
+	}
+	// newest first: the first entry of the page is the total minus the entries skipped
+	first := total - (page-1)*limit
+	for i, v := range versions {
+		v.Version = first - int64(i)
+	}
+	return total, versions, nil
+}
+
+// DeleteMemberHistoryByOrg removes the member history of an organization.
+func (ms *MongoStorage) DeleteMemberHistoryByOrg(orgAddress common.Address) (int64, error) {
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.memberHistory.DeleteMany(ctx, bson.M{"orgAddress": orgAddress})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete member history: %w", err)
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,102 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestDiffOrgMembers(t *testing.T) {
+	c := qt.New(t)
+	before := &OrgMember{
+		Email: "ana@example.com", Name: "Ana", NationalID: "12345678Z", Weight: 1,
+		Other: map[string]any{"club": "chess", "gone": "yes"},
+	}
+	after := &OrgMember{
+		Email: "bea@example.com", Name: "Ana", NationalID: "12345678Z", Weight: 2,
+		HashedPass: []byte("hash"), Other: map[string]any{"club": "go", "new": 3},
+	}
+	c.Assert(diffOrgMembers(before, after), qt.DeepEquals, []MemberFieldChange{
+		{Field: "email", Old: "a***@example.com", New: "b***@example.com"},
+		{Field: "password", New: maskedValue},
+		{Field: "weight", Old: "1", New: "2"},
+		{Field: "other.club", Old: "chess", New: "go"},
+		{Field: "other.gone", Old: "yes"},
+		{Field: "other.new", New: "3"},
+	})
+	c.Assert(diffOrgMembers(after, after), qt.HasLen, 0)
+
+	// a creation lists every field set, the sensitive ones masked
+	created := diffOrgMembers(nil, &OrgMember{NationalID: "12345678Z", MemberNumber: "7"})
+	c.Assert(created, qt.DeepEquals, []MemberFieldChange{
+		{Field: "memberNumber", New: "7"},
+		{Field: "nationalId", New: "1***"},
+	})
+}
+
+func TestMemberHistory(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	manager := MemberChangeSource{Actor: "manager@example.com", Via: MemberChangeViaAPI}
+
+	// created by an import job
+	progress, err := testDB.AddBulkOrgMembers(org, []*OrgMember{{MemberNumber: "1", Name: "Ana"}}, testSalt,
+		MemberChangeSource{Actor: "manager@example.com", Via: MemberChangeViaImport, JobID: "job1"})
+	c.Assert(err, qt.IsNil)
+	var job *BulkOrgMembersJob
+	for p := range progress {
+		job = p
+	}
+	c.Assert(job.MemberIDs, qt.HasLen, 1)
+	memberID := job.MemberIDs[0]
+	member, err := testDB.OrgMember(testOrgAddress, memberID)
+	c.Assert(err, qt.IsNil)
+
+	// updated through the API, then a no-op update that is not recorded
+	member.PlaintextPhone = "+34600000001"
+	_, _, err = testDB.UpsertOrgMemberAndCensusParticipants(org, member, testSalt, manager)
+	c.Assert(err, qt.IsNil)
+	_, _, err = testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{ID: member.ID}, testSalt, manager)
+	c.Assert(err, qt.IsNil)
+
+	// deleted: the history outlives the member
+	_, _, err = testDB.DeleteOrgMembers(testOrgAddress, []string{memberID}, manager)
+	c.Assert(err, qt.IsNil)
+
+	total, versions, err := testDB.MemberHistory(testOrgAddress, memberID, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(3))
+	c.Assert(versions, qt.HasLen, 3)
+	deleted, updated, created := versions[0], versions[1], versions[2]
+
+	c.Assert(deleted.Action, qt.Equals, MemberDeleted)
+	c.Assert(deleted.Version, qt.Equals, int64(3))
+	c.Assert(deleted.Source, qt.DeepEquals, manager)
+
+	phone, err := NewHashedPhone("+34600000001", org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(updated.Action, qt.Equals, MemberUpdated)
+	c.Assert(updated.Changes, qt.DeepEquals, []MemberFieldChange{{Field: "phone", New: phone.String()}})
+
+	c.Assert(created.Action, qt.Equals, MemberCreated)
+	c.Assert(created.Version, qt.Equals, int64(1))
+	c.Assert(created.Source.JobID, qt.Equals, "job1")
+	c.Assert(created.Changes, qt.DeepEquals, []MemberFieldChange{
+		{Field: "memberNumber", New: "1"},
+		{Field: "name", New: "Ana"},
+	})
+
+	// the second page numbers its entries after the first
+	_, page2, err := testDB.MemberHistory(testOrgAddress, memberID, 2, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(page2, qt.HasLen, 1)
+	c.Assert(page2[0].Version, qt.Equals, int64(1))
+
+	removed, err := testDB.DeleteMemberHistoryByOrg(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(removed, qt.Equals, int64(3))
+}
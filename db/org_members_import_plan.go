@@ -0,0 +1,238 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// OrgMemberImportAction is what importing a row does.
+type OrgMemberImportAction string
+
+const (
+	OrgMemberImportInsert OrgMemberImportAction = "insert"
+	OrgMemberImportUpdate OrgMemberImportAction = "update"
+	OrgMemberImportReject OrgMemberImportAction = "reject"
+)
+
+// OrgMemberImportRow reports the outcome of one row of an import that does not simply insert a new
+// member. Line is the 1-based position of the row in the submitted list, as in the import errors.
+type OrgMemberImportRow struct {
+	Line   int                   `json:"line"`
+	Action OrgMemberImportAction `json:"action"`
+	// MemberID and MatchedBy identify the existing member an update writes to, and whether it was
+	// matched by memberNumber or email.
+	MemberID  string `json:"memberId,omitempty"`
+	MatchedBy string `json:"matchedBy,omitempty"`
+	// Changes are the fields an update changes, masked as in the member history.
+	Changes []MemberFieldChange `json:"changes,omitempty"`
+	// Reasons are why a row is rejected.
+	Reasons []string `json:"reasons,omitempty"`
+	// Warnings are the invalid values of a row that is still imported, without them.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// OrgMemberImportPlan is what importing a list of members into an organization does, row by row,
+// as PlanBulkOrgMembers decides it: a dry run reports it, and ImportBulkOrgMembers carries it out.
+// Rows lists the updates, the rejections and the inserts with warnings; plain inserts are only
+// counted.
+type OrgMemberImportPlan struct {
+	Inserts int
+	Updates int
+	Rejects int
+	Rows    []*OrgMemberImportRow
+
+	org   *Organization
+	salt  string
+	steps []*orgMemberImportStep
+}
+
+// orgMemberImportStep is the planned action of one row. An insert writes member, prepared; an
+// update upserts input, the row as submitted given the id of the matched member, so the upsert
+// merges and hashes it as it does any other write. errs are the invalid values the row is imported
+// without, and reasons why it is rejected.
+type orgMemberImportStep struct {
+	line    int
+	action  OrgMemberImportAction
+	member  *OrgMember
+	input   *OrgMember
+	errs    []error
+	reasons []string
+}
+
+// overlayImportedMember returns stored with the fields an upsert of member writes: every non-empty
+// field of member, and the weight, which is always written.
+func overlayImportedMember(stored, member *OrgMember) *OrgMember {
+	after := *stored
+	for _, f := range []struct{ to, from *string }{
+		{&after.Name, &member.Name},
+		{&after.Surname, &member.Surname},
+		{&after.MemberNumber, &member.MemberNumber},
+		{&after.NationalID, &member.NationalID},
+		{&after.Email, &member.Email},
+		{&after.BirthDate, &member.BirthDate},
+	} {
+		if *f.from != "" {
+			*f.to = *f.from
+		}
+	}
+	if !member.Phone.IsEmpty() {
+		after.Phone = member.Phone
+	}
+	if len(member.HashedPass) > 0 {
+		after.HashedPass = member.HashedPass
+	}
+	if member.Other != nil {
+		after.Other = member.Other
+	}
+	if member.EmailConsent != nil {
+		after.EmailConsent = member.EmailConsent
+	}
+	if member.SMSConsent != nil {
+		after.SMSConsent = member.SMSConsent
+	}
+	after.Weight = member.Weight
+	return &after
+}
+
+// withoutInvalidValues returns the submitted row m without the values prepareOrgMember dropped from
+// prepared as invalid, so an update leaves the stored ones in place as an insert leaves them empty.
+func withoutInvalidValues(m, prepared *OrgMember) *OrgMember {
+	input := *m
+	if prepared.Email == "" {
+		input.Email = ""
+	}
+	if prepared.BirthDate == "" {
+		input.BirthDate = ""
+	}
+	if prepared.Phone.IsEmpty() {
+		input.PlaintextPhone = ""
+	}
+	return &input
+}
+
+// PlanBulkOrgMembers decides what importing members into org does, without writing anything. A row
+// with invalid values is imported without them, and warned about. Without upsert every row is an
+// insert, as the import has always done. With upsert, a row that matches an existing member of the
+// organization by memberNumber, or else by email, is an update and reports the fields it changes;
+// a row repeating the memberNumber or email of an earlier row, or matching the same member, which
+// would write it twice, or naming an id other than the member it matches, is rejected; every other
+// row is an insert.
+func (ms *MongoStorage) PlanBulkOrgMembers(org *Organization, members []*OrgMember, salt string, upsert bool,
+) (*OrgMemberImportPlan, error) {
+	if org == nil || org.Address.Cmp(common.Address{}) == 0 {
+		return nil, ErrInvalidData
+	}
+
+	now := time.Now()
+	plan := &OrgMemberImportPlan{Rows: []*OrgMemberImportRow{}, org: org, salt: salt}
+	numbers, emails := []string{}, []string{}
+	for i, m := range members {
+		member, errs := prepareOrgMember(org, m, salt, now)
+		plan.steps = append(plan.steps, &orgMemberImportStep{
+			line: i + 1, action: OrgMemberImportInsert, member: member, input: m, errs: errs,
+		})
+		if member.MemberNumber != "" {
+			numbers = append(numbers, member.MemberNumber)
+		}
+		if member.Email != "" {
+			emails = append(emails, member.Email)
+		}
+	}
+
+	// the oldest stored member wins when several share a memberNumber or email, as in a merge
+	byNumber, byEmail := map[string]*OrgMember{}, map[string]*OrgMember{}
+	if upsert && (len(numbers) > 0 || len(emails) > 0) {
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		defer cancel()
+		filter := bson.M{"orgAddress": org.Address, "$or": bson.A{
+			bson.M{"memberNumber": bson.M{"$in": numbers}},
+			bson.M{"email": bson.M{"$in": emails}},
+		}}
+		cursor, err := ms.orgMembers.Find(ctx, filter, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+		if err != nil {
+			return nil, fmt.Errorf("failed to find existing org members: %w", err)
+		}
+		var stored []*OrgMember
+		if err := cursor.All(ctx, &stored); err != nil {
+			return nil, fmt.Errorf("failed to decode existing org members: %w", err)
+		}
+		for _, m := range stored {
+			if _, ok := byNumber[m.MemberNumber]; m.MemberNumber != "" && !ok {
+				byNumber[m.MemberNumber] = m
+			}
+			if _, ok := byEmail[m.Email]; m.Email != "" && !ok {
+				byEmail[m.Email] = m
+			}
+		}
+	}
+
+	numberLines, emailLines := map[string]int{}, map[string]int{}
+	matchedLines := map[primitive.ObjectID]int{}
+	for _, step := range plan.steps {
+		member := step.member
+		var stored *OrgMember
+		var matchedBy string
+		if upsert {
+			if first, ok := numberLines[member.MemberNumber]; member.MemberNumber != "" && ok {
+				step.reasons = append(step.reasons, fmt.Sprintf("memberNumber %q repeats line %d", member.MemberNumber, first))
+			} else if member.MemberNumber != "" {
+				numberLines[member.MemberNumber] = step.line
+			}
+			if first, ok := emailLines[member.Email]; member.Email != "" && ok {
+				step.reasons = append(step.reasons, fmt.Sprintf("email repeats line %d", first))
+			} else if member.Email != "" {
+				emailLines[member.Email] = step.line
+			}
+			stored, matchedBy = byNumber[member.MemberNumber], "memberNumber"
+			if stored == nil {
+				stored, matchedBy = byEmail[member.Email], "email"
+			}
+			if stored != nil && !step.input.ID.IsZero() && step.input.ID != stored.ID {
+				step.reasons = append(step.reasons, fmt.Sprintf("id does not match the member matched by %s", matchedBy))
+			}
+			if stored != nil {
+				if first, ok := matchedLines[stored.ID]; ok {
+					step.reasons = append(step.reasons, fmt.Sprintf("matches the same member as line %d", first))
+				} else {
+					matchedLines[stored.ID] = step.line
+				}
+			}
+		}
+
+		switch {
+		case len(step.reasons) > 0:
+			step.action = OrgMemberImportReject
+			plan.Rejects++
+			plan.Rows = append(plan.Rows, &OrgMemberImportRow{
+				Line: step.line, Action: OrgMemberImportReject, Reasons: step.reasons,
+			})
+		case stored != nil:
+			step.action = OrgMemberImportUpdate
+			step.input = withoutInvalidValues(step.input, member)
+			step.input.ID = stored.ID
+			plan.Updates++
+			plan.Rows = append(plan.Rows, &OrgMemberImportRow{
+				Line:      step.line,
+				Action:    OrgMemberImportUpdate,
+				MemberID:  stored.ID.Hex(),
+				MatchedBy: matchedBy,
+				Changes:   diffOrgMembers(stored, overlayImportedMember(stored, member)),
+				Warnings:  errorsAsStrings(step.errs),
+			})
+		default:
+			plan.Inserts++
+			if len(step.errs) > 0 {
+				plan.Rows = append(plan.Rows, &OrgMemberImportRow{
+					Line: step.line, Action: OrgMemberImportInsert, Warnings: errorsAsStrings(step.errs),
+				})
+			}
+		}
+	}
+	return plan, nil
+}
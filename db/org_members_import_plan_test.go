@@ -0,0 +1,105 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestPlanBulkOrgMembers(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org,
+		&OrgMember{MemberNumber: "1", Name: "Ana", Email: "ana@example.com", Weight: 1}, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+
+	members := []*OrgMember{
+		{MemberNumber: "1", Name: "Ana", Surname: "Gil", Weight: 2},
+		{MemberNumber: "2", Name: "Bea", Email: " ANA@example.com", Weight: 1},
+		{MemberNumber: "3", Name: "Carla", BirthDate: "not a date", Weight: 1},
+		{MemberNumber: "4", Name: "Dani", Weight: 1},
+		{MemberNumber: "4", Name: "Dani", Weight: 1},
+	}
+
+	c.Run("upsert", func(c *qt.C) {
+		plan, err := testDB.PlanBulkOrgMembers(org, members, testSalt, true)
+		c.Assert(err, qt.IsNil)
+		c.Assert([]int{plan.Inserts, plan.Updates, plan.Rejects}, qt.DeepEquals, []int{2, 1, 2})
+		c.Assert(plan.Rows, qt.HasLen, 4)
+		c.Assert(plan.Rows[0], qt.DeepEquals, &OrgMemberImportRow{
+			Line: 1, Action: OrgMemberImportUpdate, MemberID: id.Hex(), MatchedBy: "memberNumber",
+			Changes:  []MemberFieldChange{{Field: "surname", New: "Gil"}, {Field: "weight", Old: "1", New: "2"}},
+			Warnings: []string{},
+		})
+		// the email matches the member line 1 already writes
+		c.Assert(plan.Rows[1], qt.DeepEquals, &OrgMemberImportRow{
+			Line: 2, Action: OrgMemberImportReject, Reasons: []string{"matches the same member as line 1"},
+		})
+		c.Assert(plan.Rows[2].Action, qt.Equals, OrgMemberImportInsert)
+		c.Assert(plan.Rows[2].Warnings, qt.HasLen, 1)
+		c.Assert(plan.Rows[3], qt.DeepEquals, &OrgMemberImportRow{
+			Line: 5, Action: OrgMemberImportReject, Reasons: []string{`memberNumber "4" repeats line 4`},
+		})
+	})
+
+	c.Run("insert only", func(c *qt.C) {
+		plan, err := testDB.PlanBulkOrgMembers(org, members, testSalt, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert([]int{plan.Inserts, plan.Updates, plan.Rejects}, qt.DeepEquals, []int{5, 0, 0})
+		c.Assert(plan.Rows, qt.HasLen, 1)
+		c.Assert(plan.Rows[0].Line, qt.Equals, 3)
+	})
+
+	count, err := testDB.CountOrgMembers(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(1))
+}
+
+func TestImportBulkOrgMembers(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org,
+		&OrgMember{MemberNumber: "1", Name: "Ana", Email: "ana@example.com", Weight: 1}, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+
+	plan, err := testDB.PlanBulkOrgMembers(org, []*OrgMember{
+		{MemberNumber: "1", Name: "Ana", Surname: "Gil", Weight: 2},
+		{MemberNumber: "2", Name: "Bea", Email: "ana@example.com", Weight: 1},
+		{MemberNumber: "3", Name: "Carla", BirthDate: "not a date", Weight: 1},
+	}, testSalt, true)
+	c.Assert(err, qt.IsNil)
+	progress, err := testDB.ImportBulkOrgMembers(plan, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	var last *BulkOrgMembersJob
+	for p := range progress {
+		last = p
+	}
+	c.Assert(last, qt.Not(qt.IsNil))
+
+	// the import does what the plan says: one update, one insert without the invalid birthdate,
+	// and the rejected row left out
+	c.Assert(last.Progress, qt.Equals, 100)
+	c.Assert([]int{last.Added, last.Updated}, qt.DeepEquals, []int{1, 1})
+	errs := last.ErrorsAsStrings()
+	c.Assert(errs, qt.HasLen, 2)
+	c.Assert(errs[0], qt.Matches, `line 2: .*matches the same member as line 1`)
+	c.Assert(errs[1], qt.Matches, `line 3: invalid birthdate format: not a date`)
+
+	count, err := testDB.CountOrgMembers(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(count, qt.Equals, int64(2))
+	ana, err := testDB.OrgMember(testOrgAddress, id.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(ana.Surname, qt.Equals, "Gil")
+	c.Assert(ana.Weight, qt.Equals, uint64(2))
+	c.Assert(ana.Email, qt.Equals, "ana@example.com")
+}
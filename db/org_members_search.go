@@ -0,0 +1,372 @@
+package db
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// OrgMemberSortField is a field org member searches can be sorted by.
+type OrgMemberSortField string
+
+const (
+	OrgMemberSortName         OrgMemberSortField = "name"
+	OrgMemberSortSurname      OrgMemberSortField = "surname"
+	OrgMemberSortMemberNumber OrgMemberSortField = "memberNumber"
+	OrgMemberSortEmail        OrgMemberSortField = "email"
+	OrgMemberSortWeight       OrgMemberSortField = "weight"
+	OrgMemberSortBirthDate    OrgMemberSortField = "birthDate"
+	OrgMemberSortCreatedAt    OrgMemberSortField = "createdAt"
+)
+
+// IsValid reports whether f is a known sort field.
+func (f OrgMemberSortField) IsValid() bool {
+	switch f {
+	case OrgMemberSortName, OrgMemberSortSurname, OrgMemberSortMemberNumber, OrgMemberSortEmail,
+		OrgMemberSortWeight, OrgMemberSortBirthDate, OrgMemberSortCreatedAt:
+		return true
+	}
+	return false
+}
+
+// bsonField returns the BSON field a sort field orders by.
+func (f OrgMemberSortField) bsonField() string {
+	if f == OrgMemberSortBirthDate {
+		return "parsedBirthDate"
+	}
+	return string(f)
+}
+
+// OrgMemberSearch is a structured search over the members of an organization. Every criterion
+// set must hold. Results are sorted by Sort, then by id, and paged with an opaque cursor rather
+// than an offset, so reading deep into a large memberbase costs the same as reading its start.
+type OrgMemberSearch struct {
+	// In matches a lookup field against a set of values; a single value is an equality. Emails
+	// are matched lowercased, and phones in plaintext are hashed as they are stored.
+	In map[OrgMemberLookupField][]string
+	// WeightMin and WeightMax bound the weight, inclusively.
+	WeightMin *uint64
+	WeightMax *uint64
+	// BirthDateFrom and BirthDateTo bound the birth date, inclusively. Members without a birth
+	// date never match a birth date range.
+	BirthDateFrom *time.Time
+	BirthDateTo   *time.Time
+	// HasEmail and HasPhone match members with, or without, an email or phone.
+	HasEmail *bool
+	HasPhone *bool
+	// GroupID matches the members of a group of the organization. The auto group matches everyone.
+	GroupID string
+	// CensusID matches the participants of a census of the organization.
+	CensusID string
+	// Sort is the field to sort by; empty sorts by creation order (id).
+	Sort       OrgMemberSortField
+	Descending bool
+	// Limit is the maximum number of members returned.
+	Limit int64
+	// Cursor is the NextCursor of the previous page, empty for the first one.
+	Cursor string
+	// IncludeTotal counts every member matching the search, at the cost of a second query.
+	IncludeTotal bool
+}
+
+// OrgMemberSearchResult is a page of an org member search.
+type OrgMemberSearchResult struct {
+	Members []*OrgMember
+	// NextCursor continues the search after this page; empty on the last page.
+	NextCursor string
+	// Total is only set when the search asked for it.
+	Total *int64
+}
+
+// orgMemberCursor is the position after the last member of a page: its sort value and id. The
+// sort it was taken with is kept too, as the position means nothing under another order.
+type orgMemberCursor struct {
+	Sort       OrgMemberSortField `bson:"s"`
+	Descending bool               `bson:"d"`
+	Value      any                `bson:"v"`
+	ID         primitive.ObjectID `bson:"i"`
+}
+
+// orgMemberCursorRead is orgMemberCursor decoded, its value kept raw so it compares with the
+// stored one under the same BSON type.
+type orgMemberCursorRead struct {
+	Sort       OrgMemberSortField `bson:"s"`
+	Descending bool               `bson:"d"`
+	Value      bson.RawValue      `bson:"v"`
+	ID         primitive.ObjectID `bson:"i"`
+}
+
+func encodeOrgMemberCursor(c *orgMemberCursor) (string, error) {
+	data, err := bson.Marshal(c)
+	if err != nil {
+		return "", fmt.Errorf("could not encode search cursor: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+func decodeOrgMemberCursor(s string) (*orgMemberCursorRead, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidData)
+	}
+	c := &orgMemberCursorRead{}
+	if err := bson.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("%w: malformed cursor", ErrInvalidData)
+	}
+	return c, nil
+}
+
+// searchConditions translates the criteria of a search into query conditions.
+func (ms *MongoStorage) searchConditions(ctx context.Context, org *Organization, search *OrgMemberSearch,
+) ([]bson.M, error) {
+	conditions := []bson.M{{"orgAddress": org.Address}}
+	for field, values := range search.In {
+		if !field.IsValid() || len(values) == 0 {
+			return nil, fmt.Errorf("%w: cannot match field %q", ErrInvalidData, field)
+		}
+		matches := make([]any, 0, len(values))
+		for _, v := range values {
+			switch field {
+			case OrgMemberLookupFieldEmail:
+				matches = append(matches, strings.ToLower(strings.TrimSpace(v)))
+			case OrgMemberLookupFieldPhone:
+				phone, err := NewHashedPhone(v, org)
+				if err != nil {
+					return nil, fmt.Errorf("%w: invalid phone %q", ErrInvalidData, v)
+				}
+				matches = append(matches, phone)
+			default:
+				matches = append(matches, strings.TrimSpace(v))
+			}
+		}
+		conditions = append(conditions, bson.M{field.bsonField(): bson.M{"$in": matches}})
+	}
+	if search.WeightMin != nil || search.WeightMax != nil {
+		weight := bson.M{}
+		if search.WeightMin != nil {
+			weight["$gte"] = *search.WeightMin
+		}
+		if search.WeightMax != nil {
+			weight["$lte"] = *search.WeightMax
+		}
+		conditions = append(conditions, bson.M{"weight": weight})
+	}
+	if search.BirthDateFrom != nil || search.BirthDateTo != nil {
+		// a member without a birth date stores the zero time, which no range should match
+		birthDate := bson.M{"$gt": time.Time{}}
+		if search.BirthDateFrom != nil {
+			birthDate["$gte"] = *search.BirthDateFrom
+		}
+		if search.BirthDateTo != nil {
+			birthDate["$lte"] = *search.BirthDateTo
+		}
+		conditions = append(conditions, bson.M{"parsedBirthDate": birthDate})
+	}
+	if search.HasEmail != nil {
+		conditions = append(conditions, presenceCondition("email", *search.HasEmail, ""))
+	}
+	if search.HasPhone != nil {
+		conditions = append(conditions, presenceCondition("phone", *search.HasPhone, []byte{}))
+	}
+	if search.GroupID != "" {
+		group, err := ms.OrganizationMemberGroup(search.GroupID, org.Address)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidData) {
+				return nil, fmt.Errorf("group %s: %w", search.GroupID, ErrNotFound)
+			}
+			return nil, fmt.Errorf("could not load group: %w", err)
+		}
+		// the auto group holds every member: no condition
+		if !group.IsAutoGroup {
+			conditions = append(conditions, bson.M{"_id": bson.M{"$in": hexToObjectIDs(group.MemberIDs)}})
+		}
+	}
+	if search.CensusID != "" {
+		ids, err := ms.censusMemberObjectIDs(ctx, org, search.CensusID)
+		if err != nil {
+			return nil, err
+		}
+		conditions = append(conditions, bson.M{"_id": bson.M{"$in": ids}})
+	}
+	return conditions, nil
+}
+
+// presenceCondition matches documents where field is set (not missing, null or empty), or unset.
+func presenceCondition(field string, present bool, empty any) bson.M {
+	unset := []any{nil, empty}
+	if present {
+		return bson.M{field: bson.M{"$nin": unset}}
+	}
+	return bson.M{field: bson.M{"$in": unset}}
+}
+
+// hexToObjectIDs converts member ids, skipping any malformed one: it names no member.
+func hexToObjectIDs(ids []string) []primitive.ObjectID {
+	objIDs := make([]primitive.ObjectID, 0, len(ids))
+	for _, id := range ids {
+		if objID, err := primitive.ObjectIDFromHex(id); err == nil {
+			objIDs = append(objIDs, objID)
+		}
+	}
+	return objIDs
+}
+
+// censusMemberObjectIDs returns the member ids of the participants of a census of org. The ids
+// are streamed rather than collected with Distinct, whose reply is capped at 16MB.
+func (ms *MongoStorage) censusMemberObjectIDs(ctx context.Context, org *Organization, censusID string,
+) ([]primitive.ObjectID, error) {
+	census, err := ms.Census(censusID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) || errors.Is(err, ErrInvalidData) {
+			return nil, fmt.Errorf("census %s: %w", censusID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("could not load census: %w", err)
+	}
+	if census.OrgAddress != org.Address {
+		return nil, fmt.Errorf("census %s: %w", censusID, ErrNotFound)
+	}
+	cursor, err := ms.censusParticipants.Find(ctx, bson.M{"censusId": censusID},
+		options.Find().SetProjection(bson.M{"participantID": 1, "_id": 0}))
+	if err != nil {
+		return nil, fmt.Errorf("could not find census participants: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	ids := []primitive.ObjectID{}
+	for cursor.Next(ctx) {
+		var p struct {
+			ParticipantID string `bson:"participantID"`
+		}
+		if err := cursor.Decode(&p); err != nil {
+			return nil, fmt.Errorf("could not decode census participant: %w", err)
+		}
+		if id, err := primitive.ObjectIDFromHex(p.ParticipantID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("could not read census participants: %w", err)
+	}
+	return ids, nil
+}
+
+// SearchOrgMembers returns a page of the members of org matching search. A cursor taken under a
+// different sort, or a malformed one, returns ErrInvalidData; a group or census that is not the
+// organization's returns ErrNotFound.
+//
+// Pages are read by keyset: the query resumes strictly after the cursor's (sort value, id) in
+// the sort order, which the {orgAddress, <field>, _id} indexes serve without skipping documents.
+func (ms *MongoStorage) SearchOrgMembers(org *Organization, search *OrgMemberSearch) (*OrgMemberSearchResult, error) {
+	if org == nil || search == nil || search.Limit <= 0 {
+		return nil, ErrInvalidData
+	}
+	if search.Sort != "" && !search.Sort.IsValid() {
+		return nil, fmt.Errorf("%w: cannot sort by %q", ErrInvalidData, search.Sort)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	conditions, err := ms.searchConditions(ctx, org, search)
+	if err != nil {
+		return nil, err
+	}
+	result := &OrgMemberSearchResult{Members: []*OrgMember{}}
+	if search.IncludeTotal {
+		total, err := ms.orgMembers.CountDocuments(ctx, bson.M{"$and": conditions})
+		if err != nil {
+			return nil, fmt.Errorf("failed to count org members: %w", err)
+		}
+		result.Total = &total
+	}
+
+	direction, after := 1, "$gt"
+	if search.Descending {
+		direction, after = -1, "$lt"
+	}
+	sort := bson.D{{Key: "_id", Value: direction}}
+	if search.Sort != "" {
+		sort = append(bson.D{{Key: search.Sort.bsonField(), Value: direction}}, sort...)
+	}
+	if search.Cursor != "" {
+		c, err := decodeOrgMemberCursor(search.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		if c.Sort != search.Sort || c.Descending != search.Descending {
+			return nil, fmt.Errorf("%w: the cursor was taken under another sort", ErrInvalidData)
+		}
+		if search.Sort == "" {
+			conditions = append(conditions, bson.M{"_id": bson.M{after: c.ID}})
+		} else {
+			conditions = append(conditions, afterSortValue(search.Sort.bsonField(), after, c))
+		}
+	}
+
+	// one more than the page, to tell whether another page follows
+	opts := options.Find().SetSort(sort).SetLimit(search.Limit + 1)
+	cursor, err := ms.orgMembers.Find(ctx, bson.M{"$and": conditions}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search org members: %w", err)
+	}
+	// members are kept raw too, so the cursor takes the sort value as it is stored, missing included
+	var raws []bson.Raw
+	if err := cursor.All(ctx, &raws); err != nil {
+		return nil, fmt.Errorf("failed to read org members: %w", err)
+	}
+	for _, raw := range raws {
+		member := &OrgMember{}
+		if err := bson.Unmarshal(raw, member); err != nil {
+			return nil, fmt.Errorf("failed to decode org member: %w", err)
+		}
+		result.Members = append(result.Members, member)
+	}
+	if int64(len(result.Members)) > search.Limit {
+		result.Members = result.Members[:search.Limit]
+		last := result.Members[len(result.Members)-1]
+		next := &orgMemberCursor{Sort: search.Sort, Descending: search.Descending, ID: last.ID}
+		if search.Sort != "" {
+			if value, err := raws[search.Limit-1].LookupErr(search.Sort.bsonField()); err == nil {
+				next.Value = value
+			}
+		}
+		if result.NextCursor, err = encodeOrgMemberCursor(next); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// afterSortValue matches the members after the cursor c in the order of field, after being $gt
+// ascending and $lt descending. Members without the field, or with it null, sort before every value,
+// which a comparison with a value never matches, so they are matched by their own branches.
+func afterSortValue(field, after string, c *orgMemberCursorRead) bson.M {
+	isNull := c.Value.Type == 0 || c.Value.Type == bson.TypeNull
+	switch {
+	case isNull && after == "$gt":
+		return bson.M{"$or": []bson.M{
+			{field: bson.M{"$ne": nil}},
+			{field: nil, "_id": bson.M{after: c.ID}},
+		}}
+	case isNull:
+		return bson.M{field: nil, "_id": bson.M{after: c.ID}}
+	}
+	branches := []bson.M{
+		{field: bson.M{after: c.Value}},
+		{field: c.Value, "_id": bson.M{after: c.ID}},
+	}
+	if after == "$lt" {
+		branches = append(branches, bson.M{field: nil})
+	}
+	return bson.M{"$or": branches}
+}
@@ -0,0 +1,132 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestSearchOrgMembers(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	ids := map[string]string{}
+	for _, m := range []*OrgMember{
+		{MemberNumber: "1", Name: "Carla", Email: "carla@example.com", Weight: 1, BirthDate: "1990-01-01"},
+		{MemberNumber: "2", Name: "Ana", Email: "ana@example.com", Weight: 3, PlaintextPhone: "+34600000001"},
+		{MemberNumber: "3", Name: "Bea", Weight: 5, BirthDate: "2001-06-30"},
+		{MemberNumber: "4", Name: "Ana", Email: "ana2@example.com", Weight: 2},
+	} {
+		id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, m, testSalt, MemberChangeSource{})
+		c.Assert(err, qt.IsNil)
+		ids[m.MemberNumber] = id.Hex()
+	}
+	numbers := func(members []*OrgMember) []string {
+		out := []string{}
+		for _, m := range members {
+			out = append(out, m.MemberNumber)
+		}
+		return out
+	}
+	search := func(s *OrgMemberSearch) *OrgMemberSearchResult {
+		if s.Limit == 0 {
+			s.Limit = 10
+		}
+		result, err := testDB.SearchOrgMembers(org, s)
+		c.Assert(err, qt.IsNil)
+		return result
+	}
+	yes, no := true, false
+	weight := uint64(2)
+	born, _ := time.Parse(time.DateOnly, "2000-01-01")
+
+	c.Assert(numbers(search(&OrgMemberSearch{
+		In: map[OrgMemberLookupField][]string{OrgMemberLookupFieldName: {"Ana", "Bea"}},
+	}).Members), qt.DeepEquals, []string{"2", "3", "4"})
+	c.Assert(numbers(search(&OrgMemberSearch{
+		In: map[OrgMemberLookupField][]string{OrgMemberLookupFieldEmail: {" ANA@example.com"}},
+	}).Members), qt.DeepEquals, []string{"2"})
+	c.Assert(numbers(search(&OrgMemberSearch{
+		In: map[OrgMemberLookupField][]string{OrgMemberLookupFieldPhone: {"600000001"}},
+	}).Members), qt.DeepEquals, []string{"2"})
+	c.Assert(numbers(search(&OrgMemberSearch{WeightMin: &weight}).Members), qt.DeepEquals, []string{"2", "3", "4"})
+	c.Assert(numbers(search(&OrgMemberSearch{BirthDateTo: &born}).Members), qt.DeepEquals, []string{"1"})
+	c.Assert(numbers(search(&OrgMemberSearch{HasEmail: &no}).Members), qt.DeepEquals, []string{"3"})
+	c.Assert(numbers(search(&OrgMemberSearch{HasPhone: &yes}).Members), qt.DeepEquals, []string{"2"})
+
+	// pages follow each other by cursor, ties on the sort field broken by id
+	var pages [][]string
+	s := &OrgMemberSearch{Sort: OrgMemberSortName, Descending: true, Limit: 3, IncludeTotal: true}
+	for {
+		result := search(s)
+		c.Assert(*result.Total, qt.Equals, int64(4))
+		pages = append(pages, numbers(result.Members))
+		if result.NextCursor == "" {
+			break
+		}
+		s.Cursor = result.NextCursor
+	}
+	c.Assert(pages, qt.DeepEquals, [][]string{{"1", "3", "4"}, {"2"}})
+
+	// a cursor only continues the sort it was taken under
+	s.Sort = OrgMemberSortWeight
+	_, err := testDB.SearchOrgMembers(org, s)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+	_, err = testDB.SearchOrgMembers(org, &OrgMemberSearch{Limit: 1, Cursor: "not-a-cursor"})
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+
+	// group membership, and an unknown group
+	groupID, err := testDB.CreateOrganizationMemberGroup(&OrganizationMemberGroup{
+		OrgAddress: testOrgAddress, Title: "g", MemberIDs: []string{ids["1"], ids["4"]},
+	})
+	c.Assert(err, qt.IsNil)
+	c.Assert(numbers(search(&OrgMemberSearch{GroupID: groupID, Sort: OrgMemberSortWeight}).Members),
+		qt.DeepEquals, []string{"1", "4"})
+	_, err = testDB.SearchOrgMembers(org, &OrgMemberSearch{Limit: 1, GroupID: "000000000000000000000000"})
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+}
+
+func TestSearchOrgMembersWithoutSortField(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	for _, m := range []*OrgMember{{MemberNumber: "1", Name: "Bea"}, {MemberNumber: "2", Name: "Ana"}} {
+		_, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, m, testSalt, MemberChangeSource{})
+		c.Assert(err, qt.IsNil)
+	}
+	// members stored before the field existed lack it, or hold it null
+	_, err := testDB.orgMembers.InsertMany(context.Background(), []any{
+		bson.M{"_id": primitive.NewObjectID(), "orgAddress": org.Address, "memberNumber": "3"},
+		bson.M{"_id": primitive.NewObjectID(), "orgAddress": org.Address, "memberNumber": "4", "name": nil},
+	})
+	c.Assert(err, qt.IsNil)
+
+	// one member a page, so every cursor is taken on a member with or without a name
+	pages := func(descending bool) []string {
+		out := []string{}
+		s := &OrgMemberSearch{Sort: OrgMemberSortName, Descending: descending, Limit: 1}
+		for {
+			result, err := testDB.SearchOrgMembers(org, s)
+			c.Assert(err, qt.IsNil)
+			for _, m := range result.Members {
+				out = append(out, m.MemberNumber)
+			}
+			if result.NextCursor == "" {
+				return out
+			}
+			s.Cursor = result.NextCursor
+		}
+	}
+	c.Assert(pages(false), qt.DeepEquals, []string{"3", "4", "2", "1"})
+	c.Assert(pages(true), qt.DeepEquals, []string{"1", "2", "4", "3"})
+}
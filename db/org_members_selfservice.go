@@ -0,0 +1,456 @@
+package db
+
+import (
+	"context"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// SelfServiceFieldEmail and SelfServiceFieldPhone name the contact fields a member may edit
+	// from the self-service portal. Any other editable field is a key of the member's other data.
+	SelfServiceFieldEmail = "email"
+	SelfServiceFieldPhone = "phone"
+
+	// maxSelfServiceLookup bounds the members read to identify a self-service login: two are
+	// enough to tell a unique match from an ambiguous one.
+	maxSelfServiceLookup = 2
+)
+
+// selfServiceReservedFields are the member fields a self-service config can never open up: they
+// identify the member (and feed the census login hashes), or are managed by the organization.
+var selfServiceReservedFields = map[string]bool{
+	"id": true, "orgAddress": true, "name": true, "surname": true, "memberNumber": true,
+	"nationalId": true, "birthDate": true, "parsedBirthDate": true, "password": true, "pass": true,
+	"weight": true, "other": true, "createdAt": true, "updatedAt": true,
+}
+
+// MemberSelfServiceConfig configures the public portal where members of an organization update
+// their own contact data. A member logs in with the AuthFields plus a one-time code sent to one of
+// the TwoFaFields, and may then change the EditableFields.
+type MemberSelfServiceConfig struct {
+	Enabled     bool                 `json:"enabled" bson:"enabled"`
+	AuthFields  OrgMemberAuthFields  `json:"authFields" bson:"authFields"`
+	TwoFaFields OrgMemberTwoFaFields `json:"twoFaFields" bson:"twoFaFields"`
+	// EditableFields lists "email", "phone", or keys of the member's other data (e.g. "address").
+	EditableFields []string `json:"editableFields" bson:"editableFields"`
+	// RequireApproval queues every change for a manager instead of applying it right away.
+	RequireApproval bool `json:"requireApproval" bson:"requireApproval"`
+}
+
+// Validate checks the config can be served: a member is identified by at least one auth field,
+// the one-time code needs a contact channel, and the editable fields must be contact fields or
+// plain keys of the member's other data.
+func (c *MemberSelfServiceConfig) Validate() error {
+	if len(c.AuthFields) == 0 {
+		return fmt.Errorf("%w: at least one auth field is required", ErrInvalidData)
+	}
+	for _, f := range c.AuthFields {
+		switch f {
+		case OrgMemberAuthFieldsName, OrgMemberAuthFieldsSurname, OrgMemberAuthFieldsMemberNumber,
+			OrgMemberAuthFieldsNationalID, OrgMemberAuthFieldsBirthDate:
+		default:
+			return fmt.Errorf("%w: invalid auth field %q", ErrInvalidData, f)
+		}
+	}
+	if len(c.TwoFaFields) == 0 {
+		return fmt.Errorf("%w: at least one twoFa field is required", ErrInvalidData)
+	}
+	for _, f := range c.TwoFaFields {
+		if f != OrgMemberTwoFaFieldEmail && f != OrgMemberTwoFaFieldPhone {
+			return fmt.Errorf("%w: invalid twoFa field %q", ErrInvalidData, f)
+		}
+	}
+	if len(c.EditableFields) == 0 {
+		return fmt.Errorf("%w: at least one editable field is required", ErrInvalidData)
+	}
+	seen := make(map[string]bool, len(c.EditableFields))
+	for _, f := range c.EditableFields {
+		// the field becomes part of a mongo key path (other.<field>) when applied
+		if f == "" || f != strings.TrimSpace(f) || strings.ContainsAny(f, ".$") {
+			return fmt.Errorf("%w: invalid editable field %q", ErrInvalidData, f)
+		}
+		if selfServiceReservedFields[f] {
+			return fmt.Errorf("%w: field %q cannot be edited by members", ErrInvalidData, f)
+		}
+		if seen[f] {
+			return fmt.Errorf("%w: duplicated editable field %q", ErrInvalidData, f)
+		}
+		seen[f] = true
+	}
+	return nil
+}
+
+// Editable reports whether members may change the given field.
+func (c *MemberSelfServiceConfig) Editable(field string) bool {
+	for _, f := range c.EditableFields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// MemberSelfServiceAnchor returns the anchor of the CSP auth tokens issued by the self-service
+// portal of the given organization. It is derived, not random, so it can never be the id of a
+// bundle or a process: a portal token is therefore useless to sign a vote, and a voting token
+// is useless in the portal.
+func MemberSelfServiceAnchor(orgAddress common.Address) internal.HexBytes {
+	h := sha256.Sum256(append([]byte("member-self-service"), orgAddress.Bytes()...))
+	return h[:]
+}
+
+// SetMemberSelfServiceConfig stores the self-service config of the organization. A nil config
+// disables the portal.
+func (ms *MongoStorage) SetMemberSelfServiceConfig(orgAddress common.Address, config *MemberSelfServiceConfig) error {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	updateDoc := bson.M{"$set": bson.M{"memberSelfService": config}}
+	if config == nil {
+		updateDoc = bson.M{"$unset": bson.M{"memberSelfService": ""}}
+	}
+	res, err := ms.organizations.UpdateOne(ctx, bson.M{"_id": orgAddress}, updateDoc)
+	if err != nil {
+		return fmt.Errorf("failed to set member self-service config: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// OrgMembersByLogin returns the members of the organization matching the given member data on
+// every one of the auth fields and on its contact: the email if set, otherwise the hashed phone.
+// The member data must already be normalized. At most two members are returned, enough for the
+// caller to refuse an ambiguous login.
+func (ms *MongoStorage) OrgMembersByLogin(
+	orgAddress common.Address, authFields OrgMemberAuthFields, member *OrgMember,
+) ([]*OrgMember, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return nil, ErrInvalidData
+	}
+	filter := bson.M{"orgAddress": orgAddress}
+	for _, f := range authFields {
+		var value string
+		switch f {
+		case OrgMemberAuthFieldsName:
+			value = member.Name
+		case OrgMemberAuthFieldsSurname:
+			value = member.Surname
+		case OrgMemberAuthFieldsMemberNumber:
+			value = member.MemberNumber
+		case OrgMemberAuthFieldsNationalID:
+			value = member.NationalID
+		case OrgMemberAuthFieldsBirthDate:
+			value = member.BirthDate
+		default:
+			return nil, fmt.Errorf("%w: invalid auth field %q", ErrInvalidData, f)
+		}
+		// an empty value would match every member missing that field
+		if value == "" {
+			return nil, fmt.Errorf("%w: missing auth field %q", ErrInvalidData, f)
+		}
+		filter[string(f)] = value
+	}
+	switch {
+	case member.Email != "":
+		filter["email"] = member.Email
+	case !member.Phone.IsEmpty():
+		filter["phone"] = member.Phone
+	default:
+		return nil, fmt.Errorf("%w: missing contact", ErrInvalidData)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	cursor, err := ms.orgMembers.Find(ctx, filter, options.Find().SetLimit(maxSelfServiceLookup))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find org members: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	var members []*OrgMember
+	if err := cursor.All(ctx, &members); err != nil {
+		return nil, fmt.Errorf("failed to decode org members: %w", err)
+	}
+	return members, nil
+}
+
+// MemberUpdateStatus is the state of a member update request.
+type MemberUpdateStatus string
+
+const (
+	MemberUpdatePending  MemberUpdateStatus = "pending"
+	MemberUpdateApplied  MemberUpdateStatus = "applied"
+	MemberUpdateRejected MemberUpdateStatus = "rejected"
+	// MemberUpdateFailed marks a change the portal could not apply right away. It is not
+	// queued for approval: the member is told and may submit it again.
+	MemberUpdateFailed MemberUpdateStatus = "failed"
+)
+
+// MemberUpdateRequest records a change a member submitted from the self-service portal. It is
+// both the approval queue and the audit trail: requests are never deleted while the
+// organization exists. The phone number in Changes is kept in plain text only while the
+// request is pending, since it is needed to apply it, and is masked once resolved.
+type MemberUpdateRequest struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	OrgAddress common.Address     `json:"orgAddress" bson:"orgAddress"`
+	MemberID   string             `json:"memberId" bson:"memberId"`
+	Changes    map[string]string  `json:"changes" bson:"changes"`
+	Status     MemberUpdateStatus `json:"status" bson:"status"`
+	CreatedAt  time.Time          `json:"createdAt" bson:"createdAt"`
+	ResolvedAt time.Time          `json:"resolvedAt,omitempty" bson:"resolvedAt,omitempty"`
+	// ResolvedBy is the email of the manager who resolved the request, empty when the change
+	// was applied straight from the portal.
+	ResolvedBy string `json:"resolvedBy,omitempty" bson:"resolvedBy,omitempty"`
+}
+
+// CreateMemberUpdateRequest stores a new pending member update request and returns its id.
+func (ms *MongoStorage) CreateMemberUpdateRequest(req *MemberUpdateRequest) (string, error) {
+	if req == nil || req.OrgAddress.Cmp(common.Address{}) == 0 || req.MemberID == "" || len(req.Changes) == 0 {
+		return "", ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	req.ID = primitive.NewObjectID()
+	req.Status = MemberUpdatePending
+	req.CreatedAt = time.Now()
+	if _, err := ms.memberUpdates.InsertOne(ctx, req); err != nil {
+		return "", fmt.Errorf("failed to create member update request: %w", err)
+	}
+	return req.ID.Hex(), nil
+}
+
+// MemberUpdateRequest returns the member update request with the given id in the organization.
+func (ms *MongoStorage) MemberUpdateRequest(orgAddress common.Address, id string) (*MemberUpdateRequest, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	req := &MemberUpdateRequest{}
+	err = ms.memberUpdates.FindOne(ctx, bson.M{"_id": oid, "orgAddress": orgAddress}).Decode(req)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member update request: %w", err)
+	}
+	return req, nil
+}
+
+// MemberUpdateRequests returns a page of the organization's member update requests, newest
+// first, optionally filtered by status, along with the total number of matching requests.
+func (ms *MongoStorage) MemberUpdateRequests(
+	orgAddress common.Address, status MemberUpdateStatus, page, limit int64,
+) (int64, []*MemberUpdateRequest, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, nil, ErrInvalidData
+	}
+	filter := bson.M{"orgAddress": orgAddress}
+	if status != "" {
+		filter["status"] = status
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	return paginatedDocuments[*MemberUpdateRequest](ms.memberUpdates, page, limit, filter, findOptions)
+}
+
+// ApplyMemberUpdateRequest writes the changes of a pending request to its member through
+// UpsertOrgMemberAndCensusParticipants, so the member's census participants follow. The request
+// is claimed first, so two concurrent approvals cannot both apply it; if the write then fails
+// the request goes back to pending for the manager to retry. resolvedBy is empty when the portal
+// applies it directly, with no approval required: a failed write then marks the request failed
+// rather than queueing it. It returns ErrNotFound for an unknown request and ErrConflict for one
+// already resolved.
+func (ms *MongoStorage) ApplyMemberUpdateRequest(org *Organization, id, resolvedBy, salt string) error {
+	req, err := ms.resolveMemberUpdateRequest(org.Address, id, MemberUpdateApplied, resolvedBy)
+	if err != nil {
+		return err
+	}
+	source := MemberChangeSource{Actor: resolvedBy, Via: MemberChangeViaSelfService}
+	if err := ms.applyMemberChanges(org, req, salt, source); err != nil {
+		if resolvedBy == "" {
+			if ferr := ms.failMemberUpdateRequest(req.ID); ferr != nil {
+				log.Warnw("could not mark member update request failed", "request", id, "error", ferr)
+			}
+			ms.maskMemberUpdateRequest(org, req)
+			return err
+		}
+		if rerr := ms.reopenMemberUpdateRequest(req.ID); rerr != nil {
+			log.Warnw("could not reopen member update request", "request", id, "error", rerr)
+		}
+		return err
+	}
+	ms.maskMemberUpdateRequest(org, req)
+	return nil
+}
+
+// RejectMemberUpdateRequest resolves a pending request without applying it. It returns
+// ErrNotFound for an unknown request and ErrConflict for one already resolved.
+func (ms *MongoStorage) RejectMemberUpdateRequest(org *Organization, id, resolvedBy string) error {
+	req, err := ms.resolveMemberUpdateRequest(org.Address, id, MemberUpdateRejected, resolvedBy)
+	if err != nil {
+		return err
+	}
+	ms.maskMemberUpdateRequest(org, req)
+	return nil
+}
+
+// DeleteMemberUpdateRequestsByOrg removes every member update request of the organization.
+// Best-effort cleanup used when tearing down an organization. Returns the number of deleted
+// requests.
+func (ms *MongoStorage) DeleteMemberUpdateRequestsByOrg(orgAddress common.Address) (int64, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.memberUpdates.DeleteMany(ctx, bson.M{"orgAddress": orgAddress})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete member update requests by org: %w", err)
+	}
+	return res.DeletedCount, nil
+}
+
+// resolveMemberUpdateRequest atomically moves a pending request to the given status and returns
+// it as it was before the transition.
+func (ms *MongoStorage) resolveMemberUpdateRequest(
+	orgAddress common.Address, id string, status MemberUpdateStatus, resolvedBy string,
+) (*MemberUpdateRequest, error) {
+	oid, err := primitive.ObjectIDFromHex(id)
+	if err != nil {
+		return nil, ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	set := bson.M{"status": status, "resolvedAt": time.Now()}
+	if resolvedBy != "" {
+		set["resolvedBy"] = resolvedBy
+	}
+	req := &MemberUpdateRequest{}
+	err = ms.memberUpdates.FindOneAndUpdate(ctx,
+		bson.M{"_id": oid, "orgAddress": orgAddress, "status": MemberUpdatePending},
+		bson.M{"$set": set},
+	).Decode(req)
+	if err == nil {
+		return req, nil
+	}
+	if !errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, fmt.Errorf("failed to resolve member update request: %w", err)
+	}
+	// nothing pending matched: tell an unknown request from one already resolved
+	count, err := ms.memberUpdates.CountDocuments(ctx, bson.M{"_id": oid, "orgAddress": orgAddress})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get member update request: %w", err)
+	}
+	if count == 0 {
+		return nil, ErrNotFound
+	}
+	return nil, ErrConflict
+}
+
+// reopenMemberUpdateRequest moves a request claimed for applying back to pending.
+func (ms *MongoStorage) reopenMemberUpdateRequest(id primitive.ObjectID) error {
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, err := ms.memberUpdates.UpdateOne(ctx,
+		bson.M{"_id": id, "status": MemberUpdateApplied},
+		bson.M{
+			"$set":   bson.M{"status": MemberUpdatePending},
+			"$unset": bson.M{"resolvedAt": "", "resolvedBy": ""},
+		})
+	return err
+}
+
+// failMemberUpdateRequest moves a request claimed for applying to failed.
+func (ms *MongoStorage) failMemberUpdateRequest(id primitive.ObjectID) error {
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	_, err := ms.memberUpdates.UpdateOne(ctx,
+		bson.M{"_id": id, "status": MemberUpdateApplied},
+		bson.M{"$set": bson.M{"status": MemberUpdateFailed}})
+	return err
+}
+
+// applyMemberChanges writes the changes of the request to its member.
+func (ms *MongoStorage) applyMemberChanges(org *Organization, req *MemberUpdateRequest, salt string,
+	source MemberChangeSource,
+) error {
+	member, err := ms.OrgMember(org.Address, req.MemberID)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return fmt.Errorf("member %s: %w", req.MemberID, ErrNotFound)
+		}
+		return err
+	}
+	for field, value := range req.Changes {
+		switch field {
+		case SelfServiceFieldEmail:
+			member.Email = value
+		case SelfServiceFieldPhone:
+			member.PlaintextPhone = value
+		default:
+			if member.Other == nil {
+				member.Other = map[string]any{}
+			}
+			member.Other[field] = value
+		}
+	}
+	if _, _, err := ms.UpsertOrgMemberAndCensusParticipants(org, member, salt, source); err != nil {
+		return err
+	}
+	return nil
+}
+
+// maskMemberUpdateRequest replaces the plain text phone of a resolved request with its masked
+// hash. Failing to do so is logged rather than returned: the request is already resolved.
+func (ms *MongoStorage) maskMemberUpdateRequest(org *Organization, req *MemberUpdateRequest) {
+	phone, ok := req.Changes[SelfServiceFieldPhone]
+	if !ok {
+		return
+	}
+	masked := ""
+	if hashed, err := NewHashedPhone(phone, org); err == nil {
+		masked = hashed.String()
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	if _, err := ms.memberUpdates.UpdateOne(ctx,
+		bson.M{"_id": req.ID},
+		bson.M{"$set": bson.M{"changes." + SelfServiceFieldPhone: masked}},
+	); err != nil {
+		log.Warnw("could not mask member update request phone", "request", req.ID.Hex(), "error", err)
+	}
+}
@@ -0,0 +1,124 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestMemberSelfServiceConfigValidate(t *testing.T) {
+	c := qt.New(t)
+	valid := MemberSelfServiceConfig{
+		Enabled:        true,
+		AuthFields:     OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		TwoFaFields:    OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail},
+		EditableFields: []string{SelfServiceFieldEmail, SelfServiceFieldPhone, "address"},
+	}
+	c.Assert(valid.Validate(), qt.IsNil)
+	c.Assert(valid.Editable("address"), qt.IsTrue)
+	c.Assert(valid.Editable("name"), qt.IsFalse)
+
+	for name, mutate := range map[string]func(*MemberSelfServiceConfig){
+		"no auth field":   func(cfg *MemberSelfServiceConfig) { cfg.AuthFields = nil },
+		"no contact":      func(cfg *MemberSelfServiceConfig) { cfg.TwoFaFields = nil },
+		"nothing to edit": func(cfg *MemberSelfServiceConfig) { cfg.EditableFields = nil },
+		"identity field":  func(cfg *MemberSelfServiceConfig) { cfg.EditableFields = []string{"nationalId"} },
+		"weight":          func(cfg *MemberSelfServiceConfig) { cfg.EditableFields = []string{"weight"} },
+		"key path":        func(cfg *MemberSelfServiceConfig) { cfg.EditableFields = []string{"address.city"} },
+		"duplicated":      func(cfg *MemberSelfServiceConfig) { cfg.EditableFields = []string{"address", "address"} },
+	} {
+		cfg := valid
+		mutate(&cfg)
+		c.Assert(errors.Is(cfg.Validate(), ErrInvalidData), qt.IsTrue, qt.Commentf(name))
+	}
+}
+
+func TestMemberUpdateRequests(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+
+	memberID, err := testDB.SetOrgMember(testSalt, &OrgMember{
+		OrgAddress: testOrgAddress, MemberNumber: "42", Email: "ana@example.com",
+		Other: map[string]any{"club": "chess"},
+	})
+	c.Assert(err, qt.IsNil)
+
+	// the login matches on the auth fields and the contact together
+	members, err := testDB.OrgMembersByLogin(testOrgAddress, OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		&OrgMember{MemberNumber: "42", Email: "ana@example.com"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(members, qt.HasLen, 1)
+	members, err = testDB.OrgMembersByLogin(testOrgAddress, OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		&OrgMember{MemberNumber: "43", Email: "ana@example.com"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(members, qt.HasLen, 0)
+	_, err = testDB.OrgMembersByLogin(testOrgAddress, OrgMemberAuthFields{OrgMemberAuthFieldsMemberNumber},
+		&OrgMember{Email: "ana@example.com"})
+	c.Assert(errors.Is(err, ErrInvalidData), qt.IsTrue)
+
+	applyID, err := testDB.CreateMemberUpdateRequest(&MemberUpdateRequest{
+		OrgAddress: testOrgAddress, MemberID: memberID,
+		Changes: map[string]string{SelfServiceFieldPhone: "+34600000001", "address": "Main St 1"},
+	})
+	c.Assert(err, qt.IsNil)
+	rejectID, err := testDB.CreateMemberUpdateRequest(&MemberUpdateRequest{
+		OrgAddress: testOrgAddress, MemberID: memberID,
+		Changes: map[string]string{SelfServiceFieldEmail: "mallory@example.com"},
+	})
+	c.Assert(err, qt.IsNil)
+
+	total, pending, err := testDB.MemberUpdateRequests(testOrgAddress, MemberUpdatePending, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(2))
+	c.Assert(pending, qt.HasLen, 2)
+
+	c.Assert(testDB.ApplyMemberUpdateRequest(org, applyID, "manager@example.com", testSalt), qt.IsNil)
+	c.Assert(testDB.RejectMemberUpdateRequest(org, rejectID, "manager@example.com"), qt.IsNil)
+	// a resolved request cannot be resolved again, an unknown one is not found
+	c.Assert(testDB.ApplyMemberUpdateRequest(org, rejectID, "", testSalt), qt.Equals, ErrConflict)
+	c.Assert(testDB.RejectMemberUpdateRequest(org, "000000000000000000000000", ""), qt.Equals, ErrNotFound)
+
+	member, err := testDB.OrgMember(testOrgAddress, memberID)
+	c.Assert(err, qt.IsNil)
+	wantPhone, err := NewHashedPhone("+34600000001", org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.Phone.Matches(wantPhone), qt.IsTrue)
+	c.Assert(member.Email, qt.Equals, "ana@example.com")
+	c.Assert(member.Other, qt.DeepEquals, map[string]any{"club": "chess", "address": "Main St 1"})
+
+	applied, err := testDB.MemberUpdateRequest(testOrgAddress, applyID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(applied.Status, qt.Equals, MemberUpdateApplied)
+	c.Assert(applied.ResolvedBy, qt.Equals, "manager@example.com")
+	// the phone is not kept in clear once resolved
+	c.Assert(applied.Changes[SelfServiceFieldPhone], qt.Equals, wantPhone.String())
+
+	// a request whose member is gone goes back to pending when a manager approves it...
+	goneID, err := testDB.CreateMemberUpdateRequest(&MemberUpdateRequest{
+		OrgAddress: testOrgAddress, MemberID: "000000000000000000000000",
+		Changes: map[string]string{"address": "Nowhere"},
+	})
+	c.Assert(err, qt.IsNil)
+	err = testDB.ApplyMemberUpdateRequest(org, goneID, "manager@example.com", testSalt)
+	c.Assert(errors.Is(err, ErrNotFound), qt.IsTrue)
+	gone, err := testDB.MemberUpdateRequest(testOrgAddress, goneID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(gone.Status, qt.Equals, MemberUpdatePending)
+	// ...and fails when the portal applies it, staying out of the approval queue
+	c.Assert(errors.Is(testDB.ApplyMemberUpdateRequest(org, goneID, "", testSalt), ErrNotFound), qt.IsTrue)
+	gone, err = testDB.MemberUpdateRequest(testOrgAddress, goneID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(gone.Status, qt.Equals, MemberUpdateFailed)
+	total, _, err = testDB.MemberUpdateRequests(testOrgAddress, MemberUpdatePending, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(0))
+
+	deleted, err := testDB.DeleteMemberUpdateRequestsByOrg(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(deleted, qt.Equals, int64(3))
+}
@@ -234,7 +234,7 @@ func TestOrgMembers(t *testing.T) {
 		}
 
 		// Perform bulk upsert
-		progressChan, err := testDB.AddBulkOrgMembers(testOrg, members, testSalt)
+		progressChan, err := testDB.AddBulkOrgMembers(testOrg, members, testSalt, MemberChangeSource{})
 		c.Assert(err, qt.IsNil)
 
 		// Wait for the operation to complete and get the final status
@@ -273,7 +273,7 @@ func TestOrgMembers(t *testing.T) {
 			Address: common.Address{},
 			Country: "ES",
 		}
-		_, err = testDB.AddBulkOrgMembers(testOrgWithEmptyAddress, members, testSalt)
+		_, err = testDB.AddBulkOrgMembers(testOrgWithEmptyAddress, members, testSalt, MemberChangeSource{})
 		c.Assert(err, qt.Not(qt.IsNil))
 	})
 
@@ -301,7 +301,7 @@ func TestOrgMembers(t *testing.T) {
 			},
 		}
 
-		progressChan, err := testDB.AddBulkOrgMembers(testOrg, members, testSalt)
+		progressChan, err := testDB.AddBulkOrgMembers(testOrg, members, testSalt, MemberChangeSource{})
 		c.Assert(err, qt.IsNil)
 
 		var lastStatus *BulkOrgMembersJob
@@ -339,7 +339,7 @@ func TestOrgMembers(t *testing.T) {
 		c.Assert(err, qt.Equals, ErrInvalidData)
 
 		// Test DeleteOrgMembers with zero address - should fail
-		_, _, err = testDB.DeleteOrgMembers(common.Address{}, []string{"some-id"})
+		_, _, err = testDB.DeleteOrgMembers(common.Address{}, []string{"some-id"}, MemberChangeSource{})
 		c.Assert(err, qt.Equals, ErrInvalidData)
 	})
 
@@ -531,7 +531,7 @@ func TestOrgMemberFieldsTrimmedOnWrite(t *testing.T) {
 				MemberNumber: "M-002 ",
 				NationalID:   " 87654321X",
 			},
-		}, testSalt)
+		}, testSalt, MemberChangeSource{})
 		c.Assert(err, qt.IsNil)
 
 		var lastStatus *BulkOrgMembersJob
@@ -607,7 +607,7 @@ func TestUpsertOrgMemberPartialUpdateKeepsLoginHash(t *testing.T) {
 
 	// a partial update carrying only the weight: every hashed field is absent from the request
 	_, created, err := testDB.UpsertOrgMemberAndCensusParticipants(
-		org, &OrgMember{ID: member.ID, Weight: 7}, "test_salt",
+		org, &OrgMember{ID: member.ID, Weight: 7}, "test_salt", MemberChangeSource{},
 	)
 	c.Assert(err, qt.IsNil)
 	c.Assert(created, qt.IsFalse)
@@ -640,14 +640,121 @@ func TestUpsertOrgMemberReportsCreated(t *testing.T) {
 		MemberNumber: "created-1",
 		Name:         "Grace",
 		Email:        "grace.created@example.com",
-	}, "test_salt")
+	}, "test_salt", MemberChangeSource{})
 	c.Assert(err, qt.IsNil)
 	c.Assert(created, qt.IsTrue)
 
 	_, created, err = testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
 		ID:   id,
 		Name: "Grace Hopper",
-	}, "test_salt")
+	}, "test_salt", MemberChangeSource{})
 	c.Assert(err, qt.IsNil)
 	c.Assert(created, qt.IsFalse)
 }
+
+// TestOrgMembersFrom pins the offset pagination SCIM lists page with, and the externalId lookup
+// SCIM filters use.
+func TestOrgMembersFrom(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	var ids []primitive.ObjectID
+	for _, number := range []string{"1", "2", "3"} {
+		id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+			MemberNumber: number,
+			ExternalID:   "hr-" + number,
+		}, "test_salt", MemberChangeSource{})
+		c.Assert(err, qt.IsNil)
+		ids = append(ids, id)
+	}
+
+	total, members, err := testDB.OrgMembersFrom(testOrgAddress, 1, 5)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(3))
+	c.Assert(members, qt.HasLen, 2)
+	c.Assert(members[0].ID, qt.Equals, ids[1])
+	c.Assert(members[1].ID, qt.Equals, ids[2])
+
+	total, members, err = testDB.OrgMembersFrom(testOrgAddress, 0, 0)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(3))
+	c.Assert(members, qt.HasLen, 0)
+
+	found, err := testDB.OrgMembersByField(testOrgAddress, OrgMemberLookupFieldExternalID, "hr-2")
+	c.Assert(err, qt.IsNil)
+	c.Assert(found, qt.HasLen, 1)
+	c.Assert(found[0].ID, qt.Equals, ids[1])
+}
+
+// TestSetOrgMemberActive pins that a deactivated member keeps its data and the censuses it is in,
+// but joins no new census until it is active again.
+func TestSetOrgMemberActive(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	org := &Organization{Address: testOrgAddress, CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+	var ids []string
+	for _, number := range []string{"1", "2"} {
+		id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+			MemberNumber: number,
+			Email:        "member" + number + "@example.com",
+		}, "test_salt", MemberChangeSource{})
+		c.Assert(err, qt.IsNil)
+		ids = append(ids, id.Hex())
+	}
+	groupID, err := testDB.CreateOrganizationMemberGroup(&OrganizationMemberGroup{
+		OrgAddress: testOrgAddress, Title: "both", MemberIDs: ids,
+	})
+	c.Assert(err, qt.IsNil)
+	before := &Census{OrgAddress: testOrgAddress, TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail}}
+	_, err = testDB.PopulateGroupCensus(before, groupID)
+	c.Assert(err, qt.IsNil)
+
+	source := MemberChangeSource{Via: MemberChangeViaSCIM}
+	c.Assert(testDB.SetOrgMemberActive(testOrgAddress, ids[1], false, source), qt.IsNil)
+	member, err := testDB.OrgMember(testOrgAddress, ids[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.DeactivatedAt.IsZero(), qt.IsFalse)
+	c.Assert(member.Email, qt.Equals, "member2@example.com")
+	_, versions, err := testDB.MemberHistory(testOrgAddress, ids[1], 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(versions[0].Changes, qt.DeepEquals, []MemberFieldChange{{Field: "active", Old: "true", New: "false"}})
+	// an edit through the upsert keeps the member inactive
+	_, _, err = testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+		ID: member.ID, Name: "Renamed",
+	}, "test_salt", MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	member, err = testDB.OrgMember(testOrgAddress, ids[1])
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.DeactivatedAt.IsZero(), qt.IsFalse)
+
+	// the census it was in keeps it; a new one, from the group or by id, does not take it
+	_, err = testDB.CensusParticipant(before.ID.Hex(), ids[1])
+	c.Assert(err, qt.IsNil)
+	after := &Census{OrgAddress: testOrgAddress, TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail}}
+	size, err := testDB.PopulateGroupCensus(after, groupID)
+	c.Assert(err, qt.IsNil)
+	c.Assert(size, qt.Equals, int64(1))
+	_, err = testDB.CensusParticipant(after.ID.Hex(), ids[1])
+	c.Assert(err, qt.Equals, ErrNotFound)
+	byID := &Census{OrgAddress: testOrgAddress, TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail}}
+	byIDHex, err := testDB.SetCensus(byID)
+	c.Assert(err, qt.IsNil)
+	added, errs, err := testDB.AddCensusParticipantsByMemberIDs(byIDHex, ids)
+	c.Assert(err, qt.IsNil)
+	c.Assert(added, qt.Equals, 1)
+	c.Assert(errs, qt.HasLen, 1)
+	c.Assert(errs[0], qt.Contains, "member is inactive")
+
+	// once active again it joins new censuses
+	c.Assert(testDB.SetOrgMemberActive(testOrgAddress, ids[1], true, source), qt.IsNil)
+	added, _, err = testDB.AddCensusParticipantsByMemberIDs(byIDHex, ids[1:])
+	c.Assert(err, qt.IsNil)
+	c.Assert(added, qt.Equals, 1)
+	c.Assert(testDB.SetOrgMemberActive(testOrgAddress, primitive.NewObjectID().Hex(), false, source), qt.Equals, ErrNotFound)
+}
@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MaxProcessTemplates bounds the templates an organization keeps.
+const MaxProcessTemplates = 100
+
+// ProcessTemplate is a voting process an organization saved to run again: its texts, questions
+// and census configuration, without dates. Instantiating it creates a new draft.
+type ProcessTemplate struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	OrgAddress common.Address     `json:"orgAddress" bson:"orgAddress"`
+	// Name identifies the template within its organization.
+	Name      string          `json:"name" bson:"name"`
+	Process   TemplateProcess `json:"process" bson:"process"`
+	CreatedAt time.Time       `json:"createdAt" bson:"createdAt"`
+}
+
+// TemplateProcess is the content of a process template.
+type TemplateProcess struct {
+	Title       MultiLangString    `json:"title" bson:"title"`
+	Description MultiLangString    `json:"description,omitempty" bson:"description,omitempty"`
+	Header      string             `json:"header,omitempty" bson:"header,omitempty"`
+	StreamURI   string             `json:"streamUri,omitempty" bson:"streamUri,omitempty"`
+	Census      TemplateCensus     `json:"census" bson:"census"`
+	Questions   []TemplateQuestion `json:"questions" bson:"questions"`
+}
+
+// TemplateCensus is the census configuration of a process template. Its participants are the
+// members of GroupID or MemberIDs when the template is instantiated, not when it was saved.
+type TemplateCensus struct {
+	Weighted        bool                 `json:"weighted" bson:"weighted"`
+	WeightRule      *WeightRule          `json:"weightRule,omitempty" bson:"weightRule,omitempty"`
+	Ephemeral       bool                 `json:"ephemeral,omitempty" bson:"ephemeral,omitempty"`
+	FreezeOnPublish bool                 `json:"freezeOnPublish,omitempty" bson:"freezeOnPublish,omitempty"`
+	MaxProxies      int                  `json:"maxProxies,omitempty" bson:"maxProxies,omitempty"`
+	AuthFields      OrgMemberAuthFields  `json:"authFields,omitempty" bson:"authFields,omitempty"`
+	TwoFaFields     OrgMemberTwoFaFields `json:"twoFaFields,omitempty" bson:"twoFaFields,omitempty"`
+	GroupID         string               `json:"groupId,omitempty" bson:"groupId,omitempty"`
+	MemberIDs       []string             `json:"memberIds,omitempty" bson:"memberIds,omitempty"`
+}
+
+// TemplateQuestion is a question of a process template, with its eligibility subset, if any, as
+// a group or a list of members.
+type TemplateQuestion struct {
+	Title             MultiLangString   `json:"title" bson:"title"`
+	Description       MultiLangString   `json:"description,omitempty" bson:"description,omitempty"`
+	Choices           []Choice          `json:"choices" bson:"choices"`
+	Type              string            `json:"type" bson:"type"`
+	TypeSetup         QuestionTypeSetup `json:"typeSetup" bson:"typeSetup"`
+	BallotProtocol    *BallotProtocol   `json:"ballotProtocol,omitempty" bson:"ballotProtocol,omitempty"`
+	SecretUntilTheEnd bool              `json:"secretUntilTheEnd" bson:"secretUntilTheEnd"`
+	EligibleGroupID   string            `json:"eligibleGroupId,omitempty" bson:"eligibleGroupId,omitempty"`
+	EligibleMemberIDs []string          `json:"eligibleMemberIds,omitempty" bson:"eligibleMemberIds,omitempty"`
+	Metadata          map[string]any    `json:"metadata,omitempty" bson:"metadata,omitempty"`
+	// Conditions refer to other questions of the template by their position, as on a process.
+	Conditions []QuestionCondition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+	Rules      *DecisionRules      `json:"rules,omitempty" bson:"rules,omitempty"`
+}
+
+// CreateProcessTemplate stores a new template of an organization and returns its id. Returns
+// ErrAlreadyExists when the organization has a template with the same name, and ErrInvalidData
+// once it keeps MaxProcessTemplates.
+func (ms *MongoStorage) CreateProcessTemplate(template *ProcessTemplate) (string, error) {
+	if template == nil || template.OrgAddress.Cmp(common.Address{}) == 0 || template.Name == "" {
+		return "", ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	// count and insert under the lock, so two requests cannot both take the last slot
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	count, err := ms.processTemplates.CountDocuments(ctx, bson.M{"orgAddress": template.OrgAddress})
+	if err != nil {
+		return "", fmt.Errorf("failed to count process templates: %w", err)
+	}
+	if count >= MaxProcessTemplates {
+		return "", fmt.Errorf("%w: an organization keeps at most %d templates", ErrInvalidData, MaxProcessTemplates)
+	}
+	template.ID = primitive.NewObjectID()
+	template.CreatedAt = time.Now()
+	if _, err := ms.processTemplates.InsertOne(ctx, template); err != nil {
+		if mongo.IsDuplicateKeyError(err) {
+			return "", ErrAlreadyExists
+		}
+		return "", fmt.Errorf("failed to create process template: %w", err)
+	}
+	return template.ID.Hex(), nil
+}
+
+// ProcessTemplate returns a template of an organization, or ErrNotFound.
+func (ms *MongoStorage) ProcessTemplate(orgAddress common.Address, templateID string) (*ProcessTemplate, error) {
+	oid, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	template := &ProcessTemplate{}
+	err = ms.processTemplates.FindOne(ctx, bson.M{"_id": oid, "orgAddress": orgAddress}).Decode(template)
+	if errors.Is(err, mongo.ErrNoDocuments) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get process template: %w", err)
+	}
+	return template, nil
+}
+
+// ProcessTemplates returns a page of the templates of an organization, by name.
+func (ms *MongoStorage) ProcessTemplates(orgAddress common.Address, page, limit int64) (int64, []*ProcessTemplate, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, nil, ErrInvalidData
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "name", Value: 1}})
+	return paginatedDocuments[*ProcessTemplate](ms.processTemplates, page, limit,
+		bson.M{"orgAddress": orgAddress}, findOptions)
+}
+
+// DeleteProcessTemplate deletes a template of an organization. The drafts created from it are
+// kept. Returns ErrNotFound when the organization has no such template.
+func (ms *MongoStorage) DeleteProcessTemplate(orgAddress common.Address, templateID string) error {
+	oid, err := primitive.ObjectIDFromHex(templateID)
+	if err != nil {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	result, err := ms.processTemplates.DeleteOne(ctx, bson.M{"_id": oid, "orgAddress": orgAddress})
+	if err != nil {
+		return fmt.Errorf("failed to delete process template: %w", err)
+	}
+	if result.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// DeleteProcessTemplatesByOrg removes every template of an organization, with the members their
+// census names. Best-effort cleanup used when tearing down an organization. Returns the number of
+// deleted templates.
+func (ms *MongoStorage) DeleteProcessTemplatesByOrg(orgAddress common.Address) (int64, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.processTemplates.DeleteMany(ctx, bson.M{"orgAddress": orgAddress})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete process templates by org: %w", err)
+	}
+	return res.DeletedCount, nil
+}
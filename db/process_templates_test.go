@@ -0,0 +1,67 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+)
+
+func TestProcessTemplates(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+
+	template := &ProcessTemplate{
+		OrgAddress: testOrgAddress,
+		Name:       "assembly",
+		Process: TemplateProcess{
+			Title:  MultiLangString{"default": "Annual assembly"},
+			Census: TemplateCensus{GroupID: "group", MaxProxies: 2},
+			Questions: []TemplateQuestion{{
+				Title: MultiLangString{"default": "Approve?"},
+				Type:  VotingTypeSingleChoice,
+			}},
+		},
+	}
+	id, err := testDB.CreateProcessTemplate(template)
+	c.Assert(err, qt.IsNil)
+	// names are unique within an organization only
+	_, err = testDB.CreateProcessTemplate(&ProcessTemplate{OrgAddress: testOrgAddress, Name: "assembly"})
+	c.Assert(err, qt.ErrorIs, ErrAlreadyExists)
+	otherOrg := common.HexToAddress("0x2")
+	_, err = testDB.CreateProcessTemplate(&ProcessTemplate{OrgAddress: otherOrg, Name: "assembly"})
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.CreateProcessTemplate(&ProcessTemplate{OrgAddress: testOrgAddress, Name: "board"})
+	c.Assert(err, qt.IsNil)
+
+	got, err := testDB.ProcessTemplate(testOrgAddress, id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got.Process.Census.MaxProxies, qt.Equals, 2)
+	c.Assert(got.Process.Questions, qt.HasLen, 1)
+	_, err = testDB.ProcessTemplate(otherOrg, id)
+	c.Assert(err, qt.ErrorIs, ErrNotFound)
+
+	total, templates, err := testDB.ProcessTemplates(testOrgAddress, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(2))
+	c.Assert(templates[0].Name, qt.Equals, "assembly")
+	c.Assert(templates[1].Name, qt.Equals, "board")
+
+	c.Assert(testDB.DeleteProcessTemplate(otherOrg, id), qt.ErrorIs, ErrNotFound)
+	c.Assert(testDB.DeleteProcessTemplate(testOrgAddress, id), qt.IsNil)
+	c.Assert(testDB.DeleteProcessTemplate(testOrgAddress, id), qt.ErrorIs, ErrNotFound)
+
+	// tearing down an organization removes its templates, and only its own
+	removed, err := testDB.DeleteProcessTemplatesByOrg(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(removed, qt.Equals, int64(1))
+	total, _, err = testDB.ProcessTemplates(testOrgAddress, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(0))
+	total, _, err = testDB.ProcessTemplates(otherOrg, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(1))
+	_, err = testDB.DeleteProcessTemplatesByOrg(common.Address{})
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+}
@@ -0,0 +1,340 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.vocdoni.io/dvote/log"
+)
+
+// TurnoutMinCellSize is the fewest eligible participants a turnout figure is reported for. Smaller
+// groups of participants are merged or left out, so no figure narrows down to a handful of members.
+const TurnoutMinCellSize = 5
+
+// TurnoutBreakdownGroup breaks turnout down by member group. Any other breakdown names a key of
+// the member's other data as "other.<key>".
+const TurnoutBreakdownGroup = "group"
+
+// TurnoutCount is the turnout of a set of eligible participants: how many the CSP signed for,
+// and their weight. A participant weighs 1 in a non-weighted census.
+type TurnoutCount struct {
+	Eligible        int64   `json:"eligible"`
+	Signed          int64   `json:"signed"`
+	EligibleWeight  uint64  `json:"eligibleWeight"`
+	SignedWeight    uint64  `json:"signedWeight"`
+	Turnout         float64 `json:"turnout"`
+	WeightedTurnout float64 `json:"weightedTurnout"`
+}
+
+func (tc *TurnoutCount) add(signed bool, weight uint64) {
+	tc.Eligible++
+	tc.EligibleWeight += weight
+	if signed {
+		tc.Signed++
+		tc.SignedWeight += weight
+	}
+}
+
+func (tc *TurnoutCount) merge(other *TurnoutCount) {
+	tc.Eligible += other.Eligible
+	tc.Signed += other.Signed
+	tc.EligibleWeight += other.EligibleWeight
+	tc.SignedWeight += other.SignedWeight
+}
+
+func (tc *TurnoutCount) rates() {
+	if tc.Eligible > 0 {
+		tc.Turnout = float64(tc.Signed) / float64(tc.Eligible)
+	}
+	if tc.EligibleWeight > 0 {
+		tc.WeightedTurnout = float64(tc.SignedWeight) / float64(tc.EligibleWeight)
+	}
+}
+
+// TurnoutCell is the turnout of the eligible participants sharing a value of a breakdown: the
+// group id, titled by Label, or the value of the member field, empty for members without one.
+type TurnoutCell struct {
+	Value string `json:"value"`
+	Label string `json:"label,omitempty"`
+	TurnoutCount
+}
+
+// TurnoutBreakdown is the turnout of a question broken down by group or by a member field. Cells
+// smaller than TurnoutMinCellSize are not listed and counted in SuppressedCells. A field splits
+// the participants in disjoint cells, so those are merged in Suppressed, with as many of the
+// smallest listed cells as it takes for Suppressed to reach the minimum size as well: otherwise
+// the total less the listed cells would give a small cell away. Groups overlap and have no such
+// complement, so a small group is only left out.
+type TurnoutBreakdown struct {
+	By              string        `json:"by"`
+	Cells           []TurnoutCell `json:"cells"`
+	SuppressedCells int           `json:"suppressedCells"`
+	Suppressed      *TurnoutCount `json:"suppressed,omitempty"`
+}
+
+// QuestionTurnout is the turnout of a question. A question with fewer eligible participants than
+// TurnoutMinCellSize reports no figures nor breakdowns, only Suppressed.
+type QuestionTurnout struct {
+	QuestionID primitive.ObjectID `json:"questionId"`
+	Status     string             `json:"status,omitempty"`
+	*TurnoutCount
+	Suppressed bool               `json:"suppressed,omitempty"`
+	Breakdowns []TurnoutBreakdown `json:"breakdowns,omitempty"`
+}
+
+// ProcessTurnout is the turnout of every question of a voting process.
+type ProcessTurnout struct {
+	ProcessID   primitive.ObjectID `json:"processId"`
+	Weighted    bool               `json:"weighted"`
+	MinCellSize int                `json:"minCellSize"`
+	Questions   []QuestionTurnout  `json:"questions"`
+}
+
+// ValidTurnoutBreakdown reports whether by names a breakdown ProcessTurnout understands: "group",
+// or a top-level key of the member's other data.
+func ValidTurnoutBreakdown(by string) bool {
+	key, isOther := strings.CutPrefix(by, memberOtherFieldPrefix)
+	return by == TurnoutBreakdownGroup || (isOther && key != "" && !strings.Contains(key, "."))
+}
+
+// turnoutParticipant is a census participant with the values it is broken down by.
+type turnoutParticipant struct {
+	weight uint64
+	values map[string]string
+}
+
+// ProcessTurnout counts, per question of a voting process, the eligible participants of census
+// and those the CSP signed for, and breaks them down by each of breakdowns (see
+// ValidTurnoutBreakdown). Signing is what the CSP records: it may precede, or never reach, the
+// ballot on chain. A question with an eligibility list counts its members only, and a question
+// not published yet has nobody signed for. Only aggregates of at least TurnoutMinCellSize
+// participants are returned.
+func (ms *MongoStorage) ProcessTurnout(
+	vp *VotingProcess, questions []VotingProcessQuestion, census *Census, breakdowns []string,
+) (*ProcessTurnout, error) {
+	if vp == nil || census == nil {
+		return nil, ErrInvalidData
+	}
+	for _, by := range breakdowns {
+		if !ValidTurnoutBreakdown(by) {
+			return nil, fmt.Errorf("%w: unknown breakdown %q", ErrInvalidData, by)
+		}
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	rows, err := ms.censusParticipantWeights(ctx, census)
+	if err != nil {
+		return nil, err
+	}
+	participants := make(map[string]*turnoutParticipant, len(rows))
+	for _, row := range rows {
+		participants[row.ParticipantID] = &turnoutParticipant{weight: row.Weight, values: map[string]string{}}
+	}
+	groups, err := ms.turnoutBreakdownValues(ctx, census, breakdowns, participants)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ProcessTurnout{
+		ProcessID:   vp.ID,
+		Weighted:    census.Weighted,
+		MinCellSize: TurnoutMinCellSize,
+		Questions:   make([]QuestionTurnout, 0, len(questions)),
+	}
+	for i := range questions {
+		q := &questions[i]
+		eligible := participants
+		if len(q.EligibleMemberIDs) > 0 {
+			eligible = make(map[string]*turnoutParticipant, len(q.EligibleMemberIDs))
+			for _, id := range q.EligibleMemberIDs {
+				if p, ok := participants[id]; ok {
+					eligible[id] = p
+				}
+			}
+		}
+		signed := map[string]bool{}
+		if len(q.UpstreamID) > 0 {
+			voters, err := ms.SignedVotersForElections([]internal.HexBytes{q.UpstreamID})
+			if err != nil {
+				return nil, err
+			}
+			for _, id := range voters {
+				signed[id] = true
+			}
+		}
+		result.Questions = append(result.Questions, questionTurnout(q, eligible, signed, breakdowns, groups))
+	}
+	return result, nil
+}
+
+// turnoutBreakdownValues fills the values of participants for the member field breakdowns, from
+// the organization members or the ephemeral members of census, and returns the groups of the
+// organization when broken down by group. Auto groups hold every member, so they are left out.
+func (ms *MongoStorage) turnoutBreakdownValues(
+	ctx context.Context, census *Census, breakdowns []string, participants map[string]*turnoutParticipant,
+) ([]*OrganizationMemberGroup, error) {
+	var groups []*OrganizationMemberGroup
+	projection := bson.M{"_id": 1}
+	for _, by := range breakdowns {
+		if by == TurnoutBreakdownGroup {
+			cursor, err := ms.orgMemberGroups.Find(ctx, bson.M{
+				"orgAddress":  census.OrgAddress,
+				"isAutoGroup": bson.M{"$ne": true},
+			}, options.Find().SetProjection(bson.M{"title": 1, "memberIds": 1}))
+			if err != nil {
+				return nil, fmt.Errorf("failed to find member groups: %w", err)
+			}
+			if err := cursor.All(ctx, &groups); err != nil {
+				return nil, fmt.Errorf("failed to decode member groups: %w", err)
+			}
+			continue
+		}
+		projection[by] = 1
+	}
+	if len(projection) == 1 {
+		return groups, nil
+	}
+
+	// the members of the organization are read whole rather than by id, as a census can hold most of them
+	filter := bson.M{"orgAddress": census.OrgAddress}
+	collection := ms.orgMembers
+	if census.Ephemeral {
+		filter = bson.M{"censusId": census.ID.Hex()}
+		collection = ms.ephemeralMembers
+	}
+	cursor, err := collection.Find(ctx, filter, options.Find().SetProjection(projection))
+	if err != nil {
+		return nil, fmt.Errorf("failed to find census members: %w", err)
+	}
+	defer func() {
+		if err := cursor.Close(ctx); err != nil {
+			log.Warnw("error closing cursor", "error", err)
+		}
+	}()
+	for cursor.Next(ctx) {
+		var member OrgMember
+		if err := cursor.Decode(&member); err != nil {
+			return nil, fmt.Errorf("failed to decode census member: %w", err)
+		}
+		p, ok := participants[member.ID.Hex()]
+		if !ok {
+			continue
+		}
+		for _, by := range breakdowns {
+			if value, ok := member.Other[strings.TrimPrefix(by, memberOtherFieldPrefix)]; ok && value != nil {
+				p.values[by] = fmt.Sprint(value)
+			}
+		}
+	}
+	if err := cursor.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read census members: %w", err)
+	}
+	return groups, nil
+}
+
+// questionTurnout aggregates the turnout of a question, suppressing what is smaller than
+// TurnoutMinCellSize.
+func questionTurnout(
+	q *VotingProcessQuestion, eligible map[string]*turnoutParticipant, signed map[string]bool,
+	breakdowns []string, groups []*OrganizationMemberGroup,
+) QuestionTurnout {
+	result := QuestionTurnout{QuestionID: q.ID, Status: q.Status}
+	total := &TurnoutCount{}
+	for id, p := range eligible {
+		total.add(signed[id], p.weight)
+	}
+	if total.Eligible < TurnoutMinCellSize {
+		result.Suppressed = true
+		return result
+	}
+	total.rates()
+	result.TurnoutCount = total
+
+	for _, by := range breakdowns {
+		if by == TurnoutBreakdownGroup {
+			result.Breakdowns = append(result.Breakdowns, groupTurnout(eligible, signed, groups))
+			continue
+		}
+		cells := map[string]*TurnoutCell{}
+		for id, p := range eligible {
+			value := p.values[by]
+			cell, ok := cells[value]
+			if !ok {
+				cell = &TurnoutCell{Value: value}
+				cells[value] = cell
+			}
+			cell.add(signed[id], p.weight)
+		}
+		result.Breakdowns = append(result.Breakdowns, fieldTurnout(by, cells))
+	}
+	return result
+}
+
+// groupTurnout breaks the turnout of the eligible participants down by group, leaving out the
+// groups smaller than TurnoutMinCellSize.
+func groupTurnout(
+	eligible map[string]*turnoutParticipant, signed map[string]bool, groups []*OrganizationMemberGroup,
+) TurnoutBreakdown {
+	breakdown := TurnoutBreakdown{By: TurnoutBreakdownGroup, Cells: []TurnoutCell{}}
+	for _, group := range groups {
+		cell := TurnoutCell{Value: group.ID.Hex(), Label: group.Title}
+		for _, id := range group.MemberIDs {
+			if p, ok := eligible[id]; ok {
+				cell.add(signed[id], p.weight)
+			}
+		}
+		if cell.Eligible == 0 {
+			continue
+		}
+		if cell.Eligible < TurnoutMinCellSize {
+			breakdown.SuppressedCells++
+			continue
+		}
+		cell.rates()
+		breakdown.Cells = append(breakdown.Cells, cell)
+	}
+	sort.Slice(breakdown.Cells, func(i, j int) bool { return breakdown.Cells[i].Value < breakdown.Cells[j].Value })
+	return breakdown
+}
+
+// fieldTurnout lists the cells of a member field breakdown, merging the ones smaller than
+// TurnoutMinCellSize, and then the smallest others until the merge reaches that size too.
+func fieldTurnout(by string, cells map[string]*TurnoutCell) TurnoutBreakdown {
+	sorted := make([]*TurnoutCell, 0, len(cells))
+	for _, cell := range cells {
+		sorted = append(sorted, cell)
+	}
+	// smallest first, by value on ties so the merge does not depend on map order
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].Eligible != sorted[j].Eligible {
+			return sorted[i].Eligible < sorted[j].Eligible
+		}
+		return sorted[i].Value < sorted[j].Value
+	})
+	breakdown := TurnoutBreakdown{By: by, Cells: []TurnoutCell{}}
+	suppressed := &TurnoutCount{}
+	merged := 0
+	for merged < len(sorted) && (sorted[merged].Eligible < TurnoutMinCellSize ||
+		(merged > 0 && suppressed.Eligible < TurnoutMinCellSize)) {
+		suppressed.merge(&sorted[merged].TurnoutCount)
+		merged++
+	}
+	for _, cell := range sorted[merged:] {
+		cell.rates()
+		breakdown.Cells = append(breakdown.Cells, *cell)
+	}
+	sort.Slice(breakdown.Cells, func(i, j int) bool { return breakdown.Cells[i].Value < breakdown.Cells[j].Value })
+	if merged > 0 {
+		suppressed.rates()
+		breakdown.SuppressedCells = merged
+		breakdown.Suppressed = suppressed
+	}
+	return breakdown
+}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestProcessTurnout(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	census := &Census{OrgAddress: testOrgAddress, TwoFaFields: OrgMemberTwoFaFields{OrgMemberTwoFaFieldEmail}}
+	censusID, err := testDB.SetCensus(census)
+	c.Assert(err, qt.IsNil)
+	upstream := internal.HexBytes{0xAB, 0xCD}
+	// 6 in the north, 2 in the south, 3 without a region; the first 4 are signed for
+	regions := []string{"north", "north", "north", "north", "north", "north", "south", "south", "", "", ""}
+	ids := make([]string, 0, len(regions))
+	for i, region := range regions {
+		id := primitive.NewObjectID()
+		member := &OrgMember{ID: id, OrgAddress: testOrgAddress}
+		if region != "" {
+			member.Other = map[string]any{"region": region}
+		}
+		_, err := testDB.orgMembers.InsertOne(ctx, member)
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{ParticipantID: id.Hex(), CensusID: censusID})
+		c.Assert(err, qt.IsNil)
+		if i < 4 {
+			_, err = testDB.cspTokensStatus.InsertOne(ctx, &CSPProcess{
+				ID: internal.RandomBytes(32), UserID: internal.HexBytesFromString(id.Hex()), ProcessID: upstream, Used: true,
+			})
+			c.Assert(err, qt.IsNil)
+		}
+		ids = append(ids, id.Hex())
+	}
+	large := &OrganizationMemberGroup{ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, Title: "large", MemberIDs: ids[:6]}
+	small := &OrganizationMemberGroup{ID: primitive.NewObjectID(), OrgAddress: testOrgAddress, Title: "small", MemberIDs: ids[6:8]}
+	for _, group := range []*OrganizationMemberGroup{large, small} {
+		_, err := testDB.orgMemberGroups.InsertOne(ctx, group)
+		c.Assert(err, qt.IsNil)
+	}
+
+	vp := &VotingProcess{ID: primitive.NewObjectID(), OrgAddress: testOrgAddress}
+	questions := []VotingProcessQuestion{
+		{ID: primitive.NewObjectID(), UpstreamID: upstream},
+		{ID: primitive.NewObjectID(), UpstreamID: upstream, EligibleMemberIDs: ids[:3]},
+		{ID: primitive.NewObjectID()},
+	}
+	turnout, err := testDB.ProcessTurnout(vp, questions, census, []string{TurnoutBreakdownGroup, "other.region"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(turnout.Questions, qt.HasLen, 3)
+
+	whole := turnout.Questions[0]
+	c.Assert(whole.Suppressed, qt.IsFalse)
+	c.Assert(whole.Eligible, qt.Equals, int64(11))
+	c.Assert(whole.Signed, qt.Equals, int64(4))
+	c.Assert(whole.Breakdowns, qt.HasLen, 2)
+	// the small group is left out
+	groups := whole.Breakdowns[0]
+	c.Assert(groups.Cells, qt.HasLen, 1)
+	c.Assert(groups.Cells[0].Value, qt.Equals, large.ID.Hex())
+	c.Assert(groups.Cells[0].Signed, qt.Equals, int64(4))
+	c.Assert(groups.SuppressedCells, qt.Equals, 1)
+	// south (2) and no region (3) are merged, and reach the minimum together
+	regionsBreakdown := whole.Breakdowns[1]
+	c.Assert(regionsBreakdown.Cells, qt.HasLen, 1)
+	c.Assert(regionsBreakdown.Cells[0].Value, qt.Equals, "north")
+	c.Assert(regionsBreakdown.Cells[0].Turnout, qt.Equals, 4.0/6)
+	c.Assert(regionsBreakdown.SuppressedCells, qt.Equals, 2)
+	c.Assert(regionsBreakdown.Suppressed.Eligible, qt.Equals, int64(5))
+
+	// too few eligible to report anything
+	c.Assert(turnout.Questions[1].Suppressed, qt.IsTrue)
+	c.Assert(turnout.Questions[1].TurnoutCount, qt.IsNil)
+	// not published: nobody signed for
+	c.Assert(turnout.Questions[2].Signed, qt.Equals, int64(0))
+
+	_, err = testDB.ProcessTurnout(vp, questions, census, []string{"name"})
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+}
+
+func TestFieldTurnoutMergesUntilLargeEnough(t *testing.T) {
+	c := qt.New(t)
+	cells := map[string]*TurnoutCell{}
+	for value, eligible := range map[string]int64{"a": 1, "b": 2, "c": 10, "d": 20} {
+		cells[value] = &TurnoutCell{Value: value, TurnoutCount: TurnoutCount{Eligible: eligible}}
+	}
+	// a and b only add up to 3, so c, the smallest listed value, is merged too
+	breakdown := fieldTurnout("other.x", cells)
+	c.Assert(breakdown.Cells, qt.HasLen, 1)
+	c.Assert(breakdown.Cells[0].Value, qt.Equals, "d")
+	c.Assert(breakdown.SuppressedCells, qt.Equals, 3)
+	c.Assert(breakdown.Suppressed.Eligible, qt.Equals, int64(13))
+}
@@ -251,6 +251,21 @@ func (ms *MongoStorage) SetQuestionEncryptionKeys(id primitive.ObjectID, keys []
 	return nil
 }
 
+// SetQuestionInstantRunoff stores the instant-runoff count of a question whose results are final
+// (targeted update). It is written once: a count already stored is kept.
+func (ms *MongoStorage) SetQuestionInstantRunoff(id primitive.ObjectID, runoff *InstantRunoff) error {
+	if id == primitive.NilObjectID || runoff == nil {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": id, "instantRunoff": bson.M{"$exists": false}}
+	if _, err := ms.processesQuestions.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"instantRunoff": runoff}}); err != nil {
+		return fmt.Errorf("failed to set question instant runoff: %w", err)
+	}
+	return nil
+}
+
 // DeleteQuestion removes a single question document (used when replacing a draft's
 // questions on update).
 func (ms *MongoStorage) DeleteQuestion(id primitive.ObjectID) error {
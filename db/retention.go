@@ -0,0 +1,380 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MemberChangeViaRetention marks the changes made by the retention sweeper.
+const MemberChangeViaRetention = "retention"
+
+// anonymizeBatchSize is how many members AnonymizeInactiveMembers checks for census activity at once.
+const anonymizeBatchSize = 100
+
+// anonymizedName replaces the name of an anonymized member, so lists still show a row for it.
+const anonymizedName = "Anonymized member"
+
+// Bounds of the retention rules, so a typo cannot wipe a memberbase (0 months) or keep it forever.
+const (
+	MaxRetentionMonths = 120
+	MaxRetentionDays   = 3650
+)
+
+// RetentionPolicy configures how long an organization keeps the personal data of its members. A
+// zero rule is disabled.
+type RetentionPolicy struct {
+	// AnonymizeInactiveMembersMonths anonymizes the members that were neither created, changed nor
+	// added to a census for that many months.
+	AnonymizeInactiveMembersMonths int `json:"anonymizeInactiveMembersMonths" bson:"anonymizeInactiveMembersMonths"`
+	// PurgeCSPTokensDays deletes the CSP auth tokens of a voting process that many days after it
+	// ended.
+	PurgeCSPTokensDays int `json:"purgeCspTokensDays" bson:"purgeCspTokensDays"`
+	// LastSweep is the outcome of the last sweep of the organization, set by the sweeper.
+	LastSweep *RetentionSweep `json:"lastSweep,omitempty" bson:"lastSweep,omitempty"`
+}
+
+// RetentionSweep is what one sweep of an organization's retention policy did.
+type RetentionSweep struct {
+	At                time.Time `json:"at" bson:"at"`
+	AnonymizedMembers int64     `json:"anonymizedMembers" bson:"anonymizedMembers"`
+	PurgedCSPTokens   int64     `json:"purgedCspTokens" bson:"purgedCspTokens"`
+	// Error is why the sweep stopped early, if it did. The next sweep retries.
+	Error string `json:"error,omitempty" bson:"error,omitempty"`
+}
+
+// Validate checks the rules are disabled or within bounds.
+func (p *RetentionPolicy) Validate() error {
+	if p.AnonymizeInactiveMembersMonths < 0 || p.AnonymizeInactiveMembersMonths > MaxRetentionMonths {
+		return fmt.Errorf("%w: anonymizeInactiveMembersMonths must be between 0 and %d",
+			ErrInvalidData, MaxRetentionMonths)
+	}
+	if p.PurgeCSPTokensDays < 0 || p.PurgeCSPTokensDays > MaxRetentionDays {
+		return fmt.Errorf("%w: purgeCspTokensDays must be between 0 and %d", ErrInvalidData, MaxRetentionDays)
+	}
+	return nil
+}
+
+// IsEmpty reports whether every rule of the policy is disabled.
+func (p *RetentionPolicy) IsEmpty() bool {
+	return p.AnonymizeInactiveMembersMonths == 0 && p.PurgeCSPTokensDays == 0
+}
+
+// SetRetentionPolicy stores the rules of the retention policy of the organization, keeping the
+// outcome of its last sweep. A nil or empty policy disables retention.
+func (ms *MongoStorage) SetRetentionPolicy(orgAddress common.Address, policy *RetentionPolicy) error {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	updateDoc := bson.M{"$unset": bson.M{"retentionPolicy": ""}}
+	if policy != nil && !policy.IsEmpty() {
+		updateDoc = bson.M{"$set": bson.M{
+			"retentionPolicy.anonymizeInactiveMembersMonths": policy.AnonymizeInactiveMembersMonths,
+			"retentionPolicy.purgeCspTokensDays":             policy.PurgeCSPTokensDays,
+		}}
+	}
+	res, err := ms.organizations.UpdateOne(ctx, bson.M{"_id": orgAddress}, updateDoc)
+	if err != nil {
+		return fmt.Errorf("failed to set retention policy: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SetRetentionSweep records the outcome of the last sweep of the organization's retention policy.
+// It does nothing if the policy was disabled meanwhile.
+func (ms *MongoStorage) SetRetentionSweep(orgAddress common.Address, sweep *RetentionSweep) error {
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": orgAddress, "retentionPolicy": bson.M{"$exists": true}}
+	if _, err := ms.organizations.UpdateOne(ctx, filter,
+		bson.M{"$set": bson.M{"retentionPolicy.lastSweep": sweep}}); err != nil {
+		return fmt.Errorf("failed to record retention sweep: %w", err)
+	}
+	return nil
+}
+
+// OrganizationsWithRetentionPolicy returns the organizations that have a retention policy.
+func (ms *MongoStorage) OrganizationsWithRetentionPolicy() ([]*Organization, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	cursor, err := ms.organizations.Find(ctx, bson.M{"retentionPolicy": bson.M{"$exists": true}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find organizations with a retention policy: %w", err)
+	}
+	var orgs []*Organization
+	if err := cursor.All(ctx, &orgs); err != nil {
+		return nil, fmt.Errorf("failed to decode organizations with a retention policy: %w", err)
+	}
+	return orgs, nil
+}
+
+// unfinishedCensusIDs returns the ids of the censuses of the organization's voting processes that
+// are not finished: drafts, scheduled ones included, and published processes with a question that
+// was not ended, canceled or counted yet.
+func (ms *MongoStorage) unfinishedCensusIDs(ctx context.Context, orgAddress common.Address) ([]string, error) {
+	open, err := ms.processesQuestions.Distinct(ctx, "processId", bson.M{
+		"orgAddress": orgAddress,
+		"status":     bson.M{"$nin": []string{QuestionStatusEnded, QuestionStatusCanceled, QuestionStatusResults}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unfinished processes: %w", err)
+	}
+	if open == nil {
+		open = bson.A{}
+	}
+	censusIDs, err := ms.votingProcesses.Distinct(ctx, "censusId", bson.M{
+		"orgAddress": orgAddress,
+		"$or":        bson.A{bson.M{"published": bson.M{"$ne": true}}, bson.M{"_id": bson.M{"$in": open}}},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to find unfinished censuses: %w", err)
+	}
+	ids := make([]string, 0, len(censusIDs))
+	for _, id := range censusIDs {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			ids = append(ids, oid.Hex())
+		}
+	}
+	return ids, nil
+}
+
+// memberLastChange is the aggregation expression of when a member was last created or changed. A
+// member stored without updatedAt counts from its createdAt, and one without createdAt from the
+// creation time of its id, so neither is taken for one that never changed.
+var memberLastChange = bson.M{"$max": bson.A{
+	bson.M{"$ifNull": bson.A{"$createdAt", bson.M{"$toDate": "$_id"}}},
+	"$updatedAt",
+}}
+
+// AnonymizeInactiveMembers anonymizes at most limit members of the organization that were neither
+// created, changed nor added to a census since cutoff, and returns how many it anonymized. Members
+// of the census of a voting process not finished yet, even a draft, are left alone, so they can
+// still log in to it.
+//
+// Anonymizing replaces every personal field with a placeholder: the name reads "Anonymized member"
+// and the email, phone, member number, national id, birth date, password, external id, extra data
+// and consents are cleared. The login hashes of the member's census participations are cleared
+// too, its CSP tokens and self-service update requests deleted, and the values of its history
+// erased. The member keeps its id, weight, groups and participations, so the member and census
+// counts, and any result, stay as they were. Nothing can restore the data.
+func (ms *MongoStorage) AnonymizeInactiveMembers(orgAddress common.Address, cutoff time.Time, limit int64,
+) (int64, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || limit <= 0 {
+		return 0, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	unfinished, err := ms.unfinishedCensusIDs(ctx, orgAddress)
+	if err != nil {
+		return 0, err
+	}
+	cursor, err := ms.orgMembers.Find(ctx, bson.M{
+		"orgAddress":   orgAddress,
+		"anonymizedAt": bson.M{"$exists": false},
+		"$expr":        bson.M{"$lt": bson.A{memberLastChange, cutoff}},
+	}, options.Find().SetSort(bson.D{{Key: "_id", Value: 1}}))
+	if err != nil {
+		return 0, fmt.Errorf("failed to find inactive members: %w", err)
+	}
+	defer func() { _ = cursor.Close(ctx) }()
+
+	// the members are read in batches, and skipped ones do not count towards limit, so members
+	// kept by an unfinished process cannot starve the ones behind them
+	var anonymized int64
+	batch := make([]*OrgMember, 0, anonymizeBatchSize)
+	for anonymized < limit && cursor.Next(ctx) {
+		member := &OrgMember{}
+		if err := cursor.Decode(member); err != nil {
+			return anonymized, fmt.Errorf("failed to decode inactive member: %w", err)
+		}
+		batch = append(batch, member)
+		if int64(len(batch)) < min(limit-anonymized, anonymizeBatchSize) {
+			continue
+		}
+		n, err := ms.anonymizeIdleMembers(ctx, orgAddress, batch, cutoff, unfinished)
+		anonymized += n
+		if err != nil {
+			return anonymized, err
+		}
+		batch = batch[:0]
+	}
+	if err := cursor.Err(); err != nil {
+		return anonymized, fmt.Errorf("failed to iterate inactive members: %w", err)
+	}
+	n, err := ms.anonymizeIdleMembers(ctx, orgAddress, batch, cutoff, unfinished)
+	return anonymized + n, err
+}
+
+// anonymizeIdleMembers anonymizes the members of the batch that were not added to a census since
+// cutoff, nor are in one of the unfinished censuses.
+func (ms *MongoStorage) anonymizeIdleMembers(ctx context.Context, orgAddress common.Address, batch []*OrgMember,
+	cutoff time.Time, unfinished []string,
+) (int64, error) {
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	ids := make([]string, 0, len(batch))
+	for _, m := range batch {
+		ids = append(ids, m.ID.Hex())
+	}
+	active, err := ms.censusParticipants.Distinct(ctx, "participantID", bson.M{
+		"participantID": bson.M{"$in": ids},
+		"$or": bson.A{
+			bson.M{"createdAt": bson.M{"$gte": cutoff}},
+			bson.M{"updatedAt": bson.M{"$gte": cutoff}},
+			bson.M{"censusId": bson.M{"$in": unfinished}},
+		},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find active census participants: %w", err)
+	}
+	skip := make(map[string]bool, len(active))
+	for _, id := range active {
+		if s, ok := id.(string); ok {
+			skip[s] = true
+		}
+	}
+
+	now := time.Now()
+	source := MemberChangeSource{Via: MemberChangeViaRetention}
+	var anonymized int64
+	for _, before := range batch {
+		if skip[before.ID.Hex()] {
+			continue
+		}
+		if err := ms.anonymizeMember(ctx, before, now); err != nil {
+			return anonymized, err
+		}
+		after := anonymizedMember(before, now)
+		changes := diffOrgMembers(before, after)
+		for i := range changes {
+			// the history says which fields were erased, not what they held
+			changes[i].Old, changes[i].New = "", ""
+		}
+		ms.recordMemberVersions([]*MemberVersion{
+			newMemberVersion(orgAddress, before.ID.Hex(), MemberAnonymized, source, changes, now),
+		})
+		anonymized++
+	}
+	return anonymized, nil
+}
+
+// anonymizedMember returns member with its personal fields replaced by placeholders.
+func anonymizedMember(member *OrgMember, now time.Time) *OrgMember {
+	return &OrgMember{
+		ID:           member.ID,
+		OrgAddress:   member.OrgAddress,
+		Name:         anonymizedName,
+		Weight:       member.Weight,
+		CreatedAt:    member.CreatedAt,
+		UpdatedAt:    now,
+		AnonymizedAt: now,
+	}
+}
+
+// anonymizeMember replaces the stored member with its anonymized form, and erases what other
+// collections hold about it.
+func (ms *MongoStorage) anonymizeMember(ctx context.Context, member *OrgMember, now time.Time) error {
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	id := member.ID.Hex()
+	// the updatedAt condition keeps a member changed since it was read from being overwritten; a
+	// member read without one is matched as still without it
+	var updatedAt any = member.UpdatedAt
+	if member.UpdatedAt.IsZero() {
+		updatedAt = bson.M{"$in": bson.A{nil, member.UpdatedAt}}
+	}
+	res, err := ms.orgMembers.ReplaceOne(ctx,
+		bson.M{"_id": member.ID, "updatedAt": updatedAt, "anonymizedAt": bson.M{"$exists": false}},
+		anonymizedMember(member, now))
+	if err != nil {
+		return fmt.Errorf("failed to anonymize member: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return nil
+	}
+	if _, err := ms.censusParticipants.UpdateMany(ctx, bson.M{"participantID": id}, bson.M{"$set": bson.M{
+		"loginHash": nil, "loginHashPhone": nil, "loginHashEmail": nil,
+	}}); err != nil {
+		return fmt.Errorf("failed to clear the login hashes of an anonymized member: %w", err)
+	}
+	if _, err := ms.cspTokens.DeleteMany(ctx, bson.M{"userid": internal.HexBytes(member.ID[:])}); err != nil {
+		return fmt.Errorf("failed to delete the CSP tokens of an anonymized member: %w", err)
+	}
+	if _, err := ms.memberUpdates.DeleteMany(ctx, bson.M{"orgAddress": member.OrgAddress, "memberId": id}); err != nil {
+		return fmt.Errorf("failed to delete the update requests of an anonymized member: %w", err)
+	}
+	if _, err := ms.memberHistory.UpdateMany(ctx, bson.M{"orgAddress": member.OrgAddress, "memberId": id},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"changes": bson.M{"$map": bson.M{
+			"input": "$changes",
+			"in":    bson.M{"field": "$$this.field"},
+		}}}}}}); err != nil {
+		return fmt.Errorf("failed to erase the history of an anonymized member: %w", err)
+	}
+	return nil
+}
+
+// PurgeEndedProcessCSPTokens deletes the CSP auth tokens of the organization's voting processes
+// that ended before cutoff, and returns how many it deleted. A process has ended once its end date
+// has passed and none of its questions accepts votes any more. The records of which members voted
+// are kept, so turnout counts stay as they were.
+func (ms *MongoStorage) PurgeEndedProcessCSPTokens(orgAddress common.Address, cutoff time.Time) (int64, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	ongoing, err := ms.processesQuestions.Distinct(ctx, "processId", bson.M{
+		"orgAddress": orgAddress,
+		"status":     bson.M{"$in": []string{QuestionStatusReady, QuestionStatusPaused}},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find ongoing processes: %w", err)
+	}
+	if ongoing == nil {
+		ongoing = bson.A{}
+	}
+	ended, err := ms.votingProcesses.Distinct(ctx, "_id", bson.M{
+		"orgAddress": orgAddress,
+		"published":  true,
+		"endDate":    bson.M{"$gt": time.Time{}, "$lt": cutoff},
+		"_id":        bson.M{"$nin": ongoing},
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to find ended processes: %w", err)
+	}
+	anchors := make([]internal.HexBytes, 0, len(ended))
+	for _, id := range ended {
+		if oid, ok := id.(primitive.ObjectID); ok {
+			anchors = append(anchors, internal.HexBytes(oid[:]))
+		}
+	}
+	if len(anchors) == 0 {
+		return 0, nil
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	res, err := ms.cspTokens.DeleteMany(ctx, bson.M{"bundleid": bson.M{"$in": anchors}})
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge CSP tokens: %w", err)
+	}
+	return res.DeletedCount, nil
+}
@@ -0,0 +1,196 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestRetentionPolicy(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	c.Assert(testDB.SetOrganization(&Organization{Address: testOrgAddress, CreatedAt: time.Now()}), qt.IsNil)
+
+	c.Assert((&RetentionPolicy{AnonymizeInactiveMembersMonths: -1}).Validate(), qt.ErrorIs, ErrInvalidData)
+	c.Assert((&RetentionPolicy{PurgeCSPTokensDays: MaxRetentionDays + 1}).Validate(), qt.ErrorIs, ErrInvalidData)
+
+	policy := &RetentionPolicy{AnonymizeInactiveMembersMonths: 12, PurgeCSPTokensDays: 90}
+	c.Assert(testDB.SetRetentionPolicy(testOrgAddress, policy), qt.IsNil)
+	sweep := &RetentionSweep{At: time.Now().Truncate(time.Millisecond).UTC(), AnonymizedMembers: 3}
+	c.Assert(testDB.SetRetentionSweep(testOrgAddress, sweep), qt.IsNil)
+
+	// changing the rules keeps the outcome of the last sweep
+	c.Assert(testDB.SetRetentionPolicy(testOrgAddress, &RetentionPolicy{AnonymizeInactiveMembersMonths: 6}), qt.IsNil)
+	orgs, err := testDB.OrganizationsWithRetentionPolicy()
+	c.Assert(err, qt.IsNil)
+	c.Assert(orgs, qt.HasLen, 1)
+	c.Assert(orgs[0].RetentionPolicy, qt.DeepEquals, &RetentionPolicy{AnonymizeInactiveMembersMonths: 6, LastSweep: sweep})
+
+	// an empty policy disables retention, and a sweep finishing afterwards does not bring it back
+	c.Assert(testDB.SetRetentionPolicy(testOrgAddress, &RetentionPolicy{}), qt.IsNil)
+	c.Assert(testDB.SetRetentionSweep(testOrgAddress, sweep), qt.IsNil)
+	orgs, err = testDB.OrganizationsWithRetentionPolicy()
+	c.Assert(err, qt.IsNil)
+	c.Assert(orgs, qt.HasLen, 0)
+}
+
+func TestAnonymizeInactiveMembers(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+
+	longAgo := time.Now().AddDate(-2, 0, 0)
+	cutoff := time.Now().AddDate(-1, 0, 0)
+	// members stored before their dates were recorded: an id created longAgo, and only the dates
+	// in dates
+	storedMember := func(number string, dates bson.M) string {
+		id := primitive.NewObjectIDFromTimestamp(longAgo)
+		doc := bson.M{
+			"_id": id, "orgAddress": testOrgAddress, "memberNumber": number, "name": "Ana", "surname": "Gil",
+			"email": number + "@example.com", "nationalId": "12345678Z", "birthDate": "1980-01-01",
+			"weight": 3, "other": bson.M{"club": "chess"},
+		}
+		for k, v := range dates {
+			doc[k] = v
+		}
+		_, err := testDB.orgMembers.InsertOne(ctx, doc)
+		c.Assert(err, qt.IsNil)
+		return id.Hex()
+	}
+	participate := func(memberID, censusID string, at time.Time) {
+		_, err := testDB.censusParticipants.InsertOne(ctx, &CensusParticipant{
+			ParticipantID: memberID, CensusID: censusID, LoginHash: []byte("hash"), CreatedAt: at, UpdatedAt: at,
+		})
+		c.Assert(err, qt.IsNil)
+	}
+	process := func(censusID primitive.ObjectID, published bool, status string) {
+		processID := primitive.NewObjectID()
+		_, err := testDB.votingProcesses.InsertOne(ctx, &VotingProcess{
+			ID: processID, OrgAddress: testOrgAddress, Published: published, CensusID: censusID,
+		})
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.processesQuestions.InsertOne(ctx, &VotingProcessQuestion{
+			ProcessID: processID, OrgAddress: testOrgAddress, UpstreamID: internal.HexBytes{0x01}, Status: status,
+		})
+		c.Assert(err, qt.IsNil)
+	}
+
+	idle := storedMember("1", nil)
+	recentCensus := storedMember("2", nil)
+	recentlyCreated := storedMember("3", bson.M{"createdAt": time.Now()})
+	recentID, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+		MemberNumber: "4", Name: "Ana", Email: "4@example.com", Weight: 3,
+	}, testSalt, MemberChangeSource{Via: MemberChangeViaAPI})
+	c.Assert(err, qt.IsNil)
+	recent := recentID.Hex()
+	testDB.recordMemberVersions([]*MemberVersion{newMemberVersion(testOrgAddress, idle, MemberCreated,
+		MemberChangeSource{Via: MemberChangeViaAPI}, []MemberFieldChange{{Field: "email", New: "1@example.com"}}, longAgo)})
+	participate(idle, primitive.NewObjectID().Hex(), longAgo)
+	participate(recentCensus, primitive.NewObjectID().Hex(), time.Now())
+
+	// the census of a process not finished yet, whether voting or still a draft, keeps its members,
+	// however idle; the census of an ended one does not
+	voting, drafted, ended := storedMember("5", nil), storedMember("6", nil), storedMember("7", nil)
+	votingCensus, draftCensus, endedCensus := primitive.NewObjectID(), primitive.NewObjectID(), primitive.NewObjectID()
+	participate(voting, votingCensus.Hex(), longAgo)
+	participate(drafted, draftCensus.Hex(), longAgo)
+	participate(ended, endedCensus.Hex(), longAgo)
+	process(votingCensus, true, QuestionStatusReady)
+	process(draftCensus, false, "")
+	process(endedCensus, true, QuestionStatusEnded)
+
+	oid, err := primitive.ObjectIDFromHex(idle)
+	c.Assert(err, qt.IsNil)
+	c.Assert(testDB.SetCSPAuth(internal.HexBytes{0xAA}, internal.HexBytes(oid[:]), internal.HexBytes{0xBB}, "s"), qt.IsNil)
+
+	n, err := testDB.AnonymizeInactiveMembers(testOrgAddress, cutoff, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, int64(2))
+	// a second sweep has nothing left to do
+	n, err = testDB.AnonymizeInactiveMembers(testOrgAddress, cutoff, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(n, qt.Equals, int64(0))
+
+	for _, id := range []string{recentCensus, recentlyCreated, recent, voting, drafted} {
+		member, err := testDB.OrgMember(testOrgAddress, id)
+		c.Assert(err, qt.IsNil)
+		c.Assert(member.Email, qt.Not(qt.Equals), "", qt.Commentf("member %s", id))
+		c.Assert(member.AnonymizedAt.IsZero(), qt.IsTrue)
+	}
+
+	member, err := testDB.OrgMember(testOrgAddress, idle)
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.AnonymizedAt.IsZero(), qt.IsFalse)
+	c.Assert(member, qt.DeepEquals, &OrgMember{
+		ID: member.ID, OrgAddress: testOrgAddress, Name: anonymizedName, Weight: 3,
+		CreatedAt: member.CreatedAt, UpdatedAt: member.UpdatedAt, AnonymizedAt: member.AnonymizedAt,
+	})
+	member, err = testDB.OrgMember(testOrgAddress, ended)
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.AnonymizedAt.IsZero(), qt.IsFalse)
+	participant := &CensusParticipant{}
+	c.Assert(testDB.censusParticipants.FindOne(ctx, bson.M{"participantID": idle}).Decode(participant), qt.IsNil)
+	c.Assert(participant.LoginHash, qt.IsNil)
+	_, err = testDB.CSPAuth(internal.HexBytes{0xAA})
+	c.Assert(err, qt.Not(qt.IsNil))
+
+	// the history says which fields were erased, and no longer holds any value
+	_, versions, err := testDB.MemberHistory(testOrgAddress, idle, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(versions, qt.HasLen, 2)
+	c.Assert(versions[0].Action, qt.Equals, MemberAnonymized)
+	c.Assert(versions[0].Source.Via, qt.Equals, MemberChangeViaRetention)
+	for _, v := range versions {
+		c.Assert(v.Changes, qt.Not(qt.HasLen), 0)
+		for _, change := range v.Changes {
+			c.Assert(change, qt.DeepEquals, MemberFieldChange{Field: change.Field})
+		}
+	}
+}
+
+func TestPurgeEndedProcessCSPTokens(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	ctx := context.Background()
+
+	newProcess := func(endDate time.Time, status string) internal.HexBytes {
+		id := primitive.NewObjectID()
+		_, err := testDB.votingProcesses.InsertOne(ctx, &VotingProcess{
+			ID: id, OrgAddress: testOrgAddress, Published: true, EndDate: endDate,
+		})
+		c.Assert(err, qt.IsNil)
+		_, err = testDB.processesQuestions.InsertOne(ctx, &VotingProcessQuestion{
+			ProcessID: id, OrgAddress: testOrgAddress, UpstreamID: internal.HexBytes(id[:]), Status: status,
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(testDB.SetCSPAuth(internal.HexBytes(id[8:]), internal.HexBytes{0x01}, internal.HexBytes(id[:]), "s"),
+			qt.IsNil)
+		return internal.HexBytes(id[8:])
+	}
+	longAgo := time.Now().AddDate(0, -6, 0)
+	ended := newProcess(longAgo, QuestionStatusEnded)
+	// still voting past its end date, ended too recently, and open-ended
+	paused := newProcess(longAgo, QuestionStatusPaused)
+	recent := newProcess(time.Now().AddDate(0, 0, -1), QuestionStatusEnded)
+	openEnded := newProcess(time.Time{}, QuestionStatusEnded)
+
+	purged, err := testDB.PurgeEndedProcessCSPTokens(testOrgAddress, time.Now().AddDate(0, 0, -90))
+	c.Assert(err, qt.IsNil)
+	c.Assert(purged, qt.Equals, int64(1))
+	_, err = testDB.CSPAuth(ended)
+	c.Assert(err, qt.Not(qt.IsNil))
+	for _, token := range []internal.HexBytes{paused, recent, openEnded} {
+		_, err := testDB.CSPAuth(token)
+		c.Assert(err, qt.IsNil)
+	}
+}
@@ -0,0 +1,261 @@
+package db
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"github.com/vocdoni/saas-backend/internal"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// MemberChangeViaUnsubscribe marks the consent a member withdrew through an unsubscribe link.
+const MemberChangeViaUnsubscribe = "unsubscribe"
+
+// Suppression is an entry of an organization's suppression list: no campaign-style message is
+// sent to the address on its channel, whatever the consent of the members holding it. One-time
+// codes are transactional and ignore the list.
+type Suppression struct {
+	ID         primitive.ObjectID `json:"id" bson:"_id"`
+	OrgAddress common.Address     `json:"orgAddress" bson:"orgAddress"`
+	Channel    MessageChannel     `json:"channel" bson:"channel"`
+	// Email is the address of an email suppression.
+	Email string `json:"email,omitempty" bson:"email,omitempty"`
+	// Phone is the number of an SMS suppression, hashed as member phones are.
+	Phone HashedPhone `json:"phone,omitempty" bson:"phone,omitempty"`
+	// Reason is free text, e.g. "unsubscribed" or "bounced".
+	Reason string `json:"reason,omitempty" bson:"reason,omitempty"`
+	// Source is how the entry was added: through the API or an unsubscribe link.
+	Source    MemberChangeSource `json:"source" bson:"source"`
+	CreatedAt time.Time          `json:"createdAt" bson:"createdAt"`
+}
+
+// SuppressionTarget is an address on a channel, in the form it is stored and matched in.
+type SuppressionTarget struct {
+	Channel MessageChannel
+	Email   string
+	Phone   HashedPhone
+}
+
+// NewSuppressionTarget normalizes an email address, or hashes a phone number for org, into a
+// target. Returns ErrInvalidData for an unknown channel or an invalid address.
+func NewSuppressionTarget(org *Organization, channel MessageChannel, address string) (*SuppressionTarget, error) {
+	switch channel {
+	case MessageChannelEmail:
+		email := internal.NormalizeEmail(address)
+		if !internal.ValidEmail(email) {
+			return nil, fmt.Errorf("%w: invalid email", ErrInvalidData)
+		}
+		return &SuppressionTarget{Channel: channel, Email: email}, nil
+	case MessageChannelSMS:
+		phone, err := NewHashedPhone(address, org)
+		if err != nil || phone.IsEmpty() {
+			return nil, fmt.Errorf("%w: invalid phone", ErrInvalidData)
+		}
+		return &SuppressionTarget{Channel: channel, Phone: phone}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidData, channel)
+	}
+}
+
+// Key is the stored form of the address: the email, or the hex of the phone hash. Unlike the
+// masked display form of a phone, it identifies the target again through ParseSuppressionTarget.
+func (t *SuppressionTarget) Key() string {
+	if t.Channel == MessageChannelSMS {
+		return hex.EncodeToString(t.Phone)
+	}
+	return t.Email
+}
+
+// ParseSuppressionTarget is the inverse of SuppressionTarget.Key.
+func ParseSuppressionTarget(channel MessageChannel, key string) (*SuppressionTarget, error) {
+	switch channel {
+	case MessageChannelEmail:
+		if !internal.ValidEmail(key) {
+			return nil, fmt.Errorf("%w: invalid email", ErrInvalidData)
+		}
+		return &SuppressionTarget{Channel: channel, Email: key}, nil
+	case MessageChannelSMS:
+		phone, err := hex.DecodeString(key)
+		if err != nil || len(phone) == 0 {
+			return nil, fmt.Errorf("%w: invalid phone hash", ErrInvalidData)
+		}
+		return &SuppressionTarget{Channel: channel, Phone: phone}, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown channel %q", ErrInvalidData, channel)
+	}
+}
+
+// filter matches the suppression of the target in an organization.
+func (t *SuppressionTarget) filter(orgAddress common.Address) bson.M {
+	if t.Channel == MessageChannelSMS {
+		return bson.M{"orgAddress": orgAddress, "channel": t.Channel, "phone": t.Phone}
+	}
+	return bson.M{"orgAddress": orgAddress, "channel": t.Channel, "email": t.Email}
+}
+
+// memberFilter matches the members of an organization holding the target's address, and
+// consentField is the member field recording their consent on its channel.
+func (t *SuppressionTarget) memberFilter(orgAddress common.Address) (filter bson.M, consentField string) {
+	if t.Channel == MessageChannelSMS {
+		return bson.M{"orgAddress": orgAddress, "phone": t.Phone}, "smsConsent"
+	}
+	return bson.M{"orgAddress": orgAddress, "email": t.Email}, "emailConsent"
+}
+
+// SuppressAddress adds the target to the suppression list of the organization, and records that
+// every member holding the address refused messages on the channel. Adding an address already on
+// the list keeps its entry and returns false.
+func (ms *MongoStorage) SuppressAddress(orgAddress common.Address, target *SuppressionTarget, reason string,
+	source MemberChangeSource,
+) (bool, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || target == nil {
+		return false, ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+
+	now := time.Now()
+	res, err := ms.suppressions.UpdateOne(ctx, target.filter(orgAddress), bson.M{"$setOnInsert": bson.M{
+		"_id":       primitive.NewObjectID(),
+		"reason":    reason,
+		"source":    source,
+		"createdAt": now,
+	}}, options.Update().SetUpsert(true))
+	if err != nil {
+		return false, fmt.Errorf("failed to add suppression: %w", err)
+	}
+
+	// withdraw the consent of the members holding the address, one by one so each change lands
+	// in their history
+	memberFilter, consentField := target.memberFilter(orgAddress)
+	memberFilter[consentField+".granted"] = bson.M{"$ne": false}
+	cursor, err := ms.orgMembers.Find(ctx, memberFilter)
+	if err != nil {
+		return false, fmt.Errorf("failed to find suppressed members: %w", err)
+	}
+	var members []*OrgMember
+	if err := cursor.All(ctx, &members); err != nil {
+		return false, fmt.Errorf("failed to decode suppressed members: %w", err)
+	}
+	consent := &MemberConsent{Granted: false, At: now, Source: source.Via}
+	versions := make([]*MemberVersion, 0, len(members))
+	for _, before := range members {
+		if _, err := ms.orgMembers.UpdateOne(ctx, bson.M{"_id": before.ID},
+			bson.M{"$set": bson.M{consentField: consent, "updatedAt": now}}); err != nil {
+			return false, fmt.Errorf("failed to withdraw member consent: %w", err)
+		}
+		after := *before
+		if target.Channel == MessageChannelSMS {
+			after.SMSConsent = consent
+		} else {
+			after.EmailConsent = consent
+		}
+		versions = append(versions, newMemberVersion(orgAddress, before.ID.Hex(), MemberUpdated, source,
+			diffOrgMembers(before, &after), now))
+	}
+	ms.recordMemberVersions(versions)
+	return res.UpsertedCount > 0, nil
+}
+
+// UnsuppressAddress removes the target from the suppression list of the organization. The consent
+// of the members holding the address is left as it is: only they can grant it again. Returns
+// ErrNotFound if the address is not on the list.
+func (ms *MongoStorage) UnsuppressAddress(orgAddress common.Address, target *SuppressionTarget) error {
+	if orgAddress.Cmp(common.Address{}) == 0 || target == nil {
+		return ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.suppressions.DeleteOne(ctx, target.filter(orgAddress))
+	if err != nil {
+		return fmt.Errorf("failed to remove suppression: %w", err)
+	}
+	if res.DeletedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// IsSuppressed reports whether the target is on the suppression list of the organization.
+func (ms *MongoStorage) IsSuppressed(orgAddress common.Address, target *SuppressionTarget) (bool, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 || target == nil {
+		return false, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	count, err := ms.suppressions.CountDocuments(ctx, target.filter(orgAddress), options.Count().SetLimit(1))
+	if err != nil {
+		return false, fmt.Errorf("failed to check suppression: %w", err)
+	}
+	return count > 0, nil
+}
+
+// Suppressions returns a page of the suppression list of the organization, newest first.
+func (ms *MongoStorage) Suppressions(orgAddress common.Address, page, limit int64) (int64, []*Suppression, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, nil, ErrInvalidData
+	}
+	findOptions := options.Find().SetSort(bson.D{{Key: "createdAt", Value: -1}, {Key: "_id", Value: -1}})
+	return paginatedDocuments[*Suppression](ms.suppressions, page, limit, bson.M{"orgAddress": orgAddress}, findOptions)
+}
+
+// CampaignRecipientAllowed reports whether a campaign-style message may be sent to the member on
+// the channel: the member has not refused it, and the member's address on the channel is not on
+// the suppression list. A member never asked for consent is allowed. Every campaign-style send
+// must go through it; one-time codes and other transactional messages must not, so that a member
+// who unsubscribed can still log in to vote.
+func (ms *MongoStorage) CampaignRecipientAllowed(member *OrgMember, channel MessageChannel) (bool, error) {
+	if member == nil {
+		return false, ErrInvalidData
+	}
+	target := &SuppressionTarget{Channel: channel}
+	switch channel {
+	case MessageChannelEmail:
+		if member.EmailConsent != nil && !member.EmailConsent.Granted {
+			return false, nil
+		}
+		target.Email = member.Email
+	case MessageChannelSMS:
+		if member.SMSConsent != nil && !member.SMSConsent.Granted {
+			return false, nil
+		}
+		target.Phone = member.Phone
+	default:
+		return false, fmt.Errorf("%w: unknown channel %q", ErrInvalidData, channel)
+	}
+	if target.Key() == "" {
+		// no address to reach the member through
+		return false, nil
+	}
+	suppressed, err := ms.IsSuppressed(member.OrgAddress, target)
+	if err != nil {
+		return false, err
+	}
+	return !suppressed, nil
+}
+
+// DeleteSuppressionsByOrg removes the suppression list of an organization. Best-effort cleanup
+// used when tearing down an organization. Returns the number of deleted entries.
+func (ms *MongoStorage) DeleteSuppressionsByOrg(orgAddress common.Address) (int64, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	res, err := ms.suppressions.DeleteMany(ctx, bson.M{"orgAddress": orgAddress})
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete suppressions by org: %w", err)
+	}
+	return res.DeletedCount, nil
+}
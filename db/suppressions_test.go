@@ -0,0 +1,131 @@
+package db
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewSuppressionTarget(t *testing.T) {
+	c := qt.New(t)
+	org := &Organization{Address: testOrgAddress, Country: "ES"}
+
+	target, err := NewSuppressionTarget(org, MessageChannelEmail, " Ana@Example.com ")
+	c.Assert(err, qt.IsNil)
+	c.Assert(target.Email, qt.Equals, "ana@example.com")
+	c.Assert(target.Key(), qt.Equals, "ana@example.com")
+
+	target, err = NewSuppressionTarget(org, MessageChannelSMS, "600000001")
+	c.Assert(err, qt.IsNil)
+	phone, err := NewHashedPhone("+34600000001", org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(target.Phone, qt.DeepEquals, phone)
+	parsed, err := ParseSuppressionTarget(MessageChannelSMS, target.Key())
+	c.Assert(err, qt.IsNil)
+	c.Assert(parsed, qt.DeepEquals, target)
+
+	for _, tc := range []struct {
+		channel MessageChannel
+		address string
+	}{
+		{MessageChannelEmail, "not an email"},
+		{MessageChannelSMS, ""},
+		{"fax", "ana@example.com"},
+	} {
+		_, err := NewSuppressionTarget(org, tc.channel, tc.address)
+		c.Assert(errors.Is(err, ErrInvalidData), qt.IsTrue, qt.Commentf("%s %q", tc.channel, tc.address))
+	}
+}
+
+func TestSuppressAddress(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := &Organization{Address: testOrgAddress, Country: "ES", CreatedAt: time.Now()}
+	c.Assert(testDB.SetOrganization(org), qt.IsNil)
+
+	granted := &MemberConsent{Granted: true, At: time.Now(), Source: "signup"}
+	id, _, err := testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+		MemberNumber: "1", Name: "Ana", Email: "ana@example.com", PlaintextPhone: "+34600000001",
+		EmailConsent: granted, SMSConsent: granted,
+	}, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	member, err := testDB.OrgMember(testOrgAddress, id.Hex())
+	c.Assert(err, qt.IsNil)
+	allowed, err := testDB.CampaignRecipientAllowed(member, MessageChannelEmail)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsTrue)
+
+	target, err := NewSuppressionTarget(org, MessageChannelEmail, "ana@example.com")
+	c.Assert(err, qt.IsNil)
+	source := MemberChangeSource{Via: MemberChangeViaUnsubscribe}
+	created, err := testDB.SuppressAddress(testOrgAddress, target, "unsubscribed", source)
+	c.Assert(err, qt.IsNil)
+	c.Assert(created, qt.IsTrue)
+	// suppressing twice keeps the entry and records nothing new
+	created, err = testDB.SuppressAddress(testOrgAddress, target, "bounced", source)
+	c.Assert(err, qt.IsNil)
+	c.Assert(created, qt.IsFalse)
+
+	// the consent is withdrawn, and the change is in the member's history
+	member, err = testDB.OrgMember(testOrgAddress, id.Hex())
+	c.Assert(err, qt.IsNil)
+	c.Assert(member.EmailConsent.Granted, qt.IsFalse)
+	c.Assert(member.EmailConsent.Source, qt.Equals, MemberChangeViaUnsubscribe)
+	c.Assert(member.SMSConsent.Granted, qt.IsTrue)
+	_, versions, err := testDB.MemberHistory(testOrgAddress, id.Hex(), 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(versions, qt.HasLen, 2)
+	c.Assert(versions[0].Source, qt.DeepEquals, source)
+	c.Assert(versions[0].Changes, qt.DeepEquals, []MemberFieldChange{
+		{Field: "emailConsent", Old: "granted", New: "refused"},
+	})
+
+	allowed, err = testDB.CampaignRecipientAllowed(member, MessageChannelEmail)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsFalse)
+	allowed, err = testDB.CampaignRecipientAllowed(member, MessageChannelSMS)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsTrue)
+
+	total, suppressions, err := testDB.Suppressions(testOrgAddress, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(1))
+	c.Assert(suppressions[0].Email, qt.Equals, "ana@example.com")
+	c.Assert(suppressions[0].Reason, qt.Equals, "unsubscribed")
+
+	// lifting the suppression leaves the refused consent in place
+	c.Assert(testDB.UnsuppressAddress(testOrgAddress, target), qt.IsNil)
+	c.Assert(testDB.UnsuppressAddress(testOrgAddress, target), qt.ErrorIs, ErrNotFound)
+	suppressed, err := testDB.IsSuppressed(testOrgAddress, target)
+	c.Assert(err, qt.IsNil)
+	c.Assert(suppressed, qt.IsFalse)
+	allowed, err = testDB.CampaignRecipientAllowed(member, MessageChannelEmail)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsFalse)
+
+	// an address no member holds can be suppressed too
+	other, err := NewSuppressionTarget(org, MessageChannelSMS, "+34600000002")
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.SuppressAddress(testOrgAddress, other, "", MemberChangeSource{Via: MemberChangeViaAPI})
+	c.Assert(err, qt.IsNil)
+	// and it keeps out a member who holds it later, whatever their consent
+	id, _, err = testDB.UpsertOrgMemberAndCensusParticipants(org, &OrgMember{
+		MemberNumber: "2", Name: "Berta", PlaintextPhone: "+34600000002", SMSConsent: granted,
+	}, testSalt, MemberChangeSource{})
+	c.Assert(err, qt.IsNil)
+	member, err = testDB.OrgMember(testOrgAddress, id.Hex())
+	c.Assert(err, qt.IsNil)
+	allowed, err = testDB.CampaignRecipientAllowed(member, MessageChannelSMS)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsFalse)
+	// a member without an address on the channel cannot be reached through it
+	allowed, err = testDB.CampaignRecipientAllowed(member, MessageChannelEmail)
+	c.Assert(err, qt.IsNil)
+	c.Assert(allowed, qt.IsFalse)
+	removed, err := testDB.DeleteSuppressionsByOrg(testOrgAddress)
+	c.Assert(err, qt.IsNil)
+	c.Assert(removed, qt.Equals, int64(1))
+}
@@ -95,6 +95,15 @@ type Organization struct {
 	// integrator status (manual/admin path) and caps its managed resources. When unset,
 	// integrator status and limits derive from the active subscription plan instead.
 	IntegratorLimits *IntegratorLimits `json:"integratorLimits,omitempty" bson:"integratorLimits,omitempty"`
+	// MemberSelfService configures the public portal where members update their own
+	// contact data. Unset means the portal is disabled.
+	MemberSelfService *MemberSelfServiceConfig `json:"memberSelfService,omitempty" bson:"memberSelfService,omitempty"`
+	// RetentionPolicy configures how long the organization keeps the personal data of its
+	// members. Unset means the data is kept until deleted.
+	RetentionPolicy *RetentionPolicy `json:"retentionPolicy,omitempty" bson:"retentionPolicy,omitempty"`
+	// PublishReview requires the drafts of the organization to be approved by a reviewer before
+	// they are published. Unset means drafts are published without review.
+	PublishReview *PublishReviewPolicy `json:"publishReview,omitempty" bson:"publishReview,omitempty"`
 }
 
 // metaDefaultString extracts the "default" locale value from a meta entry that
@@ -275,6 +284,21 @@ type Census struct {
 	Published   PublishedCensus      `json:"published" bson:"published"`
 	AuthFields  OrgMemberAuthFields  `json:"authFields" bson:"orgMemberAuthFields"`
 	TwoFaFields OrgMemberTwoFaFields `json:"twoFaFields" bson:"orgMemberTwoFaFields"`
+	// WeightRule computes the weight of each participant of a weighted census as it joins; without
+	// one, the census weighs its participants by OrgMember.Weight.
+	WeightRule *WeightRule `json:"weightRule,omitempty" bson:"weightRule,omitempty"`
+	// Ephemeral marks a census whose participants were imported from a file instead of taken from
+	// the organization's members. Their data is kept apart and purged, at PurgedAt, once the
+	// processes of the census ended.
+	Ephemeral bool      `json:"ephemeral,omitempty" bson:"ephemeral,omitempty"`
+	PurgedAt  time.Time `json:"purgedAt,omitempty" bson:"purgedAt,omitempty"`
+	// FreezeOnPublish asks for the census to be frozen when its process is published. Frozen is
+	// set then and never cleared: its participants can no longer be added nor removed.
+	FreezeOnPublish bool          `json:"freezeOnPublish,omitempty" bson:"freezeOnPublish,omitempty"`
+	Frozen          *CensusFreeze `json:"frozen,omitempty" bson:"frozen,omitempty"`
+	// MaxProxies is how many participants may delegate their vote to a single other participant;
+	// 0 disables delegation in the census.
+	MaxProxies int `json:"maxProxies,omitempty" bson:"maxProxies,omitempty"`
 
 	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
 	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
@@ -305,6 +329,51 @@ type OrgMember struct {
 	Other           map[string]any `json:"other" bson:"other"`
 	CreatedAt       time.Time      `json:"createdAt" bson:"createdAt"`
 	UpdatedAt       time.Time      `json:"updatedAt" bson:"updatedAt"`
+	// ExternalID is the member's id in the organization's own system, set by SCIM provisioning.
+	ExternalID string `json:"externalId,omitempty" bson:"externalId,omitempty"`
+	// EmailConsent and SMSConsent record whether the member agreed to receive non-transactional
+	// messages on each channel. Nil means the member was never asked.
+	EmailConsent *MemberConsent `json:"emailConsent,omitempty" bson:"emailConsent,omitempty"`
+	SMSConsent   *MemberConsent `json:"smsConsent,omitempty" bson:"smsConsent,omitempty"`
+	// AnonymizedAt is when the retention policy of the organization erased the member's personal
+	// data. Zero for a member that still holds it.
+	AnonymizedAt time.Time `json:"anonymizedAt,omitempty" bson:"anonymizedAt,omitempty"`
+	// DeactivatedAt is when the member was deactivated, by SCIM provisioning. An inactive member
+	// keeps its data and the censuses it is in, but joins no new census. Zero for an active member.
+	DeactivatedAt time.Time `json:"deactivatedAt,omitempty" bson:"deactivatedAt,omitempty"`
+}
+
+// MessageChannel is a channel messages reach members through.
+type MessageChannel string
+
+const (
+	MessageChannelEmail MessageChannel = "email"
+	MessageChannelSMS   MessageChannel = "sms"
+)
+
+// IsValid reports whether c is a known channel.
+func (c MessageChannel) IsValid() bool {
+	return c == MessageChannelEmail || c == MessageChannelSMS
+}
+
+// MemberConsent is a member's answer to receiving non-transactional messages on a channel: when
+// it was given, and how it was collected (a signup form, a paper ballot, an unsubscribe link...).
+type MemberConsent struct {
+	Granted bool      `json:"granted" bson:"granted"`
+	At      time.Time `json:"at" bson:"at"`
+	Source  string    `json:"source,omitempty" bson:"source,omitempty"`
+}
+
+// String describes the consent for the member history.
+func (c *MemberConsent) String() string {
+	switch {
+	case c == nil:
+		return ""
+	case c.Granted:
+		return "granted"
+	default:
+		return "refused"
+	}
 }
 
 // Normalized returns a copy of the member with every field that can feed the CSP
@@ -512,13 +581,17 @@ type OrganizationMemberGroup struct {
 //
 //nolint:lll
 type CensusParticipant struct {
-	ParticipantID  string    `json:"participantID" bson:"participantID"`
-	CensusID       string    `json:"censusId" bson:"censusId"`
-	LoginHash      []byte    `json:"loginHash" bson:"loginHash" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
-	LoginHashPhone []byte    `json:"loginHashPhone" bson:"loginHashPhone" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
-	LoginHashEmail []byte    `json:"loginHashEmail" bson:"loginHashEmail" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
-	CreatedAt      time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt      time.Time `json:"updatedAt" bson:"updatedAt"`
+	ParticipantID  string `json:"participantID" bson:"participantID"`
+	CensusID       string `json:"censusId" bson:"censusId"`
+	LoginHash      []byte `json:"loginHash" bson:"loginHash" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
+	LoginHashPhone []byte `json:"loginHashPhone" bson:"loginHashPhone" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
+	LoginHashEmail []byte `json:"loginHashEmail" bson:"loginHashEmail" swaggertype:"string" format:"base64" example:"aGVsbG8gd29ybGQ="`
+	// Weight is the weight the census WeightRule gave the participant when it joined, or the one
+	// it was frozen with. Nil in a census without a rule until it is frozen, whose participants
+	// weigh what their member does.
+	Weight    *uint64   `json:"weight,omitempty" bson:"weight,omitempty"`
+	CreatedAt time.Time `json:"createdAt" bson:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt" bson:"updatedAt"`
 }
 
 // Represents a published census as a census is represented in the vochain
@@ -704,8 +777,54 @@ type VotingProcess struct {
 	// and the stale sweep on it being old, so a zero date persisted as a value would make every
 	// draft ever created look like a crashed publish.
 	Publishing time.Time `json:"-" bson:"publishing,omitempty"`
-	CreatedAt  time.Time `json:"createdAt" bson:"createdAt"`
-	UpdatedAt  time.Time `json:"updatedAt" bson:"updatedAt"`
+	// CreatedBy is the id of the user who created the draft, who is told when a scheduled publish
+	// of it fails. Zero for processes created before it was recorded.
+	CreatedBy uint64 `json:"-" bson:"createdBy,omitempty"`
+	// PublishSchedule is the scheduled publish of the draft, pending or already fired; nil when none
+	// was scheduled (see ScheduleVotingProcessPublish).
+	PublishSchedule *PublishSchedule `json:"-" bson:"publishSchedule,omitempty"`
+	CreatedAt       time.Time        `json:"createdAt" bson:"createdAt"`
+	UpdatedAt       time.Time        `json:"updatedAt" bson:"updatedAt"`
+
+	// ResultsCertificate is the results certificate issued once every question had final results,
+	// nil until then (see SetVotingProcessResultsCertificate).
+	ResultsCertificate *ResultsCertificate `json:"-" bson:"resultsCertificate,omitempty"`
+	// Review is the review of the draft, when its organization requires one; see CurrentReview for
+	// whether it still covers the draft as stored.
+	Review *ProcessReview `json:"-" bson:"review,omitempty"`
+	// RunoffOf links a run-off round to the question of the process it was created from; nil for
+	// any other process (see VotingProcessRunoffs).
+	RunoffOf *RunoffOrigin `json:"-" bson:"runoffOf,omitempty"`
+}
+
+// RunoffOrigin is the question, of a previous voting process, a run-off round decides between the
+// choices that led its results.
+type RunoffOrigin struct {
+	ProcessID  primitive.ObjectID `json:"processId" bson:"processId"`
+	QuestionID primitive.ObjectID `json:"questionId" bson:"questionId"`
+}
+
+// ResultsCertificate is the stored results certificate of a voting process: the object storage
+// names of its CSV and PDF renderings, the sha256 digest of the CSV, and the signature the backend
+// key, Signer, made over it.
+type ResultsCertificate struct {
+	CSV       string            `json:"csv" bson:"csv"`
+	PDF       string            `json:"pdf" bson:"pdf"`
+	Digest    internal.HexBytes `json:"digest" bson:"digest"`
+	Signature internal.HexBytes `json:"signature" bson:"signature"`
+	Signer    internal.HexBytes `json:"signer" bson:"signer"`
+	IssuedAt  time.Time         `json:"issuedAt" bson:"issuedAt"`
+}
+
+// PublishSchedule is a publish of a voting process deferred to At, on behalf of the user who
+// scheduled it. It is pending until FiredAt is set; once fired, it records either the JobID of the
+// publish it enqueued or the Problems that kept the process from being published.
+type PublishSchedule struct {
+	At       time.Time `json:"at" bson:"at"`
+	UserID   uint64    `json:"-" bson:"userId"`
+	FiredAt  time.Time `json:"firedAt,omitempty" bson:"firedAt,omitempty"`
+	JobID    string    `json:"jobId,omitempty" bson:"jobId,omitempty"`
+	Problems []string  `json:"problems,omitempty" bson:"problems,omitempty"`
 }
 
 // PublishInProgress reports whether a publish worker currently holds this process. A marker older
@@ -754,6 +873,38 @@ type VotingProcessQuestion struct {
 	// /processes/{id} and the public question read); the list endpoint leaves it nil to avoid an N+1
 	// chain fan-out, so its absence in a LIST response means "not resolved here", not "not published".
 	Results *QuestionResults `json:"results,omitempty" bson:"-"`
+	// Conditions make the question apply only to the voters who answered earlier questions of the
+	// process a certain way; a question without them applies to every eligible voter. All of them
+	// must hold. They are what clients show or hide the question by, and what the CSP checks before
+	// signing a ballot for it.
+	Conditions []QuestionCondition `json:"conditions,omitempty" bson:"conditions,omitempty"`
+	// InstantRunoff is the instant-runoff count of a ranked question, which needs every ballot
+	// rather than the on-chain matrix. It is computed once the results are final and kept, since
+	// they cannot change after that.
+	InstantRunoff *InstantRunoff `json:"-" bson:"instantRunoff,omitempty"`
+	// Rules are the quorum and majority the question must reach to pass, as a statutory vote
+	// declares them. The results read decides the question by them.
+	Rules *DecisionRules `json:"rules,omitempty" bson:"rules,omitempty"`
+}
+
+// DecisionRules are the rules a question is decided by. Quorum is the share of the eligible weight
+// (the census weight, or the eligible participants in a non-weighted census) that must vote, from
+// 0 to 1. Majority is the share of the votes cast the decided choice needs: more than any other
+// choice (simple; for a multichoice question, more selections than not), more than half (absolute)
+// or at least two thirds (twoThirds). It is measured on Choice, such as the "yes" of a motion, or
+// else on the leading choice. Either rule may be left out.
+type DecisionRules struct {
+	Quorum   float64 `json:"quorum,omitempty" bson:"quorum,omitempty"`
+	Majority string  `json:"majority,omitempty" bson:"majority,omitempty"`
+	Choice   *uint32 `json:"choice,omitempty" bson:"choice,omitempty"`
+}
+
+// QuestionCondition is one dependency of a conditional question: the voter's answer to the
+// question at position Question of the process, which must be an earlier singlechoice question,
+// has to be one of Choices.
+type QuestionCondition struct {
+	Question int      `json:"question" bson:"question"`
+	Choices  []uint32 `json:"choices" bson:"choices"`
 }
 
 // QuestionResults is one question's on-chain election tally, resolved on read from its own election.
@@ -768,6 +919,72 @@ type QuestionResults struct {
 	// values leave empty buckets); a multi-choice question has one row per choice, each [notSelected,
 	// selected]. Absent until the tally is published.
 	Results [][]string `json:"results,omitempty"`
+	// Outcome counts Results by the rules of the question's ballot type. Absent for a ballot
+	// protocol with no named type, and until the tally is published.
+	Outcome *TallyOutcome `json:"outcome,omitempty"`
+	// Decision applies the question's decision rules to Results. Absent for a question without
+	// rules, and until the tally is published.
+	Decision *QuestionDecision `json:"decision,omitempty"`
+}
+
+// QuestionDecision is a question decided by its rules. VotedWeight is the weight of the votes
+// cast, out of EligibleWeight. QuorumReached is set when the rules declare a quorum, and
+// ThresholdMet when they declare a majority, along with the Choice it was measured on (absent when
+// the lead is tied). Decision is pending until the results are final, and then passed when every
+// rule holds, failed otherwise.
+type QuestionDecision struct {
+	EligibleWeight string  `json:"eligibleWeight"`
+	VotedWeight    string  `json:"votedWeight"`
+	QuorumReached  *bool   `json:"quorumReached,omitempty"`
+	ThresholdMet   *bool   `json:"thresholdMet,omitempty"`
+	Choice         *uint32 `json:"choice,omitempty"`
+	Decision       string  `json:"decision"`
+}
+
+// TallyOutcome is a question's results counted by the rules of its ballot type: votes per choice for
+// a singlechoice question (plurality), selections per choice for a multichoice one (approval), Borda
+// points for a ranked one and points for a cumulative one. Scores follow the order of the choices
+// and are weighted like the on-chain tally; Winners are the values of the choices with the highest
+// score, several on a tie and none before any vote. A ranked question also carries its
+// instant-runoff count once its results are final.
+type TallyOutcome struct {
+	Method        string         `json:"method"`
+	Scores        []ChoiceScore  `json:"scores"`
+	Winners       []uint32       `json:"winners"`
+	InstantRunoff *InstantRunoff `json:"instantRunoff,omitempty"`
+}
+
+// ChoiceScore is the score of one choice, by its value, as a stringified big integer.
+type ChoiceScore struct {
+	Value uint32 `json:"value" bson:"value"`
+	Score string `json:"score" bson:"score"`
+}
+
+// InstantRunoff is the instant-runoff count of a ranked question. Each round counts every ballot
+// for its most preferred choice still running, and eliminates the choices with the fewest votes
+// until one holds a majority of the ballots not yet exhausted. Winners holds several choices when
+// all those left are tied, and none when no ballot ranks any choice. A question that will never be
+// counted keeps the reason in NotCounted instead, without rounds or winners, so it is not retried.
+type InstantRunoff struct {
+	Ballots    uint64        `json:"ballots" bson:"ballots"`
+	Rounds     []RunoffRound `json:"rounds" bson:"rounds"`
+	Winners    []uint32      `json:"winners" bson:"winners"`
+	NotCounted string        `json:"notCounted,omitempty" bson:"notCounted,omitempty"`
+}
+
+// Reasons an instant-runoff count is not made: the question holds more ballots than a count reads,
+// or one of them cannot be counted (undecodable, or with an invalid weight).
+const (
+	RunoffNotCountedTooManyBallots = "tooManyBallots"
+	RunoffNotCountedFailed         = "failed"
+)
+
+// RunoffRound is one round of an instant-runoff count: the votes of the choices still running,
+// the weight of the ballots that rank none of them, and the choices eliminated at its end.
+type RunoffRound struct {
+	Votes      []ChoiceScore `json:"votes" bson:"votes"`
+	Exhausted  string        `json:"exhausted" bson:"exhausted"`
+	Eliminated []uint32      `json:"eliminated,omitempty" bson:"eliminated,omitempty"`
 }
 
 // QuestionStatusRef is the minimal projection of a published question the status syncer and the
@@ -850,13 +1067,15 @@ const (
 	// JobTypePublishVotingProcess represents a multi-question voting-process publish
 	// (batch of NEW_PROCESS txs) tx job
 	JobTypePublishVotingProcess JobType = "publish_voting_process"
+	// JobTypeCensusExport represents the build of a signed auditor export of a published census
+	JobTypeCensusExport JobType = "census_export"
 )
 
 // IsValid reports whether t is one of the known job types.
 func (t JobType) IsValid() bool {
 	switch t {
 	case JobTypeOrgMembers, JobTypeCensusParticipants, JobTypePublishProcess, JobTypeSetProcessStatus,
-		JobTypeSetProcessCensus, JobTypeRelayVote, JobTypeRelayVotes, JobTypePublishVotingProcess:
+		JobTypeSetProcessCensus, JobTypeRelayVote, JobTypeRelayVotes, JobTypePublishVotingProcess, JobTypeCensusExport:
 		return true
 	default:
 		return false
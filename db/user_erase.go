@@ -173,12 +173,27 @@ func (ms *MongoStorage) eraseOrgData(address common.Address) error {
 	}
 	// the emptied questions are ignored on purpose: this is an erasure, so there is no election
 	// left to resize.
-	if _, _, err := ms.DeleteAllOrgMembers(address); err != nil {
+	if _, _, err := ms.DeleteAllOrgMembers(address, MemberChangeSource{}); err != nil {
 		errs = append(errs, fmt.Errorf("deleting members: %w", err))
 	}
 	if _, err := ms.DeleteJobsByOrg(address); err != nil {
 		errs = append(errs, fmt.Errorf("deleting jobs: %w", err))
 	}
+	if _, err := ms.DeleteMemberUpdateRequestsByOrg(address); err != nil {
+		errs = append(errs, fmt.Errorf("deleting member update requests: %w", err))
+	}
+	if _, err := ms.DeleteCSPAuthByBundle(MemberSelfServiceAnchor(address)); err != nil {
+		errs = append(errs, fmt.Errorf("deleting member self-service tokens: %w", err))
+	}
+	if _, err := ms.DeleteMemberHistoryByOrg(address); err != nil {
+		errs = append(errs, fmt.Errorf("deleting member history: %w", err))
+	}
+	if _, err := ms.DeleteSuppressionsByOrg(address); err != nil {
+		errs = append(errs, fmt.Errorf("deleting suppressions: %w", err))
+	}
+	if _, err := ms.DeleteProcessTemplatesByOrg(address); err != nil {
+		errs = append(errs, fmt.Errorf("deleting process templates: %w", err))
+	}
 	if _, err := ms.DeleteInvitationsByOrg(address); err != nil {
 		errs = append(errs, fmt.Errorf("deleting invitations: %w", err))
 	}
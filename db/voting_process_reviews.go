@@ -0,0 +1,198 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// States of the review of a voting process draft.
+const (
+	ReviewStatePending  = "pending"
+	ReviewStateApproved = "approved"
+	ReviewStateRejected = "rejected"
+)
+
+// MaxReviewCommentLength bounds the comment a reviewer leaves on a draft.
+const MaxReviewCommentLength = 2000
+
+// PublishReviewPolicy makes the drafts of an organization go through a review before they can be
+// published: a draft is submitted, and a user with ReviewerRole, other than the one who submitted
+// it, approves or rejects it.
+type PublishReviewPolicy struct {
+	Required bool `json:"required" bson:"required"`
+	// ReviewerRole is the role that may review, admin or manager. Admins may review either way.
+	ReviewerRole UserRole `json:"reviewerRole" bson:"reviewerRole"`
+}
+
+// Validate checks the reviewer role, defaulting an empty one to admin.
+func (p *PublishReviewPolicy) Validate() error {
+	switch p.ReviewerRole {
+	case "":
+		p.ReviewerRole = AdminRole
+	case AdminRole, ManagerRole:
+	default:
+		return fmt.Errorf("%w: reviewerRole must be %s or %s", ErrInvalidData, AdminRole, ManagerRole)
+	}
+	return nil
+}
+
+// CanReview reports whether user may review the drafts of the organization.
+func (p *PublishReviewPolicy) CanReview(user *User, orgAddress common.Address) bool {
+	if user.HasRoleFor(orgAddress, AdminRole) {
+		return true
+	}
+	return p.ReviewerRole == ManagerRole && user.HasRoleFor(orgAddress, ManagerRole)
+}
+
+// ReviewRequired reports whether the organization requires its drafts to be approved before they
+// are published.
+func (o *Organization) ReviewRequired() bool {
+	return o.PublishReview != nil && o.PublishReview.Required
+}
+
+// ProcessReview is the review of a voting process draft. It covers the draft as it was when
+// submitted, identified by its updatedAt: any later write of the draft moves updatedAt past
+// DraftUpdatedAt and voids the review, so an approval never carries over to an edited draft.
+type ProcessReview struct {
+	State          string    `json:"state" bson:"state"`
+	SubmittedBy    uint64    `json:"submittedBy" bson:"submittedBy"`
+	SubmittedAt    time.Time `json:"submittedAt" bson:"submittedAt"`
+	DraftUpdatedAt time.Time `json:"-" bson:"draftUpdatedAt"`
+	ReviewedBy     uint64    `json:"reviewedBy,omitempty" bson:"reviewedBy,omitempty"`
+	ReviewedAt     time.Time `json:"reviewedAt,omitempty" bson:"reviewedAt,omitempty"`
+	Comment        string    `json:"comment,omitempty" bson:"comment,omitempty"`
+}
+
+// CurrentReview returns the review of the draft as stored, nil when it was never submitted or was
+// written since.
+func (vp *VotingProcess) CurrentReview() *ProcessReview {
+	if vp.Review == nil || !vp.Review.DraftUpdatedAt.Equal(vp.UpdatedAt) {
+		return nil
+	}
+	return vp.Review
+}
+
+// ReviewApproved reports whether the draft as stored is the one a reviewer approved.
+func (vp *VotingProcess) ReviewApproved() bool {
+	r := vp.CurrentReview()
+	return r != nil && r.State == ReviewStateApproved
+}
+
+// SetPublishReviewPolicy stores the publish review policy of the organization. A nil policy, or
+// one not required, disables the review.
+func (ms *MongoStorage) SetPublishReviewPolicy(orgAddress common.Address, policy *PublishReviewPolicy) error {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return ErrInvalidData
+	}
+	ms.keysLock.Lock()
+	defer ms.keysLock.Unlock()
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	updateDoc := bson.M{"$unset": bson.M{"publishReview": ""}}
+	if policy != nil && policy.Required {
+		updateDoc = bson.M{"$set": bson.M{"publishReview": policy}}
+	}
+	res, err := ms.organizations.UpdateOne(ctx, bson.M{"_id": orgAddress}, updateDoc)
+	if err != nil {
+		return fmt.Errorf("failed to set publish review policy: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// SubmitVotingProcessReview submits a draft for review on behalf of userID, replacing any previous
+// review. seen is the updatedAt of the draft the user submits: the write is refused with ErrConflict
+// if the draft was written since, is published, or a publish worker holds it. It does not touch
+// updatedAt, which is what later voids the review. ErrNotFound is returned for an unknown process.
+func (ms *MongoStorage) SubmitVotingProcessReview(id primitive.ObjectID, seen time.Time, userID uint64) error {
+	if id == primitive.NilObjectID || userID == 0 {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": id, "published": false, "updatedAt": seen, "$or": notPublishing()}
+	review := &ProcessReview{
+		State:          ReviewStatePending,
+		SubmittedBy:    userID,
+		SubmittedAt:    time.Now(),
+		DraftUpdatedAt: seen,
+	}
+	res, err := ms.votingProcesses.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"review": review}})
+	if err != nil {
+		return fmt.Errorf("failed to submit voting process for review: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		if _, err := ms.VotingProcess(id); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+// ReviewVotingProcess approves or rejects, by state, the pending review of a draft on behalf of
+// userID, who must not be the user who submitted it. It returns ErrConflict when the draft has no
+// pending review, was written or submitted again since it was read, or is published, and
+// ErrNotFound for an unknown process.
+func (ms *MongoStorage) ReviewVotingProcess(id primitive.ObjectID, state string, userID uint64, comment string) error {
+	if id == primitive.NilObjectID || userID == 0 || (state != ReviewStateApproved && state != ReviewStateRejected) {
+		return ErrInvalidData
+	}
+	vp, err := ms.VotingProcess(id)
+	if err != nil {
+		return err
+	}
+	review := vp.CurrentReview()
+	if vp.Published || review == nil || review.State != ReviewStatePending {
+		return ErrConflict
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	// the filter repeats what was just checked, so a write of the draft in between refuses this one
+	filter := bson.M{
+		"_id":                   id,
+		"published":             false,
+		"updatedAt":             review.DraftUpdatedAt,
+		"review.state":          ReviewStatePending,
+		"review.draftUpdatedAt": review.DraftUpdatedAt,
+		"review.submittedAt":    review.SubmittedAt,
+		"review.submittedBy":    bson.M{"$ne": userID},
+	}
+	update := bson.M{"$set": bson.M{
+		"review.state":      state,
+		"review.reviewedBy": userID,
+		"review.reviewedAt": time.Now(),
+		"review.comment":    comment,
+	}}
+	res, err := ms.votingProcesses.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to review voting process: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrConflict
+	}
+	return nil
+}
+
+// VoidVotingProcessReview drops the review of a draft, for the writes of it that do not go through
+// SetVotingProcessDraft and so leave updatedAt as it was. A published or unknown process is left
+// alone.
+func (ms *MongoStorage) VoidVotingProcessReview(id primitive.ObjectID) error {
+	if id == primitive.NilObjectID {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": id, "published": false, "review": bson.M{"$exists": true}}
+	if _, err := ms.votingProcesses.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"review": ""}}); err != nil {
+		return fmt.Errorf("failed to void voting process review: %w", err)
+	}
+	return nil
+}
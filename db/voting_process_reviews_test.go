@@ -0,0 +1,85 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVotingProcessReview(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := common.Address{0x32}
+	setupVotingProcessOrg(c, org)
+
+	c.Assert(testDB.SetPublishReviewPolicy(org, &PublishReviewPolicy{Required: true, ReviewerRole: ManagerRole}), qt.IsNil)
+	got, err := testDB.Organization(org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got.ReviewRequired(), qt.IsTrue)
+	c.Assert(got.PublishReview.ReviewerRole, qt.Equals, ManagerRole)
+
+	id, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, Title: MultiLangString{"default": "P"}})
+	c.Assert(err, qt.IsNil)
+	vp, err := testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(testDB.SubmitVotingProcessReview(primitive.NewObjectID(), vp.UpdatedAt, 1), qt.ErrorIs, ErrNotFound)
+	c.Assert(testDB.ReviewVotingProcess(id, ReviewStateApproved, 2, ""), qt.ErrorIs, ErrConflict)
+	// submitting a version of the draft that is not the stored one is refused
+	c.Assert(testDB.SubmitVotingProcessReview(id, vp.UpdatedAt.Add(-time.Second), 1), qt.ErrorIs, ErrConflict)
+
+	c.Assert(testDB.SubmitVotingProcessReview(id, vp.UpdatedAt, 1), qt.IsNil)
+	vp, err = testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp.CurrentReview().State, qt.Equals, ReviewStatePending)
+	c.Assert(vp.ReviewApproved(), qt.IsFalse)
+	// the submitter cannot approve it
+	c.Assert(testDB.ReviewVotingProcess(id, ReviewStateApproved, 1, ""), qt.ErrorIs, ErrConflict)
+	c.Assert(testDB.ReviewVotingProcess(id, ReviewStateApproved, 2, "fine"), qt.IsNil)
+	c.Assert(testDB.ReviewVotingProcess(id, ReviewStateRejected, 2, "no"), qt.ErrorIs, ErrConflict)
+	vp, err = testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp.ReviewApproved(), qt.IsTrue)
+	c.Assert(vp.Review.ReviewedBy, qt.Equals, uint64(2))
+	c.Assert(vp.Review.Comment, qt.Equals, "fine")
+
+	// an edit of the draft voids the approval
+	c.Assert(testDB.SetVotingProcessDraft(vp, vp.UpdatedAt), qt.IsNil)
+	vp, err = testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp.Review, qt.IsNotNil)
+	c.Assert(vp.CurrentReview(), qt.IsNil)
+	c.Assert(vp.ReviewApproved(), qt.IsFalse)
+	c.Assert(testDB.ReviewVotingProcess(id, ReviewStateApproved, 2, ""), qt.ErrorIs, ErrConflict)
+
+	// so does a write that leaves updatedAt alone, once voided explicitly
+	c.Assert(testDB.SubmitVotingProcessReview(id, vp.UpdatedAt, 1), qt.IsNil)
+	c.Assert(testDB.VoidVotingProcessReview(id), qt.IsNil)
+	vp, err = testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp.Review, qt.IsNil)
+
+	c.Assert(testDB.SetPublishReviewPolicy(org, &PublishReviewPolicy{}), qt.IsNil)
+	got, err = testDB.Organization(org)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got.PublishReview, qt.IsNil)
+}
+
+func TestPublishReviewPolicyValidate(t *testing.T) {
+	c := qt.New(t)
+	p := &PublishReviewPolicy{Required: true}
+	c.Assert(p.Validate(), qt.IsNil)
+	c.Assert(p.ReviewerRole, qt.Equals, AdminRole)
+	c.Assert((&PublishReviewPolicy{Required: true, ReviewerRole: ViewerRole}).Validate(), qt.ErrorIs, ErrInvalidData)
+
+	org := common.Address{0x33}
+	manager := &User{Organizations: []OrganizationUser{{Address: org, Role: ManagerRole}}}
+	admin := &User{Organizations: []OrganizationUser{{Address: org, Role: AdminRole}}}
+	c.Assert(p.CanReview(manager, org), qt.IsFalse)
+	c.Assert(p.CanReview(admin, org), qt.IsTrue)
+	p.ReviewerRole = ManagerRole
+	c.Assert(p.CanReview(manager, org), qt.IsTrue)
+}
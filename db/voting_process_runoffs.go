@@ -0,0 +1,30 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// VotingProcessRunoffs returns the run-off rounds created from the questions of a voting process,
+// drafts included, oldest first.
+func (ms *MongoStorage) VotingProcessRunoffs(processID primitive.ObjectID) ([]VotingProcess, error) {
+	if processID == primitive.NilObjectID {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	opts := options.Find().SetSort(bson.D{{Key: "createdAt", Value: 1}, {Key: "_id", Value: 1}})
+	cursor, err := ms.votingProcesses.Find(ctx, bson.M{"runoffOf.processId": processID}, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find run-off rounds: %w", err)
+	}
+	var runoffs []VotingProcess
+	if err := cursor.All(ctx, &runoffs); err != nil {
+		return nil, fmt.Errorf("failed to decode run-off rounds: %w", err)
+	}
+	return runoffs, nil
+}
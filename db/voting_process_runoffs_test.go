@@ -0,0 +1,41 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVotingProcessRunoffs(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := common.Address{0x34}
+	setupVotingProcessOrg(c, org)
+
+	parent, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, Title: MultiLangString{"default": "P"}})
+	c.Assert(err, qt.IsNil)
+	runoffs, err := testDB.VotingProcessRunoffs(parent)
+	c.Assert(err, qt.IsNil)
+	c.Assert(runoffs, qt.HasLen, 0)
+
+	origin := &RunoffOrigin{ProcessID: parent, QuestionID: primitive.NewObjectID()}
+	first, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, RunoffOf: origin})
+	c.Assert(err, qt.IsNil)
+	second, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, RunoffOf: origin})
+	c.Assert(err, qt.IsNil)
+	_, err = testDB.SetVotingProcess(&VotingProcess{OrgAddress: org})
+	c.Assert(err, qt.IsNil)
+
+	runoffs, err = testDB.VotingProcessRunoffs(parent)
+	c.Assert(err, qt.IsNil)
+	c.Assert(runoffs, qt.HasLen, 2)
+	c.Assert(runoffs[0].ID, qt.Equals, first)
+	c.Assert(runoffs[1].ID, qt.Equals, second)
+	c.Assert(*runoffs[0].RunoffOf, qt.Equals, *origin)
+
+	_, err = testDB.VotingProcessRunoffs(primitive.NilObjectID)
+	c.Assert(err, qt.ErrorIs, ErrInvalidData)
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// notPublishing matches the processes no publish worker currently holds: those without a publishing
+// marker or with a stale one, as ClaimVotingProcessForPublish sees them.
+func notPublishing() bson.A {
+	return bson.A{
+		bson.M{"publishing": bson.M{"$exists": false}},
+		bson.M{"publishing": bson.M{"$lt": time.Now().Add(-PublishStaleAfter)}},
+	}
+}
+
+// ScheduleVotingProcessPublish schedules the publish of a draft at at, on behalf of userID,
+// replacing any schedule it had: a pending one is moved, and a fired one, failed or whose publish
+// job failed, is armed again. It returns ErrNotFound for an unknown process and ErrConflict for a
+// published one or one a publish worker holds.
+func (ms *MongoStorage) ScheduleVotingProcessPublish(id primitive.ObjectID, at time.Time, userID uint64) error {
+	if id == primitive.NilObjectID || at.IsZero() {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": id, "published": false, "$or": notPublishing()}
+	update := bson.M{"$set": bson.M{"publishSchedule": &PublishSchedule{At: at, UserID: userID}}}
+	res, err := ms.votingProcesses.UpdateOne(ctx, filter, update)
+	if err != nil {
+		return fmt.Errorf("failed to schedule voting process publish: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		if _, err := ms.VotingProcess(id); err != nil {
+			return err
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+// CancelVotingProcessPublish removes the schedule of a draft: a pending one is cancelled, a fired
+// one dismissed. It returns ErrNotFound when the process is unknown, published or has no schedule,
+// and ErrConflict while a publish worker holds it.
+func (ms *MongoStorage) CancelVotingProcessPublish(id primitive.ObjectID) error {
+	if id == primitive.NilObjectID {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{
+		"_id":             id,
+		"published":       false,
+		"publishSchedule": bson.M{"$exists": true},
+		"$or":             notPublishing(),
+	}
+	res, err := ms.votingProcesses.UpdateOne(ctx, filter, bson.M{"$unset": bson.M{"publishSchedule": ""}})
+	if err != nil {
+		return fmt.Errorf("failed to cancel voting process publish: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		vp, err := ms.VotingProcess(id)
+		if err != nil {
+			return err
+		}
+		if vp.Published || vp.PublishSchedule == nil {
+			return ErrNotFound
+		}
+		return ErrConflict
+	}
+	return nil
+}
+
+// DueScheduledPublishes returns the ids of the drafts whose scheduled publish is due at now and has
+// not fired yet, earliest first.
+func (ms *MongoStorage) DueScheduledPublishes(now time.Time) ([]primitive.ObjectID, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{
+		"published":               false,
+		"publishSchedule.at":      bson.M{"$lte": now},
+		"publishSchedule.firedAt": bson.M{"$exists": false},
+	}
+	opts := options.Find().SetProjection(bson.M{"_id": 1}).SetSort(bson.D{{Key: "publishSchedule.at", Value: 1}})
+	cur, err := ms.votingProcesses.Find(ctx, filter, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query due scheduled publishes: %w", err)
+	}
+	defer func() { _ = cur.Close(ctx) }()
+	var out []primitive.ObjectID
+	for cur.Next(ctx) {
+		var doc struct {
+			ID primitive.ObjectID `bson:"_id"`
+		}
+		if err := cur.Decode(&doc); err != nil {
+			return nil, fmt.Errorf("failed to decode scheduled process id: %w", err)
+		}
+		out = append(out, doc.ID)
+	}
+	return out, cur.Err()
+}
+
+// ClaimScheduledPublish atomically marks the scheduled publish of a draft as fired at now, if it is
+// still due and unfired, and returns the process as claimed. It returns nil when another scheduler
+// pass fired it first, or it was cancelled or rescheduled in between, so each schedule fires once.
+func (ms *MongoStorage) ClaimScheduledPublish(id primitive.ObjectID, now time.Time) (*VotingProcess, error) {
+	if id == primitive.NilObjectID {
+		return nil, ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{
+		"_id":                     id,
+		"published":               false,
+		"publishSchedule.at":      bson.M{"$lte": now},
+		"publishSchedule.firedAt": bson.M{"$exists": false},
+	}
+	update := bson.M{"$set": bson.M{"publishSchedule.firedAt": now}}
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	vp := &VotingProcess{}
+	if err := ms.votingProcesses.FindOneAndUpdate(ctx, filter, update, opts).Decode(vp); err != nil {
+		if err == mongo.ErrNoDocuments {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to claim scheduled publish: %w", err)
+	}
+	return vp, nil
+}
+
+// SetScheduledPublishOutcome records what a fired schedule led to: the id of the publish job it
+// enqueued, or the problems that kept the process from being published.
+func (ms *MongoStorage) SetScheduledPublishOutcome(id primitive.ObjectID, jobID string, problems []string) error {
+	if id == primitive.NilObjectID {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	set := bson.M{}
+	if jobID != "" {
+		set["publishSchedule.jobId"] = jobID
+	}
+	if len(problems) > 0 {
+		set["publishSchedule.problems"] = problems
+	}
+	if len(set) == 0 {
+		return nil
+	}
+	filter := bson.M{"_id": id, "publishSchedule.firedAt": bson.M{"$exists": true}}
+	res, err := ms.votingProcesses.UpdateOne(ctx, filter, bson.M{"$set": set})
+	if err != nil {
+		return fmt.Errorf("failed to record scheduled publish outcome: %w", err)
+	}
+	if res.MatchedCount == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// ScheduledPublishes returns a page of the drafts of an organization that have a scheduled publish,
+// pending or failed, soonest first. A process drops out of the list once published.
+func (ms *MongoStorage) ScheduledPublishes(orgAddress common.Address, page, limit int64) (int64, []VotingProcess, error) {
+	if orgAddress.Cmp(common.Address{}) == 0 {
+		return 0, nil, ErrInvalidData
+	}
+	filter := bson.M{
+		"orgAddress":      orgAddress,
+		"published":       false,
+		"publishSchedule": bson.M{"$exists": true},
+	}
+	opts := options.Find().SetSort(bson.D{{Key: "publishSchedule.at", Value: 1}, {Key: "_id", Value: 1}})
+	return paginatedDocuments[VotingProcess](ms.votingProcesses, page, limit, filter, opts)
+}
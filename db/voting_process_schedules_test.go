@@ -0,0 +1,81 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ethereum/go-ethereum/common"
+	qt "github.com/frankban/quicktest"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestVotingProcessPublishSchedule(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(testDB.DeleteAllDocuments(), qt.IsNil)
+	c.Cleanup(func() { c.Assert(testDB.DeleteAllDocuments(), qt.IsNil) })
+	org := common.Address{0x31}
+	setupVotingProcessOrg(c, org)
+
+	id, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, Title: MultiLangString{"default": "P"}})
+	c.Assert(err, qt.IsNil)
+	other, err := testDB.SetVotingProcess(&VotingProcess{OrgAddress: org, Title: MultiLangString{"default": "Q"}})
+	c.Assert(err, qt.IsNil)
+	c.Assert(testDB.ScheduleVotingProcessPublish(primitive.NewObjectID(), time.Now(), 1), qt.ErrorIs, ErrNotFound)
+	c.Assert(testDB.CancelVotingProcessPublish(id), qt.ErrorIs, ErrNotFound)
+
+	at := time.Now().Add(time.Hour).Truncate(time.Millisecond)
+	c.Assert(testDB.ScheduleVotingProcessPublish(id, at, 7), qt.IsNil)
+	c.Assert(testDB.ScheduleVotingProcessPublish(other, at.Add(time.Minute), 7), qt.IsNil)
+	total, scheduled, err := testDB.ScheduledPublishes(org, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(2))
+	c.Assert(scheduled[0].ID, qt.Equals, id)
+	c.Assert(scheduled[0].PublishSchedule.UserID, qt.Equals, uint64(7))
+
+	// nothing is due before the schedule
+	due, err := testDB.DueScheduledPublishes(time.Now())
+	c.Assert(err, qt.IsNil)
+	c.Assert(due, qt.HasLen, 0)
+	due, err = testDB.DueScheduledPublishes(at)
+	c.Assert(err, qt.IsNil)
+	c.Assert(due, qt.DeepEquals, []primitive.ObjectID{id})
+
+	// a schedule is claimed once
+	vp, err := testDB.ClaimScheduledPublish(id, at)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp.PublishSchedule.FiredAt.IsZero(), qt.IsFalse)
+	vp, err = testDB.ClaimScheduledPublish(id, at)
+	c.Assert(err, qt.IsNil)
+	c.Assert(vp, qt.IsNil)
+	due, err = testDB.DueScheduledPublishes(at.Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(due, qt.DeepEquals, []primitive.ObjectID{other})
+
+	c.Assert(testDB.SetScheduledPublishOutcome(id, "", []string{"missing end date"}), qt.IsNil)
+	got, err := testDB.VotingProcess(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(got.PublishSchedule.Problems, qt.DeepEquals, []string{"missing end date"})
+
+	// scheduling again arms a fired schedule
+	c.Assert(testDB.ScheduleVotingProcessPublish(id, at, 7), qt.IsNil)
+	due, err = testDB.DueScheduledPublishes(at)
+	c.Assert(err, qt.IsNil)
+	c.Assert(due, qt.DeepEquals, []primitive.ObjectID{id})
+
+	// a process a publish worker holds can be neither rescheduled nor cancelled
+	claimed, err := testDB.ClaimVotingProcessForPublish(id)
+	c.Assert(err, qt.IsNil)
+	c.Assert(claimed, qt.IsTrue)
+	c.Assert(testDB.ScheduleVotingProcessPublish(id, at, 7), qt.ErrorIs, ErrConflict)
+	c.Assert(testDB.CancelVotingProcessPublish(id), qt.ErrorIs, ErrConflict)
+	c.Assert(testDB.SetVotingProcessPublished(id, time.Time{}), qt.IsNil)
+	c.Assert(testDB.CancelVotingProcessPublish(id), qt.ErrorIs, ErrNotFound)
+	total, _, err = testDB.ScheduledPublishes(org, 1, 10)
+	c.Assert(err, qt.IsNil)
+	c.Assert(total, qt.Equals, int64(1))
+
+	c.Assert(testDB.CancelVotingProcessPublish(other), qt.IsNil)
+	due, err = testDB.DueScheduledPublishes(at.Add(time.Hour))
+	c.Assert(err, qt.IsNil)
+	c.Assert(due, qt.HasLen, 0)
+}
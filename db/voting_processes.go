@@ -344,3 +344,18 @@ func (ms *MongoStorage) SetVotingProcessPublished(id primitive.ObjectID, startDa
 	}
 	return nil
 }
+
+// SetVotingProcessResultsCertificate stores the results certificate of a process. A certificate is
+// issued once: a process that already has one keeps it.
+func (ms *MongoStorage) SetVotingProcessResultsCertificate(id primitive.ObjectID, cert *ResultsCertificate) error {
+	if id == primitive.NilObjectID || cert == nil {
+		return ErrInvalidData
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+	defer cancel()
+	filter := bson.M{"_id": id, "resultsCertificate": bson.M{"$exists": false}}
+	if _, err := ms.votingProcesses.UpdateOne(ctx, filter, bson.M{"$set": bson.M{"resultsCertificate": cert}}); err != nil {
+		return fmt.Errorf("failed to set voting process results certificate: %w", err)
+	}
+	return nil
+}
@@ -75,6 +75,7 @@ var (
 	ErrProcessNotFound           = Error{Code: 40038, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("process not found")}
 	ErrGroupNotFound             = Error{Code: 40057, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("group not found")}
 	ErrBundleNotFound            = Error{Code: 40058, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("bundle not found")}
+	ErrOrgMemberNotFound         = Error{Code: 40175, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("organization member not found")}
 
 	// Conflict errors (409)
 	ErrDuplicateConflict           = Error{Code: 40901, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("resource already exists")}
@@ -118,9 +119,34 @@ var (
 	// is deliberate: the CSP records consumption when it issues the signature, not when the ballot
 	// reaches the chain, so this means "already signed for" and must never be reported as "voted".
 	ErrCensusMemberAlreadySignedFor = Error{Code: 40173, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("member has already been signed for in an ongoing process")}
+	// ErrMemberMergeAffectsOngoingElection carries the processes holding the election in
+	// data.processIds.
+	ErrMemberMergeAffectsOngoingElection = Error{Code: 40174, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("member merge would affect an ongoing election")}
+	ErrMemberSelfServiceDisabled         = Error{Code: 40176, HTTPstatus: http.StatusForbidden, Err: fmt.Errorf("member self-service is not enabled for this organization"), LogLevel: "info"}
+	ErrMemberUpdateRequestNotFound       = Error{Code: 40177, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("member update request not found")}
+	ErrMemberUpdateRequestResolved       = Error{Code: 40178, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("member update request is already resolved"), LogLevel: "info"}
+	ErrInvalidUnsubscribeToken           = Error{Code: 40179, HTTPstatus: http.StatusBadRequest, Err: fmt.Errorf("invalid unsubscribe token"), LogLevel: "info"}
+	ErrSuppressionNotFound               = Error{Code: 40180, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("address is not on the suppression list")}
+	ErrCensusSnapshotNotFound            = Error{Code: 40181, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("census snapshot not found")}
+	ErrCensusNotPublished                = Error{Code: 40182, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("census is not published"), LogLevel: "info"}
+	ErrCensusExportNotFound              = Error{Code: 40183, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("census export not found")}
+	ErrCensusFrozen                      = Error{Code: 40184, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("census is frozen"), LogLevel: "info"}
+	ErrDelegationNotAllowed              = Error{Code: 40185, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("census does not allow vote delegation"), LogLevel: "info"}
+	ErrDelegationsLocked                 = Error{Code: 40186, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("delegations are locked once voting starts"), LogLevel: "info"}
+	ErrProxyLimitReached                 = Error{Code: 40187, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("delegate holds the maximum number of proxies"), LogLevel: "info"}
+	ErrDelegationNotFound                = Error{Code: 40188, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("delegation not found")}
+	ErrProcessTemplateNotFound           = Error{Code: 40189, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("process template not found")}
+	ErrPublishScheduleNotFound           = Error{Code: 40190, HTTPstatus: http.StatusNotFound, Err: fmt.Errorf("process has no scheduled publish")}
+	ErrQuestionConditionNotMet           = Error{Code: 40191, HTTPstatus: http.StatusForbidden, Err: fmt.Errorf("question condition not met"), LogLevel: "info"}
+	ErrPublishReviewRequired             = Error{Code: 40192, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("process must be approved by a reviewer before it is published"), LogLevel: "info"}
+	ErrPublishReviewDisabled             = Error{Code: 40193, HTTPstatus: http.StatusForbidden, Err: fmt.Errorf("publish review is not enabled for this organization"), LogLevel: "info"}
+	ErrReviewNotPending                  = Error{Code: 40194, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("process is not pending review"), LogLevel: "info"}
+	ErrSelfReview                        = Error{Code: 40195, HTTPstatus: http.StatusForbidden, Err: fmt.Errorf("a draft cannot be reviewed by the user who submitted it"), LogLevel: "info"}
+	ErrResultsNotFinal                   = Error{Code: 40196, HTTPstatus: http.StatusConflict, Err: fmt.Errorf("question results are not final yet"), LogLevel: "info"}
 
 	// CSP errors (408)
 	ErrZeroWeightVoter = Error{Code: 40801, HTTPstatus: http.StatusUnauthorized, Err: fmt.Errorf("voter weight cannot be zero")}
+	ErrVoteDelegated   = Error{Code: 40802, HTTPstatus: http.StatusUnauthorized, Err: fmt.Errorf("vote delegated to another member"), LogLevel: "info"}
 
 	// Server errors (500) - These should be used sparingly and only for true internal errors
 	ErrMarshalingServerJSONFailed  = Error{Code: 50001, HTTPstatus: http.StatusInternalServerError, Err: fmt.Errorf("server error: failed to process response"), LogLevel: "error"}
@@ -133,5 +159,6 @@ var (
 	ErrStripeWebhookError          = Error{Code: 50008, HTTPstatus: http.StatusInternalServerError, Err: fmt.Errorf("server error: stripe webhook failed"), LogLevel: "error"}
 
 	// Service unavailable errors (503)
-	ErrTxQueueFull = Error{Code: 50301, HTTPstatus: http.StatusServiceUnavailable, Err: fmt.Errorf("transaction queue is full, retry later"), LogLevel: "warn"}
+	ErrTxQueueFull        = Error{Code: 50301, HTTPstatus: http.StatusServiceUnavailable, Err: fmt.Errorf("transaction queue is full, retry later"), LogLevel: "warn"}
+	ErrTooManyLiveStreams = Error{Code: 50302, HTTPstatus: http.StatusServiceUnavailable, Err: fmt.Errorf("too many live streams, retry later"), LogLevel: "warn"}
 )
@@ -74,6 +74,16 @@ func SanitizeAndVerifyPhoneNumber(phone, country string) (string, error) {
 	return fmt.Sprintf("+%d%d", pn.GetCountryCode(), pn.GetNationalNumber()), nil
 }
 
+// MaskPhoneNumber masks a phone number sanitized by SanitizeAndVerifyPhoneNumber for display: its
+// first three characters and its last three digits stay, every digit between them becomes '*'.
+func MaskPhoneNumber(phone string) string {
+	const prefix, suffix = 3, 3
+	if len(phone) <= prefix+suffix {
+		return strings.Repeat("*", len(phone))
+	}
+	return phone[:prefix] + strings.Repeat("*", len(phone)-prefix-suffix) + phone[len(phone)-suffix:]
+}
+
 // RandomInt returns a secure random integer in the range [0, maxInt).
 func RandomInt(maxInt int) int {
 	n, err := rand.Int(rand.Reader, big.NewInt(int64(maxInt)))
@@ -104,6 +104,15 @@ func TestSanitizePhone(t *testing.T) {
 	})
 }
 
+func TestMaskPhoneNumber(t *testing.T) {
+	c := quicktest.New(t)
+	c.Assert(MaskPhoneNumber("+34623456789"), quicktest.Equals, "+34******789")
+	c.Assert(MaskPhoneNumber("+12025550123"), quicktest.Equals, "+12******123")
+	// too short to keep anything without disclosing most of it
+	c.Assert(MaskPhoneNumber("+34623"), quicktest.Equals, "******")
+	c.Assert(MaskPhoneNumber(""), quicktest.Equals, "")
+}
+
 func TestEncryptDecryptToken(t *testing.T) {
 	c := quicktest.New(t)
 
@@ -0,0 +1,270 @@
+// Package liveresults streams the live state of voting processes, the status of their questions and
+// their tallies, to many subscribers at once. Every process with a subscriber has a single poller,
+// however many screens follow it: the poller reads the process every interval, or sooner when
+// notified of a change, and hands each subscriber what changed since the last read. A subscriber
+// that falls behind is not queued every intermediate state: it gets the latest one of each event
+// when it reads again.
+package liveresults
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// defaultInterval is the pause between two reads of a followed process, when Config.Interval
+	// is 0.
+	defaultInterval = 5 * time.Second
+	// defaultMaxSubscribers caps the open subscriptions, when Config.MaxSubscribers is 0.
+	defaultMaxSubscribers = 2000
+	// defaultMaxProcessSubscribers caps the open subscriptions to one process, when
+	// Config.MaxProcessSubscribers is 0.
+	defaultMaxProcessSubscribers = 500
+)
+
+var (
+	// ErrTooManySubscribers is returned by Subscribe when the hub holds its maximum of subscriptions.
+	ErrTooManySubscribers = fmt.Errorf("too many live subscribers")
+	// ErrTooManyProcessSubscribers is returned by Subscribe when the process holds its maximum of
+	// subscriptions, so the followers of a few processes cannot take every subscription.
+	ErrTooManyProcessSubscribers = fmt.Errorf("too many live subscribers to the process")
+)
+
+// Config tunes a Hub. Zero fields use the defaults.
+type Config struct {
+	Interval              time.Duration
+	MaxSubscribers        int
+	MaxProcessSubscribers int
+}
+
+// Update is the state of one event of a process: its name and its data, to be sent as is.
+type Update struct {
+	Event string
+	Data  []byte
+}
+
+// PollFunc reads the current state of a process, one update per event.
+type PollFunc func() ([]Update, error)
+
+// Hub runs the pollers of the followed processes and fans their updates out to the subscribers.
+// ctx cancellation stops every poller and ends every subscription.
+type Hub struct {
+	ctx                   context.Context
+	interval              time.Duration
+	maxSubscribers        int
+	maxProcessSubscribers int
+
+	mu          sync.Mutex
+	pollers     map[string]*poller
+	subscribers int
+}
+
+// poller is the reader of one process, and the latest data it read of each event, in the order the
+// events were first seen, which a new subscriber starts from.
+type poller struct {
+	id     string
+	poll   PollFunc
+	wake   chan struct{}
+	stop   chan struct{}
+	subs   map[*Subscription]struct{}
+	events []string
+	last   map[string][]byte
+}
+
+// Subscription receives the updates of a process. C is signalled when updates are pending, and
+// Updates takes them.
+type Subscription struct {
+	hub       *Hub
+	processID string
+	ready     chan struct{}
+	// closed is guarded by the hub's mutex
+	closed bool
+
+	mu      sync.Mutex
+	events  []string
+	pending map[string][]byte
+}
+
+// New builds a Hub bound to ctx.
+func New(ctx context.Context, c *Config) *Hub {
+	if c == nil {
+		c = &Config{}
+	}
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	maxSubscribers := c.MaxSubscribers
+	if maxSubscribers <= 0 {
+		maxSubscribers = defaultMaxSubscribers
+	}
+	maxProcessSubscribers := c.MaxProcessSubscribers
+	if maxProcessSubscribers <= 0 {
+		maxProcessSubscribers = defaultMaxProcessSubscribers
+	}
+	return &Hub{
+		ctx:                   ctx,
+		interval:              interval,
+		maxSubscribers:        maxSubscribers,
+		maxProcessSubscribers: maxProcessSubscribers,
+		pollers:               make(map[string]*poller),
+	}
+}
+
+// Subscribe follows processID. The first subscriber of a process starts its poller, which reads it
+// with poll; later subscribers share that poller and start from the latest state it read. The
+// subscription must be closed when done with.
+func (h *Hub) Subscribe(processID string, poll PollFunc) (*Subscription, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.subscribers >= h.maxSubscribers {
+		return nil, ErrTooManySubscribers
+	}
+	if p, ok := h.pollers[processID]; ok && len(p.subs) >= h.maxProcessSubscribers {
+		return nil, ErrTooManyProcessSubscribers
+	}
+	s := &Subscription{
+		hub:       h,
+		processID: processID,
+		ready:     make(chan struct{}, 1),
+		pending:   make(map[string][]byte),
+	}
+	p, ok := h.pollers[processID]
+	if !ok {
+		p = &poller{
+			id:   processID,
+			poll: poll,
+			wake: make(chan struct{}, 1),
+			stop: make(chan struct{}),
+			subs: make(map[*Subscription]struct{}),
+			last: make(map[string][]byte),
+		}
+		h.pollers[processID] = p
+		go h.run(p)
+	}
+	p.subs[s] = struct{}{}
+	h.subscribers++
+	for _, event := range p.events {
+		s.push(Update{Event: event, Data: p.last[event]})
+	}
+	return s, nil
+}
+
+// Notify makes the poller of processID, if it has one, read it now instead of at its next interval.
+// A no-op on a nil hub.
+func (h *Hub) Notify(processID string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	p, ok := h.pollers[processID]
+	h.mu.Unlock()
+	if !ok {
+		return
+	}
+	select {
+	case p.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run reads a process until its last subscriber leaves or the hub stops. A failed read is logged
+// and retried at the next interval; the subscribers keep the state they have.
+func (h *Hub) run(p *poller) {
+	for {
+		updates, err := p.poll()
+		if err != nil {
+			log.Warnw("live results: could not read process", "process", p.id, "error", err)
+		} else {
+			h.publish(p, updates)
+		}
+		select {
+		case <-h.ctx.Done():
+			return
+		case <-p.stop:
+			return
+		case <-p.wake:
+		case <-time.After(h.interval):
+		}
+	}
+}
+
+// publish hands the updates whose data changed since the last read to every subscriber of p.
+func (h *Hub) publish(p *poller, updates []Update) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, u := range updates {
+		last, seen := p.last[u.Event]
+		if seen && bytes.Equal(last, u.Data) {
+			continue
+		}
+		if !seen {
+			p.events = append(p.events, u.Event)
+		}
+		p.last[u.Event] = u.Data
+		for s := range p.subs {
+			s.push(u)
+		}
+	}
+}
+
+// push makes u pending, replacing the pending data of the same event, and signals C.
+func (s *Subscription) push(u Update) {
+	s.mu.Lock()
+	if _, ok := s.pending[u.Event]; !ok {
+		s.events = append(s.events, u.Event)
+	}
+	s.pending[u.Event] = u.Data
+	s.mu.Unlock()
+	select {
+	case s.ready <- struct{}{}:
+	default:
+	}
+}
+
+// C is signalled when updates are pending.
+func (s *Subscription) C() <-chan struct{} {
+	return s.ready
+}
+
+// Done is closed when the hub stops.
+func (s *Subscription) Done() <-chan struct{} {
+	return s.hub.ctx.Done()
+}
+
+// Updates takes the pending updates: the latest data of each event changed since the last call, in
+// the order the events changed.
+func (s *Subscription) Updates() []Update {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	updates := make([]Update, len(s.events))
+	for i, event := range s.events {
+		updates[i] = Update{Event: event, Data: s.pending[event]}
+	}
+	s.events = nil
+	clear(s.pending)
+	return updates
+}
+
+// Close ends the subscription. The last subscriber of a process to leave stops its poller.
+func (s *Subscription) Close() {
+	h := s.hub
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if s.closed {
+		return
+	}
+	s.closed = true
+	h.subscribers--
+	p := h.pollers[s.processID]
+	delete(p.subs, s)
+	if len(p.subs) == 0 {
+		close(p.stop)
+		delete(h.pollers, s.processID)
+	}
+}
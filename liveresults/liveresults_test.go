@@ -0,0 +1,138 @@
+package liveresults
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+// source is a process whose state the test sets, counting the reads of it.
+type source struct {
+	mu     sync.Mutex
+	reads  int
+	tally  int
+	status string
+	polled chan struct{}
+}
+
+func newSource() *source {
+	return &source{status: "READY", polled: make(chan struct{}, 100)}
+}
+
+func (s *source) poll() ([]Update, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reads++
+	defer func() { s.polled <- struct{}{} }()
+	return []Update{
+		{Event: "status", Data: []byte(s.status)},
+		{Event: "results", Data: fmt.Appendf(nil, "%d", s.tally)},
+	}, nil
+}
+
+func (s *source) set(status string, tally int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.status, s.tally = status, tally
+}
+
+func (s *source) waitPoll(c *qt.C) {
+	select {
+	case <-s.polled:
+	case <-time.After(5 * time.Second):
+		c.Fatal("the process was not read")
+	}
+}
+
+func next(c *qt.C, sub *Subscription) []Update {
+	select {
+	case <-sub.C():
+	case <-time.After(5 * time.Second):
+		c.Fatal("no update")
+	}
+	return sub.Updates()
+}
+
+func TestHub(t *testing.T) {
+	c := qt.New(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// an interval long enough for only Notify to trigger the reads after the first
+	hub := New(ctx, &Config{Interval: time.Hour, MaxSubscribers: 3, MaxProcessSubscribers: 2})
+	src := newSource()
+
+	first, err := hub.Subscribe("p1", src.poll)
+	c.Assert(err, qt.IsNil)
+	defer first.Close()
+	src.waitPoll(c)
+	c.Assert(next(c, first), qt.DeepEquals, []Update{
+		{Event: "status", Data: []byte("READY")}, {Event: "results", Data: []byte("0")},
+	})
+
+	c.Run("a later subscriber shares the poller and starts from its state", func(c *qt.C) {
+		second, err := hub.Subscribe("p1", func() ([]Update, error) {
+			c.Fatal("a second poller was started")
+			return nil, nil
+		})
+		c.Assert(err, qt.IsNil)
+		defer second.Close()
+		c.Assert(next(c, second), qt.HasLen, 2)
+	})
+
+	c.Run("only what changed is sent, coalesced to the latest", func(c *qt.C) {
+		for tally := 1; tally <= 3; tally++ {
+			src.set("READY", tally)
+			hub.Notify("p1")
+			src.waitPoll(c)
+		}
+		c.Assert(next(c, first), qt.DeepEquals, []Update{{Event: "results", Data: []byte("3")}})
+
+		src.set("ENDED", 3)
+		hub.Notify("p1")
+		src.waitPoll(c)
+		c.Assert(next(c, first), qt.DeepEquals, []Update{{Event: "status", Data: []byte("ENDED")}})
+	})
+
+	c.Run("subscribers are capped", func(c *qt.C) {
+		other, err := hub.Subscribe("p2", newSource().poll)
+		c.Assert(err, qt.IsNil)
+		defer other.Close()
+		third, err := hub.Subscribe("p3", newSource().poll)
+		c.Assert(err, qt.IsNil)
+		_, err = hub.Subscribe("p4", newSource().poll)
+		c.Assert(err, qt.Equals, ErrTooManySubscribers)
+		third.Close()
+		third.Close() // closing twice frees one slot only
+		fourth, err := hub.Subscribe("p4", newSource().poll)
+		c.Assert(err, qt.IsNil)
+		fourth.Close()
+	})
+
+	c.Run("subscribers to a process are capped", func(c *qt.C) {
+		second, err := hub.Subscribe("p1", src.poll)
+		c.Assert(err, qt.IsNil)
+		_, err = hub.Subscribe("p1", src.poll)
+		c.Assert(err, qt.Equals, ErrTooManyProcessSubscribers)
+		second.Close()
+		third, err := hub.Subscribe("p1", src.poll)
+		c.Assert(err, qt.IsNil)
+		third.Close()
+	})
+
+	c.Run("the last subscriber to leave stops the poller", func(c *qt.C) {
+		src := newSource()
+		sub, err := hub.Subscribe("p5", src.poll)
+		c.Assert(err, qt.IsNil)
+		src.waitPoll(c)
+		sub.Close()
+		hub.Notify("p5")
+		time.Sleep(50 * time.Millisecond)
+		src.mu.Lock()
+		defer src.mu.Unlock()
+		c.Assert(src.reads, qt.Equals, 1)
+	})
+}
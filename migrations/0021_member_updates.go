@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	AddMigration(21, "member_updates", upMemberUpdates, downMemberUpdates)
+}
+
+// upMemberUpdates creates the memberUpdates collection, the approval queue and
+// audit trail of the member self-service portal. Managers list an organization's requests
+// newest first, optionally filtered by status.
+func upMemberUpdates(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "memberUpdates"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create memberUpdates collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("memberUpdates").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "orgAddress", Value: 1}, {Key: "status", Value: 1}, {Key: "createdAt", Value: -1}}},
+		{Keys: bson.D{{Key: "orgAddress", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create indexes on memberUpdates: %w", err)
+	}
+	return nil
+}
+
+func downMemberUpdates(context.Context, *mongo.Database) error {
+	// The collection is the audit trail of member self-service changes; dropping it would
+	// destroy it, so matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
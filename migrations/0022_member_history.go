@@ -0,0 +1,36 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	AddMigration(22, "member_history", upMemberHistory, downMemberHistory)
+}
+
+// upMemberHistory creates the memberHistory collection, the append-only change log of
+// organization members. It is read one member at a time, newest first.
+func upMemberHistory(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "memberHistory"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create memberHistory collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("memberHistory").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "orgAddress", Value: 1}, {Key: "memberId", Value: 1}, {Key: "createdAt", Value: -1}},
+	}); err != nil {
+		return fmt.Errorf("failed to create index on memberHistory: %w", err)
+	}
+	return nil
+}
+
+func downMemberHistory(context.Context, *mongo.Database) error {
+	// The collection is the audit trail of member changes; dropping it would destroy it, so
+	// matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
@@ -0,0 +1,62 @@
+package migrations
+
+import (
+	"context"
+	stderrors "errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	AddMigration(23, "org_members_search_indexes", upOrgMembersSearchIndexes, downOrgMembersSearchIndexes)
+}
+
+// orgMembersSearchSortFields are the orgMembers fields a member search can sort by.
+var orgMembersSearchSortFields = []string{
+	"name", "surname", "memberNumber", "email", "weight", "parsedBirthDate", "createdAt",
+}
+
+// upOrgMembersSearchIndexes adds the indexes behind the keyset-paged member search: one
+// {orgAddress, <sort field>, _id} per sort field, which serves both the sort and the resume-after
+// condition of every page in either direction, and one on externalId for SCIM lookups. The
+// creation-order sort is served by 0002's {orgAddress, _id}.
+func upOrgMembersSearchIndexes(ctx context.Context, database *mongo.Database) error {
+	models := make([]mongo.IndexModel, 0, len(orgMembersSearchSortFields)+1)
+	for _, field := range orgMembersSearchSortFields {
+		models = append(models, mongo.IndexModel{Keys: bson.D{
+			{Key: "orgAddress", Value: 1},
+			{Key: field, Value: 1},
+			{Key: "_id", Value: 1},
+		}})
+	}
+	models = append(models, mongo.IndexModel{Keys: bson.D{
+		{Key: "orgAddress", Value: 1},
+		{Key: "externalId", Value: 1},
+	}})
+	if _, err := database.Collection("orgMembers").Indexes().CreateMany(ctx, models); err != nil {
+		return fmt.Errorf("failed to create search indexes on orgMembers: %w", err)
+	}
+	return nil
+}
+
+// downOrgMembersSearchIndexes drops the indexes again. They carry no data, so this migration is
+// genuinely reversible.
+func downOrgMembersSearchIndexes(ctx context.Context, database *mongo.Database) error {
+	names := make([]string, 0, len(orgMembersSearchSortFields)+1)
+	for _, field := range orgMembersSearchSortFields {
+		names = append(names, "orgAddress_1_"+field+"_1__id_1")
+	}
+	names = append(names, "orgAddress_1_externalId_1")
+	for _, name := range names {
+		if _, err := database.Collection("orgMembers").Indexes().DropOne(ctx, name); err != nil {
+			// IndexNotFound (27) and NamespaceNotFound (26) leave nothing to drop, as in 0019
+			var cmdErr mongo.CommandError
+			if !stderrors.As(err, &cmdErr) || (cmdErr.Code != 27 && cmdErr.Code != 26) {
+				return fmt.Errorf("failed to drop index %s on orgMembers: %w", name, err)
+			}
+		}
+	}
+	return nil
+}
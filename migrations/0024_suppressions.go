@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(24, "suppressions", upSuppressions, downSuppressions)
+}
+
+// upSuppressions creates the suppressions collection, the per-organization list of addresses no
+// campaign-style message is sent to. An address is listed once per channel: email suppressions
+// carry no phone and SMS ones no email, so the missing field indexes as null.
+func upSuppressions(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "suppressions"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create suppressions collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("suppressions").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys: bson.D{
+				{Key: "orgAddress", Value: 1},
+				{Key: "channel", Value: 1},
+				{Key: "email", Value: 1},
+				{Key: "phone", Value: 1},
+			},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "orgAddress", Value: 1}, {Key: "createdAt", Value: -1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create indexes on suppressions: %w", err)
+	}
+	return nil
+}
+
+func downSuppressions(context.Context, *mongo.Database) error {
+	// The collection records who asked not to be contacted; dropping it would start messaging
+	// them again, so matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
@@ -0,0 +1,47 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(25, "census_snapshots", upCensusSnapshots, downCensusSnapshots)
+}
+
+// upCensusSnapshots creates the censusSnapshots collection, one immutable record per publication
+// of a census numbered from 1, and censusSnapshotParticipants, the hashed participant list of
+// each snapshot. A census has at most one snapshot per version.
+func upCensusSnapshots(ctx context.Context, database *mongo.Database) error {
+	for _, name := range []string{"censusSnapshots", "censusSnapshotParticipants"} {
+		if err := database.CreateCollection(ctx, name); err != nil {
+			// ignore "collection already exists" (code 48) so the migration is idempotent
+			if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+				return fmt.Errorf("failed to create %s collection: %w", name, err)
+			}
+		}
+	}
+	if _, err := database.Collection("censusSnapshots").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "censusId", Value: 1}, {Key: "version", Value: -1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return fmt.Errorf("failed to create index on censusSnapshots: %w", err)
+	}
+	if _, err := database.Collection("censusSnapshotParticipants").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "snapshotId", Value: 1}, {Key: "hash", Value: 1}}},
+		{Keys: bson.D{{Key: "censusId", Value: 1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create indexes on censusSnapshotParticipants: %w", err)
+	}
+	return nil
+}
+
+func downCensusSnapshots(context.Context, *mongo.Database) error {
+	// The collections are the audit trail of census publications; dropping them would destroy
+	// it, so matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(26, "census_exports", upCensusExports, downCensusExports)
+}
+
+// upCensusExports creates the censusExports collection, the signed auditor bundles of published
+// censuses, each found by the job that built it.
+func upCensusExports(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "censusExports"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create censusExports collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("censusExports").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{Keys: bson.D{{Key: "jobId", Value: 1}}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "censusId", Value: 1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create indexes on censusExports: %w", err)
+	}
+	return nil
+}
+
+func downCensusExports(context.Context, *mongo.Database) error {
+	// Exports may already be in the hands of auditors who expect to download them again, so
+	// matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
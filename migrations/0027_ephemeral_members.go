@@ -0,0 +1,42 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func init() {
+	AddMigration(27, "ephemeral_members", upEphemeralMembers, downEphemeralMembers)
+}
+
+// upEphemeralMembers creates the ephemeralMembers collection, the participants of ephemeral
+// censuses, which are read by id at login and purged per census.
+func upEphemeralMembers(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "ephemeralMembers"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create ephemeralMembers collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("ephemeralMembers").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "censusId", Value: 1}},
+	}); err != nil {
+		return fmt.Errorf("failed to create index on ephemeralMembers: %w", err)
+	}
+	// the purge looks up the ephemeral censuses not purged yet
+	if _, err := database.Collection("censuses").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys: bson.D{{Key: "ephemeral", Value: 1}, {Key: "purgedAt", Value: 1}},
+	}); err != nil {
+		return fmt.Errorf("failed to create ephemeral index on censuses: %w", err)
+	}
+	return nil
+}
+
+func downEphemeralMembers(context.Context, *mongo.Database) error {
+	// The collection holds the participants of live ephemeral censuses; dropping it would lock
+	// them out, so matching the repo policy for data-bearing collections we do nothing here.
+	return nil
+}
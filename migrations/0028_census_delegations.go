@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(28, "census_delegations", upCensusDelegations, downCensusDelegations)
+}
+
+// upCensusDelegations creates the censusDelegations collection. A participant delegates at most
+// once per census, and the CSP looks delegations up both by delegator and by delegate.
+func upCensusDelegations(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "censusDelegations"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create censusDelegations collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("censusDelegations").Indexes().CreateMany(ctx, []mongo.IndexModel{
+		{
+			Keys:    bson.D{{Key: "censusId", Value: 1}, {Key: "delegatorId", Value: 1}},
+			Options: options.Index().SetUnique(true),
+		},
+		{Keys: bson.D{{Key: "censusId", Value: 1}, {Key: "delegateId", Value: 1}}},
+	}); err != nil {
+		return fmt.Errorf("failed to create indexes on censusDelegations: %w", err)
+	}
+	return nil
+}
+
+func downCensusDelegations(context.Context, *mongo.Database) error {
+	// The collection holds the delegations of live censuses; dropping it would silently hand the
+	// delegated votes back, so matching the repo policy for data-bearing collections we do nothing.
+	return nil
+}
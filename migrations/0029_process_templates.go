@@ -0,0 +1,38 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(29, "process_templates", upProcessTemplates, downProcessTemplates)
+}
+
+// upProcessTemplates creates the processTemplates collection. A template name is unique within
+// its organization, which lists its templates by name.
+func upProcessTemplates(ctx context.Context, database *mongo.Database) error {
+	if err := database.CreateCollection(ctx, "processTemplates"); err != nil {
+		// ignore "collection already exists" (code 48) so the migration is idempotent
+		if cmdErr, ok := err.(mongo.CommandError); !ok || cmdErr.Code != 48 {
+			return fmt.Errorf("failed to create processTemplates collection: %w", err)
+		}
+	}
+	if _, err := database.Collection("processTemplates").Indexes().CreateOne(ctx, mongo.IndexModel{
+		Keys:    bson.D{{Key: "orgAddress", Value: 1}, {Key: "name", Value: 1}},
+		Options: options.Index().SetUnique(true),
+	}); err != nil {
+		return fmt.Errorf("failed to create index on processTemplates: %w", err)
+	}
+	return nil
+}
+
+func downProcessTemplates(context.Context, *mongo.Database) error {
+	// The collection holds the templates organizations saved; matching the repo policy for
+	// data-bearing collections we do nothing.
+	return nil
+}
@@ -0,0 +1,43 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(30, "voting_process_publish_schedule_index", upPublishScheduleIndex, downPublishScheduleIndex)
+}
+
+// publishScheduleIndexName is the default name Mongo assigns to a single-field ascending index on
+// publishSchedule.at; kept explicit so the down migration can drop it deterministically.
+const publishScheduleIndexName = "publishSchedule.at_1"
+
+// upPublishScheduleIndex indexes votingProcesses.publishSchedule.at. The publish scheduler looks
+// for due schedules every few seconds, which without this index is a scan of every process ever
+// created. The index is partial, like the managedBy one, because only the few drafts with a
+// schedule carry the field.
+func upPublishScheduleIndex(ctx context.Context, database *mongo.Database) error {
+	coll := database.Collection("votingProcesses")
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: "publishSchedule.at", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"publishSchedule": bson.M{"$exists": true}}),
+	}
+	if _, err := coll.Indexes().CreateOne(ctx, model); err != nil {
+		return fmt.Errorf("failed to create publishSchedule index on votingProcesses: %w", err)
+	}
+	return nil
+}
+
+// downPublishScheduleIndex drops the publishSchedule.at index, which leaves the documents intact.
+func downPublishScheduleIndex(ctx context.Context, database *mongo.Database) error {
+	coll := database.Collection("votingProcesses")
+	if _, err := coll.Indexes().DropOne(ctx, publishScheduleIndexName); err != nil {
+		return fmt.Errorf("failed to drop publishSchedule index on votingProcesses: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package migrations
+
+import (
+	"context"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func init() {
+	AddMigration(31, "voting_process_runoff_index", upRunoffIndex, downRunoffIndex)
+}
+
+// runoffIndexName is the default name Mongo assigns to a single-field ascending index on
+// runoffOf.processId; kept explicit so the down migration can drop it deterministically.
+const runoffIndexName = "runoffOf.processId_1"
+
+// upRunoffIndex indexes votingProcesses.runoffOf.processId, which every read of a process looks up
+// to list its run-off rounds. The index is partial because only run-off rounds carry the field.
+func upRunoffIndex(ctx context.Context, database *mongo.Database) error {
+	coll := database.Collection("votingProcesses")
+	model := mongo.IndexModel{
+		Keys:    bson.D{{Key: "runoffOf.processId", Value: 1}},
+		Options: options.Index().SetPartialFilterExpression(bson.M{"runoffOf": bson.M{"$exists": true}}),
+	}
+	if _, err := coll.Indexes().CreateOne(ctx, model); err != nil {
+		return fmt.Errorf("failed to create runoffOf index on votingProcesses: %w", err)
+	}
+	return nil
+}
+
+// downRunoffIndex drops the runoffOf.processId index, which leaves the documents intact.
+func downRunoffIndex(ctx context.Context, database *mongo.Database) error {
+	coll := database.Collection("votingProcesses")
+	if _, err := coll.Indexes().DropOne(ctx, runoffIndexName); err != nil {
+		return fmt.Errorf("failed to drop runoffOf index on votingProcesses: %w", err)
+	}
+	return nil
+}
@@ -41,3 +41,16 @@ var SupportNotification = MailTemplate{
 var MembersImportCompletionNotification = MailTemplate{
 	File: "members_import_done",
 }
+
+// PublishScheduleFailedNotification is the notification to be sent to the
+// creator of a voting process when its scheduled publish could not happen.
+var PublishScheduleFailedNotification = MailTemplate{
+	File: "process_publish_failed",
+}
+
+// MemberMessageNotification is a message an organization sends to one of
+// its members. It is a campaign message, so it carries an unsubscribe link.
+var MemberMessageNotification = MailTemplate{
+	File:     "member_message",
+	Campaign: true,
+}
@@ -28,6 +28,8 @@ func TestMailTemplateLoading(t *testing.T) {
 			"invite_admin",
 			"support",
 			"members_import_done",
+			"process_publish_failed",
+			"member_message",
 		}
 
 		for _, templateFile := range expectedTemplates {
@@ -634,3 +636,37 @@ func TestMailTemplateIntegration(t *testing.T) {
 		c.Assert(notification.PlainBody, qt.Contains, "<script>alert('xss')</script>")
 	})
 }
+
+func TestMemberMessageNotification(t *testing.T) {
+	c := qt.New(t)
+
+	// Load templates first
+	err := Load()
+	c.Assert(err, qt.IsNil)
+	c.Assert(MemberMessageNotification.Campaign, qt.IsTrue)
+
+	data := struct {
+		Data struct {
+			OrganizationName string
+			MemberName       string
+			Subject          string
+			Message          string
+		}
+		UnsubscribeURL string
+	}{UnsubscribeURL: "https://example.com/unsubscribe?token=abc.def"}
+	data.Data.OrganizationName = "Test Club"
+	data.Data.MemberName = "Ana"
+	data.Data.Subject = "General assembly"
+	data.Data.Message = "The assembly is on Friday."
+
+	// a campaign message shows its unsubscribe link in every language
+	for _, lang := range []string{"en", "es", "ca"} {
+		notification, err := MemberMessageNotification.Localized(lang).ExecTemplate(data)
+		c.Assert(err, qt.IsNil)
+		c.Assert(notification.Subject, qt.Equals, "General assembly")
+		c.Assert(notification.PlainBody, qt.Contains, "The assembly is on Friday.")
+		c.Assert(notification.PlainBody, qt.Contains, data.UnsubscribeURL, qt.Commentf("lang %s", lang))
+		c.Assert(notification.Body, qt.Contains, "Test Club")
+		c.Assert(notification.Body, qt.Contains, data.UnsubscribeURL, qt.Commentf("lang %s", lang))
+	}
+}
@@ -25,6 +25,10 @@ type TemplateKey string
 type MailTemplate struct {
 	File      string // Base filename (e.g., "verification_account")
 	WebAppURI string // App-specific URI for links
+	// Campaign marks a non-transactional message to organization members. It is
+	// sent only after checking the member's consent and the suppression list of
+	// the organization, and its templates must show the unsubscribe link.
+	Campaign bool
 }
 
 // TemplatePlainText represents the YAML content
@@ -18,6 +18,8 @@ func TestObjectIDFromName(t *testing.T) {
 		{"jpg", "Img9.jpg", "Img9", true},
 		{"jpeg", "Img9.jpeg", "Img9", true},
 		{"json", "deadbeef.json", "deadbeef", true},
+		{"pdf", "deadbeef.pdf", "deadbeef", true},
+		{"csv", "deadbeef.csv", "deadbeef", true},
 		{"double extension rejected", "abc.json.bak", "", false},
 		{"path traversal rejected", "abc.jpg/../../etc/passwd", "", false},
 		{"no extension rejected", "abc123", "", false},
@@ -31,6 +31,12 @@ const (
 	FileTypePNG ObjectFileType = "image/png"
 	// FileTypeJPG represents the JPG image MIME type.
 	FileTypeJPG ObjectFileType = "image/jpg"
+	// FileTypeJSON represents the JSON document MIME type.
+	FileTypeJSON ObjectFileType = "application/json"
+	// FileTypePDF represents the PDF document MIME type.
+	FileTypePDF ObjectFileType = "application/pdf"
+	// FileTypeCSV represents the CSV document MIME type.
+	FileTypeCSV ObjectFileType = "text/csv"
 )
 
 // DefaultSupportedFileTypes is a map of file types that are supported by default.
@@ -192,19 +198,38 @@ func (osc *Client) Put(data io.Reader, size int64, userID string) (string, error
 	return fmt.Sprintf("%s.%s", objectID, fileExtension), nil
 }
 
+// documentExtensions maps the types of the documents the server generates to the extension of
+// their object names.
+var documentExtensions = map[ObjectFileType]string{
+	FileTypeJSON: "json",
+	FileTypePDF:  "pdf",
+	FileTypeCSV:  "csv",
+}
+
 // PutJSON stores the given JSON document content-addressed (object id = hash of
 // the data) with an explicit "application/json" content type and returns the
 // object name "{objectID}.json". Unlike Put it does not sniff or restrict the
 // content type, so it must only be used for server-generated JSON.
 func (osc *Client) PutJSON(data []byte, userID string) (string, error) {
+	return osc.PutDocument(data, FileTypeJSON, userID)
+}
+
+// PutDocument stores a server-generated document of the given type (JSON, PDF or
+// CSV) content-addressed, like PutJSON, and returns its object name
+// "{objectID}.{extension}".
+func (osc *Client) PutDocument(data []byte, fileType ObjectFileType, userID string) (string, error) {
+	extension, ok := documentExtensions[fileType]
+	if !ok {
+		return "", ErrorFileTypeNotSupported
+	}
 	objectID, err := calculateObjectID(data)
 	if err != nil {
 		return "", fmt.Errorf("error calculating objectID: %w", err)
 	}
-	if err := osc.db.SetObject(objectID, userID, "application/json", data); err != nil {
+	if err := osc.db.SetObject(objectID, userID, string(fileType), data); err != nil {
 		return "", fmt.Errorf("cannot set object: %w", err)
 	}
-	return fmt.Sprintf("%s.json", objectID), nil
+	return fmt.Sprintf("%s.%s", objectID, extension), nil
 }
 
 // calculateObjectID calculates the objectID from the given data. The objectID
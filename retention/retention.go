@@ -0,0 +1,134 @@
+// Package retention provides the background sweeper that enforces the data retention policies of
+// organizations: it anonymizes the members inactive for longer than their organization keeps them,
+// and purges the CSP auth tokens of the voting processes that ended long enough ago. Every pass
+// sweeps each organization with a policy once and records the outcome on the policy, so the
+// organization can see what was done. Regardless of policies, every pass also purges the
+// participants of the ephemeral censuses whose voting processes have all ended. Sweeps are
+// idempotent: an interrupted pass is finished by the next one.
+package retention
+
+import (
+	"context"
+	"time"
+
+	"github.com/vocdoni/saas-backend/db"
+	"go.vocdoni.io/dvote/log"
+)
+
+const (
+	// defaultInterval is the pause between two passes, when Config.Interval is 0.
+	defaultInterval = time.Hour
+	// defaultMaxMembers caps the members anonymized per organization and pass, when
+	// Config.MaxMembersPerSweep is 0, so a first sweep over a large memberbase is spread out.
+	defaultMaxMembers = 1000
+)
+
+// Config wires the sweeper's dependencies and tuning. Zero Interval/MaxMembersPerSweep default.
+type Config struct {
+	DB                 *db.MongoStorage
+	Interval           time.Duration
+	MaxMembersPerSweep int64
+}
+
+// Sweeper enforces the retention policies of every organization from a single loop goroutine; ctx
+// cancellation stops it.
+type Sweeper struct {
+	db         *db.MongoStorage
+	interval   time.Duration
+	maxMembers int64
+	ctx        context.Context
+}
+
+// New builds a Sweeper bound to ctx (cancelling ctx stops it). Zero tuning fields use the defaults.
+func New(ctx context.Context, c *Config) *Sweeper {
+	interval := c.Interval
+	if interval <= 0 {
+		interval = defaultInterval
+	}
+	maxMembers := c.MaxMembersPerSweep
+	if maxMembers <= 0 {
+		maxMembers = defaultMaxMembers
+	}
+	return &Sweeper{
+		db:         c.DB,
+		interval:   interval,
+		maxMembers: maxMembers,
+		ctx:        ctx,
+	}
+}
+
+// Start launches the sweeping loop and returns immediately. The first pass runs right away.
+func (s *Sweeper) Start() {
+	log.Infow("starting retention sweeper", "interval", s.interval.String(), "maxMembers", s.maxMembers)
+	go s.loop()
+}
+
+func (s *Sweeper) loop() {
+	for {
+		s.SweepAll(time.Now())
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+// SweepAll purges the ended ephemeral censuses and sweeps every organization with a retention
+// policy as of now, and returns how many organizations it swept. Exported as a deterministic test
+// hook; production uses the interval loop.
+func (s *Sweeper) SweepAll(now time.Time) int {
+	if n, err := s.db.PurgeEndedEphemeralCensuses(now); err != nil {
+		log.Warnw("could not purge ended ephemeral censuses", "error", err)
+	} else if n > 0 {
+		log.Infow("purged ended ephemeral censuses", "censuses", n)
+	}
+	orgs, err := s.db.OrganizationsWithRetentionPolicy()
+	if err != nil {
+		log.Warnw("could not list organizations with a retention policy", "error", err)
+		return 0
+	}
+	for _, org := range orgs {
+		if s.ctx.Err() != nil {
+			return 0
+		}
+		sweep := s.Sweep(org, now)
+		if err := s.db.SetRetentionSweep(org.Address, sweep); err != nil {
+			log.Warnw("could not record retention sweep", "org", org.Address.Hex(), "error", err)
+		}
+	}
+	return len(orgs)
+}
+
+// Sweep applies the retention policy of org as of now and returns what it did. A rule that fails
+// stops the sweep; the error is reported in the outcome and the next pass retries.
+func (s *Sweeper) Sweep(org *db.Organization, now time.Time) *db.RetentionSweep {
+	sweep := &db.RetentionSweep{At: now}
+	policy := org.RetentionPolicy
+	if policy == nil {
+		return sweep
+	}
+	if months := policy.AnonymizeInactiveMembersMonths; months > 0 {
+		n, err := s.db.AnonymizeInactiveMembers(org.Address, now.AddDate(0, -months, 0), s.maxMembers)
+		sweep.AnonymizedMembers = n
+		if err != nil {
+			log.Warnw("could not anonymize inactive members", "org", org.Address.Hex(), "error", err)
+			sweep.Error = err.Error()
+			return sweep
+		}
+	}
+	if days := policy.PurgeCSPTokensDays; days > 0 {
+		n, err := s.db.PurgeEndedProcessCSPTokens(org.Address, now.AddDate(0, 0, -days))
+		sweep.PurgedCSPTokens = n
+		if err != nil {
+			log.Warnw("could not purge CSP tokens", "org", org.Address.Hex(), "error", err)
+			sweep.Error = err.Error()
+			return sweep
+		}
+	}
+	if sweep.AnonymizedMembers > 0 || sweep.PurgedCSPTokens > 0 {
+		log.Infow("retention sweep", "org", org.Address.Hex(),
+			"anonymizedMembers", sweep.AnonymizedMembers, "purgedCspTokens", sweep.PurgedCSPTokens)
+	}
+	return sweep
+}
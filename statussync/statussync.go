@@ -30,12 +30,21 @@ const (
 )
 
 // Config wires the syncer's dependencies and tuning. Zero Interval/ConfirmTimeout/Workers default.
+// Notifier is optional.
 type Config struct {
 	DB             *db.MongoStorage
 	Account        *account.Account
 	Interval       time.Duration
 	ConfirmTimeout time.Duration
 	Workers        int
+	Notifier       Notifier
+}
+
+// Notifier is told about the voting processes whose stored question statuses the syncer changed. It
+// is satisfied by *liveresults.Hub, so live streams push a change made on-chain without waiting for
+// their next read.
+type Notifier interface {
+	Notify(processID string)
 }
 
 // task is one queued reconciliation, keyed (for dedup) by its on-chain election id.
@@ -62,6 +71,7 @@ type Syncer struct {
 	interval    time.Duration
 	maxAttempts int
 	workers     int
+	notifier    Notifier
 	ctx         context.Context
 
 	mu      sync.Mutex
@@ -93,6 +103,7 @@ func New(ctx context.Context, c *Config) *Syncer {
 		interval:    interval,
 		maxAttempts: maxAttempts,
 		workers:     workers,
+		notifier:    c.Notifier,
 		ctx:         ctx,
 		pending:     make(map[string]*task),
 		wake:        make(chan struct{}, 1),
@@ -218,9 +229,13 @@ func (s *Syncer) process(t *task) {
 
 	// read path: converge stored status to the chain (also refreshes syncedAt), then drop.
 	if t.expected == "" {
-		if _, err := s.db.SetQuestionStatusSynced(t.upstreamID, t.known, chainStatus); err != nil {
+		matched, err := s.db.SetQuestionStatusSynced(t.upstreamID, t.known, chainStatus)
+		if err != nil {
 			log.Warnw("status sync: reconcile write failed", "upstreamId", hexID, "error", err.Error())
 		}
+		if matched && chainStatus != t.known {
+			s.notify(t.upstreamID)
+		}
 		return
 	}
 
@@ -239,9 +254,26 @@ func (s *Syncer) process(t *task) {
 		return
 	}
 	// gave up: reconcile the optimistic value to whatever the chain actually holds.
-	if _, err := s.db.SetQuestionStatusSynced(t.upstreamID, t.expected, chainStatus); err != nil {
+	matched, err := s.db.SetQuestionStatusSynced(t.upstreamID, t.expected, chainStatus)
+	if err != nil {
 		log.Warnw("status sync: give-up reconcile failed", "upstreamId", hexID, "error", err.Error())
 	}
+	if matched && chainStatus != t.expected {
+		s.notify(t.upstreamID)
+	}
+}
+
+// notify tells the notifier, if any, about the process of a question whose stored status changed.
+func (s *Syncer) notify(upstreamID internal.HexBytes) {
+	if s.notifier == nil {
+		return
+	}
+	q, err := s.db.QuestionByUpstreamID(upstreamID)
+	if err != nil {
+		log.Warnw("status sync: could not resolve question to notify", "upstreamId", upstreamID.String(), "error", err.Error())
+		return
+	}
+	s.notifier.Notify(q.ProcessID.Hex())
 }
 
 // retryOrDrop reschedules a confirm task with attempts left after a transient chain-read error;
@@ -361,6 +361,20 @@ func (p *Subscriptions) OrgAllowsVotingType(orgAddress common.Address, voteType
 	return nil
 }
 
+// OrgHasLiveResults reports whether the organization's plan shows the tallies of a voting process
+// live, before its results are final. For a managed org the integrator's plan decides.
+func (p *Subscriptions) OrgHasLiveResults(orgAddress common.Address) (bool, error) {
+	org, err := p.db.Organization(orgAddress)
+	if err != nil {
+		return false, errors.ErrOrganizationNotFound.WithErr(err)
+	}
+	_, plan, err := p.limitsOwner(org)
+	if err != nil {
+		return false, err
+	}
+	return plan.Features.LiveResults, nil
+}
+
 func (p *Subscriptions) OrgCanAddNMembers(orgAddress common.Address, memberNumber int) error {
 	org, err := p.db.Organization(orgAddress)
 	if err != nil {
@@ -590,6 +590,31 @@ func TestOrgAllowsVotingType(t *testing.T) {
 	c.Assert(subs.OrgAllowsVotingType(addr, "quadratic"), qt.ErrorIs, errors.ErrInvalidData) // unknown type name
 }
 
+func TestOrgHasLiveResults(t *testing.T) {
+	c := qt.New(t)
+	live, plain := common.Address{0x03}, common.Address{0x04}
+	mockDB := &mockMongoStorage{
+		plans: map[string]*db.Plan{
+			"live":  {ID: "live", Features: db.Features{LiveResults: true}},
+			"plain": {ID: "plain"},
+		},
+		orgs: map[string]*db.Organization{
+			live.String():  {Address: live, Subscription: db.OrganizationSubscription{PlanID: "live", Active: true}},
+			plain.String(): {Address: plain, Subscription: db.OrganizationSubscription{PlanID: "plain", Active: true}},
+		},
+	}
+	subs := &Subscriptions{db: mockDB}
+
+	ok, err := subs.OrgHasLiveResults(live)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok, qt.IsTrue)
+	ok, err = subs.OrgHasLiveResults(plain)
+	c.Assert(err, qt.IsNil)
+	c.Assert(ok, qt.IsFalse)
+	_, err = subs.OrgHasLiveResults(common.Address{0x05})
+	c.Assert(err, qt.ErrorIs, errors.ErrOrganizationNotFound)
+}
+
 // Mock implementation of the necessary db.MongoStorage methods for testing
 type mockMongoStorage struct {
 	plans map[string]*db.Plan
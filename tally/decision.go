@@ -0,0 +1,116 @@
+package tally
+
+import (
+	"math/big"
+
+	"github.com/vocdoni/saas-backend/db"
+)
+
+// Decide applies the decision rules of question to its results. The weight that voted is the sum
+// of the first field of the tally, where every ballot adds its weight to one cell, in a weighted
+// census or one whose participants may delegate their vote (a proxy's ballot carries the weight of
+// their delegators), and the vote count otherwise; eligible is the weight the quorum is a share of.
+// A majority is measured on the votes per choice of the question's outcome, out of the weight cast.
+//
+// It returns nil for a question without rules, for results not published yet, and when the rules
+// declare a quorum but the eligible weight is unknown (zero), since a decision against a wrong
+// denominator is worse than none.
+func Decide(
+	question *db.VotingProcessQuestion, results *db.QuestionResults, eligible *big.Int, weighted, delegated bool,
+) (*db.QuestionDecision, error) {
+	rules := question.Rules
+	if rules == nil || len(results.Results) == 0 {
+		return nil, nil
+	}
+	if rules.Quorum > 0 && (eligible == nil || eligible.Sign() == 0) {
+		return nil, nil
+	}
+	matrix, err := parseMatrix(results.Results)
+	if err != nil {
+		return nil, err
+	}
+	cast := new(big.Int)
+	if len(matrix) > 0 {
+		for _, weight := range matrix[0] {
+			cast.Add(cast, weight)
+		}
+	}
+	voted := cast
+	if !weighted && !delegated {
+		voted = new(big.Int).SetUint64(results.VoteCount)
+	}
+	if eligible == nil {
+		eligible = new(big.Int)
+	}
+	decision := &db.QuestionDecision{EligibleWeight: eligible.String(), VotedWeight: voted.String()}
+	passed := true
+	if rules.Quorum > 0 {
+		need := new(big.Rat).Mul(new(big.Rat).SetFloat64(rules.Quorum), new(big.Rat).SetInt(eligible))
+		reached := new(big.Rat).SetInt(voted).Cmp(need) >= 0
+		decision.QuorumReached = &reached
+		passed = passed && reached
+	}
+	if rules.Majority != "" {
+		outcome, err := Outcome(question, results.Results)
+		if err != nil {
+			return nil, err
+		}
+		met := false
+		if outcome != nil {
+			decision.Choice, met = majority(rules, question.Type, outcome, cast)
+		}
+		decision.ThresholdMet = &met
+		passed = passed && met
+	}
+	switch {
+	case !results.FinalResults:
+		decision.Decision = db.DecisionPending
+	case passed:
+		decision.Decision = db.DecisionPassed
+	default:
+		decision.Decision = db.DecisionFailed
+	}
+	return decision, nil
+}
+
+// majority returns the choice the majority of rules is measured on, the rules' own or else the
+// sole leader of outcome (none on a tie), and whether it holds out of the weight cast.
+func majority(rules *db.DecisionRules, qType string, outcome *db.TallyOutcome, cast *big.Int) (*uint32, bool) {
+	choice := rules.Choice
+	if choice == nil {
+		if len(outcome.Winners) != 1 {
+			return nil, false
+		}
+		choice = &outcome.Winners[0]
+	}
+	score, others := new(big.Int), []*big.Int{}
+	for _, s := range outcome.Scores {
+		n, _ := new(big.Int).SetString(s.Score, 10)
+		if s.Value == *choice {
+			score = n
+		} else {
+			others = append(others, n)
+		}
+	}
+	if score.Sign() == 0 {
+		return choice, false
+	}
+	switch rules.Majority {
+	case db.MajoritySimple:
+		if qType == db.VotingTypeMultiChoice {
+			// every choice is its own yes/no field: more selections than not
+			return choice, new(big.Int).Lsh(score, 1).Cmp(cast) > 0
+		}
+		for _, other := range others {
+			if score.Cmp(other) <= 0 {
+				return choice, false
+			}
+		}
+		return choice, true
+	case db.MajorityAbsolute:
+		return choice, new(big.Int).Lsh(score, 1).Cmp(cast) > 0
+	case db.MajorityTwoThirds:
+		return choice, new(big.Int).Mul(score, big.NewInt(3)).Cmp(new(big.Int).Lsh(cast, 1)) >= 0
+	}
+	return choice, false
+}
@@ -0,0 +1,116 @@
+package tally
+
+import (
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+)
+
+func TestDecide(t *testing.T) {
+	c := qt.New(t)
+	yes := uint32(0)
+	// a motion: yes, no, abstain
+	motion := func(rules db.DecisionRules) *db.VotingProcessQuestion {
+		q := question(db.VotingTypeSingleChoice, 0, 1, 2)
+		q.Rules = &rules
+		return q
+	}
+	final := func(voteCount uint64, row ...string) *db.QuestionResults {
+		return &db.QuestionResults{VoteCount: voteCount, FinalResults: true, Results: [][]string{row}}
+	}
+
+	c.Run("quorum against the eligible weight", func(c *qt.C) {
+		q := motion(db.DecisionRules{Quorum: 0.5})
+		d, err := Decide(q, final(5, "3", "1", "1"), big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d.VotedWeight, qt.Equals, "5")
+		c.Assert(*d.QuorumReached, qt.IsTrue)
+		c.Assert(d.ThresholdMet, qt.IsNil)
+		c.Assert(d.Decision, qt.Equals, db.DecisionPassed)
+
+		d, err = Decide(q, final(4, "3", "1", "0"), big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(*d.QuorumReached, qt.IsFalse)
+		c.Assert(d.Decision, qt.Equals, db.DecisionFailed)
+	})
+
+	c.Run("a weighted census reads the voted weight from the tally", func(c *qt.C) {
+		d, err := Decide(motion(db.DecisionRules{Quorum: 0.5}), final(2, "30", "20", "0"), big.NewInt(100), true, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d.VotedWeight, qt.Equals, "50")
+		c.Assert(*d.QuorumReached, qt.IsTrue)
+	})
+
+	c.Run("a census with proxies reads the voted weight from the tally", func(c *qt.C) {
+		// four participants voted, one of them a proxy for two more: six of ten are represented
+		q := motion(db.DecisionRules{Quorum: 0.6})
+		d, err := Decide(q, final(4, "4", "2", "0"), big.NewInt(10), false, true)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d.VotedWeight, qt.Equals, "6")
+		c.Assert(*d.QuorumReached, qt.IsTrue)
+		c.Assert(d.Decision, qt.Equals, db.DecisionPassed)
+
+		// counting the ballots instead would leave the quorum short
+		d, err = Decide(q, final(4, "4", "2", "0"), big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(*d.QuorumReached, qt.IsFalse)
+	})
+
+	c.Run("majorities of a motion", func(c *qt.C) {
+		// yes 6, no 4, abstain 2: a simple majority but not an absolute one
+		results := final(12, "6", "4", "2")
+		for majority, met := range map[string]bool{
+			db.MajoritySimple: true, db.MajorityAbsolute: false, db.MajorityTwoThirds: false,
+		} {
+			d, err := Decide(motion(db.DecisionRules{Majority: majority, Choice: &yes}), results, nil, false, false)
+			c.Assert(err, qt.IsNil)
+			c.Assert(*d.ThresholdMet, qt.Equals, met, qt.Commentf("%s", majority))
+			c.Assert(*d.Choice, qt.Equals, yes)
+		}
+		d, err := Decide(motion(db.DecisionRules{Majority: db.MajorityTwoThirds}), final(9, "6", "3", "0"), nil, false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(*d.ThresholdMet, qt.IsTrue)
+	})
+
+	c.Run("the leading choice, unless tied", func(c *qt.C) {
+		q := motion(db.DecisionRules{Majority: db.MajorityAbsolute})
+		d, err := Decide(q, final(10, "2", "7", "1"), nil, false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(*d.Choice, qt.Equals, uint32(1))
+		c.Assert(*d.ThresholdMet, qt.IsTrue)
+
+		d, err = Decide(q, final(10, "5", "5", "0"), nil, false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d.Choice, qt.IsNil)
+		c.Assert(*d.ThresholdMet, qt.IsFalse)
+	})
+
+	c.Run("a simple majority of a multichoice choice is more selections than not", func(c *qt.C) {
+		q := question(db.VotingTypeMultiChoice, 0, 1)
+		q.Rules = &db.DecisionRules{Majority: db.MajoritySimple, Choice: &yes}
+		d, err := Decide(q, &db.QuestionResults{FinalResults: true, Results: [][]string{{"4", "6"}, {"1", "9"}}}, nil, false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(*d.ThresholdMet, qt.IsTrue)
+	})
+
+	c.Run("pending until final, none without rules, a tally or a denominator", func(c *qt.C) {
+		q := motion(db.DecisionRules{Quorum: 0.1, Majority: db.MajoritySimple})
+		live := final(3, "3", "0", "0")
+		live.FinalResults = false
+		d, err := Decide(q, live, big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d.Decision, qt.Equals, db.DecisionPending)
+
+		d, err = Decide(q, &db.QuestionResults{VoteCount: 3}, big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d, qt.IsNil)
+		d, err = Decide(q, live, new(big.Int), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d, qt.IsNil)
+		d, err = Decide(question(db.VotingTypeSingleChoice, 0, 1), live, big.NewInt(10), false, false)
+		c.Assert(err, qt.IsNil)
+		c.Assert(d, qt.IsNil)
+	})
+}
@@ -0,0 +1,264 @@
+// Package tally counts the results of a voting process question by the rules of its ballot type.
+// The on-chain tally is a raw matrix, one row per ballot field, whose meaning depends on how the
+// ballot encodes a vote (see account.BallotProtocolFromType); this package turns it into scores
+// per choice and winners. Instant-runoff cannot be counted from the matrix, so it reads the
+// individual ballots instead.
+package tally
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/vocdoni/saas-backend/account"
+	"github.com/vocdoni/saas-backend/db"
+)
+
+// Ballot is one vote cast on a question: the value of each ballot field, as in the vote package,
+// and the weight it was cast with. A nil Weight counts as 1.
+type Ballot struct {
+	Values []int
+	Weight *big.Int
+}
+
+// Outcome counts the raw on-chain results of question by the rules of its ballot type. It returns
+// nil for a question whose ballot protocol has no named type, which has no counting rule, and for
+// results not published yet.
+//
+// The matrix layout follows the ballot protocol of each type:
+//   - singlechoice has one field, whose row is indexed by the chosen Choice.Value;
+//   - multichoice has one field per choice, each row [not selected, selected];
+//   - ranked has one field per choice, each row indexed by the rank the choice got, the most
+//     preferred choice having the highest rank, so its Borda points are the sum of those ranks;
+//   - cumulative has one field per choice, whose values the chain adds up in the first column.
+func Outcome(question *db.VotingProcessQuestion, results [][]string) (*db.TallyOutcome, error) {
+	if len(results) == 0 || len(question.Choices) == 0 {
+		return nil, nil
+	}
+	qType := account.EffectiveQuestionType(question)
+	method, ok := methods[qType]
+	if !ok {
+		return nil, nil
+	}
+	matrix, err := parseMatrix(results)
+	if err != nil {
+		return nil, err
+	}
+	scores := make([]*big.Int, len(question.Choices))
+	for i, choice := range question.Choices {
+		switch qType {
+		case db.VotingTypeSingleChoice:
+			scores[i] = cell(matrix, 0, int(choice.Value))
+		case db.VotingTypeMultiChoice:
+			scores[i] = cell(matrix, i, 1)
+		case db.VotingTypeRanked:
+			points := new(big.Int)
+			if i < len(matrix) {
+				for rank, weight := range matrix[i] {
+					points.Add(points, new(big.Int).Mul(big.NewInt(int64(rank)), weight))
+				}
+			}
+			scores[i] = points
+		case db.VotingTypeCumulative:
+			scores[i] = cell(matrix, i, 0)
+		}
+	}
+	return &db.TallyOutcome{
+		Method:  method,
+		Scores:  choiceScores(question.Choices, scores, nil),
+		Winners: leaders(question.Choices, scores, nil),
+	}, nil
+}
+
+// methods maps each named ballot type to the method its outcome is counted by.
+var methods = map[string]string{
+	db.VotingTypeSingleChoice: db.TallyMethodPlurality,
+	db.VotingTypeMultiChoice:  db.TallyMethodApproval,
+	db.VotingTypeRanked:       db.TallyMethodBorda,
+	db.VotingTypeCumulative:   db.TallyMethodPoints,
+}
+
+// InstantRunoff counts the ballots of a ranked question by instant-runoff. Every round gives each
+// ballot to its most preferred choice still running; a choice holding more than half of the ballots
+// not exhausted wins, and otherwise the choices with the fewest votes are eliminated together. When
+// every choice left is tied they all win, since eliminating them all would leave nobody. A ballot
+// ranks the choices by the value of their field, highest first, and a field it leaves out ranks its
+// choice nowhere.
+func InstantRunoff(question *db.VotingProcessQuestion, ballots []Ballot) (*db.InstantRunoff, error) {
+	if account.EffectiveQuestionType(question) != db.VotingTypeRanked {
+		return nil, fmt.Errorf("instant-runoff applies to ranked questions only")
+	}
+	running := make([]bool, len(question.Choices))
+	for i := range running {
+		running[i] = true
+	}
+	runoff := &db.InstantRunoff{Ballots: uint64(len(ballots))}
+	for {
+		votes := make([]*big.Int, len(question.Choices))
+		for i := range votes {
+			if running[i] {
+				votes[i] = new(big.Int)
+			}
+		}
+		exhausted, active := new(big.Int), new(big.Int)
+		for _, b := range ballots {
+			weight := b.Weight
+			if weight == nil {
+				weight = big.NewInt(1)
+			}
+			if top := preferred(b.Values, running); top >= 0 {
+				votes[top].Add(votes[top], weight)
+				active.Add(active, weight)
+			} else {
+				exhausted.Add(exhausted, weight)
+			}
+		}
+		round := db.RunoffRound{Votes: choiceScores(question.Choices, votes, running), Exhausted: exhausted.String()}
+		if active.Sign() == 0 {
+			runoff.Rounds = append(runoff.Rounds, round)
+			runoff.Winners = []uint32{}
+			return runoff, nil
+		}
+		top, bottom := extremes(votes, running)
+		if majority := new(big.Int).Mul(votes[top], big.NewInt(2)); majority.Cmp(active) > 0 {
+			runoff.Rounds = append(runoff.Rounds, round)
+			runoff.Winners = []uint32{question.Choices[top].Value}
+			return runoff, nil
+		}
+		if votes[top].Cmp(votes[bottom]) == 0 {
+			runoff.Rounds = append(runoff.Rounds, round)
+			runoff.Winners = leaders(question.Choices, votes, running)
+			return runoff, nil
+		}
+		for i, v := range votes {
+			if running[i] && v.Cmp(votes[bottom]) == 0 {
+				running[i] = false
+				round.Eliminated = append(round.Eliminated, question.Choices[i].Value)
+			}
+		}
+		runoff.Rounds = append(runoff.Rounds, round)
+	}
+}
+
+// preferred returns the index of the choice still running that values ranks highest, or -1 when
+// it ranks none of them.
+func preferred(values []int, running []bool) int {
+	top := -1
+	for i, v := range values {
+		if i < len(running) && running[i] && (top < 0 || v > values[top]) {
+			top = i
+		}
+	}
+	return top
+}
+
+// extremes returns the indexes of a running choice with the most votes and one with the fewest.
+func extremes(votes []*big.Int, running []bool) (top, bottom int) {
+	top, bottom = -1, -1
+	for i, v := range votes {
+		if !running[i] {
+			continue
+		}
+		if top < 0 || v.Cmp(votes[top]) > 0 {
+			top = i
+		}
+		if bottom < 0 || v.Cmp(votes[bottom]) < 0 {
+			bottom = i
+		}
+	}
+	return top, bottom
+}
+
+// choiceScores lists the score of every choice, or only of those included when it is not nil.
+func choiceScores(choices []db.Choice, scores []*big.Int, included []bool) []db.ChoiceScore {
+	out := make([]db.ChoiceScore, 0, len(choices))
+	for i, choice := range choices {
+		if included == nil || included[i] {
+			out = append(out, db.ChoiceScore{Value: choice.Value, Score: scores[i].String()})
+		}
+	}
+	return out
+}
+
+// leaders returns the values of the choices, among those included when it is not nil, that share
+// the highest score, or none when that score is zero.
+func leaders(choices []db.Choice, scores []*big.Int, included []bool) []uint32 {
+	best := new(big.Int)
+	for i, s := range scores {
+		if (included == nil || included[i]) && s.Cmp(best) > 0 {
+			best = s
+		}
+	}
+	winners := []uint32{}
+	if best.Sign() == 0 {
+		return winners
+	}
+	for i, s := range scores {
+		if (included == nil || included[i]) && s.Cmp(best) == 0 {
+			winners = append(winners, choices[i].Value)
+		}
+	}
+	return winners
+}
+
+// cell returns matrix[row][col], or zero when the matrix does not reach it.
+func cell(matrix [][]*big.Int, row, col int) *big.Int {
+	if row < 0 || row >= len(matrix) || col < 0 || col >= len(matrix[row]) {
+		return new(big.Int)
+	}
+	return new(big.Int).Set(matrix[row][col])
+}
+
+// parseMatrix parses the stringified big integers of a results matrix.
+func parseMatrix(results [][]string) ([][]*big.Int, error) {
+	matrix := make([][]*big.Int, len(results))
+	for i, row := range results {
+		matrix[i] = make([]*big.Int, len(row))
+		for j, v := range row {
+			n, ok := new(big.Int).SetString(v, 10)
+			if !ok {
+				return nil, fmt.Errorf("invalid result %q at field %d, value %d", v, i, j)
+			}
+			matrix[i][j] = n
+		}
+	}
+	return matrix, nil
+}
+
+// Top returns the values of the n choices of outcome with the highest scores, best first, for a
+// run-off between them. Choices tied on the score of the last one taken are all taken, so the
+// result may hold more than n; choices without any score are never taken. Equal scores keep the
+// order of the choices.
+func Top(outcome *db.TallyOutcome, n int) ([]uint32, error) {
+	type scored struct {
+		value uint32
+		score *big.Int
+	}
+	if n < 1 {
+		return []uint32{}, nil
+	}
+	candidates := make([]scored, 0, len(outcome.Scores))
+	for _, s := range outcome.Scores {
+		score, ok := new(big.Int).SetString(s.Score, 10)
+		if !ok {
+			return nil, fmt.Errorf("invalid score %q of choice %d", s.Score, s.Value)
+		}
+		if score.Sign() > 0 {
+			candidates = append(candidates, scored{value: s.Value, score: score})
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score.Cmp(candidates[j].score) > 0 })
+	top := []uint32{}
+	for i, c := range candidates {
+		if i >= n && c.score.Cmp(candidates[n-1].score) != 0 {
+			break
+		}
+		top = append(top, c.value)
+	}
+	return top, nil
+}
+
+// Ranked reports whether question is counted by instant-runoff too, and so needs its ballots.
+func Ranked(question *db.VotingProcessQuestion) bool {
+	return len(question.Choices) > 0 && account.EffectiveQuestionType(question) == db.VotingTypeRanked
+}
@@ -0,0 +1,160 @@
+package tally
+
+import (
+	"math/big"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+	"github.com/vocdoni/saas-backend/db"
+)
+
+func question(qType string, values ...uint32) *db.VotingProcessQuestion {
+	q := &db.VotingProcessQuestion{Type: qType, TypeSetup: db.QuestionTypeSetup{Budget: 10, CostExponent: 1}}
+	for _, v := range values {
+		q.Choices = append(q.Choices, db.Choice{Value: v})
+	}
+	return q
+}
+
+func scores(values []uint32, points ...string) []db.ChoiceScore {
+	out := make([]db.ChoiceScore, len(values))
+	for i := range values {
+		out[i] = db.ChoiceScore{Value: values[i], Score: points[i]}
+	}
+	return out
+}
+
+func TestOutcome(t *testing.T) {
+	c := qt.New(t)
+
+	c.Run("plurality over sparse choice values", func(c *qt.C) {
+		out, err := Outcome(question(db.VotingTypeSingleChoice, 0, 5), [][]string{{"3", "0", "0", "0", "0", "7"}})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out.Method, qt.Equals, db.TallyMethodPlurality)
+		c.Assert(out.Scores, qt.DeepEquals, scores([]uint32{0, 5}, "3", "7"))
+		c.Assert(out.Winners, qt.DeepEquals, []uint32{5})
+	})
+
+	c.Run("approval counts the selections", func(c *qt.C) {
+		out, err := Outcome(question(db.VotingTypeMultiChoice, 0, 1, 2), [][]string{{"1", "4"}, {"0", "5"}, {"5", "0"}})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out.Method, qt.Equals, db.TallyMethodApproval)
+		c.Assert(out.Scores, qt.DeepEquals, scores([]uint32{0, 1, 2}, "4", "5", "0"))
+		c.Assert(out.Winners, qt.DeepEquals, []uint32{1})
+	})
+
+	c.Run("borda adds up the ranks", func(c *qt.C) {
+		// three voters: A>B>C, A>C>B, B>C>A, the top rank being the highest value
+		out, err := Outcome(question(db.VotingTypeRanked, 0, 1, 2), [][]string{
+			{"1", "0", "2"}, {"1", "1", "1"}, {"1", "2", "0"},
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out.Method, qt.Equals, db.TallyMethodBorda)
+		c.Assert(out.Scores, qt.DeepEquals, scores([]uint32{0, 1, 2}, "4", "3", "2"))
+		c.Assert(out.Winners, qt.DeepEquals, []uint32{0})
+	})
+
+	c.Run("points read the aggregated column and report ties", func(c *qt.C) {
+		out, err := Outcome(question(db.VotingTypeCumulative, 0, 1), [][]string{{"12"}, {"12"}})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out.Method, qt.Equals, db.TallyMethodPoints)
+		c.Assert(out.Winners, qt.DeepEquals, []uint32{0, 1})
+	})
+
+	c.Run("no outcome without results or a named type", func(c *qt.C) {
+		out, err := Outcome(question(db.VotingTypeSingleChoice, 0, 1), nil)
+		c.Assert(err, qt.IsNil)
+		c.Assert(out, qt.IsNil)
+		raw := question("", 0, 1)
+		raw.BallotProtocol = &db.BallotProtocol{MaxCount: 2, MaxValue: 3, MaxVoteOverwrites: 2}
+		out, err = Outcome(raw, [][]string{{"1"}})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out, qt.IsNil)
+	})
+
+	c.Run("no winner before any vote", func(c *qt.C) {
+		out, err := Outcome(question(db.VotingTypeSingleChoice, 0, 1), [][]string{{"0", "0"}})
+		c.Assert(err, qt.IsNil)
+		c.Assert(out.Winners, qt.HasLen, 0)
+	})
+
+	c.Run("a malformed result is an error", func(c *qt.C) {
+		_, err := Outcome(question(db.VotingTypeSingleChoice, 0, 1), [][]string{{"x", "0"}})
+		c.Assert(err, qt.ErrorMatches, `invalid result "x".*`)
+	})
+}
+
+func TestInstantRunoff(t *testing.T) {
+	c := qt.New(t)
+	ranked := question(db.VotingTypeRanked, 10, 20, 30)
+	ballot := func(weight int64, values ...int) Ballot {
+		return Ballot{Values: values, Weight: big.NewInt(weight)}
+	}
+
+	c.Run("eliminates the last choice until a majority", func(c *qt.C) {
+		// first preferences 10: 4, 20: 3, 30: 2; 30 goes and its ballots move to 20
+		runoff, err := InstantRunoff(ranked, []Ballot{
+			ballot(4, 2, 1, 0), ballot(3, 0, 2, 1), ballot(2, 0, 1, 2),
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(runoff.Ballots, qt.Equals, uint64(3))
+		c.Assert(runoff.Rounds, qt.HasLen, 2)
+		c.Assert(runoff.Rounds[0].Votes, qt.DeepEquals, scores([]uint32{10, 20, 30}, "4", "3", "2"))
+		c.Assert(runoff.Rounds[0].Eliminated, qt.DeepEquals, []uint32{30})
+		c.Assert(runoff.Rounds[1].Votes, qt.DeepEquals, scores([]uint32{10, 20}, "4", "5"))
+		c.Assert(runoff.Winners, qt.DeepEquals, []uint32{20})
+	})
+
+	c.Run("a partial ballot is exhausted once its choices are gone", func(c *qt.C) {
+		// the first ballot ranks only 10, its first field, and drops out with it
+		runoff, err := InstantRunoff(ranked, []Ballot{
+			ballot(1, 2), ballot(3, 0, 2, 1), ballot(3, 0, 1, 2), ballot(1, 1, 0, 2),
+		})
+		c.Assert(err, qt.IsNil)
+		c.Assert(runoff.Rounds[0].Eliminated, qt.DeepEquals, []uint32{10})
+		c.Assert(runoff.Rounds[1].Exhausted, qt.Equals, "1")
+		c.Assert(runoff.Rounds[1].Votes, qt.DeepEquals, scores([]uint32{20, 30}, "3", "4"))
+		c.Assert(runoff.Winners, qt.DeepEquals, []uint32{30})
+	})
+
+	c.Run("a tie among every choice left is shared", func(c *qt.C) {
+		runoff, err := InstantRunoff(ranked, []Ballot{ballot(1, 2, 1, 0), ballot(1, 0, 2, 1)})
+		c.Assert(err, qt.IsNil)
+		c.Assert(runoff.Rounds[0].Eliminated, qt.DeepEquals, []uint32{30})
+		c.Assert(runoff.Winners, qt.DeepEquals, []uint32{10, 20})
+	})
+
+	c.Run("no ballots, no winner", func(c *qt.C) {
+		runoff, err := InstantRunoff(ranked, nil)
+		c.Assert(err, qt.IsNil)
+		c.Assert(runoff.Rounds, qt.HasLen, 1)
+		c.Assert(runoff.Winners, qt.HasLen, 0)
+	})
+
+	c.Run("only ranked questions", func(c *qt.C) {
+		_, err := InstantRunoff(question(db.VotingTypeSingleChoice, 0, 1), nil)
+		c.Assert(err, qt.IsNotNil)
+	})
+}
+
+func TestTop(t *testing.T) {
+	c := qt.New(t)
+	outcome := &db.TallyOutcome{Scores: scores([]uint32{0, 1, 2, 3, 4}, "5", "9", "5", "0", "2")}
+
+	top, err := Top(outcome, 1)
+	c.Assert(err, qt.IsNil)
+	c.Assert(top, qt.DeepEquals, []uint32{1})
+
+	// a tie at the cutoff takes every tied choice, in the order of the choices
+	top, err = Top(outcome, 2)
+	c.Assert(err, qt.IsNil)
+	c.Assert(top, qt.DeepEquals, []uint32{1, 0, 2})
+
+	// choices without votes are left out
+	top, err = Top(outcome, 5)
+	c.Assert(err, qt.IsNil)
+	c.Assert(top, qt.DeepEquals, []uint32{1, 0, 2, 4})
+
+	_, err = Top(&db.TallyOutcome{Scores: scores([]uint32{0}, "x")}, 1)
+	c.Assert(err, qt.IsNotNil)
+}